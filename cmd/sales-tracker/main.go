@@ -3,6 +3,7 @@ package main
 import (
 	"context"
 	"errors"
+	"maps"
 	"os/signal"
 	"syscall"
 	"time"
@@ -11,18 +12,66 @@ import (
 	"github.com/wb-go/wbf/dbpg"
 	"github.com/wb-go/wbf/zlog"
 
+	"github.com/aliskhannn/sales-tracker/internal/api/handler/account"
 	"github.com/aliskhannn/sales-tracker/internal/api/handler/analytics"
+	"github.com/aliskhannn/sales-tracker/internal/api/handler/apikey"
+	"github.com/aliskhannn/sales-tracker/internal/api/handler/attachment"
 	"github.com/aliskhannn/sales-tracker/internal/api/handler/category"
 	"github.com/aliskhannn/sales-tracker/internal/api/handler/item"
+	"github.com/aliskhannn/sales-tracker/internal/api/handler/job"
+	"github.com/aliskhannn/sales-tracker/internal/api/handler/reconciliation"
+	"github.com/aliskhannn/sales-tracker/internal/api/handler/recurring"
+	"github.com/aliskhannn/sales-tracker/internal/api/handler/rule"
+	"github.com/aliskhannn/sales-tracker/internal/api/handler/schedule"
+	"github.com/aliskhannn/sales-tracker/internal/api/handler/stream"
+	"github.com/aliskhannn/sales-tracker/internal/api/handler/tag"
+	"github.com/aliskhannn/sales-tracker/internal/api/handler/webhook"
+	"github.com/aliskhannn/sales-tracker/internal/api/handler/workspace"
+	"github.com/aliskhannn/sales-tracker/internal/api/middleware"
 	"github.com/aliskhannn/sales-tracker/internal/api/router"
 	"github.com/aliskhannn/sales-tracker/internal/api/server"
+	"github.com/aliskhannn/sales-tracker/internal/auth"
+	"github.com/aliskhannn/sales-tracker/internal/cache"
 	"github.com/aliskhannn/sales-tracker/internal/config"
+	"github.com/aliskhannn/sales-tracker/internal/database"
+	"github.com/aliskhannn/sales-tracker/internal/logging"
+	"github.com/aliskhannn/sales-tracker/internal/mail"
+	"github.com/aliskhannn/sales-tracker/internal/metrics"
+	"github.com/aliskhannn/sales-tracker/internal/ratelimit"
+	repoaccount "github.com/aliskhannn/sales-tracker/internal/repository/account"
 	repoanalytics "github.com/aliskhannn/sales-tracker/internal/repository/analytics"
+	repoapikey "github.com/aliskhannn/sales-tracker/internal/repository/apikey"
+	repoattachment "github.com/aliskhannn/sales-tracker/internal/repository/attachment"
 	repocategory "github.com/aliskhannn/sales-tracker/internal/repository/category"
 	repoitem "github.com/aliskhannn/sales-tracker/internal/repository/item"
+	repojob "github.com/aliskhannn/sales-tracker/internal/repository/job"
+	reporeconciliation "github.com/aliskhannn/sales-tracker/internal/repository/reconciliation"
+	reporecurring "github.com/aliskhannn/sales-tracker/internal/repository/recurring"
+	reporule "github.com/aliskhannn/sales-tracker/internal/repository/rule"
+	reposchedule "github.com/aliskhannn/sales-tracker/internal/repository/schedule"
+	repostream "github.com/aliskhannn/sales-tracker/internal/repository/stream"
+	repotag "github.com/aliskhannn/sales-tracker/internal/repository/tag"
+	repowebhook "github.com/aliskhannn/sales-tracker/internal/repository/webhook"
+	repoworkspace "github.com/aliskhannn/sales-tracker/internal/repository/workspace"
+	srvcaccount "github.com/aliskhannn/sales-tracker/internal/service/account"
 	srvcanalytics "github.com/aliskhannn/sales-tracker/internal/service/analytics"
+	srvcapikey "github.com/aliskhannn/sales-tracker/internal/service/apikey"
+	srvcattachment "github.com/aliskhannn/sales-tracker/internal/service/attachment"
 	srvccategory "github.com/aliskhannn/sales-tracker/internal/service/category"
 	srvcitem "github.com/aliskhannn/sales-tracker/internal/service/item"
+	srvcjob "github.com/aliskhannn/sales-tracker/internal/service/job"
+	srvcreconciliation "github.com/aliskhannn/sales-tracker/internal/service/reconciliation"
+	srvcrecurring "github.com/aliskhannn/sales-tracker/internal/service/recurring"
+	srvcrule "github.com/aliskhannn/sales-tracker/internal/service/rule"
+	srvcschedule "github.com/aliskhannn/sales-tracker/internal/service/schedule"
+	srvcstream "github.com/aliskhannn/sales-tracker/internal/service/stream"
+	srvctag "github.com/aliskhannn/sales-tracker/internal/service/tag"
+	srvcwebhook "github.com/aliskhannn/sales-tracker/internal/service/webhook"
+	srvcworkspace "github.com/aliskhannn/sales-tracker/internal/service/workspace"
+	"github.com/aliskhannn/sales-tracker/internal/storage"
+	"github.com/aliskhannn/sales-tracker/internal/tenant"
+	"github.com/aliskhannn/sales-tracker/internal/tracing"
+	"github.com/aliskhannn/sales-tracker/internal/validation"
 )
 
 func main() {
@@ -30,6 +79,30 @@ func main() {
 	zlog.Init()
 	cfg := config.MustLoad()
 	val := validator.New()
+	if err := validation.Register(val, validation.Options{
+		MaxFuture:       cfg.Validation.MaxFuture,
+		MaxMetadataSize: cfg.Validation.MaxMetadataSize,
+	}); err != nil {
+		zlog.Logger.Fatal().Err(err).Msg("failed to register validations")
+	}
+
+	// Start exporting traces; log lines of traced requests carry their trace ID.
+	shutdownTracing := func(context.Context) error { return nil }
+	if cfg.Tracing.Enabled {
+		var err error
+		shutdownTracing, err = tracing.Init(tracing.Options{
+			Exporter:    cfg.Tracing.Exporter,
+			Endpoint:    cfg.Tracing.Endpoint,
+			ServiceName: cfg.Tracing.ServiceName,
+			SampleRatio: cfg.Tracing.SampleRatio,
+			Timeout:     cfg.Tracing.Timeout,
+		})
+		if err != nil {
+			zlog.Logger.Fatal().Err(err).Msg("failed to initialize tracing")
+		}
+
+		zlog.Logger = zlog.Logger.Hook(tracing.LogHook{})
+	}
 
 	// Connect to PostgreSQL master and slave databases.
 	opts := &dbpg.Options{
@@ -44,30 +117,265 @@ func main() {
 		slaveDNSs = append(slaveDNSs, s.DSN())
 	}
 
-	db, err := dbpg.New(cfg.Database.Master.DSN(), slaveDNSs, opts)
+	// With row-level security every connection carries the workspace of the
+	// request; with tracing every query is recorded as a span.
+	var wrappers []database.Wrapper
+	if cfg.Workspaces.RowLevelSecurity {
+		wrappers = append(wrappers, tenant.Connector)
+	}
+	if cfg.Tracing.Enabled {
+		wrappers = append(wrappers, tracing.Connector)
+	}
+
+	pools, err := database.Open(cfg.Database.Master.DSN(), slaveDNSs, opts, wrappers...)
 	if err != nil {
 		zlog.Logger.Fatal().Err(err).Msg("failed to connect to database")
 	}
 
+	// Expose connection pool statistics of the master and slaves on /metrics.
+	metrics.RegisterDB(pools)
+
+	// Route reads to healthy slaves that keep up with the master.
+	db, err := database.NewDB(pools, database.RoutingOptions{
+		Strategy:            cfg.Database.Routing.Strategy,
+		HealthCheckInterval: cfg.Database.Routing.HealthCheckInterval,
+		HealthCheckTimeout:  cfg.Database.Routing.HealthCheckTimeout,
+		MaxLag:              cfg.Database.Routing.MaxLag,
+	})
+	if err != nil {
+		zlog.Logger.Fatal().Err(err).Str("strategy", cfg.Database.Routing.Strategy).Msg("failed to configure read routing")
+	}
+
+	// Initialize workspace repository, service, and handler for workspace endpoints.
+	workspaceRepo := repoworkspace.NewRepository(db)
+	workspaceService := srvcworkspace.NewService(workspaceRepo)
+	workspaceHandler := workspace.NewHandler(workspaceService, val)
+
 	// Initialize category repository, service, and handler for category endpoints.
 	categoryRepo := repocategory.NewRepository(db)
 	categoryService := srvccategory.NewService(categoryRepo)
 	categoryHandler := category.NewHandler(categoryService, val)
 
-	// Initialize item repository, service, and handler for item endpoints.
+	// Initialize account repository, service, and handler for account endpoints.
+	accountRepo := repoaccount.NewRepository(db)
+	accountService := srvcaccount.NewService(accountRepo)
+	accountHandler := account.NewHandler(accountService, val)
+
+	// Initialize rule repository, service, and handler for auto-categorization rule endpoints.
 	itemRepo := repoitem.NewRepository(db)
-	itemService := srvcitem.NewService(itemRepo)
-	itemHandler := item.NewHandler(itemService, val)
+	ruleRepo := reporule.NewRepository(db)
+	ruleService := srvcrule.NewService(ruleRepo, itemRepo)
+	ruleHandler := rule.NewHandler(ruleService, val)
+
+	// Initialize attachment storage, shared by item deletion and attachment endpoints.
+	attachmentStorage, err := storage.NewLocal(cfg.Attachments.Dir)
+	if err != nil {
+		zlog.Logger.Fatal().Err(err).Msg("failed to initialize attachment storage")
+	}
+
+	// Initialize item service and handler for item endpoints; new items pass through
+	// rules and deleted items take their attachments with them.
+	itemService := srvcitem.NewService(itemRepo, accountRepo, ruleService, attachmentStorage)
+	itemHandler := item.NewHandler(itemService, val, cfg)
+
+	// Initialize tag repository, service, and handler for tag endpoints.
+	tagRepo := repotag.NewRepository(db)
+	tagService := srvctag.NewService(tagRepo, itemRepo)
+	tagHandler := tag.NewHandler(tagService, val)
+
+	// Initialize attachment repository, service, and handler for item attachment endpoints.
+	attachmentRepo := repoattachment.NewRepository(db)
+	attachmentService := srvcattachment.NewService(attachmentRepo, itemRepo, attachmentStorage, cfg.Attachments.MaxSize, cfg.Attachments.AllowedTypes)
+	attachmentHandler := attachment.NewHandler(attachmentService, cfg)
 
 	// Initialize analytics repository, service, and handler for analytics endpoints.
 	analyticsRepo := repoanalytics.NewRepository(db)
 	analyticsService := srvcanalytics.NewService(analyticsRepo)
 	analyticsHandler := analytics.NewHandler(analyticsService, cfg)
 
+	// Cache analytics results in memory; reads from replicas may lag behind
+	// changes by up to the max lag, so invalidations are repeated after it.
+	var analyticsCache *srvcanalytics.CachedService
+	if cfg.Analytics.Cache.Enabled {
+		var settle time.Duration
+		if len(pools.Slaves) > 0 {
+			settle = cfg.Database.Routing.MaxLag
+		}
+
+		analyticsCache = srvcanalytics.NewCachedService(analyticsService, cache.NewLRU(cfg.Analytics.Cache.MaxEntries), srvcanalytics.CacheOptions{
+			TTL:    cfg.Analytics.Cache.TTL,
+			DSN:    cfg.Database.Master.DSN(),
+			Settle: settle,
+		})
+		analyticsHandler = analytics.NewHandler(analyticsCache, cfg)
+	}
+
+	// Initialize analytics job repository, service, and handler for long-running
+	// reports; jobs bypass the cache, whose entries they would mostly evict.
+	jobRepo := repojob.NewRepository(db)
+	jobService := srvcjob.NewService(jobRepo, analyticsService, srvcjob.Options{
+		Workers:      cfg.Jobs.Workers,
+		PollInterval: cfg.Jobs.PollInterval,
+		Heartbeat:    cfg.Jobs.Heartbeat,
+		Lease:        cfg.Jobs.Lease,
+		Timeout:      cfg.Jobs.Timeout,
+		Retention:    cfg.Jobs.Retention,
+		MaxAttempts:  cfg.Jobs.MaxAttempts,
+	})
+	jobHandler := job.NewHandler(jobService, val, cfg)
+
+	// Initialize scheduled report repository, service, and handler; the service
+	// also emails due reports through the SMTP server.
+	mailer, err := mail.NewSMTP(mail.SMTPOptions{
+		Host:     cfg.SMTP.Host,
+		Port:     cfg.SMTP.Port,
+		Username: cfg.SMTP.Username,
+		Password: cfg.SMTP.Password,
+		TLS:      cfg.SMTP.TLS,
+		Timeout:  cfg.SMTP.Timeout,
+	})
+	if err != nil {
+		zlog.Logger.Fatal().Err(err).Msg("failed to initialize smtp")
+	}
+
+	scheduleRepo := reposchedule.NewRepository(db)
+	scheduleService := srvcschedule.NewService(scheduleRepo, analyticsService, categoryService, mailer, srvcschedule.Options{
+		PollInterval: cfg.Reports.PollInterval,
+		Lease:        cfg.Reports.Lease,
+		Timeout:      cfg.Reports.Timeout,
+		MaxAttempts:  cfg.Reports.MaxAttempts,
+		RetryDelay:   cfg.Reports.RetryDelay,
+		From:         cfg.SMTP.From,
+	})
+	scheduleHandler := schedule.NewHandler(scheduleService, val, cfg)
+
+	// Initialize stream repository, service, and handler for the live item change and totals streams.
+	streamRepo := repostream.NewRepository(db)
+	streamService := srvcstream.NewService(streamRepo, analyticsRepo, cfg.Database.Master.DSN(), cfg.Stream.Retention)
+	streamHandler := stream.NewHandler(streamService, cfg)
+
+	// Initialize recurring item repository, service, and handler for recurring item endpoints.
+	recurringRepo := reporecurring.NewRepository(db)
+	recurringService := srvcrecurring.NewService(recurringRepo, itemService, workspaceService)
+	recurringHandler := recurring.NewHandler(recurringService, val)
+
+	// Initialize reconciliation repository, service, and handler for bank statement reconciliation endpoints.
+	reconciliationRepo := reporeconciliation.NewRepository(db)
+	reconciliationService := srvcreconciliation.NewService(reconciliationRepo, accountRepo, itemService, cfg.Reconciliation.DateWindow)
+	reconciliationHandler := reconciliation.NewHandler(reconciliationService, cfg)
+
+	// Initialize webhook repository, service, and handler for webhook endpoints; the
+	// service also delivers the events item and category changes write to the outbox.
+	webhookRepo := repowebhook.NewRepository(db)
+	webhookService := srvcwebhook.NewService(webhookRepo, srvcwebhook.Options{
+		PollInterval: cfg.Webhooks.PollInterval,
+		BatchSize:    cfg.Webhooks.BatchSize,
+		Timeout:      cfg.Webhooks.Timeout,
+		MaxAttempts:  cfg.Webhooks.MaxAttempts,
+		BackoffBase:  cfg.Webhooks.BackoffBase,
+		BackoffMax:   cfg.Webhooks.BackoffMax,
+
+		AllowPrivateTargets: cfg.Webhooks.AllowPrivateTargets,
+	})
+	webhookHandler := webhook.NewHandler(webhookService, val)
+
+	// Initialize API key repository, service, and handler, and the authentication middleware.
+	apiKeyRepo := repoapikey.NewRepository(db)
+	apiKeyService := srvcapikey.NewService(apiKeyRepo)
+	apiKeyHandler := apikey.NewHandler(apiKeyService, val)
+
+	if !cfg.Auth.Enabled {
+		zlog.Logger.Warn().Msg("authentication is disabled, every request is treated as an admin")
+	} else if cfg.Auth.JWTSecret == "" {
+		zlog.Logger.Warn().Msg("JWT_SECRET is not set, only API keys are accepted")
+	}
+
+	jwt := auth.NewJWT(cfg.Auth.JWTSecret, cfg.Auth.JWTIssuer, cfg.Auth.JWTAudience, cfg.Auth.JWTLeeway)
+	authenticate := middleware.Authenticate(apiKeyService, jwt, cfg.Auth.Enabled)
+	resolveWorkspace := middleware.Workspace(workspaceService, cfg.Workspaces.Default)
+
+	// Initialize per-client rate limits of the API route groups.
+	var limits middleware.RateLimits
+	if cfg.RateLimit.Enabled {
+		limits.Default = ratelimit.Every(cfg.RateLimit.Default.Requests, cfg.RateLimit.Default.Period, cfg.RateLimit.Default.Burst)
+		limits.Groups = make(map[string]ratelimit.Limit, len(cfg.RateLimit.Groups))
+		for group, l := range cfg.RateLimit.Groups {
+			limits.Groups[group] = ratelimit.Every(l.Requests, l.Period, l.Burst)
+		}
+	}
+
+	rateLimit := middleware.RateLimit(ratelimit.NewMemoryStore(), limits)
+
+	// Initialize the statement timeouts of analytics reads; a read outliving the
+	// write timeout would run on after its response was cut off.
+	timeouts := middleware.QueryTimeouts{Default: cfg.QueryTimeout.Default, Endpoints: cfg.QueryTimeout.Endpoints}
+	routes := map[string]time.Duration{"default": cfg.QueryTimeout.Default}
+	maps.Copy(routes, cfg.QueryTimeout.Endpoints)
+	for route, d := range routes {
+		if cfg.Server.WriteTimeout > 0 && d >= cfg.Server.WriteTimeout {
+			zlog.Logger.Warn().Str("route", route).Dur("timeout", d).Msg("query timeout is not below the server write timeout")
+		}
+	}
+
+	queryTimeout := middleware.QueryTimeout(timeouts)
+
 	// Initialize API router and HTTP server.
-	r := router.New(categoryHandler, itemHandler, analyticsHandler)
+	r, err := router.New(categoryHandler, itemHandler, analyticsHandler, recurringHandler, accountHandler, ruleHandler, tagHandler, attachmentHandler, reconciliationHandler, apiKeyHandler, workspaceHandler, webhookHandler, streamHandler, jobHandler, scheduleHandler, authenticate, rateLimit, queryTimeout, resolveWorkspace, cfg.Server.TrustedProxies)
+	if err != nil {
+		zlog.Logger.Fatal().Err(err).Msg("failed to initialize router")
+	}
 	s := server.New(cfg, r)
 
+	// Setup context to handle SIGINT and SIGTERM for graceful shutdown.
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	// Start slave health checks, they stop when the shutdown signal is received.
+	go db.Run(logging.With(ctx, "worker", "replicas"))
+
+	// Start analytics cache invalidation, it stops when the shutdown signal is received.
+	if analyticsCache != nil {
+		go analyticsCache.Run(logging.With(ctx, "worker", "analytics_cache"))
+	}
+
+	// Start recurring items worker, it stops when the shutdown signal is received.
+	recurringDone := make(chan struct{})
+	go func() {
+		defer close(recurringDone)
+		recurringService.Run(logging.With(ctx, "worker", "recurring"), cfg.Recurring.PollInterval)
+	}()
+
+	// Start webhook dispatcher, it stops when the shutdown signal is received.
+	webhooksDone := make(chan struct{})
+	go func() {
+		defer close(webhooksDone)
+		webhookService.Run(logging.With(ctx, "worker", "webhooks"))
+	}()
+
+	// Start analytics job workers, they stop when the shutdown signal is received
+	// and put the jobs they are running back in the queue.
+	jobsDone := make(chan struct{})
+	go func() {
+		defer close(jobsDone)
+		jobService.Run(logging.With(ctx, "worker", "analytics_jobs"))
+	}()
+
+	// Start scheduled report scheduler, it stops when the shutdown signal is
+	// received and releases the report it is sending.
+	reportsDone := make(chan struct{})
+	go func() {
+		defer close(reportsDone)
+		scheduleService.Run(logging.With(ctx, "worker", "reports"))
+	}()
+
+	// Start item change listener; when the shutdown signal is received it stops
+	// and ends the open streams, which server shutdown would otherwise wait for.
+	streamDone := make(chan struct{})
+	go func() {
+		defer close(streamDone)
+		streamService.Run(logging.With(ctx, "worker", "stream"))
+	}()
+
 	// Start HTTP server in a separate goroutine.
 	go func() {
 		if err := s.ListenAndServe(); err != nil {
@@ -75,10 +383,6 @@ func main() {
 		}
 	}()
 
-	// Setup context to handle SIGINT and SIGTERM for graceful shutdown.
-	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
-	defer stop()
-
 	// Wait for shutdown signal.
 	<-ctx.Done()
 	zlog.Logger.Print("shutdown signal received")
@@ -95,6 +399,46 @@ func main() {
 		zlog.Logger.Info().Msg("timeout exceeded, forcing shutdown")
 	}
 
+	// Wait for recurring items worker to finish its current run.
+	select {
+	case <-recurringDone:
+	case <-shutdownCtx.Done():
+		zlog.Logger.Info().Msg("recurring items worker did not stop in time")
+	}
+
+	// Wait for item change listener to close its connection.
+	select {
+	case <-streamDone:
+	case <-shutdownCtx.Done():
+		zlog.Logger.Info().Msg("item change listener did not stop in time")
+	}
+
+	// Wait for webhook dispatcher to finish its in-flight deliveries.
+	select {
+	case <-webhooksDone:
+	case <-shutdownCtx.Done():
+		zlog.Logger.Info().Msg("webhook dispatcher did not stop in time")
+	}
+
+	// Wait for analytics job workers to release their running jobs.
+	select {
+	case <-jobsDone:
+	case <-shutdownCtx.Done():
+		zlog.Logger.Info().Msg("analytics job workers did not stop in time")
+	}
+
+	// Wait for scheduled report scheduler to release the report it is sending.
+	select {
+	case <-reportsDone:
+	case <-shutdownCtx.Done():
+		zlog.Logger.Info().Msg("scheduled report scheduler did not stop in time")
+	}
+
+	// Export the spans of the last requests.
+	if err := shutdownTracing(shutdownCtx); err != nil {
+		zlog.Logger.Error().Err(err).Msg("failed to flush traces")
+	}
+
 	zlog.Logger.Print("closing master and slave databases...\n")
 
 	// Close master database connection.
@@ -0,0 +1,71 @@
+// Command token issues a JWT bearer token signed with JWT_SECRET, e.g. to
+// bootstrap the first admin who then creates API keys:
+//
+//	JWT_SECRET=... go run ./cmd/token -sub ops -role admin -ttl 1h
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/wb-go/wbf/zlog"
+
+	"github.com/aliskhannn/sales-tracker/internal/auth"
+	"github.com/aliskhannn/sales-tracker/internal/config"
+	"github.com/aliskhannn/sales-tracker/internal/model"
+)
+
+func main() {
+	sub := flag.String("sub", "", "subject of the token (required)")
+	role := flag.String("role", model.RoleViewer, "role: viewer, editor or admin")
+	scopes := flag.String("scopes", "", "comma-separated scopes, empty allows all")
+	workspace := flag.String("workspace", "", "ID of the workspace the token is bound to, empty allows all")
+	ttl := flag.Duration("ttl", 24*time.Hour, "validity of the token")
+	flag.Parse()
+
+	zlog.Init()
+	cfg := config.MustLoad()
+
+	if *sub == "" || !model.IsRole(*role) {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	var list []string
+	for _, s := range strings.Split(*scopes, ",") {
+		if s = strings.TrimSpace(s); s == "" {
+			continue
+		}
+
+		if !model.IsScope(s) {
+			fmt.Fprintf(os.Stderr, "unknown scope %q, expected one of %s\n", s, strings.Join(model.Scopes, ", "))
+			os.Exit(2)
+		}
+
+		list = append(list, s)
+	}
+
+	var workspaceID *uuid.UUID
+	if *workspace != "" {
+		id, err := uuid.Parse(*workspace)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "invalid workspace id %q\n", *workspace)
+			os.Exit(2)
+		}
+
+		workspaceID = &id
+	}
+
+	jwt := auth.NewJWT(cfg.Auth.JWTSecret, cfg.Auth.JWTIssuer, cfg.Auth.JWTAudience, cfg.Auth.JWTLeeway)
+	token, err := jwt.Sign(*sub, *role, list, workspaceID, *ttl)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "failed to sign token: %v\n", err)
+		os.Exit(1)
+	}
+
+	fmt.Println(token)
+}
@@ -0,0 +1,187 @@
+package account
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+	"github.com/wb-go/wbf/ginext"
+
+	"github.com/aliskhannn/sales-tracker/internal/api/request"
+	"github.com/aliskhannn/sales-tracker/internal/api/response"
+	"github.com/aliskhannn/sales-tracker/internal/logging"
+	"github.com/aliskhannn/sales-tracker/internal/model"
+	"github.com/aliskhannn/sales-tracker/internal/validation"
+)
+
+type service interface {
+	// Create adds a new account.
+	Create(ctx context.Context, name, currency string, openingBalance decimal.Decimal) (uuid.UUID, error)
+
+	// GetByID returns an account by its ID.
+	GetByID(ctx context.Context, id uuid.UUID) (*model.Account, error)
+
+	// List returns all accounts.
+	List(ctx context.Context) ([]model.Account, error)
+
+	// Update modifies an existing account identified by id.
+	Update(ctx context.Context, id uuid.UUID, name, currency string, openingBalance decimal.Decimal) error
+
+	// Delete removes an account by its ID.
+	Delete(ctx context.Context, id uuid.UUID) error
+
+	// Balance returns the balance of an account at the given time, or now if at is nil.
+	Balance(ctx context.Context, id uuid.UUID, at *time.Time) (*model.AccountBalance, error)
+}
+
+// Handler defines the HTTP layer for accounts.
+type Handler struct {
+	service   service
+	validator *validator.Validate
+}
+
+// NewHandler creates a new account handler.
+func NewHandler(s service, v *validator.Validate) *Handler {
+	return &Handler{
+		service:   s,
+		validator: v,
+	}
+}
+
+// CreateRequest represents the JSON body for creating an account.
+type CreateRequest struct {
+	Name           string          `json:"name" validate:"required"`
+	Currency       string          `json:"currency" validate:"required,iso4217"`
+	OpeningBalance decimal.Decimal `json:"opening_balance"`
+}
+
+// UpdateRequest represents the JSON body for updating an account.
+type UpdateRequest struct {
+	Name           string          `json:"name" validate:"required"`
+	Currency       string          `json:"currency" validate:"required,iso4217"`
+	OpeningBalance decimal.Decimal `json:"opening_balance"`
+}
+
+// Create handles POST /accounts.
+func (h *Handler) Create(c *ginext.Context) {
+	var req CreateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		logging.Ctx(c.Request.Context()).Error().Err(err).Msg("failed to bind create request")
+		response.Fail(c, http.StatusBadRequest, fmt.Errorf("invalid request body"))
+		return
+	}
+
+	if err := h.validator.Struct(req); err != nil {
+		logging.Ctx(c.Request.Context()).Error().Err(err).Msg("failed to validate request")
+		response.ValidationFail(c, validation.Fields(err))
+		return
+	}
+
+	id, err := h.service.Create(c.Request.Context(), req.Name, req.Currency, req.OpeningBalance)
+	if err != nil {
+		response.Error(c, err)
+		return
+	}
+
+	response.Created(c, map[string]string{"id": id.String()})
+}
+
+// GetByID handles GET /accounts/:id.
+func (h *Handler) GetByID(c *ginext.Context) {
+	id, err := request.ParseUUIDParam(c, "id")
+	if err != nil {
+		response.Fail(c, http.StatusBadRequest, err)
+		return
+	}
+
+	a, err := h.service.GetByID(c.Request.Context(), id)
+	if err != nil {
+		response.Error(c, err)
+		return
+	}
+
+	response.OK(c, map[string]*model.Account{"account": a})
+}
+
+// List handles GET /accounts.
+func (h *Handler) List(c *ginext.Context) {
+	accounts, err := h.service.List(c.Request.Context())
+	if err != nil {
+		response.Error(c, err)
+		return
+	}
+
+	response.OK(c, map[string][]model.Account{"accounts": accounts})
+}
+
+// Update handles PUT /accounts/:id.
+func (h *Handler) Update(c *ginext.Context) {
+	id, err := request.ParseUUIDParam(c, "id")
+	if err != nil {
+		response.Fail(c, http.StatusBadRequest, err)
+		return
+	}
+
+	var req UpdateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		logging.Ctx(c.Request.Context()).Error().Err(err).Msg("failed to bind update request")
+		response.Fail(c, http.StatusBadRequest, fmt.Errorf("invalid request body"))
+		return
+	}
+
+	if err := h.validator.Struct(req); err != nil {
+		logging.Ctx(c.Request.Context()).Error().Err(err).Msg("failed to validate request")
+		response.ValidationFail(c, validation.Fields(err))
+		return
+	}
+
+	if err := h.service.Update(c.Request.Context(), id, req.Name, req.Currency, req.OpeningBalance); err != nil {
+		response.Error(c, err)
+		return
+	}
+
+	response.OK(c, map[string]string{"message": "account updated"})
+}
+
+// Delete handles DELETE /accounts/:id.
+func (h *Handler) Delete(c *ginext.Context) {
+	id, err := request.ParseUUIDParam(c, "id")
+	if err != nil {
+		response.Fail(c, http.StatusBadRequest, err)
+		return
+	}
+
+	if err := h.service.Delete(c.Request.Context(), id); err != nil {
+		response.Error(c, err)
+		return
+	}
+
+	response.OK(c, map[string]string{"message": "account deleted"})
+}
+
+// Balance handles GET /accounts/:id/balance.
+func (h *Handler) Balance(c *ginext.Context) {
+	id, err := request.ParseUUIDParam(c, "id")
+	if err != nil {
+		response.Fail(c, http.StatusBadRequest, err)
+		return
+	}
+
+	at, err := request.ParseTimeQuery(c, "at", time.RFC3339)
+	if err != nil {
+		response.Fail(c, http.StatusBadRequest, err)
+		return
+	}
+
+	balance, err := h.service.Balance(c.Request.Context(), id, at)
+	if err != nil {
+		response.Error(c, err)
+		return
+	}
+
+	response.OK(c, map[string]*model.AccountBalance{"balance": balance})
+}
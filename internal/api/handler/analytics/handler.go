@@ -2,45 +2,70 @@ package analytics
 
 import (
 	"context"
-	"fmt"
 	"net/http"
+	"strconv"
 	"time"
 
 	"github.com/google/uuid"
 	"github.com/wb-go/wbf/ginext"
-	"github.com/wb-go/wbf/zlog"
 
 	"github.com/aliskhannn/sales-tracker/internal/api/request"
 	"github.com/aliskhannn/sales-tracker/internal/api/response"
 	"github.com/aliskhannn/sales-tracker/internal/config"
+	"github.com/aliskhannn/sales-tracker/internal/model"
 )
 
 type service interface {
 	// Sum returns the total amount of items matching the filter.
-	Sum(ctx context.Context, from, to *time.Time, categoryID *uuid.UUID, kind *string) (string, error)
+	Sum(ctx context.Context, from, to *time.Time, categoryID *uuid.UUID, kind *string, accountID *uuid.UUID, tags *model.TagFilter) (string, error)
 
 	// Avg returns the average amount of items matching the filter.
-	Avg(ctx context.Context, from, to *time.Time, categoryID *uuid.UUID, kind *string) (string, error)
+	Avg(ctx context.Context, from, to *time.Time, categoryID *uuid.UUID, kind *string, accountID *uuid.UUID, tags *model.TagFilter) (string, error)
 
 	// Count returns the number of items matching the filter.
-	Count(ctx context.Context, from, to *time.Time, categoryID *uuid.UUID, kind *string) (int64, error)
+	Count(ctx context.Context, from, to *time.Time, categoryID *uuid.UUID, kind *string, accountID *uuid.UUID, tags *model.TagFilter) (int64, error)
 
 	// Median returns the median amount of items matching the filter.
-	Median(ctx context.Context, from, to *time.Time, categoryID *uuid.UUID, kind *string) (string, error)
+	Median(ctx context.Context, from, to *time.Time, categoryID *uuid.UUID, kind *string, accountID *uuid.UUID, tags *model.TagFilter) (string, error)
 
 	// Percentile returns the N-th percentile amount of items matching the filter.
-	Percentile(ctx context.Context, from, to *time.Time, categoryID *uuid.UUID, kind *string, percentile float64) (string, error)
+	Percentile(ctx context.Context, from, to *time.Time, categoryID *uuid.UUID, kind *string, accountID *uuid.UUID, tags *model.TagFilter, percentile float64) (string, error)
+
+	// Revenue returns gross income, refunds and net revenue of items matching the filter.
+	Revenue(ctx context.Context, from, to *time.Time, categoryID, accountID *uuid.UUID, tags *model.TagFilter) (*model.Revenue, error)
+
+	// ByCategory returns count and sum per category of items matching the filter.
+	ByCategory(ctx context.Context, from, to *time.Time, kind *string, accountID *uuid.UUID, tags *model.TagFilter) ([]model.CategoryTotal, error)
+
+	// ByTag returns count and sum per tag of items matching the filter.
+	ByTag(ctx context.Context, from, to *time.Time, categoryID *uuid.UUID, kind *string, accountID *uuid.UUID, tags *model.TagFilter) ([]model.TagTotal, error)
 }
 
+// vary are the request headers that select the workspace and caller whose
+// results are returned.
+var vary = []string{"Authorization", "X-API-Key", "X-Workspace-ID"}
+
 // Handler provides HTTP handlers for analytics.
 type Handler struct {
-	service service
-	cfg     *config.Config
+	service      service
+	cfg          *config.Config
+	cacheControl string
 }
 
-// NewHandler creates a new analytics handler.
+// NewHandler creates a new analytics handler. Clients may reuse results for
+// the configured max age and revalidate them by ETag afterwards.
 func NewHandler(s service, cfg *config.Config) *Handler {
-	return &Handler{service: s, cfg: cfg}
+	cacheControl := "private, no-cache"
+	if maxAge := int(cfg.Analytics.Cache.MaxAge.Seconds()); maxAge > 0 {
+		cacheControl = "private, max-age=" + strconv.Itoa(maxAge)
+	}
+
+	return &Handler{service: s, cfg: cfg, cacheControl: cacheControl}
+}
+
+// ok sends a result with an ETag and the configured Cache-Control.
+func (h *Handler) ok(c *ginext.Context, result interface{}) {
+	response.OKCacheable(c, h.cacheControl, vary, result)
 }
 
 // Query represents query parameters for analytics endpoints.
@@ -49,6 +74,8 @@ type Query struct {
 	To         *time.Time
 	CategoryID *uuid.UUID
 	Kind       *string
+	AccountID  *uuid.UUID
+	Tags       *model.TagFilter
 	Percentile float64
 }
 
@@ -60,14 +87,13 @@ func (h *Handler) Sum(c *ginext.Context) {
 		return
 	}
 
-	total, err := h.service.Sum(c.Request.Context(), q.From, q.To, q.CategoryID, q.Kind)
+	total, err := h.service.Sum(c.Request.Context(), q.From, q.To, q.CategoryID, q.Kind, q.AccountID, q.Tags)
 	if err != nil {
-		zlog.Logger.Error().Err(err).Msg("failed to calculate sum")
-		response.Fail(c, http.StatusInternalServerError, fmt.Errorf("internal server error"))
+		response.Error(c, err)
 		return
 	}
 
-	response.OK(c, map[string]string{"sum": total})
+	h.ok(c, map[string]string{"sum": total})
 }
 
 // Avg handles GET /analytics/avg.
@@ -78,14 +104,13 @@ func (h *Handler) Avg(c *ginext.Context) {
 		return
 	}
 
-	avg, err := h.service.Avg(c.Request.Context(), q.From, q.To, q.CategoryID, q.Kind)
+	avg, err := h.service.Avg(c.Request.Context(), q.From, q.To, q.CategoryID, q.Kind, q.AccountID, q.Tags)
 	if err != nil {
-		zlog.Logger.Error().Err(err).Msg("failed to calculate average")
-		response.Fail(c, http.StatusInternalServerError, fmt.Errorf("internal server error"))
+		response.Error(c, err)
 		return
 	}
 
-	response.OK(c, map[string]string{"avg": avg})
+	h.ok(c, map[string]string{"avg": avg})
 }
 
 // Count handles GET /analytics/count.
@@ -96,14 +121,13 @@ func (h *Handler) Count(c *ginext.Context) {
 		return
 	}
 
-	cnt, err := h.service.Count(c.Request.Context(), q.From, q.To, q.CategoryID, q.Kind)
+	cnt, err := h.service.Count(c.Request.Context(), q.From, q.To, q.CategoryID, q.Kind, q.AccountID, q.Tags)
 	if err != nil {
-		zlog.Logger.Error().Err(err).Msg("failed to calculate count")
-		response.Fail(c, http.StatusInternalServerError, fmt.Errorf("internal server error"))
+		response.Error(c, err)
 		return
 	}
 
-	response.OK(c, map[string]int64{"count": cnt})
+	h.ok(c, map[string]int64{"count": cnt})
 }
 
 // Median handles GET /analytics/median.
@@ -114,14 +138,13 @@ func (h *Handler) Median(c *ginext.Context) {
 		return
 	}
 
-	median, err := h.service.Median(c.Request.Context(), q.From, q.To, q.CategoryID, q.Kind)
+	median, err := h.service.Median(c.Request.Context(), q.From, q.To, q.CategoryID, q.Kind, q.AccountID, q.Tags)
 	if err != nil {
-		zlog.Logger.Error().Err(err).Msg("failed to calculate median")
-		response.Fail(c, http.StatusInternalServerError, fmt.Errorf("internal server error"))
+		response.Error(c, err)
 		return
 	}
 
-	response.OK(c, map[string]string{"median": median})
+	h.ok(c, map[string]string{"median": median})
 }
 
 // Percentile handles GET /analytics/percentile.
@@ -132,14 +155,64 @@ func (h *Handler) Percentile(c *ginext.Context) {
 		return
 	}
 
-	value, err := h.service.Percentile(c.Request.Context(), q.From, q.To, q.CategoryID, q.Kind, q.Percentile)
+	value, err := h.service.Percentile(c.Request.Context(), q.From, q.To, q.CategoryID, q.Kind, q.AccountID, q.Tags, q.Percentile)
+	if err != nil {
+		response.Error(c, err)
+		return
+	}
+
+	h.ok(c, map[string]string{"percentile": value})
+}
+
+// Revenue handles GET /analytics/revenue.
+func (h *Handler) Revenue(c *ginext.Context) {
+	q, err := h.parseQuery(c)
+	if err != nil {
+		response.Fail(c, http.StatusBadRequest, err)
+		return
+	}
+
+	rev, err := h.service.Revenue(c.Request.Context(), q.From, q.To, q.CategoryID, q.AccountID, q.Tags)
 	if err != nil {
-		zlog.Logger.Error().Err(err).Msg("failed to calculate percentile")
-		response.Fail(c, http.StatusInternalServerError, fmt.Errorf("internal server error"))
+		response.Error(c, err)
 		return
 	}
 
-	response.OK(c, map[string]string{"percentile": value})
+	h.ok(c, map[string]*model.Revenue{"revenue": rev})
+}
+
+// ByCategory handles GET /analytics/categories.
+func (h *Handler) ByCategory(c *ginext.Context) {
+	q, err := h.parseQuery(c)
+	if err != nil {
+		response.Fail(c, http.StatusBadRequest, err)
+		return
+	}
+
+	totals, err := h.service.ByCategory(c.Request.Context(), q.From, q.To, q.Kind, q.AccountID, q.Tags)
+	if err != nil {
+		response.Error(c, err)
+		return
+	}
+
+	h.ok(c, map[string][]model.CategoryTotal{"categories": totals})
+}
+
+// ByTag handles GET /analytics/tags.
+func (h *Handler) ByTag(c *ginext.Context) {
+	q, err := h.parseQuery(c)
+	if err != nil {
+		response.Fail(c, http.StatusBadRequest, err)
+		return
+	}
+
+	totals, err := h.service.ByTag(c.Request.Context(), q.From, q.To, q.CategoryID, q.Kind, q.AccountID, q.Tags)
+	if err != nil {
+		response.Error(c, err)
+		return
+	}
+
+	h.ok(c, map[string][]model.TagTotal{"tags": totals})
 }
 
 // parseQuery parses common analytics query parameters.
@@ -159,7 +232,20 @@ func (h *Handler) parseQuery(c *ginext.Context) (*Query, error) {
 		return nil, err
 	}
 
-	kind := request.ParseStringQueryPtr(c, "kind")
+	kind, err := request.ParseKindQuery(c, "kind")
+	if err != nil {
+		return nil, err
+	}
+
+	accountID, err := request.ParseUUIDQuery(c, "account_id")
+	if err != nil {
+		return nil, err
+	}
+
+	tags, err := request.ParseTagFilter(c)
+	if err != nil {
+		return nil, err
+	}
 
 	percentile, err := request.ParseFloatQuery(c, "percentile", h.cfg.Analytics.PercentileDefault)
 	if err != nil {
@@ -171,6 +257,8 @@ func (h *Handler) parseQuery(c *ginext.Context) (*Query, error) {
 		To:         to,
 		CategoryID: categoryID,
 		Kind:       kind,
+		AccountID:  accountID,
+		Tags:       tags,
 		Percentile: percentile,
 	}, nil
 }
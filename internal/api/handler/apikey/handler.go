@@ -0,0 +1,129 @@
+package apikey
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/google/uuid"
+	"github.com/wb-go/wbf/ginext"
+
+	"github.com/aliskhannn/sales-tracker/internal/api/request"
+	"github.com/aliskhannn/sales-tracker/internal/api/response"
+	"github.com/aliskhannn/sales-tracker/internal/auth"
+	"github.com/aliskhannn/sales-tracker/internal/logging"
+	"github.com/aliskhannn/sales-tracker/internal/model"
+	"github.com/aliskhannn/sales-tracker/internal/validation"
+)
+
+// service defines API key management.
+type service interface {
+	// Create issues a new API key, optionally bound to a workspace, and returns it with its secret.
+	Create(ctx context.Context, name, role string, scopes []string, workspaceID *uuid.UUID, expiresAt *time.Time) (*model.APIKey, string, error)
+
+	// List returns API keys without their secrets, optionally only those of a workspace.
+	List(ctx context.Context, workspaceID *uuid.UUID) ([]model.APIKey, error)
+
+	// Revoke revokes an API key, optionally only if it belongs to a workspace.
+	Revoke(ctx context.Context, id uuid.UUID, workspaceID *uuid.UUID) error
+}
+
+// Handler defines HTTP layer for API keys. Admins bound to a workspace only
+// manage keys of their workspace; keys they create are bound to it.
+type Handler struct {
+	service   service
+	validator *validator.Validate
+}
+
+// NewHandler creates a new API key handler.
+func NewHandler(s service, v *validator.Validate) *Handler {
+	return &Handler{service: s, validator: v}
+}
+
+// CreateRequest JSON body for creating an API key.
+type CreateRequest struct {
+	Name        string     `json:"name" validate:"required,max=200"`
+	Role        string     `json:"role" validate:"required,oneof=viewer editor admin"`
+	Scopes      []string   `json:"scopes,omitempty"`
+	WorkspaceID *uuid.UUID `json:"workspace_id,omitempty"`
+	ExpiresAt   *time.Time `json:"expires_at,omitempty"`
+}
+
+// CreateResponse is the result of POST /keys. Key is the secret, which is
+// not returned again.
+type CreateResponse struct {
+	Key    string        `json:"key"`
+	APIKey *model.APIKey `json:"api_key"`
+}
+
+// Create handles POST /keys.
+func (h *Handler) Create(c *ginext.Context) {
+	var req CreateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		logging.Ctx(c.Request.Context()).Error().Err(err).Msg("failed to bind create request")
+		response.Fail(c, http.StatusBadRequest, fmt.Errorf("invalid request body"))
+		return
+	}
+
+	if err := h.validator.Struct(req); err != nil {
+		logging.Ctx(c.Request.Context()).Error().Err(err).Msg("failed to validate request")
+		response.ValidationFail(c, validation.Fields(err))
+		return
+	}
+
+	workspaceID := req.WorkspaceID
+	if bound := boundWorkspace(c); bound != nil {
+		if workspaceID != nil && *workspaceID != *bound {
+			response.Fail(c, http.StatusForbidden, auth.ErrWorkspaceForbidden)
+			return
+		}
+
+		workspaceID = bound
+	}
+
+	k, secret, err := h.service.Create(c.Request.Context(), req.Name, req.Role, req.Scopes, workspaceID, req.ExpiresAt)
+	if err != nil {
+		response.Error(c, err)
+		return
+	}
+
+	response.Created(c, CreateResponse{Key: secret, APIKey: k})
+}
+
+// List handles GET /keys.
+func (h *Handler) List(c *ginext.Context) {
+	keys, err := h.service.List(c.Request.Context(), boundWorkspace(c))
+	if err != nil {
+		response.Error(c, err)
+		return
+	}
+
+	response.OK(c, map[string][]model.APIKey{"keys": keys})
+}
+
+// Revoke handles DELETE /keys/:id.
+func (h *Handler) Revoke(c *ginext.Context) {
+	id, err := request.ParseUUIDParam(c, "id")
+	if err != nil {
+		response.Fail(c, http.StatusBadRequest, err)
+		return
+	}
+
+	if err = h.service.Revoke(c.Request.Context(), id, boundWorkspace(c)); err != nil {
+		response.Error(c, err)
+		return
+	}
+
+	response.OK(c, map[string]string{"message": "api key revoked"})
+}
+
+// boundWorkspace returns the workspace the caller is bound to, or nil.
+func boundWorkspace(c *ginext.Context) *uuid.UUID {
+	if p := request.Principal(c); p != nil {
+		return p.WorkspaceID
+	}
+
+	return nil
+}
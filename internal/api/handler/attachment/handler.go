@@ -0,0 +1,166 @@
+package attachment
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+
+	"github.com/google/uuid"
+	"github.com/wb-go/wbf/ginext"
+
+	"github.com/aliskhannn/sales-tracker/internal/api/request"
+	"github.com/aliskhannn/sales-tracker/internal/api/response"
+	"github.com/aliskhannn/sales-tracker/internal/config"
+	"github.com/aliskhannn/sales-tracker/internal/logging"
+	"github.com/aliskhannn/sales-tracker/internal/model"
+	srvcattachment "github.com/aliskhannn/sales-tracker/internal/service/attachment"
+)
+
+// multipartOverhead is the allowance for multipart headers and boundaries on
+// top of the max attachment size when limiting the request body.
+const multipartOverhead = 1 << 20
+
+// service defines business logic for attachments.
+type service interface {
+	// Upload attaches a file to an item; created is false if the item already had it.
+	Upload(ctx context.Context, itemID uuid.UUID, filename string, r io.Reader) (*model.Attachment, bool, error)
+
+	// List returns the attachments of an item.
+	List(ctx context.Context, itemID uuid.UUID) ([]model.Attachment, error)
+
+	// Open returns an attachment with a reader of its contents.
+	Open(ctx context.Context, itemID, id uuid.UUID) (*model.Attachment, io.ReadCloser, error)
+
+	// Delete removes an attachment of an item.
+	Delete(ctx context.Context, itemID, id uuid.UUID) error
+}
+
+// Handler defines HTTP layer for item attachments.
+type Handler struct {
+	service service
+	cfg     *config.Config
+}
+
+// NewHandler creates a new attachment handler.
+func NewHandler(s service, cfg *config.Config) *Handler {
+	return &Handler{service: s, cfg: cfg}
+}
+
+// Upload handles POST /items/:id/attachments (multipart form field "file").
+func (h *Handler) Upload(c *ginext.Context) {
+	itemID, err := request.ParseUUIDParam(c, "id")
+	if err != nil {
+		response.Fail(c, http.StatusBadRequest, err)
+		return
+	}
+
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, h.cfg.Attachments.MaxSize+multipartOverhead)
+
+	fh, err := c.FormFile("file")
+	if err != nil {
+		var maxErr *http.MaxBytesError
+		if errors.As(err, &maxErr) {
+			response.Fail(c, http.StatusRequestEntityTooLarge, srvcattachment.ErrFileTooLarge)
+			return
+		}
+
+		logging.Ctx(c.Request.Context()).Error().Err(err).Msg("failed to read uploaded file")
+		response.Fail(c, http.StatusBadRequest, fmt.Errorf("multipart field \"file\" is required"))
+		return
+	}
+
+	if fh.Size > h.cfg.Attachments.MaxSize {
+		response.Fail(c, http.StatusRequestEntityTooLarge, srvcattachment.ErrFileTooLarge)
+		return
+	}
+
+	f, err := fh.Open()
+	if err != nil {
+		response.Error(c, err)
+		return
+	}
+	defer func() { _ = f.Close() }()
+
+	a, created, err := h.service.Upload(c.Request.Context(), itemID, fh.Filename, f)
+	if err != nil {
+		response.Error(c, err)
+		return
+	}
+
+	if !created {
+		response.OK(c, map[string]*model.Attachment{"attachment": a})
+		return
+	}
+
+	response.Created(c, map[string]*model.Attachment{"attachment": a})
+}
+
+// List handles GET /items/:id/attachments.
+func (h *Handler) List(c *ginext.Context) {
+	itemID, err := request.ParseUUIDParam(c, "id")
+	if err != nil {
+		response.Fail(c, http.StatusBadRequest, err)
+		return
+	}
+
+	attachments, err := h.service.List(c.Request.Context(), itemID)
+	if err != nil {
+		response.Error(c, err)
+		return
+	}
+
+	response.OK(c, map[string][]model.Attachment{"attachments": attachments})
+}
+
+// Download handles GET /items/:id/attachments/:attachment_id.
+func (h *Handler) Download(c *ginext.Context) {
+	itemID, err := request.ParseUUIDParam(c, "id")
+	if err != nil {
+		response.Fail(c, http.StatusBadRequest, err)
+		return
+	}
+
+	id, err := request.ParseUUIDParam(c, "attachment_id")
+	if err != nil {
+		response.Fail(c, http.StatusBadRequest, err)
+		return
+	}
+
+	a, rc, err := h.service.Open(c.Request.Context(), itemID, id)
+	if err != nil {
+		response.Error(c, err)
+		return
+	}
+	defer func() { _ = rc.Close() }()
+
+	c.DataFromReader(http.StatusOK, a.Size, a.ContentType, rc, map[string]string{
+		"Content-Disposition":    mime.FormatMediaType("attachment", map[string]string{"filename": a.Filename}),
+		"ETag":                   `"` + a.SHA256 + `"`,
+		"X-Content-Type-Options": "nosniff",
+	})
+}
+
+// Delete handles DELETE /items/:id/attachments/:attachment_id.
+func (h *Handler) Delete(c *ginext.Context) {
+	itemID, err := request.ParseUUIDParam(c, "id")
+	if err != nil {
+		response.Fail(c, http.StatusBadRequest, err)
+		return
+	}
+
+	id, err := request.ParseUUIDParam(c, "attachment_id")
+	if err != nil {
+		response.Fail(c, http.StatusBadRequest, err)
+		return
+	}
+
+	if err := h.service.Delete(c.Request.Context(), itemID, id); err != nil {
+		response.Error(c, err)
+		return
+	}
+
+	response.OK(c, map[string]string{"message": "attachment deleted"})
+}
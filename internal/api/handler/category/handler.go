@@ -2,19 +2,18 @@ package category
 
 import (
 	"context"
-	"errors"
 	"fmt"
 	"net/http"
 
 	"github.com/go-playground/validator/v10"
 	"github.com/google/uuid"
 	"github.com/wb-go/wbf/ginext"
-	"github.com/wb-go/wbf/zlog"
 
 	"github.com/aliskhannn/sales-tracker/internal/api/request"
 	"github.com/aliskhannn/sales-tracker/internal/api/response"
+	"github.com/aliskhannn/sales-tracker/internal/logging"
 	"github.com/aliskhannn/sales-tracker/internal/model"
-	"github.com/aliskhannn/sales-tracker/internal/repository/category"
+	"github.com/aliskhannn/sales-tracker/internal/validation"
 )
 
 type service interface {
@@ -66,24 +65,23 @@ type UpdateRequest struct {
 
 // Create handles POST /categories.
 func (h *Handler) Create(c *ginext.Context) {
-	zlog.Logger.Info().Msg("create requested")
+	logging.Ctx(c.Request.Context()).Info().Msg("create requested")
 	var req CreateRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		zlog.Logger.Error().Err(err).Msg("failed to bind create request")
+		logging.Ctx(c.Request.Context()).Error().Err(err).Msg("failed to bind create request")
 		response.Fail(c, http.StatusBadRequest, fmt.Errorf("invalid request body"))
 		return
 	}
 
 	if err := h.validator.Struct(req); err != nil {
-		zlog.Logger.Error().Err(err).Msg("failed to validate request")
-		response.Fail(c, http.StatusBadRequest, fmt.Errorf("validation error: %s", err.Error()))
+		logging.Ctx(c.Request.Context()).Error().Err(err).Msg("failed to validate request")
+		response.ValidationFail(c, validation.Fields(err))
 		return
 	}
 
 	id, err := h.service.Create(c.Request.Context(), req.Name, req.Description, req.ParentID)
 	if err != nil {
-		zlog.Logger.Error().Err(err).Msg("failed to create category")
-		response.Fail(c, http.StatusInternalServerError, fmt.Errorf("internal server error"))
+		response.Error(c, err)
 		return
 	}
 
@@ -101,15 +99,8 @@ func (h *Handler) GetByID(c *ginext.Context) {
 	cat, err := h.service.GetByID(c.Request.Context(), id)
 	if err != nil {
 		// If category not found, return 404 Not Found.
-		if errors.Is(err, category.ErrCategoryNotFound) {
-			zlog.Logger.Error().Err(err).Msg("category not found")
-			response.Fail(c, http.StatusNotFound, err)
-			return
-		}
-
 		// Internal Server Error.
-		zlog.Logger.Error().Err(err).Msg("failed to get category")
-		response.Fail(c, http.StatusInternalServerError, fmt.Errorf("internal server error"))
+		response.Error(c, err)
 		return
 	}
 
@@ -121,8 +112,7 @@ func (h *Handler) List(c *ginext.Context) {
 	categories, err := h.service.List(c.Request.Context())
 	if err != nil {
 		// Internal Server Error.
-		zlog.Logger.Error().Err(err).Msg("failed to get categories")
-		response.Fail(c, http.StatusInternalServerError, fmt.Errorf("internal server error"))
+		response.Error(c, err)
 		return
 	}
 
@@ -139,28 +129,21 @@ func (h *Handler) Update(c *ginext.Context) {
 
 	var req UpdateRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		zlog.Logger.Error().Err(err).Msg("failed to bind update request")
+		logging.Ctx(c.Request.Context()).Error().Err(err).Msg("failed to bind update request")
 		response.Fail(c, http.StatusBadRequest, fmt.Errorf("invalid request body"))
 		return
 	}
 
 	if err := h.validator.Struct(req); err != nil {
-		zlog.Logger.Error().Err(err).Msg("failed to validate request")
-		response.Fail(c, http.StatusBadRequest, fmt.Errorf("validation error: %s", err.Error()))
+		logging.Ctx(c.Request.Context()).Error().Err(err).Msg("failed to validate request")
+		response.ValidationFail(c, validation.Fields(err))
 		return
 	}
 
 	if err := h.service.Update(c.Request.Context(), id, req.Name, req.Description, req.ParentID); err != nil {
 		// If category not found, return 404 Not Found.
-		if errors.Is(err, category.ErrCategoryNotFound) {
-			zlog.Logger.Error().Err(err).Msg("category not found")
-			response.Fail(c, http.StatusNotFound, err)
-			return
-		}
-
 		// Internal Server Error.
-		zlog.Logger.Error().Err(err).Msg("failed to update category")
-		response.Fail(c, http.StatusInternalServerError, fmt.Errorf("internal server error"))
+		response.Error(c, err)
 		return
 	}
 
@@ -177,15 +160,8 @@ func (h *Handler) Delete(c *ginext.Context) {
 
 	if err := h.service.Delete(c.Request.Context(), id); err != nil {
 		// If category not found, return 404 Not Found.
-		if errors.Is(err, category.ErrCategoryNotFound) {
-			zlog.Logger.Error().Err(err).Msg("category not found")
-			response.Fail(c, http.StatusNotFound, err)
-			return
-		}
-
 		// Internal Server Error.
-		zlog.Logger.Error().Err(err).Msg("failed to delete category")
-		response.Fail(c, http.StatusInternalServerError, fmt.Errorf("internal server error"))
+		response.Error(c, err)
 		return
 	}
 
@@ -1,89 +1,162 @@
 package item
 
 import (
+	"bytes"
 	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
+	"io"
 	"net/http"
+	"strings"
 	"time"
 
 	"github.com/go-playground/validator/v10"
 	"github.com/google/uuid"
 	"github.com/shopspring/decimal"
 	"github.com/wb-go/wbf/ginext"
-	"github.com/wb-go/wbf/zlog"
 
 	"github.com/aliskhannn/sales-tracker/internal/api/request"
 	"github.com/aliskhannn/sales-tracker/internal/api/response"
+	"github.com/aliskhannn/sales-tracker/internal/config"
+	"github.com/aliskhannn/sales-tracker/internal/logging"
 	"github.com/aliskhannn/sales-tracker/internal/model"
-	"github.com/aliskhannn/sales-tracker/internal/repository/item"
+	"github.com/aliskhannn/sales-tracker/internal/validation"
 )
 
+// multipartOverhead is the allowance for multipart headers and boundaries on
+// top of the max import file size when limiting the request body.
+const multipartOverhead = 1 << 20
+
+// codePossibleDuplicates is the error code of the 409 problem returned by
+// POST /items?check_duplicates=true; its "candidates" member lists the items.
+const codePossibleDuplicates = "possible_duplicates"
+
 // service defines business logic for items.
 type service interface {
-	// Create adds a new item with the given fields.
-	Create(ctx context.Context, kind, title string, amount decimal.Decimal, currency string, occurredAt time.Time, categoryID *uuid.UUID, metadata json.RawMessage) (uuid.UUID, error)
+	// Create adds a new item with the given fields and optional references and splits.
+	Create(ctx context.Context, kind, title string, amount decimal.Decimal, currency string, occurredAt time.Time, categoryID, accountID, refundOf *uuid.UUID, metadata json.RawMessage, splits []model.ItemSplit) (uuid.UUID, error)
 
 	// GetByID returns an item by its ID.
 	GetByID(ctx context.Context, id uuid.UUID) (*model.Item, error)
 
 	// List returns items applying the given filters such as date range,
-	// category, kind, pagination, and sort order.
-	List(ctx context.Context, from, to *time.Time, categoryID *uuid.UUID, kind *string, limit, offset int, sortBy string) ([]model.Item, error)
+	// category, kind, account, tags, reconciliation state, pagination, and sort order.
+	List(ctx context.Context, from, to *time.Time, categoryID *uuid.UUID, kind *string, accountID *uuid.UUID, tags *model.TagFilter, reconciled *bool, limit, offset int, sortBy string) ([]model.Item, error)
 
 	// Update modifies an existing item by its ID.
-	Update(ctx context.Context, id uuid.UUID, kind, title string, amount decimal.Decimal, currency string, occurredAt time.Time, categoryID *uuid.UUID, metadata json.RawMessage) error
+	// nil splits keep the existing ones, an empty slice removes them.
+	Update(ctx context.Context, id uuid.UUID, kind, title string, amount decimal.Decimal, currency string, occurredAt time.Time, categoryID, accountID, refundOf *uuid.UUID, metadata json.RawMessage, splits []model.ItemSplit) error
 
 	// Delete removes an item by its ID.
 	Delete(ctx context.Context, id uuid.UUID) error
+
+	// ListSplits returns the splits of an item.
+	ListSplits(ctx context.Context, itemID uuid.UUID) ([]model.ItemSplit, error)
+
+	// ReplaceSplits replaces the splits of an item.
+	ReplaceSplits(ctx context.Context, itemID uuid.UUID, splits []model.ItemSplit) error
+
+	// Refunds returns the refunds of an item with refunded and remaining amounts.
+	Refunds(ctx context.Context, id uuid.UUID) (*model.RefundSummary, error)
+
+	// CreateTransfer atomically creates linked source and destination legs of a transfer.
+	CreateTransfer(ctx context.Context, sourceAccountID, destinationAccountID uuid.UUID, title string, amount decimal.Decimal, destinationAmount *decimal.Decimal, occurredAt time.Time, metadata json.RawMessage) (*model.Transfer, error)
+
+	// Duplicates returns clusters of likely duplicate items.
+	Duplicates(ctx context.Context, from, to *time.Time, window time.Duration, similarity float64) ([]model.DuplicateCluster, error)
+
+	// FindSimilar returns existing items that are likely duplicates of an item with the given fields.
+	FindSimilar(ctx context.Context, title string, amount decimal.Decimal, currency string, occurredAt time.Time, window time.Duration, similarity float64) ([]model.Item, error)
+
+	// Merge keeps one item, combines metadata and deletes the duplicates.
+	Merge(ctx context.Context, keepID uuid.UUID, duplicateIDs []uuid.UUID) (*model.Item, error)
+
+	// Import creates items from a bank file and reports the result of every entry.
+	Import(ctx context.Context, format string, r io.Reader, accountID *uuid.UUID, currency string) (*model.ImportReport, error)
 }
 
 // Handler defines HTTP layer for items.
 type Handler struct {
 	service   service
 	validator *validator.Validate
+	cfg       *config.Config
 }
 
 // NewHandler creates a new item handler.
-func NewHandler(s service, v *validator.Validate) *Handler {
-	return &Handler{service: s, validator: v}
+func NewHandler(s service, v *validator.Validate, cfg *config.Config) *Handler {
+	return &Handler{service: s, validator: v, cfg: cfg}
 }
 
 // CreateRequest JSON body for creating an item.
 type CreateRequest struct {
-	Kind       string          `json:"kind" validate:"required"`
+	Kind       string          `json:"kind" validate:"required,item_kind"`
 	Title      string          `json:"title" validate:"required"`
-	Amount     decimal.Decimal `json:"amount" validate:"required"`
-	Currency   string          `json:"currency" validate:"required"`
-	OccurredAt time.Time       `json:"occurred_at" validate:"required"`
+	Amount     decimal.Decimal `json:"amount" validate:"required,amount=Currency"`
+	Currency   string          `json:"currency" validate:"required,iso4217"`
+	OccurredAt time.Time       `json:"occurred_at" validate:"required,not_future"`
 	CategoryID *uuid.UUID      `json:"category_id,omitempty"`
-	Metadata   json.RawMessage `json:"metadata,omitempty"`
+	AccountID  *uuid.UUID      `json:"account_id,omitempty"`
+	RefundOf   *uuid.UUID      `json:"refund_of,omitempty"`
+	Metadata   json.RawMessage `json:"metadata,omitempty" validate:"omitempty,json_object"`
+	Splits     []SplitRequest  `json:"splits,omitempty" validate:"omitempty,dive"`
 }
 
 // UpdateRequest JSON body for updating an item.
 type UpdateRequest struct {
-	Kind       string          `json:"kind" validate:"required"`
+	Kind       string          `json:"kind" validate:"required,item_kind"`
 	Title      string          `json:"title" validate:"required"`
-	Amount     decimal.Decimal `json:"amount" validate:"required"`
-	Currency   string          `json:"currency" validate:"required"`
-	OccurredAt time.Time       `json:"occurred_at" validate:"required"`
+	Amount     decimal.Decimal `json:"amount" validate:"required,amount=Currency"`
+	Currency   string          `json:"currency" validate:"required,iso4217"`
+	OccurredAt time.Time       `json:"occurred_at" validate:"required,not_future"`
 	CategoryID *uuid.UUID      `json:"category_id,omitempty"`
-	Metadata   json.RawMessage `json:"metadata,omitempty"`
+	AccountID  *uuid.UUID      `json:"account_id,omitempty"`
+	RefundOf   *uuid.UUID      `json:"refund_of,omitempty"`
+	Metadata   json.RawMessage `json:"metadata,omitempty" validate:"omitempty,json_object"`
+	Splits     []SplitRequest  `json:"splits,omitempty" validate:"omitempty,dive"`
+}
+
+// SplitRequest JSON body of a single item split.
+type SplitRequest struct {
+	CategoryID *uuid.UUID      `json:"category_id,omitempty"`
+	Amount     decimal.Decimal `json:"amount" validate:"required,amount"`
+	Note       *string         `json:"note,omitempty"`
+}
+
+// TransferRequest JSON body for creating a transfer between accounts.
+// DestinationAmount is required when the accounts use different currencies.
+type TransferRequest struct {
+	SourceAccountID      uuid.UUID        `json:"source_account_id" validate:"required"`
+	DestinationAccountID uuid.UUID        `json:"destination_account_id" validate:"required"`
+	Title                string           `json:"title" validate:"required"`
+	Amount               decimal.Decimal  `json:"amount" validate:"required,amount"`
+	DestinationAmount    *decimal.Decimal `json:"destination_amount,omitempty" validate:"omitempty,amount"`
+	OccurredAt           time.Time        `json:"occurred_at" validate:"required,not_future"`
+	Metadata             json.RawMessage  `json:"metadata,omitempty" validate:"omitempty,json_object"`
+}
+
+// MergeRequest JSON body for merging duplicates into an item.
+type MergeRequest struct {
+	DuplicateIDs []uuid.UUID `json:"duplicate_ids" validate:"required,min=1"`
+}
+
+// ReplaceSplitsRequest JSON body for replacing item splits.
+type ReplaceSplitsRequest struct {
+	Splits []SplitRequest `json:"splits" validate:"dive"`
 }
 
 // Create handles POST /items.
 func (h *Handler) Create(c *ginext.Context) {
 	var req CreateRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		zlog.Logger.Error().Err(err).Msg("failed to bind create request")
+		logging.Ctx(c.Request.Context()).Error().Err(err).Msg("failed to bind create request")
 		response.Fail(c, http.StatusBadRequest, fmt.Errorf("invalid request body"))
 		return
 	}
 
 	if err := h.validator.Struct(req); err != nil {
-		zlog.Logger.Error().Err(err).Msg("failed to validate request")
-		response.Fail(c, http.StatusBadRequest, fmt.Errorf("validation error: %s", err.Error()))
+		logging.Ctx(c.Request.Context()).Error().Err(err).Msg("failed to validate request")
+		response.ValidationFail(c, validation.Fields(err))
 		return
 	}
 
@@ -91,10 +164,30 @@ func (h *Handler) Create(c *ginext.Context) {
 		req.Metadata = json.RawMessage(`{}`)
 	}
 
-	id, err := h.service.Create(c.Request.Context(), req.Kind, req.Title, req.Amount, req.Currency, req.OccurredAt, req.CategoryID, req.Metadata)
+	checkDuplicates, err := request.ParseBoolQuery(c, "check_duplicates", false)
 	if err != nil {
-		zlog.Logger.Error().Err(err).Msg("failed to create item")
-		response.Fail(c, http.StatusInternalServerError, fmt.Errorf("internal server error"))
+		response.Fail(c, http.StatusBadRequest, err)
+		return
+	}
+
+	if checkDuplicates {
+		candidates, err := h.service.FindSimilar(c.Request.Context(), req.Title, req.Amount, req.Currency, req.OccurredAt, h.cfg.Duplicates.TimeWindow, h.cfg.Duplicates.TitleSimilarity)
+		if err != nil {
+			response.Error(c, err)
+			return
+		}
+
+		if len(candidates) > 0 {
+			p := response.NewProblem(c, http.StatusConflict, codePossibleDuplicates, "possible duplicate items exist")
+			p.Extensions = map[string]interface{}{"candidates": candidates}
+			response.WriteProblem(c, p)
+			return
+		}
+	}
+
+	id, err := h.service.Create(c.Request.Context(), req.Kind, req.Title, req.Amount, req.Currency, req.OccurredAt, req.CategoryID, req.AccountID, req.RefundOf, req.Metadata, toSplits(req.Splits))
+	if err != nil {
+		response.Error(c, err)
 		return
 	}
 
@@ -111,14 +204,7 @@ func (h *Handler) GetByID(c *ginext.Context) {
 
 	i, err := h.service.GetByID(c.Request.Context(), id)
 	if err != nil {
-		if errors.Is(err, item.ErrItemNotFound) {
-			zlog.Logger.Error().Err(err).Msg("item not found")
-			response.Fail(c, http.StatusNotFound, err)
-			return
-		}
-
-		zlog.Logger.Error().Err(err).Msg("failed to get item")
-		response.Fail(c, http.StatusInternalServerError, fmt.Errorf("internal server error"))
+		response.Error(c, err)
 		return
 	}
 
@@ -145,7 +231,29 @@ func (h *Handler) List(c *ginext.Context) {
 		return
 	}
 
-	kind := request.ParseStringQueryPtr(c, "kind")
+	kind, err := request.ParseKindQuery(c, "kind")
+	if err != nil {
+		response.Fail(c, http.StatusBadRequest, err)
+		return
+	}
+
+	accountID, err := request.ParseUUIDQuery(c, "account_id")
+	if err != nil {
+		response.Fail(c, http.StatusBadRequest, err)
+		return
+	}
+
+	tags, err := request.ParseTagFilter(c)
+	if err != nil {
+		response.Fail(c, http.StatusBadRequest, err)
+		return
+	}
+
+	reconciled, err := request.ParseBoolQueryPtr(c, "reconciled")
+	if err != nil {
+		response.Fail(c, http.StatusBadRequest, err)
+		return
+	}
 
 	limit, err := request.ParseIntQuery(c, "limit", 20) // default = 20
 	if err != nil {
@@ -161,10 +269,9 @@ func (h *Handler) List(c *ginext.Context) {
 
 	sortBy := request.ParseStringQuery(c, "sort_by", "occurred_at")
 
-	items, err := h.service.List(c.Request.Context(), from, to, categoryID, kind, limit, offset, sortBy)
+	items, err := h.service.List(c.Request.Context(), from, to, categoryID, kind, accountID, tags, reconciled, limit, offset, sortBy)
 	if err != nil {
-		zlog.Logger.Error().Err(err).Msg("failed to list items")
-		response.Fail(c, http.StatusInternalServerError, fmt.Errorf("internal server error"))
+		response.Error(c, err)
 		return
 	}
 
@@ -181,14 +288,14 @@ func (h *Handler) Update(c *ginext.Context) {
 
 	var req UpdateRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		zlog.Logger.Error().Err(err).Msg("failed to bind update request")
+		logging.Ctx(c.Request.Context()).Error().Err(err).Msg("failed to bind update request")
 		response.Fail(c, http.StatusBadRequest, fmt.Errorf("invalid request body"))
 		return
 	}
 
 	if err := h.validator.Struct(req); err != nil {
-		zlog.Logger.Error().Err(err).Msg("failed to validate request")
-		response.Fail(c, http.StatusBadRequest, fmt.Errorf("validation error: %s", err.Error()))
+		logging.Ctx(c.Request.Context()).Error().Err(err).Msg("failed to validate request")
+		response.ValidationFail(c, validation.Fields(err))
 		return
 	}
 
@@ -196,15 +303,8 @@ func (h *Handler) Update(c *ginext.Context) {
 		req.Metadata = json.RawMessage(`{}`)
 	}
 
-	if err := h.service.Update(c.Request.Context(), id, req.Kind, req.Title, req.Amount, req.Currency, req.OccurredAt, req.CategoryID, req.Metadata); err != nil {
-		if errors.Is(err, item.ErrItemNotFound) {
-			zlog.Logger.Error().Err(err).Msg("item not found")
-			response.Fail(c, http.StatusNotFound, err)
-			return
-		}
-
-		zlog.Logger.Error().Err(err).Msg("failed to update item")
-		response.Fail(c, http.StatusInternalServerError, fmt.Errorf("internal server error"))
+	if err := h.service.Update(c.Request.Context(), id, req.Kind, req.Title, req.Amount, req.Currency, req.OccurredAt, req.CategoryID, req.AccountID, req.RefundOf, req.Metadata, toSplits(req.Splits)); err != nil {
+		response.Error(c, err)
 		return
 	}
 
@@ -220,16 +320,287 @@ func (h *Handler) Delete(c *ginext.Context) {
 	}
 
 	if err := h.service.Delete(c.Request.Context(), id); err != nil {
-		if errors.Is(err, item.ErrItemNotFound) {
-			zlog.Logger.Error().Err(err).Msg("item not found")
-			response.Fail(c, http.StatusNotFound, err)
+		response.Error(c, err)
+		return
+	}
+
+	response.OK(c, map[string]string{"message": "item deleted"})
+}
+
+// ListSplits handles GET /items/:id/splits.
+func (h *Handler) ListSplits(c *ginext.Context) {
+	id, err := request.ParseUUIDParam(c, "id")
+	if err != nil {
+		response.Fail(c, http.StatusBadRequest, err)
+		return
+	}
+
+	splits, err := h.service.ListSplits(c.Request.Context(), id)
+	if err != nil {
+		response.Error(c, err)
+		return
+	}
+
+	response.OK(c, map[string][]model.ItemSplit{"splits": splits})
+}
+
+// ReplaceSplits handles PUT /items/:id/splits.
+func (h *Handler) ReplaceSplits(c *ginext.Context) {
+	id, err := request.ParseUUIDParam(c, "id")
+	if err != nil {
+		response.Fail(c, http.StatusBadRequest, err)
+		return
+	}
+
+	var req ReplaceSplitsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		logging.Ctx(c.Request.Context()).Error().Err(err).Msg("failed to bind splits request")
+		response.Fail(c, http.StatusBadRequest, fmt.Errorf("invalid request body"))
+		return
+	}
+
+	if err := h.validator.Struct(req); err != nil {
+		logging.Ctx(c.Request.Context()).Error().Err(err).Msg("failed to validate request")
+		response.ValidationFail(c, validation.Fields(err))
+		return
+	}
+
+	splits := toSplits(req.Splits)
+	if splits == nil {
+		splits = []model.ItemSplit{}
+	}
+
+	h.replaceSplits(c, id, splits, "item splits updated")
+}
+
+// DeleteSplits handles DELETE /items/:id/splits.
+func (h *Handler) DeleteSplits(c *ginext.Context) {
+	id, err := request.ParseUUIDParam(c, "id")
+	if err != nil {
+		response.Fail(c, http.StatusBadRequest, err)
+		return
+	}
+
+	h.replaceSplits(c, id, []model.ItemSplit{}, "item splits deleted")
+}
+
+// replaceSplits replaces item splits and writes the response.
+func (h *Handler) replaceSplits(c *ginext.Context, id uuid.UUID, splits []model.ItemSplit, message string) {
+	if err := h.service.ReplaceSplits(c.Request.Context(), id, splits); err != nil {
+		response.Error(c, err)
+		return
+	}
+
+	response.OK(c, map[string]string{"message": message})
+}
+
+// Refunds handles GET /items/:id/refunds.
+func (h *Handler) Refunds(c *ginext.Context) {
+	id, err := request.ParseUUIDParam(c, "id")
+	if err != nil {
+		response.Fail(c, http.StatusBadRequest, err)
+		return
+	}
+
+	summary, err := h.service.Refunds(c.Request.Context(), id)
+	if err != nil {
+		response.Error(c, err)
+		return
+	}
+
+	response.OK(c, map[string]*model.RefundSummary{"refunds": summary})
+}
+
+// CreateTransfer handles POST /transfers.
+func (h *Handler) CreateTransfer(c *ginext.Context) {
+	var req TransferRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		logging.Ctx(c.Request.Context()).Error().Err(err).Msg("failed to bind transfer request")
+		response.Fail(c, http.StatusBadRequest, fmt.Errorf("invalid request body"))
+		return
+	}
+
+	if err := h.validator.Struct(req); err != nil {
+		logging.Ctx(c.Request.Context()).Error().Err(err).Msg("failed to validate request")
+		response.ValidationFail(c, validation.Fields(err))
+		return
+	}
+
+	if len(req.Metadata) == 0 {
+		req.Metadata = json.RawMessage(`{}`)
+	}
+
+	transfer, err := h.service.CreateTransfer(c.Request.Context(), req.SourceAccountID, req.DestinationAccountID, req.Title, req.Amount, req.DestinationAmount, req.OccurredAt, req.Metadata)
+	if err != nil {
+		response.Error(c, err)
+		return
+	}
+
+	response.Created(c, map[string]*model.Transfer{"transfer": transfer})
+}
+
+// Duplicates handles GET /items/duplicates.
+func (h *Handler) Duplicates(c *ginext.Context) {
+	from, err := request.ParseTimeQuery(c, "from", time.RFC3339)
+	if err != nil {
+		response.Fail(c, http.StatusBadRequest, err)
+		return
+	}
+
+	to, err := request.ParseTimeQuery(c, "to", time.RFC3339)
+	if err != nil {
+		response.Fail(c, http.StatusBadRequest, err)
+		return
+	}
+
+	window, err := request.ParseDurationQuery(c, "window", h.cfg.Duplicates.TimeWindow)
+	if err != nil {
+		response.Fail(c, http.StatusBadRequest, err)
+		return
+	}
+
+	if window < 0 {
+		response.Fail(c, http.StatusBadRequest, fmt.Errorf("window must not be negative"))
+		return
+	}
+
+	similarity, err := request.ParseFloatQuery(c, "similarity", h.cfg.Duplicates.TitleSimilarity)
+	if err != nil {
+		response.Fail(c, http.StatusBadRequest, err)
+		return
+	}
+
+	if similarity < 0 || similarity > 1 {
+		response.Fail(c, http.StatusBadRequest, fmt.Errorf("similarity must be between 0 and 1"))
+		return
+	}
+
+	clusters, err := h.service.Duplicates(c.Request.Context(), from, to, window, similarity)
+	if err != nil {
+		response.Error(c, err)
+		return
+	}
+
+	response.OK(c, map[string][]model.DuplicateCluster{"clusters": clusters})
+}
+
+// Merge handles POST /items/:id/merge.
+func (h *Handler) Merge(c *ginext.Context) {
+	id, err := request.ParseUUIDParam(c, "id")
+	if err != nil {
+		response.Fail(c, http.StatusBadRequest, err)
+		return
+	}
+
+	var req MergeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		logging.Ctx(c.Request.Context()).Error().Err(err).Msg("failed to bind merge request")
+		response.Fail(c, http.StatusBadRequest, fmt.Errorf("invalid request body"))
+		return
+	}
+
+	if err := h.validator.Struct(req); err != nil {
+		logging.Ctx(c.Request.Context()).Error().Err(err).Msg("failed to validate request")
+		response.ValidationFail(c, validation.Fields(err))
+		return
+	}
+
+	merged, err := h.service.Merge(c.Request.Context(), id, req.DuplicateIDs)
+	if err != nil {
+		response.Error(c, err)
+		return
+	}
+
+	response.OK(c, map[string]*model.Item{"item": merged})
+}
+
+// Import handles POST /items/import?format=ofx|qif|camt053|csv.
+// The file is read from the multipart form field "file" or, for other content
+// types, from the raw request body. Optional query parameters are account_id
+// and currency.
+func (h *Handler) Import(c *ginext.Context) {
+	format := strings.ToLower(c.Query("format"))
+	if format == "" {
+		response.Fail(c, http.StatusBadRequest, fmt.Errorf("format is required"))
+		return
+	}
+
+	accountID, err := request.ParseUUIDQuery(c, "account_id")
+	if err != nil {
+		response.Fail(c, http.StatusBadRequest, err)
+		return
+	}
+
+	var body io.Reader
+	if strings.HasPrefix(c.ContentType(), "multipart/form-data") {
+		c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, h.cfg.Import.MaxFileSize+multipartOverhead)
+
+		fh, err := c.FormFile("file")
+		if err != nil {
+			var maxErr *http.MaxBytesError
+			if errors.As(err, &maxErr) {
+				response.Fail(c, http.StatusRequestEntityTooLarge, fmt.Errorf("file is too large"))
+				return
+			}
+
+			logging.Ctx(c.Request.Context()).Error().Err(err).Msg("failed to read uploaded file")
+			response.Fail(c, http.StatusBadRequest, fmt.Errorf("multipart field \"file\" is required"))
 			return
 		}
 
-		zlog.Logger.Error().Err(err).Msg("failed to delete item")
-		response.Fail(c, http.StatusInternalServerError, fmt.Errorf("internal server error"))
+		if fh.Size > h.cfg.Import.MaxFileSize {
+			response.Fail(c, http.StatusRequestEntityTooLarge, fmt.Errorf("file is too large"))
+			return
+		}
+
+		f, err := fh.Open()
+		if err != nil {
+			response.Error(c, err)
+			return
+		}
+		defer func() { _ = f.Close() }()
+
+		body = f
+	} else {
+		data, err := io.ReadAll(http.MaxBytesReader(c.Writer, c.Request.Body, h.cfg.Import.MaxFileSize))
+		if err != nil {
+			var maxErr *http.MaxBytesError
+			if errors.As(err, &maxErr) {
+				response.Fail(c, http.StatusRequestEntityTooLarge, fmt.Errorf("file is too large"))
+				return
+			}
+
+			logging.Ctx(c.Request.Context()).Error().Err(err).Msg("failed to read request body")
+			response.Fail(c, http.StatusBadRequest, fmt.Errorf("invalid request body"))
+			return
+		}
+
+		body = bytes.NewReader(data)
+	}
+
+	report, err := h.service.Import(c.Request.Context(), format, body, accountID, c.Query("currency"))
+	if err != nil {
+		response.Error(c, err)
 		return
 	}
 
-	response.OK(c, map[string]string{"message": "item deleted"})
+	response.OK(c, map[string]*model.ImportReport{"report": report})
+}
+
+// toSplits converts split requests to models, preserving nil.
+func toSplits(reqs []SplitRequest) []model.ItemSplit {
+	if reqs == nil {
+		return nil
+	}
+
+	splits := make([]model.ItemSplit, 0, len(reqs))
+	for _, r := range reqs {
+		splits = append(splits, model.ItemSplit{
+			CategoryID: r.CategoryID,
+			Amount:     r.Amount,
+			Note:       r.Note,
+		})
+	}
+
+	return splits
 }
@@ -0,0 +1,158 @@
+package job
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/google/uuid"
+	"github.com/wb-go/wbf/ginext"
+
+	"github.com/aliskhannn/sales-tracker/internal/api/request"
+	"github.com/aliskhannn/sales-tracker/internal/api/response"
+	"github.com/aliskhannn/sales-tracker/internal/config"
+	"github.com/aliskhannn/sales-tracker/internal/logging"
+	"github.com/aliskhannn/sales-tracker/internal/model"
+	"github.com/aliskhannn/sales-tracker/internal/validation"
+)
+
+// defaultListLimit is the number of jobs listed when no limit is given.
+const defaultListLimit = 50
+
+// maxListLimit bounds the limit query parameter of the job list.
+const maxListLimit = 500
+
+// service defines business logic for analytics jobs.
+type service interface {
+	// Create validates a report and enqueues a job computing it.
+	Create(ctx context.Context, spec model.ReportSpec) (*model.AnalyticsJob, error)
+
+	// GetByID returns a job with its result by its ID.
+	GetByID(ctx context.Context, id uuid.UUID) (*model.AnalyticsJob, error)
+
+	// List returns the latest jobs without their results.
+	List(ctx context.Context, limit int) ([]model.AnalyticsJob, error)
+
+	// Cancel cancels a queued or running job.
+	Cancel(ctx context.Context, id uuid.UUID) (*model.AnalyticsJob, error)
+}
+
+// Handler defines HTTP layer for analytics jobs.
+type Handler struct {
+	service   service
+	validator *validator.Validate
+	cfg       *config.Config
+}
+
+// NewHandler creates a new analytics job handler.
+func NewHandler(s service, v *validator.Validate, cfg *config.Config) *Handler {
+	return &Handler{service: s, validator: v, cfg: cfg}
+}
+
+// CreateRequest JSON body for enqueuing a report. Filters are those of the
+// analytics endpoints; Percentile defaults to the configured percentile.
+type CreateRequest struct {
+	Metrics    []string   `json:"metrics" validate:"required,min=1"`
+	From       *time.Time `json:"from,omitempty"`
+	To         *time.Time `json:"to,omitempty"`
+	CategoryID *uuid.UUID `json:"category_id,omitempty"`
+	Kind       *string    `json:"kind,omitempty" validate:"omitempty,item_kind"`
+	AccountID  *uuid.UUID `json:"account_id,omitempty"`
+	Tags       []string   `json:"tags,omitempty"`
+	TagsMatch  string     `json:"tags_match,omitempty" validate:"omitempty,oneof=any all"`
+	Percentile *float64   `json:"percentile,omitempty"`
+	GroupBy    string     `json:"group_by,omitempty"`
+}
+
+// Create handles POST /analytics/jobs.
+func (h *Handler) Create(c *ginext.Context) {
+	var req CreateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		logging.Ctx(c.Request.Context()).Error().Err(err).Msg("failed to bind create request")
+		response.Fail(c, http.StatusBadRequest, fmt.Errorf("invalid request body"))
+		return
+	}
+
+	if err := h.validator.Struct(req); err != nil {
+		logging.Ctx(c.Request.Context()).Error().Err(err).Msg("failed to validate request")
+		response.ValidationFail(c, validation.Fields(err))
+		return
+	}
+
+	spec := model.ReportSpec{
+		Metrics:    req.Metrics,
+		From:       req.From,
+		To:         req.To,
+		CategoryID: req.CategoryID,
+		Kind:       req.Kind,
+		AccountID:  req.AccountID,
+		Tags:       request.TagFilter(req.Tags, req.TagsMatch == "all"),
+		Percentile: h.cfg.Analytics.PercentileDefault,
+		GroupBy:    req.GroupBy,
+	}
+	if req.Percentile != nil {
+		spec.Percentile = *req.Percentile
+	}
+
+	j, err := h.service.Create(c.Request.Context(), spec)
+	if err != nil {
+		response.Error(c, err)
+		return
+	}
+
+	c.Header("Location", "/api/analytics/jobs/"+j.ID.String())
+	response.Accepted(c, map[string]*model.AnalyticsJob{"job": j})
+}
+
+// List handles GET /analytics/jobs.
+func (h *Handler) List(c *ginext.Context) {
+	limit, err := request.ParseIntQuery(c, "limit", defaultListLimit)
+	if err != nil || limit <= 0 || limit > maxListLimit {
+		response.Fail(c, http.StatusBadRequest, fmt.Errorf("limit must be between 1 and %d", maxListLimit))
+		return
+	}
+
+	jobs, err := h.service.List(c.Request.Context(), limit)
+	if err != nil {
+		response.Error(c, err)
+		return
+	}
+
+	response.OK(c, map[string][]model.AnalyticsJob{"jobs": jobs})
+}
+
+// GetByID handles GET /analytics/jobs/:id.
+func (h *Handler) GetByID(c *ginext.Context) {
+	id, err := request.ParseUUIDParam(c, "id")
+	if err != nil {
+		response.Fail(c, http.StatusBadRequest, err)
+		return
+	}
+
+	j, err := h.service.GetByID(c.Request.Context(), id)
+	if err != nil {
+		response.Error(c, err)
+		return
+	}
+
+	response.OK(c, map[string]*model.AnalyticsJob{"job": j})
+}
+
+// Cancel handles POST /analytics/jobs/:id/cancel.
+func (h *Handler) Cancel(c *ginext.Context) {
+	id, err := request.ParseUUIDParam(c, "id")
+	if err != nil {
+		response.Fail(c, http.StatusBadRequest, err)
+		return
+	}
+
+	j, err := h.service.Cancel(c.Request.Context(), id)
+	if err != nil {
+		response.Error(c, err)
+		return
+	}
+
+	response.OK(c, map[string]*model.AnalyticsJob{"job": j})
+}
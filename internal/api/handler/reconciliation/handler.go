@@ -0,0 +1,247 @@
+package reconciliation
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/wb-go/wbf/ginext"
+
+	"github.com/aliskhannn/sales-tracker/internal/api/request"
+	"github.com/aliskhannn/sales-tracker/internal/api/response"
+	"github.com/aliskhannn/sales-tracker/internal/config"
+	"github.com/aliskhannn/sales-tracker/internal/logging"
+	"github.com/aliskhannn/sales-tracker/internal/model"
+)
+
+// multipartOverhead is the allowance for multipart headers and boundaries on
+// top of the max statement size when limiting the request body.
+const multipartOverhead = 1 << 20
+
+// service defines business logic for bank statement reconciliation.
+type service interface {
+	// Upload parses a statement, stores it as a session and auto-matches its lines.
+	Upload(ctx context.Context, format, filename string, r io.Reader, accountID *uuid.UUID, currency string) (*model.ReconciliationSession, error)
+
+	// Get returns a session with its lines.
+	Get(ctx context.Context, id uuid.UUID) (*model.ReconciliationSession, error)
+
+	// List returns all sessions with their line summaries.
+	List(ctx context.Context) ([]model.ReconciliationSession, error)
+
+	// AutoMatch proposes items for the unmatched lines of a session.
+	AutoMatch(ctx context.Context, id uuid.UUID) (*model.ReconciliationSession, error)
+
+	// Confirm confirms the match of a line and marks the item as reconciled.
+	Confirm(ctx context.Context, sessionID, lineID uuid.UUID, itemID *uuid.UUID) (*model.ReconciliationLine, error)
+
+	// Unmatch removes the item of a line and clears its reconciliation.
+	Unmatch(ctx context.Context, sessionID, lineID uuid.UUID) (*model.ReconciliationLine, error)
+
+	// CreateItem creates the item missing for a line and marks it as reconciled.
+	CreateItem(ctx context.Context, sessionID, lineID uuid.UUID, categoryID *uuid.UUID) (*model.ReconciliationLine, error)
+}
+
+// Handler defines HTTP layer for bank statement reconciliation.
+type Handler struct {
+	service service
+	cfg     *config.Config
+}
+
+// NewHandler creates a new reconciliation handler.
+func NewHandler(s service, cfg *config.Config) *Handler {
+	return &Handler{service: s, cfg: cfg}
+}
+
+// ConfirmRequest optional JSON body for confirming a line.
+// Without ItemID the item proposed by auto-matching is confirmed.
+type ConfirmRequest struct {
+	ItemID *uuid.UUID `json:"item_id,omitempty"`
+}
+
+// CreateItemRequest optional JSON body for creating an item from a line.
+type CreateItemRequest struct {
+	CategoryID *uuid.UUID `json:"category_id,omitempty"`
+}
+
+// Upload handles POST /reconciliations (multipart form fields "file", "format"
+// and optional "account_id" and "currency").
+func (h *Handler) Upload(c *ginext.Context) {
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, h.cfg.Reconciliation.MaxFileSize+multipartOverhead)
+
+	fh, err := c.FormFile("file")
+	if err != nil {
+		var maxErr *http.MaxBytesError
+		if errors.As(err, &maxErr) {
+			response.Fail(c, http.StatusRequestEntityTooLarge, fmt.Errorf("file is too large"))
+			return
+		}
+
+		logging.Ctx(c.Request.Context()).Error().Err(err).Msg("failed to read uploaded statement")
+		response.Fail(c, http.StatusBadRequest, fmt.Errorf("multipart field \"file\" is required"))
+		return
+	}
+
+	if fh.Size > h.cfg.Reconciliation.MaxFileSize {
+		response.Fail(c, http.StatusRequestEntityTooLarge, fmt.Errorf("file is too large"))
+		return
+	}
+
+	var accountID *uuid.UUID
+	if v := c.PostForm("account_id"); v != "" {
+		id, err := uuid.Parse(v)
+		if err != nil {
+			response.Fail(c, http.StatusBadRequest, fmt.Errorf("invalid account_id"))
+			return
+		}
+
+		accountID = &id
+	}
+
+	f, err := fh.Open()
+	if err != nil {
+		response.Error(c, err)
+		return
+	}
+	defer func() { _ = f.Close() }()
+
+	format := strings.ToLower(c.PostForm("format"))
+	session, err := h.service.Upload(c.Request.Context(), format, fh.Filename, f, accountID, c.PostForm("currency"))
+	if err != nil {
+		response.Error(c, err)
+		return
+	}
+
+	response.Created(c, map[string]*model.ReconciliationSession{"session": session})
+}
+
+// List handles GET /reconciliations.
+func (h *Handler) List(c *ginext.Context) {
+	sessions, err := h.service.List(c.Request.Context())
+	if err != nil {
+		response.Error(c, err)
+		return
+	}
+
+	response.OK(c, map[string][]model.ReconciliationSession{"sessions": sessions})
+}
+
+// GetByID handles GET /reconciliations/:id.
+func (h *Handler) GetByID(c *ginext.Context) {
+	id, err := request.ParseUUIDParam(c, "id")
+	if err != nil {
+		response.Fail(c, http.StatusBadRequest, err)
+		return
+	}
+
+	session, err := h.service.Get(c.Request.Context(), id)
+	if err != nil {
+		response.Error(c, err)
+		return
+	}
+
+	response.OK(c, map[string]*model.ReconciliationSession{"session": session})
+}
+
+// AutoMatch handles POST /reconciliations/:id/auto-match.
+func (h *Handler) AutoMatch(c *ginext.Context) {
+	id, err := request.ParseUUIDParam(c, "id")
+	if err != nil {
+		response.Fail(c, http.StatusBadRequest, err)
+		return
+	}
+
+	session, err := h.service.AutoMatch(c.Request.Context(), id)
+	if err != nil {
+		response.Error(c, err)
+		return
+	}
+
+	response.OK(c, map[string]*model.ReconciliationSession{"session": session})
+}
+
+// Confirm handles POST /reconciliations/:id/lines/:line_id/confirm.
+func (h *Handler) Confirm(c *ginext.Context) {
+	sessionID, lineID, ok := parseLineParams(c)
+	if !ok {
+		return
+	}
+
+	var req ConfirmRequest
+	if c.Request.ContentLength != 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			response.Fail(c, http.StatusBadRequest, fmt.Errorf("invalid request body"))
+			return
+		}
+	}
+
+	l, err := h.service.Confirm(c.Request.Context(), sessionID, lineID, req.ItemID)
+	if err != nil {
+		response.Error(c, err)
+		return
+	}
+
+	response.OK(c, map[string]*model.ReconciliationLine{"line": l})
+}
+
+// Unmatch handles POST /reconciliations/:id/lines/:line_id/unmatch.
+func (h *Handler) Unmatch(c *ginext.Context) {
+	sessionID, lineID, ok := parseLineParams(c)
+	if !ok {
+		return
+	}
+
+	l, err := h.service.Unmatch(c.Request.Context(), sessionID, lineID)
+	if err != nil {
+		response.Error(c, err)
+		return
+	}
+
+	response.OK(c, map[string]*model.ReconciliationLine{"line": l})
+}
+
+// CreateItem handles POST /reconciliations/:id/lines/:line_id/create.
+func (h *Handler) CreateItem(c *ginext.Context) {
+	sessionID, lineID, ok := parseLineParams(c)
+	if !ok {
+		return
+	}
+
+	var req CreateItemRequest
+	if c.Request.ContentLength != 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			response.Fail(c, http.StatusBadRequest, fmt.Errorf("invalid request body"))
+			return
+		}
+	}
+
+	l, err := h.service.CreateItem(c.Request.Context(), sessionID, lineID, req.CategoryID)
+	if err != nil {
+		response.Error(c, err)
+		return
+	}
+
+	response.Created(c, map[string]*model.ReconciliationLine{"line": l})
+}
+
+// parseLineParams parses the session and line IDs from the path. It writes
+// the error response and reports false if either is invalid.
+func parseLineParams(c *ginext.Context) (sessionID, lineID uuid.UUID, ok bool) {
+	sessionID, err := request.ParseUUIDParam(c, "id")
+	if err != nil {
+		response.Fail(c, http.StatusBadRequest, err)
+		return uuid.Nil, uuid.Nil, false
+	}
+
+	lineID, err = request.ParseUUIDParam(c, "line_id")
+	if err != nil {
+		response.Fail(c, http.StatusBadRequest, err)
+		return uuid.Nil, uuid.Nil, false
+	}
+
+	return sessionID, lineID, true
+}
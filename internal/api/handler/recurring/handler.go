@@ -0,0 +1,273 @@
+package recurring
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+	"github.com/wb-go/wbf/ginext"
+
+	"github.com/aliskhannn/sales-tracker/internal/api/request"
+	"github.com/aliskhannn/sales-tracker/internal/api/response"
+	"github.com/aliskhannn/sales-tracker/internal/logging"
+	"github.com/aliskhannn/sales-tracker/internal/model"
+	"github.com/aliskhannn/sales-tracker/internal/validation"
+)
+
+// service defines business logic for recurring items.
+type service interface {
+	// Create adds a new recurring item with the given template and schedule.
+	Create(ctx context.Context, kind, title string, amount decimal.Decimal, currency string, categoryID *uuid.UUID, metadata json.RawMessage, rule string, startAt time.Time, endAt *time.Time) (uuid.UUID, error)
+
+	// GetByID returns a recurring item by its ID.
+	GetByID(ctx context.Context, id uuid.UUID) (*model.RecurringItem, error)
+
+	// List returns all recurring items.
+	List(ctx context.Context) ([]model.RecurringItem, error)
+
+	// Update replaces the template and schedule of a recurring item.
+	Update(ctx context.Context, id uuid.UUID, kind, title string, amount decimal.Decimal, currency string, categoryID *uuid.UUID, metadata json.RawMessage, rule string, startAt time.Time, endAt *time.Time) error
+
+	// Delete removes a recurring item by its ID.
+	Delete(ctx context.Context, id uuid.UUID) error
+
+	// Pause suspends materialization of a recurring item.
+	Pause(ctx context.Context, id uuid.UUID) error
+
+	// Resume re-enables materialization of a paused recurring item.
+	Resume(ctx context.Context, id uuid.UUID) error
+
+	// Skip marks a single occurrence as skipped; nil skips the next one.
+	Skip(ctx context.Context, id uuid.UUID, at *time.Time) (time.Time, error)
+
+	// ListOccurrences returns handled occurrences of a recurring item.
+	ListOccurrences(ctx context.Context, id uuid.UUID) ([]model.RecurringOccurrence, error)
+}
+
+// Handler defines HTTP layer for recurring items.
+type Handler struct {
+	service   service
+	validator *validator.Validate
+}
+
+// NewHandler creates a new recurring item handler.
+func NewHandler(s service, v *validator.Validate) *Handler {
+	return &Handler{service: s, validator: v}
+}
+
+// CreateRequest JSON body for creating a recurring item.
+type CreateRequest struct {
+	Kind       string          `json:"kind" validate:"required,item_kind"`
+	Title      string          `json:"title" validate:"required"`
+	Amount     decimal.Decimal `json:"amount" validate:"required,amount=Currency"`
+	Currency   string          `json:"currency" validate:"required,iso4217"`
+	CategoryID *uuid.UUID      `json:"category_id,omitempty"`
+	Metadata   json.RawMessage `json:"metadata,omitempty" validate:"omitempty,json_object"`
+	Rule       string          `json:"rule" validate:"required"`
+	StartAt    time.Time       `json:"start_at" validate:"required"`
+	EndAt      *time.Time      `json:"end_at,omitempty"`
+}
+
+// UpdateRequest JSON body for updating a recurring item.
+type UpdateRequest struct {
+	Kind       string          `json:"kind" validate:"required,item_kind"`
+	Title      string          `json:"title" validate:"required"`
+	Amount     decimal.Decimal `json:"amount" validate:"required,amount=Currency"`
+	Currency   string          `json:"currency" validate:"required,iso4217"`
+	CategoryID *uuid.UUID      `json:"category_id,omitempty"`
+	Metadata   json.RawMessage `json:"metadata,omitempty" validate:"omitempty,json_object"`
+	Rule       string          `json:"rule" validate:"required"`
+	StartAt    time.Time       `json:"start_at" validate:"required"`
+	EndAt      *time.Time      `json:"end_at,omitempty"`
+}
+
+// SkipRequest JSON body for skipping an occurrence.
+// If OccurrenceAt is omitted, the next pending occurrence is skipped.
+type SkipRequest struct {
+	OccurrenceAt *time.Time `json:"occurrence_at,omitempty"`
+}
+
+// Create handles POST /recurring-items.
+func (h *Handler) Create(c *ginext.Context) {
+	var req CreateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		logging.Ctx(c.Request.Context()).Error().Err(err).Msg("failed to bind create request")
+		response.Fail(c, http.StatusBadRequest, fmt.Errorf("invalid request body"))
+		return
+	}
+
+	if err := h.validator.Struct(req); err != nil {
+		logging.Ctx(c.Request.Context()).Error().Err(err).Msg("failed to validate request")
+		response.ValidationFail(c, validation.Fields(err))
+		return
+	}
+
+	if len(req.Metadata) == 0 {
+		req.Metadata = json.RawMessage(`{}`)
+	}
+
+	id, err := h.service.Create(c.Request.Context(), req.Kind, req.Title, req.Amount, req.Currency, req.CategoryID, req.Metadata, req.Rule, req.StartAt, req.EndAt)
+	if err != nil {
+		response.Error(c, err)
+		return
+	}
+
+	response.Created(c, map[string]string{"id": id.String()})
+}
+
+// GetByID handles GET /recurring-items/:id.
+func (h *Handler) GetByID(c *ginext.Context) {
+	id, err := request.ParseUUIDParam(c, "id")
+	if err != nil {
+		response.Fail(c, http.StatusBadRequest, err)
+		return
+	}
+
+	ri, err := h.service.GetByID(c.Request.Context(), id)
+	if err != nil {
+		response.Error(c, err)
+		return
+	}
+
+	response.OK(c, map[string]*model.RecurringItem{"recurring_item": ri})
+}
+
+// List handles GET /recurring-items.
+func (h *Handler) List(c *ginext.Context) {
+	items, err := h.service.List(c.Request.Context())
+	if err != nil {
+		response.Error(c, err)
+		return
+	}
+
+	response.OK(c, map[string][]model.RecurringItem{"recurring_items": items})
+}
+
+// Update handles PUT /recurring-items/:id.
+func (h *Handler) Update(c *ginext.Context) {
+	id, err := request.ParseUUIDParam(c, "id")
+	if err != nil {
+		response.Fail(c, http.StatusBadRequest, err)
+		return
+	}
+
+	var req UpdateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		logging.Ctx(c.Request.Context()).Error().Err(err).Msg("failed to bind update request")
+		response.Fail(c, http.StatusBadRequest, fmt.Errorf("invalid request body"))
+		return
+	}
+
+	if err := h.validator.Struct(req); err != nil {
+		logging.Ctx(c.Request.Context()).Error().Err(err).Msg("failed to validate request")
+		response.ValidationFail(c, validation.Fields(err))
+		return
+	}
+
+	if len(req.Metadata) == 0 {
+		req.Metadata = json.RawMessage(`{}`)
+	}
+
+	if err := h.service.Update(c.Request.Context(), id, req.Kind, req.Title, req.Amount, req.Currency, req.CategoryID, req.Metadata, req.Rule, req.StartAt, req.EndAt); err != nil {
+		response.Error(c, err)
+		return
+	}
+
+	response.OK(c, map[string]string{"message": "recurring item updated"})
+}
+
+// Delete handles DELETE /recurring-items/:id.
+func (h *Handler) Delete(c *ginext.Context) {
+	id, err := request.ParseUUIDParam(c, "id")
+	if err != nil {
+		response.Fail(c, http.StatusBadRequest, err)
+		return
+	}
+
+	if err := h.service.Delete(c.Request.Context(), id); err != nil {
+		response.Error(c, err)
+		return
+	}
+
+	response.OK(c, map[string]string{"message": "recurring item deleted"})
+}
+
+// Pause handles POST /recurring-items/:id/pause.
+func (h *Handler) Pause(c *ginext.Context) {
+	id, err := request.ParseUUIDParam(c, "id")
+	if err != nil {
+		response.Fail(c, http.StatusBadRequest, err)
+		return
+	}
+
+	if err := h.service.Pause(c.Request.Context(), id); err != nil {
+		response.Error(c, err)
+		return
+	}
+
+	response.OK(c, map[string]string{"message": "recurring item paused"})
+}
+
+// Resume handles POST /recurring-items/:id/resume.
+func (h *Handler) Resume(c *ginext.Context) {
+	id, err := request.ParseUUIDParam(c, "id")
+	if err != nil {
+		response.Fail(c, http.StatusBadRequest, err)
+		return
+	}
+
+	if err := h.service.Resume(c.Request.Context(), id); err != nil {
+		response.Error(c, err)
+		return
+	}
+
+	response.OK(c, map[string]string{"message": "recurring item resumed"})
+}
+
+// Skip handles POST /recurring-items/:id/skip.
+func (h *Handler) Skip(c *ginext.Context) {
+	id, err := request.ParseUUIDParam(c, "id")
+	if err != nil {
+		response.Fail(c, http.StatusBadRequest, err)
+		return
+	}
+
+	var req SkipRequest
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			logging.Ctx(c.Request.Context()).Error().Err(err).Msg("failed to bind skip request")
+			response.Fail(c, http.StatusBadRequest, fmt.Errorf("invalid request body"))
+			return
+		}
+	}
+
+	skipped, err := h.service.Skip(c.Request.Context(), id, req.OccurrenceAt)
+	if err != nil {
+		response.Error(c, err)
+		return
+	}
+
+	response.OK(c, map[string]time.Time{"skipped": skipped})
+}
+
+// ListOccurrences handles GET /recurring-items/:id/occurrences.
+func (h *Handler) ListOccurrences(c *ginext.Context) {
+	id, err := request.ParseUUIDParam(c, "id")
+	if err != nil {
+		response.Fail(c, http.StatusBadRequest, err)
+		return
+	}
+
+	occurrences, err := h.service.ListOccurrences(c.Request.Context(), id)
+	if err != nil {
+		response.Error(c, err)
+		return
+	}
+
+	response.OK(c, map[string][]model.RecurringOccurrence{"occurrences": occurrences})
+}
@@ -0,0 +1,203 @@
+package rule
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/google/uuid"
+	"github.com/wb-go/wbf/ginext"
+
+	"github.com/aliskhannn/sales-tracker/internal/api/request"
+	"github.com/aliskhannn/sales-tracker/internal/api/response"
+	"github.com/aliskhannn/sales-tracker/internal/logging"
+	"github.com/aliskhannn/sales-tracker/internal/model"
+	"github.com/aliskhannn/sales-tracker/internal/validation"
+)
+
+// service defines business logic for auto-categorization rules.
+type service interface {
+	// Create adds a new rule.
+	Create(ctx context.Context, name string, position int, enabled, stopProcessing bool, conditions []model.RuleCondition, actions []model.RuleAction) (uuid.UUID, error)
+
+	// GetByID returns a rule by its ID.
+	GetByID(ctx context.Context, id uuid.UUID) (*model.Rule, error)
+
+	// List returns all rules in evaluation order.
+	List(ctx context.Context) ([]model.Rule, error)
+
+	// Update modifies an existing rule identified by id.
+	Update(ctx context.Context, id uuid.UUID, name string, position int, enabled, stopProcessing bool, conditions []model.RuleCondition, actions []model.RuleAction) error
+
+	// Delete removes a rule by its ID.
+	Delete(ctx context.Context, id uuid.UUID) error
+
+	// ApplyExisting re-runs enabled rules over existing items, optionally as a dry run.
+	ApplyExisting(ctx context.Context, from, to *time.Time, overwrite, dryRun bool) (*model.RuleApplyReport, error)
+}
+
+// Handler defines HTTP layer for rules.
+type Handler struct {
+	service   service
+	validator *validator.Validate
+}
+
+// NewHandler creates a new rule handler.
+func NewHandler(s service, v *validator.Validate) *Handler {
+	return &Handler{service: s, validator: v}
+}
+
+// CreateRequest JSON body for creating a rule. Enabled defaults to true.
+type CreateRequest struct {
+	Name           string                `json:"name" validate:"required"`
+	Position       int                   `json:"position"`
+	Enabled        *bool                 `json:"enabled,omitempty"`
+	StopProcessing bool                  `json:"stop_processing"`
+	Conditions     []model.RuleCondition `json:"conditions" validate:"required"`
+	Actions        []model.RuleAction    `json:"actions" validate:"required"`
+}
+
+// UpdateRequest JSON body for updating a rule. Enabled defaults to true.
+type UpdateRequest struct {
+	Name           string                `json:"name" validate:"required"`
+	Position       int                   `json:"position"`
+	Enabled        *bool                 `json:"enabled,omitempty"`
+	StopProcessing bool                  `json:"stop_processing"`
+	Conditions     []model.RuleCondition `json:"conditions" validate:"required"`
+	Actions        []model.RuleAction    `json:"actions" validate:"required"`
+}
+
+// ApplyRequest JSON body for re-running rules over existing items.
+// DryRun defaults to true so that changes are previewed unless explicitly requested.
+type ApplyRequest struct {
+	From      *time.Time `json:"from,omitempty"`
+	To        *time.Time `json:"to,omitempty"`
+	Overwrite bool       `json:"overwrite"`
+	DryRun    *bool      `json:"dry_run,omitempty"`
+}
+
+// Create handles POST /rules.
+func (h *Handler) Create(c *ginext.Context) {
+	var req CreateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		logging.Ctx(c.Request.Context()).Error().Err(err).Msg("failed to bind create request")
+		response.Fail(c, http.StatusBadRequest, fmt.Errorf("invalid request body"))
+		return
+	}
+
+	if err := h.validator.Struct(req); err != nil {
+		logging.Ctx(c.Request.Context()).Error().Err(err).Msg("failed to validate request")
+		response.ValidationFail(c, validation.Fields(err))
+		return
+	}
+
+	id, err := h.service.Create(c.Request.Context(), req.Name, req.Position, enabled(req.Enabled), req.StopProcessing, req.Conditions, req.Actions)
+	if err != nil {
+		response.Error(c, err)
+		return
+	}
+
+	response.Created(c, map[string]string{"id": id.String()})
+}
+
+// GetByID handles GET /rules/:id.
+func (h *Handler) GetByID(c *ginext.Context) {
+	id, err := request.ParseUUIDParam(c, "id")
+	if err != nil {
+		response.Fail(c, http.StatusBadRequest, err)
+		return
+	}
+
+	r, err := h.service.GetByID(c.Request.Context(), id)
+	if err != nil {
+		response.Error(c, err)
+		return
+	}
+
+	response.OK(c, map[string]*model.Rule{"rule": r})
+}
+
+// List handles GET /rules.
+func (h *Handler) List(c *ginext.Context) {
+	rules, err := h.service.List(c.Request.Context())
+	if err != nil {
+		response.Error(c, err)
+		return
+	}
+
+	response.OK(c, map[string][]model.Rule{"rules": rules})
+}
+
+// Update handles PUT /rules/:id.
+func (h *Handler) Update(c *ginext.Context) {
+	id, err := request.ParseUUIDParam(c, "id")
+	if err != nil {
+		response.Fail(c, http.StatusBadRequest, err)
+		return
+	}
+
+	var req UpdateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		logging.Ctx(c.Request.Context()).Error().Err(err).Msg("failed to bind update request")
+		response.Fail(c, http.StatusBadRequest, fmt.Errorf("invalid request body"))
+		return
+	}
+
+	if err := h.validator.Struct(req); err != nil {
+		logging.Ctx(c.Request.Context()).Error().Err(err).Msg("failed to validate request")
+		response.ValidationFail(c, validation.Fields(err))
+		return
+	}
+
+	if err := h.service.Update(c.Request.Context(), id, req.Name, req.Position, enabled(req.Enabled), req.StopProcessing, req.Conditions, req.Actions); err != nil {
+		response.Error(c, err)
+		return
+	}
+
+	response.OK(c, map[string]string{"message": "rule updated"})
+}
+
+// Delete handles DELETE /rules/:id.
+func (h *Handler) Delete(c *ginext.Context) {
+	id, err := request.ParseUUIDParam(c, "id")
+	if err != nil {
+		response.Fail(c, http.StatusBadRequest, err)
+		return
+	}
+
+	if err := h.service.Delete(c.Request.Context(), id); err != nil {
+		response.Error(c, err)
+		return
+	}
+
+	response.OK(c, map[string]string{"message": "rule deleted"})
+}
+
+// Apply handles POST /rules/apply.
+func (h *Handler) Apply(c *ginext.Context) {
+	var req ApplyRequest
+	if c.Request.ContentLength != 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			logging.Ctx(c.Request.Context()).Error().Err(err).Msg("failed to bind apply request")
+			response.Fail(c, http.StatusBadRequest, fmt.Errorf("invalid request body"))
+			return
+		}
+	}
+
+	dryRun := req.DryRun == nil || *req.DryRun
+
+	report, err := h.service.ApplyExisting(c.Request.Context(), req.From, req.To, req.Overwrite, dryRun)
+	if err != nil {
+		response.Error(c, err)
+		return
+	}
+
+	response.OK(c, map[string]*model.RuleApplyReport{"report": report})
+}
+
+// enabled returns the value of an optional enabled flag, true if omitted.
+func enabled(v *bool) bool {
+	return v == nil || *v
+}
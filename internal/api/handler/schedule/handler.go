@@ -0,0 +1,225 @@
+package schedule
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/google/uuid"
+	"github.com/wb-go/wbf/ginext"
+
+	"github.com/aliskhannn/sales-tracker/internal/api/request"
+	"github.com/aliskhannn/sales-tracker/internal/api/response"
+	"github.com/aliskhannn/sales-tracker/internal/config"
+	"github.com/aliskhannn/sales-tracker/internal/logging"
+	"github.com/aliskhannn/sales-tracker/internal/model"
+	"github.com/aliskhannn/sales-tracker/internal/validation"
+)
+
+// defaultRunsLimit is the number of runs listed when no limit is given.
+const defaultRunsLimit = 50
+
+// maxRunsLimit bounds the limit query parameter of the run history.
+const maxRunsLimit = 500
+
+// service defines business logic for scheduled reports.
+type service interface {
+	// Create validates and saves a scheduled report.
+	Create(ctx context.Context, sr *model.ScheduledReport) (*model.ScheduledReport, error)
+
+	// GetByID returns a scheduled report by its ID.
+	GetByID(ctx context.Context, id uuid.UUID) (*model.ScheduledReport, error)
+
+	// List returns all scheduled reports.
+	List(ctx context.Context) ([]model.ScheduledReport, error)
+
+	// Update replaces the definition of a scheduled report.
+	Update(ctx context.Context, id uuid.UUID, def *model.ScheduledReport) (*model.ScheduledReport, error)
+
+	// Delete removes a scheduled report and its run history by its ID.
+	Delete(ctx context.Context, id uuid.UUID) error
+
+	// ListRuns returns the latest runs of a scheduled report.
+	ListRuns(ctx context.Context, id uuid.UUID, limit int) ([]model.ReportRun, error)
+}
+
+// Handler defines HTTP layer for scheduled reports.
+type Handler struct {
+	service   service
+	validator *validator.Validate
+	cfg       *config.Config
+}
+
+// NewHandler creates a new scheduled report handler.
+func NewHandler(s service, v *validator.Validate, cfg *config.Config) *Handler {
+	return &Handler{service: s, validator: v, cfg: cfg}
+}
+
+// SaveRequest JSON body for creating or replacing a scheduled report. Metrics
+// and filters are those of analytics jobs without a date range: every run
+// covers the last full Range before it. Enabled defaults to true and
+// Percentile to the configured percentile.
+type SaveRequest struct {
+	Name       string     `json:"name" validate:"required,max=200"`
+	Metrics    []string   `json:"metrics" validate:"required,min=1"`
+	CategoryID *uuid.UUID `json:"category_id,omitempty"`
+	Kind       *string    `json:"kind,omitempty" validate:"omitempty,item_kind"`
+	AccountID  *uuid.UUID `json:"account_id,omitempty"`
+	Tags       []string   `json:"tags,omitempty"`
+	TagsMatch  string     `json:"tags_match,omitempty" validate:"omitempty,oneof=any all"`
+	Percentile *float64   `json:"percentile,omitempty"`
+	GroupBy    string     `json:"group_by,omitempty"`
+	Range      string     `json:"range" validate:"required,oneof=day week month quarter year"`
+	Rule       string     `json:"rule" validate:"required"`
+	StartAt    time.Time  `json:"start_at" validate:"required"`
+	Recipients []string   `json:"recipients" validate:"required,min=1,max=50,dive,email"`
+	Enabled    *bool      `json:"enabled,omitempty"`
+}
+
+// Create handles POST /reports.
+func (h *Handler) Create(c *ginext.Context) {
+	def, ok := h.bind(c)
+	if !ok {
+		return
+	}
+
+	sr, err := h.service.Create(c.Request.Context(), def)
+	if err != nil {
+		response.Error(c, err)
+		return
+	}
+
+	response.Created(c, map[string]*model.ScheduledReport{"report": sr})
+}
+
+// List handles GET /reports.
+func (h *Handler) List(c *ginext.Context) {
+	reports, err := h.service.List(c.Request.Context())
+	if err != nil {
+		response.Error(c, err)
+		return
+	}
+
+	response.OK(c, map[string][]model.ScheduledReport{"reports": reports})
+}
+
+// GetByID handles GET /reports/:id.
+func (h *Handler) GetByID(c *ginext.Context) {
+	id, err := request.ParseUUIDParam(c, "id")
+	if err != nil {
+		response.Fail(c, http.StatusBadRequest, err)
+		return
+	}
+
+	sr, err := h.service.GetByID(c.Request.Context(), id)
+	if err != nil {
+		response.Error(c, err)
+		return
+	}
+
+	response.OK(c, map[string]*model.ScheduledReport{"report": sr})
+}
+
+// Update handles PUT /reports/:id.
+func (h *Handler) Update(c *ginext.Context) {
+	id, err := request.ParseUUIDParam(c, "id")
+	if err != nil {
+		response.Fail(c, http.StatusBadRequest, err)
+		return
+	}
+
+	def, ok := h.bind(c)
+	if !ok {
+		return
+	}
+
+	sr, err := h.service.Update(c.Request.Context(), id, def)
+	if err != nil {
+		response.Error(c, err)
+		return
+	}
+
+	response.OK(c, map[string]*model.ScheduledReport{"report": sr})
+}
+
+// Delete handles DELETE /reports/:id.
+func (h *Handler) Delete(c *ginext.Context) {
+	id, err := request.ParseUUIDParam(c, "id")
+	if err != nil {
+		response.Fail(c, http.StatusBadRequest, err)
+		return
+	}
+
+	if err = h.service.Delete(c.Request.Context(), id); err != nil {
+		response.Error(c, err)
+		return
+	}
+
+	response.OK(c, map[string]string{"message": "scheduled report deleted"})
+}
+
+// Runs handles GET /reports/:id/runs.
+func (h *Handler) Runs(c *ginext.Context) {
+	id, err := request.ParseUUIDParam(c, "id")
+	if err != nil {
+		response.Fail(c, http.StatusBadRequest, err)
+		return
+	}
+
+	limit, err := request.ParseIntQuery(c, "limit", defaultRunsLimit)
+	if err != nil || limit <= 0 || limit > maxRunsLimit {
+		response.Fail(c, http.StatusBadRequest, fmt.Errorf("limit must be between 1 and %d", maxRunsLimit))
+		return
+	}
+
+	runs, err := h.service.ListRuns(c.Request.Context(), id, limit)
+	if err != nil {
+		response.Error(c, err)
+		return
+	}
+
+	response.OK(c, map[string][]model.ReportRun{"runs": runs})
+}
+
+// bind binds and validates a SaveRequest into a scheduled report definition.
+// It writes the error response and returns false if the request is invalid.
+func (h *Handler) bind(c *ginext.Context) (*model.ScheduledReport, bool) {
+	var req SaveRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		logging.Ctx(c.Request.Context()).Error().Err(err).Msg("failed to bind save request")
+		response.Fail(c, http.StatusBadRequest, fmt.Errorf("invalid request body"))
+		return nil, false
+	}
+
+	if err := h.validator.Struct(req); err != nil {
+		logging.Ctx(c.Request.Context()).Error().Err(err).Msg("failed to validate request")
+		response.ValidationFail(c, validation.Fields(err))
+		return nil, false
+	}
+
+	def := &model.ScheduledReport{
+		Name: strings.TrimSpace(req.Name),
+		Spec: model.ReportSpec{
+			Metrics:    req.Metrics,
+			CategoryID: req.CategoryID,
+			Kind:       req.Kind,
+			AccountID:  req.AccountID,
+			Tags:       request.TagFilter(req.Tags, req.TagsMatch == "all"),
+			Percentile: h.cfg.Analytics.PercentileDefault,
+			GroupBy:    req.GroupBy,
+		},
+		Range:      req.Range,
+		Rule:       req.Rule,
+		StartAt:    req.StartAt,
+		Recipients: req.Recipients,
+		Enabled:    req.Enabled == nil || *req.Enabled,
+	}
+	if req.Percentile != nil {
+		def.Spec.Percentile = *req.Percentile
+	}
+
+	return def, true
+}
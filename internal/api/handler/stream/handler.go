@@ -0,0 +1,340 @@
+package stream
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/wb-go/wbf/ginext"
+
+	"github.com/aliskhannn/sales-tracker/internal/api/request"
+	"github.com/aliskhannn/sales-tracker/internal/api/response"
+	"github.com/aliskhannn/sales-tracker/internal/config"
+	"github.com/aliskhannn/sales-tracker/internal/logging"
+	"github.com/aliskhannn/sales-tracker/internal/model"
+	srvcstream "github.com/aliskhannn/sales-tracker/internal/service/stream"
+)
+
+// Stream defaults used when the configuration leaves them unset.
+const (
+	defaultHeartbeat         = 15 * time.Second
+	defaultAnalyticsInterval = time.Second
+)
+
+// pendingPollInterval is how often a stream checks whether changes held back
+// by an older running transaction have become stable.
+const pendingPollInterval = 250 * time.Millisecond
+
+// eventTotals is the name of analytics stream events.
+const eventTotals = "totals"
+
+// service defines streaming of item changes and running totals.
+type service interface {
+	// Subscribe subscribes to changes of the workspace of ctx.
+	Subscribe(ctx context.Context) (*srvcstream.Subscription, error)
+
+	// Latest returns the position of the latest stable change to items.
+	Latest(ctx context.Context) (model.ChangeCursor, error)
+
+	// ItemChanges returns the next stable changes after the cursor matching the filter and the cursor to continue from.
+	ItemChanges(ctx context.Context, after model.ChangeCursor, filter *model.ItemFilter) ([]model.ItemChange, model.ChangeCursor, error)
+
+	// Pending reports whether changes after the cursor are committed but not stable yet.
+	Pending(ctx context.Context, after model.ChangeCursor) (bool, error)
+
+	// Totals returns the running totals of items matching the filter.
+	Totals(ctx context.Context, filter *model.ItemFilter) (*model.Totals, error)
+}
+
+// Handler provides Server-Sent Events streams of item changes and totals.
+type Handler struct {
+	service           service
+	heartbeat         time.Duration
+	analyticsInterval time.Duration
+}
+
+// NewHandler creates a new stream handler.
+func NewHandler(s service, cfg *config.Config) *Handler {
+	h := &Handler{
+		service:           s,
+		heartbeat:         cfg.Stream.Heartbeat,
+		analyticsInterval: cfg.Stream.AnalyticsInterval,
+	}
+
+	if h.heartbeat <= 0 {
+		h.heartbeat = defaultHeartbeat
+	}
+	if h.analyticsInterval <= 0 {
+		h.analyticsInterval = defaultAnalyticsInterval
+	}
+
+	return h
+}
+
+// Totals is the data of an analytics stream event.
+type Totals struct {
+	*model.Totals
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// Items handles GET /stream/items. It streams item.created, item.updated and
+// item.deleted events of items matching the filter, starting after the change
+// in Last-Event-ID, or with new changes if it is absent.
+func (h *Handler) Items(c *ginext.Context) {
+	filter, err := parseFilter(c)
+	if err != nil {
+		response.Fail(c, http.StatusBadRequest, err)
+		return
+	}
+
+	lastID, err := lastEventID(c)
+	if err != nil {
+		response.Fail(c, http.StatusBadRequest, err)
+		return
+	}
+
+	ctx := c.Request.Context()
+
+	// Subscribe before reading the latest change, so that no change between
+	// both goes unnoticed.
+	sub, err := h.service.Subscribe(ctx)
+	if err != nil {
+		response.Error(c, err)
+		return
+	}
+	defer sub.Close()
+
+	if lastID == nil {
+		latest, err := h.service.Latest(ctx)
+		if err != nil {
+			response.Error(c, err)
+			return
+		}
+
+		lastID = &latest
+	}
+
+	w := openStream(c)
+	heartbeat := time.NewTicker(h.heartbeat)
+	defer heartbeat.Stop()
+
+	cursor := *lastID
+	for {
+		for {
+			changes, next, err := h.service.ItemChanges(ctx, cursor, filter)
+			if err != nil {
+				if ctx.Err() == nil {
+					logging.Ctx(c.Request.Context()).Error().Err(err).Msg("failed to stream item changes")
+				}
+
+				return
+			}
+
+			for _, ch := range changes {
+				if err = w.event(ch.Cursor().String(), ch.Type, ch); err != nil {
+					return
+				}
+			}
+
+			if next == cursor {
+				break
+			}
+			cursor = next
+		}
+
+		pending, err := h.service.Pending(ctx, cursor)
+		if err != nil {
+			if ctx.Err() == nil {
+				logging.Ctx(c.Request.Context()).Error().Err(err).Msg("failed to stream item changes")
+			}
+
+			return
+		}
+
+		if !h.wait(ctx, sub, heartbeat, pending, w) {
+			return
+		}
+	}
+}
+
+// Analytics handles GET /stream/analytics. It streams the sum, average and
+// count of items matching the same filters as /analytics/sum, recomputed at
+// most once per analytics interval when items change. A client reconnecting
+// with the ID of the latest change gets no event until the next change.
+func (h *Handler) Analytics(c *ginext.Context) {
+	filter, err := parseFilter(c)
+	if err != nil {
+		response.Fail(c, http.StatusBadRequest, err)
+		return
+	}
+
+	if filter.Tags, err = request.ParseTagFilter(c); err != nil {
+		response.Fail(c, http.StatusBadRequest, err)
+		return
+	}
+
+	lastID, err := lastEventID(c)
+	if err != nil {
+		response.Fail(c, http.StatusBadRequest, err)
+		return
+	}
+
+	ctx := c.Request.Context()
+
+	sub, err := h.service.Subscribe(ctx)
+	if err != nil {
+		response.Error(c, err)
+		return
+	}
+	defer sub.Close()
+
+	w := openStream(c)
+	heartbeat := time.NewTicker(h.heartbeat)
+	defer heartbeat.Stop()
+
+	for {
+		latest, err := h.service.Latest(ctx)
+		if err != nil {
+			if ctx.Err() == nil {
+				logging.Ctx(c.Request.Context()).Error().Err(err).Msg("failed to get latest item change")
+			}
+
+			return
+		}
+
+		if lastID == nil || *lastID != latest {
+			totals, err := h.service.Totals(ctx, filter)
+			if err != nil {
+				if ctx.Err() == nil {
+					logging.Ctx(c.Request.Context()).Error().Err(err).Msg("failed to stream totals")
+				}
+
+				return
+			}
+
+			data := Totals{Totals: totals, UpdatedAt: time.Now().UTC()}
+			if err = w.event(latest.String(), eventTotals, data); err != nil {
+				return
+			}
+
+			lastID = &latest
+		}
+
+		pending, err := h.service.Pending(ctx, latest)
+		if err != nil {
+			if ctx.Err() == nil {
+				logging.Ctx(c.Request.Context()).Error().Err(err).Msg("failed to check pending item changes")
+			}
+
+			return
+		}
+
+		// Changes during the interval are coalesced into the next update.
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(h.analyticsInterval):
+		}
+
+		if !h.wait(ctx, sub, heartbeat, pending, w) {
+			return
+		}
+	}
+}
+
+// wait blocks until the subscription wakes up, or for the poll interval if
+// changes are pending, sending heartbeats meanwhile. It returns false when
+// the stream must end: the client went away or the service shuts down.
+func (h *Handler) wait(ctx context.Context, sub *srvcstream.Subscription, heartbeat *time.Ticker, pending bool, w *eventWriter) bool {
+	var poll <-chan time.Time
+	if pending {
+		timer := time.NewTimer(pendingPollInterval)
+		defer timer.Stop()
+		poll = timer.C
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return false
+		case _, ok := <-sub.C:
+			return ok
+		case <-poll:
+			return true
+		case <-heartbeat.C:
+			if err := w.comment("heartbeat"); err != nil {
+				return false
+			}
+		}
+	}
+}
+
+// parseFilter parses the date range, category, kind and account query parameters.
+func parseFilter(c *ginext.Context) (*model.ItemFilter, error) {
+	from, err := request.ParseTimeQuery(c, "from", time.DateOnly)
+	if err != nil {
+		return nil, err
+	}
+
+	to, err := request.ParseTimeQuery(c, "to", time.DateOnly)
+	if err != nil {
+		return nil, err
+	}
+
+	categoryID, err := request.ParseUUIDQuery(c, "category_id")
+	if err != nil {
+		return nil, err
+	}
+
+	kind, err := request.ParseKindQuery(c, "kind")
+	if err != nil {
+		return nil, err
+	}
+
+	accountID, err := request.ParseUUIDQuery(c, "account_id")
+	if err != nil {
+		return nil, err
+	}
+
+	return &model.ItemFilter{
+		From:       from,
+		To:         to,
+		CategoryID: categoryID,
+		Kind:       kind,
+		AccountID:  accountID,
+	}, nil
+}
+
+// lastEventID returns the change cursor of the last event the client
+// received, from the Last-Event-ID header browsers send on reconnect or the
+// last_event_id query parameter, or nil if there is none.
+func lastEventID(c *ginext.Context) (*model.ChangeCursor, error) {
+	value := c.GetHeader("Last-Event-ID")
+	if value == "" {
+		value = c.Query("last_event_id")
+	}
+	if value == "" {
+		return nil, nil
+	}
+
+	xact, id, ok := strings.Cut(value, "-")
+	if !ok {
+		return nil, fmt.Errorf("invalid Last-Event-ID %q", value)
+	}
+
+	var (
+		cursor model.ChangeCursor
+		err    error
+	)
+	if cursor.XactID, err = strconv.ParseUint(xact, 10, 64); err != nil {
+		return nil, fmt.Errorf("invalid Last-Event-ID %q", value)
+	}
+	if cursor.ID, err = strconv.ParseInt(id, 10, 64); err != nil || cursor.ID < 0 {
+		return nil, fmt.Errorf("invalid Last-Event-ID %q", value)
+	}
+
+	return &cursor, nil
+}
@@ -0,0 +1,67 @@
+package stream
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/wb-go/wbf/ginext"
+
+	"github.com/aliskhannn/sales-tracker/internal/logging"
+)
+
+// retryInterval is the reconnect delay suggested to clients.
+const retryInterval = 3 * time.Second
+
+// eventWriter writes Server-Sent Events to a response.
+type eventWriter struct {
+	c *ginext.Context
+}
+
+// openStream starts an event stream response. The server's write timeout is
+// lifted for the response, since streams stay open indefinitely.
+func openStream(c *ginext.Context) *eventWriter {
+	if err := http.NewResponseController(c.Writer).SetWriteDeadline(time.Time{}); err != nil {
+		logging.Ctx(c.Request.Context()).Warn().Err(err).Msg("failed to lift write deadline of stream")
+	}
+
+	h := c.Writer.Header()
+	h.Set("Content-Type", "text/event-stream")
+	h.Set("Cache-Control", "no-cache")
+	h.Set("Connection", "keep-alive")
+	h.Set("X-Accel-Buffering", "no") // disable buffering in nginx
+
+	c.Status(http.StatusOK)
+
+	w := &eventWriter{c: c}
+	_ = w.write(fmt.Sprintf("retry: %d\n\n", retryInterval.Milliseconds()))
+
+	return w
+}
+
+// event writes an event with the given ID and name and data encoded as JSON.
+func (w *eventWriter) event(id, name string, data any) error {
+	b, err := json.Marshal(data)
+	if err != nil {
+		return fmt.Errorf("marshal event: %w", err)
+	}
+
+	return w.write(fmt.Sprintf("id: %s\nevent: %s\ndata: %s\n\n", id, name, b))
+}
+
+// comment writes a comment, which clients ignore, to keep the connection alive.
+func (w *eventWriter) comment(text string) error {
+	return w.write(": " + text + "\n\n")
+}
+
+// write writes s and flushes it to the client.
+func (w *eventWriter) write(s string) error {
+	if _, err := w.c.Writer.WriteString(s); err != nil {
+		return err
+	}
+
+	w.c.Writer.Flush()
+
+	return nil
+}
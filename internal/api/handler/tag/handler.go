@@ -0,0 +1,237 @@
+package tag
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/google/uuid"
+	"github.com/wb-go/wbf/ginext"
+
+	"github.com/aliskhannn/sales-tracker/internal/api/request"
+	"github.com/aliskhannn/sales-tracker/internal/api/response"
+	"github.com/aliskhannn/sales-tracker/internal/logging"
+	"github.com/aliskhannn/sales-tracker/internal/model"
+	"github.com/aliskhannn/sales-tracker/internal/validation"
+)
+
+// service defines business logic for tags.
+type service interface {
+	// Create adds a new tag.
+	Create(ctx context.Context, name string, description *string) (uuid.UUID, error)
+
+	// GetByID returns a tag by its ID.
+	GetByID(ctx context.Context, id uuid.UUID) (*model.Tag, error)
+
+	// List returns all tags.
+	List(ctx context.Context) ([]model.Tag, error)
+
+	// Update modifies an existing tag identified by id.
+	Update(ctx context.Context, id uuid.UUID, name string, description *string) error
+
+	// Delete removes a tag by its ID.
+	Delete(ctx context.Context, id uuid.UUID) error
+
+	// ListByItem returns the tags attached to an item.
+	ListByItem(ctx context.Context, itemID uuid.UUID) ([]model.Tag, error)
+
+	// Attach attaches tags to an item.
+	Attach(ctx context.Context, itemID uuid.UUID, tagIDs []uuid.UUID) error
+
+	// Detach removes a tag from an item.
+	Detach(ctx context.Context, itemID, tagID uuid.UUID) error
+}
+
+// Handler defines HTTP layer for tags.
+type Handler struct {
+	service   service
+	validator *validator.Validate
+}
+
+// NewHandler creates a new tag handler.
+func NewHandler(s service, v *validator.Validate) *Handler {
+	return &Handler{service: s, validator: v}
+}
+
+// CreateRequest JSON body for creating a tag.
+type CreateRequest struct {
+	Name        string  `json:"name" validate:"required"`
+	Description *string `json:"description,omitempty"`
+}
+
+// UpdateRequest JSON body for updating a tag.
+type UpdateRequest struct {
+	Name        string  `json:"name" validate:"required"`
+	Description *string `json:"description,omitempty"`
+}
+
+// AttachRequest JSON body for attaching tags to an item.
+type AttachRequest struct {
+	TagIDs []uuid.UUID `json:"tag_ids" validate:"required,min=1"`
+}
+
+// Create handles POST /tags.
+func (h *Handler) Create(c *ginext.Context) {
+	var req CreateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		logging.Ctx(c.Request.Context()).Error().Err(err).Msg("failed to bind create request")
+		response.Fail(c, http.StatusBadRequest, fmt.Errorf("invalid request body"))
+		return
+	}
+
+	if err := h.validator.Struct(req); err != nil {
+		logging.Ctx(c.Request.Context()).Error().Err(err).Msg("failed to validate request")
+		response.ValidationFail(c, validation.Fields(err))
+		return
+	}
+
+	id, err := h.service.Create(c.Request.Context(), req.Name, req.Description)
+	if err != nil {
+		response.Error(c, err)
+		return
+	}
+
+	response.Created(c, map[string]string{"id": id.String()})
+}
+
+// GetByID handles GET /tags/:id.
+func (h *Handler) GetByID(c *ginext.Context) {
+	id, err := request.ParseUUIDParam(c, "id")
+	if err != nil {
+		response.Fail(c, http.StatusBadRequest, err)
+		return
+	}
+
+	t, err := h.service.GetByID(c.Request.Context(), id)
+	if err != nil {
+		response.Error(c, err)
+		return
+	}
+
+	response.OK(c, map[string]*model.Tag{"tag": t})
+}
+
+// List handles GET /tags.
+func (h *Handler) List(c *ginext.Context) {
+	tags, err := h.service.List(c.Request.Context())
+	if err != nil {
+		response.Error(c, err)
+		return
+	}
+
+	response.OK(c, map[string][]model.Tag{"tags": tags})
+}
+
+// Update handles PUT /tags/:id.
+func (h *Handler) Update(c *ginext.Context) {
+	id, err := request.ParseUUIDParam(c, "id")
+	if err != nil {
+		response.Fail(c, http.StatusBadRequest, err)
+		return
+	}
+
+	var req UpdateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		logging.Ctx(c.Request.Context()).Error().Err(err).Msg("failed to bind update request")
+		response.Fail(c, http.StatusBadRequest, fmt.Errorf("invalid request body"))
+		return
+	}
+
+	if err := h.validator.Struct(req); err != nil {
+		logging.Ctx(c.Request.Context()).Error().Err(err).Msg("failed to validate request")
+		response.ValidationFail(c, validation.Fields(err))
+		return
+	}
+
+	if err := h.service.Update(c.Request.Context(), id, req.Name, req.Description); err != nil {
+		response.Error(c, err)
+		return
+	}
+
+	response.OK(c, map[string]string{"message": "tag updated"})
+}
+
+// Delete handles DELETE /tags/:id.
+func (h *Handler) Delete(c *ginext.Context) {
+	id, err := request.ParseUUIDParam(c, "id")
+	if err != nil {
+		response.Fail(c, http.StatusBadRequest, err)
+		return
+	}
+
+	if err := h.service.Delete(c.Request.Context(), id); err != nil {
+		response.Error(c, err)
+		return
+	}
+
+	response.OK(c, map[string]string{"message": "tag deleted"})
+}
+
+// ListByItem handles GET /items/:id/tags.
+func (h *Handler) ListByItem(c *ginext.Context) {
+	id, err := request.ParseUUIDParam(c, "id")
+	if err != nil {
+		response.Fail(c, http.StatusBadRequest, err)
+		return
+	}
+
+	tags, err := h.service.ListByItem(c.Request.Context(), id)
+	if err != nil {
+		response.Error(c, err)
+		return
+	}
+
+	response.OK(c, map[string][]model.Tag{"tags": tags})
+}
+
+// Attach handles POST /items/:id/tags.
+func (h *Handler) Attach(c *ginext.Context) {
+	id, err := request.ParseUUIDParam(c, "id")
+	if err != nil {
+		response.Fail(c, http.StatusBadRequest, err)
+		return
+	}
+
+	var req AttachRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		logging.Ctx(c.Request.Context()).Error().Err(err).Msg("failed to bind attach request")
+		response.Fail(c, http.StatusBadRequest, fmt.Errorf("invalid request body"))
+		return
+	}
+
+	if err := h.validator.Struct(req); err != nil {
+		logging.Ctx(c.Request.Context()).Error().Err(err).Msg("failed to validate request")
+		response.ValidationFail(c, validation.Fields(err))
+		return
+	}
+
+	if err := h.service.Attach(c.Request.Context(), id, req.TagIDs); err != nil {
+		response.Error(c, err)
+		return
+	}
+
+	response.OK(c, map[string]string{"message": "tags attached"})
+}
+
+// Detach handles DELETE /items/:id/tags/:tag_id.
+func (h *Handler) Detach(c *ginext.Context) {
+	id, err := request.ParseUUIDParam(c, "id")
+	if err != nil {
+		response.Fail(c, http.StatusBadRequest, err)
+		return
+	}
+
+	tagID, err := request.ParseUUIDParam(c, "tag_id")
+	if err != nil {
+		response.Fail(c, http.StatusBadRequest, err)
+		return
+	}
+
+	if err := h.service.Detach(c.Request.Context(), id, tagID); err != nil {
+		response.Error(c, err)
+		return
+	}
+
+	response.OK(c, map[string]string{"message": "tag detached"})
+}
@@ -0,0 +1,230 @@
+package webhook
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/google/uuid"
+	"github.com/wb-go/wbf/ginext"
+
+	"github.com/aliskhannn/sales-tracker/internal/api/request"
+	"github.com/aliskhannn/sales-tracker/internal/api/response"
+	"github.com/aliskhannn/sales-tracker/internal/logging"
+	"github.com/aliskhannn/sales-tracker/internal/model"
+	"github.com/aliskhannn/sales-tracker/internal/validation"
+)
+
+// defaultDeliveriesLimit is the number of deliveries listed when no limit is given.
+const defaultDeliveriesLimit = 50
+
+// maxDeliveriesLimit bounds the limit query parameter of the delivery log.
+const maxDeliveriesLimit = 500
+
+// service defines business logic for webhooks.
+type service interface {
+	// Create adds a new webhook and returns it with its secret.
+	Create(ctx context.Context, url string, eventTypes []string, secret string, enabled bool) (*model.Webhook, error)
+
+	// GetByID returns a webhook by its ID.
+	GetByID(ctx context.Context, id uuid.UUID) (*model.Webhook, error)
+
+	// List returns all webhooks.
+	List(ctx context.Context) ([]model.Webhook, error)
+
+	// Update replaces a webhook, keeping its secret unless a new one is given.
+	Update(ctx context.Context, id uuid.UUID, url string, eventTypes []string, secret *string, enabled bool) (*model.Webhook, error)
+
+	// Delete removes a webhook by its ID.
+	Delete(ctx context.Context, id uuid.UUID) error
+
+	// ListDeliveries returns the latest deliveries of a webhook.
+	ListDeliveries(ctx context.Context, webhookID uuid.UUID, limit int) ([]model.WebhookDelivery, error)
+
+	// Redeliver sends the event of a delivery again.
+	Redeliver(ctx context.Context, webhookID, deliveryID uuid.UUID) (*model.WebhookDelivery, error)
+}
+
+// Handler defines HTTP layer for webhooks.
+type Handler struct {
+	service   service
+	validator *validator.Validate
+}
+
+// NewHandler creates a new webhook handler.
+func NewHandler(s service, v *validator.Validate) *Handler {
+	return &Handler{service: s, validator: v}
+}
+
+// CreateRequest JSON body for creating a webhook. Enabled defaults to true and
+// an empty secret is generated.
+type CreateRequest struct {
+	URL        string   `json:"url" validate:"required,max=2048"`
+	EventTypes []string `json:"event_types,omitempty"`
+	Secret     string   `json:"secret,omitempty" validate:"omitempty,min=16,max=200"`
+	Enabled    *bool    `json:"enabled,omitempty"`
+}
+
+// UpdateRequest JSON body for updating a webhook. The secret is kept if omitted.
+type UpdateRequest struct {
+	URL        string   `json:"url" validate:"required,max=2048"`
+	EventTypes []string `json:"event_types,omitempty"`
+	Secret     *string  `json:"secret,omitempty" validate:"omitempty,min=16,max=200"`
+	Enabled    *bool    `json:"enabled,omitempty"`
+}
+
+// CreateResponse is the result of POST /webhooks. Secret is the key
+// deliveries are signed with, which is not returned again.
+type CreateResponse struct {
+	Secret  string         `json:"secret"`
+	Webhook *model.Webhook `json:"webhook"`
+}
+
+// Create handles POST /webhooks.
+func (h *Handler) Create(c *ginext.Context) {
+	var req CreateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		logging.Ctx(c.Request.Context()).Error().Err(err).Msg("failed to bind create request")
+		response.Fail(c, http.StatusBadRequest, fmt.Errorf("invalid request body"))
+		return
+	}
+
+	if err := h.validator.Struct(req); err != nil {
+		logging.Ctx(c.Request.Context()).Error().Err(err).Msg("failed to validate request")
+		response.ValidationFail(c, validation.Fields(err))
+		return
+	}
+
+	enabled := req.Enabled == nil || *req.Enabled
+
+	w, err := h.service.Create(c.Request.Context(), req.URL, req.EventTypes, req.Secret, enabled)
+	if err != nil {
+		response.Error(c, err)
+		return
+	}
+
+	response.Created(c, CreateResponse{Secret: w.Secret, Webhook: w})
+}
+
+// List handles GET /webhooks.
+func (h *Handler) List(c *ginext.Context) {
+	webhooks, err := h.service.List(c.Request.Context())
+	if err != nil {
+		response.Error(c, err)
+		return
+	}
+
+	response.OK(c, map[string][]model.Webhook{"webhooks": webhooks})
+}
+
+// GetByID handles GET /webhooks/:id.
+func (h *Handler) GetByID(c *ginext.Context) {
+	id, err := request.ParseUUIDParam(c, "id")
+	if err != nil {
+		response.Fail(c, http.StatusBadRequest, err)
+		return
+	}
+
+	w, err := h.service.GetByID(c.Request.Context(), id)
+	if err != nil {
+		response.Error(c, err)
+		return
+	}
+
+	response.OK(c, map[string]*model.Webhook{"webhook": w})
+}
+
+// Update handles PUT /webhooks/:id.
+func (h *Handler) Update(c *ginext.Context) {
+	id, err := request.ParseUUIDParam(c, "id")
+	if err != nil {
+		response.Fail(c, http.StatusBadRequest, err)
+		return
+	}
+
+	var req UpdateRequest
+	if err = c.ShouldBindJSON(&req); err != nil {
+		logging.Ctx(c.Request.Context()).Error().Err(err).Msg("failed to bind update request")
+		response.Fail(c, http.StatusBadRequest, fmt.Errorf("invalid request body"))
+		return
+	}
+
+	if err = h.validator.Struct(req); err != nil {
+		logging.Ctx(c.Request.Context()).Error().Err(err).Msg("failed to validate request")
+		response.ValidationFail(c, validation.Fields(err))
+		return
+	}
+
+	enabled := req.Enabled == nil || *req.Enabled
+
+	w, err := h.service.Update(c.Request.Context(), id, req.URL, req.EventTypes, req.Secret, enabled)
+	if err != nil {
+		response.Error(c, err)
+		return
+	}
+
+	response.OK(c, map[string]*model.Webhook{"webhook": w})
+}
+
+// Delete handles DELETE /webhooks/:id.
+func (h *Handler) Delete(c *ginext.Context) {
+	id, err := request.ParseUUIDParam(c, "id")
+	if err != nil {
+		response.Fail(c, http.StatusBadRequest, err)
+		return
+	}
+
+	if err = h.service.Delete(c.Request.Context(), id); err != nil {
+		response.Error(c, err)
+		return
+	}
+
+	response.OK(c, map[string]string{"message": "webhook deleted"})
+}
+
+// Deliveries handles GET /webhooks/:id/deliveries.
+func (h *Handler) Deliveries(c *ginext.Context) {
+	id, err := request.ParseUUIDParam(c, "id")
+	if err != nil {
+		response.Fail(c, http.StatusBadRequest, err)
+		return
+	}
+
+	limit, err := request.ParseIntQuery(c, "limit", defaultDeliveriesLimit)
+	if err != nil || limit <= 0 || limit > maxDeliveriesLimit {
+		response.Fail(c, http.StatusBadRequest, fmt.Errorf("limit must be between 1 and %d", maxDeliveriesLimit))
+		return
+	}
+
+	deliveries, err := h.service.ListDeliveries(c.Request.Context(), id, limit)
+	if err != nil {
+		response.Error(c, err)
+		return
+	}
+
+	response.OK(c, map[string][]model.WebhookDelivery{"deliveries": deliveries})
+}
+
+// Redeliver handles POST /webhooks/:id/deliveries/:delivery_id/redeliver.
+func (h *Handler) Redeliver(c *ginext.Context) {
+	id, err := request.ParseUUIDParam(c, "id")
+	if err != nil {
+		response.Fail(c, http.StatusBadRequest, err)
+		return
+	}
+
+	deliveryID, err := request.ParseUUIDParam(c, "delivery_id")
+	if err != nil {
+		response.Fail(c, http.StatusBadRequest, err)
+		return
+	}
+
+	d, err := h.service.Redeliver(c.Request.Context(), id, deliveryID)
+	if err != nil {
+		response.Error(c, err)
+		return
+	}
+
+	response.Created(c, map[string]*model.WebhookDelivery{"delivery": d})
+}
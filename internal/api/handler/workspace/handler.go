@@ -0,0 +1,166 @@
+package workspace
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/google/uuid"
+	"github.com/wb-go/wbf/ginext"
+
+	"github.com/aliskhannn/sales-tracker/internal/api/request"
+	"github.com/aliskhannn/sales-tracker/internal/api/response"
+	"github.com/aliskhannn/sales-tracker/internal/auth"
+	"github.com/aliskhannn/sales-tracker/internal/logging"
+	"github.com/aliskhannn/sales-tracker/internal/model"
+	"github.com/aliskhannn/sales-tracker/internal/repository/workspace"
+	"github.com/aliskhannn/sales-tracker/internal/validation"
+)
+
+// service defines business logic for workspaces.
+type service interface {
+	// Create adds a new workspace.
+	Create(ctx context.Context, slug, name string) (*model.Workspace, error)
+
+	// GetByID returns a workspace by its ID.
+	GetByID(ctx context.Context, id uuid.UUID) (*model.Workspace, error)
+
+	// List returns all workspaces.
+	List(ctx context.Context) ([]model.Workspace, error)
+
+	// Update renames a workspace.
+	Update(ctx context.Context, id uuid.UUID, name string) error
+}
+
+// Handler defines HTTP layer for workspaces. Principals bound to a workspace
+// only see and rename their own workspace and cannot create new ones.
+type Handler struct {
+	service   service
+	validator *validator.Validate
+}
+
+// NewHandler creates a new workspace handler.
+func NewHandler(s service, v *validator.Validate) *Handler {
+	return &Handler{service: s, validator: v}
+}
+
+// CreateRequest JSON body for creating a workspace.
+type CreateRequest struct {
+	Slug string `json:"slug" validate:"required,max=63"`
+	Name string `json:"name" validate:"required,max=200"`
+}
+
+// UpdateRequest JSON body for renaming a workspace.
+type UpdateRequest struct {
+	Name string `json:"name" validate:"required,max=200"`
+}
+
+// Create handles POST /workspaces.
+func (h *Handler) Create(c *ginext.Context) {
+	if p := request.Principal(c); p != nil && p.WorkspaceID != nil {
+		response.Fail(c, http.StatusForbidden, auth.ErrWorkspaceForbidden)
+		return
+	}
+
+	var req CreateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		logging.Ctx(c.Request.Context()).Error().Err(err).Msg("failed to bind create request")
+		response.Fail(c, http.StatusBadRequest, fmt.Errorf("invalid request body"))
+		return
+	}
+
+	if err := h.validator.Struct(req); err != nil {
+		logging.Ctx(c.Request.Context()).Error().Err(err).Msg("failed to validate request")
+		response.ValidationFail(c, validation.Fields(err))
+		return
+	}
+
+	w, err := h.service.Create(c.Request.Context(), req.Slug, req.Name)
+	if err != nil {
+		response.Error(c, err)
+		return
+	}
+
+	response.Created(c, map[string]*model.Workspace{"workspace": w})
+}
+
+// List handles GET /workspaces.
+func (h *Handler) List(c *ginext.Context) {
+	workspaces, err := h.service.List(c.Request.Context())
+	if err != nil {
+		response.Error(c, err)
+		return
+	}
+
+	p := request.Principal(c)
+	visible := make([]model.Workspace, 0, len(workspaces))
+	for _, w := range workspaces {
+		if canAccess(p, w.ID) {
+			visible = append(visible, w)
+		}
+	}
+
+	response.OK(c, map[string][]model.Workspace{"workspaces": visible})
+}
+
+// GetByID handles GET /workspaces/:id.
+func (h *Handler) GetByID(c *ginext.Context) {
+	id, err := request.ParseUUIDParam(c, "id")
+	if err != nil {
+		response.Fail(c, http.StatusBadRequest, err)
+		return
+	}
+
+	if !canAccess(request.Principal(c), id) {
+		response.Fail(c, http.StatusNotFound, workspace.ErrWorkspaceNotFound)
+		return
+	}
+
+	w, err := h.service.GetByID(c.Request.Context(), id)
+	if err != nil {
+		response.Error(c, err)
+		return
+	}
+
+	response.OK(c, map[string]*model.Workspace{"workspace": w})
+}
+
+// Update handles PUT /workspaces/:id.
+func (h *Handler) Update(c *ginext.Context) {
+	id, err := request.ParseUUIDParam(c, "id")
+	if err != nil {
+		response.Fail(c, http.StatusBadRequest, err)
+		return
+	}
+
+	if !canAccess(request.Principal(c), id) {
+		response.Fail(c, http.StatusNotFound, workspace.ErrWorkspaceNotFound)
+		return
+	}
+
+	var req UpdateRequest
+	if err = c.ShouldBindJSON(&req); err != nil {
+		logging.Ctx(c.Request.Context()).Error().Err(err).Msg("failed to bind update request")
+		response.Fail(c, http.StatusBadRequest, fmt.Errorf("invalid request body"))
+		return
+	}
+
+	if err = h.validator.Struct(req); err != nil {
+		logging.Ctx(c.Request.Context()).Error().Err(err).Msg("failed to validate request")
+		response.ValidationFail(c, validation.Fields(err))
+		return
+	}
+
+	if err = h.service.Update(c.Request.Context(), id, req.Name); err != nil {
+		response.Error(c, err)
+		return
+	}
+
+	response.OK(c, map[string]string{"message": "workspace updated"})
+}
+
+// canAccess reports whether the principal may see workspace id.
+func canAccess(p *model.Principal, id uuid.UUID) bool {
+	return p == nil || p.WorkspaceID == nil || *p.WorkspaceID == id
+}
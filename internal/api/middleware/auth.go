@@ -0,0 +1,155 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/wb-go/wbf/ginext"
+
+	"github.com/aliskhannn/sales-tracker/internal/api/request"
+	"github.com/aliskhannn/sales-tracker/internal/api/response"
+	"github.com/aliskhannn/sales-tracker/internal/auth"
+	"github.com/aliskhannn/sales-tracker/internal/logging"
+	"github.com/aliskhannn/sales-tracker/internal/model"
+)
+
+// APIKeyHeader is the header carrying an API key.
+const APIKeyHeader = "X-API-Key"
+
+// keyAuthenticator authenticates API keys.
+type keyAuthenticator interface {
+	// Authenticate returns the principal of an active API key.
+	Authenticate(ctx context.Context, key string) (*model.Principal, error)
+}
+
+// tokenAuthenticator authenticates JWT bearer tokens.
+type tokenAuthenticator interface {
+	// Authenticate verifies a bearer token and returns its principal.
+	Authenticate(token string) (*model.Principal, error)
+}
+
+// anonymous is the principal of every request while authentication is disabled.
+var anonymous = &model.Principal{Subject: "anonymous", Role: model.RoleAdmin, Method: model.AuthNone}
+
+// Authenticate identifies the caller by an API key in the X-API-Key header
+// or a bearer token in the Authorization header; bearer tokens starting with
+// the API key prefix are treated as API keys, others as JWTs. Requests without
+// valid credentials are rejected with 401. With enabled false every request
+// is let through as an anonymous admin.
+func Authenticate(keys keyAuthenticator, tokens tokenAuthenticator, enabled bool) ginext.HandlerFunc {
+	return func(c *ginext.Context) {
+		if !enabled {
+			request.SetPrincipal(c, anonymous)
+			c.Next()
+			return
+		}
+
+		credential, isKey := credentials(c)
+		if credential == "" {
+			unauthorized(c, auth.ErrMissingCredentials)
+			return
+		}
+
+		var (
+			p   *model.Principal
+			err error
+		)
+
+		if isKey {
+			p, err = keys.Authenticate(c.Request.Context(), credential)
+		} else {
+			p, err = tokens.Authenticate(credential)
+		}
+
+		if err != nil {
+			if !isAuthError(err) {
+				response.Error(c, err)
+				c.Abort()
+				return
+			}
+
+			logging.Ctx(c.Request.Context()).Error().Err(err).Str("path", c.Request.URL.Path).Msg("authentication failed")
+			unauthorized(c, err)
+			return
+		}
+
+		request.SetPrincipal(c, p)
+		c.Next()
+	}
+}
+
+// Authorize allows requests of principals that may access scope. Safe
+// methods (GET, HEAD, OPTIONS) require the viewer role, others the editor role.
+func Authorize(scope string) ginext.HandlerFunc {
+	return func(c *ginext.Context) {
+		role := model.RoleEditor
+		switch c.Request.Method {
+		case http.MethodGet, http.MethodHead, http.MethodOptions:
+			role = model.RoleViewer
+		}
+
+		authorize(c, role, scope)
+	}
+}
+
+// RequireRole allows requests of principals that have at least role and
+// may access scope.
+func RequireRole(role, scope string) ginext.HandlerFunc {
+	return func(c *ginext.Context) {
+		authorize(c, role, scope)
+	}
+}
+
+// authorize aborts the request with 401 if it is not authenticated and with
+// 403 if its principal lacks role or scope.
+func authorize(c *ginext.Context, role, scope string) {
+	p := request.Principal(c)
+	if p == nil {
+		unauthorized(c, auth.ErrMissingCredentials)
+		return
+	}
+
+	if !p.HasRole(role) {
+		response.FailAbort(c, http.StatusForbidden, auth.ErrInsufficientRole)
+		return
+	}
+
+	if !p.HasScope(scope) {
+		response.FailAbort(c, http.StatusForbidden, auth.ErrInsufficientScope)
+		return
+	}
+
+	c.Next()
+}
+
+// credentials returns the credential of the request and whether it is an
+// API key.
+func credentials(c *ginext.Context) (string, bool) {
+	if key := strings.TrimSpace(c.GetHeader(APIKeyHeader)); key != "" {
+		return key, true
+	}
+
+	scheme, token, ok := strings.Cut(strings.TrimSpace(c.GetHeader("Authorization")), " ")
+	if !ok || !strings.EqualFold(scheme, "Bearer") {
+		return "", false
+	}
+
+	token = strings.TrimSpace(token)
+	return token, auth.IsKey(token)
+}
+
+// isAuthError reports whether err means the credentials were rejected,
+// as opposed to a failure while checking them.
+func isAuthError(err error) bool {
+	return errors.Is(err, auth.ErrInvalidAPIKey) ||
+		errors.Is(err, auth.ErrInvalidToken) ||
+		errors.Is(err, auth.ErrTokenExpired)
+}
+
+// unauthorized aborts the request with 401 and a bearer challenge.
+func unauthorized(c *ginext.Context, err error) {
+	c.Header("WWW-Authenticate", `Bearer realm="sales-tracker"`)
+	response.FailAbort(c, http.StatusUnauthorized, err)
+}
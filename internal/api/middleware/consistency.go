@@ -0,0 +1,49 @@
+package middleware
+
+import (
+	"net/http"
+
+	"github.com/wb-go/wbf/ginext"
+
+	"github.com/aliskhannn/sales-tracker/internal/api/response"
+	"github.com/aliskhannn/sales-tracker/internal/database"
+)
+
+// Consistency headers and query parameters.
+const (
+	ConsistencyHeader = "X-Consistency"
+	ConsistencyParam  = "consistency"
+)
+
+// Consistency sets the consistency level of the reads of a request. Requests
+// other than GET, HEAD and OPTIONS read from the master, so they see their own
+// writes; others read from replicas unless the client asks for strong
+// consistency with consistency=strong or the X-Consistency header, e.g. to
+// read its own writes of a previous request.
+func Consistency() ginext.HandlerFunc {
+	return func(c *ginext.Context) {
+		level := c.Query(ConsistencyParam)
+		if level == "" {
+			level = c.GetHeader(ConsistencyHeader)
+		}
+
+		switch level {
+		case "":
+			level = database.Eventual
+		case database.Eventual, database.Strong:
+		default:
+			response.FailAbort(c, http.StatusBadRequest, database.ErrInvalidConsistency)
+			return
+		}
+
+		switch c.Request.Method {
+		case http.MethodGet, http.MethodHead, http.MethodOptions:
+		default:
+			level = database.Strong
+		}
+
+		c.Request = c.Request.WithContext(database.WithConsistency(c.Request.Context(), level))
+
+		c.Next()
+	}
+}
@@ -0,0 +1,56 @@
+package middleware
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/rs/zerolog"
+	"github.com/wb-go/wbf/ginext"
+	"github.com/wb-go/wbf/zlog"
+
+	"github.com/aliskhannn/sales-tracker/internal/api/request"
+	"github.com/aliskhannn/sales-tracker/internal/logging"
+)
+
+// Logger attaches a logger with the request ID, method and route of the
+// request to its context, which handlers, services and repositories log
+// with, and logs every request once it completes. Authentication and
+// workspace resolution add the user and workspace to the logger. Requests
+// failing with a 5xx status are logged as errors, with a 4xx status as
+// warnings.
+func Logger() ginext.HandlerFunc {
+	return func(c *ginext.Context) {
+		start := time.Now()
+
+		route := c.FullPath()
+		if route == "" {
+			route = unmatchedRoute
+		}
+
+		l := zlog.Logger.With().
+			Str("request_id", request.ID(c)).
+			Str("method", c.Request.Method).
+			Str("route", route).
+			Logger()
+		c.Request = c.Request.WithContext(logging.WithLogger(c.Request.Context(), l))
+
+		c.Next()
+
+		status := c.Writer.Status()
+		level := zerolog.InfoLevel
+		switch {
+		case status >= http.StatusInternalServerError:
+			level = zerolog.ErrorLevel
+		case status >= http.StatusBadRequest:
+			level = zerolog.WarnLevel
+		}
+
+		logging.Ctx(c.Request.Context()).WithLevel(level).
+			Str("path", c.Request.URL.Path).
+			Int("status", status).
+			Dur("latency", time.Since(start)).
+			Int("size", max(c.Writer.Size(), 0)).
+			Str("client_ip", c.ClientIP()).
+			Msg("request completed")
+	}
+}
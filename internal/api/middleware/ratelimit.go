@@ -0,0 +1,116 @@
+package middleware
+
+import (
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/wb-go/wbf/ginext"
+
+	"github.com/aliskhannn/sales-tracker/internal/api/request"
+	"github.com/aliskhannn/sales-tracker/internal/api/response"
+	"github.com/aliskhannn/sales-tracker/internal/logging"
+	"github.com/aliskhannn/sales-tracker/internal/metrics"
+	"github.com/aliskhannn/sales-tracker/internal/model"
+	"github.com/aliskhannn/sales-tracker/internal/ratelimit"
+)
+
+// Rate limit headers sent with every limited response.
+const (
+	RateLimitLimitHeader     = "X-RateLimit-Limit"
+	RateLimitRemainingHeader = "X-RateLimit-Remaining"
+	RateLimitResetHeader     = "X-RateLimit-Reset"
+)
+
+// defaultRateLimitGroup is the group of routes outside any /api/<group>.
+const defaultRateLimitGroup = "default"
+
+// rateLimited counts requests rejected by a rate limit.
+var rateLimited = promauto.NewCounterVec(prometheus.CounterOpts{
+	Namespace: metrics.Namespace,
+	Name:      "http_rate_limited_total",
+	Help:      "Number of requests rejected by a rate limit by route group.",
+}, []string{"group"})
+
+// RateLimits are the limits of the route groups.
+type RateLimits struct {
+	Default ratelimit.Limit            // limit of groups without their own
+	Groups  map[string]ratelimit.Limit // limits by group
+}
+
+// limit returns the limit of group.
+func (l RateLimits) limit(group string) ratelimit.Limit {
+	if gl, ok := l.Groups[group]; ok {
+		return gl
+	}
+
+	return l.Default
+}
+
+// RateLimit limits the requests of every client to each route group, the
+// first path segment after /api, e.g. analytics for /api/analytics/median.
+// Clients are told apart by their API key or JWT subject, or by IP address if
+// authentication is disabled, so it must run after Authenticate. Responses
+// carry X-RateLimit-Limit, X-RateLimit-Remaining and X-RateLimit-Reset
+// (seconds until the bucket is full); rejected requests get 429 with
+// Retry-After. Requests are let through if the store fails.
+func RateLimit(store ratelimit.Store, limits RateLimits) ginext.HandlerFunc {
+	return func(c *ginext.Context) {
+		group := routeGroup(c.FullPath())
+		limit := limits.limit(group)
+		if !limit.Valid() {
+			c.Next()
+			return
+		}
+
+		res, err := store.Take(c.Request.Context(), group+"|"+client(c), limit)
+		if err != nil {
+			logging.Ctx(c.Request.Context()).Error().Err(err).Str("group", group).Msg("failed to check rate limit")
+			c.Next()
+			return
+		}
+
+		h := c.Writer.Header()
+		h.Set(RateLimitLimitHeader, strconv.Itoa(res.Limit))
+		h.Set(RateLimitRemainingHeader, strconv.Itoa(res.Remaining))
+		h.Set(RateLimitResetHeader, ceilSeconds(res.Reset))
+
+		if !res.Allowed {
+			rateLimited.WithLabelValues(group).Inc()
+			h.Set("Retry-After", ceilSeconds(res.RetryAfter))
+			response.FailAbort(c, http.StatusTooManyRequests, ratelimit.ErrRateLimited)
+			return
+		}
+
+		c.Next()
+	}
+}
+
+// routeGroup returns the group of a route template.
+func routeGroup(route string) string {
+	rest, ok := strings.CutPrefix(route, "/api/")
+	if !ok || rest == "" {
+		return defaultRateLimitGroup
+	}
+
+	group, _, _ := strings.Cut(rest, "/")
+	return group
+}
+
+// client identifies the caller of a request.
+func client(c *ginext.Context) string {
+	if p := request.Principal(c); p != nil && p.Method != model.AuthNone {
+		return p.Method + ":" + p.Subject
+	}
+
+	return "ip:" + c.ClientIP()
+}
+
+// ceilSeconds formats d as whole seconds, rounded up.
+func ceilSeconds(d time.Duration) string {
+	return strconv.FormatInt(int64(math.Ceil(d.Seconds())), 10)
+}
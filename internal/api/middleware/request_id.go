@@ -0,0 +1,44 @@
+// Package middleware provides HTTP middleware of the API router.
+package middleware
+
+import (
+	"github.com/google/uuid"
+	"github.com/wb-go/wbf/ginext"
+
+	"github.com/aliskhannn/sales-tracker/internal/api/request"
+)
+
+// maxRequestIDLen is the longest client-supplied request ID that is accepted.
+const maxRequestIDLen = 128
+
+// RequestID assigns every request an ID, taken from the X-Request-ID header
+// if the client sent a usable one, and echoes it in the response header.
+func RequestID() ginext.HandlerFunc {
+	return func(c *ginext.Context) {
+		id := c.GetHeader(request.IDHeader)
+		if !validRequestID(id) {
+			id = uuid.NewString()
+		}
+
+		request.SetID(c, id)
+		c.Header(request.IDHeader, id)
+
+		c.Next()
+	}
+}
+
+// validRequestID reports whether id is non-empty, not too long and consists
+// of printable ASCII characters only.
+func validRequestID(id string) bool {
+	if id == "" || len(id) > maxRequestIDLen {
+		return false
+	}
+
+	for i := 0; i < len(id); i++ {
+		if id[i] < 0x21 || id[i] > 0x7e {
+			return false
+		}
+	}
+
+	return true
+}
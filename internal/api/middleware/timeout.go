@@ -0,0 +1,37 @@
+package middleware
+
+import (
+	"time"
+
+	"github.com/wb-go/wbf/ginext"
+
+	"github.com/aliskhannn/sales-tracker/internal/database"
+)
+
+// QueryTimeouts are the statement timeouts of the endpoints.
+type QueryTimeouts struct {
+	Default   time.Duration            // timeout of endpoints without their own; zero for none
+	Endpoints map[string]time.Duration // timeouts by route template, e.g. /api/analytics/percentile
+}
+
+// timeout returns the timeout of route.
+func (t QueryTimeouts) timeout(route string) time.Duration {
+	if d, ok := t.Endpoints[route]; ok {
+		return d
+	}
+
+	return t.Default
+}
+
+// QueryTimeout sets the statement timeout of the reads of a request to the
+// timeout of its route. Reads that exceed it are canceled and the request
+// fails with 504 query_timeout.
+func QueryTimeout(timeouts QueryTimeouts) ginext.HandlerFunc {
+	return func(c *ginext.Context) {
+		if d := timeouts.timeout(c.FullPath()); d > 0 {
+			c.Request = c.Request.WithContext(database.WithStatementTimeout(c.Request.Context(), d))
+		}
+
+		c.Next()
+	}
+}
@@ -0,0 +1,17 @@
+package middleware
+
+import (
+	"github.com/wb-go/wbf/ginext"
+	"go.opentelemetry.io/contrib/instrumentation/github.com/gin-gonic/gin/otelgin"
+)
+
+// Tracing records a server span for every request, continuing the trace of
+// the caller's traceparent header. The span is named after the method and the
+// route template, e.g. GET /api/items/:id, and carried in the request context
+// to the spans of services and queries. Requests failing with a 5xx status or
+// with errors recorded in the gin context mark the span as failed.
+//
+// The server.address attribute is taken from the Host header of the request.
+func Tracing() ginext.HandlerFunc {
+	return otelgin.Middleware("")
+}
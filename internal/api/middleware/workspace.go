@@ -0,0 +1,64 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"strings"
+
+	"github.com/wb-go/wbf/ginext"
+
+	"github.com/aliskhannn/sales-tracker/internal/api/request"
+	"github.com/aliskhannn/sales-tracker/internal/api/response"
+	"github.com/aliskhannn/sales-tracker/internal/auth"
+	"github.com/aliskhannn/sales-tracker/internal/logging"
+	"github.com/aliskhannn/sales-tracker/internal/model"
+	"github.com/aliskhannn/sales-tracker/internal/tenant"
+)
+
+// WorkspaceHeader is the header selecting the workspace of a request by ID or slug.
+const WorkspaceHeader = "X-Workspace-ID"
+
+// workspaceResolver looks up workspaces.
+type workspaceResolver interface {
+	// Resolve returns the workspace identified by ref, a workspace ID or slug.
+	Resolve(ctx context.Context, ref string) (*model.Workspace, error)
+}
+
+// Workspace resolves the workspace a request acts in and stores it in the
+// request context, where repositories pick it up. Principals bound to a
+// workspace act in it and may not select another one; others select one with
+// the X-Workspace-ID header or act in the workspace defaultRef. The resolved
+// workspace ID is echoed in the X-Workspace-ID response header.
+func Workspace(workspaces workspaceResolver, defaultRef string) ginext.HandlerFunc {
+	return func(c *ginext.Context) {
+		p := request.Principal(c)
+		bound := p != nil && p.WorkspaceID != nil
+
+		ref := strings.TrimSpace(c.GetHeader(WorkspaceHeader))
+		switch {
+		case ref != "":
+		case bound:
+			ref = p.WorkspaceID.String()
+		default:
+			ref = defaultRef
+		}
+
+		w, err := workspaces.Resolve(c.Request.Context(), ref)
+		if err != nil {
+			response.Error(c, err)
+			c.Abort()
+			return
+		}
+
+		if bound && *p.WorkspaceID != w.ID {
+			response.FailAbort(c, http.StatusForbidden, auth.ErrWorkspaceForbidden)
+			return
+		}
+
+		ctx := tenant.WithWorkspace(c.Request.Context(), w.ID)
+		c.Request = c.Request.WithContext(logging.With(ctx, "workspace_id", w.ID.String()))
+		c.Header(WorkspaceHeader, w.ID.String())
+
+		c.Next()
+	}
+}
@@ -0,0 +1,20 @@
+package request
+
+import "github.com/wb-go/wbf/ginext"
+
+// IDHeader is the header carrying the request ID in requests and responses.
+const IDHeader = "X-Request-ID"
+
+// idKey is the gin context key the request ID is stored under.
+const idKey = "request_id"
+
+// SetID stores the request ID in the gin context.
+func SetID(c *ginext.Context, id string) {
+	c.Set(idKey, id)
+}
+
+// ID returns the ID of the current request, or an empty string if the
+// request ID middleware did not run.
+func ID(c *ginext.Context) string {
+	return c.GetString(idKey)
+}
@@ -2,12 +2,16 @@ package request
 
 import (
 	"fmt"
+	"slices"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/google/uuid"
 	"github.com/wb-go/wbf/ginext"
-	"github.com/wb-go/wbf/zlog"
+
+	"github.com/aliskhannn/sales-tracker/internal/logging"
+	"github.com/aliskhannn/sales-tracker/internal/model"
 )
 
 // ParseUUIDParam parses a UUID from the URL parameters and logs errors if invalid.
@@ -16,7 +20,7 @@ func ParseUUIDParam(c *ginext.Context, key string) (uuid.UUID, error) {
 	value := c.Param(key)
 	id, err := uuid.Parse(value)
 	if err != nil {
-		zlog.Logger.Error().Err(err).Interface(key, value).Msg("failed to parse UUID param")
+		logging.Ctx(c.Request.Context()).Error().Err(err).Interface(key, value).Msg("failed to parse UUID param")
 		return uuid.Nil, fmt.Errorf("invalid %s", key)
 	}
 
@@ -33,7 +37,7 @@ func ParseUUIDQuery(c *ginext.Context, key string) (*uuid.UUID, error) {
 
 	id, err := uuid.Parse(value)
 	if err != nil {
-		zlog.Logger.Error().Err(err).Str(key, value).Msg("failed to parse UUID query")
+		logging.Ctx(c.Request.Context()).Error().Err(err).Str(key, value).Msg("failed to parse UUID query")
 		return nil, fmt.Errorf("invalid %s", key)
 	}
 
@@ -50,7 +54,7 @@ func ParseTimeQuery(c *ginext.Context, key string, layout string) (*time.Time, e
 
 	t, err := time.Parse(layout, value)
 	if err != nil {
-		zlog.Logger.Error().Err(err).Interface(key, value).Msg("failed to parse time query")
+		logging.Ctx(c.Request.Context()).Error().Err(err).Interface(key, value).Msg("failed to parse time query")
 		return nil, fmt.Errorf("invalid time format for %s", key)
 	}
 
@@ -88,13 +92,49 @@ func ParseIntQuery(c *ginext.Context, key string, defaultValue int) (int, error)
 
 	n, err := strconv.Atoi(value)
 	if err != nil {
-		zlog.Logger.Error().Err(err).Str(key, value).Msg("failed to parse int query")
+		logging.Ctx(c.Request.Context()).Error().Err(err).Str(key, value).Msg("failed to parse int query")
 		return 0, fmt.Errorf("invalid int format for %s", key)
 	}
 
 	return n, nil
 }
 
+// ParseDurationQuery parses a query parameter as time.Duration (e.g. "24h").
+// Returns defaultValue if parameter is empty.
+// Returns error if value is present but not a valid duration.
+func ParseDurationQuery(c *ginext.Context, key string, defaultValue time.Duration) (time.Duration, error) {
+	value := c.Query(key)
+	if value == "" {
+		return defaultValue, nil
+	}
+
+	d, err := time.ParseDuration(value)
+	if err != nil {
+		logging.Ctx(c.Request.Context()).Error().Err(err).Str(key, value).Msg("failed to parse duration query")
+		return 0, fmt.Errorf("invalid duration format for %s", key)
+	}
+
+	return d, nil
+}
+
+// ParseBoolQuery parses a query parameter as bool.
+// Returns defaultValue if parameter is empty.
+// Returns error if value is present but not a valid bool.
+func ParseBoolQuery(c *ginext.Context, key string, defaultValue bool) (bool, error) {
+	value := c.Query(key)
+	if value == "" {
+		return defaultValue, nil
+	}
+
+	b, err := strconv.ParseBool(value)
+	if err != nil {
+		logging.Ctx(c.Request.Context()).Error().Err(err).Str(key, value).Msg("failed to parse bool query")
+		return false, fmt.Errorf("invalid bool format for %s", key)
+	}
+
+	return b, nil
+}
+
 // ParseFloatQuery parses a query parameter as float64.
 // Returns defaultValue if parameter is empty.
 // Returns error if value is present but not a valid float.
@@ -106,9 +146,92 @@ func ParseFloatQuery(c *ginext.Context, key string, defaultValue float64) (float
 
 	f, err := strconv.ParseFloat(value, 64)
 	if err != nil {
-		zlog.Logger.Error().Err(err).Str(key, value).Msg("failed to parse float query")
+		logging.Ctx(c.Request.Context()).Error().Err(err).Str(key, value).Msg("failed to parse float query")
 		return 0, fmt.Errorf("invalid float format for %s", key)
 	}
 
 	return f, nil
 }
+
+// ParseStringListQuery parses a comma-separated or repeated query parameter
+// as a list of strings. Empty values are dropped; returns nil if none are left.
+func ParseStringListQuery(c *ginext.Context, key string) []string {
+	var values []string
+	for _, raw := range c.QueryArray(key) {
+		for _, v := range strings.Split(raw, ",") {
+			if v = strings.TrimSpace(v); v != "" {
+				values = append(values, v)
+			}
+		}
+	}
+
+	return values
+}
+
+// ParseTagFilter parses the "tags" and "tags_match" (any or all, default any)
+// query parameters. Returns nil if no tags are given.
+func ParseTagFilter(c *ginext.Context) (*model.TagFilter, error) {
+	match := ParseStringQuery(c, "tags_match", "any")
+	if match != "any" && match != "all" {
+		logging.Ctx(c.Request.Context()).Error().Str("tags_match", match).Msg("failed to parse tags_match query")
+		return nil, fmt.Errorf("invalid tags_match, expected any or all")
+	}
+
+	return TagFilter(ParseStringListQuery(c, "tags"), match == "all"), nil
+}
+
+// TagFilter builds a tag filter from tag names, e.g. those of a request body,
+// trimmed, lower-cased and deduplicated. Returns nil if no tags are given.
+func TagFilter(names []string, matchAll bool) *model.TagFilter {
+	var filter *model.TagFilter
+	for _, n := range names {
+		n = strings.ToLower(strings.TrimSpace(n))
+		if n == "" {
+			continue
+		}
+
+		if filter == nil {
+			filter = &model.TagFilter{MatchAll: matchAll}
+		}
+		if !slices.Contains(filter.Names, n) {
+			filter.Names = append(filter.Names, n)
+		}
+	}
+
+	return filter
+}
+
+// ParseBoolQueryPtr parses a query parameter as *bool.
+// Returns nil if parameter is empty.
+// Returns error if value is present but not a valid bool.
+func ParseBoolQueryPtr(c *ginext.Context, key string) (*bool, error) {
+	value := c.Query(key)
+	if value == "" {
+		return nil, nil
+	}
+
+	b, err := strconv.ParseBool(value)
+	if err != nil {
+		logging.Ctx(c.Request.Context()).Error().Err(err).Str(key, value).Msg("failed to parse bool query")
+		return nil, fmt.Errorf("invalid bool format for %s", key)
+	}
+
+	return &b, nil
+}
+
+// ParseKindQuery parses a query parameter as an optional item kind.
+// Returns nil if parameter is empty.
+// Returns error if value is present but not one of the item kinds.
+func ParseKindQuery(c *ginext.Context, key string) (*string, error) {
+	value := c.Query(key)
+	if value == "" {
+		return nil, nil
+	}
+
+	switch value {
+	case model.KindIncome, model.KindExpense, model.KindTransfer, model.KindRefund:
+		return &value, nil
+	default:
+		return nil, fmt.Errorf("invalid %s, expected income, expense, transfer or refund", key)
+	}
+}
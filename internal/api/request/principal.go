@@ -0,0 +1,32 @@
+package request
+
+import (
+	"github.com/wb-go/wbf/ginext"
+
+	"github.com/aliskhannn/sales-tracker/internal/logging"
+	"github.com/aliskhannn/sales-tracker/internal/model"
+)
+
+// principalKey is the gin context key the authenticated principal is stored under.
+const principalKey = "principal"
+
+// SetPrincipal stores the authenticated principal in the gin context and
+// adds it to the request logger.
+func SetPrincipal(c *ginext.Context, p *model.Principal) {
+	c.Set(principalKey, p)
+
+	ctx := logging.With(c.Request.Context(), "user", p.Subject)
+	c.Request = c.Request.WithContext(logging.With(ctx, "role", p.Role))
+}
+
+// Principal returns the authenticated principal of the current request,
+// or nil if the request was not authenticated.
+func Principal(c *ginext.Context) *model.Principal {
+	v, ok := c.Get(principalKey)
+	if !ok {
+		return nil
+	}
+
+	p, _ := v.(*model.Principal)
+	return p
+}
@@ -0,0 +1,53 @@
+package response
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"github.com/wb-go/wbf/ginext"
+)
+
+// OKCacheable sends a 200 OK response with an ETag derived from its body and
+// the given Cache-Control, or an empty 304 Not Modified if the ETag matches
+// the request's If-None-Match, i.e. the client already has the result. vary
+// names the request headers besides the URL that select the result.
+func OKCacheable(c *ginext.Context, cacheControl string, vary []string, result interface{}) {
+	body, err := json.Marshal(Success{Result: result})
+	if err != nil {
+		Error(c, err)
+		return
+	}
+
+	sum := sha256.Sum256(body)
+	etag := `"` + hex.EncodeToString(sum[:16]) + `"`
+
+	h := c.Writer.Header()
+	h.Set("ETag", etag)
+	h.Set("Cache-Control", cacheControl)
+	if len(vary) > 0 {
+		h.Set("Vary", strings.Join(vary, ", "))
+	}
+
+	if etagMatches(c.GetHeader("If-None-Match"), etag) {
+		c.Status(http.StatusNotModified)
+		return
+	}
+
+	c.Data(http.StatusOK, "application/json; charset=utf-8", body)
+}
+
+// etagMatches reports whether an If-None-Match header matches etag, using the
+// weak comparison RFC 9110 requires for it.
+func etagMatches(ifNoneMatch, etag string) bool {
+	for _, tag := range strings.Split(ifNoneMatch, ",") {
+		tag = strings.TrimSpace(tag)
+		if tag == "*" || strings.TrimPrefix(tag, "W/") == etag {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,277 @@
+package response
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/lib/pq"
+
+	"github.com/aliskhannn/sales-tracker/internal/auth"
+	"github.com/aliskhannn/sales-tracker/internal/database"
+	"github.com/aliskhannn/sales-tracker/internal/ratelimit"
+	"github.com/aliskhannn/sales-tracker/internal/report"
+	"github.com/aliskhannn/sales-tracker/internal/repository/account"
+	"github.com/aliskhannn/sales-tracker/internal/repository/apikey"
+	"github.com/aliskhannn/sales-tracker/internal/repository/attachment"
+	"github.com/aliskhannn/sales-tracker/internal/repository/category"
+	"github.com/aliskhannn/sales-tracker/internal/repository/item"
+	"github.com/aliskhannn/sales-tracker/internal/repository/job"
+	"github.com/aliskhannn/sales-tracker/internal/repository/reconciliation"
+	"github.com/aliskhannn/sales-tracker/internal/repository/recurring"
+	"github.com/aliskhannn/sales-tracker/internal/repository/rule"
+	"github.com/aliskhannn/sales-tracker/internal/repository/schedule"
+	"github.com/aliskhannn/sales-tracker/internal/repository/tag"
+	"github.com/aliskhannn/sales-tracker/internal/repository/webhook"
+	"github.com/aliskhannn/sales-tracker/internal/repository/workspace"
+	srvcapikey "github.com/aliskhannn/sales-tracker/internal/service/apikey"
+	srvcattachment "github.com/aliskhannn/sales-tracker/internal/service/attachment"
+	srvcitem "github.com/aliskhannn/sales-tracker/internal/service/item"
+	srvcjob "github.com/aliskhannn/sales-tracker/internal/service/job"
+	srvcreconciliation "github.com/aliskhannn/sales-tracker/internal/service/reconciliation"
+	srvcrecurring "github.com/aliskhannn/sales-tracker/internal/service/recurring"
+	srvcrule "github.com/aliskhannn/sales-tracker/internal/service/rule"
+	srvcschedule "github.com/aliskhannn/sales-tracker/internal/service/schedule"
+	srvcstream "github.com/aliskhannn/sales-tracker/internal/service/stream"
+	srvctag "github.com/aliskhannn/sales-tracker/internal/service/tag"
+	srvcwebhook "github.com/aliskhannn/sales-tracker/internal/service/webhook"
+	srvcworkspace "github.com/aliskhannn/sales-tracker/internal/service/workspace"
+	"github.com/aliskhannn/sales-tracker/internal/statement"
+)
+
+// Generic error codes, used when an error has no more specific code.
+const (
+	CodeBadRequest           = "bad_request"
+	CodeUnauthorized         = "unauthorized"
+	CodeForbidden            = "forbidden"
+	CodeNotFound             = "not_found"
+	CodeConflict             = "conflict"
+	CodePayloadTooLarge      = "payload_too_large"
+	CodeUnsupportedMediaType = "unsupported_media_type"
+	CodeValidationFailed     = "validation_failed"
+	CodeTooManyRequests      = "too_many_requests"
+	CodeRequestCanceled      = "request_canceled"
+	CodeInternal             = "internal_error"
+	CodeServiceUnavailable   = "service_unavailable"
+	CodeTimeout              = "timeout"
+)
+
+// statusClientClosedRequest is the non-standard status of requests the client
+// canceled before a response was written.
+const statusClientClosedRequest = 499
+
+// errorMapping maps an error to an HTTP status and a stable error code.
+type errorMapping struct {
+	err    error
+	status int
+	code   string
+}
+
+// errorTable is the central mapping of repository and service errors.
+// Errors are matched with errors.Is in order.
+var errorTable = []errorMapping{
+	// Authentication and authorization.
+	{auth.ErrMissingCredentials, http.StatusUnauthorized, "missing_credentials"},
+	{auth.ErrInvalidAPIKey, http.StatusUnauthorized, "invalid_api_key"},
+	{auth.ErrInvalidToken, http.StatusUnauthorized, "invalid_token"},
+	{auth.ErrTokenExpired, http.StatusUnauthorized, "token_expired"},
+	{auth.ErrInsufficientRole, http.StatusForbidden, "insufficient_role"},
+	{auth.ErrInsufficientScope, http.StatusForbidden, "insufficient_scope"},
+	{auth.ErrWorkspaceForbidden, http.StatusForbidden, "workspace_forbidden"},
+
+	// Missing resources.
+	{category.ErrCategoryNotFound, http.StatusNotFound, "category_not_found"},
+	{item.ErrItemNotFound, http.StatusNotFound, "item_not_found"},
+	{item.ErrNoItemsFound, http.StatusNotFound, "items_not_found"},
+	{account.ErrAccountNotFound, http.StatusNotFound, "account_not_found"},
+	{tag.ErrTagNotFound, http.StatusNotFound, "tag_not_found"},
+	{rule.ErrRuleNotFound, http.StatusNotFound, "rule_not_found"},
+	{recurring.ErrRecurringItemNotFound, http.StatusNotFound, "recurring_item_not_found"},
+	{attachment.ErrAttachmentNotFound, http.StatusNotFound, "attachment_not_found"},
+	{reconciliation.ErrSessionNotFound, http.StatusNotFound, "reconciliation_session_not_found"},
+	{reconciliation.ErrLineNotFound, http.StatusNotFound, "reconciliation_line_not_found"},
+	{reconciliation.ErrItemNotFound, http.StatusNotFound, "item_not_found"},
+	{apikey.ErrAPIKeyNotFound, http.StatusNotFound, "api_key_not_found"},
+	{workspace.ErrWorkspaceNotFound, http.StatusNotFound, "workspace_not_found"},
+	{webhook.ErrWebhookNotFound, http.StatusNotFound, "webhook_not_found"},
+	{webhook.ErrDeliveryNotFound, http.StatusNotFound, "webhook_delivery_not_found"},
+	{job.ErrJobNotFound, http.StatusNotFound, "analytics_job_not_found"},
+	{schedule.ErrScheduledReportNotFound, http.StatusNotFound, "scheduled_report_not_found"},
+
+	// Conflicts with the current state.
+	{account.ErrAccountInUse, http.StatusConflict, "account_in_use"},
+	{item.ErrItemHasRefunds, http.StatusConflict, "item_has_refunds"},
+	{tag.ErrTagExists, http.StatusConflict, "tag_exists"},
+	{recurring.ErrOccurrenceHandled, http.StatusConflict, "occurrence_already_handled"},
+	{reconciliation.ErrItemReconciled, http.StatusConflict, "item_already_reconciled"},
+	{srvcreconciliation.ErrLineReconciled, http.StatusConflict, "line_already_reconciled"},
+	{workspace.ErrWorkspaceExists, http.StatusConflict, "workspace_exists"},
+	{srvcjob.ErrJobFinished, http.StatusConflict, "analytics_job_finished"},
+
+	// Violated business rules.
+	{item.ErrSplitsAmountMismatch, http.StatusBadRequest, "splits_amount_mismatch"},
+	{item.ErrRefundTargetNotFound, http.StatusBadRequest, "refund_target_not_found"},
+	{item.ErrInvalidRefundTarget, http.StatusBadRequest, "invalid_refund_target"},
+	{item.ErrRefundCurrency, http.StatusBadRequest, "refund_currency_mismatch"},
+	{item.ErrRefundExceedsAmount, http.StatusBadRequest, "refund_exceeds_amount"},
+	{srvcitem.ErrInvalidSplitAmount, http.StatusBadRequest, "invalid_split_amount"},
+	{srvcitem.ErrCurrencyMismatch, http.StatusBadRequest, "currency_mismatch"},
+	{srvcitem.ErrSameAccount, http.StatusBadRequest, "same_account"},
+	{srvcitem.ErrRefundOfKind, http.StatusBadRequest, "refund_of_kind"},
+	{srvcitem.ErrNoDuplicates, http.StatusBadRequest, "no_duplicates"},
+	{srvcitem.ErrMergeSelf, http.StatusBadRequest, "merge_self"},
+	{srvcitem.ErrMergeTransfer, http.StatusBadRequest, "merge_transfer"},
+	{srvcitem.ErrMergeMismatch, http.StatusBadRequest, "merge_mismatch"},
+	{srvcrecurring.ErrInvalidRule, http.StatusBadRequest, "invalid_recurrence_rule"},
+	{srvcrecurring.ErrNotAnOccurrence, http.StatusBadRequest, "not_an_occurrence"},
+	{srvcrule.ErrInvalidRule, http.StatusBadRequest, "invalid_rule"},
+	{srvctag.ErrInvalidTagName, http.StatusBadRequest, "invalid_tag_name"},
+	{srvcreconciliation.ErrEmptyStatement, http.StatusBadRequest, "empty_statement"},
+	{srvcreconciliation.ErrCurrencyRequired, http.StatusBadRequest, "currency_required"},
+	{srvcreconciliation.ErrNoItemToConfirm, http.StatusBadRequest, "no_item_to_confirm"},
+	{srvcreconciliation.ErrItemMismatch, http.StatusBadRequest, "item_mismatch"},
+	{srvcapikey.ErrInvalidRole, http.StatusBadRequest, "invalid_role"},
+	{srvcapikey.ErrInvalidScope, http.StatusBadRequest, "invalid_scope"},
+	{srvcapikey.ErrExpiresInPast, http.StatusBadRequest, "expires_in_past"},
+	{apikey.ErrUnknownWorkspace, http.StatusBadRequest, "unknown_workspace"},
+	{srvcworkspace.ErrInvalidSlug, http.StatusBadRequest, "invalid_slug"},
+	{srvcwebhook.ErrInvalidURL, http.StatusBadRequest, "invalid_webhook_url"},
+	{srvcwebhook.ErrForbiddenTarget, http.StatusBadRequest, "forbidden_webhook_target"},
+	{srvcwebhook.ErrInvalidEventType, http.StatusBadRequest, "invalid_event_type"},
+	{statement.ErrInvalidStatement, http.StatusBadRequest, "invalid_statement"},
+	{database.ErrInvalidConsistency, http.StatusBadRequest, "invalid_consistency"},
+	{statement.ErrUnsupportedFormat, http.StatusBadRequest, "unsupported_format"},
+	{report.ErrNoMetrics, http.StatusBadRequest, "no_metrics"},
+	{report.ErrInvalidMetric, http.StatusBadRequest, "invalid_metric"},
+	{report.ErrInvalidGrouping, http.StatusBadRequest, "invalid_grouping"},
+	{report.ErrRangeRequired, http.StatusBadRequest, "range_required"},
+	{report.ErrInvalidRange, http.StatusBadRequest, "invalid_range"},
+	{report.ErrInvalidPercentile, http.StatusBadRequest, "invalid_percentile"},
+	{report.ErrTooManyPeriods, http.StatusBadRequest, "too_many_periods"},
+	{srvcschedule.ErrInvalidRange, http.StatusBadRequest, "invalid_report_range"},
+
+	// Uploads.
+	{srvcattachment.ErrFileTooLarge, http.StatusRequestEntityTooLarge, "file_too_large"},
+	{srvcattachment.ErrEmptyFile, http.StatusBadRequest, "empty_file"},
+	{srvcattachment.ErrContentTypeNotAllowed, http.StatusUnsupportedMediaType, "content_type_not_allowed"},
+	{srvcattachment.ErrAttachmentContentsLost, http.StatusInternalServerError, "attachment_contents_lost"},
+
+	// Rate limits.
+	{ratelimit.ErrRateLimited, http.StatusTooManyRequests, "rate_limited"},
+
+	// Shutdown.
+	{srvcstream.ErrClosed, http.StatusServiceUnavailable, "stream_closed"},
+
+	// Deadlines and cancellation.
+	{database.ErrQueryTimeout, http.StatusGatewayTimeout, "query_timeout"},
+	{context.DeadlineExceeded, http.StatusGatewayTimeout, CodeTimeout},
+	{context.Canceled, statusClientClosedRequest, CodeRequestCanceled},
+}
+
+// pqErrorTable maps PostgreSQL error codes to HTTP statuses and error codes.
+var pqErrorTable = map[pq.ErrorCode]struct {
+	status int
+	code   string
+	detail string
+}{
+	"23503": {http.StatusConflict, "foreign_key_violation", "a referenced resource does not exist or is still referenced"},
+	"23505": {http.StatusConflict, "unique_violation", "a resource with the same unique value already exists"},
+	"23514": {http.StatusUnprocessableEntity, "check_violation", "a value violates a constraint"},
+	"23502": {http.StatusUnprocessableEntity, "not_null_violation", "a required value is missing"},
+	"22P02": {http.StatusBadRequest, "invalid_input", "a value has an invalid format"},
+	"22001": {http.StatusUnprocessableEntity, "value_too_long", "a value is too long"},
+	"22003": {http.StatusUnprocessableEntity, "numeric_out_of_range", "a number is out of range"},
+	"22007": {http.StatusBadRequest, "invalid_datetime", "a date or time has an invalid format"},
+	"40001": {http.StatusConflict, "serialization_failure", "the request conflicted with a concurrent update, retry it"},
+	"40P01": {http.StatusConflict, "deadlock_detected", "the request conflicted with a concurrent update, retry it"},
+	"57014": {http.StatusGatewayTimeout, "query_timeout", "the query took too long"},
+	"53300": {http.StatusServiceUnavailable, "too_many_connections", "the database is overloaded, retry later"},
+}
+
+// lookup returns the mapping of a known repository or service error.
+func lookup(err error) (errorMapping, bool) {
+	for _, m := range errorTable {
+		if errors.Is(err, m.err) {
+			return m, true
+		}
+	}
+
+	return errorMapping{}, false
+}
+
+// mapError returns the status, code and client-facing detail of err.
+func mapError(err error) (status int, code, detail string) {
+	if m, ok := lookup(err); ok {
+		// Server errors hide their cause, except for the timeout a query
+		// exceeded, which tells clients to narrow it down.
+		var timeout *database.TimeoutError
+		if errors.As(err, &timeout) {
+			return m.status, m.code, timeout.Error()
+		}
+
+		if m.status >= http.StatusInternalServerError {
+			return m.status, m.code, http.StatusText(m.status)
+		}
+
+		return m.status, m.code, clientDetail(err, m.err)
+	}
+
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) {
+		if m, ok := pqErrorTable[pqErr.Code]; ok {
+			return m.status, m.code, m.detail
+		}
+	}
+
+	return http.StatusInternalServerError, CodeInternal, "internal server error"
+}
+
+// clientDetail returns the message of err starting at the message of the
+// sentinel it wraps. It keeps the details added after the sentinel, e.g. the
+// offending value, and drops the operations err was wrapped with on its way
+// up, e.g. "create item: ".
+func clientDetail(err, sentinel error) string {
+	msg := err.Error()
+	if i := strings.Index(msg, sentinel.Error()); i >= 0 {
+		return msg[i:]
+	}
+
+	return sentinel.Error()
+}
+
+// codeForStatus returns the generic error code of an HTTP status.
+func codeForStatus(status int) string {
+	switch status {
+	case http.StatusBadRequest:
+		return CodeBadRequest
+	case http.StatusUnauthorized:
+		return CodeUnauthorized
+	case http.StatusForbidden:
+		return CodeForbidden
+	case http.StatusNotFound:
+		return CodeNotFound
+	case http.StatusConflict:
+		return CodeConflict
+	case http.StatusRequestEntityTooLarge:
+		return CodePayloadTooLarge
+	case http.StatusUnsupportedMediaType:
+		return CodeUnsupportedMediaType
+	case http.StatusUnprocessableEntity:
+		return CodeValidationFailed
+	case http.StatusTooManyRequests:
+		return CodeTooManyRequests
+	case http.StatusServiceUnavailable:
+		return CodeServiceUnavailable
+	case http.StatusGatewayTimeout:
+		return CodeTimeout
+	case statusClientClosedRequest:
+		return CodeRequestCanceled
+	default:
+		if status >= http.StatusInternalServerError {
+			return CodeInternal
+		}
+
+		return CodeBadRequest
+	}
+}
@@ -0,0 +1,77 @@
+package response
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/lib/pq"
+
+	"github.com/aliskhannn/sales-tracker/internal/report"
+	"github.com/aliskhannn/sales-tracker/internal/repository/account"
+	"github.com/aliskhannn/sales-tracker/internal/repository/item"
+)
+
+func TestMapError(t *testing.T) {
+	tests := []struct {
+		name       string
+		err        error
+		wantStatus int
+		wantCode   string
+		wantDetail string
+	}{
+		{
+			name:       "sentinel",
+			err:        item.ErrItemNotFound,
+			wantStatus: http.StatusNotFound,
+			wantCode:   "item_not_found",
+			wantDetail: item.ErrItemNotFound.Error(),
+		},
+		{
+			name:       "wrapped sentinel",
+			err:        fmt.Errorf("create item: %w", account.ErrAccountNotFound),
+			wantStatus: http.StatusNotFound,
+			wantCode:   "account_not_found",
+			wantDetail: account.ErrAccountNotFound.Error(),
+		},
+		{
+			name:       "sentinel with detail",
+			err:        fmt.Errorf("run report: %w", fmt.Errorf("%w: %q", report.ErrInvalidMetric, "foo")),
+			wantStatus: http.StatusBadRequest,
+			wantCode:   "invalid_metric",
+			wantDetail: report.ErrInvalidMetric.Error() + `: "foo"`,
+		},
+		{
+			name:       "postgres error",
+			err:        fmt.Errorf("insert tag: %w", &pq.Error{Code: "23505", Message: "duplicate key"}),
+			wantStatus: http.StatusConflict,
+			wantCode:   "unique_violation",
+			wantDetail: "a resource with the same unique value already exists",
+		},
+		{
+			name:       "canceled",
+			err:        fmt.Errorf("list items: %w", context.Canceled),
+			wantStatus: statusClientClosedRequest,
+			wantCode:   CodeRequestCanceled,
+			wantDetail: context.Canceled.Error(),
+		},
+		{
+			name:       "unknown",
+			err:        errors.New("connection refused"),
+			wantStatus: http.StatusInternalServerError,
+			wantCode:   CodeInternal,
+			wantDetail: "internal server error",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			status, code, detail := mapError(tt.err)
+			if status != tt.wantStatus || code != tt.wantCode || detail != tt.wantDetail {
+				t.Errorf("mapError() = %d, %q, %q, want %d, %q, %q", status, code, detail, tt.wantStatus, tt.wantCode, tt.wantDetail)
+			}
+		})
+	}
+}
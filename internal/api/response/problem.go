@@ -0,0 +1,88 @@
+package response
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/wb-go/wbf/ginext"
+
+	"github.com/aliskhannn/sales-tracker/internal/api/request"
+)
+
+// ProblemContentType is the media type of problem details (RFC 7807).
+const ProblemContentType = "application/problem+json"
+
+// problemTypePrefix prefixes the error code to form the problem type URI.
+const problemTypePrefix = "urn:sales-tracker:problem:"
+
+// Problem is an RFC 7807 problem details object.
+//
+// Besides the standard members it carries a stable machine-readable Code,
+// the RequestID of the failed request, optional field-level Errors and
+// arbitrary Extensions that are inlined into the JSON object.
+type Problem struct {
+	Type       string                 `json:"type"`
+	Title      string                 `json:"title"`
+	Status     int                    `json:"status"`
+	Detail     string                 `json:"detail,omitempty"`
+	Instance   string                 `json:"instance,omitempty"`
+	Code       string                 `json:"code"`
+	RequestID  string                 `json:"request_id,omitempty"`
+	Errors     interface{}            `json:"errors,omitempty"`
+	Extensions map[string]interface{} `json:"-"`
+}
+
+// NewProblem creates a problem for the current request.
+func NewProblem(c *ginext.Context, status int, code, detail string) *Problem {
+	title := http.StatusText(status)
+	if status == statusClientClosedRequest {
+		title = "Client Closed Request"
+	}
+
+	return &Problem{
+		Type:      problemTypePrefix + code,
+		Title:     title,
+		Status:    status,
+		Detail:    detail,
+		Instance:  c.Request.URL.Path,
+		Code:      code,
+		RequestID: request.ID(c),
+	}
+}
+
+// MarshalJSON encodes the problem with its extensions inlined.
+func (p Problem) MarshalJSON() ([]byte, error) {
+	type problem Problem
+
+	data, err := json.Marshal(problem(p))
+	if err != nil || len(p.Extensions) == 0 {
+		return data, err
+	}
+
+	members := make(map[string]interface{}, len(p.Extensions)+8)
+	for k, v := range p.Extensions {
+		members[k] = v
+	}
+
+	var standard map[string]interface{}
+	if err = json.Unmarshal(data, &standard); err != nil {
+		return nil, err
+	}
+
+	for k, v := range standard {
+		members[k] = v
+	}
+
+	return json.Marshal(members)
+}
+
+// WriteProblem writes a problem details response.
+func WriteProblem(c *ginext.Context, p *Problem) {
+	data, err := json.Marshal(p)
+	if err != nil {
+		c.Status(http.StatusInternalServerError)
+		return
+	}
+
+	c.Data(p.Status, ProblemContentType, data)
+}
@@ -3,17 +3,16 @@ package response
 import (
 	"net/http"
 
+	"github.com/rs/zerolog"
 	"github.com/wb-go/wbf/ginext"
+
+	"github.com/aliskhannn/sales-tracker/internal/logging"
 )
 
 type Success struct {
 	Result interface{} `json:"result"`
 }
 
-type Error struct {
-	Message string `json:"error"`
-}
-
 // JSON writes any JSON response with a given status code
 func JSON(c *ginext.Context, status int, data interface{}) {
 	c.JSON(status, data)
@@ -29,9 +28,48 @@ func Created(c *ginext.Context, result interface{}) {
 	JSON(c, http.StatusCreated, Success{Result: result})
 }
 
-// Fail sends an error response with a given status code
+// Accepted sends a 202 Accepted response
+func Accepted(c *ginext.Context, result interface{}) {
+	JSON(c, http.StatusAccepted, Success{Result: result})
+}
+
+// Fail sends a problem details response with a given status code. The error
+// code is looked up in the central error table and falls back to the code of
+// the status; err's message becomes the detail.
 func Fail(c *ginext.Context, status int, err error) {
-	JSON(c, status, Error{Message: err.Error()})
+	code := codeForStatus(status)
+	if m, ok := lookup(err); ok {
+		code = m.code
+	}
+
+	WriteProblem(c, NewProblem(c, status, code, err.Error()))
+}
+
+// Error sends a problem details response for err, mapping it to a status and
+// code via the central error table and PostgreSQL error codes. Unknown errors
+// become a 500 without exposing their message.
+//
+// Errors mapped to a server error are logged with their cause; client errors
+// are expected and only logged at debug level, as the request log already
+// records their status.
+func Error(c *ginext.Context, err error) {
+	status, code, detail := mapError(err)
+
+	level := zerolog.DebugLevel
+	if status >= http.StatusInternalServerError {
+		level = zerolog.ErrorLevel
+	}
+
+	logging.Ctx(c.Request.Context()).WithLevel(level).Err(err).Int("status", status).Str("code", code).Msg("request failed")
+
+	WriteProblem(c, NewProblem(c, status, code, detail))
+}
+
+// ValidationFail sends a 422 Unprocessable Entity response with per-field errors.
+func ValidationFail(c *ginext.Context, fields interface{}) {
+	p := NewProblem(c, http.StatusUnprocessableEntity, CodeValidationFailed, "request body failed validation")
+	p.Errors = fields
+	WriteProblem(c, p)
 }
 
 // FailAbort sends an error JSON response and aborts the Gin context.
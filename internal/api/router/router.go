@@ -1,34 +1,93 @@
 package router
 
 import (
+	"fmt"
+
 	"github.com/wb-go/wbf/ginext"
-	"github.com/wb-go/wbf/zlog"
 
+	"github.com/aliskhannn/sales-tracker/internal/api/handler/account"
 	"github.com/aliskhannn/sales-tracker/internal/api/handler/analytics"
+	"github.com/aliskhannn/sales-tracker/internal/api/handler/apikey"
+	"github.com/aliskhannn/sales-tracker/internal/api/handler/attachment"
 	"github.com/aliskhannn/sales-tracker/internal/api/handler/category"
 	"github.com/aliskhannn/sales-tracker/internal/api/handler/item"
+	"github.com/aliskhannn/sales-tracker/internal/api/handler/job"
+	"github.com/aliskhannn/sales-tracker/internal/api/handler/reconciliation"
+	"github.com/aliskhannn/sales-tracker/internal/api/handler/recurring"
+	"github.com/aliskhannn/sales-tracker/internal/api/handler/rule"
+	"github.com/aliskhannn/sales-tracker/internal/api/handler/schedule"
+	"github.com/aliskhannn/sales-tracker/internal/api/handler/stream"
+	"github.com/aliskhannn/sales-tracker/internal/api/handler/tag"
+	"github.com/aliskhannn/sales-tracker/internal/api/handler/webhook"
+	"github.com/aliskhannn/sales-tracker/internal/api/handler/workspace"
+	"github.com/aliskhannn/sales-tracker/internal/api/middleware"
+	"github.com/aliskhannn/sales-tracker/internal/logging"
+	"github.com/aliskhannn/sales-tracker/internal/metrics"
+	"github.com/aliskhannn/sales-tracker/internal/model"
 )
 
 // New creates a new Gin engine and sets up routes for the SalesTracker API.
+// Every /api route requires the caller authenticated by authenticate to have
+// the scope of its route group; reads need the viewer role, writes the editor
+// role and API key, workspace, webhook and scheduled report management the
+// admin role, since webhooks and reports send data out of the system; analytics
+// jobs only compute reports, so viewers may enqueue them. Data routes run
+// in the workspace chosen by resolveWorkspace. Callers are rate limited by
+// rateLimit per route group once authenticated, and their reads are bounded
+// by the statement timeout queryTimeout sets for the route. Client IPs are
+// taken from X-Forwarded-For and X-Real-IP only for requests from
+// trustedProxies, since clients could set them to evade per-IP limits.
 func New(
 	categoryHandler *category.Handler,
 	itemHandler *item.Handler,
 	analyticsHandler *analytics.Handler,
-) *ginext.Engine {
+	recurringHandler *recurring.Handler,
+	accountHandler *account.Handler,
+	ruleHandler *rule.Handler,
+	tagHandler *tag.Handler,
+	attachmentHandler *attachment.Handler,
+	reconciliationHandler *reconciliation.Handler,
+	apiKeyHandler *apikey.Handler,
+	workspaceHandler *workspace.Handler,
+	webhookHandler *webhook.Handler,
+	streamHandler *stream.Handler,
+	jobHandler *job.Handler,
+	scheduleHandler *schedule.Handler,
+	authenticate ginext.HandlerFunc,
+	rateLimit ginext.HandlerFunc,
+	queryTimeout ginext.HandlerFunc,
+	resolveWorkspace ginext.HandlerFunc,
+	trustedProxies []string,
+) (*ginext.Engine, error) {
 	r := ginext.New()
+	if err := r.SetTrustedProxies(trustedProxies); err != nil {
+		return nil, fmt.Errorf("set trusted proxies: %w", err)
+	}
 
-	r.Use(ginext.Logger())
+	r.Use(middleware.RequestID())
+	r.Use(middleware.Tracing())
+	r.Use(middleware.Logger())
+	r.Use(middleware.Metrics())
+	r.Use(middleware.Consistency())
 	r.Use(ginext.Recovery())
 
 	// Health check route
 	r.GET("/health", func(c *ginext.Context) {
-		zlog.Logger.Info().Msg("Health check requested")
+		logging.Ctx(c.Request.Context()).Info().Msg("Health check requested")
 		c.JSON(200, map[string]string{"status": "ok"})
 	})
 
-	api := r.Group("/api")
+	// Prometheus metrics, unauthenticated like the health check; restrict
+	// access to the scraper at the network level.
+	r.GET("/metrics", func(c *ginext.Context) {
+		metrics.Handler().ServeHTTP(c.Writer, c.Request)
+	})
+
+	api := r.Group("/api", authenticate, rateLimit, queryTimeout)
 	{
-		categories := api.Group("/categories")
+		scoped := api.Group("", resolveWorkspace)
+
+		categories := scoped.Group("/categories", middleware.Authorize(model.ScopeCategories))
 		{
 			categories.POST("", categoryHandler.Create)
 			categories.GET("", categoryHandler.List)
@@ -37,24 +96,147 @@ func New(
 			categories.DELETE("/:id", categoryHandler.Delete)
 		}
 
-		items := api.Group("/items")
+		items := scoped.Group("/items", middleware.Authorize(model.ScopeItems))
 		{
 			items.POST("", itemHandler.Create)
 			items.GET("", itemHandler.List)
+			items.GET("/duplicates", itemHandler.Duplicates)
+			items.POST("/import", itemHandler.Import)
 			items.GET("/:id", itemHandler.GetByID)
 			items.PUT("/:id", itemHandler.Update)
 			items.DELETE("/:id", itemHandler.Delete)
+			items.GET("/:id/splits", itemHandler.ListSplits)
+			items.PUT("/:id/splits", itemHandler.ReplaceSplits)
+			items.DELETE("/:id/splits", itemHandler.DeleteSplits)
+			items.GET("/:id/refunds", itemHandler.Refunds)
+			items.POST("/:id/merge", itemHandler.Merge)
+			items.GET("/:id/tags", tagHandler.ListByItem)
+			items.POST("/:id/tags", tagHandler.Attach)
+			items.DELETE("/:id/tags/:tag_id", tagHandler.Detach)
+			items.POST("/:id/attachments", attachmentHandler.Upload)
+			items.GET("/:id/attachments", attachmentHandler.List)
+			items.GET("/:id/attachments/:attachment_id", attachmentHandler.Download)
+			items.DELETE("/:id/attachments/:attachment_id", attachmentHandler.Delete)
+		}
+
+		accounts := scoped.Group("/accounts", middleware.Authorize(model.ScopeAccounts))
+		{
+			accounts.POST("", accountHandler.Create)
+			accounts.GET("", accountHandler.List)
+			accounts.GET("/:id", accountHandler.GetByID)
+			accounts.PUT("/:id", accountHandler.Update)
+			accounts.DELETE("/:id", accountHandler.Delete)
+			accounts.GET("/:id/balance", accountHandler.Balance)
+		}
+
+		scoped.POST("/transfers", middleware.Authorize(model.ScopeItems), itemHandler.CreateTransfer)
+
+		recurringItems := scoped.Group("/recurring-items", middleware.Authorize(model.ScopeRecurring))
+		{
+			recurringItems.POST("", recurringHandler.Create)
+			recurringItems.GET("", recurringHandler.List)
+			recurringItems.GET("/:id", recurringHandler.GetByID)
+			recurringItems.PUT("/:id", recurringHandler.Update)
+			recurringItems.DELETE("/:id", recurringHandler.Delete)
+			recurringItems.POST("/:id/pause", recurringHandler.Pause)
+			recurringItems.POST("/:id/resume", recurringHandler.Resume)
+			recurringItems.POST("/:id/skip", recurringHandler.Skip)
+			recurringItems.GET("/:id/occurrences", recurringHandler.ListOccurrences)
+		}
+
+		tags := scoped.Group("/tags", middleware.Authorize(model.ScopeTags))
+		{
+			tags.POST("", tagHandler.Create)
+			tags.GET("", tagHandler.List)
+			tags.GET("/:id", tagHandler.GetByID)
+			tags.PUT("/:id", tagHandler.Update)
+			tags.DELETE("/:id", tagHandler.Delete)
 		}
 
-		analyticsGroup := api.Group("/analytics")
+		rules := scoped.Group("/rules", middleware.Authorize(model.ScopeRules))
+		{
+			rules.POST("", ruleHandler.Create)
+			rules.GET("", ruleHandler.List)
+			rules.POST("/apply", ruleHandler.Apply)
+			rules.GET("/:id", ruleHandler.GetByID)
+			rules.PUT("/:id", ruleHandler.Update)
+			rules.DELETE("/:id", ruleHandler.Delete)
+		}
+
+		reconciliations := scoped.Group("/reconciliations", middleware.Authorize(model.ScopeReconciliations))
+		{
+			reconciliations.POST("", reconciliationHandler.Upload)
+			reconciliations.GET("", reconciliationHandler.List)
+			reconciliations.GET("/:id", reconciliationHandler.GetByID)
+			reconciliations.POST("/:id/auto-match", reconciliationHandler.AutoMatch)
+			reconciliations.POST("/:id/lines/:line_id/confirm", reconciliationHandler.Confirm)
+			reconciliations.POST("/:id/lines/:line_id/unmatch", reconciliationHandler.Unmatch)
+			reconciliations.POST("/:id/lines/:line_id/create", reconciliationHandler.CreateItem)
+		}
+
+		analyticsGroup := scoped.Group("/analytics", middleware.Authorize(model.ScopeAnalytics))
 		{
 			analyticsGroup.GET("/sum", analyticsHandler.Sum)
 			analyticsGroup.GET("/avg", analyticsHandler.Avg)
 			analyticsGroup.GET("/count", analyticsHandler.Count)
 			analyticsGroup.GET("/median", analyticsHandler.Median)
 			analyticsGroup.GET("/percentile", analyticsHandler.Percentile)
+			analyticsGroup.GET("/categories", analyticsHandler.ByCategory)
+			analyticsGroup.GET("/revenue", analyticsHandler.Revenue)
+			analyticsGroup.GET("/tags", analyticsHandler.ByTag)
+		}
+
+		jobs := scoped.Group("/analytics/jobs", middleware.RequireRole(model.RoleViewer, model.ScopeAnalytics))
+		{
+			jobs.POST("", jobHandler.Create)
+			jobs.GET("", jobHandler.List)
+			jobs.GET("/:id", jobHandler.GetByID)
+			// Canceling discards another user's work, so viewers may not.
+			jobs.POST("/:id/cancel", middleware.RequireRole(model.RoleEditor, model.ScopeAnalytics), jobHandler.Cancel)
+		}
+
+		streams := scoped.Group("/stream")
+		{
+			streams.GET("/items", middleware.Authorize(model.ScopeItems), streamHandler.Items)
+			streams.GET("/analytics", middleware.Authorize(model.ScopeAnalytics), streamHandler.Analytics)
+		}
+
+		webhooks := scoped.Group("/webhooks", middleware.RequireRole(model.RoleAdmin, model.ScopeWebhooks))
+		{
+			webhooks.POST("", webhookHandler.Create)
+			webhooks.GET("", webhookHandler.List)
+			webhooks.GET("/:id", webhookHandler.GetByID)
+			webhooks.PUT("/:id", webhookHandler.Update)
+			webhooks.DELETE("/:id", webhookHandler.Delete)
+			webhooks.GET("/:id/deliveries", webhookHandler.Deliveries)
+			webhooks.POST("/:id/deliveries/:delivery_id/redeliver", webhookHandler.Redeliver)
+		}
+
+		reports := scoped.Group("/reports", middleware.RequireRole(model.RoleAdmin, model.ScopeReports))
+		{
+			reports.POST("", scheduleHandler.Create)
+			reports.GET("", scheduleHandler.List)
+			reports.GET("/:id", scheduleHandler.GetByID)
+			reports.PUT("/:id", scheduleHandler.Update)
+			reports.DELETE("/:id", scheduleHandler.Delete)
+			reports.GET("/:id/runs", scheduleHandler.Runs)
+		}
+
+		keys := api.Group("/keys", middleware.RequireRole(model.RoleAdmin, model.ScopeKeys))
+		{
+			keys.POST("", apiKeyHandler.Create)
+			keys.GET("", apiKeyHandler.List)
+			keys.DELETE("/:id", apiKeyHandler.Revoke)
+		}
+
+		workspaces := api.Group("/workspaces", middleware.RequireRole(model.RoleAdmin, model.ScopeWorkspaces))
+		{
+			workspaces.POST("", workspaceHandler.Create)
+			workspaces.GET("", workspaceHandler.List)
+			workspaces.GET("/:id", workspaceHandler.GetByID)
+			workspaces.PUT("/:id", workspaceHandler.Update)
 		}
 	}
 
-	return r
+	return r, nil
 }
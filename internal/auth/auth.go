@@ -0,0 +1,15 @@
+// Package auth implements API key generation and JWT bearer tokens.
+package auth
+
+import "errors"
+
+var (
+	ErrMissingCredentials = errors.New("missing credentials")
+	ErrInvalidAPIKey      = errors.New("invalid api key")
+	ErrInvalidToken       = errors.New("invalid token")
+	ErrTokenExpired       = errors.New("token expired")
+	ErrInsufficientRole   = errors.New("insufficient role")
+	ErrInsufficientScope  = errors.New("insufficient scope")
+	ErrWorkspaceForbidden = errors.New("workspace not accessible with these credentials")
+	ErrNoSecret           = errors.New("jwt secret is not configured")
+)
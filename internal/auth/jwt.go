@@ -0,0 +1,257 @@
+package auth
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/aliskhannn/sales-tracker/internal/model"
+)
+
+// jwtHeader is the only JOSE header accepted and issued: HS256-signed JWTs.
+var jwtHeader = base64.RawURLEncoding.EncodeToString([]byte(`{"alg":"HS256","typ":"JWT"}`))
+
+// Claims are the JWT claims understood by the API.
+//
+// Fields:
+//   - Subject, Issuer, Audience: registered claims (sub, iss, aud)
+//   - ExpiresAt, NotBefore, IssuedAt: registered claims as Unix seconds (exp, nbf, iat)
+//   - Role: role granted to the bearer
+//   - Scopes: route groups the bearer may access, empty allows all
+//   - WorkspaceID: workspace the bearer is bound to, nil allows all workspaces
+type Claims struct {
+	Subject     string     `json:"sub"`
+	Issuer      string     `json:"iss,omitempty"`
+	Audience    audience   `json:"aud,omitempty"`
+	ExpiresAt   int64      `json:"exp"`
+	NotBefore   int64      `json:"nbf,omitempty"`
+	IssuedAt    int64      `json:"iat,omitempty"`
+	Role        string     `json:"role"`
+	Scopes      []string   `json:"scopes,omitempty"`
+	WorkspaceID *uuid.UUID `json:"workspace_id,omitempty"`
+}
+
+// audience is the aud claim, a single string or an array of strings.
+type audience []string
+
+// MarshalJSON encodes a single audience as a string.
+func (a audience) MarshalJSON() ([]byte, error) {
+	if len(a) == 1 {
+		return json.Marshal(a[0])
+	}
+
+	return json.Marshal([]string(a))
+}
+
+// UnmarshalJSON decodes a string or an array of strings.
+func (a *audience) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err == nil {
+		*a = audience{s}
+		return nil
+	}
+
+	var list []string
+	if err := json.Unmarshal(data, &list); err != nil {
+		return err
+	}
+
+	*a = list
+	return nil
+}
+
+// contains reports whether aud is one of the audiences.
+func (a audience) contains(aud string) bool {
+	for _, v := range a {
+		if v == aud {
+			return true
+		}
+	}
+
+	return false
+}
+
+// JWT signs and verifies HS256 JWTs with a locally configured secret.
+type JWT struct {
+	secret   []byte
+	issuer   string
+	audience string
+	leeway   time.Duration
+	now      func() time.Time
+}
+
+// NewJWT creates a JWT signer and verifier. A non-empty issuer or audience
+// is required to be present in verified tokens and is set on signed ones;
+// leeway tolerates clock skew when checking exp and nbf. With an empty
+// secret JWTs are disabled: signing fails and every token is rejected.
+func NewJWT(secret, issuer, audience string, leeway time.Duration) *JWT {
+	return &JWT{
+		secret:   []byte(secret),
+		issuer:   issuer,
+		audience: audience,
+		leeway:   leeway,
+		now:      time.Now,
+	}
+}
+
+// Sign issues a token for subject with role and scopes, optionally bound to
+// a workspace, valid for ttl.
+func (j *JWT) Sign(subject, role string, scopes []string, workspaceID *uuid.UUID, ttl time.Duration) (string, error) {
+	if len(j.secret) == 0 {
+		return "", ErrNoSecret
+	}
+
+	now := j.now()
+	claims := Claims{
+		Subject:     subject,
+		Issuer:      j.issuer,
+		ExpiresAt:   now.Add(ttl).Unix(),
+		IssuedAt:    now.Unix(),
+		Role:        role,
+		Scopes:      scopes,
+		WorkspaceID: workspaceID,
+	}
+	if j.audience != "" {
+		claims.Audience = audience{j.audience}
+	}
+
+	payload, err := json.Marshal(claims)
+	if err != nil {
+		return "", fmt.Errorf("marshal claims: %w", err)
+	}
+
+	unsigned := jwtHeader + "." + base64.RawURLEncoding.EncodeToString(payload)
+
+	return unsigned + "." + base64.RawURLEncoding.EncodeToString(j.sign(unsigned)), nil
+}
+
+// Verify checks the signature and registered claims of a token and
+// returns its claims.
+func (j *JWT) Verify(token string) (*Claims, error) {
+	if len(j.secret) == 0 {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidToken, ErrNoSecret)
+	}
+
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return nil, ErrInvalidToken
+	}
+
+	if err := checkHeader(parts[0]); err != nil {
+		return nil, err
+	}
+
+	sig, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil || !hmac.Equal(sig, j.sign(parts[0]+"."+parts[1])) {
+		return nil, ErrInvalidToken
+	}
+
+	payload, err := base64.RawURLEncoding.DecodeString(parts[1])
+	if err != nil {
+		return nil, ErrInvalidToken
+	}
+
+	var claims Claims
+	if err = json.Unmarshal(payload, &claims); err != nil {
+		return nil, ErrInvalidToken
+	}
+
+	if err = j.checkClaims(&claims); err != nil {
+		return nil, err
+	}
+
+	return &claims, nil
+}
+
+// Authenticate verifies a bearer token and returns its principal.
+func (j *JWT) Authenticate(token string) (*model.Principal, error) {
+	claims, err := j.Verify(token)
+	if err != nil {
+		return nil, err
+	}
+
+	return &model.Principal{
+		Subject:     claims.Subject,
+		Role:        claims.Role,
+		Scopes:      claims.Scopes,
+		WorkspaceID: claims.WorkspaceID,
+		Method:      model.AuthJWT,
+	}, nil
+}
+
+// sign returns the HMAC-SHA256 signature of the unsigned token.
+func (j *JWT) sign(unsigned string) []byte {
+	mac := hmac.New(sha256.New, j.secret)
+	mac.Write([]byte(unsigned))
+	return mac.Sum(nil)
+}
+
+// checkHeader rejects tokens that are not HS256-signed JWTs, including
+// the "none" algorithm.
+func checkHeader(encoded string) error {
+	data, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil {
+		return ErrInvalidToken
+	}
+
+	var header struct {
+		Alg string `json:"alg"`
+		Typ string `json:"typ"`
+	}
+	if err = json.Unmarshal(data, &header); err != nil {
+		return ErrInvalidToken
+	}
+
+	if header.Alg != "HS256" || (header.Typ != "" && !strings.EqualFold(header.Typ, "JWT")) {
+		return ErrInvalidToken
+	}
+
+	return nil
+}
+
+// checkClaims validates the registered claims and the role of a token.
+func (j *JWT) checkClaims(c *Claims) error {
+	now := j.now()
+
+	if c.ExpiresAt == 0 {
+		return fmt.Errorf("%w: missing exp", ErrInvalidToken)
+	}
+
+	if now.After(time.Unix(c.ExpiresAt, 0).Add(j.leeway)) {
+		return ErrTokenExpired
+	}
+
+	if c.NotBefore != 0 && now.Add(j.leeway).Before(time.Unix(c.NotBefore, 0)) {
+		return fmt.Errorf("%w: not valid yet", ErrInvalidToken)
+	}
+
+	if c.Subject == "" {
+		return fmt.Errorf("%w: missing sub", ErrInvalidToken)
+	}
+
+	if j.issuer != "" && c.Issuer != j.issuer {
+		return fmt.Errorf("%w: unexpected iss", ErrInvalidToken)
+	}
+
+	if j.audience != "" && !c.Audience.contains(j.audience) {
+		return fmt.Errorf("%w: unexpected aud", ErrInvalidToken)
+	}
+
+	if !model.IsRole(c.Role) {
+		return fmt.Errorf("%w: unknown role", ErrInvalidToken)
+	}
+
+	for _, s := range c.Scopes {
+		if !model.IsScope(s) {
+			return fmt.Errorf("%w: unknown scope %q", ErrInvalidToken, s)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,177 @@
+package auth
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/aliskhannn/sales-tracker/internal/model"
+)
+
+const testSecret = "test-secret"
+
+// testNow is the clock of the verifier in tests.
+var testNow = time.Date(2025, 10, 1, 12, 0, 0, 0, time.UTC)
+
+// newTestJWT returns a verifier with fixed issuer, audience, leeway and clock.
+func newTestJWT(secret string) *JWT {
+	j := NewJWT(secret, "sales-tracker", "api", 30*time.Second)
+	j.now = func() time.Time { return testNow }
+	return j
+}
+
+// token builds a token from a raw header and payload signed with secret.
+func token(header, payload, secret string) string {
+	unsigned := base64.RawURLEncoding.EncodeToString([]byte(header)) + "." +
+		base64.RawURLEncoding.EncodeToString([]byte(payload))
+
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(unsigned))
+
+	return unsigned + "." + base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// stripSignature strips the signature of a token, keeping the trailing dot.
+func stripSignature(token string) string {
+	return token[:strings.LastIndex(token, ".")+1]
+}
+
+// claims returns a valid payload with the given fields replaced or added.
+func claims(fields ...string) string {
+	values := map[string]string{
+		"sub":  `"alice"`,
+		"iss":  `"sales-tracker"`,
+		"aud":  `"api"`,
+		"exp":  "1759323600", // testNow + 1h
+		"role": `"editor"`,
+	}
+	for i := 0; i+1 < len(fields); i += 2 {
+		values[fields[i]] = fields[i+1]
+	}
+
+	var parts []string
+	for k, v := range values {
+		if v != "" {
+			parts = append(parts, `"`+k+`":`+v)
+		}
+	}
+
+	return "{" + strings.Join(parts, ",") + "}"
+}
+
+func TestJWTVerify(t *testing.T) {
+	const hs256 = `{"alg":"HS256","typ":"JWT"}`
+
+	tests := []struct {
+		name    string
+		token   string
+		wantErr error
+	}{
+		{name: "valid", token: token(hs256, claims(), testSecret)},
+		{name: "header without typ", token: token(`{"alg":"HS256"}`, claims(), testSecret)},
+		{name: "audience list", token: token(hs256, claims("aud", `["web","api"]`), testSecret)},
+		{name: "scopes", token: token(hs256, claims("scopes", `["items","analytics"]`), testSecret)},
+		{name: "expired within leeway", token: token(hs256, claims("exp", "1759319980"), testSecret)},
+		{name: "not before within leeway", token: token(hs256, claims("nbf", "1759320020"), testSecret)},
+
+		{name: "alg none", token: token(`{"alg":"none","typ":"JWT"}`, claims(), testSecret), wantErr: ErrInvalidToken},
+		{name: "alg HS512", token: token(`{"alg":"HS512","typ":"JWT"}`, claims(), testSecret), wantErr: ErrInvalidToken},
+		{name: "alg RS256", token: token(`{"alg":"RS256","typ":"JWT"}`, claims(), testSecret), wantErr: ErrInvalidToken},
+		{name: "unexpected typ", token: token(`{"alg":"HS256","typ":"JWE"}`, claims(), testSecret), wantErr: ErrInvalidToken},
+		{name: "malformed header", token: token(`{"alg":`, claims(), testSecret), wantErr: ErrInvalidToken},
+		{name: "two parts", token: "a.b", wantErr: ErrInvalidToken},
+		{name: "empty", token: "", wantErr: ErrInvalidToken},
+		{name: "empty signature", token: stripSignature(token(hs256, claims(), testSecret)), wantErr: ErrInvalidToken},
+		{name: "wrong secret", token: token(hs256, claims(), "other-secret"), wantErr: ErrInvalidToken},
+		{name: "malformed payload", token: token(hs256, `{"sub":`, testSecret), wantErr: ErrInvalidToken},
+
+		{name: "expired", token: token(hs256, claims("exp", "1759319900"), testSecret), wantErr: ErrTokenExpired},
+		{name: "missing exp", token: token(hs256, claims("exp", ""), testSecret), wantErr: ErrInvalidToken},
+		{name: "not valid yet", token: token(hs256, claims("nbf", "1759320100"), testSecret), wantErr: ErrInvalidToken},
+		{name: "missing sub", token: token(hs256, claims("sub", ""), testSecret), wantErr: ErrInvalidToken},
+		{name: "missing iss", token: token(hs256, claims("iss", ""), testSecret), wantErr: ErrInvalidToken},
+		{name: "unexpected iss", token: token(hs256, claims("iss", `"someone-else"`), testSecret), wantErr: ErrInvalidToken},
+		{name: "missing aud", token: token(hs256, claims("aud", ""), testSecret), wantErr: ErrInvalidToken},
+		{name: "unexpected aud", token: token(hs256, claims("aud", `["web"]`), testSecret), wantErr: ErrInvalidToken},
+		{name: "unknown role", token: token(hs256, claims("role", `"root"`), testSecret), wantErr: ErrInvalidToken},
+		{name: "unknown scope", token: token(hs256, claims("scopes", `["everything"]`), testSecret), wantErr: ErrInvalidToken},
+	}
+
+	j := newTestJWT(testSecret)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, err := j.Verify(tt.token)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("Verify() error = %v, want %v", err, tt.wantErr)
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("Verify() error = %v", err)
+			}
+			if c.Subject != "alice" || c.Role != model.RoleEditor {
+				t.Errorf("Verify() claims = %+v", c)
+			}
+		})
+	}
+}
+
+func TestJWTVerifyTamperedPayload(t *testing.T) {
+	j := newTestJWT(testSecret)
+	signed := token(`{"alg":"HS256","typ":"JWT"}`, claims(), testSecret)
+
+	parts := strings.Split(signed, ".")
+	parts[1] = base64.RawURLEncoding.EncodeToString([]byte(claims("role", `"admin"`)))
+
+	if _, err := j.Verify(strings.Join(parts, ".")); !errors.Is(err, ErrInvalidToken) {
+		t.Fatalf("Verify() error = %v, want ErrInvalidToken", err)
+	}
+}
+
+func TestJWTNoSecret(t *testing.T) {
+	j := newTestJWT("")
+
+	if _, err := j.Sign("alice", model.RoleAdmin, nil, nil, time.Hour); !errors.Is(err, ErrNoSecret) {
+		t.Errorf("Sign() error = %v, want ErrNoSecret", err)
+	}
+
+	// A token signed with an empty key must not verify either.
+	if _, err := j.Verify(token(`{"alg":"HS256","typ":"JWT"}`, claims(), "")); !errors.Is(err, ErrInvalidToken) {
+		t.Errorf("Verify() error = %v, want ErrInvalidToken", err)
+	}
+}
+
+func TestJWTSignRoundTrip(t *testing.T) {
+	j := newTestJWT(testSecret)
+	workspaceID := uuid.New()
+
+	signed, err := j.Sign("alice", model.RoleViewer, []string{model.ScopeItems}, &workspaceID, time.Hour)
+	if err != nil {
+		t.Fatalf("Sign() error = %v", err)
+	}
+
+	principal, err := j.Authenticate(signed)
+	if err != nil {
+		t.Fatalf("Authenticate() error = %v", err)
+	}
+
+	if principal.Subject != "alice" || principal.Role != model.RoleViewer || principal.Method != model.AuthJWT ||
+		len(principal.Scopes) != 1 || principal.Scopes[0] != model.ScopeItems ||
+		principal.WorkspaceID == nil || *principal.WorkspaceID != workspaceID {
+		t.Errorf("Authenticate() = %+v", principal)
+	}
+
+	// The token expires after its ttl plus the leeway.
+	j.now = func() time.Time { return testNow.Add(time.Hour + time.Minute) }
+	if _, err = j.Verify(signed); !errors.Is(err, ErrTokenExpired) {
+		t.Errorf("Verify() after ttl error = %v, want ErrTokenExpired", err)
+	}
+}
@@ -0,0 +1,51 @@
+package auth
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"strings"
+)
+
+// KeyPrefix starts every API key, telling keys apart from JWTs.
+const KeyPrefix = "st_"
+
+// keyIDLen is the length of the random key ID following KeyPrefix.
+const keyIDLen = 8
+
+// keySecretLen is the number of random bytes of the key secret.
+const keySecretLen = 32
+
+// GenerateKey returns a new random API key of the form
+// st_<id>_<secret> together with its listing prefix and hash.
+func GenerateKey() (key, prefix, hash string, err error) {
+	id := make([]byte, keyIDLen/2)
+	secret := make([]byte, keySecretLen)
+
+	if _, err = rand.Read(id); err != nil {
+		return "", "", "", fmt.Errorf("generate key id: %w", err)
+	}
+
+	if _, err = rand.Read(secret); err != nil {
+		return "", "", "", fmt.Errorf("generate key secret: %w", err)
+	}
+
+	prefix = KeyPrefix + hex.EncodeToString(id)
+	key = prefix + "_" + base64.RawURLEncoding.EncodeToString(secret)
+
+	return key, prefix, HashKey(key), nil
+}
+
+// HashKey returns the hex-encoded SHA-256 hash of an API key. Keys carry
+// 256 bits of entropy, so a fast unsalted hash is enough to protect them.
+func HashKey(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
+
+// IsKey reports whether credential looks like an API key rather than a JWT.
+func IsKey(credential string) bool {
+	return strings.HasPrefix(credential, KeyPrefix)
+}
@@ -0,0 +1,54 @@
+// Package cache caches computed results of a workspace by key. Every entry
+// records the workspace and the range of dates its result was computed from,
+// so that a change to the data of some dates invalidates only the entries it
+// may affect. Entries are kept in a Store, in process memory by default.
+package cache
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Scope is the data an entry was computed from: the items of a workspace that
+// occurred between From and To, both inclusive. A nil bound is unbounded.
+type Scope struct {
+	Workspace uuid.UUID
+	From      *time.Time
+	To        *time.Time
+}
+
+// All is the scope of the data of all workspaces.
+var All = Scope{}
+
+// Overlaps reports whether s and o share any data. The nil workspace is part
+// of every scope.
+func (s Scope) Overlaps(o Scope) bool {
+	if s.Workspace != uuid.Nil && o.Workspace != uuid.Nil && s.Workspace != o.Workspace {
+		return false
+	}
+
+	if s.From != nil && o.To != nil && o.To.Before(*s.From) {
+		return false
+	}
+
+	if s.To != nil && o.From != nil && o.From.After(*s.To) {
+		return false
+	}
+
+	return true
+}
+
+// Store keeps cached entries.
+type Store interface {
+	// Get returns the value of the entry with key, and false if there is none
+	// or it expired.
+	Get(ctx context.Context, key string) ([]byte, bool, error)
+
+	// Set stores value under key until ttl elapses or data in scope changes.
+	Set(ctx context.Context, key string, value []byte, scope Scope, ttl time.Duration) error
+
+	// Invalidate removes the entries whose scope overlaps scope.
+	Invalidate(ctx context.Context, scope Scope) error
+}
@@ -0,0 +1,107 @@
+package cache
+
+import (
+	"container/list"
+	"context"
+	"sync"
+	"time"
+)
+
+// defaultMaxEntries bounds the LRU store when no size is configured.
+const defaultMaxEntries = 10000
+
+// LRU keeps up to a fixed number of entries in process memory, so every
+// server instance has a cache of its own. When it is full, the least recently
+// used entry is evicted.
+type LRU struct {
+	mu      sync.Mutex
+	max     int
+	order   *list.List // most recently used first
+	entries map[string]*list.Element
+	now     func() time.Time
+}
+
+// lruEntry is a single entry of the LRU store.
+type lruEntry struct {
+	key     string
+	value   []byte
+	scope   Scope
+	expires time.Time
+}
+
+// NewLRU creates an empty LRU store holding up to maxEntries entries.
+func NewLRU(maxEntries int) *LRU {
+	if maxEntries <= 0 {
+		maxEntries = defaultMaxEntries
+	}
+
+	return &LRU{
+		max:     maxEntries,
+		order:   list.New(),
+		entries: make(map[string]*list.Element),
+		now:     time.Now,
+	}
+}
+
+// Get implements Store.
+func (s *LRU) Get(_ context.Context, key string) ([]byte, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	el, ok := s.entries[key]
+	if !ok {
+		return nil, false, nil
+	}
+
+	e := el.Value.(*lruEntry)
+	if !s.now().Before(e.expires) {
+		s.remove(el)
+		return nil, false, nil
+	}
+
+	s.order.MoveToFront(el)
+	return e.value, true, nil
+}
+
+// Set implements Store.
+func (s *LRU) Set(_ context.Context, key string, value []byte, scope Scope, ttl time.Duration) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	e := &lruEntry{key: key, value: value, scope: scope, expires: s.now().Add(ttl)}
+
+	if el, ok := s.entries[key]; ok {
+		el.Value = e
+		s.order.MoveToFront(el)
+		return nil
+	}
+
+	s.entries[key] = s.order.PushFront(e)
+	for s.order.Len() > s.max {
+		s.remove(s.order.Back())
+	}
+
+	return nil
+}
+
+// Invalidate implements Store.
+func (s *LRU) Invalidate(_ context.Context, scope Scope) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for el := s.order.Front(); el != nil; {
+		next := el.Next()
+		if el.Value.(*lruEntry).scope.Overlaps(scope) {
+			s.remove(el)
+		}
+		el = next
+	}
+
+	return nil
+}
+
+// remove removes an entry.
+func (s *LRU) remove(el *list.Element) {
+	s.order.Remove(el)
+	delete(s.entries, el.Value.(*lruEntry).key)
+}
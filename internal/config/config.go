@@ -10,9 +10,25 @@ import (
 )
 
 type Config struct {
-	Server    Server    `mapstructure:"server"`
-	Database  Database  `mapstructure:"database"`
-	Analytics Analytics `mapstructure:"analytics"`
+	Server         Server         `mapstructure:"server"`
+	Database       Database       `mapstructure:"database"`
+	Analytics      Analytics      `mapstructure:"analytics"`
+	Recurring      Recurring      `mapstructure:"recurring"`
+	Duplicates     Duplicates     `mapstructure:"duplicates"`
+	Attachments    Attachments    `mapstructure:"attachments"`
+	Reconciliation Reconciliation `mapstructure:"reconciliation"`
+	Import         Import         `mapstructure:"import"`
+	Validation     Validation     `mapstructure:"validation"`
+	Auth           Auth           `mapstructure:"auth"`
+	Workspaces     Workspaces     `mapstructure:"workspaces"`
+	Webhooks       Webhooks       `mapstructure:"webhooks"`
+	Stream         Stream         `mapstructure:"stream"`
+	Tracing        Tracing        `mapstructure:"tracing"`
+	RateLimit      RateLimit      `mapstructure:"rate_limit"`
+	QueryTimeout   QueryTimeout   `mapstructure:"query_timeout"`
+	Jobs           Jobs           `mapstructure:"jobs"`
+	Reports        Reports        `mapstructure:"reports"`
+	SMTP           SMTP           `mapstructure:"smtp"`
 }
 
 // Server holds HTTP server-related configuration.
@@ -20,6 +36,10 @@ type Server struct {
 	HTTPPort     string        `mapstructure:"http_port"` // HTTP port to listen on
 	ReadTimeout  time.Duration `mapstructure:"read_timeout"`
 	WriteTimeout time.Duration `mapstructure:"write_timeout"`
+
+	// TrustedProxies are the IPs and CIDRs of reverse proxies whose
+	// X-Forwarded-For header is trusted for client IPs; none if empty.
+	TrustedProxies []string `mapstructure:"trusted_proxies"`
 }
 
 // Database holds database master and slave configuration.
@@ -30,6 +50,16 @@ type Database struct {
 	MaxOpenConnections int           `mapstructure:"max_open_connections"`
 	MaxIdleConnections int           `mapstructure:"max_idle_connections"`
 	ConnMaxLifetime    time.Duration `mapstructure:"conn_max_lifetime"`
+
+	Routing Routing `mapstructure:"routing"`
+}
+
+// Routing holds configuration of how reads are routed to the slaves.
+type Routing struct {
+	Strategy            string        `mapstructure:"strategy"`              // "round_robin" or "least_loaded" (fewest connections in use)
+	HealthCheckInterval time.Duration `mapstructure:"health_check_interval"` // how often slaves are checked
+	HealthCheckTimeout  time.Duration `mapstructure:"health_check_timeout"`  // timeout of a single check
+	MaxLag              time.Duration `mapstructure:"max_lag"`               // slaves lagging more are skipped until they catch up, 0 disables
 }
 
 // DatabaseNode holds connection parameters for a single database node.
@@ -51,7 +81,146 @@ func (n DatabaseNode) DSN() string {
 }
 
 type Analytics struct {
-	PercentileDefault float64 `mapstructure:"percentile_default"`
+	PercentileDefault float64        `mapstructure:"percentile_default"`
+	Cache             AnalyticsCache `mapstructure:"cache"`
+}
+
+// AnalyticsCache holds configuration of the analytics result cache.
+type AnalyticsCache struct {
+	Enabled    bool          `mapstructure:"enabled"`
+	TTL        time.Duration `mapstructure:"ttl"`         // how long results are cached at most
+	MaxEntries int           `mapstructure:"max_entries"` // results kept in memory, least recently used are evicted
+	MaxAge     time.Duration `mapstructure:"max_age"`     // how long clients may reuse a result without revalidating its ETag
+}
+
+// Recurring holds configuration of the recurring items worker.
+type Recurring struct {
+	PollInterval time.Duration `mapstructure:"poll_interval"` // how often due occurrences are materialized
+}
+
+// Attachments holds configuration of item attachment storage.
+type Attachments struct {
+	Dir          string   `mapstructure:"dir"`           // local directory attachments are stored in
+	MaxSize      int64    `mapstructure:"max_size"`      // max size of a single attachment in bytes
+	AllowedTypes []string `mapstructure:"allowed_types"` // sniffed content types accepted, empty allows all
+}
+
+// Reconciliation holds configuration of bank statement reconciliation.
+type Reconciliation struct {
+	DateWindow  time.Duration `mapstructure:"date_window"`   // max occurred_at distance between a statement line and its item
+	MaxFileSize int64         `mapstructure:"max_file_size"` // max size of an uploaded statement in bytes
+}
+
+// Validation holds limits of request validation.
+type Validation struct {
+	MaxFuture       time.Duration `mapstructure:"max_future"`        // how far in the future occurred_at may be
+	MaxMetadataSize int           `mapstructure:"max_metadata_size"` // max size of item metadata in bytes
+}
+
+// Auth holds configuration of API authentication.
+type Auth struct {
+	Enabled     bool          `mapstructure:"enabled"` // when false every request is treated as an admin
+	JWTSecret   string        // HS256 key JWTs are signed with, from JWT_SECRET; JWTs are rejected if empty
+	JWTIssuer   string        `mapstructure:"jwt_issuer"`   // required iss claim, empty accepts any
+	JWTAudience string        `mapstructure:"jwt_audience"` // required aud claim, empty accepts any
+	JWTLeeway   time.Duration `mapstructure:"jwt_leeway"`   // tolerated clock skew for exp and nbf
+}
+
+// Workspaces holds configuration of multi-tenant workspaces.
+type Workspaces struct {
+	Default          string `mapstructure:"default"`            // ID or slug of the workspace used when a request names none
+	RowLevelSecurity bool   `mapstructure:"row_level_security"` // set app.workspace_id on connections for the RLS policies
+}
+
+// Webhooks holds configuration of webhook delivery.
+type Webhooks struct {
+	PollInterval time.Duration `mapstructure:"poll_interval"` // how often the outbox and due deliveries are checked
+	BatchSize    int           `mapstructure:"batch_size"`    // max events fanned out and deliveries sent per round
+	Timeout      time.Duration `mapstructure:"timeout"`       // timeout of a single delivery request
+	MaxAttempts  int           `mapstructure:"max_attempts"`  // attempts after which a delivery is marked failed
+	BackoffBase  time.Duration `mapstructure:"backoff_base"`  // delay before the first retry, doubled for each further one
+	BackoffMax   time.Duration `mapstructure:"backoff_max"`   // upper bound of the retry delay
+
+	AllowPrivateTargets bool `mapstructure:"allow_private_targets"` // allow non-public webhook URLs, for development only
+}
+
+// Stream holds configuration of the Server-Sent Events streams.
+type Stream struct {
+	Heartbeat         time.Duration `mapstructure:"heartbeat"`          // interval of keep-alive comments on idle streams
+	AnalyticsInterval time.Duration `mapstructure:"analytics_interval"` // min interval between totals of an analytics stream
+	Retention         time.Duration `mapstructure:"retention"`          // how long item changes are kept for Last-Event-ID replay
+}
+
+// Tracing holds configuration of request tracing.
+type Tracing struct {
+	Enabled     bool          `mapstructure:"enabled"`
+	Exporter    string        `mapstructure:"exporter"`     // "otlp" to send spans to an OTLP/HTTP receiver, "stdout" to print them
+	Endpoint    string        `mapstructure:"endpoint"`     // base URL of the OTLP/HTTP receiver, overridden by OTEL_EXPORTER_OTLP_ENDPOINT
+	ServiceName string        `mapstructure:"service_name"` // service.name of the exported spans
+	SampleRatio float64       `mapstructure:"sample_ratio"` // share of new traces recorded (0.0–1.0)
+	Timeout     time.Duration `mapstructure:"timeout"`      // timeout of a single export
+}
+
+// RateLimit holds configuration of the per-client rate limits of the API.
+type RateLimit struct {
+	Enabled bool                     `mapstructure:"enabled"`
+	Default RateLimitRule            `mapstructure:"default"` // limit of route groups not listed in groups
+	Groups  map[string]RateLimitRule `mapstructure:"groups"`  // limits by route group, e.g. analytics for /api/analytics
+}
+
+// RateLimitRule is a token bucket limit of a route group.
+type RateLimitRule struct {
+	Requests int           `mapstructure:"requests"` // requests allowed per period on average
+	Period   time.Duration `mapstructure:"period"`
+	Burst    int           `mapstructure:"burst"` // requests allowed at once, requests if zero
+}
+
+// QueryTimeout holds the statement timeouts of analytics reads by endpoint.
+type QueryTimeout struct {
+	Default   time.Duration            `mapstructure:"default"`   // timeout of endpoints not listed in endpoints, none if zero
+	Endpoints map[string]time.Duration `mapstructure:"endpoints"` // timeouts by route, e.g. /api/analytics/percentile
+}
+
+// Jobs holds configuration of asynchronous analytics jobs.
+type Jobs struct {
+	Workers      int           `mapstructure:"workers"`       // number of jobs run concurrently
+	PollInterval time.Duration `mapstructure:"poll_interval"` // how often idle workers check for queued jobs
+	Heartbeat    time.Duration `mapstructure:"heartbeat"`     // how often a running job records its progress and renews its lease
+	Lease        time.Duration `mapstructure:"lease"`         // how long a job stays claimed without a heartbeat, above heartbeat
+	Timeout      time.Duration `mapstructure:"timeout"`       // max duration of a single attempt
+	Retention    time.Duration `mapstructure:"retention"`     // how long finished jobs and their results are kept
+	MaxAttempts  int           `mapstructure:"max_attempts"`  // attempts after which a job abandoned by its worker is failed
+}
+
+// Reports holds configuration of the scheduled report scheduler.
+type Reports struct {
+	PollInterval time.Duration `mapstructure:"poll_interval"` // how often due reports are checked
+	Lease        time.Duration `mapstructure:"lease"`         // how long a report stays claimed by a scheduler, above timeout
+	Timeout      time.Duration `mapstructure:"timeout"`       // max duration of computing and sending a single report
+	MaxAttempts  int           `mapstructure:"max_attempts"`  // attempts at an occurrence before it is given up
+	RetryDelay   time.Duration `mapstructure:"retry_delay"`   // delay before retrying a failed occurrence
+}
+
+// SMTP holds configuration of the SMTP server reports are sent through.
+type SMTP struct {
+	Host     string        `mapstructure:"host"`
+	Port     int           `mapstructure:"port"`
+	Username string        `mapstructure:"username"` // authenticates with PLAIN if set
+	Password string        // from SMTP_PASSWORD
+	From     string        `mapstructure:"from"`    // sender address, e.g. "SalesTracker <reports@example.com>"
+	TLS      string        `mapstructure:"tls"`     // "none", "starttls" or "tls"
+	Timeout  time.Duration `mapstructure:"timeout"` // timeout of sending a single email
+}
+
+// Import holds configuration of bank file item imports.
+type Import struct {
+	MaxFileSize int64 `mapstructure:"max_file_size"` // max size of an imported bank file in bytes
+}
+
+// Duplicates holds default tolerances of duplicate item detection.
+type Duplicates struct {
+	TimeWindow      time.Duration `mapstructure:"time_window"`      // max occurred_at distance between duplicates
+	TitleSimilarity float64       `mapstructure:"title_similarity"` // min pg_trgm similarity of titles (0.0–1.0)
 }
 
 func MustLoad() *Config {
@@ -71,6 +240,18 @@ func MustLoad() *Config {
 
 	cfg.Database.Master.User = os.Getenv("DB_USER")
 	cfg.Database.Master.Pass = os.Getenv("DB_PASSWORD")
+	for i := range cfg.Database.Slaves {
+		// Slaves use the master's credentials unless they have their own.
+		if cfg.Database.Slaves[i].User == "" {
+			cfg.Database.Slaves[i].User = cfg.Database.Master.User
+			cfg.Database.Slaves[i].Pass = cfg.Database.Master.Pass
+		}
+	}
+	cfg.Auth.JWTSecret = os.Getenv("JWT_SECRET")
+	cfg.SMTP.Password = os.Getenv("SMTP_PASSWORD")
+	if endpoint := os.Getenv("OTEL_EXPORTER_OTLP_ENDPOINT"); endpoint != "" {
+		cfg.Tracing.Endpoint = endpoint
+	}
 
 	return &cfg
 }
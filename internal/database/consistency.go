@@ -0,0 +1,37 @@
+package database
+
+import (
+	"context"
+	"errors"
+)
+
+// Read consistency levels.
+const (
+	// Eventual reads may go to a replica and miss the latest writes.
+	Eventual = "eventual"
+
+	// Strong reads go to the master and see all committed writes.
+	Strong = "strong"
+)
+
+// ErrInvalidConsistency is returned for an unknown consistency level.
+var ErrInvalidConsistency = errors.New("invalid consistency, must be eventual or strong")
+
+// consistencyKey is the context key the consistency level is stored under.
+type consistencyKey struct{}
+
+// WithConsistency returns a copy of ctx whose reads have the given
+// consistency level.
+func WithConsistency(ctx context.Context, level string) context.Context {
+	return context.WithValue(ctx, consistencyKey{}, level)
+}
+
+// Consistency returns the consistency level of reads in ctx, Eventual unless
+// set otherwise.
+func Consistency(ctx context.Context) string {
+	if level, ok := ctx.Value(consistencyKey{}).(string); ok {
+		return level
+	}
+
+	return Eventual
+}
@@ -0,0 +1,121 @@
+// Package database opens the master and slave connection pools. Connections
+// can be wrapped to act on every statement, e.g. to carry the workspace of a
+// request to PostgreSQL or to trace queries.
+package database
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+
+	"github.com/lib/pq"
+	"github.com/wb-go/wbf/dbpg"
+
+	"github.com/aliskhannn/sales-tracker/internal/logging"
+)
+
+// Conn is the set of driver interfaces implemented by lib/pq connections. A
+// Wrapper is given connections implementing it and must return such as well.
+type Conn interface {
+	driver.Conn
+	driver.QueryerContext
+	driver.ExecerContext
+	driver.ConnPrepareContext
+	driver.ConnBeginTx
+	driver.Pinger
+	driver.SessionResetter
+	driver.Validator
+}
+
+// Wrapper wraps the connector of a pool; the connections it creates must
+// implement Conn.
+type Wrapper func(driver.Connector) driver.Connector
+
+// Open connects to the master and slave databases like dbpg.New. With
+// wrappers, the connectors of all pools are wrapped by them in order, so the
+// last one sees statements first.
+func Open(masterDSN string, slaveDSNs []string, opts *dbpg.Options, wrappers ...Wrapper) (*dbpg.DB, error) {
+	db, err := dbpg.New(masterDSN, slaveDSNs, opts)
+	if err != nil || len(wrappers) == 0 {
+		return db, err
+	}
+
+	// Replace the plain connection pools, which dbpg.New opens without
+	// connecting, keeping its slave balancer.
+	_ = db.Master.Close()
+	if db.Master, err = open(masterDSN, opts, wrappers); err != nil {
+		return nil, err
+	}
+
+	for i, dsn := range slaveDSNs {
+		_ = db.Slaves[i].Close()
+		if db.Slaves[i], err = open(dsn, opts, wrappers); err != nil {
+			return nil, err
+		}
+	}
+
+	return db, nil
+}
+
+// open opens a connection pool whose connections are wrapped by wrappers.
+func open(dsn string, opts *dbpg.Options, wrappers []Wrapper) (*sql.DB, error) {
+	c, err := pq.NewConnector(dsn)
+	if err != nil {
+		return nil, fmt.Errorf("create connector: %w", err)
+	}
+
+	var connector driver.Connector = &pqConnector{pq: c}
+	for _, wrap := range wrappers {
+		connector = wrap(connector)
+	}
+
+	db := sql.OpenDB(connector)
+	if opts != nil {
+		if opts.MaxOpenConns > 0 {
+			db.SetMaxOpenConns(opts.MaxOpenConns)
+		}
+		if opts.MaxIdleConns > 0 {
+			db.SetMaxIdleConns(opts.MaxIdleConns)
+		}
+		if opts.ConnMaxLifetime > 0 {
+			db.SetConnMaxLifetime(opts.ConnMaxLifetime)
+		}
+	}
+
+	return db, nil
+}
+
+// pqConnector creates lib/pq connections, checking that they implement Conn.
+type pqConnector struct {
+	pq *pq.Connector
+}
+
+// Connect implements driver.Connector.
+func (c *pqConnector) Connect(ctx context.Context) (driver.Conn, error) {
+	cn, err := c.pq.Connect(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, ok := cn.(Conn); !ok {
+		_ = cn.Close()
+		return nil, fmt.Errorf("unexpected driver connection %T", cn)
+	}
+
+	return cn, nil
+}
+
+// Driver implements driver.Connector.
+func (c *pqConnector) Driver() driver.Driver {
+	return c.pq.Driver()
+}
+
+// Rollback rolls back tx unless it was committed, logging a failure with the
+// logger of ctx. It is deferred right after a transaction begins.
+func Rollback(ctx context.Context, tx *sql.Tx) {
+	if err := tx.Rollback(); err != nil && !errors.Is(err, sql.ErrTxDone) {
+		logging.Ctx(ctx).Error().Err(err).Msg("failed to roll back transaction")
+	}
+}
@@ -0,0 +1,247 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"strconv"
+	"sync/atomic"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/wb-go/wbf/dbpg"
+
+	"github.com/aliskhannn/sales-tracker/internal/logging"
+	"github.com/aliskhannn/sales-tracker/internal/metrics"
+)
+
+// Replica selection strategies.
+const (
+	RoundRobin  = "round_robin"
+	LeastLoaded = "least_loaded"
+)
+
+// Defaults used when an option is not configured.
+const (
+	defaultHealthCheckInterval = 5 * time.Second
+	defaultHealthCheckTimeout  = 2 * time.Second
+)
+
+// ErrInvalidStrategy is returned for an unknown replica selection strategy.
+var ErrInvalidStrategy = errors.New("invalid replica selection strategy")
+
+// lagQuery returns the replication lag of a replica in seconds: zero when it
+// streams WAL from the primary and has replayed all of it, otherwise the age
+// of the last replayed transaction. A replica that is not streaming, e.g.
+// because its WAL receiver lost the primary, cannot tell whether it is behind
+// and reports the age of its last replay, or infinity if it replayed nothing
+// yet. On a primary it returns zero.
+//
+// The status of pg_stat_wal_receiver is only visible to superusers and
+// members of pg_read_all_stats; replicas checked by other roles are never
+// considered streaming.
+const lagQuery = `
+	SELECT CASE
+		WHEN NOT pg_is_in_recovery() THEN 0
+		WHEN NOT EXISTS (SELECT 1 FROM pg_stat_wal_receiver WHERE status = 'streaming')
+			THEN COALESCE(EXTRACT(EPOCH FROM now() - pg_last_xact_replay_timestamp())::float8, 'Infinity')
+		WHEN pg_last_wal_receive_lsn() = pg_last_wal_replay_lsn() THEN 0
+		ELSE COALESCE(EXTRACT(EPOCH FROM now() - pg_last_xact_replay_timestamp())::float8, 0)
+	END;
+`
+
+var (
+	reads = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: metrics.Namespace,
+		Name:      "db_reads_total",
+		Help:      "Number of reads routed to a database by target: master or slave_<n>.",
+	}, []string{"db"})
+	replicaHealthy = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: metrics.Namespace,
+		Name:      "db_replica_healthy",
+		Help:      "Whether a replica passed its last health check and lag check (1) or not (0).",
+	}, []string{"db"})
+	replicaLag = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: metrics.Namespace,
+		Name:      "db_replica_lag_seconds",
+		Help:      "Replication lag of a replica measured by its last health check in seconds.",
+	}, []string{"db"})
+)
+
+// RoutingOptions configures how reads are routed to replicas.
+type RoutingOptions struct {
+	Strategy            string        // RoundRobin or LeastLoaded
+	HealthCheckInterval time.Duration // how often replicas are checked
+	HealthCheckTimeout  time.Duration // timeout of a single check
+	MaxLag              time.Duration // replicas lagging more are not read from; zero disables the lag check
+}
+
+// DB is a master database and its replicas. QueryContext and QueryRowContext
+// read from a healthy replica, chosen by the configured strategy, unless the
+// context requires strong consistency or no replica is healthy, in which case
+// they read from the master. ExecContext, transactions and Master always use
+// the master, so writes and reads that must see them go there.
+type DB struct {
+	*dbpg.DB
+
+	opts     RoutingOptions
+	replicas []*replica
+	next     atomic.Uint64
+}
+
+// replica is a slave database and the outcome of its last health check.
+type replica struct {
+	name    string
+	db      *sql.DB
+	healthy atomic.Bool
+	checked bool // whether Run checked it yet
+}
+
+// NewDB routes the reads of db as configured by opts. Replicas are not read
+// from until Run checked them.
+func NewDB(db *dbpg.DB, opts RoutingOptions) (*DB, error) {
+	switch opts.Strategy {
+	case "":
+		opts.Strategy = RoundRobin
+	case RoundRobin, LeastLoaded:
+	default:
+		return nil, ErrInvalidStrategy
+	}
+
+	if opts.HealthCheckInterval <= 0 {
+		opts.HealthCheckInterval = defaultHealthCheckInterval
+	}
+	if opts.HealthCheckTimeout <= 0 {
+		opts.HealthCheckTimeout = defaultHealthCheckTimeout
+	}
+
+	d := &DB{DB: db, opts: opts}
+	for i, s := range db.Slaves {
+		d.replicas = append(d.replicas, &replica{name: "slave_" + strconv.Itoa(i), db: s})
+	}
+
+	return d, nil
+}
+
+// QueryContext runs a query that returns rows on the database chosen by Reader.
+func (d *DB) QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error) {
+	return d.Reader(ctx).QueryContext(ctx, query, args...)
+}
+
+// QueryRowContext runs a query that returns at most one row on the database
+// chosen by Reader.
+func (d *DB) QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row {
+	return d.Reader(ctx).QueryRowContext(ctx, query, args...)
+}
+
+// Reader returns the database a read in ctx goes to: the master if ctx
+// requires strong consistency or no replica is healthy, otherwise a healthy
+// replica.
+func (d *DB) Reader(ctx context.Context) *sql.DB {
+	if len(d.replicas) == 0 || Consistency(ctx) == Strong {
+		reads.WithLabelValues("master").Inc()
+		return d.Master
+	}
+
+	if r := d.pick(); r != nil {
+		reads.WithLabelValues(r.name).Inc()
+		return r.db
+	}
+
+	reads.WithLabelValues("master").Inc()
+	return d.Master
+}
+
+// pick chooses a healthy replica, or returns nil if there is none. Healthy
+// replicas take turns; with LeastLoaded the one with the fewest connections
+// in use is chosen and turns only break ties.
+func (d *DB) pick() *replica {
+	healthy := make([]*replica, 0, len(d.replicas))
+	for _, r := range d.replicas {
+		if r.healthy.Load() {
+			healthy = append(healthy, r)
+		}
+	}
+
+	n := len(healthy)
+	if n == 0 {
+		return nil
+	}
+
+	start := int(d.next.Add(1) % uint64(n))
+	if d.opts.Strategy == RoundRobin {
+		return healthy[start]
+	}
+
+	best, inUse := healthy[start], healthy[start].db.Stats().InUse
+	for i := 1; i < n; i++ {
+		r := healthy[(start+i)%n]
+		if load := r.db.Stats().InUse; load < inUse {
+			best, inUse = r, load
+		}
+	}
+
+	return best
+}
+
+// Run checks the health and replication lag of the replicas immediately and
+// then on every health check interval until ctx is cancelled.
+func (d *DB) Run(ctx context.Context) {
+	if len(d.replicas) == 0 {
+		return
+	}
+
+	ticker := time.NewTicker(d.opts.HealthCheckInterval)
+	defer ticker.Stop()
+
+	for {
+		for _, r := range d.replicas {
+			d.check(ctx, r)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// check checks a replica and logs when it becomes healthy or unhealthy.
+func (d *DB) check(ctx context.Context, r *replica) {
+	checkCtx, cancel := context.WithTimeout(ctx, d.opts.HealthCheckTimeout)
+	defer cancel()
+
+	var lag float64
+	err := r.db.QueryRowContext(checkCtx, lagQuery).Scan(&lag)
+	if ctx.Err() != nil {
+		return
+	}
+
+	healthy := err == nil && (d.opts.MaxLag <= 0 || lag <= d.opts.MaxLag.Seconds())
+	if err == nil {
+		replicaLag.WithLabelValues(r.name).Set(lag)
+	}
+
+	if healthy {
+		replicaHealthy.WithLabelValues(r.name).Set(1)
+	} else {
+		replicaHealthy.WithLabelValues(r.name).Set(0)
+	}
+
+	if r.healthy.Swap(healthy) == healthy && r.checked {
+		return
+	}
+	r.checked = true
+
+	l := logging.Ctx(ctx)
+	switch {
+	case healthy:
+		l.Info().Str("db", r.name).Float64("lag_seconds", lag).Msg("replica is healthy, reading from it")
+	case err != nil:
+		l.Warn().Err(err).Str("db", r.name).Msg("replica failed its health check, reading from master")
+	default:
+		l.Warn().Str("db", r.name).Float64("lag_seconds", lag).Msg("replica lags behind, reading from master")
+	}
+}
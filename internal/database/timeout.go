@@ -0,0 +1,115 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// queryCanceled is the PostgreSQL error code of statements canceled by a
+// statement timeout or a cancel request.
+const queryCanceled = "57014"
+
+// ErrQueryTimeout is returned for reads that exceeded their statement timeout.
+var ErrQueryTimeout = errors.New("query timed out")
+
+// TimeoutError is a read canceled because it exceeded the statement timeout
+// of its context.
+type TimeoutError struct {
+	Timeout time.Duration // statement timeout of the read
+	Err     error         // error the read failed with
+}
+
+// Error implements error.
+func (e *TimeoutError) Error() string {
+	return fmt.Sprintf("the query was canceled after exceeding the %s timeout of this endpoint", e.Timeout)
+}
+
+// Unwrap returns ErrQueryTimeout and the error the read failed with.
+func (e *TimeoutError) Unwrap() []error {
+	return []error{ErrQueryTimeout, e.Err}
+}
+
+// statementTimeoutKey is the context key the statement timeout is stored under.
+type statementTimeoutKey struct{}
+
+// WithStatementTimeout returns a copy of ctx whose reads run for at most d.
+func WithStatementTimeout(ctx context.Context, d time.Duration) context.Context {
+	return context.WithValue(ctx, statementTimeoutKey{}, d)
+}
+
+// StatementTimeout returns the statement timeout of reads in ctx, or zero if
+// they have none.
+func StatementTimeout(ctx context.Context) time.Duration {
+	d, _ := ctx.Value(statementTimeoutKey{}).(time.Duration)
+	return d
+}
+
+// Querier runs queries, on a database or in a transaction.
+type Querier interface {
+	QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row
+}
+
+// Read runs the queries of fn, with the context it is given, on the database
+// chosen by Reader. If ctx has a statement timeout, they run with a context
+// deadline and in a read-only transaction with SET LOCAL statement_timeout,
+// so that PostgreSQL stops them even if the cancel request sent on the
+// deadline does not reach it. A read that times out fails with a
+// TimeoutError; one whose context is canceled, e.g. because the client
+// disconnected, with an error wrapping context.Canceled.
+func (d *DB) Read(ctx context.Context, fn func(ctx context.Context, q Querier) error) error {
+	timeout := StatementTimeout(ctx)
+	if timeout <= 0 {
+		return canceled(ctx, fn(ctx, d.Reader(ctx)))
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	err := d.readTx(ctx, timeout, fn)
+
+	var pqErr *pq.Error
+	if errors.Is(err, context.DeadlineExceeded) || errors.As(err, &pqErr) && pqErr.Code == queryCanceled {
+		if !errors.Is(ctx.Err(), context.Canceled) {
+			return &TimeoutError{Timeout: timeout, Err: err}
+		}
+	}
+
+	return canceled(ctx, err)
+}
+
+// readTx runs fn in a read-only transaction with the given statement timeout.
+func (d *DB) readTx(ctx context.Context, timeout time.Duration, fn func(ctx context.Context, q Querier) error) error {
+	tx, err := d.Reader(ctx).BeginTx(ctx, &sql.TxOptions{ReadOnly: true})
+	if err != nil {
+		return fmt.Errorf("begin read: %w", err)
+	}
+	defer Rollback(ctx, tx)
+
+	// A statement_timeout of 0 disables it, so round up to a millisecond.
+	ms := max(timeout.Milliseconds(), 1)
+	if _, err = tx.ExecContext(ctx, fmt.Sprintf("SET LOCAL statement_timeout = %d", ms)); err != nil {
+		return fmt.Errorf("set statement timeout: %w", err)
+	}
+
+	if err = fn(ctx, tx); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// canceled marks err as caused by the cancellation of ctx, if it was, since
+// lib/pq reports a canceled query as a PostgreSQL error.
+func canceled(ctx context.Context, err error) error {
+	if err == nil || !errors.Is(ctx.Err(), context.Canceled) || errors.Is(err, context.Canceled) {
+		return err
+	}
+
+	return fmt.Errorf("%w: %w", context.Canceled, err)
+}
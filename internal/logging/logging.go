@@ -0,0 +1,42 @@
+// Package logging carries a request-scoped logger through contexts, so that
+// handlers, services and repositories log with the fields of the request they
+// serve, e.g. its request ID, route, user and workspace.
+package logging
+
+import (
+	"context"
+
+	"github.com/rs/zerolog"
+	"github.com/wb-go/wbf/zlog"
+)
+
+// loggerKey is the context key the logger is stored under.
+type loggerKey struct{}
+
+// WithLogger returns a copy of ctx carrying l.
+func WithLogger(ctx context.Context, l zerolog.Logger) context.Context {
+	return context.WithValue(ctx, loggerKey{}, l)
+}
+
+// With returns a copy of ctx whose logger adds the field key with value to
+// every message.
+func With(ctx context.Context, key, value string) context.Context {
+	return WithLogger(ctx, logger(ctx).With().Str(key, value).Logger())
+}
+
+// Ctx returns the logger of ctx, or the global logger if ctx carries none.
+// Its messages are bound to ctx, so hooks such as tracing.LogHook can add the
+// trace of the request.
+func Ctx(ctx context.Context) *zerolog.Logger {
+	l := logger(ctx).With().Ctx(ctx).Logger()
+	return &l
+}
+
+// logger returns the logger of ctx, or the global logger if there is none.
+func logger(ctx context.Context) zerolog.Logger {
+	if l, ok := ctx.Value(loggerKey{}).(zerolog.Logger); ok {
+		return l
+	}
+
+	return zlog.Logger
+}
@@ -0,0 +1,154 @@
+// Package mail builds MIME email messages and sends them over SMTP.
+package mail
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net/mail"
+	"net/textproto"
+	"strings"
+	"time"
+)
+
+// Sender sends email messages.
+type Sender interface {
+	Send(ctx context.Context, m *Message) error
+}
+
+// Message is an email with a plain text and an HTML body and attachments.
+type Message struct {
+	From        string   // sender address, e.g. "Reports <reports@example.com>"
+	To          []string // recipient addresses
+	Subject     string
+	Text        string // plain text body, for clients that do not show HTML
+	HTML        string // HTML body
+	Attachments []Attachment
+}
+
+// Attachment is a file attached to a message.
+type Attachment struct {
+	Filename    string
+	ContentType string
+	Data        []byte
+}
+
+// Bytes encodes the message as multipart/mixed MIME: the bodies as
+// multipart/alternative followed by the base64-encoded attachments.
+func (m *Message) Bytes(now time.Time) ([]byte, error) {
+	// The alternative bodies are nested in the first part of the message.
+	var body bytes.Buffer
+	alt := multipart.NewWriter(&body)
+	if err := writeText(alt, "text/plain; charset=utf-8", m.Text); err != nil {
+		return nil, err
+	}
+	if err := writeText(alt, "text/html; charset=utf-8", m.HTML); err != nil {
+		return nil, err
+	}
+	if err := alt.Close(); err != nil {
+		return nil, fmt.Errorf("close body: %w", err)
+	}
+
+	domain := "localhost"
+	if addr, err := mail.ParseAddress(m.From); err == nil {
+		if _, d, ok := strings.Cut(addr.Address, "@"); ok {
+			domain = d
+		}
+	}
+
+	id := make([]byte, 16)
+	if _, err := rand.Read(id); err != nil {
+		return nil, fmt.Errorf("generate message id: %w", err)
+	}
+
+	var buf bytes.Buffer
+	mixed := multipart.NewWriter(&buf)
+
+	header := []string{
+		"From: " + m.From,
+		"To: " + strings.Join(m.To, ", "),
+		"Subject: " + mime.QEncoding.Encode("utf-8", m.Subject),
+		"Date: " + now.Format(time.RFC1123Z),
+		"Message-ID: <" + hex.EncodeToString(id) + "@" + domain + ">",
+		"MIME-Version: 1.0",
+		"Content-Type: multipart/mixed; boundary=" + mixed.Boundary(),
+	}
+	buf.WriteString(strings.Join(header, "\r\n") + "\r\n\r\n")
+
+	part, err := mixed.CreatePart(textproto.MIMEHeader{
+		"Content-Type": {"multipart/alternative; boundary=" + alt.Boundary()},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("create body: %w", err)
+	}
+	if _, err = part.Write(body.Bytes()); err != nil {
+		return nil, fmt.Errorf("write body: %w", err)
+	}
+
+	for _, a := range m.Attachments {
+		mediaType, params, err := mime.ParseMediaType(a.ContentType)
+		if err != nil {
+			return nil, fmt.Errorf("parse attachment content type: %w", err)
+		}
+		params["name"] = a.Filename
+
+		part, err := mixed.CreatePart(textproto.MIMEHeader{
+			"Content-Type":              {mime.FormatMediaType(mediaType, params)},
+			"Content-Disposition":       {mime.FormatMediaType("attachment", map[string]string{"filename": a.Filename})},
+			"Content-Transfer-Encoding": {"base64"},
+		})
+		if err != nil {
+			return nil, fmt.Errorf("create attachment: %w", err)
+		}
+
+		if err = writeBase64(part, a.Data); err != nil {
+			return nil, fmt.Errorf("write attachment: %w", err)
+		}
+	}
+
+	if err = mixed.Close(); err != nil {
+		return nil, fmt.Errorf("close message: %w", err)
+	}
+
+	return buf.Bytes(), nil
+}
+
+// writeText writes a quoted-printable text part.
+func writeText(w *multipart.Writer, contentType, text string) error {
+	part, err := w.CreatePart(textproto.MIMEHeader{
+		"Content-Type":              {contentType},
+		"Content-Transfer-Encoding": {"quoted-printable"},
+	})
+	if err != nil {
+		return fmt.Errorf("create text: %w", err)
+	}
+
+	qp := quotedprintable.NewWriter(part)
+	if _, err = qp.Write([]byte(text)); err != nil {
+		return fmt.Errorf("write text: %w", err)
+	}
+
+	return qp.Close()
+}
+
+// writeBase64 writes data base64-encoded in lines of 76 characters, as
+// RFC 2045 requires.
+func writeBase64(w io.Writer, data []byte) error {
+	encoded := base64.StdEncoding.EncodeToString(data)
+	for len(encoded) > 0 {
+		n := min(len(encoded), 76)
+		if _, err := w.Write([]byte(encoded[:n] + "\r\n")); err != nil {
+			return err
+		}
+		encoded = encoded[n:]
+	}
+
+	return nil
+}
@@ -0,0 +1,152 @@
+package mail
+
+import (
+	"bytes"
+	"encoding/base64"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/mail"
+	"strings"
+	"testing"
+	"time"
+)
+
+// testMessage returns a message with non-ASCII text and an attachment long
+// enough to span several base64 lines.
+func testMessage() *Message {
+	return &Message{
+		From:    "Reports <reports@example.com>",
+		To:      []string{"ops@example.com", "Anna <anna@example.com>"},
+		Subject: "Отчёт за октябрь",
+		Text:    "Revenue: 1 200,00 €\nSee the attached CSV.",
+		HTML:    `<p style="color: red">Revenue: <b>1 200,00 €</b></p>`,
+		Attachments: []Attachment{{
+			Filename:    "report 2025-10.csv",
+			ContentType: "text/csv; charset=utf-8",
+			Data:        bytes.Repeat([]byte("period,sum\n2025-10-01,1200.00\n"), 20),
+		}},
+	}
+}
+
+// part is a decoded leaf part of a message.
+type part struct {
+	contentType string
+	filename    string
+	body        string
+}
+
+// readMessage parses raw as a MIME message and returns its header and leaf
+// parts in order, with quoted-printable and base64 bodies decoded.
+func readMessage(t *testing.T, raw []byte) (mail.Header, []part) {
+	t.Helper()
+
+	msg, err := mail.ReadMessage(bytes.NewReader(raw))
+	if err != nil {
+		t.Fatalf("read message: %v", err)
+	}
+
+	var parts []part
+	var walk func(contentType string, r io.Reader)
+	walk = func(contentType string, r io.Reader) {
+		mediaType, params, err := mime.ParseMediaType(contentType)
+		if err != nil {
+			t.Fatalf("parse content type %q: %v", contentType, err)
+		}
+
+		mr := multipart.NewReader(r, params["boundary"])
+		for {
+			p, err := mr.NextPart()
+			if err == io.EOF {
+				return
+			}
+			if err != nil {
+				t.Fatalf("read part of %s: %v", mediaType, err)
+			}
+
+			ct := p.Header.Get("Content-Type")
+			if strings.HasPrefix(ct, "multipart/") {
+				walk(ct, p)
+				continue
+			}
+
+			var body io.Reader = p
+			if p.Header.Get("Content-Transfer-Encoding") == "base64" {
+				body = base64.NewDecoder(base64.StdEncoding, p)
+			}
+
+			data, err := io.ReadAll(body)
+			if err != nil {
+				t.Fatalf("read body of %s: %v", ct, err)
+			}
+
+			parts = append(parts, part{contentType: ct, filename: p.FileName(), body: string(data)})
+		}
+	}
+
+	walk(msg.Header.Get("Content-Type"), msg.Body)
+
+	return msg.Header, parts
+}
+
+func TestMessageBytes(t *testing.T) {
+	m := testMessage()
+	now := time.Date(2025, 11, 1, 8, 0, 0, 0, time.UTC)
+
+	raw, err := m.Bytes(now)
+	if err != nil {
+		t.Fatalf("Bytes() error = %v", err)
+	}
+
+	for i, line := range strings.Split(string(raw), "\r\n") {
+		if len(line) > 998 {
+			t.Errorf("line %d is %d characters long, over the limit of RFC 5322", i+1, len(line))
+		}
+	}
+
+	header, parts := readMessage(t, raw)
+
+	subject, err := new(mime.WordDecoder).DecodeHeader(header.Get("Subject"))
+	if err != nil || subject != m.Subject {
+		t.Errorf("subject = %q (%v), want %q", subject, err, m.Subject)
+	}
+
+	to, err := header.AddressList("To")
+	if err != nil || len(to) != 2 || to[0].Address != "ops@example.com" || to[1].Address != "anna@example.com" {
+		t.Errorf("To = %v (%v), want both recipients", to, err)
+	}
+
+	if date, err := header.Date(); err != nil || !date.Equal(now) {
+		t.Errorf("Date = %v (%v), want %v", date, err, now)
+	}
+	if id := header.Get("Message-ID"); !strings.HasSuffix(id, "@example.com>") {
+		t.Errorf("Message-ID = %q, want it in the sender's domain", id)
+	}
+	if header.Get("MIME-Version") != "1.0" {
+		t.Errorf("MIME-Version = %q", header.Get("MIME-Version"))
+	}
+
+	want := []part{
+		// Quoted-printable text has CRLF line breaks.
+		{contentType: "text/plain; charset=utf-8", body: strings.ReplaceAll(m.Text, "\n", "\r\n")},
+		{contentType: "text/html; charset=utf-8", body: m.HTML},
+		{contentType: `text/csv; charset=utf-8; name="report 2025-10.csv"`, filename: "report 2025-10.csv", body: string(m.Attachments[0].Data)},
+	}
+	if len(parts) != len(want) {
+		t.Fatalf("message has %d parts, want %d", len(parts), len(want))
+	}
+	for i := range want {
+		if parts[i] != want[i] {
+			t.Errorf("part %d = %+v, want %+v", i, parts[i], want[i])
+		}
+	}
+}
+
+func TestMessageBytesRejectsInvalidAttachmentType(t *testing.T) {
+	m := testMessage()
+	m.Attachments[0].ContentType = "text/csv; charset"
+
+	if _, err := m.Bytes(time.Now()); err == nil {
+		t.Fatal("Bytes() error = nil, want an error for the invalid content type")
+	}
+}
@@ -0,0 +1,160 @@
+package mail
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net"
+	"net/mail"
+	"net/smtp"
+	"strconv"
+	"time"
+)
+
+// TLS modes of an SMTP server.
+const (
+	TLSNone     = "none"     // plain text, e.g. a local SMTP stand-in
+	TLSStartTLS = "starttls" // upgrade with STARTTLS, usually on port 587
+	TLSImplicit = "tls"      // TLS from the start, usually on port 465
+)
+
+const defaultTimeout = 30 * time.Second
+
+var ErrInvalidTLSMode = errors.New("invalid smtp tls mode, expected none, starttls or tls")
+
+// SMTPOptions configures an SMTP sender.
+type SMTPOptions struct {
+	Host     string
+	Port     int
+	Username string // authenticates with PLAIN if set
+	Password string
+	TLS      string        // TLSNone, TLSStartTLS or TLSImplicit
+	Timeout  time.Duration // timeout of sending a single message
+}
+
+// SMTP sends messages through an SMTP server, one connection per message.
+type SMTP struct {
+	opts SMTPOptions
+}
+
+// NewSMTP creates an SMTP sender.
+func NewSMTP(opts SMTPOptions) (*SMTP, error) {
+	switch opts.TLS {
+	case TLSNone, TLSStartTLS, TLSImplicit:
+	default:
+		return nil, ErrInvalidTLSMode
+	}
+
+	if opts.Timeout <= 0 {
+		opts.Timeout = defaultTimeout
+	}
+
+	return &SMTP{opts: opts}, nil
+}
+
+// Send sends m to its recipients.
+func (s *SMTP) Send(ctx context.Context, m *Message) error {
+	from, err := mail.ParseAddress(m.From)
+	if err != nil {
+		return fmt.Errorf("parse sender: %w", err)
+	}
+
+	to := make([]string, 0, len(m.To))
+	for _, rcpt := range m.To {
+		addr, err := mail.ParseAddress(rcpt)
+		if err != nil {
+			return fmt.Errorf("parse recipient: %w", err)
+		}
+		to = append(to, addr.Address)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, s.opts.Timeout)
+	defer cancel()
+
+	msg, err := m.Bytes(time.Now())
+	if err != nil {
+		return err
+	}
+
+	c, err := s.dial(ctx)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = c.Close() }()
+
+	if err = c.Mail(from.Address); err != nil {
+		return fmt.Errorf("smtp mail: %w", err)
+	}
+
+	for _, rcpt := range to {
+		if err = c.Rcpt(rcpt); err != nil {
+			return fmt.Errorf("smtp rcpt %s: %w", rcpt, err)
+		}
+	}
+
+	w, err := c.Data()
+	if err != nil {
+		return fmt.Errorf("smtp data: %w", err)
+	}
+
+	if _, err = w.Write(msg); err != nil {
+		return fmt.Errorf("write message: %w", err)
+	}
+
+	if err = w.Close(); err != nil {
+		return fmt.Errorf("smtp data: %w", err)
+	}
+
+	return c.Quit()
+}
+
+// dial connects and authenticates to the server. The connection expires at
+// the deadline of ctx.
+func (s *SMTP) dial(ctx context.Context) (*smtp.Client, error) {
+	addr := net.JoinHostPort(s.opts.Host, strconv.Itoa(s.opts.Port))
+	tlsConfig := &tls.Config{ServerName: s.opts.Host, MinVersion: tls.VersionTLS12}
+
+	var (
+		conn net.Conn
+		err  error
+	)
+	if s.opts.TLS == TLSImplicit {
+		d := &tls.Dialer{Config: tlsConfig}
+		conn, err = d.DialContext(ctx, "tcp", addr)
+	} else {
+		var d net.Dialer
+		conn, err = d.DialContext(ctx, "tcp", addr)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("dial smtp: %w", err)
+	}
+
+	if deadline, ok := ctx.Deadline(); ok {
+		_ = conn.SetDeadline(deadline)
+	}
+
+	c, err := smtp.NewClient(conn, s.opts.Host)
+	if err != nil {
+		_ = conn.Close()
+		return nil, fmt.Errorf("smtp greeting: %w", err)
+	}
+
+	if s.opts.TLS == TLSStartTLS {
+		if err = c.StartTLS(tlsConfig); err != nil {
+			_ = c.Close()
+			return nil, fmt.Errorf("smtp starttls: %w", err)
+		}
+	}
+
+	if s.opts.Username != "" {
+		// PlainAuth refuses to send credentials without TLS except to localhost.
+		auth := smtp.PlainAuth("", s.opts.Username, s.opts.Password, s.opts.Host)
+		if err = c.Auth(auth); err != nil {
+			_ = c.Close()
+			return nil, fmt.Errorf("smtp auth: %w", err)
+		}
+	}
+
+	return c, nil
+}
@@ -0,0 +1,254 @@
+package mail
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"net"
+	"net/textproto"
+	"strings"
+	"testing"
+	"time"
+)
+
+// envelope is what fakeSMTP received in one session.
+type envelope struct {
+	auth string // decoded AUTH PLAIN response
+	from string
+	to   []string
+	data []byte
+	quit bool
+}
+
+// fakeSMTP is an in-process SMTP server accepting one session at a time. It
+// rejects recipients whose address starts with "reject".
+type fakeSMTP struct {
+	ln       net.Listener
+	sessions chan envelope
+}
+
+// newFakeSMTP starts a server on a random local port, stopped with the test.
+func newFakeSMTP(t *testing.T) *fakeSMTP {
+	t.Helper()
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+
+	s := &fakeSMTP{ln: ln, sessions: make(chan envelope, 10)}
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			s.serve(conn)
+		}
+	}()
+
+	t.Cleanup(func() {
+		_ = ln.Close()
+		<-done
+	})
+
+	return s
+}
+
+// port returns the port the server listens on.
+func (s *fakeSMTP) port() int {
+	return s.ln.Addr().(*net.TCPAddr).Port
+}
+
+// next waits for the end of the next session and returns what it received.
+func (s *fakeSMTP) next(t *testing.T) envelope {
+	t.Helper()
+
+	select {
+	case env := <-s.sessions:
+		return env
+	case <-time.After(5 * time.Second):
+		t.Fatal("no SMTP session ended")
+		return envelope{}
+	}
+}
+
+// serve runs one SMTP session.
+func (s *fakeSMTP) serve(conn net.Conn) {
+	defer func() { _ = conn.Close() }()
+	_ = conn.SetDeadline(time.Now().Add(5 * time.Second))
+
+	tp := textproto.NewConn(conn)
+	var env envelope
+	defer func() { s.sessions <- env }()
+
+	reply := func(lines ...string) {
+		_ = tp.PrintfLine("%s", strings.Join(lines, "\r\n"))
+	}
+
+	reply("220 fake ESMTP")
+	for {
+		line, err := tp.ReadLine()
+		if err != nil {
+			return
+		}
+
+		verb, arg, _ := strings.Cut(line, " ")
+		switch strings.ToUpper(verb) {
+		case "EHLO":
+			reply("250-fake", "250 AUTH PLAIN")
+		case "AUTH":
+			mech, resp, _ := strings.Cut(arg, " ")
+			decoded, err := base64.StdEncoding.DecodeString(resp)
+			if mech != "PLAIN" || err != nil {
+				reply("535 authentication failed")
+				continue
+			}
+			env.auth = string(decoded)
+			reply("235 authenticated")
+		case "MAIL":
+			env.from = strings.Trim(strings.TrimPrefix(arg, "FROM:"), "<>")
+			reply("250 ok")
+		case "RCPT":
+			rcpt := strings.Trim(strings.TrimPrefix(arg, "TO:"), "<>")
+			if strings.HasPrefix(rcpt, "reject") {
+				reply("550 no such user")
+				continue
+			}
+			env.to = append(env.to, rcpt)
+			reply("250 ok")
+		case "DATA":
+			reply("354 end with <CRLF>.<CRLF>")
+			if env.data, err = tp.ReadDotBytes(); err != nil {
+				return
+			}
+			reply("250 queued")
+		case "QUIT":
+			env.quit = true
+			reply("221 bye")
+			return
+		default:
+			reply("502 not implemented")
+		}
+	}
+}
+
+// newTestSMTP returns a sender to srv without TLS.
+func newTestSMTP(t *testing.T, srv *fakeSMTP, username string) *SMTP {
+	t.Helper()
+
+	s, err := NewSMTP(SMTPOptions{
+		Host:     "127.0.0.1",
+		Port:     srv.port(),
+		Username: username,
+		Password: "secret",
+		TLS:      TLSNone,
+		Timeout:  5 * time.Second,
+	})
+	if err != nil {
+		t.Fatalf("NewSMTP() error = %v", err)
+	}
+
+	return s
+}
+
+func TestSMTPSend(t *testing.T) {
+	srv := newFakeSMTP(t)
+	m := testMessage()
+
+	if err := newTestSMTP(t, srv, "reports").Send(context.Background(), m); err != nil {
+		t.Fatalf("Send() error = %v", err)
+	}
+
+	env := srv.next(t)
+	if env.auth != "\x00reports\x00secret" {
+		t.Errorf("AUTH PLAIN = %q, want the configured credentials", env.auth)
+	}
+	if env.from != "reports@example.com" {
+		t.Errorf("MAIL FROM = %q, want the bare sender address", env.from)
+	}
+	if strings.Join(env.to, ",") != "ops@example.com,anna@example.com" {
+		t.Errorf("RCPT TO = %v, want the bare recipient addresses", env.to)
+	}
+	if !env.quit {
+		t.Error("session was not ended with QUIT")
+	}
+
+	header, parts := readMessage(t, env.data)
+	if header.Get("From") != m.From || len(parts) != 3 {
+		t.Fatalf("received message from %q with %d parts, want from %q with 3", header.Get("From"), len(parts), m.From)
+	}
+	if parts[2].body != string(m.Attachments[0].Data) {
+		t.Error("received attachment differs from the sent one")
+	}
+}
+
+func TestSMTPSendWithoutAuth(t *testing.T) {
+	srv := newFakeSMTP(t)
+
+	if err := newTestSMTP(t, srv, "").Send(context.Background(), testMessage()); err != nil {
+		t.Fatalf("Send() error = %v", err)
+	}
+
+	if env := srv.next(t); env.auth != "" || env.data == nil {
+		t.Errorf("received %+v, want a message sent without AUTH", env)
+	}
+}
+
+func TestSMTPSendRejectedRecipient(t *testing.T) {
+	srv := newFakeSMTP(t)
+	m := testMessage()
+	m.To = append(m.To, "reject@example.com")
+
+	err := newTestSMTP(t, srv, "").Send(context.Background(), m)
+	if err == nil || !strings.Contains(err.Error(), "reject@example.com") {
+		t.Fatalf("Send() error = %v, want the rejected recipient", err)
+	}
+
+	var tpErr *textproto.Error
+	if !errors.As(err, &tpErr) || tpErr.Code != 550 {
+		t.Errorf("Send() error = %v, want the server's 550 reply", err)
+	}
+
+	if env := srv.next(t); env.data != nil {
+		t.Errorf("received %+v, want no message data", env)
+	}
+}
+
+func TestSMTPSendInvalidAddresses(t *testing.T) {
+	tests := []struct {
+		name string
+		from string
+		to   []string
+	}{
+		{"sender", "not an address", []string{"ops@example.com"}},
+		{"recipient", "reports@example.com", []string{"ops@example.com", "@"}},
+	}
+
+	// No server is needed: addresses are checked before connecting.
+	s, err := NewSMTP(SMTPOptions{Host: "127.0.0.1", Port: 1, TLS: TLSNone})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := testMessage()
+			m.From, m.To = tt.from, tt.to
+
+			if err := s.Send(context.Background(), m); err == nil || !strings.Contains(err.Error(), "parse "+tt.name) {
+				t.Errorf("Send() error = %v, want a parse %s error", err, tt.name)
+			}
+		})
+	}
+}
+
+func TestNewSMTPRejectsUnknownTLSMode(t *testing.T) {
+	for _, mode := range []string{"", "ssl", "STARTTLS"} {
+		if _, err := NewSMTP(SMTPOptions{Host: "smtp.example.com", Port: 587, TLS: mode}); !errors.Is(err, ErrInvalidTLSMode) {
+			t.Errorf("NewSMTP(TLS: %q) error = %v, want ErrInvalidTLSMode", mode, err)
+		}
+	}
+}
@@ -0,0 +1,38 @@
+package metrics
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/wb-go/wbf/dbpg"
+)
+
+// queryDuration is the latency of repository operations.
+var queryDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+	Namespace: Namespace,
+	Name:      "repository_query_duration_seconds",
+	Help:      "Latency of repository operations, including all their queries, in seconds.",
+	Buckets:   DefaultBuckets,
+}, []string{"repository", "operation"})
+
+// ObserveQuery records the latency of a repository operation that started at
+// start. It is meant to be deferred at the top of the operation:
+//
+//	defer metrics.ObserveQuery("item", "Create", time.Now())
+func ObserveQuery(repository, operation string, start time.Time) {
+	queryDuration.WithLabelValues(repository, operation).Observe(time.Since(start).Seconds())
+}
+
+// RegisterDB registers connection pool statistics of the master and slaves
+// of db, read when metrics are collected. They are the go_sql_* metrics of
+// client_golang, labelled with db_name "master" or "slave_<n>" in
+// configuration order.
+func RegisterDB(db *dbpg.DB) {
+	prometheus.MustRegister(collectors.NewDBStatsCollector(db.Master, "master"))
+	for i, s := range db.Slaves {
+		prometheus.MustRegister(collectors.NewDBStatsCollector(s, "slave_"+strconv.Itoa(i)))
+	}
+}
@@ -0,0 +1,26 @@
+// Package metrics exposes application metrics in the Prometheus text
+// exposition format.
+//
+// Metrics are created with promauto, which registers them in the default
+// registry served by Handler, and are usually kept in package-level variables
+// of the package that updates them. The default registry also collects Go
+// runtime and process metrics.
+package metrics
+
+import (
+	"net/http"
+
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// Namespace prefixes the names of the application's metrics.
+const Namespace = "sales_tracker"
+
+// DefaultBuckets are histogram buckets in seconds suited to request and
+// query latencies.
+var DefaultBuckets = []float64{0.001, 0.0025, 0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10}
+
+// Handler returns an HTTP handler serving the default registry.
+func Handler() http.Handler {
+	return promhttp.Handler()
+}
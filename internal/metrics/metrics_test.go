@@ -0,0 +1,29 @@
+package metrics
+
+import (
+	"io"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestHandlerExposesQueryDuration(t *testing.T) {
+	ObserveQuery("item", "Create", time.Now().Add(-30*time.Millisecond))
+
+	rec := httptest.NewRecorder()
+	Handler().ServeHTTP(rec, httptest.NewRequest("GET", "/metrics", nil))
+
+	body, _ := io.ReadAll(rec.Body)
+	for _, want := range []string{
+		"# TYPE sales_tracker_repository_query_duration_seconds histogram",
+		`sales_tracker_repository_query_duration_seconds_bucket{operation="Create",repository="item",le="0.025"} 0`,
+		`sales_tracker_repository_query_duration_seconds_bucket{operation="Create",repository="item",le="0.05"} 1`,
+		`sales_tracker_repository_query_duration_seconds_count{operation="Create",repository="item"} 1`,
+		"# TYPE go_goroutines gauge",
+	} {
+		if !strings.Contains(string(body), want) {
+			t.Errorf("metrics do not contain %q", want)
+		}
+	}
+}
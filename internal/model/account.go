@@ -0,0 +1,40 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+)
+
+// Account represents a wallet or bank account items are booked against.
+//
+// Fields:
+//   - ID: UUID primary key (DB default gen_random_uuid())
+//   - Name: human-readable account name
+//   - Currency: 3-letter ISO currency code of the account
+//   - OpeningBalance: balance before the first booked item
+//   - CreatedAt, UpdatedAt: DB-managed timestamps
+type Account struct {
+	ID             uuid.UUID       `db:"id" json:"id"`
+	Name           string          `db:"name" json:"name"`
+	Currency       string          `db:"currency" json:"currency"`
+	OpeningBalance decimal.Decimal `db:"opening_balance" json:"opening_balance"`
+	CreatedAt      time.Time       `db:"created_at" json:"created_at"`
+	UpdatedAt      time.Time       `db:"updated_at" json:"updated_at"`
+}
+
+// AccountBalance is the balance of an account at a point in time.
+type AccountBalance struct {
+	AccountID uuid.UUID       `json:"account_id"`
+	Currency  string          `json:"currency"`
+	Balance   decimal.Decimal `json:"balance"`
+	At        time.Time       `json:"at"`
+}
+
+// Transfer links the source and destination legs of a transfer between accounts.
+type Transfer struct {
+	ID            uuid.UUID `json:"id"`
+	SourceID      uuid.UUID `json:"source_id"`
+	DestinationID uuid.UUID `json:"destination_id"`
+}
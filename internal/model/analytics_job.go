@@ -0,0 +1,48 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Analytics job statuses.
+const (
+	JobQueued    = "queued"    // waiting for a worker
+	JobRunning   = "running"   // being computed by a worker
+	JobSucceeded = "succeeded" // the result is available
+	JobFailed    = "failed"    // computing the report failed
+	JobCanceled  = "canceled"  // canceled before it finished
+)
+
+// AnalyticsJob represents a report computed in the background.
+//
+// Fields:
+//   - ID: UUID primary key (DB default gen_random_uuid())
+//   - WorkspaceID: workspace whose items the report is computed over
+//   - Spec: the report to compute
+//   - Status: queued/running/succeeded/failed/canceled
+//   - Progress: share of the report computed so far (0.0–1.0)
+//   - Result: the report, once the job succeeded
+//   - Error: why the job failed
+//   - Attempts: number of times a worker started the job
+//   - CreatedAt: when the job was enqueued
+//   - StartedAt: when a worker last started the job
+//   - FinishedAt: when the job succeeded, failed or was canceled
+//   - UpdatedAt: DB-managed timestamp
+//   - ClaimToken: token of the worker's claim, only set by Claim
+type AnalyticsJob struct {
+	ID          uuid.UUID     `db:"id" json:"id"`
+	WorkspaceID uuid.UUID     `db:"workspace_id" json:"-"`
+	Spec        ReportSpec    `db:"spec" json:"spec"`
+	Status      string        `db:"status" json:"status"`
+	Progress    float64       `db:"progress" json:"progress"`
+	Result      *ReportResult `db:"result,omitempty" json:"result,omitempty"`
+	Error       *string       `db:"error,omitempty" json:"error,omitempty"`
+	Attempts    int           `db:"attempts" json:"attempts"`
+	CreatedAt   time.Time     `db:"created_at" json:"created_at"`
+	StartedAt   *time.Time    `db:"started_at,omitempty" json:"started_at,omitempty"`
+	FinishedAt  *time.Time    `db:"finished_at,omitempty" json:"finished_at,omitempty"`
+	UpdatedAt   time.Time     `db:"updated_at" json:"updated_at"`
+	ClaimToken  uuid.UUID     `db:"claim_token" json:"-"`
+}
@@ -0,0 +1,27 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Attachment represents a file, e.g. a receipt, attached to an item.
+//
+// Fields:
+//   - ID: UUID primary key (DB default gen_random_uuid())
+//   - ItemID: FK to the item the file is attached to
+//   - Filename: original file name as uploaded
+//   - ContentType: content type sniffed from the file contents
+//   - Size: file size in bytes
+//   - SHA256: hex-encoded SHA-256 of the contents, also the storage key
+//   - CreatedAt: DB-managed timestamp
+type Attachment struct {
+	ID          uuid.UUID `db:"id" json:"id"`
+	ItemID      uuid.UUID `db:"item_id" json:"item_id"`
+	Filename    string    `db:"filename" json:"filename"`
+	ContentType string    `db:"content_type" json:"content_type"`
+	Size        int64     `db:"size" json:"size"`
+	SHA256      string    `db:"sha256" json:"sha256"`
+	CreatedAt   time.Time `db:"created_at" json:"created_at"`
+}
@@ -0,0 +1,133 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Roles of authenticated principals, from least to most privileged.
+// Viewers may read, editors may also modify data and admins may also
+// manage API keys.
+const (
+	RoleViewer = "viewer"
+	RoleEditor = "editor"
+	RoleAdmin  = "admin"
+)
+
+// Scopes restrict a principal to route groups of the API.
+const (
+	ScopeItems           = "items"
+	ScopeCategories      = "categories"
+	ScopeAccounts        = "accounts"
+	ScopeRecurring       = "recurring"
+	ScopeTags            = "tags"
+	ScopeRules           = "rules"
+	ScopeReconciliations = "reconciliations"
+	ScopeAnalytics       = "analytics"
+	ScopeKeys            = "keys"
+	ScopeWorkspaces      = "workspaces"
+	ScopeWebhooks        = "webhooks"
+	ScopeReports         = "reports"
+)
+
+// Authentication methods of principals.
+const (
+	AuthAPIKey = "api_key"
+	AuthJWT    = "jwt"
+	AuthNone   = "none"
+)
+
+// roleRank orders roles by privilege.
+var roleRank = map[string]int{
+	RoleViewer: 1,
+	RoleEditor: 2,
+	RoleAdmin:  3,
+}
+
+// Scopes lists every scope.
+var Scopes = []string{
+	ScopeItems, ScopeCategories, ScopeAccounts, ScopeRecurring, ScopeTags,
+	ScopeRules, ScopeReconciliations, ScopeAnalytics, ScopeKeys, ScopeWorkspaces,
+	ScopeWebhooks, ScopeReports,
+}
+
+// IsRole reports whether role is one of the roles.
+func IsRole(role string) bool {
+	_, ok := roleRank[role]
+	return ok
+}
+
+// IsScope reports whether scope is one of the scopes.
+func IsScope(scope string) bool {
+	for _, s := range Scopes {
+		if s == scope {
+			return true
+		}
+	}
+
+	return false
+}
+
+// APIKey represents a hashed API key of a machine client.
+//
+// Fields:
+//   - ID: UUID primary key (DB default gen_random_uuid())
+//   - Name: human-readable label of the key
+//   - Prefix: first characters of the key, identifying it in listings
+//   - Role: role granted to requests authenticated with the key
+//   - Scopes: route groups the key may access, empty allows all
+//   - WorkspaceID: workspace the key is bound to, nil allows all workspaces
+//   - ExpiresAt: optional time the key stops working
+//   - LastUsedAt: time the key last authenticated a request (approximate)
+//   - RevokedAt: time the key was revoked, nil while active
+//   - CreatedAt, UpdatedAt: DB-managed timestamps
+type APIKey struct {
+	ID          uuid.UUID  `db:"id" json:"id"`
+	Name        string     `db:"name" json:"name"`
+	Prefix      string     `db:"prefix" json:"prefix"`
+	Role        string     `db:"role" json:"role"`
+	Scopes      []string   `db:"scopes" json:"scopes"`
+	WorkspaceID *uuid.UUID `db:"workspace_id,omitempty" json:"workspace_id,omitempty"`
+	ExpiresAt   *time.Time `db:"expires_at,omitempty" json:"expires_at,omitempty"`
+	LastUsedAt  *time.Time `db:"last_used_at,omitempty" json:"last_used_at,omitempty"`
+	RevokedAt   *time.Time `db:"revoked_at,omitempty" json:"revoked_at,omitempty"`
+	CreatedAt   time.Time  `db:"created_at" json:"created_at"`
+	UpdatedAt   time.Time  `db:"updated_at" json:"updated_at"`
+}
+
+// Principal is the authenticated caller of a request.
+//
+// Fields:
+//   - Subject: API key ID or JWT subject
+//   - Role: granted role
+//   - Scopes: route groups the caller may access, empty allows all
+//   - WorkspaceID: workspace the caller is bound to, nil allows all workspaces
+//   - Method: how the caller authenticated (api_key, jwt or none)
+type Principal struct {
+	Subject     string     `json:"subject"`
+	Role        string     `json:"role"`
+	Scopes      []string   `json:"scopes,omitempty"`
+	WorkspaceID *uuid.UUID `json:"workspace_id,omitempty"`
+	Method      string     `json:"method"`
+}
+
+// HasRole reports whether the principal's role is at least role.
+func (p *Principal) HasRole(role string) bool {
+	return roleRank[p.Role] >= roleRank[role] && roleRank[role] > 0
+}
+
+// HasScope reports whether the principal may access scope.
+func (p *Principal) HasScope(scope string) bool {
+	if len(p.Scopes) == 0 {
+		return true
+	}
+
+	for _, s := range p.Scopes {
+		if s == scope {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,7 @@
+package model
+
+// DuplicateCluster is a group of items that are likely duplicates of each other:
+// same amount and currency, close occurred_at and similar titles.
+type DuplicateCluster struct {
+	Items []Item `json:"items"`
+}
@@ -0,0 +1,41 @@
+package model
+
+import (
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+)
+
+// Import entry statuses.
+const (
+	ImportCreated   = "created"   // an item was created from the entry
+	ImportDuplicate = "duplicate" // the entry was imported before and was skipped
+	ImportFailed    = "failed"    // the entry could not be imported, see Error
+)
+
+// ImportReport is the result of importing a bank file as items.
+type ImportReport struct {
+	Format     string        `json:"format"`
+	Total      int           `json:"total"`
+	Created    int           `json:"created"`
+	Duplicates int           `json:"duplicates"`
+	Failed     int           `json:"failed"`
+	Entries    []ImportEntry `json:"entries"`
+}
+
+// ImportEntry is the result of importing a single bank file entry.
+//
+// Fields:
+//   - Line: 1-based position of the entry in the file
+//   - Reference: bank reference (e.g. FITID) or a fingerprint of the entry if the bank gave none
+//   - Amount: signed amount of the entry, negative for debits
+//   - Status: created/duplicate/failed
+//   - ItemID: the created item, only set for created entries
+//   - Error: reason of a failed entry
+type ImportEntry struct {
+	Line      int             `json:"line"`
+	Reference string          `json:"reference"`
+	Amount    decimal.Decimal `json:"amount"`
+	Status    string          `json:"status"`
+	ItemID    *uuid.UUID      `json:"item_id,omitempty"`
+	Error     string          `json:"error,omitempty"`
+}
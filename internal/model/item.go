@@ -8,6 +8,20 @@ import (
 	"github.com/shopspring/decimal"
 )
 
+// Item kinds, matching the item_kind enum in the database.
+const (
+	KindIncome   = "income"
+	KindExpense  = "expense"
+	KindRefund   = "refund"
+	KindTransfer = "transfer"
+)
+
+// Transfer legs.
+const (
+	TransferSource      = "source"
+	TransferDestination = "destination"
+)
+
 // Item represents a financial record / sale / transaction.
 //
 // Fields:
@@ -18,17 +32,30 @@ import (
 //   - Currency: 3-letter ISO currency code, e.g. "USD"
 //   - OccurredAt: the timestamp when the transaction actually happened
 //   - CategoryID: optional FK to categories table
+//   - AccountID: optional FK to accounts table
+//   - TransferID, TransferLeg: link the source and destination legs of a transfer
+//   - RefundOf: for refunds, optional FK to the item being refunded
+//   - ReconciledAt: when the item was matched to a bank statement line, nil if not reconciled
 //   - Metadata: JSONB for extensible attributes
+//   - Splits: optional parts of Amount attributed to other categories
+//   - Tags: labels attached to the item
 //   - CreatedAt, UpdatedAt: DB-managed timestamps
 type Item struct {
-	ID         uuid.UUID       `db:"id" json:"id"`
-	Kind       string          `db:"kind" json:"kind"`
-	Title      string          `db:"title" json:"title"`
-	Amount     decimal.Decimal `db:"amount" json:"amount"` // as string to preserve precision; parse with decimal libs if needed
-	Currency   string          `db:"currency" json:"currency"`
-	OccurredAt time.Time       `db:"occurred_at" json:"occurred_at"`
-	CategoryID *uuid.UUID      `db:"category_id,omitempty" json:"category_id,omitempty"`
-	Metadata   json.RawMessage `db:"metadata" json:"metadata"` // store raw JSONB bytes
-	CreatedAt  time.Time       `db:"created_at" json:"created_at"`
-	UpdatedAt  time.Time       `db:"updated_at" json:"updated_at"`
+	ID           uuid.UUID       `db:"id" json:"id"`
+	Kind         string          `db:"kind" json:"kind"`
+	Title        string          `db:"title" json:"title"`
+	Amount       decimal.Decimal `db:"amount" json:"amount"` // as string to preserve precision; parse with decimal libs if needed
+	Currency     string          `db:"currency" json:"currency"`
+	OccurredAt   time.Time       `db:"occurred_at" json:"occurred_at"`
+	CategoryID   *uuid.UUID      `db:"category_id,omitempty" json:"category_id,omitempty"`
+	AccountID    *uuid.UUID      `db:"account_id,omitempty" json:"account_id,omitempty"`
+	TransferID   *uuid.UUID      `db:"transfer_id,omitempty" json:"transfer_id,omitempty"`
+	TransferLeg  *string         `db:"transfer_leg,omitempty" json:"transfer_leg,omitempty"`
+	RefundOf     *uuid.UUID      `db:"refund_of,omitempty" json:"refund_of,omitempty"`
+	ReconciledAt *time.Time      `db:"reconciled_at,omitempty" json:"reconciled_at,omitempty"`
+	Metadata     json.RawMessage `db:"metadata" json:"metadata"` // store raw JSONB bytes
+	Splits       []ItemSplit     `db:"-" json:"splits,omitempty"`
+	Tags         []Tag           `db:"-" json:"tags,omitempty"`
+	CreatedAt    time.Time       `db:"created_at" json:"created_at"`
+	UpdatedAt    time.Time       `db:"updated_at" json:"updated_at"`
 }
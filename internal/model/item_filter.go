@@ -14,7 +14,10 @@ type ItemFilter struct {
 	From       *time.Time `json:"from,omitempty"`
 	To         *time.Time `json:"to,omitempty"`
 	CategoryID *uuid.UUID `json:"category_id,omitempty"`
+	AccountID  *uuid.UUID `json:"account_id,omitempty"`
 	Kind       *string    `json:"kind,omitempty"`
+	Tags       *TagFilter `json:"tags,omitempty"`
+	Reconciled *bool      `json:"reconciled,omitempty"`
 	Limit      int        `json:"limit,omitempty"`
 	Offset     int        `json:"offset,omitempty"`
 	SortBy     string     `json:"sort_by,omitempty"` // e.g. "occurred_at desc"
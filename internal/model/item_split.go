@@ -0,0 +1,34 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+)
+
+// ItemSplit attributes a part of an item amount to a category.
+//
+// Fields:
+//   - ID: UUID primary key (DB default gen_random_uuid())
+//   - ItemID: FK to the parent item
+//   - CategoryID: optional FK to categories table
+//   - Amount: positive part of the parent item amount
+//   - Note: optional free-form note
+//   - CreatedAt: DB-managed timestamp
+type ItemSplit struct {
+	ID         uuid.UUID       `db:"id" json:"id"`
+	ItemID     uuid.UUID       `db:"item_id" json:"item_id"`
+	CategoryID *uuid.UUID      `db:"category_id,omitempty" json:"category_id,omitempty"`
+	Amount     decimal.Decimal `db:"amount" json:"amount"`
+	Note       *string         `db:"note,omitempty" json:"note,omitempty"`
+	CreatedAt  time.Time       `db:"created_at" json:"created_at"`
+}
+
+// CategoryTotal holds aggregated amounts attributed to a single category.
+// CategoryID is nil for uncategorized amounts.
+type CategoryTotal struct {
+	CategoryID *uuid.UUID `json:"category_id"`
+	Count      int64      `json:"count"`
+	Sum        string     `json:"sum"`
+}
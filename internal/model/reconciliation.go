@@ -0,0 +1,87 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+)
+
+// Reconciliation line statuses.
+const (
+	LineUnmatched = "unmatched" // no item is matched to the line
+	LineMatched   = "matched"   // an item was proposed by auto-matching, not yet confirmed
+	LineConfirmed = "confirmed" // the matched item is confirmed and reconciled
+	LineCreated   = "created"   // a missing item was created from the line and reconciled
+)
+
+// ReconciliationSession represents an uploaded bank statement being reconciled.
+//
+// Fields:
+//   - ID: UUID primary key (DB default gen_random_uuid())
+//   - AccountID: optional account the statement belongs to; limits matching and is used for created items
+//   - Format: statement file format (csv/ofx)
+//   - Filename: original file name as uploaded
+//   - Summary: number of lines per status
+//   - Lines: statement lines, only set when a single session is retrieved
+//   - CreatedAt, UpdatedAt: DB-managed timestamps
+type ReconciliationSession struct {
+	ID        uuid.UUID             `db:"id" json:"id"`
+	AccountID *uuid.UUID            `db:"account_id,omitempty" json:"account_id,omitempty"`
+	Format    string                `db:"format" json:"format"`
+	Filename  string                `db:"filename" json:"filename"`
+	Summary   ReconciliationSummary `db:"-" json:"summary"`
+	Lines     []ReconciliationLine  `db:"-" json:"lines,omitempty"`
+	CreatedAt time.Time             `db:"created_at" json:"created_at"`
+	UpdatedAt time.Time             `db:"updated_at" json:"updated_at"`
+}
+
+// ReconciliationSummary counts the lines of a session per status.
+type ReconciliationSummary struct {
+	Total     int `json:"total"`
+	Unmatched int `json:"unmatched"`
+	Matched   int `json:"matched"`
+	Confirmed int `json:"confirmed"`
+	Created   int `json:"created"`
+}
+
+// ReconciliationLine represents a single bank statement line.
+//
+// Fields:
+//   - ID: UUID primary key (DB default gen_random_uuid())
+//   - SessionID: FK to the reconciliation session
+//   - LineNo: 1-based position of the line in the statement
+//   - OccurredAt: booking date of the line
+//   - Amount: signed amount, negative for debits and positive for credits
+//   - Currency: 3-letter ISO currency code
+//   - Description: payee and memo of the line
+//   - Reference: bank transaction reference, e.g. the OFX FITID
+//   - Status: unmatched/matched/confirmed/created
+//   - ItemID: optional FK to the matched item
+//   - CreatedAt, UpdatedAt: DB-managed timestamps
+type ReconciliationLine struct {
+	ID          uuid.UUID       `db:"id" json:"id"`
+	SessionID   uuid.UUID       `db:"session_id" json:"session_id"`
+	LineNo      int             `db:"line_no" json:"line_no"`
+	OccurredAt  time.Time       `db:"occurred_at" json:"occurred_at"`
+	Amount      decimal.Decimal `db:"amount" json:"amount"`
+	Currency    string          `db:"currency" json:"currency"`
+	Description string          `db:"description" json:"description"`
+	Reference   string          `db:"reference" json:"reference,omitempty"`
+	Status      string          `db:"status" json:"status"`
+	ItemID      *uuid.UUID      `db:"item_id,omitempty" json:"item_id,omitempty"`
+	CreatedAt   time.Time       `db:"created_at" json:"created_at"`
+	UpdatedAt   time.Time       `db:"updated_at" json:"updated_at"`
+}
+
+// MatchesKind reports whether an item of the given kind and transfer leg can
+// book the line: credits match income and incoming transfers, debits match
+// expenses, refunds and outgoing transfers.
+func (l ReconciliationLine) MatchesKind(kind string, transferLeg *string) bool {
+	if l.Amount.IsPositive() {
+		return kind == KindIncome || (kind == KindTransfer && transferLeg != nil && *transferLeg == TransferDestination)
+	}
+
+	return kind == KindExpense || kind == KindRefund ||
+		(kind == KindTransfer && transferLeg != nil && *transferLeg == TransferSource)
+}
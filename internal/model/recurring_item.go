@@ -0,0 +1,55 @@
+package model
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+)
+
+// Recurring occurrence statuses.
+const (
+	OccurrencePending = "pending"
+	OccurrenceCreated = "created"
+	OccurrenceSkipped = "skipped"
+)
+
+// RecurringItem represents a template that periodically materializes items.
+//
+// Fields:
+//   - ID: UUID primary key (DB default gen_random_uuid())
+//   - Kind, Title, Amount, Currency, CategoryID, Metadata: template of the created items
+//   - Rule: RRULE-like schedule, e.g. "FREQ=MONTHLY;INTERVAL=1"
+//   - StartAt: the first occurrence of the schedule
+//   - EndAt: optional timestamp after which no occurrences are created
+//   - Paused: whether materialization is suspended
+//   - NextIndex: index of the next occurrence to materialize
+//   - NextRunAt: timestamp of the next occurrence, nil when the schedule is exhausted
+//   - CreatedAt, UpdatedAt: DB-managed timestamps
+type RecurringItem struct {
+	ID         uuid.UUID       `db:"id" json:"id"`
+	Kind       string          `db:"kind" json:"kind"`
+	Title      string          `db:"title" json:"title"`
+	Amount     decimal.Decimal `db:"amount" json:"amount"`
+	Currency   string          `db:"currency" json:"currency"`
+	CategoryID *uuid.UUID      `db:"category_id,omitempty" json:"category_id,omitempty"`
+	Metadata   json.RawMessage `db:"metadata" json:"metadata"`
+	Rule       string          `db:"rule" json:"rule"`
+	StartAt    time.Time       `db:"start_at" json:"start_at"`
+	EndAt      *time.Time      `db:"end_at,omitempty" json:"end_at,omitempty"`
+	Paused     bool            `db:"paused" json:"paused"`
+	NextIndex  int             `db:"next_index" json:"next_index"`
+	NextRunAt  *time.Time      `db:"next_run_at,omitempty" json:"next_run_at,omitempty"`
+	CreatedAt  time.Time       `db:"created_at" json:"created_at"`
+	UpdatedAt  time.Time       `db:"updated_at" json:"updated_at"`
+}
+
+// RecurringOccurrence records how a single occurrence of a recurring item was handled.
+type RecurringOccurrence struct {
+	RecurringItemID uuid.UUID  `db:"recurring_item_id" json:"recurring_item_id"`
+	OccurrenceAt    time.Time  `db:"occurrence_at" json:"occurrence_at"`
+	Status          string     `db:"status" json:"status"`
+	ItemID          *uuid.UUID `db:"item_id,omitempty" json:"item_id,omitempty"`
+	CreatedAt       time.Time  `db:"created_at" json:"created_at"`
+}
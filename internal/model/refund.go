@@ -0,0 +1,30 @@
+package model
+
+import (
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+)
+
+// RefundSummary describes how much of an item has been refunded.
+//
+// Fields:
+//   - ItemID: the original item
+//   - Currency, Amount: currency and amount of the original item
+//   - Refunded: cumulative amount of linked refunds
+//   - Remaining: amount that can still be refunded
+//   - Refunds: refund items referencing the original item
+type RefundSummary struct {
+	ItemID    uuid.UUID       `json:"item_id"`
+	Currency  string          `json:"currency"`
+	Amount    decimal.Decimal `json:"amount"`
+	Refunded  decimal.Decimal `json:"refunded"`
+	Remaining decimal.Decimal `json:"remaining"`
+	Refunds   []Item          `json:"refunds"`
+}
+
+// Revenue holds gross income, refunds of income items and net-of-refunds revenue.
+type Revenue struct {
+	Gross   string `json:"gross"`
+	Refunds string `json:"refunds"`
+	Net     string `json:"net"`
+}
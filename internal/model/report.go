@@ -0,0 +1,77 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Report metrics, named like the analytics endpoints computing them.
+const (
+	MetricSum        = "sum"
+	MetricAvg        = "avg"
+	MetricCount      = "count"
+	MetricMedian     = "median"
+	MetricPercentile = "percentile"
+	MetricRevenue    = "revenue"
+	MetricCategories = "categories"
+	MetricTags       = "tags"
+)
+
+// ReportMetrics lists every report metric.
+var ReportMetrics = []string{
+	MetricSum, MetricAvg, MetricCount, MetricMedian, MetricPercentile,
+	MetricRevenue, MetricCategories, MetricTags,
+}
+
+// Report groupings, the length of the periods a report is broken down into.
+const (
+	GroupNone    = ""
+	GroupDay     = "day"
+	GroupWeek    = "week"
+	GroupMonth   = "month"
+	GroupQuarter = "quarter"
+	GroupYear    = "year"
+)
+
+// ReportSpec describes a report: metrics of the items matching the filters,
+// over the whole range or per period of GroupBy.
+//
+// Fields:
+//   - Metrics: metrics to compute, e.g. "sum" or "categories"
+//   - From, To: date range, both inclusive; required when grouping
+//   - CategoryID, Kind, AccountID, Tags: filters as of the analytics endpoints
+//   - Percentile: percentile computed by the "percentile" metric (0.0–1.0)
+//   - GroupBy: day/week/month/quarter/year, empty for the whole range
+type ReportSpec struct {
+	Metrics    []string   `json:"metrics"`
+	From       *time.Time `json:"from,omitempty"`
+	To         *time.Time `json:"to,omitempty"`
+	CategoryID *uuid.UUID `json:"category_id,omitempty"`
+	Kind       *string    `json:"kind,omitempty"`
+	AccountID  *uuid.UUID `json:"account_id,omitempty"`
+	Tags       *TagFilter `json:"tags,omitempty"`
+	Percentile float64    `json:"percentile,omitempty"`
+	GroupBy    string     `json:"group_by,omitempty"`
+}
+
+// ReportResult holds the metrics of a report per period, oldest first, or for
+// the whole range in a single period if it is not grouped.
+type ReportResult struct {
+	Periods []ReportPeriod `json:"periods"`
+}
+
+// ReportPeriod holds the metrics of a report over a period. Metrics that were
+// not requested are omitted.
+type ReportPeriod struct {
+	From       *time.Time      `json:"from,omitempty"`
+	To         *time.Time      `json:"to,omitempty"`
+	Sum        *string         `json:"sum,omitempty"`
+	Avg        *string         `json:"avg,omitempty"`
+	Count      *int64          `json:"count,omitempty"`
+	Median     *string         `json:"median,omitempty"`
+	Percentile *string         `json:"percentile,omitempty"`
+	Revenue    *Revenue        `json:"revenue,omitempty"`
+	Categories []CategoryTotal `json:"categories,omitempty"`
+	Tags       []TagTotal      `json:"tags,omitempty"`
+}
@@ -0,0 +1,108 @@
+package model
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+)
+
+// Rule condition fields.
+const (
+	RuleFieldTitle    = "title"
+	RuleFieldAmount   = "amount"
+	RuleFieldKind     = "kind"
+	RuleFieldCurrency = "currency"
+	RuleFieldMetadata = "metadata"
+)
+
+// Rule condition operators.
+const (
+	RuleOpEquals   = "equals"
+	RuleOpContains = "contains"
+	RuleOpRegex    = "regex"
+	RuleOpBetween  = "between"
+	RuleOpExists   = "exists"
+)
+
+// Rule action types.
+const (
+	RuleActionSetCategory = "set_category"
+	RuleActionAddMetadata = "add_metadata"
+	RuleActionSetKind     = "set_kind"
+)
+
+// Rule represents an auto-categorization rule applied to items.
+//
+// Fields:
+//   - ID: UUID primary key (DB default gen_random_uuid())
+//   - Name: human-readable rule name
+//   - Position: evaluation order, lower positions run first
+//   - Enabled: whether the rule is evaluated at all
+//   - StopProcessing: when the rule matches, later rules are not evaluated
+//   - Conditions: all must match for the rule to apply
+//   - Actions: changes applied to matching items in order
+//   - CreatedAt, UpdatedAt: DB-managed timestamps
+type Rule struct {
+	ID             uuid.UUID       `db:"id" json:"id"`
+	Name           string          `db:"name" json:"name"`
+	Position       int             `db:"position" json:"position"`
+	Enabled        bool            `db:"enabled" json:"enabled"`
+	StopProcessing bool            `db:"stop_processing" json:"stop_processing"`
+	Conditions     []RuleCondition `db:"conditions" json:"conditions"`
+	Actions        []RuleAction    `db:"actions" json:"actions"`
+	CreatedAt      time.Time       `db:"created_at" json:"created_at"`
+	UpdatedAt      time.Time       `db:"updated_at" json:"updated_at"`
+}
+
+// RuleCondition is a single predicate over an item field.
+//
+// Title supports equals (case-insensitive), contains (case-insensitive) and regex.
+// Amount supports between with optional Min and Max bounds (inclusive).
+// Kind and Currency support equals. Metadata supports equals, contains and
+// exists on the dot-separated Path, e.g. "payment.provider".
+type RuleCondition struct {
+	Field    string           `json:"field"`
+	Operator string           `json:"operator"`
+	Path     string           `json:"path,omitempty"`
+	Value    string           `json:"value,omitempty"`
+	Min      *decimal.Decimal `json:"min,omitempty"`
+	Max      *decimal.Decimal `json:"max,omitempty"`
+}
+
+// RuleAction is a single change applied to a matching item.
+//
+// set_category sets CategoryID, add_metadata sets Key to Value and set_kind
+// sets Kind.
+type RuleAction struct {
+	Type       string          `json:"type"`
+	CategoryID *uuid.UUID      `json:"category_id,omitempty"`
+	Key        string          `json:"key,omitempty"`
+	Value      json.RawMessage `json:"value,omitempty"`
+	Kind       string          `json:"kind,omitempty"`
+}
+
+// RuleChange describes the classification of an item before or after rules.
+type RuleChange struct {
+	Kind       string          `json:"kind"`
+	CategoryID *uuid.UUID      `json:"category_id,omitempty"`
+	Metadata   json.RawMessage `json:"metadata"`
+}
+
+// RuleResult is the outcome of applying rules to a single existing item.
+type RuleResult struct {
+	ItemID  uuid.UUID   `json:"item_id"`
+	Title   string      `json:"title"`
+	RuleIDs []uuid.UUID `json:"rule_ids"`
+	Before  RuleChange  `json:"before"`
+	After   RuleChange  `json:"after"`
+}
+
+// RuleApplyReport summarizes a run of rules over existing items.
+type RuleApplyReport struct {
+	DryRun  bool         `json:"dry_run"`
+	Scanned int          `json:"scanned"`
+	Changed int          `json:"changed"`
+	Results []RuleResult `json:"results"`
+}
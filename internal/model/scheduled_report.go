@@ -0,0 +1,72 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Report run statuses.
+const (
+	RunRunning   = "running"   // being computed and sent
+	RunSucceeded = "succeeded" // sent to every recipient
+	RunFailed    = "failed"    // computing or sending the report failed
+)
+
+// ScheduledReport represents a saved report emailed on a schedule.
+//
+// Fields:
+//   - ID: UUID primary key (DB default gen_random_uuid())
+//   - WorkspaceID: workspace whose items the report is computed over
+//   - Name: subject of the emails
+//   - Spec: the report to compute, without a date range
+//   - Range: calendar period covered by each run, the last full one before the run, e.g. week
+//   - Rule: RRULE-like schedule, e.g. "FREQ=WEEKLY" with StartAt on a Monday morning
+//   - StartAt: the first occurrence of the schedule
+//   - Recipients: email addresses the report is sent to
+//   - Enabled: whether the report is sent
+//   - NextIndex: index of the next occurrence to run
+//   - NextRunAt: timestamp of the next occurrence, nil when the schedule is exhausted
+//   - Attempts: number of failed attempts at the next occurrence
+//   - RetryAt: when the next occurrence is retried after a failed attempt
+//   - CreatedAt, UpdatedAt: DB-managed timestamps
+type ScheduledReport struct {
+	ID          uuid.UUID  `db:"id" json:"id"`
+	WorkspaceID uuid.UUID  `db:"workspace_id" json:"-"`
+	Name        string     `db:"name" json:"name"`
+	Spec        ReportSpec `db:"spec" json:"spec"`
+	Range       string     `db:"range" json:"range"`
+	Rule        string     `db:"rule" json:"rule"`
+	StartAt     time.Time  `db:"start_at" json:"start_at"`
+	Recipients  []string   `db:"recipients" json:"recipients"`
+	Enabled     bool       `db:"enabled" json:"enabled"`
+	NextIndex   int        `db:"next_index" json:"next_index"`
+	NextRunAt   *time.Time `db:"next_run_at,omitempty" json:"next_run_at,omitempty"`
+	Attempts    int        `db:"attempts" json:"attempts"`
+	RetryAt     *time.Time `db:"retry_at,omitempty" json:"retry_at,omitempty"`
+	CreatedAt   time.Time  `db:"created_at" json:"created_at"`
+	UpdatedAt   time.Time  `db:"updated_at" json:"updated_at"`
+}
+
+// ReportRun records a single run of a scheduled report.
+//
+// Fields:
+//   - OccurrenceAt: the occurrence of the schedule the run is for
+//   - PeriodFrom, PeriodTo: date range the report covered, both inclusive
+//   - Status: running/succeeded/failed
+//   - Attempt: number of the attempt at the occurrence, from 1
+//   - Recipients: email addresses the report was sent to
+//   - Error: why the run failed
+type ReportRun struct {
+	ID           uuid.UUID  `db:"id" json:"id"`
+	ReportID     uuid.UUID  `db:"report_id" json:"report_id"`
+	OccurrenceAt time.Time  `db:"occurrence_at" json:"occurrence_at"`
+	PeriodFrom   time.Time  `db:"period_from" json:"period_from"`
+	PeriodTo     time.Time  `db:"period_to" json:"period_to"`
+	Status       string     `db:"status" json:"status"`
+	Attempt      int        `db:"attempt" json:"attempt"`
+	Recipients   []string   `db:"recipients" json:"recipients"`
+	Error        *string    `db:"error,omitempty" json:"error,omitempty"`
+	StartedAt    time.Time  `db:"started_at" json:"started_at"`
+	FinishedAt   *time.Time `db:"finished_at,omitempty" json:"finished_at,omitempty"`
+}
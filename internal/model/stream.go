@@ -0,0 +1,47 @@
+package model
+
+import (
+	"strconv"
+	"time"
+)
+
+// ItemChange is a logged change to an item, streamed to clients.
+//
+// Fields:
+//   - ID: increasing change ID, taken when the change is made
+//   - XactID: ID of the transaction that made the change
+//   - Type: item.created, item.updated or item.deleted
+//   - Item: the item after the change, or before it for deletions, without splits and tags
+//   - ChangedAt: when the change was made
+type ItemChange struct {
+	ID        int64     `json:"id"`
+	XactID    uint64    `json:"-"`
+	Type      string    `json:"type"`
+	Item      Item      `json:"item"`
+	ChangedAt time.Time `json:"changed_at"`
+}
+
+// Cursor returns the position of the change in the change log.
+func (c *ItemChange) Cursor() ChangeCursor {
+	return ChangeCursor{XactID: c.XactID, ID: c.ID}
+}
+
+// ChangeCursor is a position in the item change log, used as the SSE event
+// ID. Changes are ordered by the transaction that made them and then by ID,
+// since IDs are taken before transactions commit, in any order.
+type ChangeCursor struct {
+	XactID uint64
+	ID     int64
+}
+
+// String formats the cursor as "<xact_id>-<id>".
+func (c ChangeCursor) String() string {
+	return strconv.FormatUint(c.XactID, 10) + "-" + strconv.FormatInt(c.ID, 10)
+}
+
+// Totals are the running aggregates of items matching a filter.
+type Totals struct {
+	Sum   string `json:"sum"`
+	Avg   string `json:"avg"`
+	Count int64  `json:"count"`
+}
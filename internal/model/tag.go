@@ -0,0 +1,39 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+)
+
+// Tag represents a free-form label attached to items, orthogonal to categories.
+//
+// Fields:
+//   - ID: UUID primary key (DB default gen_random_uuid())
+//   - Name: unique (case-insensitive) label, e.g. "tax-deductible" or "client:acme"
+//   - Description: optional description text
+//   - CreatedAt, UpdatedAt: DB-managed timestamps
+type Tag struct {
+	ID          uuid.UUID `db:"id" json:"id"`
+	Name        string    `db:"name" json:"name"`
+	Description *string   `db:"description,omitempty" json:"description,omitempty"`
+	CreatedAt   time.Time `db:"created_at" json:"created_at"`
+	UpdatedAt   time.Time `db:"updated_at" json:"updated_at"`
+}
+
+// TagFilter restricts items to those tagged with the given tag names.
+// Names are lower-cased and unique. With MatchAll an item must have all
+// of the tags, otherwise any of them.
+type TagFilter struct {
+	Names    []string `json:"names"`
+	MatchAll bool     `json:"match_all"`
+}
+
+// TagTotal is the number and sum of items with a tag.
+type TagTotal struct {
+	TagID uuid.UUID       `json:"tag_id"`
+	Name  string          `json:"name"`
+	Count int64           `json:"count"`
+	Sum   decimal.Decimal `json:"sum"`
+}
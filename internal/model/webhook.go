@@ -0,0 +1,114 @@
+package model
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Lifecycle event types delivered to webhooks.
+const (
+	EventItemCreated     = "item.created"
+	EventItemUpdated     = "item.updated"
+	EventItemDeleted     = "item.deleted"
+	EventCategoryCreated = "category.created"
+	EventCategoryUpdated = "category.updated"
+	EventCategoryDeleted = "category.deleted"
+)
+
+// EventTypes lists every event type.
+var EventTypes = []string{
+	EventItemCreated, EventItemUpdated, EventItemDeleted,
+	EventCategoryCreated, EventCategoryUpdated, EventCategoryDeleted,
+}
+
+// IsEventType reports whether t is one of the event types.
+func IsEventType(t string) bool {
+	for _, e := range EventTypes {
+		if e == t {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Webhook delivery statuses.
+const (
+	DeliveryPending   = "pending"   // waiting for the first attempt or a retry
+	DeliverySucceeded = "succeeded" // the receiver answered with a 2xx status
+	DeliveryFailed    = "failed"    // all attempts failed
+)
+
+// Event is a lifecycle event recorded in the outbox.
+//
+// Fields:
+//   - ID: UUID primary key, sent to receivers as the idempotency key of the event
+//   - WorkspaceID: workspace the changed resource belongs to
+//   - Type: event type, e.g. "item.created"
+//   - Data: the resource after the change, or before it for deletions
+//   - CreatedAt: when the change was committed
+type Event struct {
+	ID          uuid.UUID       `db:"id" json:"id"`
+	WorkspaceID uuid.UUID       `db:"workspace_id" json:"workspace_id"`
+	Type        string          `db:"event_type" json:"type"`
+	Data        json.RawMessage `db:"payload" json:"data"`
+	CreatedAt   time.Time       `db:"created_at" json:"created_at"`
+}
+
+// Webhook represents a subscription of a URL to lifecycle events.
+//
+// Fields:
+//   - ID: UUID primary key (DB default gen_random_uuid())
+//   - URL: http(s) endpoint events are POSTed to
+//   - EventTypes: subscribed event types, empty subscribes to all
+//   - Secret: HMAC-SHA256 key deliveries are signed with, never serialized
+//   - Enabled: disabled webhooks receive no new deliveries
+//   - CreatedAt, UpdatedAt: DB-managed timestamps
+type Webhook struct {
+	ID         uuid.UUID `db:"id" json:"id"`
+	URL        string    `db:"url" json:"url"`
+	EventTypes []string  `db:"event_types" json:"event_types"`
+	Secret     string    `db:"secret" json:"-"`
+	Enabled    bool      `db:"enabled" json:"enabled"`
+	CreatedAt  time.Time `db:"created_at" json:"created_at"`
+	UpdatedAt  time.Time `db:"updated_at" json:"updated_at"`
+}
+
+// WebhookDelivery represents the delivery of an event to a webhook.
+//
+// Fields:
+//   - ID: UUID primary key (DB default gen_random_uuid())
+//   - WebhookID: webhook the event is delivered to
+//   - EventID, EventType: the delivered event
+//   - Status: pending/succeeded/failed
+//   - Attempts: number of attempts made so far
+//   - NextAttemptAt: when the next attempt is due, meaningful while pending
+//   - LastStatusCode: HTTP status of the last attempt, nil if no response was received
+//   - LastError: error of the last failed attempt
+//   - DeliveredAt: when the receiver accepted the event
+//   - CreatedAt, UpdatedAt: DB-managed timestamps
+type WebhookDelivery struct {
+	ID             uuid.UUID  `db:"id" json:"id"`
+	WebhookID      uuid.UUID  `db:"webhook_id" json:"webhook_id"`
+	EventID        uuid.UUID  `db:"event_id" json:"event_id"`
+	EventType      string     `db:"event_type" json:"event_type"`
+	Status         string     `db:"status" json:"status"`
+	Attempts       int        `db:"attempts" json:"attempts"`
+	NextAttemptAt  time.Time  `db:"next_attempt_at" json:"next_attempt_at"`
+	LastStatusCode *int       `db:"last_status_code,omitempty" json:"last_status_code,omitempty"`
+	LastError      *string    `db:"last_error,omitempty" json:"last_error,omitempty"`
+	DeliveredAt    *time.Time `db:"delivered_at,omitempty" json:"delivered_at,omitempty"`
+	CreatedAt      time.Time  `db:"created_at" json:"created_at"`
+	UpdatedAt      time.Time  `db:"updated_at" json:"updated_at"`
+}
+
+// PendingDelivery is a due delivery together with what is needed to send it.
+type PendingDelivery struct {
+	ID       uuid.UUID
+	Attempts int
+	URL      string
+	Secret   string
+	Event    Event
+}
@@ -0,0 +1,23 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Workspace represents a tenant whose items, categories and other data are
+// isolated from other workspaces.
+//
+// Fields:
+//   - ID: UUID primary key (DB default gen_random_uuid())
+//   - Slug: unique URL-safe identifier, e.g. "retail-eu"
+//   - Name: display name
+//   - CreatedAt, UpdatedAt: DB-managed timestamps
+type Workspace struct {
+	ID        uuid.UUID `db:"id" json:"id"`
+	Slug      string    `db:"slug" json:"slug"`
+	Name      string    `db:"name" json:"name"`
+	CreatedAt time.Time `db:"created_at" json:"created_at"`
+	UpdatedAt time.Time `db:"updated_at" json:"updated_at"`
+}
@@ -0,0 +1,69 @@
+package ratelimit
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// sweepInterval is how often the memory store drops idle buckets.
+const sweepInterval = time.Minute
+
+// MemoryStore keeps buckets in process memory, so limits apply per server
+// instance. Buckets that have refilled completely are dropped, since a new
+// bucket is full as well.
+type MemoryStore struct {
+	mu        sync.Mutex
+	buckets   map[string]*bucket
+	lastSweep time.Time
+	now       func() time.Time
+}
+
+// bucket is the state of a single bucket.
+type bucket struct {
+	tokens float64
+	last   time.Time // time tokens were last refilled
+	full   time.Time // time the bucket is full again
+}
+
+// NewMemoryStore creates an empty memory store.
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{
+		buckets: make(map[string]*bucket),
+		now:     time.Now,
+	}
+}
+
+// Take implements Store.
+func (s *MemoryStore) Take(_ context.Context, key string, l Limit) (Result, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.now()
+	if now.Sub(s.lastSweep) >= sweepInterval {
+		s.sweep(now)
+	}
+
+	b, ok := s.buckets[key]
+	if !ok {
+		b = &bucket{tokens: float64(l.Burst), last: now}
+		s.buckets[key] = b
+	}
+
+	var res Result
+	b.tokens, res = take(b.tokens, b.last, now, l)
+	b.last, b.full = now, now.Add(res.Reset)
+
+	return res, nil
+}
+
+// sweep drops the buckets that are full by now.
+func (s *MemoryStore) sweep(now time.Time) {
+	for key, b := range s.buckets {
+		if !now.Before(b.full) {
+			delete(s.buckets, key)
+		}
+	}
+
+	s.lastSweep = now
+}
@@ -0,0 +1,85 @@
+package ratelimit
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestMemoryStore(t *testing.T) {
+	now := time.Date(2025, 10, 1, 12, 0, 0, 0, time.UTC)
+	s := NewMemoryStore()
+	s.now = func() time.Time { return now }
+
+	limit := Limit{Rate: 1, Burst: 2}
+	ctx := context.Background()
+
+	tests := []struct {
+		name      string
+		advance   time.Duration
+		key       string
+		allowed   bool
+		remaining int
+	}{
+		{"first request", 0, "client-a", true, 1},
+		{"burst used up", 0, "client-a", true, 0},
+		{"rejected while empty", 0, "client-a", false, 0},
+		{"other clients have their own bucket", 0, "client-b", true, 1},
+		{"not refilled yet", 500 * time.Millisecond, "client-a", false, 0},
+		{"refilled one token", 500 * time.Millisecond, "client-a", true, 0},
+		{"refilled to burst", time.Hour, "client-a", true, 1},
+	}
+
+	for _, tt := range tests {
+		now = now.Add(tt.advance)
+
+		res, err := s.Take(ctx, tt.key, limit)
+		if err != nil {
+			t.Fatalf("%s: Take() error = %v", tt.name, err)
+		}
+		if res.Allowed != tt.allowed || res.Remaining != tt.remaining || res.Limit != limit.Burst {
+			t.Errorf("%s: Take() = %+v, want allowed %v with %d remaining", tt.name, res, tt.allowed, tt.remaining)
+		}
+		if !res.Allowed && res.RetryAfter <= 0 {
+			t.Errorf("%s: rejected without a retry delay", tt.name)
+		}
+	}
+}
+
+func TestMemoryStoreSweepsFullBuckets(t *testing.T) {
+	now := time.Date(2025, 10, 1, 12, 0, 0, 0, time.UTC)
+	s := NewMemoryStore()
+	s.now = func() time.Time { return now }
+
+	ctx := context.Background()
+	slow := Limit{Rate: 1.0 / 3600, Burst: 1}
+
+	if _, err := s.Take(ctx, "idle", Limit{Rate: 1, Burst: 1}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.Take(ctx, "slow", slow); err != nil {
+		t.Fatal(err)
+	}
+
+	// After a sweep interval the idle bucket is full again and dropped; the
+	// slow one is still refilling and must keep its state.
+	now = now.Add(sweepInterval)
+	if _, err := s.Take(ctx, "other", slow); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, ok := s.buckets["idle"]; ok {
+		t.Error("full bucket was not swept")
+	}
+	if _, ok := s.buckets["slow"]; !ok {
+		t.Fatal("refilling bucket was swept")
+	}
+
+	res, err := s.Take(ctx, "slow", slow)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Allowed {
+		t.Error("refilling bucket allowed a request after the sweep")
+	}
+}
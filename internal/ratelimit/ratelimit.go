@@ -0,0 +1,78 @@
+// Package ratelimit implements token bucket rate limits. Every client has a
+// bucket per limited resource holding up to Burst tokens, refilled at Rate
+// tokens per second; a request takes one token and is rejected while the
+// bucket is empty. Buckets are kept in a Store, in process memory by default.
+package ratelimit
+
+import (
+	"context"
+	"errors"
+	"math"
+	"time"
+)
+
+// ErrRateLimited is returned for requests rejected by a rate limit.
+var ErrRateLimited = errors.New("rate limit exceeded, retry later")
+
+// Limit is the refill rate and capacity of a bucket.
+type Limit struct {
+	Rate  float64 // tokens added per second
+	Burst int     // max tokens, i.e. requests allowed at once
+}
+
+// Every returns the limit allowing requests per period on average and burst
+// requests at once. A burst below 1 is set to requests.
+func Every(requests int, period time.Duration, burst int) Limit {
+	if burst < 1 {
+		burst = requests
+	}
+
+	return Limit{Rate: float64(requests) / period.Seconds(), Burst: burst}
+}
+
+// Valid reports whether l allows any requests.
+func (l Limit) Valid() bool {
+	return l.Rate > 0 && l.Burst > 0 && !math.IsInf(l.Rate, 0)
+}
+
+// Result is the outcome of taking a token from a bucket.
+type Result struct {
+	Allowed    bool          // whether a token was taken
+	Limit      int           // capacity of the bucket
+	Remaining  int           // whole tokens left in the bucket
+	RetryAfter time.Duration // until the next token is available, if not allowed
+	Reset      time.Duration // until the bucket is full again
+}
+
+// Store keeps the buckets of all clients.
+type Store interface {
+	// Take takes a token from the bucket identified by key, creating a full
+	// bucket with limit l if there is none.
+	Take(ctx context.Context, key string, l Limit) (Result, error)
+}
+
+// take refills a bucket holding tokens at last to now and takes a token from
+// it. It returns the tokens left and the outcome.
+func take(tokens float64, last, now time.Time, l Limit) (float64, Result) {
+	if elapsed := now.Sub(last).Seconds(); elapsed > 0 {
+		tokens = math.Min(float64(l.Burst), tokens+elapsed*l.Rate)
+	}
+
+	res := Result{Limit: l.Burst}
+	if tokens >= 1 {
+		tokens--
+		res.Allowed = true
+	} else {
+		res.RetryAfter = seconds((1 - tokens) / l.Rate)
+	}
+
+	res.Remaining = int(tokens)
+	res.Reset = seconds((float64(l.Burst) - tokens) / l.Rate)
+
+	return tokens, res
+}
+
+// seconds converts a number of seconds to a duration.
+func seconds(s float64) time.Duration {
+	return time.Duration(s * float64(time.Second))
+}
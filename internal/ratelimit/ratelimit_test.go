@@ -0,0 +1,122 @@
+package ratelimit
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+func TestEvery(t *testing.T) {
+	tests := []struct {
+		requests int
+		period   time.Duration
+		burst    int
+		want     Limit
+	}{
+		{60, time.Minute, 10, Limit{Rate: 1, Burst: 10}},
+		{10, time.Second, 0, Limit{Rate: 10, Burst: 10}},
+		{1, 2 * time.Second, -1, Limit{Rate: 0.5, Burst: 1}},
+		{100, time.Hour, 5, Limit{Rate: 100.0 / 3600, Burst: 5}},
+	}
+
+	for _, tt := range tests {
+		if got := Every(tt.requests, tt.period, tt.burst); got != tt.want {
+			t.Errorf("Every(%d, %s, %d) = %+v, want %+v", tt.requests, tt.period, tt.burst, got, tt.want)
+		}
+	}
+}
+
+func TestLimitValid(t *testing.T) {
+	tests := []struct {
+		limit Limit
+		want  bool
+	}{
+		{Limit{Rate: 1, Burst: 1}, true},
+		{Limit{Rate: 0, Burst: 10}, false},
+		{Limit{Rate: -1, Burst: 10}, false},
+		{Limit{Rate: 1, Burst: 0}, false},
+		{Limit{Rate: math.Inf(1), Burst: 10}, false},
+		{Every(10, 0, 10), false},
+	}
+
+	for _, tt := range tests {
+		if got := tt.limit.Valid(); got != tt.want {
+			t.Errorf("%+v.Valid() = %v, want %v", tt.limit, got, tt.want)
+		}
+	}
+}
+
+func TestTake(t *testing.T) {
+	now := time.Date(2025, 10, 1, 12, 0, 0, 0, time.UTC)
+	perSecond := Limit{Rate: 1, Burst: 3}
+
+	tests := []struct {
+		name       string
+		tokens     float64
+		elapsed    time.Duration
+		limit      Limit
+		wantTokens float64
+		want       Result
+	}{
+		{
+			name:   "full bucket",
+			tokens: 3, limit: perSecond,
+			wantTokens: 2,
+			want:       Result{Allowed: true, Limit: 3, Remaining: 2, Reset: time.Second},
+		},
+		{
+			name:   "last token",
+			tokens: 1, limit: perSecond,
+			wantTokens: 0,
+			want:       Result{Allowed: true, Limit: 3, Remaining: 0, Reset: 3 * time.Second},
+		},
+		{
+			name:   "empty bucket",
+			tokens: 0, limit: perSecond,
+			wantTokens: 0,
+			want:       Result{Limit: 3, RetryAfter: time.Second, Reset: 3 * time.Second},
+		},
+		{
+			name:   "partial token",
+			tokens: 0.5, limit: perSecond,
+			wantTokens: 0.5,
+			want:       Result{Limit: 3, RetryAfter: 500 * time.Millisecond, Reset: 2500 * time.Millisecond},
+		},
+		{
+			name:   "refilled",
+			tokens: 0, elapsed: 2 * time.Second, limit: perSecond,
+			wantTokens: 1,
+			want:       Result{Allowed: true, Limit: 3, Remaining: 1, Reset: 2 * time.Second},
+		},
+		{
+			name:   "refill capped at burst",
+			tokens: 1, elapsed: time.Minute, limit: perSecond,
+			wantTokens: 2,
+			want:       Result{Allowed: true, Limit: 3, Remaining: 2, Reset: time.Second},
+		},
+		{
+			name:   "clock moved backwards",
+			tokens: 0, elapsed: -time.Minute, limit: perSecond,
+			wantTokens: 0,
+			want:       Result{Limit: 3, RetryAfter: time.Second, Reset: 3 * time.Second},
+		},
+		{
+			name:   "slow rate",
+			tokens: 0, elapsed: time.Second, limit: Every(1, 2*time.Second, 1),
+			wantTokens: 0.5,
+			want:       Result{Limit: 1, RetryAfter: time.Second, Reset: time.Second},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tokens, got := take(tt.tokens, now, now.Add(tt.elapsed), tt.limit)
+			if tokens != tt.wantTokens {
+				t.Errorf("tokens left = %v, want %v", tokens, tt.wantTokens)
+			}
+			if got != tt.want {
+				t.Errorf("take() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
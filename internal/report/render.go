@@ -0,0 +1,270 @@
+package report
+
+import (
+	"bytes"
+	"encoding/csv"
+	"fmt"
+	"html/template"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/aliskhannn/sales-tracker/internal/model"
+)
+
+// uncategorized names the total of items without a category.
+const uncategorized = "Uncategorized"
+
+// Document is a computed report ready to be rendered.
+type Document struct {
+	Title      string               // e.g. the name of a saved report
+	Range      Period               // date range the report covers
+	Spec       model.ReportSpec     // the computed report
+	Result     *model.ReportResult  // its metrics
+	Categories map[uuid.UUID]string // category names by ID, for the categories metric
+	Generated  time.Time            // when the report was computed
+}
+
+// csvHeader is the header of CSV reports.
+var csvHeader = []string{"period_from", "period_to", "metric", "name", "count", "value"}
+
+// RenderCSV renders a report as CSV with one row per value: the period, the
+// metric and, for metrics with several values, the name of the value, e.g.
+// "net" for revenue or a category name, its count if any and the value.
+func RenderCSV(d Document) ([]byte, error) {
+	var buf bytes.Buffer
+	w := csv.NewWriter(&buf)
+
+	rows := [][]string{csvHeader}
+	for _, p := range d.Result.Periods {
+		from, to := formatDate(p.From), formatDate(p.To)
+		row := func(metric, name, count, value string) {
+			rows = append(rows, []string{from, to, metric, name, count, value})
+		}
+
+		for _, m := range d.Spec.Metrics {
+			switch m {
+			case model.MetricSum:
+				row(m, "", "", deref(p.Sum))
+			case model.MetricAvg:
+				row(m, "", "", deref(p.Avg))
+			case model.MetricCount:
+				row(m, "", "", formatCount(p.Count))
+			case model.MetricMedian:
+				row(m, "", "", deref(p.Median))
+			case model.MetricPercentile:
+				row(m, strconv.FormatFloat(d.Spec.Percentile, 'f', -1, 64), "", deref(p.Percentile))
+			case model.MetricRevenue:
+				if p.Revenue != nil {
+					row(m, "gross", "", p.Revenue.Gross)
+					row(m, "refunds", "", p.Revenue.Refunds)
+					row(m, "net", "", p.Revenue.Net)
+				}
+			case model.MetricCategories:
+				for _, c := range p.Categories {
+					row(m, csvText(d.categoryName(c.CategoryID)), strconv.FormatInt(c.Count, 10), c.Sum)
+				}
+			case model.MetricTags:
+				for _, t := range p.Tags {
+					row(m, csvText(t.Name), strconv.FormatInt(t.Count, 10), t.Sum.String())
+				}
+			}
+		}
+	}
+
+	if err := w.WriteAll(rows); err != nil {
+		return nil, fmt.Errorf("write csv: %w", err)
+	}
+
+	return buf.Bytes(), nil
+}
+
+// RenderHTML renders a report as an HTML page, e.g. the body of an email:
+// a table of the single-valued metrics per period followed by the revenue,
+// category and tag breakdowns.
+func RenderHTML(d Document) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := htmlReport.Execute(&buf, newHTMLData(d)); err != nil {
+		return nil, fmt.Errorf("render html: %w", err)
+	}
+
+	return buf.Bytes(), nil
+}
+
+// htmlData is the data of the HTML template.
+type htmlData struct {
+	Title      string
+	From, To   string
+	Columns    []string  // single-valued metrics
+	Rows       []htmlRow // their values per period
+	Revenue    []htmlRow // gross, refunds and net per period
+	Categories []htmlRow // count and sum per period and category
+	Tags       []htmlRow // count and sum per period and tag
+	Generated  string
+}
+
+// htmlRow is a row of a table of the HTML report.
+type htmlRow struct {
+	From, To string
+	Name     string
+	Values   []string
+}
+
+// newHTMLData lays a report out for the HTML template.
+func newHTMLData(d Document) htmlData {
+	data := htmlData{
+		Title:     d.Title,
+		From:      formatDate(d.Range.From),
+		To:        formatDate(d.Range.To),
+		Generated: d.Generated.UTC().Format(time.RFC1123),
+	}
+
+	for _, m := range d.Spec.Metrics {
+		switch m {
+		case model.MetricSum, model.MetricAvg, model.MetricCount, model.MetricMedian:
+			data.Columns = append(data.Columns, m)
+		case model.MetricPercentile:
+			data.Columns = append(data.Columns, "p"+strconv.FormatFloat(d.Spec.Percentile*100, 'f', -1, 64))
+		}
+	}
+
+	for _, p := range d.Result.Periods {
+		from, to := formatDate(p.From), formatDate(p.To)
+
+		if len(data.Columns) > 0 {
+			row := htmlRow{From: from, To: to}
+			for _, m := range d.Spec.Metrics {
+				switch m {
+				case model.MetricSum:
+					row.Values = append(row.Values, deref(p.Sum))
+				case model.MetricAvg:
+					row.Values = append(row.Values, deref(p.Avg))
+				case model.MetricCount:
+					row.Values = append(row.Values, formatCount(p.Count))
+				case model.MetricMedian:
+					row.Values = append(row.Values, deref(p.Median))
+				case model.MetricPercentile:
+					row.Values = append(row.Values, deref(p.Percentile))
+				}
+			}
+			data.Rows = append(data.Rows, row)
+		}
+
+		if p.Revenue != nil {
+			data.Revenue = append(data.Revenue, htmlRow{
+				From: from, To: to, Values: []string{p.Revenue.Gross, p.Revenue.Refunds, p.Revenue.Net},
+			})
+		}
+
+		for _, c := range p.Categories {
+			data.Categories = append(data.Categories, htmlRow{
+				From: from, To: to, Name: d.categoryName(c.CategoryID),
+				Values: []string{strconv.FormatInt(c.Count, 10), c.Sum},
+			})
+		}
+
+		for _, t := range p.Tags {
+			data.Tags = append(data.Tags, htmlRow{
+				From: from, To: to, Name: t.Name,
+				Values: []string{strconv.FormatInt(t.Count, 10), t.Sum.String()},
+			})
+		}
+	}
+
+	return data
+}
+
+// categoryName returns the name of a category, or its ID if it is unknown.
+func (d Document) categoryName(id *uuid.UUID) string {
+	if id == nil {
+		return uncategorized
+	}
+
+	if name, ok := d.Categories[*id]; ok {
+		return name
+	}
+
+	return id.String()
+}
+
+// htmlReport is the template of HTML reports. Styles are inline since most
+// email clients drop style sheets.
+var htmlReport = template.Must(template.New("report").Parse(`<!DOCTYPE html>
+<html>
+<head><meta charset="utf-8"><title>{{.Title}}</title></head>
+<body style="font-family: Arial, sans-serif; color: #1f2937;">
+<h2 style="margin-bottom: 4px;">{{.Title}}</h2>
+<p style="margin-top: 0; color: #6b7280;">{{.From}} – {{.To}}</p>
+{{define "head"}}<th style="text-align: left; padding: 4px 12px; border-bottom: 1px solid #d1d5db;">{{.}}</th>{{end}}
+{{define "cell"}}<td style="padding: 4px 12px; border-bottom: 1px solid #e5e7eb;">{{.}}</td>{{end}}
+{{define "num"}}<td style="padding: 4px 12px; border-bottom: 1px solid #e5e7eb; text-align: right;">{{.}}</td>{{end}}
+{{if .Rows}}
+<table style="border-collapse: collapse; margin-bottom: 16px;">
+<tr>{{template "head" "From"}}{{template "head" "To"}}{{range .Columns}}{{template "head" .}}{{end}}</tr>
+{{range .Rows}}<tr>{{template "cell" .From}}{{template "cell" .To}}{{range .Values}}{{template "num" .}}{{end}}</tr>
+{{end}}</table>
+{{end}}
+{{if .Revenue}}
+<h3>Revenue</h3>
+<table style="border-collapse: collapse; margin-bottom: 16px;">
+<tr>{{template "head" "From"}}{{template "head" "To"}}{{template "head" "Gross"}}{{template "head" "Refunds"}}{{template "head" "Net"}}</tr>
+{{range .Revenue}}<tr>{{template "cell" .From}}{{template "cell" .To}}{{range .Values}}{{template "num" .}}{{end}}</tr>
+{{end}}</table>
+{{end}}
+{{if .Categories}}
+<h3>By category</h3>
+<table style="border-collapse: collapse; margin-bottom: 16px;">
+<tr>{{template "head" "From"}}{{template "head" "To"}}{{template "head" "Category"}}{{template "head" "Count"}}{{template "head" "Sum"}}</tr>
+{{range .Categories}}<tr>{{template "cell" .From}}{{template "cell" .To}}{{template "cell" .Name}}{{range .Values}}{{template "num" .}}{{end}}</tr>
+{{end}}</table>
+{{end}}
+{{if .Tags}}
+<h3>By tag</h3>
+<table style="border-collapse: collapse; margin-bottom: 16px;">
+<tr>{{template "head" "From"}}{{template "head" "To"}}{{template "head" "Tag"}}{{template "head" "Count"}}{{template "head" "Sum"}}</tr>
+{{range .Tags}}<tr>{{template "cell" .From}}{{template "cell" .To}}{{template "cell" .Name}}{{range .Values}}{{template "num" .}}{{end}}</tr>
+{{end}}</table>
+{{end}}
+<p style="color: #9ca3af; font-size: 12px;">Generated by SalesTracker on {{.Generated}}. The data is attached as CSV.</p>
+</body>
+</html>
+`))
+
+// csvText keeps spreadsheets from evaluating a user-defined name as a formula
+// by prefixing names starting with a formula character with a quote.
+func csvText(s string) string {
+	if s != "" && strings.ContainsRune("=+-@\t\r", rune(s[0])) {
+		return "'" + s
+	}
+
+	return s
+}
+
+// formatDate formats a period bound as a date, or "" if it is unbounded.
+func formatDate(t *time.Time) string {
+	if t == nil {
+		return ""
+	}
+
+	return t.UTC().Format(time.DateOnly)
+}
+
+// formatCount formats a count, or "" if it was not computed.
+func formatCount(n *int64) string {
+	if n == nil {
+		return ""
+	}
+
+	return strconv.FormatInt(*n, 10)
+}
+
+// deref returns the value of s, or "" if it was not computed.
+func deref(s *string) string {
+	if s == nil {
+		return ""
+	}
+
+	return *s
+}
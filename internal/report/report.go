@@ -0,0 +1,246 @@
+// Package report computes reports: analytics metrics of the items matching a
+// filter over a date range, optionally broken down into days, weeks, months,
+// quarters or years. Reports are computed metric by metric and period by
+// period, so that the progress of long ones can be reported, and rendered as
+// HTML or CSV.
+package report
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/aliskhannn/sales-tracker/internal/model"
+)
+
+// MaxPeriods bounds the number of periods a report is broken down into.
+const MaxPeriods = 1000
+
+var (
+	ErrNoMetrics         = errors.New("at least one metric is required")
+	ErrInvalidMetric     = errors.New("unknown metric, expected one of sum, avg, count, median, percentile, revenue, categories, tags")
+	ErrInvalidGrouping   = errors.New("invalid group_by, expected day, week, month, quarter or year")
+	ErrRangeRequired     = errors.New("from and to are required to group by period")
+	ErrInvalidRange      = errors.New("from must not be after to")
+	ErrInvalidPercentile = errors.New("percentile must be between 0 and 1")
+	ErrTooManyPeriods    = errors.New("too many periods, narrow the range or group by a longer period")
+)
+
+// Analytics computes the metrics of a report.
+type Analytics interface {
+	Sum(ctx context.Context, from, to *time.Time, categoryID *uuid.UUID, kind *string, accountID *uuid.UUID, tags *model.TagFilter) (string, error)
+	Avg(ctx context.Context, from, to *time.Time, categoryID *uuid.UUID, kind *string, accountID *uuid.UUID, tags *model.TagFilter) (string, error)
+	Count(ctx context.Context, from, to *time.Time, categoryID *uuid.UUID, kind *string, accountID *uuid.UUID, tags *model.TagFilter) (int64, error)
+	Median(ctx context.Context, from, to *time.Time, categoryID *uuid.UUID, kind *string, accountID *uuid.UUID, tags *model.TagFilter) (string, error)
+	Percentile(ctx context.Context, from, to *time.Time, categoryID *uuid.UUID, kind *string, accountID *uuid.UUID, tags *model.TagFilter, percentile float64) (string, error)
+	Revenue(ctx context.Context, from, to *time.Time, categoryID, accountID *uuid.UUID, tags *model.TagFilter) (*model.Revenue, error)
+	ByCategory(ctx context.Context, from, to *time.Time, kind *string, accountID *uuid.UUID, tags *model.TagFilter) ([]model.CategoryTotal, error)
+	ByTag(ctx context.Context, from, to *time.Time, categoryID *uuid.UUID, kind *string, accountID *uuid.UUID, tags *model.TagFilter) ([]model.TagTotal, error)
+}
+
+// Validate checks spec and normalizes its metrics, which are lower-cased and
+// deduplicated, and its grouping.
+func Validate(spec *model.ReportSpec) error {
+	metrics := make([]string, 0, len(spec.Metrics))
+	for _, m := range spec.Metrics {
+		m = strings.ToLower(strings.TrimSpace(m))
+		if !slices.Contains(model.ReportMetrics, m) {
+			return fmt.Errorf("%w: %q", ErrInvalidMetric, m)
+		}
+
+		if !slices.Contains(metrics, m) {
+			metrics = append(metrics, m)
+		}
+	}
+
+	if len(metrics) == 0 {
+		return ErrNoMetrics
+	}
+	spec.Metrics = metrics
+
+	if slices.Contains(metrics, model.MetricPercentile) && (spec.Percentile < 0 || spec.Percentile > 1) {
+		return ErrInvalidPercentile
+	}
+
+	if spec.From != nil && spec.To != nil && spec.From.After(*spec.To) {
+		return ErrInvalidRange
+	}
+
+	spec.GroupBy = strings.ToLower(strings.TrimSpace(spec.GroupBy))
+	switch spec.GroupBy {
+	case model.GroupNone:
+		return nil
+	case model.GroupDay, model.GroupWeek, model.GroupMonth, model.GroupQuarter, model.GroupYear:
+	default:
+		return ErrInvalidGrouping
+	}
+
+	if spec.From == nil || spec.To == nil {
+		return ErrRangeRequired
+	}
+
+	if len(Periods(*spec)) > MaxPeriods {
+		return ErrTooManyPeriods
+	}
+
+	return nil
+}
+
+// Period is a date range of a report, both bounds inclusive; a nil bound is
+// unbounded.
+type Period struct {
+	From *time.Time
+	To   *time.Time
+}
+
+// Periods returns the periods spec is broken down into, oldest first: a
+// single one for the whole range if it is not grouped, otherwise one per
+// calendar period in UTC, weeks starting on Monday, cut to the range. It stops
+// after one period more than MaxPeriods.
+func Periods(spec model.ReportSpec) []Period {
+	if spec.GroupBy == model.GroupNone || spec.From == nil || spec.To == nil {
+		return []Period{{From: spec.From, To: spec.To}}
+	}
+
+	var (
+		periods []Period
+		from    = spec.From.UTC()
+		to      = spec.To.UTC()
+	)
+
+	for !from.After(to) && len(periods) <= MaxPeriods {
+		// Bounds are inclusive, so a period ends a microsecond, the
+		// precision of PostgreSQL timestamps, before the next one starts.
+		next := nextPeriod(from, spec.GroupBy)
+		end := next.Add(-time.Microsecond)
+		if end.After(to) {
+			end = to
+		}
+
+		start := from
+		periods = append(periods, Period{From: &start, To: &end})
+		from = next
+	}
+
+	return periods
+}
+
+// Previous returns the last full calendar period of grouping, in UTC, that
+// ended before t, e.g. the previous week for a report sent on Monday morning.
+func Previous(t time.Time, grouping string) Period {
+	end := periodStart(t.UTC(), grouping)
+	start := periodStart(end.Add(-time.Microsecond), grouping)
+	to := end.Add(-time.Microsecond)
+
+	return Period{From: &start, To: &to}
+}
+
+// periodStart returns the start of the period of grouping t is in.
+func periodStart(t time.Time, grouping string) time.Time {
+	y, m, d := t.Date()
+
+	switch grouping {
+	case model.GroupDay:
+		return time.Date(y, m, d, 0, 0, 0, 0, time.UTC)
+	case model.GroupWeek:
+		// Days since Monday.
+		offset := (int(t.Weekday()) + 6) % 7
+		return time.Date(y, m, d-offset, 0, 0, 0, 0, time.UTC)
+	case model.GroupMonth:
+		return time.Date(y, m, 1, 0, 0, 0, 0, time.UTC)
+	case model.GroupQuarter:
+		return time.Date(y, (m-1)/3*3+1, 1, 0, 0, 0, 0, time.UTC)
+	default:
+		return time.Date(y, 1, 1, 0, 0, 0, 0, time.UTC)
+	}
+}
+
+// nextPeriod returns the start of the period of grouping after the one t is in.
+func nextPeriod(t time.Time, grouping string) time.Time {
+	start := periodStart(t, grouping)
+
+	switch grouping {
+	case model.GroupDay:
+		return start.AddDate(0, 0, 1)
+	case model.GroupWeek:
+		return start.AddDate(0, 0, 7)
+	case model.GroupMonth:
+		return start.AddDate(0, 1, 0)
+	case model.GroupQuarter:
+		return start.AddDate(0, 3, 0)
+	default:
+		return start.AddDate(1, 0, 0)
+	}
+}
+
+// Run computes the report of a valid spec. After every metric of every period
+// it calls progress, if not nil, with the number of steps done and in total;
+// an error returned by progress stops the report.
+func Run(ctx context.Context, a Analytics, spec model.ReportSpec, progress func(done, total int) error) (*model.ReportResult, error) {
+	periods := Periods(spec)
+	total := len(periods) * len(spec.Metrics)
+
+	res := &model.ReportResult{Periods: make([]model.ReportPeriod, 0, len(periods))}
+	for i, p := range periods {
+		rp := model.ReportPeriod{From: p.From, To: p.To}
+
+		for j, m := range spec.Metrics {
+			if err := compute(ctx, a, spec, p, m, &rp); err != nil {
+				return nil, fmt.Errorf("compute %s: %w", m, err)
+			}
+
+			if progress != nil {
+				if err := progress(i*len(spec.Metrics)+j+1, total); err != nil {
+					return nil, err
+				}
+			}
+		}
+
+		res.Periods = append(res.Periods, rp)
+	}
+
+	return res, nil
+}
+
+// compute computes metric m of spec over period p into rp.
+func compute(ctx context.Context, a Analytics, spec model.ReportSpec, p Period, m string, rp *model.ReportPeriod) error {
+	var err error
+
+	switch m {
+	case model.MetricSum:
+		var v string
+		v, err = a.Sum(ctx, p.From, p.To, spec.CategoryID, spec.Kind, spec.AccountID, spec.Tags)
+		rp.Sum = &v
+	case model.MetricAvg:
+		var v string
+		v, err = a.Avg(ctx, p.From, p.To, spec.CategoryID, spec.Kind, spec.AccountID, spec.Tags)
+		rp.Avg = &v
+	case model.MetricCount:
+		var v int64
+		v, err = a.Count(ctx, p.From, p.To, spec.CategoryID, spec.Kind, spec.AccountID, spec.Tags)
+		rp.Count = &v
+	case model.MetricMedian:
+		var v string
+		v, err = a.Median(ctx, p.From, p.To, spec.CategoryID, spec.Kind, spec.AccountID, spec.Tags)
+		rp.Median = &v
+	case model.MetricPercentile:
+		var v string
+		v, err = a.Percentile(ctx, p.From, p.To, spec.CategoryID, spec.Kind, spec.AccountID, spec.Tags, spec.Percentile)
+		rp.Percentile = &v
+	case model.MetricRevenue:
+		rp.Revenue, err = a.Revenue(ctx, p.From, p.To, spec.CategoryID, spec.AccountID, spec.Tags)
+	case model.MetricCategories:
+		rp.Categories, err = a.ByCategory(ctx, p.From, p.To, spec.Kind, spec.AccountID, spec.Tags)
+	case model.MetricTags:
+		rp.Tags, err = a.ByTag(ctx, p.From, p.To, spec.CategoryID, spec.Kind, spec.AccountID, spec.Tags)
+	default:
+		err = ErrInvalidMetric
+	}
+
+	return err
+}
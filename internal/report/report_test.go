@@ -0,0 +1,49 @@
+package report
+
+import (
+	"testing"
+	"time"
+
+	"github.com/aliskhannn/sales-tracker/internal/model"
+)
+
+func TestPrevious(t *testing.T) {
+	date := func(y int, m time.Month, d int) time.Time {
+		return time.Date(y, m, d, 0, 0, 0, 0, time.UTC)
+	}
+	last := func(y int, m time.Month, d int) time.Time {
+		return date(y, m, d).Add(-time.Microsecond)
+	}
+
+	moscow := time.FixedZone("MSK", 3*60*60)
+
+	tests := []struct {
+		name     string
+		t        time.Time
+		grouping string
+		from, to time.Time
+	}{
+		{"day", time.Date(2025, 10, 15, 8, 0, 0, 0, time.UTC), model.GroupDay, date(2025, 10, 14), last(2025, 10, 15)},
+		{"day at midnight", date(2025, 10, 15), model.GroupDay, date(2025, 10, 14), last(2025, 10, 15)},
+		{"day across a year", date(2026, 1, 1), model.GroupDay, date(2025, 12, 31), last(2026, 1, 1)},
+		{"week on Monday", time.Date(2025, 10, 6, 8, 0, 0, 0, time.UTC), model.GroupWeek, date(2025, 9, 29), last(2025, 10, 6)},
+		{"week on Sunday", time.Date(2025, 10, 12, 23, 0, 0, 0, time.UTC), model.GroupWeek, date(2025, 9, 29), last(2025, 10, 6)},
+		{"month", time.Date(2025, 3, 1, 8, 0, 0, 0, time.UTC), model.GroupMonth, date(2025, 2, 1), last(2025, 3, 1)},
+		{"month of a leap year", date(2024, 3, 10), model.GroupMonth, date(2024, 2, 1), last(2024, 3, 1)},
+		{"month across a year", date(2026, 1, 20), model.GroupMonth, date(2025, 12, 1), last(2026, 1, 1)},
+		{"quarter", date(2025, 10, 1), model.GroupQuarter, date(2025, 7, 1), last(2025, 10, 1)},
+		{"quarter across a year", date(2025, 2, 14), model.GroupQuarter, date(2024, 10, 1), last(2025, 1, 1)},
+		{"year", date(2025, 6, 30), model.GroupYear, date(2024, 1, 1), last(2025, 1, 1)},
+		// 01:00 in Moscow is still the previous day in UTC.
+		{"day in another zone", time.Date(2025, 10, 15, 1, 0, 0, 0, moscow), model.GroupDay, date(2025, 10, 13), last(2025, 10, 14)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := Previous(tt.t, tt.grouping)
+			if !p.From.Equal(tt.from) || !p.To.Equal(tt.to) {
+				t.Errorf("Previous(%s, %s) = %s – %s, want %s – %s", tt.t, tt.grouping, p.From, p.To, tt.from, tt.to)
+			}
+		})
+	}
+}
@@ -0,0 +1,218 @@
+package account
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+	"github.com/shopspring/decimal"
+
+	"github.com/aliskhannn/sales-tracker/internal/database"
+	"github.com/aliskhannn/sales-tracker/internal/metrics"
+	"github.com/aliskhannn/sales-tracker/internal/model"
+	"github.com/aliskhannn/sales-tracker/internal/tenant"
+)
+
+var (
+	ErrAccountNotFound = errors.New("account not found")
+	ErrAccountInUse    = errors.New("account has items")
+)
+
+// foreignKeyViolation is the PostgreSQL error code of foreign_key_violation.
+const foreignKeyViolation = "23503"
+
+// Repository provides methods to interact with accounts.
+type Repository struct {
+	db *database.DB
+}
+
+// NewRepository creates a new account repository.
+func NewRepository(db *database.DB) *Repository {
+	return &Repository{db: db}
+}
+
+// Create adds a new account to the database.
+func (r *Repository) Create(ctx context.Context, a *model.Account) (uuid.UUID, error) {
+	defer metrics.ObserveQuery("account", "Create", time.Now())
+
+	query := `
+		INSERT INTO accounts (name, currency, opening_balance, workspace_id)
+		VALUES ($1, $2, $3, $4)
+		RETURNING id;
+	`
+
+	err := r.db.Master.QueryRowContext(ctx, query, a.Name, a.Currency, a.OpeningBalance, tenant.ID(ctx)).Scan(&a.ID)
+	if err != nil {
+		return uuid.Nil, fmt.Errorf("insert account: %w", err)
+	}
+
+	return a.ID, nil
+}
+
+// GetByID retrieves an account by its ID.
+func (r *Repository) GetByID(ctx context.Context, id uuid.UUID) (*model.Account, error) {
+	defer metrics.ObserveQuery("account", "GetByID", time.Now())
+
+	query := `
+		SELECT id, name, currency, opening_balance, created_at, updated_at
+		FROM accounts
+		WHERE id = $1
+		  AND workspace_id = $2;
+	`
+
+	var a model.Account
+	err := r.db.QueryRowContext(ctx, query, id, tenant.ID(ctx)).Scan(
+		&a.ID, &a.Name, &a.Currency, &a.OpeningBalance, &a.CreatedAt, &a.UpdatedAt,
+	)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrAccountNotFound
+		}
+
+		return nil, fmt.Errorf("get account: %w", err)
+	}
+
+	return &a, nil
+}
+
+// List retrieves all accounts from the database.
+func (r *Repository) List(ctx context.Context) ([]model.Account, error) {
+	defer metrics.ObserveQuery("account", "List", time.Now())
+
+	query := `
+		SELECT id, name, currency, opening_balance, created_at, updated_at
+		FROM accounts
+		WHERE workspace_id = $1
+		ORDER BY name;
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, tenant.ID(ctx))
+	if err != nil {
+		return nil, fmt.Errorf("list accounts: %w", err)
+	}
+	defer rows.Close()
+
+	var accounts []model.Account
+	for rows.Next() {
+		var a model.Account
+		if err = rows.Scan(
+			&a.ID, &a.Name, &a.Currency, &a.OpeningBalance, &a.CreatedAt, &a.UpdatedAt,
+		); err != nil {
+			return nil, fmt.Errorf("list accounts: %w", err)
+		}
+
+		accounts = append(accounts, a)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("list accounts: %w", err)
+	}
+
+	return accounts, nil
+}
+
+// Update updates an account.
+func (r *Repository) Update(ctx context.Context, a *model.Account) error {
+	defer metrics.ObserveQuery("account", "Update", time.Now())
+
+	query := `
+		UPDATE accounts
+		SET name = $1,
+		    currency = $2,
+		    opening_balance = $3,
+		    updated_at = NOW()
+		WHERE id = $4
+		  AND workspace_id = $5;
+	`
+
+	res, err := r.db.ExecContext(ctx, query, a.Name, a.Currency, a.OpeningBalance, a.ID, tenant.ID(ctx))
+	if err != nil {
+		return fmt.Errorf("update account: %w", err)
+	}
+
+	n, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("check rows affected: %w", err)
+	}
+
+	if n == 0 {
+		return ErrAccountNotFound
+	}
+
+	return nil
+}
+
+// Delete removes an account from the database.
+// Accounts that still have items cannot be deleted.
+func (r *Repository) Delete(ctx context.Context, id uuid.UUID) error {
+	defer metrics.ObserveQuery("account", "Delete", time.Now())
+
+	query := `
+		DELETE FROM accounts
+		WHERE id = $1
+		  AND workspace_id = $2;
+	`
+
+	res, err := r.db.ExecContext(ctx, query, id, tenant.ID(ctx))
+	if err != nil {
+		var pqErr *pq.Error
+		if errors.As(err, &pqErr) && pqErr.Code == foreignKeyViolation {
+			return ErrAccountInUse
+		}
+
+		return fmt.Errorf("delete account: %w", err)
+	}
+
+	n, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("check rows affected: %w", err)
+	}
+
+	if n == 0 {
+		return ErrAccountNotFound
+	}
+
+	return nil
+}
+
+// Balance calculates the balance of an account at the given time.
+//
+// Income and incoming transfer legs increase the balance; expenses, refunds
+// and outgoing transfer legs decrease it.
+func (r *Repository) Balance(ctx context.Context, id uuid.UUID, at time.Time) (decimal.Decimal, error) {
+	defer metrics.ObserveQuery("account", "Balance", time.Now())
+
+	query := `
+		SELECT a.opening_balance + COALESCE((
+			SELECT SUM(
+				CASE
+					WHEN i.kind = 'income' THEN i.amount
+					WHEN i.kind = 'transfer' AND i.transfer_leg = 'destination' THEN i.amount
+					ELSE -i.amount
+				END
+			)
+			FROM items i
+			WHERE i.account_id = a.id
+			  AND i.occurred_at <= $2
+		), 0)
+		FROM accounts a
+		WHERE a.id = $1
+		  AND a.workspace_id = $3;
+	`
+
+	var balance decimal.Decimal
+	err := r.db.QueryRowContext(ctx, query, id, at, tenant.ID(ctx)).Scan(&balance)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return decimal.Zero, ErrAccountNotFound
+		}
+
+		return decimal.Zero, fmt.Errorf("account balance: %w", err)
+	}
+
+	return balance, nil
+}
@@ -3,40 +3,93 @@ package analytics
 import (
 	"context"
 	"fmt"
+	"time"
 
-	"github.com/wb-go/wbf/dbpg"
+	"github.com/lib/pq"
 
+	"github.com/aliskhannn/sales-tracker/internal/database"
+	"github.com/aliskhannn/sales-tracker/internal/metrics"
 	"github.com/aliskhannn/sales-tracker/internal/model"
+	"github.com/aliskhannn/sales-tracker/internal/tenant"
 )
 
+// entries is a CTE of the amounts analytics are computed over.
+//
+// Only items of the workspace $8 are included. Without a category filter ($3)
+// every item counts once with its own amount. With a category filter, items
+// that have splits contribute their splits, attributed to the split categories,
+// instead of the parent item itself. Refunds without a category inherit the
+// category of the refunded item; refunded_kind is the kind of the refunded item.
+const entries = `
+	WITH entries AS (
+		SELECT i.id AS item_id, i.amount, COALESCE(i.category_id, o.category_id) AS category_id, i.kind, i.occurred_at, i.account_id,
+		       o.kind AS refunded_kind
+		FROM items i
+		LEFT JOIN items o ON o.id = i.refund_of
+		WHERE i.workspace_id = $8
+		  AND ($3::uuid IS NULL
+		   OR NOT EXISTS (SELECT 1 FROM item_splits s WHERE s.item_id = i.id))
+		UNION ALL
+		SELECT i.id, s.amount, s.category_id, i.kind, i.occurred_at, i.account_id, o.kind
+		FROM item_splits s
+		JOIN items i ON i.id = s.item_id
+		LEFT JOIN items o ON o.id = i.refund_of
+		WHERE i.workspace_id = $8
+		  AND $3::uuid IS NOT NULL
+	)
+`
+
+// entriesFilter filters entries by date range ($1, $2), category ($3), kind ($4),
+// account ($5) and tags ($6 names, all of which must match if $7, otherwise any).
+// Split entries are filtered by the tags of their parent item.
+const entriesFilter = `
+	WHERE ($1::timestamptz IS NULL OR occurred_at >= $1)
+	  AND ($2::timestamptz IS NULL OR occurred_at <= $2)
+	  AND ($3::uuid IS NULL OR category_id = $3)
+	  AND ($4::item_kind IS NULL OR kind = $4)
+	  AND ($5::uuid IS NULL OR account_id = $5)
+	  AND ($6::text[] IS NULL OR (
+	      SELECT COUNT(*)
+	      FROM item_tags it
+	      JOIN tags t ON t.id = it.tag_id
+	      WHERE it.item_id = entries.item_id
+	        AND lower(t.name) = ANY($6::text[])
+	  ) >= CASE WHEN $7 THEN cardinality($6::text[]) ELSE 1 END)
+`
+
 // Repository provides methods to interact with analytics.
 type Repository struct {
-	db *dbpg.DB
+	db *database.DB
 }
 
 // NewRepository creates a new analytics repository.
-func NewRepository(db *dbpg.DB) *Repository {
+func NewRepository(db *database.DB) *Repository {
 	return &Repository{db: db}
 }
 
 // Sum calculates the total amount of items matching the filter.
 func (r *Repository) Sum(ctx context.Context, filter *model.ItemFilter) (string, error) {
-	query := `
+	defer metrics.ObserveQuery("analytics", "Sum", time.Now())
+
+	query := entries + `
 		SELECT COALESCE(SUM(amount), 0)
-		FROM items
-		WHERE ($1::timestamptz IS NULL OR occurred_at >= $1)
-		  AND ($2::timestamptz IS NULL OR occurred_at <= $2)
-		  AND ($3::uuid IS NULL OR category_id = $3)
-		  AND ($4::item_kind IS NULL OR kind = $4);
-	`
+		FROM entries
+	` + entriesFilter
 
 	var total string
-	err := r.db.QueryRowContext(ctx, query,
-		filter.From,
-		filter.To,
-		filter.CategoryID,
-		filter.Kind,
-	).Scan(&total)
+	tagNames, matchAll := tagArgs(filter.Tags)
+	err := r.db.Read(ctx, func(ctx context.Context, q database.Querier) error {
+		return q.QueryRowContext(ctx, query,
+			filter.From,
+			filter.To,
+			filter.CategoryID,
+			filter.Kind,
+			filter.AccountID,
+			tagNames,
+			matchAll,
+			tenant.ID(ctx),
+		).Scan(&total)
+	})
 	if err != nil {
 		return "", fmt.Errorf("sum items: %w", err)
 	}
@@ -46,22 +99,27 @@ func (r *Repository) Sum(ctx context.Context, filter *model.ItemFilter) (string,
 
 // Avg calculates the average amount of items matching the filter.
 func (r *Repository) Avg(ctx context.Context, filter *model.ItemFilter) (string, error) {
-	query := `
+	defer metrics.ObserveQuery("analytics", "Avg", time.Now())
+
+	query := entries + `
 		SELECT COALESCE(AVG(amount), 0)
-		FROM items
-		WHERE ($1::timestamptz IS NULL OR occurred_at >= $1)
-		  AND ($2::timestamptz IS NULL OR occurred_at <= $2)
-		  AND ($3::uuid IS NULL OR category_id = $3)
-		  AND ($4::item_kind IS NULL OR kind = $4);
-	`
+		FROM entries
+	` + entriesFilter
 
 	var avg string
-	err := r.db.QueryRowContext(ctx, query,
-		filter.From,
-		filter.To,
-		filter.CategoryID,
-		filter.Kind,
-	).Scan(&avg)
+	tagNames, matchAll := tagArgs(filter.Tags)
+	err := r.db.Read(ctx, func(ctx context.Context, q database.Querier) error {
+		return q.QueryRowContext(ctx, query,
+			filter.From,
+			filter.To,
+			filter.CategoryID,
+			filter.Kind,
+			filter.AccountID,
+			tagNames,
+			matchAll,
+			tenant.ID(ctx),
+		).Scan(&avg)
+	})
 	if err != nil {
 		return "", fmt.Errorf("avg items: %w", err)
 	}
@@ -71,22 +129,27 @@ func (r *Repository) Avg(ctx context.Context, filter *model.ItemFilter) (string,
 
 // Count returns the number of items matching the filter.
 func (r *Repository) Count(ctx context.Context, filter *model.ItemFilter) (int64, error) {
-	query := `
+	defer metrics.ObserveQuery("analytics", "Count", time.Now())
+
+	query := entries + `
 		SELECT COUNT(*)
-		FROM items
-		WHERE ($1::timestamptz IS NULL OR occurred_at >= $1)
-		  AND ($2::timestamptz IS NULL OR occurred_at <= $2)
-		  AND ($3::uuid IS NULL OR category_id = $3)
-		  AND ($4::item_kind IS NULL OR kind = $4);
-	`
+		FROM entries
+	` + entriesFilter
 
 	var cnt int64
-	err := r.db.QueryRowContext(ctx, query,
-		filter.From,
-		filter.To,
-		filter.CategoryID,
-		filter.Kind,
-	).Scan(&cnt)
+	tagNames, matchAll := tagArgs(filter.Tags)
+	err := r.db.Read(ctx, func(ctx context.Context, q database.Querier) error {
+		return q.QueryRowContext(ctx, query,
+			filter.From,
+			filter.To,
+			filter.CategoryID,
+			filter.Kind,
+			filter.AccountID,
+			tagNames,
+			matchAll,
+			tenant.ID(ctx),
+		).Scan(&cnt)
+	})
 	if err != nil {
 		return 0, fmt.Errorf("count items: %w", err)
 	}
@@ -94,27 +157,66 @@ func (r *Repository) Count(ctx context.Context, filter *model.ItemFilter) (int64
 	return cnt, nil
 }
 
+// Totals calculates the sum, average and count of items matching the filter in
+// one pass. It reads from the master so that totals recomputed right after a
+// change notification include that change.
+func (r *Repository) Totals(ctx context.Context, filter *model.ItemFilter) (*model.Totals, error) {
+	defer metrics.ObserveQuery("analytics", "Totals", time.Now())
+
+	ctx = database.WithConsistency(ctx, database.Strong)
+
+	query := entries + `
+		SELECT COALESCE(SUM(amount), 0), COALESCE(AVG(amount), 0), COUNT(*)
+		FROM entries
+	` + entriesFilter
+
+	var t model.Totals
+	tagNames, matchAll := tagArgs(filter.Tags)
+	err := r.db.Read(ctx, func(ctx context.Context, q database.Querier) error {
+		return q.QueryRowContext(ctx, query,
+			filter.From,
+			filter.To,
+			filter.CategoryID,
+			filter.Kind,
+			filter.AccountID,
+			tagNames,
+			matchAll,
+			tenant.ID(ctx),
+		).Scan(&t.Sum, &t.Avg, &t.Count)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("totals of items: %w", err)
+	}
+
+	return &t, nil
+}
+
 // Median calculates the median amount of items matching the filter.
 func (r *Repository) Median(ctx context.Context, filter *model.ItemFilter) (string, error) {
-	query := `
+	defer metrics.ObserveQuery("analytics", "Median", time.Now())
+
+	query := entries + `
 		SELECT COALESCE(
 			percentile_cont(0.5) WITHIN GROUP (ORDER BY amount),
 			0
 		)
-		FROM items
-		WHERE ($1::timestamptz IS NULL OR occurred_at >= $1)
-		  AND ($2::timestamptz IS NULL OR occurred_at <= $2)
-		  AND ($3::uuid IS NULL OR category_id = $3)
-		  AND ($4::item_kind IS NULL OR kind = $4);
-	`
+		FROM entries
+	` + entriesFilter
 
 	var median string
-	err := r.db.QueryRowContext(ctx, query,
-		filter.From,
-		filter.To,
-		filter.CategoryID,
-		filter.Kind,
-	).Scan(&median)
+	tagNames, matchAll := tagArgs(filter.Tags)
+	err := r.db.Read(ctx, func(ctx context.Context, q database.Querier) error {
+		return q.QueryRowContext(ctx, query,
+			filter.From,
+			filter.To,
+			filter.CategoryID,
+			filter.Kind,
+			filter.AccountID,
+			tagNames,
+			matchAll,
+			tenant.ID(ctx),
+		).Scan(&median)
+	})
 	if err != nil {
 		return "", fmt.Errorf("median items: %w", err)
 	}
@@ -124,29 +226,206 @@ func (r *Repository) Median(ctx context.Context, filter *model.ItemFilter) (stri
 
 // Percentile calculates the N-th percentile (0.0–1.0) of items matching the filter.
 func (r *Repository) Percentile(ctx context.Context, filter *model.ItemFilter, percentile float64) (string, error) {
-	query := `
+	defer metrics.ObserveQuery("analytics", "Percentile", time.Now())
+
+	query := entries + `
 		SELECT COALESCE(
-			percentile_cont($1) WITHIN GROUP (ORDER BY amount),
+			percentile_cont($9) WITHIN GROUP (ORDER BY amount),
 			0
 		)
-		FROM items
-		WHERE ($2::timestamptz IS NULL OR occurred_at >= $2)
-		  AND ($3::timestamptz IS NULL OR occurred_at <= $3)
-		  AND ($4::uuid IS NULL OR category_id = $4)
-		  AND ($5::item_kind IS NULL OR kind = $5);
-	`
+		FROM entries
+	` + entriesFilter
 
 	var value string
-	err := r.db.QueryRowContext(ctx, query,
-		percentile,
-		filter.From,
-		filter.To,
-		filter.CategoryID,
-		filter.Kind,
-	).Scan(&value)
+	tagNames, matchAll := tagArgs(filter.Tags)
+	err := r.db.Read(ctx, func(ctx context.Context, q database.Querier) error {
+		return q.QueryRowContext(ctx, query,
+			filter.From,
+			filter.To,
+			filter.CategoryID,
+			filter.Kind,
+			filter.AccountID,
+			tagNames,
+			matchAll,
+			tenant.ID(ctx),
+			percentile,
+		).Scan(&value)
+	})
 	if err != nil {
 		return "", fmt.Errorf("percentile items: %w", err)
 	}
 
 	return value, nil
 }
+
+// Revenue calculates gross income, refunds and net-of-refunds revenue of items
+// matching the filter. Only refunds of income items count; refunds of expenses
+// are money returned on purchases, not lost revenue. The kind filter of the
+// given filter is ignored.
+func (r *Repository) Revenue(ctx context.Context, filter *model.ItemFilter) (*model.Revenue, error) {
+	defer metrics.ObserveQuery("analytics", "Revenue", time.Now())
+
+	query := entries + `
+		SELECT COALESCE(SUM(amount) FILTER (WHERE kind = 'income'), 0),
+		       COALESCE(SUM(amount) FILTER (WHERE kind = 'refund' AND refunded_kind = 'income'), 0),
+		       COALESCE(SUM(amount) FILTER (WHERE kind = 'income'), 0)
+		           - COALESCE(SUM(amount) FILTER (WHERE kind = 'refund' AND refunded_kind = 'income'), 0)
+		FROM entries
+	` + entriesFilter
+
+	var rev model.Revenue
+	tagNames, matchAll := tagArgs(filter.Tags)
+	err := r.db.Read(ctx, func(ctx context.Context, q database.Querier) error {
+		return q.QueryRowContext(ctx, query,
+			filter.From,
+			filter.To,
+			filter.CategoryID,
+			nil,
+			filter.AccountID,
+			tagNames,
+			matchAll,
+			tenant.ID(ctx),
+		).Scan(&rev.Gross, &rev.Refunds, &rev.Net)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("revenue: %w", err)
+	}
+
+	return &rev, nil
+}
+
+// ByCategory calculates count and sum per category for items matching the filter.
+// Split items are always attributed to their split categories.
+// The category filter of the given filter is ignored.
+func (r *Repository) ByCategory(ctx context.Context, filter *model.ItemFilter) ([]model.CategoryTotal, error) {
+	defer metrics.ObserveQuery("analytics", "ByCategory", time.Now())
+
+	query := `
+		WITH entries AS (
+			SELECT i.id AS item_id, i.amount, COALESCE(i.category_id, o.category_id) AS category_id, i.kind, i.occurred_at, i.account_id
+			FROM items i
+			LEFT JOIN items o ON o.id = i.refund_of
+			WHERE i.workspace_id = $7
+			  AND NOT EXISTS (SELECT 1 FROM item_splits s WHERE s.item_id = i.id)
+			UNION ALL
+			SELECT i.id, s.amount, s.category_id, i.kind, i.occurred_at, i.account_id
+			FROM item_splits s
+			JOIN items i ON i.id = s.item_id
+			WHERE i.workspace_id = $7
+		)
+		SELECT category_id, COUNT(*), COALESCE(SUM(amount), 0)
+		FROM entries
+		WHERE ($1::timestamptz IS NULL OR occurred_at >= $1)
+		  AND ($2::timestamptz IS NULL OR occurred_at <= $2)
+		  AND ($3::item_kind IS NULL OR kind = $3)
+		  AND ($4::uuid IS NULL OR account_id = $4)
+		  AND ($5::text[] IS NULL OR (
+		      SELECT COUNT(*)
+		      FROM item_tags it
+		      JOIN tags t ON t.id = it.tag_id
+		      WHERE it.item_id = entries.item_id
+		        AND lower(t.name) = ANY($5::text[])
+		  ) >= CASE WHEN $6 THEN cardinality($5::text[]) ELSE 1 END)
+		GROUP BY category_id
+		ORDER BY SUM(amount) DESC;
+	`
+
+	var totals []model.CategoryTotal
+	tagNames, matchAll := tagArgs(filter.Tags)
+	err := r.db.Read(ctx, func(ctx context.Context, q database.Querier) error {
+		rows, err := q.QueryContext(ctx, query,
+			filter.From,
+			filter.To,
+			filter.Kind,
+			filter.AccountID,
+			tagNames,
+			matchAll,
+			tenant.ID(ctx),
+		)
+		if err != nil {
+			return err
+		}
+		defer rows.Close()
+
+		for rows.Next() {
+			var t model.CategoryTotal
+			if err = rows.Scan(&t.CategoryID, &t.Count, &t.Sum); err != nil {
+				return err
+			}
+
+			totals = append(totals, t)
+		}
+
+		return rows.Err()
+	})
+	if err != nil {
+		return nil, fmt.Errorf("sum by category: %w", err)
+	}
+
+	return totals, nil
+}
+
+// ByTag calculates count and sum per tag for items matching the filter.
+// An item with several tags counts towards each of them; untagged items are omitted.
+func (r *Repository) ByTag(ctx context.Context, filter *model.ItemFilter) ([]model.TagTotal, error) {
+	defer metrics.ObserveQuery("analytics", "ByTag", time.Now())
+
+	query := entries + `,
+		filtered AS (
+			SELECT item_id, amount
+			FROM entries
+		` + entriesFilter + `
+		)
+		SELECT t.id, t.name, COUNT(*), COALESCE(SUM(f.amount), 0)
+		FROM filtered f
+		JOIN item_tags it ON it.item_id = f.item_id
+		JOIN tags t ON t.id = it.tag_id
+		GROUP BY t.id, t.name
+		ORDER BY SUM(f.amount) DESC, lower(t.name);
+	`
+
+	var totals []model.TagTotal
+	tagNames, matchAll := tagArgs(filter.Tags)
+	err := r.db.Read(ctx, func(ctx context.Context, q database.Querier) error {
+		rows, err := q.QueryContext(ctx, query,
+			filter.From,
+			filter.To,
+			filter.CategoryID,
+			filter.Kind,
+			filter.AccountID,
+			tagNames,
+			matchAll,
+			tenant.ID(ctx),
+		)
+		if err != nil {
+			return err
+		}
+		defer rows.Close()
+
+		for rows.Next() {
+			var t model.TagTotal
+			if err = rows.Scan(&t.TagID, &t.Name, &t.Count, &t.Sum); err != nil {
+				return err
+			}
+
+			totals = append(totals, t)
+		}
+
+		return rows.Err()
+	})
+	if err != nil {
+		return nil, fmt.Errorf("sum by tag: %w", err)
+	}
+
+	return totals, nil
+}
+
+// tagArgs returns the query arguments of a tag filter: the tag names as a
+// text array (NULL without a filter) and whether all of them must match.
+func tagArgs(f *model.TagFilter) (any, bool) {
+	if f == nil || len(f.Names) == 0 {
+		return nil, false
+	}
+
+	return pq.Array(f.Names), f.MatchAll
+}
@@ -0,0 +1,183 @@
+package apikey
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+
+	"github.com/aliskhannn/sales-tracker/internal/database"
+	"github.com/aliskhannn/sales-tracker/internal/metrics"
+	"github.com/aliskhannn/sales-tracker/internal/model"
+)
+
+var (
+	ErrAPIKeyNotFound   = errors.New("api key not found")
+	ErrUnknownWorkspace = errors.New("workspace of the api key not found")
+)
+
+// foreignKeyViolation is the PostgreSQL error code of foreign_key_violation.
+const foreignKeyViolation = "23503"
+
+// Repository provides methods to interact with API keys.
+type Repository struct {
+	db *database.DB
+}
+
+// NewRepository creates a new API key repository.
+func NewRepository(db *database.DB) *Repository {
+	return &Repository{db: db}
+}
+
+// Create adds a new API key with the hash of its secret to the database.
+func (r *Repository) Create(ctx context.Context, k *model.APIKey, hash string) (uuid.UUID, error) {
+	defer metrics.ObserveQuery("apikey", "Create", time.Now())
+
+	query := `
+		INSERT INTO api_keys (name, prefix, key_hash, role, scopes, workspace_id, expires_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		RETURNING id, created_at, updated_at;
+	`
+
+	err := r.db.Master.QueryRowContext(
+		ctx, query, k.Name, k.Prefix, hash, k.Role, pq.Array(k.Scopes), k.WorkspaceID, k.ExpiresAt,
+	).Scan(&k.ID, &k.CreatedAt, &k.UpdatedAt)
+	if err != nil {
+		var pqErr *pq.Error
+		if errors.As(err, &pqErr) && pqErr.Code == foreignKeyViolation {
+			return uuid.Nil, ErrUnknownWorkspace
+		}
+
+		return uuid.Nil, fmt.Errorf("insert api key: %w", err)
+	}
+
+	return k.ID, nil
+}
+
+// GetByHash retrieves an API key by the hash of its secret. It reads from
+// the master so that revocations take effect immediately.
+func (r *Repository) GetByHash(ctx context.Context, hash string) (*model.APIKey, error) {
+	defer metrics.ObserveQuery("apikey", "GetByHash", time.Now())
+
+	query := `
+		SELECT id, name, prefix, role, scopes, workspace_id, expires_at, last_used_at, revoked_at, created_at, updated_at
+		FROM api_keys
+		WHERE key_hash = $1;
+	`
+
+	k, err := scanKey(r.db.Master.QueryRowContext(ctx, query, hash))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrAPIKeyNotFound
+		}
+
+		return nil, fmt.Errorf("get api key: %w", err)
+	}
+
+	return k, nil
+}
+
+// List retrieves API keys, newest first. With a non-nil workspaceID only keys
+// bound to that workspace are returned.
+func (r *Repository) List(ctx context.Context, workspaceID *uuid.UUID) ([]model.APIKey, error) {
+	defer metrics.ObserveQuery("apikey", "List", time.Now())
+
+	query := `
+		SELECT id, name, prefix, role, scopes, workspace_id, expires_at, last_used_at, revoked_at, created_at, updated_at
+		FROM api_keys
+		WHERE ($1::uuid IS NULL OR workspace_id = $1)
+		ORDER BY created_at DESC;
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, workspaceID)
+	if err != nil {
+		return nil, fmt.Errorf("list api keys: %w", err)
+	}
+	defer rows.Close()
+
+	var keys []model.APIKey
+	for rows.Next() {
+		k, err := scanKey(rows)
+		if err != nil {
+			return nil, fmt.Errorf("scan api key: %w", err)
+		}
+
+		keys = append(keys, *k)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows error: %w", err)
+	}
+
+	return keys, nil
+}
+
+// Revoke marks an API key as revoked. Revoking a revoked key keeps its
+// original revocation time. With a non-nil workspaceID only a key bound to
+// that workspace is revoked.
+func (r *Repository) Revoke(ctx context.Context, id uuid.UUID, workspaceID *uuid.UUID) error {
+	defer metrics.ObserveQuery("apikey", "Revoke", time.Now())
+
+	query := `
+		UPDATE api_keys
+		SET revoked_at = COALESCE(revoked_at, now())
+		WHERE id = $1
+		  AND ($2::uuid IS NULL OR workspace_id = $2);
+	`
+
+	res, err := r.db.ExecContext(ctx, query, id, workspaceID)
+	if err != nil {
+		return fmt.Errorf("revoke api key: %w", err)
+	}
+
+	n, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("check rows affected: %w", err)
+	}
+
+	if n == 0 {
+		return ErrAPIKeyNotFound
+	}
+
+	return nil
+}
+
+// TouchLastUsed records that an API key authenticated a request at t.
+func (r *Repository) TouchLastUsed(ctx context.Context, id uuid.UUID, t time.Time) error {
+	defer metrics.ObserveQuery("apikey", "TouchLastUsed", time.Now())
+
+	query := `
+		UPDATE api_keys
+		SET last_used_at = $2
+		WHERE id = $1;
+	`
+
+	if _, err := r.db.ExecContext(ctx, query, id, t); err != nil {
+		return fmt.Errorf("touch api key: %w", err)
+	}
+
+	return nil
+}
+
+// scanner is implemented by *sql.Row and *sql.Rows.
+type scanner interface {
+	Scan(dest ...interface{}) error
+}
+
+// scanKey scans an API key row.
+func scanKey(s scanner) (*model.APIKey, error) {
+	var k model.APIKey
+	err := s.Scan(
+		&k.ID, &k.Name, &k.Prefix, &k.Role, pq.Array(&k.Scopes), &k.WorkspaceID,
+		&k.ExpiresAt, &k.LastUsedAt, &k.RevokedAt, &k.CreatedAt, &k.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return &k, nil
+}
@@ -0,0 +1,256 @@
+package attachment
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/aliskhannn/sales-tracker/internal/database"
+	"github.com/aliskhannn/sales-tracker/internal/metrics"
+	"github.com/aliskhannn/sales-tracker/internal/model"
+	"github.com/aliskhannn/sales-tracker/internal/tenant"
+)
+
+var (
+	ErrAttachmentNotFound = errors.New("attachment not found")
+)
+
+// Repository provides methods to interact with attachments.
+type Repository struct {
+	db *database.DB
+}
+
+// NewRepository creates a new attachment repository.
+func NewRepository(db *database.DB) *Repository {
+	return &Repository{db: db}
+}
+
+// Create adds a new attachment to the database. If the item already has an
+// attachment with the same SHA-256, no row is added, a is filled with the
+// existing attachment and created is false.
+//
+// store is called to store the contents before the row is added, under a lock
+// on the SHA-256 that Delete also takes, so contents are never removed by a
+// concurrent Delete of the last other attachment referencing them.
+func (r *Repository) Create(ctx context.Context, a *model.Attachment, store func(ctx context.Context) error) (created bool, err error) {
+	defer metrics.ObserveQuery("attachment", "Create", time.Now())
+
+	tx, err := r.db.Master.BeginTx(ctx, nil)
+	if err != nil {
+		return false, fmt.Errorf("begin tx: %w", err)
+	}
+	defer database.Rollback(ctx, tx)
+
+	if err = LockContents(ctx, tx, a.SHA256); err != nil {
+		return false, err
+	}
+
+	if err = store(ctx); err != nil {
+		return false, err
+	}
+
+	query := `
+		INSERT INTO attachments (item_id, filename, content_type, size, sha256)
+		VALUES ($1, $2, $3, $4, $5)
+		ON CONFLICT (item_id, sha256) DO NOTHING
+		RETURNING id, created_at;
+	`
+
+	err = tx.QueryRowContext(ctx, query,
+		a.ItemID, a.Filename, a.ContentType, a.Size, a.SHA256,
+	).Scan(&a.ID, &a.CreatedAt)
+	switch {
+	case err == nil:
+		created = true
+	case errors.Is(err, sql.ErrNoRows):
+		query = `
+			SELECT id, item_id, filename, content_type, size, sha256, created_at
+			FROM attachments
+			WHERE item_id = $1
+			  AND sha256 = $2;
+		`
+
+		err = tx.QueryRowContext(ctx, query, a.ItemID, a.SHA256).Scan(
+			&a.ID, &a.ItemID, &a.Filename, &a.ContentType, &a.Size, &a.SHA256, &a.CreatedAt,
+		)
+		if err != nil {
+			return false, fmt.Errorf("get existing attachment: %w", err)
+		}
+	default:
+		return false, fmt.Errorf("insert attachment: %w", err)
+	}
+
+	if err = tx.Commit(); err != nil {
+		return false, fmt.Errorf("commit tx: %w", err)
+	}
+
+	return created, nil
+}
+
+// GetByID retrieves an attachment of an item by its ID.
+func (r *Repository) GetByID(ctx context.Context, itemID, id uuid.UUID) (*model.Attachment, error) {
+	defer metrics.ObserveQuery("attachment", "GetByID", time.Now())
+
+	query := `
+		SELECT a.id, a.item_id, a.filename, a.content_type, a.size, a.sha256, a.created_at
+		FROM attachments a
+		JOIN items i ON i.id = a.item_id
+		WHERE a.id = $1
+		  AND a.item_id = $2
+		  AND i.workspace_id = $3;
+	`
+
+	var a model.Attachment
+	err := r.db.QueryRowContext(ctx, query, id, itemID, tenant.ID(ctx)).Scan(
+		&a.ID, &a.ItemID, &a.Filename, &a.ContentType, &a.Size, &a.SHA256, &a.CreatedAt,
+	)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrAttachmentNotFound
+		}
+
+		return nil, fmt.Errorf("get attachment: %w", err)
+	}
+
+	return &a, nil
+}
+
+// ListByItem retrieves the attachments of an item ordered by upload time.
+func (r *Repository) ListByItem(ctx context.Context, itemID uuid.UUID) ([]model.Attachment, error) {
+	defer metrics.ObserveQuery("attachment", "ListByItem", time.Now())
+
+	query := `
+		SELECT a.id, a.item_id, a.filename, a.content_type, a.size, a.sha256, a.created_at
+		FROM attachments a
+		JOIN items i ON i.id = a.item_id
+		WHERE a.item_id = $1
+		  AND i.workspace_id = $2
+		ORDER BY a.created_at, a.id;
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, itemID, tenant.ID(ctx))
+	if err != nil {
+		return nil, fmt.Errorf("list attachments: %w", err)
+	}
+	defer rows.Close()
+
+	var attachments []model.Attachment
+	for rows.Next() {
+		var a model.Attachment
+		if err = rows.Scan(
+			&a.ID, &a.ItemID, &a.Filename, &a.ContentType, &a.Size, &a.SHA256, &a.CreatedAt,
+		); err != nil {
+			return nil, fmt.Errorf("list attachments: %w", err)
+		}
+
+		attachments = append(attachments, a)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("list attachments: %w", err)
+	}
+
+	return attachments, nil
+}
+
+// Delete removes an attachment of an item. If no other attachment, in any
+// workspace, references its contents, remove is called with its SHA-256 before
+// the deletion is committed, under the lock taken by Create; the attachment is
+// kept if remove fails.
+func (r *Repository) Delete(ctx context.Context, itemID, id uuid.UUID, remove func(ctx context.Context, sha256 string) error) error {
+	defer metrics.ObserveQuery("attachment", "Delete", time.Now())
+
+	tx, err := r.db.Master.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("begin tx: %w", err)
+	}
+	defer database.Rollback(ctx, tx)
+
+	// The contents are locked before the row, in the order Create takes them.
+	query := `
+		SELECT a.sha256
+		FROM attachments a
+		JOIN items i ON i.id = a.item_id
+		WHERE a.id = $1
+		  AND a.item_id = $2
+		  AND i.workspace_id = $3;
+	`
+
+	var sha256 string
+	if err = tx.QueryRowContext(ctx, query, id, itemID, tenant.ID(ctx)).Scan(&sha256); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return ErrAttachmentNotFound
+		}
+
+		return fmt.Errorf("get attachment: %w", err)
+	}
+
+	if err = LockContents(ctx, tx, sha256); err != nil {
+		return err
+	}
+
+	res, err := tx.ExecContext(ctx, `DELETE FROM attachments WHERE id = $1;`, id)
+	if err != nil {
+		return fmt.Errorf("delete attachment: %w", err)
+	}
+
+	n, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("check rows affected: %w", err)
+	}
+
+	if n == 0 {
+		return ErrAttachmentNotFound
+	}
+
+	if err = RemoveUnreferenced(ctx, tx, remove, sha256); err != nil {
+		return err
+	}
+
+	if err = tx.Commit(); err != nil {
+		return fmt.Errorf("commit tx: %w", err)
+	}
+
+	return nil
+}
+
+// LockContents takes a transaction-scoped lock on the contents with each of
+// the given SHA-256 hashes, serializing storing and removing them. Callers
+// locking several contents pass them sorted, so transactions take the locks in
+// the same order.
+func LockContents(ctx context.Context, tx *sql.Tx, sha256s ...string) error {
+	for _, sha256 := range sha256s {
+		if _, err := tx.ExecContext(ctx, `SELECT pg_advisory_xact_lock(hashtext($1));`, sha256); err != nil {
+			return fmt.Errorf("lock attachment contents: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// RemoveUnreferenced calls remove with each of the given SHA-256 hashes that
+// no attachment references any more. The contents must be locked with
+// LockContents.
+func RemoveUnreferenced(ctx context.Context, tx *sql.Tx, remove func(ctx context.Context, sha256 string) error, sha256s ...string) error {
+	for _, sha256 := range sha256s {
+		var referenced bool
+		query := `SELECT EXISTS (SELECT 1 FROM attachments WHERE sha256 = $1);`
+		if err := tx.QueryRowContext(ctx, query, sha256).Scan(&referenced); err != nil {
+			return fmt.Errorf("check references: %w", err)
+		}
+
+		if referenced {
+			continue
+		}
+
+		if err := remove(ctx, sha256); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
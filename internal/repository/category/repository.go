@@ -5,11 +5,15 @@ import (
 	"database/sql"
 	"errors"
 	"fmt"
+	"time"
 
 	"github.com/google/uuid"
-	"github.com/wb-go/wbf/dbpg"
 
+	"github.com/aliskhannn/sales-tracker/internal/database"
+	"github.com/aliskhannn/sales-tracker/internal/metrics"
 	"github.com/aliskhannn/sales-tracker/internal/model"
+	"github.com/aliskhannn/sales-tracker/internal/repository/outbox"
+	"github.com/aliskhannn/sales-tracker/internal/tenant"
 )
 
 var (
@@ -18,41 +22,62 @@ var (
 
 // Repository provides methods to interact with categories.
 type Repository struct {
-	db *dbpg.DB
+	db *database.DB
 }
 
 // NewRepository creates a new category repository.
-func NewRepository(db *dbpg.DB) *Repository {
+func NewRepository(db *database.DB) *Repository {
 	return &Repository{db: db}
 }
 
-// Create adds a new category to the database.
+// Create adds a new category to the database and records a category.created event.
 func (r *Repository) Create(ctx context.Context, c *model.Category) (uuid.UUID, error) {
+	defer metrics.ObserveQuery("category", "Create", time.Now())
+
 	query := `
-		INSERT INTO categories (name, description, parent_id)
-		VALUES ($1, $2, $3)
-		RETURNING id;
+		INSERT INTO categories (name, description, parent_id, workspace_id)
+		VALUES ($1, $2, $3, $4)
+		RETURNING id, created_at, updated_at;
 	`
 
-	err := r.db.QueryRowContext(ctx, query, c.Name, c.Description, c.ParentID).Scan(&c.ID)
+	tx, err := r.db.Master.BeginTx(ctx, nil)
+	if err != nil {
+		return uuid.Nil, fmt.Errorf("begin tx: %w", err)
+	}
+	defer database.Rollback(ctx, tx)
+
+	err = tx.QueryRowContext(ctx, query, c.Name, c.Description, c.ParentID, tenant.ID(ctx)).Scan(
+		&c.ID, &c.CreatedAt, &c.UpdatedAt,
+	)
 	if err != nil {
 		return uuid.Nil, fmt.Errorf("insert category: %w", err)
 	}
 
+	if err = outbox.Write(ctx, tx, model.EventCategoryCreated, c); err != nil {
+		return uuid.Nil, err
+	}
+
+	if err = tx.Commit(); err != nil {
+		return uuid.Nil, fmt.Errorf("commit tx: %w", err)
+	}
+
 	return c.ID, nil
 }
 
 // GetByID retrieves a category by its ID.
 func (r *Repository) GetByID(ctx context.Context, id uuid.UUID) (*model.Category, error) {
+	defer metrics.ObserveQuery("category", "GetByID", time.Now())
+
 	query := `
 		SELECT id, name, description, parent_id, created_at, updated_at
 		FROM categories
-		WHERE id = $1;
+		WHERE id = $1
+		  AND workspace_id = $2;
 	`
 
 	var c model.Category
-	err := r.db.QueryRowContext(ctx, query, id).Scan(
-		c.ID, c.Name, c.Description, c.ParentID, c.CreatedAt, c.UpdatedAt,
+	err := r.db.QueryRowContext(ctx, query, id, tenant.ID(ctx)).Scan(
+		&c.ID, &c.Name, &c.Description, &c.ParentID, &c.CreatedAt, &c.UpdatedAt,
 	)
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
@@ -67,12 +92,15 @@ func (r *Repository) GetByID(ctx context.Context, id uuid.UUID) (*model.Category
 
 // List retrieves all categories from the database.
 func (r *Repository) List(ctx context.Context) ([]model.Category, error) {
+	defer metrics.ObserveQuery("category", "List", time.Now())
+
 	query := `
 		SELECT id, name, description, parent_id, created_at, updated_at
-		FROM categories;
+		FROM categories
+		WHERE workspace_id = $1;
 	`
 
-	rows, err := r.db.QueryContext(ctx, query)
+	rows, err := r.db.QueryContext(ctx, query, tenant.ID(ctx))
 	if err != nil {
 		return nil, fmt.Errorf("list categories: %w", err)
 	}
@@ -98,53 +126,84 @@ func (r *Repository) List(ctx context.Context) ([]model.Category, error) {
 	return categories, nil
 }
 
-// Update updates a category.
+// Update updates a category and records a category.updated event.
 func (r *Repository) Update(ctx context.Context, c *model.Category) error {
+	defer metrics.ObserveQuery("category", "Update", time.Now())
+
 	query := `
 		UPDATE categories
 		SET name = $1,
 			description = $2,
 			parent_id = $3,
 			updated_at = NOW()
-		WHERE id = $4;
+		WHERE id = $4
+		  AND workspace_id = $5
+		RETURNING created_at, updated_at;
 	`
 
-	res, err := r.db.ExecContext(ctx, query, c.Name, c.Description, c.ParentID, c.ID)
+	tx, err := r.db.Master.BeginTx(ctx, nil)
 	if err != nil {
-		return fmt.Errorf("update category: %w", err)
+		return fmt.Errorf("begin tx: %w", err)
 	}
+	defer database.Rollback(ctx, tx)
 
-	n, err := res.RowsAffected()
+	err = tx.QueryRowContext(ctx, query, c.Name, c.Description, c.ParentID, c.ID, tenant.ID(ctx)).Scan(
+		&c.CreatedAt, &c.UpdatedAt,
+	)
 	if err != nil {
-		return fmt.Errorf("check rows affected: %w", err)
+		if errors.Is(err, sql.ErrNoRows) {
+			return ErrCategoryNotFound
+		}
+
+		return fmt.Errorf("update category: %w", err)
+	}
+
+	if err = outbox.Write(ctx, tx, model.EventCategoryUpdated, c); err != nil {
+		return err
 	}
 
-	if n == 0 {
-		return ErrCategoryNotFound
+	if err = tx.Commit(); err != nil {
+		return fmt.Errorf("commit tx: %w", err)
 	}
 
 	return nil
 }
 
-// Delete removes a category from the database.
+// Delete removes a category from the database and records a category.deleted event.
 func (r *Repository) Delete(ctx context.Context, id uuid.UUID) error {
+	defer metrics.ObserveQuery("category", "Delete", time.Now())
+
 	query := `
 		DELETE FROM categories
-		WHERE id = $1;
+		WHERE id = $1
+		  AND workspace_id = $2
+		RETURNING id, name, description, parent_id, created_at, updated_at;
 	`
 
-	res, err := r.db.ExecContext(ctx, query, id)
+	tx, err := r.db.Master.BeginTx(ctx, nil)
 	if err != nil {
-		return fmt.Errorf("delete category: %w", err)
+		return fmt.Errorf("begin tx: %w", err)
 	}
+	defer database.Rollback(ctx, tx)
 
-	n, err := res.RowsAffected()
+	var c model.Category
+	err = tx.QueryRowContext(ctx, query, id, tenant.ID(ctx)).Scan(
+		&c.ID, &c.Name, &c.Description, &c.ParentID, &c.CreatedAt, &c.UpdatedAt,
+	)
 	if err != nil {
-		return fmt.Errorf("check rows affected: %w", err)
+		if errors.Is(err, sql.ErrNoRows) {
+			return ErrCategoryNotFound
+		}
+
+		return fmt.Errorf("delete category: %w", err)
+	}
+
+	if err = outbox.Write(ctx, tx, model.EventCategoryDeleted, &c); err != nil {
+		return err
 	}
 
-	if n == 0 {
-		return ErrCategoryNotFound
+	if err = tx.Commit(); err != nil {
+		return fmt.Errorf("commit tx: %w", err)
 	}
 
 	return nil
@@ -3,61 +3,183 @@ package item
 import (
 	"context"
 	"database/sql"
+	"encoding/json"
 	"errors"
 	"fmt"
+	"time"
 
 	"github.com/google/uuid"
-	"github.com/wb-go/wbf/dbpg"
+	"github.com/lib/pq"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/shopspring/decimal"
 
+	"github.com/aliskhannn/sales-tracker/internal/database"
+	"github.com/aliskhannn/sales-tracker/internal/metrics"
 	"github.com/aliskhannn/sales-tracker/internal/model"
+	"github.com/aliskhannn/sales-tracker/internal/repository/attachment"
+	"github.com/aliskhannn/sales-tracker/internal/repository/outbox"
+	"github.com/aliskhannn/sales-tracker/internal/tenant"
 )
 
 var (
-	ErrItemNotFound = errors.New("item not found")
-	ErrNoItemsFound = errors.New("no items found")
+	ErrItemNotFound         = errors.New("item not found")
+	ErrNoItemsFound         = errors.New("no items found")
+	ErrSplitsAmountMismatch = errors.New("split amounts must sum to the item amount")
+	ErrRefundTargetNotFound = errors.New("refunded item not found")
+	ErrInvalidRefundTarget  = errors.New("only income and expense items can be refunded")
+	ErrRefundCurrency       = errors.New("refund currency must match the refunded item currency")
+	ErrRefundExceedsAmount  = errors.New("cumulative refunds exceed the refunded item amount")
+	ErrItemHasRefunds       = errors.New("item has refunds")
 )
 
+// Business metrics of items, counted when their transaction commits.
+var (
+	itemsCreated = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: metrics.Namespace,
+		Name:      "items_created_total",
+		Help:      "Number of items created by kind.",
+	}, []string{"kind"})
+	itemsDeleted = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: metrics.Namespace,
+		Name:      "items_deleted_total",
+		Help:      "Number of items deleted by kind.",
+	}, []string{"kind"})
+)
+
+// foreignKeyViolation is the PostgreSQL error code of foreign_key_violation.
+const foreignKeyViolation = "23503"
+
+// itemColumns lists the items columns in the order scanned by scanItems.
+const itemColumns = `
+	id, kind, title, amount, currency, occurred_at, category_id, account_id,
+	transfer_id, transfer_leg, refund_of, reconciled_at, metadata, created_at, updated_at
+`
+
 // Repository provides methods to interact with items.
 type Repository struct {
-	db *dbpg.DB
+	db *database.DB
 }
 
 // NewRepository creates a new item repository.
-func NewRepository(db *dbpg.DB) *Repository {
+func NewRepository(db *database.DB) *Repository {
 	return &Repository{db: db}
 }
 
-// Create adds a new item to the database.
+// Create adds a new item and its splits to the database in a single transaction
+// and records an item.created event.
 func (r *Repository) Create(ctx context.Context, i *model.Item) (uuid.UUID, error) {
+	defer metrics.ObserveQuery("item", "Create", time.Now())
+
+	tx, err := r.db.Master.BeginTx(ctx, nil)
+	if err != nil {
+		return uuid.Nil, fmt.Errorf("begin tx: %w", err)
+	}
+	defer database.Rollback(ctx, tx)
+
+	if err = insertItem(ctx, tx, i); err != nil {
+		return uuid.Nil, err
+	}
+
+	if err = writeItemEvent(ctx, tx, model.EventItemCreated, i.ID); err != nil {
+		return uuid.Nil, err
+	}
+
+	if err = tx.Commit(); err != nil {
+		return uuid.Nil, fmt.Errorf("commit tx: %w", err)
+	}
+
+	itemsCreated.WithLabelValues(i.Kind).Inc()
+
+	return i.ID, nil
+}
+
+// CreateTransfer atomically adds the source and destination legs of a transfer
+// and links them with a new transfer ID. An item.created event is recorded for
+// each leg.
+func (r *Repository) CreateTransfer(ctx context.Context, source, destination *model.Item) (uuid.UUID, error) {
+	defer metrics.ObserveQuery("item", "CreateTransfer", time.Now())
+
+	transferID := uuid.New()
+	sourceLeg, destinationLeg := model.TransferSource, model.TransferDestination
+
+	source.TransferID, source.TransferLeg = &transferID, &sourceLeg
+	destination.TransferID, destination.TransferLeg = &transferID, &destinationLeg
+
+	tx, err := r.db.Master.BeginTx(ctx, nil)
+	if err != nil {
+		return uuid.Nil, fmt.Errorf("begin tx: %w", err)
+	}
+	defer database.Rollback(ctx, tx)
+
+	if err = insertItem(ctx, tx, source); err != nil {
+		return uuid.Nil, err
+	}
+
+	if err = insertItem(ctx, tx, destination); err != nil {
+		return uuid.Nil, err
+	}
+
+	for _, id := range []uuid.UUID{source.ID, destination.ID} {
+		if err = writeItemEvent(ctx, tx, model.EventItemCreated, id); err != nil {
+			return uuid.Nil, err
+		}
+	}
+
+	if err = tx.Commit(); err != nil {
+		return uuid.Nil, fmt.Errorf("commit tx: %w", err)
+	}
+
+	itemsCreated.WithLabelValues(source.Kind).Inc()
+	itemsCreated.WithLabelValues(destination.Kind).Inc()
+
+	return transferID, nil
+}
+
+// insertItem inserts an item with its splits inside the given transaction.
+// Refunds are checked against the item they refund.
+func insertItem(ctx context.Context, tx *sql.Tx, i *model.Item) error {
 	query := `
 		INSERT INTO items (
-		    kind, title, amount, currency, occurred_at, category_id, metadata
-		) VALUES ($1, $2, $3, $4, $5, $6, $7)
+		    kind, title, amount, currency, occurred_at, category_id, account_id,
+		    transfer_id, transfer_leg, refund_of, metadata, workspace_id
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12)
 		RETURNING id;
 	`
 
-	err := r.db.QueryRowContext(ctx, query,
-		i.Kind, i.Title, i.Amount, i.Currency, i.OccurredAt, i.CategoryID, i.Metadata,
+	if i.RefundOf != nil {
+		if err := checkRefund(ctx, tx, *i.RefundOf, i.ID, i.Amount, i.Currency); err != nil {
+			return err
+		}
+	}
+
+	err := tx.QueryRowContext(ctx, query,
+		i.Kind, i.Title, i.Amount, i.Currency, i.OccurredAt, i.CategoryID, i.AccountID,
+		i.TransferID, i.TransferLeg, i.RefundOf, i.Metadata, tenant.ID(ctx),
 	).Scan(&i.ID)
 	if err != nil {
-		return uuid.Nil, fmt.Errorf("insert item: %w", err)
+		return fmt.Errorf("insert item: %w", err)
 	}
 
-	return i.ID, nil
+	return insertSplits(ctx, tx, i.ID, i.Splits)
 }
 
 // GetByID retrieves an item by its ID.
 func (r *Repository) GetByID(ctx context.Context, id uuid.UUID) (*model.Item, error) {
+	defer metrics.ObserveQuery("item", "GetByID", time.Now())
+
 	query := `
-		SELECT id, kind, title, amount, currency, occurred_at, category_id, metadata, created_at, updated_at
+		SELECT id, kind, title, amount, currency, occurred_at, category_id, account_id,
+		       transfer_id, transfer_leg, refund_of, reconciled_at, metadata, created_at, updated_at
 		FROM items
-		WHERE id = $1;
+		WHERE id = $1
+		  AND workspace_id = $2;
 	`
 
 	var i model.Item
-	err := r.db.QueryRowContext(ctx, query, id).Scan(
+	err := r.db.QueryRowContext(ctx, query, id, tenant.ID(ctx)).Scan(
 		&i.ID, &i.Kind, &i.Title, &i.Amount, &i.Currency, &i.OccurredAt,
-		&i.CategoryID, &i.Metadata, &i.CreatedAt, &i.UpdatedAt,
+		&i.CategoryID, &i.AccountID, &i.TransferID, &i.TransferLeg, &i.RefundOf, &i.ReconciledAt, &i.Metadata, &i.CreatedAt, &i.UpdatedAt,
 	)
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
@@ -67,31 +189,60 @@ func (r *Repository) GetByID(ctx context.Context, id uuid.UUID) (*model.Item, er
 		return nil, fmt.Errorf("get item: %w", err)
 	}
 
-	return &i, nil
+	i.Splits, err = r.ListSplits(ctx, i.ID)
+	if err != nil {
+		return nil, fmt.Errorf("get item: %w", err)
+	}
+
+	items := []model.Item{i}
+	if err = r.attachTags(ctx, items); err != nil {
+		return nil, fmt.Errorf("get item: %w", err)
+	}
+
+	return &items[0], nil
 }
 
 // List retrieves items from the database applying optional filters.
-// Filters can include date range (From, To), category, kind, pagination (Limit, Offset),
-// and sort order (SortBy).
+// Filters can include date range (From, To), category, kind, account, tags, reconciliation state,
+// pagination (Limit, Offset), and sort order (SortBy).
 func (r *Repository) List(ctx context.Context, filter *model.ItemFilter) ([]model.Item, error) {
+	defer metrics.ObserveQuery("item", "List", time.Now())
+
 	query := `
-		SELECT id, kind, title, amount, currency, occurred_at, category_id, metadata, created_at, updated_at
+		SELECT id, kind, title, amount, currency, occurred_at, category_id, account_id,
+		       transfer_id, transfer_leg, refund_of, reconciled_at, metadata, created_at, updated_at
 		FROM items
 		WHERE ($1::timestamptz IS NULL OR occurred_at >= $1)
 		  AND ($2::timestamptz IS NULL OR occurred_at <= $2)
 		  AND ($3::uuid IS NULL OR category_id = $3)
 		  AND ($4::item_kind IS NULL OR kind = $4)
-		ORDER BY occurred_at DESC
-		LIMIT $5 OFFSET $6;
+		  AND ($5::uuid IS NULL OR account_id = $5)
+		  AND ($8::text[] IS NULL OR (
+		      SELECT COUNT(*)
+		      FROM item_tags it
+		      JOIN tags t ON t.id = it.tag_id
+		      WHERE it.item_id = items.id
+		        AND lower(t.name) = ANY($8::text[])
+		  ) >= CASE WHEN $9 THEN cardinality($8::text[]) ELSE 1 END)
+		  AND ($10::bool IS NULL OR (reconciled_at IS NOT NULL) = $10)
+		  AND workspace_id = $11
+		ORDER BY occurred_at DESC, id
+		LIMIT $6 OFFSET $7;
 	`
 
+	tagNames, matchAll := tagArgs(filter.Tags)
 	rows, err := r.db.QueryContext(ctx, query,
 		filter.From,
 		filter.To,
 		filter.CategoryID,
 		filter.Kind,
+		filter.AccountID,
 		filter.Limit,
 		filter.Offset,
+		tagNames,
+		matchAll,
+		filter.Reconciled,
+		tenant.ID(ctx),
 	)
 	if err != nil {
 		return nil, fmt.Errorf("list items: %w", err)
@@ -103,7 +254,7 @@ func (r *Repository) List(ctx context.Context, filter *model.ItemFilter) ([]mode
 		var i model.Item
 		if err = rows.Scan(
 			&i.ID, &i.Kind, &i.Title, &i.Amount, &i.Currency, &i.OccurredAt,
-			&i.CategoryID, &i.Metadata, &i.CreatedAt, &i.UpdatedAt,
+			&i.CategoryID, &i.AccountID, &i.TransferID, &i.TransferLeg, &i.RefundOf, &i.ReconciledAt, &i.Metadata, &i.CreatedAt, &i.UpdatedAt,
 		); err != nil {
 			return nil, fmt.Errorf("list items: %w", err)
 		}
@@ -115,11 +266,23 @@ func (r *Repository) List(ctx context.Context, filter *model.ItemFilter) ([]mode
 		return nil, fmt.Errorf("list items: %w", err)
 	}
 
+	if err = r.attachSplits(ctx, items); err != nil {
+		return nil, fmt.Errorf("list items: %w", err)
+	}
+
+	if err = r.attachTags(ctx, items); err != nil {
+		return nil, fmt.Errorf("list items: %w", err)
+	}
+
 	return items, nil
 }
 
-// Update updates an item.
+// Update updates an item. If i.Splits is not nil, the item splits are replaced
+// with it; otherwise existing splits are kept and must still sum to the new amount.
+// Refunds of the item must not exceed its new amount. An item.updated event is recorded.
 func (r *Repository) Update(ctx context.Context, i *model.Item) error {
+	defer metrics.ObserveQuery("item", "Update", time.Now())
+
 	query := `
 		UPDATE items
 		SET
@@ -129,20 +292,38 @@ func (r *Repository) Update(ctx context.Context, i *model.Item) error {
     		currency = $4,
     		occurred_at = $5,
     		category_id = $6,
-    		metadata = $7,
+    		account_id = $7,
+    		refund_of = $8,
+    		metadata = $9,
     		updated_at = NOW()
-		WHERE id = $8;
+		WHERE id = $10
+		  AND workspace_id = $11;
 	`
 
-	res, err := r.db.ExecContext(ctx, query,
+	tx, err := r.db.Master.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("begin tx: %w", err)
+	}
+	defer database.Rollback(ctx, tx)
+
+	if i.RefundOf != nil {
+		if err = checkRefund(ctx, tx, *i.RefundOf, i.ID, i.Amount, i.Currency); err != nil {
+			return err
+		}
+	}
+
+	res, err := tx.ExecContext(ctx, query,
 		i.Kind,
 		i.Title,
 		i.Amount,
 		i.Currency,
 		i.OccurredAt,
 		i.CategoryID,
+		i.AccountID,
+		i.RefundOf,
 		i.Metadata,
 		i.ID,
+		tenant.ID(ctx),
 	)
 	if err != nil {
 		return fmt.Errorf("update item: %w", err)
@@ -157,19 +338,219 @@ func (r *Repository) Update(ctx context.Context, i *model.Item) error {
 		return ErrItemNotFound
 	}
 
+	if i.Splits != nil {
+		if err = replaceSplits(ctx, tx, i.ID, i.Splits); err != nil {
+			return err
+		}
+	} else if err = checkSplitsSum(ctx, tx, i.ID, i.Amount); err != nil {
+		return err
+	}
+
+	if err = checkRefundedTotal(ctx, tx, i.ID, i.Amount, i.Currency); err != nil {
+		return err
+	}
+
+	if err = writeItemEvent(ctx, tx, model.EventItemUpdated, i.ID); err != nil {
+		return err
+	}
+
+	if err = tx.Commit(); err != nil {
+		return fmt.Errorf("commit tx: %w", err)
+	}
+
 	return nil
 }
 
-// Delete removes an item from the database.
-func (r *Repository) Delete(ctx context.Context, id uuid.UUID) error {
-	query := `
+// Delete removes an item from the database and records an item.deleted event
+// for every removed item. Deleting either leg of a transfer removes both legs.
+// Items that have refunds cannot be deleted. The attachments of the removed
+// items are removed with them: remove is called with the SHA-256 of each of
+// their contents that no other attachment references before the deletion is
+// committed, and the items are kept if remove fails.
+func (r *Repository) Delete(ctx context.Context, id uuid.UUID, remove func(ctx context.Context, sha256 string) error) error {
+	defer metrics.ObserveQuery("item", "Delete", time.Now())
+
+	tx, err := r.db.Master.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("begin tx: %w", err)
+	}
+	defer database.Rollback(ctx, tx)
+
+	rows, err := tx.QueryContext(ctx, `
+		SELECT id
+		FROM items
+		WHERE workspace_id = $2
+		  AND (id = $1
+		   OR transfer_id = (SELECT transfer_id FROM items WHERE id = $1 AND workspace_id = $2));
+	`, id, tenant.ID(ctx))
+	if err != nil {
+		return fmt.Errorf("get items: %w", err)
+	}
+
+	ids, err := scanIDs(rows)
+	if err != nil {
+		return fmt.Errorf("get items: %w", err)
+	}
+
+	if len(ids) == 0 {
+		return ErrItemNotFound
+	}
+
+	contents, err := lockAttachments(ctx, tx, ids)
+	if err != nil {
+		return err
+	}
+
+	rows, err = tx.QueryContext(ctx, `
 		DELETE FROM items
-		WHERE id = $1;
+		WHERE id = ANY($1::uuid[])
+		RETURNING `+itemColumns+`;
+	`, pq.Array(uuidStrings(ids)))
+	if err != nil {
+		return deleteError(err)
+	}
+
+	deleted, err := scanItems(rows)
+	if err != nil {
+		return deleteError(err)
+	}
+
+	for k := range deleted {
+		if err = outbox.Write(ctx, tx, model.EventItemDeleted, &deleted[k]); err != nil {
+			return err
+		}
+	}
+
+	if err = attachment.RemoveUnreferenced(ctx, tx, remove, contents...); err != nil {
+		return err
+	}
+
+	if err = tx.Commit(); err != nil {
+		return fmt.Errorf("commit tx: %w", err)
+	}
+
+	for _, d := range deleted {
+		itemsDeleted.WithLabelValues(d.Kind).Inc()
+	}
+
+	return nil
+}
+
+// lockAttachments locks the items with the given IDs, so that no attachment is
+// added to them before commit, and then the contents of their attachments. It
+// returns the SHA-256 hashes of the contents, sorted.
+func lockAttachments(ctx context.Context, tx *sql.Tx, ids []uuid.UUID) ([]string, error) {
+	args := pq.Array(uuidStrings(ids))
+
+	if _, err := tx.ExecContext(ctx, `
+		SELECT id
+		FROM items
+		WHERE id = ANY($1::uuid[])
+		ORDER BY id
+		FOR UPDATE;
+	`, args); err != nil {
+		return nil, fmt.Errorf("lock items: %w", err)
+	}
+
+	rows, err := tx.QueryContext(ctx, `
+		SELECT DISTINCT sha256
+		FROM attachments
+		WHERE item_id = ANY($1::uuid[])
+		ORDER BY sha256;
+	`, args)
+	if err != nil {
+		return nil, fmt.Errorf("list attachments: %w", err)
+	}
+	defer rows.Close()
+
+	var contents []string
+	for rows.Next() {
+		var sha256 string
+		if err = rows.Scan(&sha256); err != nil {
+			return nil, fmt.Errorf("list attachments: %w", err)
+		}
+
+		contents = append(contents, sha256)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("list attachments: %w", err)
+	}
+
+	if err = attachment.LockContents(ctx, tx, contents...); err != nil {
+		return nil, err
+	}
+
+	return contents, nil
+}
+
+// deleteError maps an error of deleting items, reporting items that are
+// still referenced by refunds as ErrItemHasRefunds.
+func deleteError(err error) error {
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) && pqErr.Code == foreignKeyViolation {
+		return ErrItemHasRefunds
+	}
+
+	return fmt.Errorf("delete item: %w", err)
+}
+
+// UpdateClassification updates the kind, category and metadata of an item,
+// leaving its amount, splits and references untouched, and records an
+// item.updated event. The kind of an item that has refunds cannot be changed;
+// ErrItemHasRefunds is returned.
+func (r *Repository) UpdateClassification(ctx context.Context, i *model.Item) error {
+	defer metrics.ObserveQuery("item", "UpdateClassification", time.Now())
+
+	query := `
+		UPDATE items
+		SET kind = $1,
+		    category_id = $2,
+		    metadata = $3,
+		    updated_at = NOW()
+		WHERE id = $4
+		  AND workspace_id = $5;
 	`
 
-	res, err := r.db.ExecContext(ctx, query, id)
+	tx, err := r.db.Master.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("begin tx: %w", err)
+	}
+	defer database.Rollback(ctx, tx)
+
+	// Locking the item, as checkRefund does, keeps refunds of it from being
+	// created before commit.
+	var kind string
+	err = tx.QueryRowContext(ctx, `
+		SELECT kind
+		FROM items
+		WHERE id = $1
+		  AND workspace_id = $2
+		FOR UPDATE;
+	`, i.ID, tenant.ID(ctx)).Scan(&kind)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return ErrItemNotFound
+		}
+
+		return fmt.Errorf("lock item: %w", err)
+	}
+
+	if kind != i.Kind {
+		var refunded bool
+		err = tx.QueryRowContext(ctx, `SELECT EXISTS (SELECT 1 FROM items WHERE refund_of = $1);`, i.ID).Scan(&refunded)
+		if err != nil {
+			return fmt.Errorf("check refunds: %w", err)
+		}
+
+		if refunded {
+			return ErrItemHasRefunds
+		}
+	}
+
+	res, err := tx.ExecContext(ctx, query, i.Kind, i.CategoryID, i.Metadata, i.ID, tenant.ID(ctx))
 	if err != nil {
-		return fmt.Errorf("delete item: %w", err)
+		return fmt.Errorf("update item classification: %w", err)
 	}
 
 	n, err := res.RowsAffected()
@@ -181,5 +562,796 @@ func (r *Repository) Delete(ctx context.Context, id uuid.UUID) error {
 		return ErrItemNotFound
 	}
 
+	if err = writeItemEvent(ctx, tx, model.EventItemUpdated, i.ID); err != nil {
+		return err
+	}
+
+	if err = tx.Commit(); err != nil {
+		return fmt.Errorf("commit tx: %w", err)
+	}
+
+	return nil
+}
+
+// ListSplits retrieves the splits of an item.
+func (r *Repository) ListSplits(ctx context.Context, itemID uuid.UUID) ([]model.ItemSplit, error) {
+	defer metrics.ObserveQuery("item", "ListSplits", time.Now())
+
+	query := `
+		SELECT s.id, s.item_id, s.category_id, s.amount, s.note, s.created_at
+		FROM item_splits s
+		JOIN items i ON i.id = s.item_id
+		WHERE s.item_id = $1
+		  AND i.workspace_id = $2
+		ORDER BY s.created_at, s.id;
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, itemID, tenant.ID(ctx))
+	if err != nil {
+		return nil, fmt.Errorf("list splits: %w", err)
+	}
+
+	return scanSplits(rows)
+}
+
+// ReplaceSplits atomically replaces the splits of an item and records an
+// item.updated event. An empty slice removes all splits.
+func (r *Repository) ReplaceSplits(ctx context.Context, itemID uuid.UUID, splits []model.ItemSplit) error {
+	defer metrics.ObserveQuery("item", "ReplaceSplits", time.Now())
+
+	query := `
+		SELECT amount
+		FROM items
+		WHERE id = $1
+		  AND workspace_id = $2
+		FOR UPDATE;
+	`
+
+	tx, err := r.db.Master.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("begin tx: %w", err)
+	}
+	defer database.Rollback(ctx, tx)
+
+	var amount decimal.Decimal
+	if err = tx.QueryRowContext(ctx, query, itemID, tenant.ID(ctx)).Scan(&amount); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return ErrItemNotFound
+		}
+
+		return fmt.Errorf("lock item: %w", err)
+	}
+
+	if len(splits) > 0 && !sumSplits(splits).Equal(amount) {
+		return ErrSplitsAmountMismatch
+	}
+
+	if err = replaceSplits(ctx, tx, itemID, splits); err != nil {
+		return err
+	}
+
+	if err = writeItemEvent(ctx, tx, model.EventItemUpdated, itemID); err != nil {
+		return err
+	}
+
+	if err = tx.Commit(); err != nil {
+		return fmt.Errorf("commit tx: %w", err)
+	}
+
+	return nil
+}
+
+// attachSplits loads splits for all given items with a single query.
+func (r *Repository) attachSplits(ctx context.Context, items []model.Item) error {
+	if len(items) == 0 {
+		return nil
+	}
+
+	ids := make([]uuid.UUID, 0, len(items))
+	for _, i := range items {
+		ids = append(ids, i.ID)
+	}
+
+	query := `
+		SELECT id, item_id, category_id, amount, note, created_at
+		FROM item_splits
+		WHERE item_id = ANY($1::uuid[])
+		ORDER BY created_at, id;
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, pq.Array(uuidStrings(ids)))
+	if err != nil {
+		return fmt.Errorf("list splits: %w", err)
+	}
+
+	splits, err := scanSplits(rows)
+	if err != nil {
+		return err
+	}
+
+	byItem := make(map[uuid.UUID][]model.ItemSplit)
+	for _, sp := range splits {
+		byItem[sp.ItemID] = append(byItem[sp.ItemID], sp)
+	}
+
+	for k := range items {
+		items[k].Splits = byItem[items[k].ID]
+	}
+
+	return nil
+}
+
+// attachTags loads tags for all given items with a single query.
+func (r *Repository) attachTags(ctx context.Context, items []model.Item) error {
+	if len(items) == 0 {
+		return nil
+	}
+
+	ids := make([]uuid.UUID, 0, len(items))
+	for _, i := range items {
+		ids = append(ids, i.ID)
+	}
+
+	query := `
+		SELECT it.item_id, t.id, t.name, t.description, t.created_at, t.updated_at
+		FROM item_tags it
+		JOIN tags t ON t.id = it.tag_id
+		WHERE it.item_id = ANY($1::uuid[])
+		ORDER BY lower(t.name);
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, pq.Array(uuidStrings(ids)))
+	if err != nil {
+		return fmt.Errorf("list tags: %w", err)
+	}
+	defer rows.Close()
+
+	byItem := make(map[uuid.UUID][]model.Tag)
+	for rows.Next() {
+		var (
+			itemID uuid.UUID
+			t      model.Tag
+		)
+		if err = rows.Scan(&itemID, &t.ID, &t.Name, &t.Description, &t.CreatedAt, &t.UpdatedAt); err != nil {
+			return fmt.Errorf("list tags: %w", err)
+		}
+
+		byItem[itemID] = append(byItem[itemID], t)
+	}
+
+	if err = rows.Err(); err != nil {
+		return fmt.Errorf("list tags: %w", err)
+	}
+
+	for k := range items {
+		items[k].Tags = byItem[items[k].ID]
+	}
+
 	return nil
 }
+
+// tagArgs returns the query arguments of a tag filter: the tag names as a
+// text array (NULL without a filter) and whether all of them must match.
+func tagArgs(f *model.TagFilter) (any, bool) {
+	if f == nil || len(f.Names) == 0 {
+		return nil, false
+	}
+
+	return pq.Array(f.Names), f.MatchAll
+}
+
+// scanSplits scans item split rows and closes them.
+func scanSplits(rows *sql.Rows) ([]model.ItemSplit, error) {
+	defer rows.Close()
+
+	var splits []model.ItemSplit
+	for rows.Next() {
+		var sp model.ItemSplit
+		if err := rows.Scan(&sp.ID, &sp.ItemID, &sp.CategoryID, &sp.Amount, &sp.Note, &sp.CreatedAt); err != nil {
+			return nil, fmt.Errorf("list splits: %w", err)
+		}
+
+		splits = append(splits, sp)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("list splits: %w", err)
+	}
+
+	return splits, nil
+}
+
+// replaceSplits deletes the existing splits of an item and inserts the given ones.
+func replaceSplits(ctx context.Context, tx *sql.Tx, itemID uuid.UUID, splits []model.ItemSplit) error {
+	query := `
+		DELETE FROM item_splits
+		WHERE item_id = $1;
+	`
+
+	if _, err := tx.ExecContext(ctx, query, itemID); err != nil {
+		return fmt.Errorf("delete splits: %w", err)
+	}
+
+	return insertSplits(ctx, tx, itemID, splits)
+}
+
+// insertSplits inserts splits of an item and fills their IDs.
+func insertSplits(ctx context.Context, tx *sql.Tx, itemID uuid.UUID, splits []model.ItemSplit) error {
+	query := `
+		INSERT INTO item_splits (item_id, category_id, amount, note)
+		VALUES ($1, $2, $3, $4)
+		RETURNING id, created_at;
+	`
+
+	for k := range splits {
+		sp := &splits[k]
+		sp.ItemID = itemID
+
+		if err := tx.QueryRowContext(ctx, query, itemID, sp.CategoryID, sp.Amount, sp.Note).Scan(&sp.ID, &sp.CreatedAt); err != nil {
+			return fmt.Errorf("insert split: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// checkSplitsSum verifies that existing splits of an item, if any, sum to amount.
+func checkSplitsSum(ctx context.Context, tx *sql.Tx, itemID uuid.UUID, amount decimal.Decimal) error {
+	query := `
+		SELECT COUNT(*), COALESCE(SUM(amount), 0)
+		FROM item_splits
+		WHERE item_id = $1;
+	`
+
+	var (
+		cnt int64
+		sum decimal.Decimal
+	)
+	if err := tx.QueryRowContext(ctx, query, itemID).Scan(&cnt, &sum); err != nil {
+		return fmt.Errorf("sum splits: %w", err)
+	}
+
+	if cnt > 0 && !sum.Equal(amount) {
+		return ErrSplitsAmountMismatch
+	}
+
+	return nil
+}
+
+// sumSplits returns the total amount of the given splits.
+func sumSplits(splits []model.ItemSplit) decimal.Decimal {
+	total := decimal.Zero
+	for _, sp := range splits {
+		total = total.Add(sp.Amount)
+	}
+
+	return total
+}
+
+// ListRefunds retrieves refund items that reference the given item.
+func (r *Repository) ListRefunds(ctx context.Context, itemID uuid.UUID) ([]model.Item, error) {
+	defer metrics.ObserveQuery("item", "ListRefunds", time.Now())
+
+	query := `SELECT ` + itemColumns + `
+		FROM items
+		WHERE refund_of = $1
+		  AND workspace_id = $2
+		ORDER BY occurred_at;
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, itemID, tenant.ID(ctx))
+	if err != nil {
+		return nil, fmt.Errorf("list refunds: %w", err)
+	}
+
+	items, err := scanItems(rows)
+	if err != nil {
+		return nil, fmt.Errorf("list refunds: %w", err)
+	}
+
+	return items, nil
+}
+
+// RefundedIDs returns which of the items with the given IDs have refunds.
+func (r *Repository) RefundedIDs(ctx context.Context, ids []uuid.UUID) (map[uuid.UUID]bool, error) {
+	defer metrics.ObserveQuery("item", "RefundedIDs", time.Now())
+
+	query := `
+		SELECT DISTINCT refund_of
+		FROM items
+		WHERE refund_of = ANY($1::uuid[])
+		  AND workspace_id = $2;
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, pq.Array(uuidStrings(ids)), tenant.ID(ctx))
+	if err != nil {
+		return nil, fmt.Errorf("list refunded items: %w", err)
+	}
+
+	refunded, err := scanIDs(rows)
+	if err != nil {
+		return nil, fmt.Errorf("list refunded items: %w", err)
+	}
+
+	out := make(map[uuid.UUID]bool, len(refunded))
+	for _, id := range refunded {
+		out[id] = true
+	}
+
+	return out, nil
+}
+
+// ListByIDs retrieves items with the given IDs including their splits.
+func (r *Repository) ListByIDs(ctx context.Context, ids []uuid.UUID) ([]model.Item, error) {
+	defer metrics.ObserveQuery("item", "ListByIDs", time.Now())
+
+	query := `SELECT ` + itemColumns + `
+		FROM items
+		WHERE id = ANY($1::uuid[])
+		  AND workspace_id = $2
+		ORDER BY occurred_at, id;
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, pq.Array(uuidStrings(ids)), tenant.ID(ctx))
+	if err != nil {
+		return nil, fmt.Errorf("list items by ids: %w", err)
+	}
+
+	items, err := scanItems(rows)
+	if err != nil {
+		return nil, fmt.Errorf("list items by ids: %w", err)
+	}
+
+	if err = r.attachSplits(ctx, items); err != nil {
+		return nil, fmt.Errorf("list items by ids: %w", err)
+	}
+
+	if err = r.attachTags(ctx, items); err != nil {
+		return nil, fmt.Errorf("list items by ids: %w", err)
+	}
+
+	return items, nil
+}
+
+// FindDuplicatePairs returns pairs of item IDs that are likely duplicates:
+// same amount and currency, occurred_at at most window apart and pg_trgm title
+// similarity of at least similarity. Both legs of the same transfer are never
+// reported as duplicates. from and to optionally limit the occurred_at range.
+func (r *Repository) FindDuplicatePairs(
+	ctx context.Context,
+	from, to *time.Time,
+	window time.Duration,
+	similarity float64,
+) ([][2]uuid.UUID, error) {
+	defer metrics.ObserveQuery("item", "FindDuplicatePairs", time.Now())
+
+	query := `
+		SELECT a.id, b.id
+		FROM items a
+		JOIN items b
+		  ON b.amount = a.amount
+		 AND b.currency = a.currency
+		 AND b.id > a.id
+		 AND b.occurred_at BETWEEN a.occurred_at - $3 * INTERVAL '1 second'
+		                       AND a.occurred_at + $3 * INTERVAL '1 second'
+		WHERE a.workspace_id = $5
+		  AND b.workspace_id = $5
+		  AND ($1::timestamptz IS NULL OR a.occurred_at >= $1)
+		  AND ($2::timestamptz IS NULL OR a.occurred_at <= $2)
+		  AND (a.transfer_id IS NULL OR b.transfer_id IS NULL OR a.transfer_id <> b.transfer_id)
+		  AND similarity(a.title, b.title) >= $4;
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, from, to, window.Seconds(), similarity, tenant.ID(ctx))
+	if err != nil {
+		return nil, fmt.Errorf("find duplicates: %w", err)
+	}
+	defer rows.Close()
+
+	var pairs [][2]uuid.UUID
+	for rows.Next() {
+		var p [2]uuid.UUID
+		if err = rows.Scan(&p[0], &p[1]); err != nil {
+			return nil, fmt.Errorf("find duplicates: %w", err)
+		}
+
+		pairs = append(pairs, p)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("find duplicates: %w", err)
+	}
+
+	return pairs, nil
+}
+
+// FindSimilar returns existing items that are likely duplicates of i
+// using the same criteria as FindDuplicatePairs.
+func (r *Repository) FindSimilar(ctx context.Context, i *model.Item, window time.Duration, similarity float64) ([]model.Item, error) {
+	defer metrics.ObserveQuery("item", "FindSimilar", time.Now())
+
+	query := `SELECT ` + itemColumns + `
+		FROM items
+		WHERE amount = $1
+		  AND currency = $2
+		  AND occurred_at BETWEEN $3::timestamptz - $4 * INTERVAL '1 second'
+		                      AND $3::timestamptz + $4 * INTERVAL '1 second'
+		  AND similarity(title, $5) >= $6
+		  AND workspace_id = $7
+		ORDER BY similarity(title, $5) DESC, occurred_at
+		LIMIT 20;
+	`
+
+	rows, err := r.db.QueryContext(ctx, query,
+		i.Amount, i.Currency, i.OccurredAt, window.Seconds(), i.Title, similarity, tenant.ID(ctx),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("find similar items: %w", err)
+	}
+
+	items, err := scanItems(rows)
+	if err != nil {
+		return nil, fmt.Errorf("find similar items: %w", err)
+	}
+
+	return items, nil
+}
+
+// Merge keeps the item keepID with the given metadata and deletes the
+// duplicates in a single transaction. References to the duplicates from
+// refunds and recurring occurrences and their attachments are moved to the
+// kept item, except attachments whose contents it already has; it returns
+// ErrInvalidRefundTarget if the kept item cannot be refunded and
+// ErrRefundExceedsAmount if the moved refunds do not fit into its amount. An
+// item.updated event is recorded for the kept item and an item.deleted event
+// for each duplicate.
+func (r *Repository) Merge(ctx context.Context, keepID uuid.UUID, duplicateIDs []uuid.UUID, metadata json.RawMessage) error {
+	defer metrics.ObserveQuery("item", "Merge", time.Now())
+
+	ids := pq.Array(uuidStrings(duplicateIDs))
+
+	tx, err := r.db.Master.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("begin tx: %w", err)
+	}
+	defer database.Rollback(ctx, tx)
+
+	// Updating the kept item locks it, so no refund of it is created before commit.
+	var (
+		kind     string
+		amount   decimal.Decimal
+		currency string
+	)
+	err = tx.QueryRowContext(ctx, `
+		UPDATE items
+		SET metadata = $1,
+		    refund_of = CASE WHEN refund_of = ANY($3::uuid[]) THEN NULL ELSE refund_of END,
+		    updated_at = NOW()
+		WHERE id = $2
+		  AND workspace_id = $4
+		RETURNING kind, amount, currency;
+	`, metadata, keepID, ids, tenant.ID(ctx)).Scan(&kind, &amount, &currency)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return ErrItemNotFound
+		}
+
+		return fmt.Errorf("update kept item: %w", err)
+	}
+
+	res, err := tx.ExecContext(ctx, `
+		UPDATE items
+		SET refund_of = $1
+		WHERE refund_of = ANY($2::uuid[])
+		  AND id <> $1;
+	`, keepID, ids)
+	if err != nil {
+		return fmt.Errorf("move refunds: %w", err)
+	}
+
+	moved, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("check rows affected: %w", err)
+	}
+
+	if moved > 0 {
+		if kind != model.KindIncome && kind != model.KindExpense {
+			return ErrInvalidRefundTarget
+		}
+
+		if err = checkRefundedTotal(ctx, tx, keepID, amount, currency); err != nil {
+			return err
+		}
+	}
+
+	if _, err = tx.ExecContext(ctx, `
+		UPDATE recurring_item_occurrences
+		SET item_id = $1
+		WHERE item_id = ANY($2::uuid[]);
+	`, keepID, ids); err != nil {
+		return fmt.Errorf("move recurring occurrences: %w", err)
+	}
+
+	// Attachments are moved unless the kept item already has the same
+	// contents; of duplicates sharing contents, the earliest is moved. The
+	// others are removed with the duplicates, so no contents are left
+	// unreferenced.
+	if _, err = lockAttachments(ctx, tx, duplicateIDs); err != nil {
+		return err
+	}
+
+	if _, err = tx.ExecContext(ctx, `
+		UPDATE attachments
+		SET item_id = $1
+		WHERE id IN (
+		    SELECT DISTINCT ON (sha256) id
+		    FROM attachments
+		    WHERE item_id = ANY($2::uuid[])
+		      AND sha256 NOT IN (SELECT sha256 FROM attachments WHERE item_id = $1)
+		    ORDER BY sha256, created_at, id
+		);
+	`, keepID, ids); err != nil {
+		return fmt.Errorf("move attachments: %w", err)
+	}
+
+	rows, err := tx.QueryContext(ctx, `
+		DELETE FROM items
+		WHERE id = ANY($1::uuid[])
+		  AND workspace_id = $2
+		RETURNING `+itemColumns+`;
+	`, ids, tenant.ID(ctx))
+	if err != nil {
+		return fmt.Errorf("delete duplicates: %w", err)
+	}
+
+	deleted, err := scanItems(rows)
+	if err != nil {
+		return fmt.Errorf("delete duplicates: %w", err)
+	}
+
+	if len(deleted) != len(duplicateIDs) {
+		return ErrItemNotFound
+	}
+
+	if err = writeItemEvent(ctx, tx, model.EventItemUpdated, keepID); err != nil {
+		return err
+	}
+
+	for k := range deleted {
+		if err = outbox.Write(ctx, tx, model.EventItemDeleted, &deleted[k]); err != nil {
+			return err
+		}
+	}
+
+	if err = tx.Commit(); err != nil {
+		return fmt.Errorf("commit tx: %w", err)
+	}
+
+	for _, d := range deleted {
+		itemsDeleted.WithLabelValues(d.Kind).Inc()
+	}
+
+	return nil
+}
+
+// ExistingBankReferences returns which of refs are already stored as
+// metadata.bank_reference of an item in the given account (nil for items
+// without an account).
+func (r *Repository) ExistingBankReferences(ctx context.Context, accountID *uuid.UUID, refs []string) (map[string]bool, error) {
+	defer metrics.ObserveQuery("item", "ExistingBankReferences", time.Now())
+
+	query := `
+		SELECT DISTINCT metadata ->> 'bank_reference'
+		FROM items
+		WHERE metadata ->> 'bank_reference' = ANY($1::text[])
+		  AND account_id IS NOT DISTINCT FROM $2::uuid
+		  AND workspace_id = $3;
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, pq.Array(refs), accountID, tenant.ID(ctx))
+	if err != nil {
+		return nil, fmt.Errorf("find bank references: %w", err)
+	}
+	defer rows.Close()
+
+	existing := make(map[string]bool)
+	for rows.Next() {
+		var ref string
+		if err = rows.Scan(&ref); err != nil {
+			return nil, fmt.Errorf("find bank references: %w", err)
+		}
+
+		existing[ref] = true
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("find bank references: %w", err)
+	}
+
+	return existing, nil
+}
+
+// scanItems scans item rows selected with itemColumns and closes them.
+func scanItems(rows *sql.Rows) ([]model.Item, error) {
+	defer rows.Close()
+
+	var items []model.Item
+	for rows.Next() {
+		var i model.Item
+		if err := rows.Scan(
+			&i.ID, &i.Kind, &i.Title, &i.Amount, &i.Currency, &i.OccurredAt,
+			&i.CategoryID, &i.AccountID, &i.TransferID, &i.TransferLeg, &i.RefundOf, &i.ReconciledAt, &i.Metadata, &i.CreatedAt, &i.UpdatedAt,
+		); err != nil {
+			return nil, err
+		}
+
+		items = append(items, i)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return items, nil
+}
+
+// scanIDs scans a single UUID column of rows.
+func scanIDs(rows *sql.Rows) ([]uuid.UUID, error) {
+	defer rows.Close()
+
+	var ids []uuid.UUID
+	for rows.Next() {
+		var id uuid.UUID
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+
+		ids = append(ids, id)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return ids, nil
+}
+
+// uuidStrings converts UUIDs to strings for use with pq.Array.
+func uuidStrings(ids []uuid.UUID) []string {
+	out := make([]string, 0, len(ids))
+	for _, id := range ids {
+		out = append(out, id.String())
+	}
+
+	return out
+}
+
+// checkRefund locks the refunded item and verifies that a refund of amount in
+// currency fits into it together with its other refunds. refundID is the ID of
+// the refund being updated, or uuid.Nil for a new refund.
+func checkRefund(ctx context.Context, tx *sql.Tx, refundOf, refundID uuid.UUID, amount decimal.Decimal, currency string) error {
+	query := `
+		SELECT kind, amount, currency
+		FROM items
+		WHERE id = $1
+		  AND workspace_id = $2
+		FOR UPDATE;
+	`
+
+	var (
+		kind           string
+		originalAmount decimal.Decimal
+		originalCcy    string
+	)
+	if err := tx.QueryRowContext(ctx, query, refundOf, tenant.ID(ctx)).Scan(&kind, &originalAmount, &originalCcy); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return ErrRefundTargetNotFound
+		}
+
+		return fmt.Errorf("lock refunded item: %w", err)
+	}
+
+	if kind != model.KindIncome && kind != model.KindExpense {
+		return ErrInvalidRefundTarget
+	}
+
+	if currency != originalCcy {
+		return ErrRefundCurrency
+	}
+
+	refunded, err := sumRefunds(ctx, tx, refundOf, refundID)
+	if err != nil {
+		return err
+	}
+
+	if refunded.Add(amount).GreaterThan(originalAmount) {
+		return ErrRefundExceedsAmount
+	}
+
+	return nil
+}
+
+// checkRefundedTotal verifies that existing refunds of an item still fit into
+// its amount and use its currency after the item is updated.
+func checkRefundedTotal(ctx context.Context, tx *sql.Tx, itemID uuid.UUID, amount decimal.Decimal, currency string) error {
+	query := `
+		SELECT COUNT(*)
+		FROM items
+		WHERE refund_of = $1
+		  AND currency <> $2;
+	`
+
+	var mismatched int64
+	if err := tx.QueryRowContext(ctx, query, itemID, currency).Scan(&mismatched); err != nil {
+		return fmt.Errorf("check refunds currency: %w", err)
+	}
+
+	if mismatched > 0 {
+		return ErrRefundCurrency
+	}
+
+	refunded, err := sumRefunds(ctx, tx, itemID, uuid.Nil)
+	if err != nil {
+		return err
+	}
+
+	if refunded.GreaterThan(amount) {
+		return ErrRefundExceedsAmount
+	}
+
+	return nil
+}
+
+// sumRefunds returns the total amount of refunds of an item, excluding the refund with excludeID.
+func sumRefunds(ctx context.Context, tx *sql.Tx, itemID, excludeID uuid.UUID) (decimal.Decimal, error) {
+	query := `
+		SELECT COALESCE(SUM(amount), 0)
+		FROM items
+		WHERE refund_of = $1
+		  AND id <> $2;
+	`
+
+	var total decimal.Decimal
+	if err := tx.QueryRowContext(ctx, query, itemID, excludeID).Scan(&total); err != nil {
+		return decimal.Zero, fmt.Errorf("sum refunds: %w", err)
+	}
+
+	return total, nil
+}
+
+// writeItemEvent records an event of eventType carrying the item with its
+// splits as seen inside tx.
+func writeItemEvent(ctx context.Context, tx *sql.Tx, eventType string, id uuid.UUID) error {
+	rows, err := tx.QueryContext(ctx, `SELECT `+itemColumns+`
+		FROM items
+		WHERE id = $1;
+	`, id)
+	if err != nil {
+		return fmt.Errorf("load %s event item: %w", eventType, err)
+	}
+
+	items, err := scanItems(rows)
+	if err != nil {
+		return fmt.Errorf("load %s event item: %w", eventType, err)
+	}
+
+	if len(items) == 0 {
+		return ErrItemNotFound
+	}
+
+	rows, err = tx.QueryContext(ctx, `
+		SELECT id, item_id, category_id, amount, note, created_at
+		FROM item_splits
+		WHERE item_id = $1
+		ORDER BY created_at, id;
+	`, id)
+	if err != nil {
+		return fmt.Errorf("load %s event splits: %w", eventType, err)
+	}
+
+	if items[0].Splits, err = scanSplits(rows); err != nil {
+		return fmt.Errorf("load %s event splits: %w", eventType, err)
+	}
+
+	return outbox.Write(ctx, tx, eventType, &items[0])
+}
@@ -0,0 +1,412 @@
+package job
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/aliskhannn/sales-tracker/internal/database"
+	"github.com/aliskhannn/sales-tracker/internal/metrics"
+	"github.com/aliskhannn/sales-tracker/internal/model"
+	"github.com/aliskhannn/sales-tracker/internal/tenant"
+)
+
+var ErrJobNotFound = errors.New("analytics job not found")
+
+// jobColumns lists the analytics_jobs columns in the order scanned by scanJob.
+const jobColumns = `
+	id, workspace_id, spec, status, progress, result, error, attempts,
+	created_at, started_at, finished_at, updated_at
+`
+
+// summaryColumns lists the same columns as jobColumns but leaves out the
+// result, which can be large, for listings.
+const summaryColumns = `
+	id, workspace_id, spec, status, progress, NULL::jsonb, error, attempts,
+	created_at, started_at, finished_at, updated_at
+`
+
+// Repository provides methods to interact with analytics jobs.
+type Repository struct {
+	db *database.DB
+}
+
+// NewRepository creates a new analytics job repository.
+func NewRepository(db *database.DB) *Repository {
+	return &Repository{db: db}
+}
+
+// Create enqueues a new analytics job.
+func (r *Repository) Create(ctx context.Context, j *model.AnalyticsJob) (uuid.UUID, error) {
+	defer metrics.ObserveQuery("job", "Create", time.Now())
+
+	spec, err := json.Marshal(j.Spec)
+	if err != nil {
+		return uuid.Nil, fmt.Errorf("encode spec: %w", err)
+	}
+
+	query := `
+		INSERT INTO analytics_jobs (workspace_id, spec)
+		VALUES ($1, $2)
+		RETURNING id, workspace_id, status, progress, attempts, created_at, updated_at;
+	`
+
+	err = r.db.Master.QueryRowContext(ctx, query, tenant.ID(ctx), spec).Scan(
+		&j.ID, &j.WorkspaceID, &j.Status, &j.Progress, &j.Attempts, &j.CreatedAt, &j.UpdatedAt,
+	)
+	if err != nil {
+		return uuid.Nil, fmt.Errorf("insert analytics job: %w", err)
+	}
+
+	return j.ID, nil
+}
+
+// GetByID retrieves an analytics job with its result by its ID. The job is
+// read from the master, since a worker may have just updated it.
+func (r *Repository) GetByID(ctx context.Context, id uuid.UUID) (*model.AnalyticsJob, error) {
+	defer metrics.ObserveQuery("job", "GetByID", time.Now())
+
+	query := `SELECT ` + jobColumns + `
+		FROM analytics_jobs
+		WHERE id = $1
+		  AND workspace_id = $2;
+	`
+
+	j, err := scanJob(r.db.Master.QueryRowContext(ctx, query, id, tenant.ID(ctx)))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrJobNotFound
+		}
+
+		return nil, fmt.Errorf("get analytics job: %w", err)
+	}
+
+	return j, nil
+}
+
+// List retrieves the latest analytics jobs without their results, newest first.
+func (r *Repository) List(ctx context.Context, limit int) ([]model.AnalyticsJob, error) {
+	defer metrics.ObserveQuery("job", "List", time.Now())
+
+	query := `SELECT ` + summaryColumns + `
+		FROM analytics_jobs
+		WHERE workspace_id = $1
+		ORDER BY created_at DESC, id
+		LIMIT $2;
+	`
+
+	rows, err := r.db.Master.QueryContext(ctx, query, tenant.ID(ctx), limit)
+	if err != nil {
+		return nil, fmt.Errorf("list analytics jobs: %w", err)
+	}
+	defer rows.Close()
+
+	var jobs []model.AnalyticsJob
+	for rows.Next() {
+		j, err := scanJob(rows)
+		if err != nil {
+			return nil, fmt.Errorf("list analytics jobs: %w", err)
+		}
+
+		jobs = append(jobs, *j)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("list analytics jobs: %w", err)
+	}
+
+	return jobs, nil
+}
+
+// Cancel cancels a queued or running analytics job. It returns false if the
+// job exists but has already finished.
+func (r *Repository) Cancel(ctx context.Context, id uuid.UUID) (bool, error) {
+	defer metrics.ObserveQuery("job", "Cancel", time.Now())
+
+	query := `
+		WITH j AS (
+			SELECT id, status
+			FROM analytics_jobs
+			WHERE id = $1
+			  AND workspace_id = $2
+			FOR UPDATE
+		), canceled AS (
+			UPDATE analytics_jobs a
+			SET status = 'canceled',
+			    locked_until = NULL,
+			    finished_at = now()
+			FROM j
+			WHERE a.id = j.id
+			  AND j.status IN ('queued', 'running')
+			RETURNING a.id
+		)
+		SELECT EXISTS (SELECT 1 FROM canceled)
+		FROM j;
+	`
+
+	var canceled bool
+	if err := r.db.Master.QueryRowContext(ctx, query, id, tenant.ID(ctx)).Scan(&canceled); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return false, ErrJobNotFound
+		}
+
+		return false, fmt.Errorf("cancel analytics job: %w", err)
+	}
+
+	return canceled, nil
+}
+
+// Claim claims the oldest queued job of any workspace, or a running one whose
+// lease expired because its worker stopped, and marks it running for lease.
+// The claim gets a new token, which Renew, Succeed, Fail and Release must be
+// called with, so that a worker whose claim was taken over cannot change the
+// job any more.
+// Jobs that were already started maxAttempts times are left to FailAbandoned.
+// It returns nil if there is no job to claim. Concurrent callers skip each
+// other's jobs.
+func (r *Repository) Claim(ctx context.Context, lease time.Duration, maxAttempts int) (*model.AnalyticsJob, error) {
+	defer metrics.ObserveQuery("job", "Claim", time.Now())
+
+	query := `
+		WITH next AS (
+			SELECT id
+			FROM analytics_jobs
+			WHERE status = 'queued'
+			   OR status = 'running' AND locked_until < now() AND attempts < $2
+			ORDER BY created_at
+			LIMIT 1
+			FOR UPDATE SKIP LOCKED
+		)
+		UPDATE analytics_jobs a
+		SET status = 'running',
+		    attempts = a.attempts + 1,
+		    locked_until = now() + $1 * INTERVAL '1 second',
+		    claim_token = gen_random_uuid(),
+		    started_at = now()
+		FROM next
+		WHERE a.id = next.id
+		RETURNING a.id, a.workspace_id, a.spec, a.status, a.progress, a.result, a.error, a.attempts,
+		          a.created_at, a.started_at, a.finished_at, a.updated_at, a.claim_token;
+	`
+
+	var token uuid.UUID
+	j, err := scanJob(r.db.Master.QueryRowContext(ctx, query, lease.Seconds(), maxAttempts), &token)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil
+		}
+
+		return nil, fmt.Errorf("claim analytics job: %w", err)
+	}
+
+	j.ClaimToken = token
+	return j, nil
+}
+
+// Renew records the progress of a running job and extends its lease. It
+// returns false if the claim with token no longer holds the job, i.e. the job
+// was canceled or claimed by another worker after the lease expired.
+func (r *Repository) Renew(ctx context.Context, id, token uuid.UUID, progress float64, lease time.Duration) (bool, error) {
+	defer metrics.ObserveQuery("job", "Renew", time.Now())
+
+	query := `
+		UPDATE analytics_jobs
+		SET progress = $3,
+		    locked_until = now() + $4 * INTERVAL '1 second'
+		WHERE id = $1
+		  AND claim_token = $2
+		  AND status = 'running';
+	`
+
+	res, err := r.db.Master.ExecContext(ctx, query, id, token, progress, lease.Seconds())
+	if err != nil {
+		return false, fmt.Errorf("renew analytics job: %w", err)
+	}
+
+	return held(res)
+}
+
+// Succeed stores the result of a running job. It returns false, storing
+// nothing, if the claim with token no longer holds the job.
+func (r *Repository) Succeed(ctx context.Context, id, token uuid.UUID, result *model.ReportResult) (bool, error) {
+	defer metrics.ObserveQuery("job", "Succeed", time.Now())
+
+	data, err := json.Marshal(result)
+	if err != nil {
+		return false, fmt.Errorf("encode result: %w", err)
+	}
+
+	query := `
+		UPDATE analytics_jobs
+		SET status = 'succeeded',
+		    progress = 1,
+		    result = $3,
+		    error = NULL,
+		    locked_until = NULL,
+		    finished_at = now()
+		WHERE id = $1
+		  AND claim_token = $2
+		  AND status = 'running';
+	`
+
+	res, err := r.db.Master.ExecContext(ctx, query, id, token, data)
+	if err != nil {
+		return false, fmt.Errorf("record analytics job success: %w", err)
+	}
+
+	return held(res)
+}
+
+// Fail marks a running job failed with the given message. It returns false,
+// changing nothing, if the claim with token no longer holds the job.
+func (r *Repository) Fail(ctx context.Context, id, token uuid.UUID, msg string) (bool, error) {
+	defer metrics.ObserveQuery("job", "Fail", time.Now())
+
+	query := `
+		UPDATE analytics_jobs
+		SET status = 'failed',
+		    error = $3,
+		    locked_until = NULL,
+		    finished_at = now()
+		WHERE id = $1
+		  AND claim_token = $2
+		  AND status = 'running';
+	`
+
+	res, err := r.db.Master.ExecContext(ctx, query, id, token, msg)
+	if err != nil {
+		return false, fmt.Errorf("record analytics job failure: %w", err)
+	}
+
+	return held(res)
+}
+
+// Release puts a running job back in the queue without counting the attempt,
+// e.g. because its worker is shutting down. It returns false, changing
+// nothing, if the claim with token no longer holds the job.
+func (r *Repository) Release(ctx context.Context, id, token uuid.UUID) (bool, error) {
+	defer metrics.ObserveQuery("job", "Release", time.Now())
+
+	query := `
+		UPDATE analytics_jobs
+		SET status = 'queued',
+		    progress = 0,
+		    attempts = greatest(attempts - 1, 0),
+		    locked_until = NULL
+		WHERE id = $1
+		  AND claim_token = $2
+		  AND status = 'running';
+	`
+
+	res, err := r.db.Master.ExecContext(ctx, query, id, token)
+	if err != nil {
+		return false, fmt.Errorf("release analytics job: %w", err)
+	}
+
+	return held(res)
+}
+
+// held reports whether the update of a claimed job changed it, i.e. the claim
+// still held the job.
+func held(res sql.Result) (bool, error) {
+	n, err := res.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("check rows affected: %w", err)
+	}
+
+	return n > 0, nil
+}
+
+// FailAbandoned marks running jobs of all workspaces failed whose lease
+// expired after they were started maxAttempts times, e.g. because every
+// attempt crashed the server, and returns how many were failed.
+func (r *Repository) FailAbandoned(ctx context.Context, maxAttempts int, msg string) (int64, error) {
+	defer metrics.ObserveQuery("job", "FailAbandoned", time.Now())
+
+	query := `
+		UPDATE analytics_jobs
+		SET status = 'failed',
+		    error = $2,
+		    locked_until = NULL,
+		    finished_at = now()
+		WHERE status = 'running'
+		  AND locked_until < now()
+		  AND attempts >= $1;
+	`
+
+	res, err := r.db.Master.ExecContext(ctx, query, maxAttempts, msg)
+	if err != nil {
+		return 0, fmt.Errorf("fail abandoned analytics jobs: %w", err)
+	}
+
+	n, err := res.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("check rows affected: %w", err)
+	}
+
+	return n, nil
+}
+
+// Prune removes jobs of all workspaces that finished before the given time,
+// together with their results, and returns how many were removed.
+func (r *Repository) Prune(ctx context.Context, before time.Time) (int64, error) {
+	defer metrics.ObserveQuery("job", "Prune", time.Now())
+
+	query := `
+		DELETE FROM analytics_jobs
+		WHERE finished_at < $1;
+	`
+
+	res, err := r.db.Master.ExecContext(ctx, query, before)
+	if err != nil {
+		return 0, fmt.Errorf("prune analytics jobs: %w", err)
+	}
+
+	n, err := res.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("check rows affected: %w", err)
+	}
+
+	return n, nil
+}
+
+// scanner is implemented by *sql.Row and *sql.Rows.
+type scanner interface {
+	Scan(dest ...any) error
+}
+
+// scanJob scans a job row selected with jobColumns, followed by the columns
+// scanned into extra, and decodes its spec and result.
+func scanJob(s scanner, extra ...any) (*model.AnalyticsJob, error) {
+	var (
+		j            model.AnalyticsJob
+		spec, result []byte
+	)
+
+	dest := []any{
+		&j.ID, &j.WorkspaceID, &spec, &j.Status, &j.Progress, &result, &j.Error, &j.Attempts,
+		&j.CreatedAt, &j.StartedAt, &j.FinishedAt, &j.UpdatedAt,
+	}
+
+	if err := s.Scan(append(dest, extra...)...); err != nil {
+		return nil, err
+	}
+
+	if err := json.Unmarshal(spec, &j.Spec); err != nil {
+		return nil, fmt.Errorf("decode spec: %w", err)
+	}
+
+	if result != nil {
+		j.Result = &model.ReportResult{}
+		if err := json.Unmarshal(result, j.Result); err != nil {
+			return nil, fmt.Errorf("decode result: %w", err)
+		}
+	}
+
+	return &j, nil
+}
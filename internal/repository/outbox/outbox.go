@@ -0,0 +1,31 @@
+package outbox
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+
+	"github.com/aliskhannn/sales-tracker/internal/tenant"
+)
+
+// Write records an event of eventType with data as its payload in the outbox
+// inside tx, so the event is committed if and only if the change it describes
+// is. The event belongs to the workspace of ctx.
+func Write(ctx context.Context, tx *sql.Tx, eventType string, data any) error {
+	query := `
+		INSERT INTO outbox_events (workspace_id, event_type, payload)
+		VALUES ($1, $2, $3);
+	`
+
+	payload, err := json.Marshal(data)
+	if err != nil {
+		return fmt.Errorf("marshal %s event: %w", eventType, err)
+	}
+
+	if _, err = tx.ExecContext(ctx, query, tenant.ID(ctx), eventType, payload); err != nil {
+		return fmt.Errorf("write %s event: %w", eventType, err)
+	}
+
+	return nil
+}
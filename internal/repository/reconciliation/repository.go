@@ -0,0 +1,469 @@
+package reconciliation
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/aliskhannn/sales-tracker/internal/database"
+	"github.com/aliskhannn/sales-tracker/internal/metrics"
+	"github.com/aliskhannn/sales-tracker/internal/model"
+	"github.com/aliskhannn/sales-tracker/internal/tenant"
+)
+
+var (
+	ErrSessionNotFound = errors.New("reconciliation session not found")
+	ErrLineNotFound    = errors.New("reconciliation line not found")
+	ErrItemNotFound    = errors.New("item not found")
+	ErrItemReconciled  = errors.New("item is already reconciled")
+)
+
+// lineColumns is the column list scanned by scanLines.
+const lineColumns = `id, session_id, line_no, occurred_at, amount, currency, description, reference, status, item_id, created_at, updated_at`
+
+// Repository provides methods to interact with reconciliation sessions and lines.
+type Repository struct {
+	db *database.DB
+}
+
+// NewRepository creates a new reconciliation repository.
+func NewRepository(db *database.DB) *Repository {
+	return &Repository{db: db}
+}
+
+// CreateSession atomically adds a session and its statement lines.
+func (r *Repository) CreateSession(ctx context.Context, s *model.ReconciliationSession) (uuid.UUID, error) {
+	defer metrics.ObserveQuery("reconciliation", "CreateSession", time.Now())
+
+	tx, err := r.db.Master.BeginTx(ctx, nil)
+	if err != nil {
+		return uuid.Nil, fmt.Errorf("begin tx: %w", err)
+	}
+	defer database.Rollback(ctx, tx)
+
+	err = tx.QueryRowContext(ctx, `
+		INSERT INTO reconciliation_sessions (account_id, format, filename, workspace_id)
+		VALUES ($1, $2, $3, $4)
+		RETURNING id, created_at, updated_at;
+	`, s.AccountID, s.Format, s.Filename, tenant.ID(ctx)).Scan(&s.ID, &s.CreatedAt, &s.UpdatedAt)
+	if err != nil {
+		return uuid.Nil, fmt.Errorf("insert reconciliation session: %w", err)
+	}
+
+	for n := range s.Lines {
+		l := &s.Lines[n]
+		l.SessionID = s.ID
+		l.Status = model.LineUnmatched
+
+		err = tx.QueryRowContext(ctx, `
+			INSERT INTO reconciliation_lines (session_id, line_no, occurred_at, amount, currency, description, reference)
+			VALUES ($1, $2, $3, $4, $5, $6, $7)
+			RETURNING id, created_at, updated_at;
+		`, l.SessionID, l.LineNo, l.OccurredAt, l.Amount, l.Currency, l.Description, l.Reference,
+		).Scan(&l.ID, &l.CreatedAt, &l.UpdatedAt)
+		if err != nil {
+			return uuid.Nil, fmt.Errorf("insert reconciliation line %d: %w", l.LineNo, err)
+		}
+	}
+
+	if err = tx.Commit(); err != nil {
+		return uuid.Nil, fmt.Errorf("commit tx: %w", err)
+	}
+
+	return s.ID, nil
+}
+
+// GetSession retrieves a session by its ID together with its lines.
+func (r *Repository) GetSession(ctx context.Context, id uuid.UUID) (*model.ReconciliationSession, error) {
+	defer metrics.ObserveQuery("reconciliation", "GetSession", time.Now())
+
+	query := `
+		SELECT id, account_id, format, filename, created_at, updated_at
+		FROM reconciliation_sessions
+		WHERE id = $1
+		  AND workspace_id = $2;
+	`
+
+	var s model.ReconciliationSession
+	err := r.db.QueryRowContext(ctx, query, id, tenant.ID(ctx)).Scan(
+		&s.ID, &s.AccountID, &s.Format, &s.Filename, &s.CreatedAt, &s.UpdatedAt,
+	)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrSessionNotFound
+		}
+
+		return nil, fmt.Errorf("get reconciliation session: %w", err)
+	}
+
+	rows, err := r.db.QueryContext(ctx, `SELECT `+lineColumns+`
+		FROM reconciliation_lines
+		WHERE session_id = $1
+		ORDER BY line_no;
+	`, id)
+	if err != nil {
+		return nil, fmt.Errorf("list reconciliation lines: %w", err)
+	}
+
+	s.Lines, err = scanLines(rows)
+	if err != nil {
+		return nil, fmt.Errorf("list reconciliation lines: %w", err)
+	}
+
+	for _, l := range s.Lines {
+		countStatus(&s.Summary, l.Status)
+	}
+
+	return &s, nil
+}
+
+// ListSessions retrieves all sessions, newest first, with their line summaries.
+func (r *Repository) ListSessions(ctx context.Context) ([]model.ReconciliationSession, error) {
+	defer metrics.ObserveQuery("reconciliation", "ListSessions", time.Now())
+
+	query := `
+		SELECT s.id, s.account_id, s.format, s.filename, s.created_at, s.updated_at,
+		       COUNT(l.id),
+		       COUNT(l.id) FILTER (WHERE l.status = 'unmatched' OR l.item_id IS NULL),
+		       COUNT(l.id) FILTER (WHERE l.status = 'matched' AND l.item_id IS NOT NULL),
+		       COUNT(l.id) FILTER (WHERE l.status = 'confirmed' AND l.item_id IS NOT NULL),
+		       COUNT(l.id) FILTER (WHERE l.status = 'created' AND l.item_id IS NOT NULL)
+		FROM reconciliation_sessions s
+		LEFT JOIN reconciliation_lines l ON l.session_id = s.id
+		WHERE s.workspace_id = $1
+		GROUP BY s.id
+		ORDER BY s.created_at DESC, s.id;
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, tenant.ID(ctx))
+	if err != nil {
+		return nil, fmt.Errorf("list reconciliation sessions: %w", err)
+	}
+	defer rows.Close()
+
+	var sessions []model.ReconciliationSession
+	for rows.Next() {
+		var s model.ReconciliationSession
+		if err = rows.Scan(
+			&s.ID, &s.AccountID, &s.Format, &s.Filename, &s.CreatedAt, &s.UpdatedAt,
+			&s.Summary.Total, &s.Summary.Unmatched, &s.Summary.Matched, &s.Summary.Confirmed, &s.Summary.Created,
+		); err != nil {
+			return nil, fmt.Errorf("list reconciliation sessions: %w", err)
+		}
+
+		sessions = append(sessions, s)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("list reconciliation sessions: %w", err)
+	}
+
+	return sessions, nil
+}
+
+// GetLine retrieves a line of a session by its ID.
+func (r *Repository) GetLine(ctx context.Context, sessionID, id uuid.UUID) (*model.ReconciliationLine, error) {
+	defer metrics.ObserveQuery("reconciliation", "GetLine", time.Now())
+
+	rows, err := r.db.QueryContext(ctx, `SELECT `+lineColumns+`
+		FROM reconciliation_lines
+		WHERE id = $1
+		  AND session_id = $2
+		  AND EXISTS (
+		      SELECT 1
+		      FROM reconciliation_sessions s
+		      WHERE s.id = $2
+		        AND s.workspace_id = $3
+		  );
+	`, id, sessionID, tenant.ID(ctx))
+	if err != nil {
+		return nil, fmt.Errorf("get reconciliation line: %w", err)
+	}
+
+	lines, err := scanLines(rows)
+	if err != nil {
+		return nil, fmt.Errorf("get reconciliation line: %w", err)
+	}
+
+	if len(lines) == 0 {
+		return nil, ErrLineNotFound
+	}
+
+	return &lines[0], nil
+}
+
+// FindCandidate returns the best unreconciled item to match the line, or nil
+// if there is none. Candidates have the same absolute amount and currency,
+// a kind fitting the sign of the line, occurred at most window apart and,
+// if accountID is set, belong to that account. Items already matched to a
+// line are skipped. The closest date wins,
+// ties are broken by pg_trgm similarity of the title to the line description.
+func (r *Repository) FindCandidate(
+	ctx context.Context,
+	l *model.ReconciliationLine,
+	accountID *uuid.UUID,
+	window time.Duration,
+) (*uuid.UUID, error) {
+	defer metrics.ObserveQuery("reconciliation", "FindCandidate", time.Now())
+
+	query := `
+		SELECT i.id
+		FROM items i
+		WHERE i.workspace_id = $8
+		  AND i.amount = $1
+		  AND i.currency = $2
+		  AND i.occurred_at BETWEEN $3::timestamptz - $4 * INTERVAL '1 second'
+		                        AND $3::timestamptz + $4 * INTERVAL '1 second'
+		  AND ($5::uuid IS NULL OR i.account_id = $5)
+		  AND i.reconciled_at IS NULL
+		  AND CASE
+		          WHEN $6 THEN i.kind = 'income' OR (i.kind = 'transfer' AND i.transfer_leg = 'destination')
+		          ELSE i.kind IN ('expense', 'refund') OR (i.kind = 'transfer' AND i.transfer_leg = 'source')
+		      END
+		  AND NOT EXISTS (
+		      SELECT 1
+		      FROM reconciliation_lines l
+		      WHERE l.item_id = i.id
+		        AND l.status <> 'unmatched'
+		  )
+		ORDER BY abs(extract(EPOCH FROM i.occurred_at - $3::timestamptz)),
+		         similarity(i.title, $7) DESC,
+		         i.id
+		LIMIT 1;
+	`
+
+	var id uuid.UUID
+	err := r.db.QueryRowContext(ctx, query,
+		l.Amount.Abs(),
+		l.Currency,
+		l.OccurredAt,
+		window.Seconds(),
+		accountID,
+		l.Amount.IsPositive(),
+		l.Description,
+		tenant.ID(ctx),
+	).Scan(&id)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil
+		}
+
+		return nil, fmt.Errorf("find reconciliation candidate: %w", err)
+	}
+
+	return &id, nil
+}
+
+// MatchLine proposes an item for an unmatched line. It reports false if the
+// line was matched in the meantime.
+func (r *Repository) MatchLine(ctx context.Context, id, itemID uuid.UUID) (bool, error) {
+	defer metrics.ObserveQuery("reconciliation", "MatchLine", time.Now())
+
+	query := `
+		UPDATE reconciliation_lines
+		SET status = 'matched',
+		    item_id = $2
+		WHERE id = $1
+		  AND (status = 'unmatched' OR item_id IS NULL);
+	`
+
+	res, err := r.db.ExecContext(ctx, query, id, itemID)
+	if err != nil {
+		return false, fmt.Errorf("match reconciliation line: %w", err)
+	}
+
+	n, err := res.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("match reconciliation line: %w", err)
+	}
+
+	return n > 0, nil
+}
+
+// SetLineItem sets the item and status of a line and marks the item as
+// reconciled in a single transaction. The item must not be reconciled or
+// matched to another line.
+func (r *Repository) SetLineItem(ctx context.Context, id, itemID uuid.UUID, status string) error {
+	defer metrics.ObserveQuery("reconciliation", "SetLineItem", time.Now())
+
+	tx, err := r.db.Master.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("begin tx: %w", err)
+	}
+	defer database.Rollback(ctx, tx)
+
+	// An item reconciled through this very line, e.g. when confirming a
+	// created item again, is not considered taken.
+	var taken bool
+	err = tx.QueryRowContext(ctx, `
+		SELECT (reconciled_at IS NOT NULL AND NOT EXISTS (
+		           SELECT 1
+		           FROM reconciliation_lines l
+		           WHERE l.id = $2
+		             AND l.item_id = items.id
+		       ))
+		       OR EXISTS (
+		           SELECT 1
+		           FROM reconciliation_lines l
+		           WHERE l.item_id = items.id
+		             AND l.id <> $2
+		             AND l.status <> 'unmatched'
+		       )
+		FROM items
+		WHERE id = $1
+		  AND workspace_id = $3
+		FOR UPDATE;
+	`, itemID, id, tenant.ID(ctx)).Scan(&taken)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return ErrItemNotFound
+		}
+
+		return fmt.Errorf("lock item: %w", err)
+	}
+
+	if taken {
+		return ErrItemReconciled
+	}
+
+	res, err := tx.ExecContext(ctx, `
+		UPDATE reconciliation_lines
+		SET status = $2,
+		    item_id = $3
+		WHERE id = $1;
+	`, id, status, itemID)
+	if err != nil {
+		return fmt.Errorf("update reconciliation line: %w", err)
+	}
+
+	n, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("update reconciliation line: %w", err)
+	}
+
+	if n == 0 {
+		return ErrLineNotFound
+	}
+
+	_, err = tx.ExecContext(ctx, `
+		UPDATE items
+		SET reconciled_at = COALESCE(reconciled_at, NOW())
+		WHERE id = $1;
+	`, itemID)
+	if err != nil {
+		return fmt.Errorf("reconcile item: %w", err)
+	}
+
+	if err = tx.Commit(); err != nil {
+		return fmt.Errorf("commit tx: %w", err)
+	}
+
+	return nil
+}
+
+// UnmatchLine resets a line to unmatched and, if its item was reconciled
+// through the line, clears the item's reconciled_at in a single transaction.
+func (r *Repository) UnmatchLine(ctx context.Context, id uuid.UUID) error {
+	defer metrics.ObserveQuery("reconciliation", "UnmatchLine", time.Now())
+
+	tx, err := r.db.Master.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("begin tx: %w", err)
+	}
+	defer database.Rollback(ctx, tx)
+
+	var (
+		status string
+		itemID *uuid.UUID
+	)
+	err = tx.QueryRowContext(ctx, `
+		SELECT l.status, l.item_id
+		FROM reconciliation_lines l
+		JOIN reconciliation_sessions s ON s.id = l.session_id
+		WHERE l.id = $1
+		  AND s.workspace_id = $2
+		FOR UPDATE OF l;
+	`, id, tenant.ID(ctx)).Scan(&status, &itemID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return ErrLineNotFound
+		}
+
+		return fmt.Errorf("lock reconciliation line: %w", err)
+	}
+
+	if itemID != nil && (status == model.LineConfirmed || status == model.LineCreated) {
+		_, err = tx.ExecContext(ctx, `
+			UPDATE items
+			SET reconciled_at = NULL
+			WHERE id = $1;
+		`, *itemID)
+		if err != nil {
+			return fmt.Errorf("unreconcile item: %w", err)
+		}
+	}
+
+	_, err = tx.ExecContext(ctx, `
+		UPDATE reconciliation_lines
+		SET status = 'unmatched',
+		    item_id = NULL
+		WHERE id = $1;
+	`, id)
+	if err != nil {
+		return fmt.Errorf("update reconciliation line: %w", err)
+	}
+
+	if err = tx.Commit(); err != nil {
+		return fmt.Errorf("commit tx: %w", err)
+	}
+
+	return nil
+}
+
+// countStatus counts a line status in the summary.
+func countStatus(s *model.ReconciliationSummary, status string) {
+	s.Total++
+	switch status {
+	case model.LineUnmatched:
+		s.Unmatched++
+	case model.LineMatched:
+		s.Matched++
+	case model.LineConfirmed:
+		s.Confirmed++
+	case model.LineCreated:
+		s.Created++
+	}
+}
+
+// scanLines scans reconciliation lines and closes rows. Lines whose item was
+// deleted are reported as unmatched.
+func scanLines(rows *sql.Rows) ([]model.ReconciliationLine, error) {
+	defer rows.Close()
+
+	var lines []model.ReconciliationLine
+	for rows.Next() {
+		var l model.ReconciliationLine
+		if err := rows.Scan(
+			&l.ID, &l.SessionID, &l.LineNo, &l.OccurredAt, &l.Amount, &l.Currency,
+			&l.Description, &l.Reference, &l.Status, &l.ItemID, &l.CreatedAt, &l.UpdatedAt,
+		); err != nil {
+			return nil, err
+		}
+
+		if l.ItemID == nil {
+			l.Status = model.LineUnmatched
+		}
+
+		lines = append(lines, l)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return lines, nil
+}
@@ -0,0 +1,424 @@
+package recurring
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/aliskhannn/sales-tracker/internal/database"
+	"github.com/aliskhannn/sales-tracker/internal/metrics"
+	"github.com/aliskhannn/sales-tracker/internal/model"
+	"github.com/aliskhannn/sales-tracker/internal/tenant"
+)
+
+var (
+	ErrRecurringItemNotFound = errors.New("recurring item not found")
+	ErrOccurrenceHandled     = errors.New("occurrence already handled")
+	ErrOccurrenceClaimed     = errors.New("occurrence claimed by another worker")
+)
+
+// Repository provides methods to interact with recurring items.
+type Repository struct {
+	db *database.DB
+}
+
+// NewRepository creates a new recurring item repository.
+func NewRepository(db *database.DB) *Repository {
+	return &Repository{db: db}
+}
+
+// Create adds a new recurring item to the database.
+func (r *Repository) Create(ctx context.Context, ri *model.RecurringItem) (uuid.UUID, error) {
+	defer metrics.ObserveQuery("recurring", "Create", time.Now())
+
+	query := `
+		INSERT INTO recurring_items (
+		    kind, title, amount, currency, category_id, metadata,
+		    rule, start_at, end_at, paused, next_index, next_run_at, workspace_id
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13)
+		RETURNING id;
+	`
+
+	err := r.db.Master.QueryRowContext(ctx, query,
+		ri.Kind, ri.Title, ri.Amount, ri.Currency, ri.CategoryID, ri.Metadata,
+		ri.Rule, ri.StartAt, ri.EndAt, ri.Paused, ri.NextIndex, ri.NextRunAt, tenant.ID(ctx),
+	).Scan(&ri.ID)
+	if err != nil {
+		return uuid.Nil, fmt.Errorf("insert recurring item: %w", err)
+	}
+
+	return ri.ID, nil
+}
+
+// GetByID retrieves a recurring item by its ID.
+func (r *Repository) GetByID(ctx context.Context, id uuid.UUID) (*model.RecurringItem, error) {
+	defer metrics.ObserveQuery("recurring", "GetByID", time.Now())
+
+	query := `
+		SELECT id, kind, title, amount, currency, category_id, metadata,
+		       rule, start_at, end_at, paused, next_index, next_run_at, created_at, updated_at
+		FROM recurring_items
+		WHERE id = $1
+		  AND workspace_id = $2;
+	`
+
+	var ri model.RecurringItem
+	err := r.db.Master.QueryRowContext(ctx, query, id, tenant.ID(ctx)).Scan(
+		&ri.ID, &ri.Kind, &ri.Title, &ri.Amount, &ri.Currency, &ri.CategoryID, &ri.Metadata,
+		&ri.Rule, &ri.StartAt, &ri.EndAt, &ri.Paused, &ri.NextIndex, &ri.NextRunAt, &ri.CreatedAt, &ri.UpdatedAt,
+	)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrRecurringItemNotFound
+		}
+
+		return nil, fmt.Errorf("get recurring item: %w", err)
+	}
+
+	return &ri, nil
+}
+
+// List retrieves all recurring items from the database.
+func (r *Repository) List(ctx context.Context) ([]model.RecurringItem, error) {
+	defer metrics.ObserveQuery("recurring", "List", time.Now())
+
+	query := `
+		SELECT id, kind, title, amount, currency, category_id, metadata,
+		       rule, start_at, end_at, paused, next_index, next_run_at, created_at, updated_at
+		FROM recurring_items
+		WHERE workspace_id = $1
+		ORDER BY created_at;
+	`
+
+	return r.list(ctx, query, tenant.ID(ctx))
+}
+
+// ListDue retrieves active recurring items of the context workspace whose next
+// occurrence is not after now.
+func (r *Repository) ListDue(ctx context.Context, now time.Time) ([]model.RecurringItem, error) {
+	defer metrics.ObserveQuery("recurring", "ListDue", time.Now())
+
+	query := `
+		SELECT id, kind, title, amount, currency, category_id, metadata,
+		       rule, start_at, end_at, paused, next_index, next_run_at, created_at, updated_at
+		FROM recurring_items
+		WHERE NOT paused
+		  AND next_run_at IS NOT NULL
+		  AND next_run_at <= $1
+		  AND workspace_id = $2
+		ORDER BY next_run_at;
+	`
+
+	return r.list(ctx, query, now, tenant.ID(ctx))
+}
+
+// list runs a recurring item select query on master and scans the result.
+func (r *Repository) list(ctx context.Context, query string, args ...interface{}) ([]model.RecurringItem, error) {
+	rows, err := r.db.Master.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("list recurring items: %w", err)
+	}
+	defer rows.Close()
+
+	var items []model.RecurringItem
+	for rows.Next() {
+		var ri model.RecurringItem
+		if err = rows.Scan(
+			&ri.ID, &ri.Kind, &ri.Title, &ri.Amount, &ri.Currency, &ri.CategoryID, &ri.Metadata,
+			&ri.Rule, &ri.StartAt, &ri.EndAt, &ri.Paused, &ri.NextIndex, &ri.NextRunAt, &ri.CreatedAt, &ri.UpdatedAt,
+		); err != nil {
+			return nil, fmt.Errorf("list recurring items: %w", err)
+		}
+
+		items = append(items, ri)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("list recurring items: %w", err)
+	}
+
+	return items, nil
+}
+
+// Update updates a recurring item including its schedule state.
+func (r *Repository) Update(ctx context.Context, ri *model.RecurringItem) error {
+	defer metrics.ObserveQuery("recurring", "Update", time.Now())
+
+	query := `
+		UPDATE recurring_items
+		SET kind = $1,
+		    title = $2,
+		    amount = $3,
+		    currency = $4,
+		    category_id = $5,
+		    metadata = $6,
+		    rule = $7,
+		    start_at = $8,
+		    end_at = $9,
+		    paused = $10,
+		    next_index = $11,
+		    next_run_at = $12,
+		    updated_at = NOW()
+		WHERE id = $13
+		  AND workspace_id = $14;
+	`
+
+	res, err := r.db.ExecContext(ctx, query,
+		ri.Kind, ri.Title, ri.Amount, ri.Currency, ri.CategoryID, ri.Metadata,
+		ri.Rule, ri.StartAt, ri.EndAt, ri.Paused, ri.NextIndex, ri.NextRunAt,
+		ri.ID, tenant.ID(ctx),
+	)
+	if err != nil {
+		return fmt.Errorf("update recurring item: %w", err)
+	}
+
+	n, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("check rows affected: %w", err)
+	}
+
+	if n == 0 {
+		return ErrRecurringItemNotFound
+	}
+
+	return nil
+}
+
+// Advance moves the schedule of a recurring item to the given occurrence.
+// nextRunAt is nil when the schedule is exhausted.
+func (r *Repository) Advance(ctx context.Context, id uuid.UUID, nextIndex int, nextRunAt *time.Time) error {
+	defer metrics.ObserveQuery("recurring", "Advance", time.Now())
+
+	query := `
+		UPDATE recurring_items
+		SET next_index = $1,
+		    next_run_at = $2
+		WHERE id = $3
+		  AND workspace_id = $4;
+	`
+
+	res, err := r.db.ExecContext(ctx, query, nextIndex, nextRunAt, id, tenant.ID(ctx))
+	if err != nil {
+		return fmt.Errorf("advance recurring item: %w", err)
+	}
+
+	n, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("check rows affected: %w", err)
+	}
+
+	if n == 0 {
+		return ErrRecurringItemNotFound
+	}
+
+	return nil
+}
+
+// Delete removes a recurring item from the database.
+// Occurrence records are removed by cascade, materialized items are kept.
+func (r *Repository) Delete(ctx context.Context, id uuid.UUID) error {
+	defer metrics.ObserveQuery("recurring", "Delete", time.Now())
+
+	query := `
+		DELETE FROM recurring_items
+		WHERE id = $1
+		  AND workspace_id = $2;
+	`
+
+	res, err := r.db.ExecContext(ctx, query, id, tenant.ID(ctx))
+	if err != nil {
+		return fmt.Errorf("delete recurring item: %w", err)
+	}
+
+	n, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("check rows affected: %w", err)
+	}
+
+	if n == 0 {
+		return ErrRecurringItemNotFound
+	}
+
+	return nil
+}
+
+// ClaimOccurrence records a pending occurrence claimed for lease. A pending
+// occurrence whose claim is older than lease was left by a stopped worker and
+// is taken over, in which case it returns true. It returns ErrOccurrenceClaimed
+// if the occurrence is pending within its lease and ErrOccurrenceHandled if it
+// has already been created or skipped.
+func (r *Repository) ClaimOccurrence(ctx context.Context, id uuid.UUID, at time.Time, lease time.Duration) (bool, error) {
+	defer metrics.ObserveQuery("recurring", "ClaimOccurrence", time.Now())
+
+	query := `
+		INSERT INTO recurring_item_occurrences (recurring_item_id, occurrence_at, status)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (recurring_item_id, occurrence_at) DO UPDATE
+		SET claimed_at = now()
+		WHERE recurring_item_occurrences.status = $3
+		  AND recurring_item_occurrences.claimed_at < now() - $4 * INTERVAL '1 second'
+		RETURNING xmax <> 0;
+	`
+
+	var takenOver bool
+	err := r.db.Master.QueryRowContext(ctx, query, id, at, model.OccurrencePending, lease.Seconds()).Scan(&takenOver)
+	if err == nil {
+		return takenOver, nil
+	}
+	if !errors.Is(err, sql.ErrNoRows) {
+		return false, fmt.Errorf("claim occurrence: %w", err)
+	}
+
+	var status string
+	query = `
+		SELECT status
+		FROM recurring_item_occurrences
+		WHERE recurring_item_id = $1
+		  AND occurrence_at = $2;
+	`
+
+	if err = r.db.Master.QueryRowContext(ctx, query, id, at).Scan(&status); err != nil {
+		return false, fmt.Errorf("claim occurrence: %w", err)
+	}
+
+	if status == model.OccurrencePending {
+		return false, ErrOccurrenceClaimed
+	}
+
+	return false, ErrOccurrenceHandled
+}
+
+// FindOccurrenceItem retrieves the ID of the item materialized for an
+// occurrence, or returns uuid.Nil if there is none. It finds items created
+// under a claim that was not completed.
+func (r *Repository) FindOccurrenceItem(ctx context.Context, id uuid.UUID, at time.Time) (uuid.UUID, error) {
+	defer metrics.ObserveQuery("recurring", "FindOccurrenceItem", time.Now())
+
+	query := `
+		SELECT id
+		FROM items
+		WHERE metadata ? 'recurring_item_id'
+		  AND metadata ->> 'recurring_item_id' = $1::text
+		  AND occurred_at = $2
+		  AND workspace_id = $3
+		ORDER BY created_at
+		LIMIT 1;
+	`
+
+	var itemID uuid.UUID
+	err := r.db.Master.QueryRowContext(ctx, query, id, at, tenant.ID(ctx)).Scan(&itemID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return uuid.Nil, nil
+		}
+
+		return uuid.Nil, fmt.Errorf("find occurrence item: %w", err)
+	}
+
+	return itemID, nil
+}
+
+// SkipOccurrence records a skipped occurrence. It returns ErrOccurrenceHandled
+// if the occurrence has already been handled.
+func (r *Repository) SkipOccurrence(ctx context.Context, id uuid.UUID, at time.Time) error {
+	defer metrics.ObserveQuery("recurring", "SkipOccurrence", time.Now())
+
+	query := `
+		INSERT INTO recurring_item_occurrences (recurring_item_id, occurrence_at, status)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (recurring_item_id, occurrence_at) DO NOTHING;
+	`
+
+	res, err := r.db.ExecContext(ctx, query, id, at, model.OccurrenceSkipped)
+	if err != nil {
+		return fmt.Errorf("skip occurrence: %w", err)
+	}
+
+	n, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("check rows affected: %w", err)
+	}
+
+	if n == 0 {
+		return ErrOccurrenceHandled
+	}
+
+	return nil
+}
+
+// CompleteOccurrence marks a pending occurrence as created by the given item.
+func (r *Repository) CompleteOccurrence(ctx context.Context, id uuid.UUID, at time.Time, itemID uuid.UUID) error {
+	defer metrics.ObserveQuery("recurring", "CompleteOccurrence", time.Now())
+
+	query := `
+		UPDATE recurring_item_occurrences
+		SET status = $1,
+		    item_id = $2
+		WHERE recurring_item_id = $3
+		  AND occurrence_at = $4;
+	`
+
+	if _, err := r.db.ExecContext(ctx, query, model.OccurrenceCreated, itemID, id, at); err != nil {
+		return fmt.Errorf("complete occurrence: %w", err)
+	}
+
+	return nil
+}
+
+// ReleaseOccurrence removes a pending occurrence so it can be retried later.
+func (r *Repository) ReleaseOccurrence(ctx context.Context, id uuid.UUID, at time.Time) error {
+	defer metrics.ObserveQuery("recurring", "ReleaseOccurrence", time.Now())
+
+	query := `
+		DELETE FROM recurring_item_occurrences
+		WHERE recurring_item_id = $1
+		  AND occurrence_at = $2
+		  AND status = $3;
+	`
+
+	if _, err := r.db.ExecContext(ctx, query, id, at, model.OccurrencePending); err != nil {
+		return fmt.Errorf("release occurrence: %w", err)
+	}
+
+	return nil
+}
+
+// ListOccurrences retrieves handled occurrences of a recurring item, newest first.
+func (r *Repository) ListOccurrences(ctx context.Context, id uuid.UUID) ([]model.RecurringOccurrence, error) {
+	defer metrics.ObserveQuery("recurring", "ListOccurrences", time.Now())
+
+	query := `
+		SELECT o.recurring_item_id, o.occurrence_at, o.status, o.item_id, o.created_at
+		FROM recurring_item_occurrences o
+		JOIN recurring_items ri ON ri.id = o.recurring_item_id
+		WHERE o.recurring_item_id = $1
+		  AND ri.workspace_id = $2
+		ORDER BY o.occurrence_at DESC;
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, id, tenant.ID(ctx))
+	if err != nil {
+		return nil, fmt.Errorf("list occurrences: %w", err)
+	}
+	defer rows.Close()
+
+	var occurrences []model.RecurringOccurrence
+	for rows.Next() {
+		var o model.RecurringOccurrence
+		if err = rows.Scan(&o.RecurringItemID, &o.OccurrenceAt, &o.Status, &o.ItemID, &o.CreatedAt); err != nil {
+			return nil, fmt.Errorf("list occurrences: %w", err)
+		}
+
+		occurrences = append(occurrences, o)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("list occurrences: %w", err)
+	}
+
+	return occurrences, nil
+}
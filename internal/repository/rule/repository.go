@@ -0,0 +1,237 @@
+package rule
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/aliskhannn/sales-tracker/internal/database"
+	"github.com/aliskhannn/sales-tracker/internal/metrics"
+	"github.com/aliskhannn/sales-tracker/internal/model"
+	"github.com/aliskhannn/sales-tracker/internal/tenant"
+)
+
+var (
+	ErrRuleNotFound = errors.New("rule not found")
+)
+
+// Repository provides methods to interact with rules.
+type Repository struct {
+	db *database.DB
+}
+
+// NewRepository creates a new rule repository.
+func NewRepository(db *database.DB) *Repository {
+	return &Repository{db: db}
+}
+
+// Create adds a new rule to the database.
+func (r *Repository) Create(ctx context.Context, rule *model.Rule) (uuid.UUID, error) {
+	defer metrics.ObserveQuery("rule", "Create", time.Now())
+
+	conditions, actions, err := marshalRule(rule)
+	if err != nil {
+		return uuid.Nil, fmt.Errorf("insert rule: %w", err)
+	}
+
+	query := `
+		INSERT INTO rules (name, position, enabled, stop_processing, conditions, actions, workspace_id)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		RETURNING id;
+	`
+
+	err = r.db.Master.QueryRowContext(ctx, query,
+		rule.Name, rule.Position, rule.Enabled, rule.StopProcessing, conditions, actions, tenant.ID(ctx),
+	).Scan(&rule.ID)
+	if err != nil {
+		return uuid.Nil, fmt.Errorf("insert rule: %w", err)
+	}
+
+	return rule.ID, nil
+}
+
+// GetByID retrieves a rule by its ID.
+func (r *Repository) GetByID(ctx context.Context, id uuid.UUID) (*model.Rule, error) {
+	defer metrics.ObserveQuery("rule", "GetByID", time.Now())
+
+	query := `
+		SELECT id, name, position, enabled, stop_processing, conditions, actions, created_at, updated_at
+		FROM rules
+		WHERE id = $1
+		  AND workspace_id = $2;
+	`
+
+	rule, err := scanRule(r.db.QueryRowContext(ctx, query, id, tenant.ID(ctx)))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrRuleNotFound
+		}
+
+		return nil, fmt.Errorf("get rule: %w", err)
+	}
+
+	return rule, nil
+}
+
+// List retrieves rules in evaluation order. If enabledOnly is true,
+// disabled rules are omitted.
+func (r *Repository) List(ctx context.Context, enabledOnly bool) ([]model.Rule, error) {
+	defer metrics.ObserveQuery("rule", "List", time.Now())
+
+	query := `
+		SELECT id, name, position, enabled, stop_processing, conditions, actions, created_at, updated_at
+		FROM rules
+		WHERE workspace_id = $2
+		  AND (NOT $1 OR enabled)
+		ORDER BY position, created_at;
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, enabledOnly, tenant.ID(ctx))
+	if err != nil {
+		return nil, fmt.Errorf("list rules: %w", err)
+	}
+	defer rows.Close()
+
+	var rules []model.Rule
+	for rows.Next() {
+		rule, err := scanRule(rows)
+		if err != nil {
+			return nil, fmt.Errorf("list rules: %w", err)
+		}
+
+		rules = append(rules, *rule)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("list rules: %w", err)
+	}
+
+	return rules, nil
+}
+
+// Update updates a rule.
+func (r *Repository) Update(ctx context.Context, rule *model.Rule) error {
+	defer metrics.ObserveQuery("rule", "Update", time.Now())
+
+	conditions, actions, err := marshalRule(rule)
+	if err != nil {
+		return fmt.Errorf("update rule: %w", err)
+	}
+
+	query := `
+		UPDATE rules
+		SET name = $1,
+		    position = $2,
+		    enabled = $3,
+		    stop_processing = $4,
+		    conditions = $5,
+		    actions = $6
+		WHERE id = $7
+		  AND workspace_id = $8;
+	`
+
+	res, err := r.db.ExecContext(ctx, query,
+		rule.Name, rule.Position, rule.Enabled, rule.StopProcessing, conditions, actions, rule.ID, tenant.ID(ctx),
+	)
+	if err != nil {
+		return fmt.Errorf("update rule: %w", err)
+	}
+
+	n, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("check rows affected: %w", err)
+	}
+
+	if n == 0 {
+		return ErrRuleNotFound
+	}
+
+	return nil
+}
+
+// Delete removes a rule from the database.
+func (r *Repository) Delete(ctx context.Context, id uuid.UUID) error {
+	defer metrics.ObserveQuery("rule", "Delete", time.Now())
+
+	query := `
+		DELETE FROM rules
+		WHERE id = $1
+		  AND workspace_id = $2;
+	`
+
+	res, err := r.db.ExecContext(ctx, query, id, tenant.ID(ctx))
+	if err != nil {
+		return fmt.Errorf("delete rule: %w", err)
+	}
+
+	n, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("check rows affected: %w", err)
+	}
+
+	if n == 0 {
+		return ErrRuleNotFound
+	}
+
+	return nil
+}
+
+// scanner is implemented by *sql.Row and *sql.Rows.
+type scanner interface {
+	Scan(dest ...any) error
+}
+
+// scanRule scans a rule row and decodes its conditions and actions.
+func scanRule(s scanner) (*model.Rule, error) {
+	var (
+		rule                model.Rule
+		conditions, actions []byte
+	)
+
+	if err := s.Scan(
+		&rule.ID, &rule.Name, &rule.Position, &rule.Enabled, &rule.StopProcessing,
+		&conditions, &actions, &rule.CreatedAt, &rule.UpdatedAt,
+	); err != nil {
+		return nil, err
+	}
+
+	if err := json.Unmarshal(conditions, &rule.Conditions); err != nil {
+		return nil, fmt.Errorf("decode conditions: %w", err)
+	}
+
+	if err := json.Unmarshal(actions, &rule.Actions); err != nil {
+		return nil, fmt.Errorf("decode actions: %w", err)
+	}
+
+	return &rule, nil
+}
+
+// marshalRule encodes rule conditions and actions as JSON arrays.
+func marshalRule(rule *model.Rule) ([]byte, []byte, error) {
+	conditions := rule.Conditions
+	if conditions == nil {
+		conditions = []model.RuleCondition{}
+	}
+
+	actions := rule.Actions
+	if actions == nil {
+		actions = []model.RuleAction{}
+	}
+
+	c, err := json.Marshal(conditions)
+	if err != nil {
+		return nil, nil, fmt.Errorf("encode conditions: %w", err)
+	}
+
+	a, err := json.Marshal(actions)
+	if err != nil {
+		return nil, nil, fmt.Errorf("encode actions: %w", err)
+	}
+
+	return c, a, nil
+}
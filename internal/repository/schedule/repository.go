@@ -0,0 +1,435 @@
+package schedule
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+
+	"github.com/aliskhannn/sales-tracker/internal/database"
+	"github.com/aliskhannn/sales-tracker/internal/metrics"
+	"github.com/aliskhannn/sales-tracker/internal/model"
+	"github.com/aliskhannn/sales-tracker/internal/tenant"
+)
+
+var ErrScheduledReportNotFound = errors.New("scheduled report not found")
+
+// reportColumns lists the scheduled_reports columns in the order scanned by scanReport.
+const reportColumns = `
+	id, workspace_id, name, spec, range, rule, start_at, recipients, enabled,
+	next_index, next_run_at, attempts, retry_at, created_at, updated_at
+`
+
+// runColumns lists the report_runs columns in the order scanned by scanRun.
+const runColumns = `
+	id, report_id, occurrence_at, period_from, period_to, status, attempt,
+	recipients, error, started_at, finished_at
+`
+
+// Repository provides methods to interact with scheduled reports and their runs.
+type Repository struct {
+	db *database.DB
+}
+
+// NewRepository creates a new scheduled report repository.
+func NewRepository(db *database.DB) *Repository {
+	return &Repository{db: db}
+}
+
+// Create adds a new scheduled report to the database.
+func (r *Repository) Create(ctx context.Context, sr *model.ScheduledReport) (uuid.UUID, error) {
+	defer metrics.ObserveQuery("schedule", "Create", time.Now())
+
+	spec, err := json.Marshal(sr.Spec)
+	if err != nil {
+		return uuid.Nil, fmt.Errorf("encode spec: %w", err)
+	}
+
+	query := `
+		INSERT INTO scheduled_reports (
+		    name, spec, range, rule, start_at, recipients, enabled, next_index, next_run_at, workspace_id
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+		RETURNING id, workspace_id, created_at, updated_at;
+	`
+
+	err = r.db.Master.QueryRowContext(ctx, query,
+		sr.Name, spec, sr.Range, sr.Rule, sr.StartAt, pq.Array(sr.Recipients), sr.Enabled,
+		sr.NextIndex, sr.NextRunAt, tenant.ID(ctx),
+	).Scan(&sr.ID, &sr.WorkspaceID, &sr.CreatedAt, &sr.UpdatedAt)
+	if err != nil {
+		return uuid.Nil, fmt.Errorf("insert scheduled report: %w", err)
+	}
+
+	return sr.ID, nil
+}
+
+// GetByID retrieves a scheduled report by its ID.
+func (r *Repository) GetByID(ctx context.Context, id uuid.UUID) (*model.ScheduledReport, error) {
+	defer metrics.ObserveQuery("schedule", "GetByID", time.Now())
+
+	query := `SELECT ` + reportColumns + `
+		FROM scheduled_reports
+		WHERE id = $1
+		  AND workspace_id = $2;
+	`
+
+	sr, err := scanReport(r.db.Master.QueryRowContext(ctx, query, id, tenant.ID(ctx)))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrScheduledReportNotFound
+		}
+
+		return nil, fmt.Errorf("get scheduled report: %w", err)
+	}
+
+	return sr, nil
+}
+
+// List retrieves all scheduled reports of the context workspace.
+func (r *Repository) List(ctx context.Context) ([]model.ScheduledReport, error) {
+	defer metrics.ObserveQuery("schedule", "List", time.Now())
+
+	query := `SELECT ` + reportColumns + `
+		FROM scheduled_reports
+		WHERE workspace_id = $1
+		ORDER BY created_at;
+	`
+
+	rows, err := r.db.Master.QueryContext(ctx, query, tenant.ID(ctx))
+	if err != nil {
+		return nil, fmt.Errorf("list scheduled reports: %w", err)
+	}
+	defer rows.Close()
+
+	var reports []model.ScheduledReport
+	for rows.Next() {
+		sr, err := scanReport(rows)
+		if err != nil {
+			return nil, fmt.Errorf("list scheduled reports: %w", err)
+		}
+
+		reports = append(reports, *sr)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("list scheduled reports: %w", err)
+	}
+
+	return reports, nil
+}
+
+// Update updates a scheduled report including its schedule state. A pending
+// retry is dropped, since the schedule restarts.
+func (r *Repository) Update(ctx context.Context, sr *model.ScheduledReport) error {
+	defer metrics.ObserveQuery("schedule", "Update", time.Now())
+
+	spec, err := json.Marshal(sr.Spec)
+	if err != nil {
+		return fmt.Errorf("encode spec: %w", err)
+	}
+
+	query := `
+		UPDATE scheduled_reports
+		SET name = $1,
+		    spec = $2,
+		    range = $3,
+		    rule = $4,
+		    start_at = $5,
+		    recipients = $6,
+		    enabled = $7,
+		    next_index = $8,
+		    next_run_at = $9,
+		    attempts = 0,
+		    retry_at = NULL
+		WHERE id = $10
+		  AND workspace_id = $11
+		RETURNING updated_at;
+	`
+
+	err = r.db.Master.QueryRowContext(ctx, query,
+		sr.Name, spec, sr.Range, sr.Rule, sr.StartAt, pq.Array(sr.Recipients), sr.Enabled,
+		sr.NextIndex, sr.NextRunAt, sr.ID, tenant.ID(ctx),
+	).Scan(&sr.UpdatedAt)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return ErrScheduledReportNotFound
+		}
+
+		return fmt.Errorf("update scheduled report: %w", err)
+	}
+
+	sr.Attempts, sr.RetryAt = 0, nil
+
+	return nil
+}
+
+// Delete removes a scheduled report and its run history from the database.
+func (r *Repository) Delete(ctx context.Context, id uuid.UUID) error {
+	defer metrics.ObserveQuery("schedule", "Delete", time.Now())
+
+	query := `
+		DELETE FROM scheduled_reports
+		WHERE id = $1
+		  AND workspace_id = $2;
+	`
+
+	res, err := r.db.Master.ExecContext(ctx, query, id, tenant.ID(ctx))
+	if err != nil {
+		return fmt.Errorf("delete scheduled report: %w", err)
+	}
+
+	n, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("check rows affected: %w", err)
+	}
+
+	if n == 0 {
+		return ErrScheduledReportNotFound
+	}
+
+	return nil
+}
+
+// ListRuns retrieves the latest runs of a scheduled report, newest first.
+func (r *Repository) ListRuns(ctx context.Context, id uuid.UUID, limit int) ([]model.ReportRun, error) {
+	defer metrics.ObserveQuery("schedule", "ListRuns", time.Now())
+
+	query := `SELECT ` + runColumns + `
+		FROM report_runs
+		WHERE report_id = $1
+		  AND EXISTS (SELECT 1 FROM scheduled_reports sr WHERE sr.id = $1 AND sr.workspace_id = $2)
+		ORDER BY started_at DESC, id
+		LIMIT $3;
+	`
+
+	rows, err := r.db.Master.QueryContext(ctx, query, id, tenant.ID(ctx), limit)
+	if err != nil {
+		return nil, fmt.Errorf("list report runs: %w", err)
+	}
+	defer rows.Close()
+
+	var runs []model.ReportRun
+	for rows.Next() {
+		run, err := scanRun(rows)
+		if err != nil {
+			return nil, fmt.Errorf("list report runs: %w", err)
+		}
+
+		runs = append(runs, *run)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("list report runs: %w", err)
+	}
+
+	return runs, nil
+}
+
+// ClaimDue locks an enabled scheduled report of any workspace whose next
+// occurrence or retry is due and that is not locked by another scheduler
+// until lease from now, and returns it. Returns nil if no report is due.
+func (r *Repository) ClaimDue(ctx context.Context, lease time.Duration) (*model.ScheduledReport, error) {
+	defer metrics.ObserveQuery("schedule", "ClaimDue", time.Now())
+
+	query := `
+		WITH next AS (
+			SELECT id
+			FROM scheduled_reports
+			WHERE enabled
+			  AND COALESCE(retry_at, next_run_at) <= now()
+			  AND (locked_until IS NULL OR locked_until < now())
+			ORDER BY COALESCE(retry_at, next_run_at)
+			LIMIT 1
+			FOR UPDATE SKIP LOCKED
+		)
+		UPDATE scheduled_reports sr
+		SET locked_until = now() + $1 * INTERVAL '1 second'
+		FROM next
+		WHERE sr.id = next.id
+		RETURNING sr.id, sr.workspace_id, sr.name, sr.spec, sr.range, sr.rule, sr.start_at, sr.recipients,
+		          sr.enabled, sr.next_index, sr.next_run_at, sr.attempts, sr.retry_at, sr.created_at, sr.updated_at;
+	`
+
+	sr, err := scanReport(r.db.Master.QueryRowContext(ctx, query, lease.Seconds()))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil
+		}
+
+		return nil, fmt.Errorf("claim scheduled report: %w", err)
+	}
+
+	return sr, nil
+}
+
+// Advance moves the schedule of a claimed report to the given occurrence,
+// clears its failed attempts and unlocks it. nextRunAt is nil when the
+// schedule is exhausted. It does nothing if the report was updated since
+// updatedAt, since the update already moved its schedule.
+func (r *Repository) Advance(ctx context.Context, id uuid.UUID, updatedAt time.Time, nextIndex int, nextRunAt *time.Time) error {
+	defer metrics.ObserveQuery("schedule", "Advance", time.Now())
+
+	query := `
+		UPDATE scheduled_reports
+		SET next_index = CASE WHEN updated_at = $2 THEN $3 ELSE next_index END,
+		    next_run_at = CASE WHEN updated_at = $2 THEN $4 ELSE next_run_at END,
+		    attempts = CASE WHEN updated_at = $2 THEN 0 ELSE attempts END,
+		    retry_at = CASE WHEN updated_at = $2 THEN NULL ELSE retry_at END,
+		    locked_until = NULL
+		WHERE id = $1;
+	`
+
+	if _, err := r.db.Master.ExecContext(ctx, query, id, updatedAt, nextIndex, nextRunAt); err != nil {
+		return fmt.Errorf("advance scheduled report: %w", err)
+	}
+
+	return nil
+}
+
+// Retry records a failed attempt at an occurrence of a claimed report,
+// schedules the next attempt at retryAt and unlocks the report. Like Advance
+// it leaves the schedule alone if the report was updated since updatedAt.
+func (r *Repository) Retry(ctx context.Context, id uuid.UUID, updatedAt time.Time, index int, occurrence time.Time, attempts int, retryAt time.Time) error {
+	defer metrics.ObserveQuery("schedule", "Retry", time.Now())
+
+	query := `
+		UPDATE scheduled_reports
+		SET next_index = CASE WHEN updated_at = $2 THEN $3 ELSE next_index END,
+		    next_run_at = CASE WHEN updated_at = $2 THEN $4 ELSE next_run_at END,
+		    attempts = CASE WHEN updated_at = $2 THEN $5 ELSE attempts END,
+		    retry_at = CASE WHEN updated_at = $2 THEN $6 ELSE retry_at END,
+		    locked_until = NULL
+		WHERE id = $1;
+	`
+
+	if _, err := r.db.Master.ExecContext(ctx, query, id, updatedAt, index, occurrence, attempts, retryAt); err != nil {
+		return fmt.Errorf("retry scheduled report: %w", err)
+	}
+
+	return nil
+}
+
+// Release unlocks a claimed report without recording an attempt, so that it
+// is claimed again on the next poll.
+func (r *Repository) Release(ctx context.Context, id uuid.UUID) error {
+	defer metrics.ObserveQuery("schedule", "Release", time.Now())
+
+	query := `
+		UPDATE scheduled_reports
+		SET locked_until = NULL
+		WHERE id = $1;
+	`
+
+	if _, err := r.db.Master.ExecContext(ctx, query, id); err != nil {
+		return fmt.Errorf("release scheduled report: %w", err)
+	}
+
+	return nil
+}
+
+// StartRun records a running run of a report and fails the runs of the report
+// left running by a scheduler that stopped, with the message abandoned.
+func (r *Repository) StartRun(ctx context.Context, run *model.ReportRun, abandoned string) error {
+	defer metrics.ObserveQuery("schedule", "StartRun", time.Now())
+
+	tx, err := r.db.Master.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("begin transaction: %w", err)
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	query := `
+		UPDATE report_runs
+		SET status = 'failed',
+		    error = $2,
+		    finished_at = now()
+		WHERE report_id = $1
+		  AND status = 'running';
+	`
+
+	if _, err = tx.ExecContext(ctx, query, run.ReportID, abandoned); err != nil {
+		return fmt.Errorf("fail abandoned report runs: %w", err)
+	}
+
+	query = `
+		INSERT INTO report_runs (report_id, occurrence_at, period_from, period_to, attempt, recipients)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		RETURNING id, status, started_at;
+	`
+
+	err = tx.QueryRowContext(ctx, query,
+		run.ReportID, run.OccurrenceAt, run.PeriodFrom, run.PeriodTo, run.Attempt, pq.Array(run.Recipients),
+	).Scan(&run.ID, &run.Status, &run.StartedAt)
+	if err != nil {
+		return fmt.Errorf("insert report run: %w", err)
+	}
+
+	if err = tx.Commit(); err != nil {
+		return fmt.Errorf("commit transaction: %w", err)
+	}
+
+	return nil
+}
+
+// FinishRun records the outcome of a run: succeeded, or failed with msg.
+func (r *Repository) FinishRun(ctx context.Context, id uuid.UUID, status string, msg *string) error {
+	defer metrics.ObserveQuery("schedule", "FinishRun", time.Now())
+
+	query := `
+		UPDATE report_runs
+		SET status = $2,
+		    error = $3,
+		    finished_at = now()
+		WHERE id = $1;
+	`
+
+	if _, err := r.db.Master.ExecContext(ctx, query, id, status, msg); err != nil {
+		return fmt.Errorf("finish report run: %w", err)
+	}
+
+	return nil
+}
+
+// scanner is implemented by *sql.Row and *sql.Rows.
+type scanner interface {
+	Scan(dest ...interface{}) error
+}
+
+// scanReport scans a row of reportColumns.
+func scanReport(s scanner) (*model.ScheduledReport, error) {
+	var (
+		sr   model.ScheduledReport
+		spec []byte
+	)
+
+	if err := s.Scan(
+		&sr.ID, &sr.WorkspaceID, &sr.Name, &spec, &sr.Range, &sr.Rule, &sr.StartAt, pq.Array(&sr.Recipients), &sr.Enabled,
+		&sr.NextIndex, &sr.NextRunAt, &sr.Attempts, &sr.RetryAt, &sr.CreatedAt, &sr.UpdatedAt,
+	); err != nil {
+		return nil, err
+	}
+
+	if err := json.Unmarshal(spec, &sr.Spec); err != nil {
+		return nil, fmt.Errorf("decode spec: %w", err)
+	}
+
+	return &sr, nil
+}
+
+// scanRun scans a row of runColumns.
+func scanRun(s scanner) (*model.ReportRun, error) {
+	var run model.ReportRun
+	if err := s.Scan(
+		&run.ID, &run.ReportID, &run.OccurrenceAt, &run.PeriodFrom, &run.PeriodTo, &run.Status, &run.Attempt,
+		pq.Array(&run.Recipients), &run.Error, &run.StartedAt, &run.FinishedAt,
+	); err != nil {
+		return nil, err
+	}
+
+	return &run, nil
+}
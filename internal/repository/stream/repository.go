@@ -0,0 +1,155 @@
+package stream
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/aliskhannn/sales-tracker/internal/database"
+	"github.com/aliskhannn/sales-tracker/internal/metrics"
+	"github.com/aliskhannn/sales-tracker/internal/model"
+	"github.com/aliskhannn/sales-tracker/internal/tenant"
+)
+
+// Repository provides methods to read the item change log. Changes are read
+// from the master, since they are read right after their notification and a
+// replica may not have them yet.
+type Repository struct {
+	db *database.DB
+}
+
+// NewRepository creates a new stream repository.
+func NewRepository(db *database.DB) *Repository {
+	return &Repository{db: db}
+}
+
+// stableChanges restricts changes to those of transactions older than every
+// running transaction. A change of a running transaction may have a smaller
+// ID than committed changes, but never an older transaction, so streams that
+// only read stable changes in transaction order do not skip it.
+const stableChanges = `xact_id < pg_snapshot_xmin(pg_current_snapshot())`
+
+// ItemChanges retrieves up to limit stable changes to items after the cursor,
+// in transaction order.
+func (r *Repository) ItemChanges(ctx context.Context, after model.ChangeCursor, limit int) ([]model.ItemChange, error) {
+	defer metrics.ObserveQuery("stream", "ItemChanges", time.Now())
+
+	query := `
+		SELECT id, xact_id, event_type, item, changed_at
+		FROM item_changes
+		WHERE workspace_id = $1
+		  AND (xact_id, id) > ($2::xid8, $3)
+		  AND ` + stableChanges + `
+		ORDER BY xact_id, id
+		LIMIT $4;
+	`
+
+	rows, err := r.db.Master.QueryContext(ctx, query, tenant.ID(ctx), xactID(after.XactID), after.ID, limit)
+	if err != nil {
+		return nil, fmt.Errorf("list item changes: %w", err)
+	}
+	defer rows.Close()
+
+	var changes []model.ItemChange
+	for rows.Next() {
+		var (
+			c    model.ItemChange
+			item []byte
+		)
+
+		if err = rows.Scan(&c.ID, &c.XactID, &c.Type, &item, &c.ChangedAt); err != nil {
+			return nil, fmt.Errorf("list item changes: %w", err)
+		}
+
+		if err = json.Unmarshal(item, &c.Item); err != nil {
+			return nil, fmt.Errorf("decode item of change %d: %w", c.ID, err)
+		}
+
+		changes = append(changes, c)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("list item changes: %w", err)
+	}
+
+	return changes, nil
+}
+
+// HasPendingItemChanges reports whether committed changes to items after the
+// cursor are not stable yet, since an older transaction is still running.
+func (r *Repository) HasPendingItemChanges(ctx context.Context, after model.ChangeCursor) (bool, error) {
+	defer metrics.ObserveQuery("stream", "HasPendingItemChanges", time.Now())
+
+	query := `
+		SELECT EXISTS (
+		    SELECT 1
+		    FROM item_changes
+		    WHERE workspace_id = $1
+		      AND (xact_id, id) > ($2::xid8, $3)
+		      AND NOT ` + stableChanges + `
+		);
+	`
+
+	var pending bool
+	if err := r.db.Master.QueryRowContext(ctx, query, tenant.ID(ctx), xactID(after.XactID), after.ID).Scan(&pending); err != nil {
+		return false, fmt.Errorf("check pending item changes: %w", err)
+	}
+
+	return pending, nil
+}
+
+// LatestItemChange returns the position of the latest stable change to items,
+// or the zero cursor if there is none.
+func (r *Repository) LatestItemChange(ctx context.Context) (model.ChangeCursor, error) {
+	defer metrics.ObserveQuery("stream", "LatestItemChange", time.Now())
+
+	query := `
+		SELECT xact_id, id
+		FROM item_changes
+		WHERE workspace_id = $1
+		  AND ` + stableChanges + `
+		ORDER BY xact_id DESC, id DESC
+		LIMIT 1;
+	`
+
+	var c model.ChangeCursor
+	err := r.db.Master.QueryRowContext(ctx, query, tenant.ID(ctx)).Scan(&c.XactID, &c.ID)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return model.ChangeCursor{}, fmt.Errorf("get latest item change: %w", err)
+	}
+
+	return c, nil
+}
+
+// xactID formats a transaction ID as a query argument, since database/sql
+// does not accept all uint64 values.
+func xactID(id uint64) string {
+	return strconv.FormatUint(id, 10)
+}
+
+// PruneItemChanges removes changes of all workspaces made before the given
+// time and returns how many were removed.
+func (r *Repository) PruneItemChanges(ctx context.Context, before time.Time) (int64, error) {
+	defer metrics.ObserveQuery("stream", "PruneItemChanges", time.Now())
+
+	query := `
+		DELETE FROM item_changes
+		WHERE changed_at < $1;
+	`
+
+	res, err := r.db.Master.ExecContext(ctx, query, before)
+	if err != nil {
+		return 0, fmt.Errorf("prune item changes: %w", err)
+	}
+
+	n, err := res.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("check rows affected: %w", err)
+	}
+
+	return n, nil
+}
@@ -0,0 +1,296 @@
+package tag
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+
+	"github.com/aliskhannn/sales-tracker/internal/database"
+	"github.com/aliskhannn/sales-tracker/internal/metrics"
+	"github.com/aliskhannn/sales-tracker/internal/model"
+	"github.com/aliskhannn/sales-tracker/internal/tenant"
+)
+
+var (
+	ErrTagNotFound = errors.New("tag not found")
+	ErrTagExists   = errors.New("tag with this name already exists")
+)
+
+// uniqueViolation is the PostgreSQL error code of unique_violation.
+const uniqueViolation = "23505"
+
+// Repository provides methods to interact with tags.
+type Repository struct {
+	db *database.DB
+}
+
+// NewRepository creates a new tag repository.
+func NewRepository(db *database.DB) *Repository {
+	return &Repository{db: db}
+}
+
+// Create adds a new tag to the database.
+func (r *Repository) Create(ctx context.Context, t *model.Tag) (uuid.UUID, error) {
+	defer metrics.ObserveQuery("tag", "Create", time.Now())
+
+	query := `
+		INSERT INTO tags (name, description, workspace_id)
+		VALUES ($1, $2, $3)
+		RETURNING id;
+	`
+
+	err := r.db.Master.QueryRowContext(ctx, query, t.Name, t.Description, tenant.ID(ctx)).Scan(&t.ID)
+	if err != nil {
+		if isUniqueViolation(err) {
+			return uuid.Nil, ErrTagExists
+		}
+
+		return uuid.Nil, fmt.Errorf("insert tag: %w", err)
+	}
+
+	return t.ID, nil
+}
+
+// GetByID retrieves a tag by its ID.
+func (r *Repository) GetByID(ctx context.Context, id uuid.UUID) (*model.Tag, error) {
+	defer metrics.ObserveQuery("tag", "GetByID", time.Now())
+
+	query := `
+		SELECT id, name, description, created_at, updated_at
+		FROM tags
+		WHERE id = $1
+		  AND workspace_id = $2;
+	`
+
+	var t model.Tag
+	err := r.db.QueryRowContext(ctx, query, id, tenant.ID(ctx)).Scan(
+		&t.ID, &t.Name, &t.Description, &t.CreatedAt, &t.UpdatedAt,
+	)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrTagNotFound
+		}
+
+		return nil, fmt.Errorf("get tag: %w", err)
+	}
+
+	return &t, nil
+}
+
+// List retrieves all tags ordered by name.
+func (r *Repository) List(ctx context.Context) ([]model.Tag, error) {
+	defer metrics.ObserveQuery("tag", "List", time.Now())
+
+	query := `
+		SELECT id, name, description, created_at, updated_at
+		FROM tags
+		WHERE workspace_id = $1
+		ORDER BY lower(name);
+	`
+
+	return r.list(ctx, query, tenant.ID(ctx))
+}
+
+// ListByItem retrieves the tags attached to an item ordered by name.
+func (r *Repository) ListByItem(ctx context.Context, itemID uuid.UUID) ([]model.Tag, error) {
+	defer metrics.ObserveQuery("tag", "ListByItem", time.Now())
+
+	query := `
+		SELECT t.id, t.name, t.description, t.created_at, t.updated_at
+		FROM tags t
+		JOIN item_tags it ON it.tag_id = t.id
+		WHERE it.item_id = $1
+		  AND t.workspace_id = $2
+		ORDER BY lower(t.name);
+	`
+
+	return r.list(ctx, query, itemID, tenant.ID(ctx))
+}
+
+// Update updates a tag.
+func (r *Repository) Update(ctx context.Context, t *model.Tag) error {
+	defer metrics.ObserveQuery("tag", "Update", time.Now())
+
+	query := `
+		UPDATE tags
+		SET name = $1,
+		    description = $2
+		WHERE id = $3
+		  AND workspace_id = $4;
+	`
+
+	res, err := r.db.ExecContext(ctx, query, t.Name, t.Description, t.ID, tenant.ID(ctx))
+	if err != nil {
+		if isUniqueViolation(err) {
+			return ErrTagExists
+		}
+
+		return fmt.Errorf("update tag: %w", err)
+	}
+
+	n, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("check rows affected: %w", err)
+	}
+
+	if n == 0 {
+		return ErrTagNotFound
+	}
+
+	return nil
+}
+
+// Delete removes a tag from the database and detaches it from all items.
+func (r *Repository) Delete(ctx context.Context, id uuid.UUID) error {
+	defer metrics.ObserveQuery("tag", "Delete", time.Now())
+
+	query := `
+		DELETE FROM tags
+		WHERE id = $1
+		  AND workspace_id = $2;
+	`
+
+	res, err := r.db.ExecContext(ctx, query, id, tenant.ID(ctx))
+	if err != nil {
+		return fmt.Errorf("delete tag: %w", err)
+	}
+
+	n, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("check rows affected: %w", err)
+	}
+
+	if n == 0 {
+		return ErrTagNotFound
+	}
+
+	return nil
+}
+
+// Attach attaches tags to an item. Tags that are already attached are ignored.
+// Returns ErrTagNotFound if any of the tags does not exist in the context workspace.
+func (r *Repository) Attach(ctx context.Context, itemID uuid.UUID, tagIDs []uuid.UUID) error {
+	defer metrics.ObserveQuery("tag", "Attach", time.Now())
+
+	ids := make([]string, 0, len(tagIDs))
+	for _, id := range tagIDs {
+		ids = append(ids, id.String())
+	}
+
+	tx, err := r.db.Master.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("begin tx: %w", err)
+	}
+	defer database.Rollback(ctx, tx)
+
+	var found int
+	err = tx.QueryRowContext(ctx, `
+		SELECT COUNT(*)
+		FROM tags
+		WHERE id = ANY($1::uuid[])
+		  AND workspace_id = $2;
+	`, pq.Array(ids), tenant.ID(ctx)).Scan(&found)
+	if err != nil {
+		return fmt.Errorf("check tags: %w", err)
+	}
+
+	if found != len(distinct(tagIDs)) {
+		return ErrTagNotFound
+	}
+
+	if _, err = tx.ExecContext(ctx, `
+		INSERT INTO item_tags (item_id, tag_id)
+		SELECT $1, id
+		FROM tags
+		WHERE id = ANY($2::uuid[])
+		  AND workspace_id = $3
+		ON CONFLICT DO NOTHING;
+	`, itemID, pq.Array(ids), tenant.ID(ctx)); err != nil {
+		return fmt.Errorf("attach tags: %w", err)
+	}
+
+	if err = tx.Commit(); err != nil {
+		return fmt.Errorf("commit tx: %w", err)
+	}
+
+	return nil
+}
+
+// Detach removes a tag from an item.
+// Returns ErrTagNotFound if the tag is not attached to the item.
+func (r *Repository) Detach(ctx context.Context, itemID, tagID uuid.UUID) error {
+	defer metrics.ObserveQuery("tag", "Detach", time.Now())
+
+	query := `
+		DELETE FROM item_tags
+		WHERE item_id = $1
+		  AND tag_id = $2
+		  AND EXISTS (SELECT 1 FROM tags t WHERE t.id = $2 AND t.workspace_id = $3);
+	`
+
+	res, err := r.db.ExecContext(ctx, query, itemID, tagID, tenant.ID(ctx))
+	if err != nil {
+		return fmt.Errorf("detach tag: %w", err)
+	}
+
+	n, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("check rows affected: %w", err)
+	}
+
+	if n == 0 {
+		return ErrTagNotFound
+	}
+
+	return nil
+}
+
+// list runs a query selecting tag columns and scans the rows.
+func (r *Repository) list(ctx context.Context, query string, args ...any) ([]model.Tag, error) {
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("list tags: %w", err)
+	}
+	defer rows.Close()
+
+	var tags []model.Tag
+	for rows.Next() {
+		var t model.Tag
+		if err = rows.Scan(&t.ID, &t.Name, &t.Description, &t.CreatedAt, &t.UpdatedAt); err != nil {
+			return nil, fmt.Errorf("list tags: %w", err)
+		}
+
+		tags = append(tags, t)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("list tags: %w", err)
+	}
+
+	return tags, nil
+}
+
+// isUniqueViolation reports whether err is a PostgreSQL unique_violation.
+func isUniqueViolation(err error) bool {
+	var pqErr *pq.Error
+	return errors.As(err, &pqErr) && pqErr.Code == uniqueViolation
+}
+
+// distinct returns ids without duplicates.
+func distinct(ids []uuid.UUID) []uuid.UUID {
+	seen := make(map[uuid.UUID]bool, len(ids))
+	out := make([]uuid.UUID, 0, len(ids))
+	for _, id := range ids {
+		if !seen[id] {
+			seen[id] = true
+			out = append(out, id)
+		}
+	}
+
+	return out
+}
@@ -0,0 +1,416 @@
+package webhook
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+
+	"github.com/aliskhannn/sales-tracker/internal/database"
+	"github.com/aliskhannn/sales-tracker/internal/metrics"
+	"github.com/aliskhannn/sales-tracker/internal/model"
+	"github.com/aliskhannn/sales-tracker/internal/tenant"
+)
+
+var (
+	ErrWebhookNotFound  = errors.New("webhook not found")
+	ErrDeliveryNotFound = errors.New("webhook delivery not found")
+)
+
+// webhookColumns lists the webhooks columns in the order scanned by scanWebhook.
+const webhookColumns = `id, url, event_types, secret, enabled, created_at, updated_at`
+
+// deliveryColumns lists the columns of a delivery d joined with its event e
+// in the order scanned by scanDelivery.
+const deliveryColumns = `
+	d.id, d.webhook_id, d.event_id, e.event_type, d.status, d.attempts, d.next_attempt_at,
+	d.last_status_code, d.last_error, d.delivered_at, d.created_at, d.updated_at
+`
+
+// Repository provides methods to interact with webhooks, their deliveries and
+// the outbox events they are fed from.
+type Repository struct {
+	db *database.DB
+}
+
+// NewRepository creates a new webhook repository.
+func NewRepository(db *database.DB) *Repository {
+	return &Repository{db: db}
+}
+
+// Create adds a new webhook to the database.
+func (r *Repository) Create(ctx context.Context, w *model.Webhook) (uuid.UUID, error) {
+	defer metrics.ObserveQuery("webhook", "Create", time.Now())
+
+	query := `
+		INSERT INTO webhooks (url, event_types, secret, enabled, workspace_id)
+		VALUES ($1, $2, $3, $4, $5)
+		RETURNING id, created_at, updated_at;
+	`
+
+	err := r.db.Master.QueryRowContext(ctx, query,
+		w.URL, pq.Array(w.EventTypes), w.Secret, w.Enabled, tenant.ID(ctx),
+	).Scan(&w.ID, &w.CreatedAt, &w.UpdatedAt)
+	if err != nil {
+		return uuid.Nil, fmt.Errorf("insert webhook: %w", err)
+	}
+
+	return w.ID, nil
+}
+
+// GetByID retrieves a webhook by its ID.
+func (r *Repository) GetByID(ctx context.Context, id uuid.UUID) (*model.Webhook, error) {
+	defer metrics.ObserveQuery("webhook", "GetByID", time.Now())
+
+	query := `SELECT ` + webhookColumns + `
+		FROM webhooks
+		WHERE id = $1
+		  AND workspace_id = $2;
+	`
+
+	w, err := scanWebhook(r.db.QueryRowContext(ctx, query, id, tenant.ID(ctx)))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrWebhookNotFound
+		}
+
+		return nil, fmt.Errorf("get webhook: %w", err)
+	}
+
+	return w, nil
+}
+
+// List retrieves all webhooks, oldest first.
+func (r *Repository) List(ctx context.Context) ([]model.Webhook, error) {
+	defer metrics.ObserveQuery("webhook", "List", time.Now())
+
+	query := `SELECT ` + webhookColumns + `
+		FROM webhooks
+		WHERE workspace_id = $1
+		ORDER BY created_at, id;
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, tenant.ID(ctx))
+	if err != nil {
+		return nil, fmt.Errorf("list webhooks: %w", err)
+	}
+	defer rows.Close()
+
+	var webhooks []model.Webhook
+	for rows.Next() {
+		w, err := scanWebhook(rows)
+		if err != nil {
+			return nil, fmt.Errorf("list webhooks: %w", err)
+		}
+
+		webhooks = append(webhooks, *w)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("list webhooks: %w", err)
+	}
+
+	return webhooks, nil
+}
+
+// Update updates the URL, event types, secret and state of a webhook.
+func (r *Repository) Update(ctx context.Context, w *model.Webhook) error {
+	defer metrics.ObserveQuery("webhook", "Update", time.Now())
+
+	query := `
+		UPDATE webhooks
+		SET url = $1,
+		    event_types = $2,
+		    secret = $3,
+		    enabled = $4
+		WHERE id = $5
+		  AND workspace_id = $6
+		RETURNING created_at, updated_at;
+	`
+
+	err := r.db.Master.QueryRowContext(ctx, query,
+		w.URL, pq.Array(w.EventTypes), w.Secret, w.Enabled, w.ID, tenant.ID(ctx),
+	).Scan(&w.CreatedAt, &w.UpdatedAt)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return ErrWebhookNotFound
+		}
+
+		return fmt.Errorf("update webhook: %w", err)
+	}
+
+	return nil
+}
+
+// Delete removes a webhook together with its delivery log.
+func (r *Repository) Delete(ctx context.Context, id uuid.UUID) error {
+	defer metrics.ObserveQuery("webhook", "Delete", time.Now())
+
+	query := `
+		DELETE FROM webhooks
+		WHERE id = $1
+		  AND workspace_id = $2;
+	`
+
+	res, err := r.db.ExecContext(ctx, query, id, tenant.ID(ctx))
+	if err != nil {
+		return fmt.Errorf("delete webhook: %w", err)
+	}
+
+	n, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("check rows affected: %w", err)
+	}
+
+	if n == 0 {
+		return ErrWebhookNotFound
+	}
+
+	return nil
+}
+
+// ListDeliveries retrieves the latest deliveries of a webhook, newest first.
+func (r *Repository) ListDeliveries(ctx context.Context, webhookID uuid.UUID, limit int) ([]model.WebhookDelivery, error) {
+	defer metrics.ObserveQuery("webhook", "ListDeliveries", time.Now())
+
+	query := `SELECT ` + deliveryColumns + `
+		FROM webhook_deliveries d
+		JOIN outbox_events e ON e.id = d.event_id
+		JOIN webhooks w ON w.id = d.webhook_id
+		WHERE d.webhook_id = $1
+		  AND w.workspace_id = $2
+		ORDER BY d.created_at DESC, d.id
+		LIMIT $3;
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, webhookID, tenant.ID(ctx), limit)
+	if err != nil {
+		return nil, fmt.Errorf("list webhook deliveries: %w", err)
+	}
+	defer rows.Close()
+
+	var deliveries []model.WebhookDelivery
+	for rows.Next() {
+		d, err := scanDelivery(rows)
+		if err != nil {
+			return nil, fmt.Errorf("list webhook deliveries: %w", err)
+		}
+
+		deliveries = append(deliveries, *d)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("list webhook deliveries: %w", err)
+	}
+
+	return deliveries, nil
+}
+
+// Redeliver schedules the event of a delivery for immediate delivery again.
+// The original delivery is kept in the log; a new pending delivery is returned.
+func (r *Repository) Redeliver(ctx context.Context, webhookID, deliveryID uuid.UUID) (*model.WebhookDelivery, error) {
+	defer metrics.ObserveQuery("webhook", "Redeliver", time.Now())
+
+	query := `
+		WITH d AS (
+			INSERT INTO webhook_deliveries (webhook_id, event_id)
+			SELECT o.webhook_id, o.event_id
+			FROM webhook_deliveries o
+			JOIN webhooks w ON w.id = o.webhook_id
+			WHERE o.id = $1
+			  AND o.webhook_id = $2
+			  AND w.workspace_id = $3
+			RETURNING *
+		)
+		SELECT ` + deliveryColumns + `
+		FROM d
+		JOIN outbox_events e ON e.id = d.event_id;
+	`
+
+	d, err := scanDelivery(r.db.Master.QueryRowContext(ctx, query, deliveryID, webhookID, tenant.ID(ctx)))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrDeliveryNotFound
+		}
+
+		return nil, fmt.Errorf("redeliver webhook delivery: %w", err)
+	}
+
+	return d, nil
+}
+
+// FanOut turns up to limit undispatched outbox events, oldest first, into
+// pending deliveries of the enabled webhooks of their workspace subscribed to
+// them and marks the events dispatched, all in a single statement. It returns
+// the number of dispatched events. Concurrent callers skip each other's events.
+func (r *Repository) FanOut(ctx context.Context, limit int) (int, error) {
+	defer metrics.ObserveQuery("webhook", "FanOut", time.Now())
+
+	query := `
+		WITH events AS (
+			SELECT id, workspace_id, event_type
+			FROM outbox_events
+			WHERE dispatched_at IS NULL
+			ORDER BY created_at
+			LIMIT $1
+			FOR UPDATE SKIP LOCKED
+		), deliveries AS (
+			INSERT INTO webhook_deliveries (webhook_id, event_id)
+			SELECT w.id, e.id
+			FROM events e
+			JOIN webhooks w ON w.workspace_id = e.workspace_id
+			WHERE w.enabled
+			  AND (cardinality(w.event_types) = 0 OR e.event_type = ANY(w.event_types))
+		)
+		UPDATE outbox_events o
+		SET dispatched_at = now()
+		FROM events e
+		WHERE o.id = e.id;
+	`
+
+	res, err := r.db.Master.ExecContext(ctx, query, limit)
+	if err != nil {
+		return 0, fmt.Errorf("fan out outbox events: %w", err)
+	}
+
+	n, err := res.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("check rows affected: %w", err)
+	}
+
+	return int(n), nil
+}
+
+// ClaimDue claims up to limit pending deliveries of enabled webhooks whose
+// next attempt is due by postponing it by lease, so that neither concurrent
+// dispatchers nor a crashed one deliver them twice before the lease expires.
+func (r *Repository) ClaimDue(ctx context.Context, limit int, lease time.Duration) ([]model.PendingDelivery, error) {
+	defer metrics.ObserveQuery("webhook", "ClaimDue", time.Now())
+
+	query := `
+		WITH due AS (
+			SELECT d.id
+			FROM webhook_deliveries d
+			JOIN webhooks w ON w.id = d.webhook_id
+			WHERE d.status = 'pending'
+			  AND d.next_attempt_at <= now()
+			  AND w.enabled
+			ORDER BY d.next_attempt_at
+			LIMIT $1
+			FOR UPDATE OF d SKIP LOCKED
+		), claimed AS (
+			UPDATE webhook_deliveries d
+			SET next_attempt_at = now() + $2 * INTERVAL '1 second'
+			FROM due
+			WHERE d.id = due.id
+			RETURNING d.id, d.attempts, d.webhook_id, d.event_id
+		)
+		SELECT c.id, c.attempts, w.url, w.secret, e.id, e.workspace_id, e.event_type, e.payload, e.created_at
+		FROM claimed c
+		JOIN webhooks w ON w.id = c.webhook_id
+		JOIN outbox_events e ON e.id = c.event_id;
+	`
+
+	rows, err := r.db.Master.QueryContext(ctx, query, limit, lease.Seconds())
+	if err != nil {
+		return nil, fmt.Errorf("claim webhook deliveries: %w", err)
+	}
+	defer rows.Close()
+
+	var deliveries []model.PendingDelivery
+	for rows.Next() {
+		var d model.PendingDelivery
+		if err = rows.Scan(
+			&d.ID, &d.Attempts, &d.URL, &d.Secret,
+			&d.Event.ID, &d.Event.WorkspaceID, &d.Event.Type, &d.Event.Data, &d.Event.CreatedAt,
+		); err != nil {
+			return nil, fmt.Errorf("claim webhook deliveries: %w", err)
+		}
+
+		deliveries = append(deliveries, d)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("claim webhook deliveries: %w", err)
+	}
+
+	return deliveries, nil
+}
+
+// Succeed records a successful attempt of a delivery.
+func (r *Repository) Succeed(ctx context.Context, id uuid.UUID, statusCode int) error {
+	defer metrics.ObserveQuery("webhook", "Succeed", time.Now())
+
+	query := `
+		UPDATE webhook_deliveries
+		SET status = 'succeeded',
+		    attempts = attempts + 1,
+		    last_status_code = $2,
+		    last_error = NULL,
+		    delivered_at = now()
+		WHERE id = $1;
+	`
+
+	if _, err := r.db.Master.ExecContext(ctx, query, id, statusCode); err != nil {
+		return fmt.Errorf("record webhook delivery success: %w", err)
+	}
+
+	return nil
+}
+
+// Fail records a failed attempt of a delivery. statusCode is nil if no
+// response was received. The delivery is retried at retryAt, or marked failed
+// if retryAt is nil.
+func (r *Repository) Fail(ctx context.Context, id uuid.UUID, statusCode *int, msg string, retryAt *time.Time) error {
+	defer metrics.ObserveQuery("webhook", "Fail", time.Now())
+
+	query := `
+		UPDATE webhook_deliveries
+		SET status = CASE WHEN $4::timestamptz IS NULL THEN 'failed' ELSE 'pending' END,
+		    attempts = attempts + 1,
+		    last_status_code = $2,
+		    last_error = $3,
+		    next_attempt_at = COALESCE($4, next_attempt_at)
+		WHERE id = $1;
+	`
+
+	if _, err := r.db.Master.ExecContext(ctx, query, id, statusCode, msg, retryAt); err != nil {
+		return fmt.Errorf("record webhook delivery failure: %w", err)
+	}
+
+	return nil
+}
+
+// scanner is implemented by *sql.Row and *sql.Rows.
+type scanner interface {
+	Scan(dest ...interface{}) error
+}
+
+// scanWebhook scans a webhook row selected with webhookColumns.
+func scanWebhook(s scanner) (*model.Webhook, error) {
+	var w model.Webhook
+	err := s.Scan(&w.ID, &w.URL, pq.Array(&w.EventTypes), &w.Secret, &w.Enabled, &w.CreatedAt, &w.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	return &w, nil
+}
+
+// scanDelivery scans a delivery row selected with deliveryColumns.
+func scanDelivery(s scanner) (*model.WebhookDelivery, error) {
+	var d model.WebhookDelivery
+	err := s.Scan(
+		&d.ID, &d.WebhookID, &d.EventID, &d.EventType, &d.Status, &d.Attempts, &d.NextAttemptAt,
+		&d.LastStatusCode, &d.LastError, &d.DeliveredAt, &d.CreatedAt, &d.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return &d, nil
+}
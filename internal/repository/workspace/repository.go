@@ -0,0 +1,163 @@
+package workspace
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+
+	"github.com/aliskhannn/sales-tracker/internal/database"
+	"github.com/aliskhannn/sales-tracker/internal/metrics"
+	"github.com/aliskhannn/sales-tracker/internal/model"
+)
+
+var (
+	ErrWorkspaceNotFound = errors.New("workspace not found")
+	ErrWorkspaceExists   = errors.New("workspace with this slug already exists")
+)
+
+// uniqueViolation is the PostgreSQL error code of unique_violation.
+const uniqueViolation = "23505"
+
+// Repository provides methods to interact with workspaces.
+type Repository struct {
+	db *database.DB
+}
+
+// NewRepository creates a new workspace repository.
+func NewRepository(db *database.DB) *Repository {
+	return &Repository{db: db}
+}
+
+// Create adds a new workspace to the database.
+func (r *Repository) Create(ctx context.Context, w *model.Workspace) (uuid.UUID, error) {
+	defer metrics.ObserveQuery("workspace", "Create", time.Now())
+
+	query := `
+		INSERT INTO workspaces (slug, name)
+		VALUES ($1, $2)
+		RETURNING id, created_at, updated_at;
+	`
+
+	err := r.db.Master.QueryRowContext(ctx, query, w.Slug, w.Name).Scan(&w.ID, &w.CreatedAt, &w.UpdatedAt)
+	if err != nil {
+		if isUniqueViolation(err) {
+			return uuid.Nil, ErrWorkspaceExists
+		}
+
+		return uuid.Nil, fmt.Errorf("insert workspace: %w", err)
+	}
+
+	return w.ID, nil
+}
+
+// GetByID retrieves a workspace by its ID.
+func (r *Repository) GetByID(ctx context.Context, id uuid.UUID) (*model.Workspace, error) {
+	defer metrics.ObserveQuery("workspace", "GetByID", time.Now())
+
+	query := `
+		SELECT id, slug, name, created_at, updated_at
+		FROM workspaces
+		WHERE id = $1;
+	`
+
+	return r.get(ctx, query, id)
+}
+
+// GetBySlug retrieves a workspace by its slug.
+func (r *Repository) GetBySlug(ctx context.Context, slug string) (*model.Workspace, error) {
+	defer metrics.ObserveQuery("workspace", "GetBySlug", time.Now())
+
+	query := `
+		SELECT id, slug, name, created_at, updated_at
+		FROM workspaces
+		WHERE slug = $1;
+	`
+
+	return r.get(ctx, query, slug)
+}
+
+// List retrieves all workspaces ordered by slug.
+func (r *Repository) List(ctx context.Context) ([]model.Workspace, error) {
+	defer metrics.ObserveQuery("workspace", "List", time.Now())
+
+	query := `
+		SELECT id, slug, name, created_at, updated_at
+		FROM workspaces
+		ORDER BY slug;
+	`
+
+	rows, err := r.db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("list workspaces: %w", err)
+	}
+	defer rows.Close()
+
+	var workspaces []model.Workspace
+	for rows.Next() {
+		var w model.Workspace
+		if err = rows.Scan(&w.ID, &w.Slug, &w.Name, &w.CreatedAt, &w.UpdatedAt); err != nil {
+			return nil, fmt.Errorf("scan workspace: %w", err)
+		}
+
+		workspaces = append(workspaces, w)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows error: %w", err)
+	}
+
+	return workspaces, nil
+}
+
+// Update renames a workspace.
+func (r *Repository) Update(ctx context.Context, w *model.Workspace) error {
+	defer metrics.ObserveQuery("workspace", "Update", time.Now())
+
+	query := `
+		UPDATE workspaces
+		SET name = $1
+		WHERE id = $2;
+	`
+
+	res, err := r.db.ExecContext(ctx, query, w.Name, w.ID)
+	if err != nil {
+		return fmt.Errorf("update workspace: %w", err)
+	}
+
+	n, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("check rows affected: %w", err)
+	}
+
+	if n == 0 {
+		return ErrWorkspaceNotFound
+	}
+
+	return nil
+}
+
+// get retrieves a single workspace with the given query.
+func (r *Repository) get(ctx context.Context, query string, arg interface{}) (*model.Workspace, error) {
+	var w model.Workspace
+	err := r.db.QueryRowContext(ctx, query, arg).Scan(&w.ID, &w.Slug, &w.Name, &w.CreatedAt, &w.UpdatedAt)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrWorkspaceNotFound
+		}
+
+		return nil, fmt.Errorf("get workspace: %w", err)
+	}
+
+	return &w, nil
+}
+
+// isUniqueViolation reports whether err is a PostgreSQL unique_violation.
+func isUniqueViolation(err error) bool {
+	var pqErr *pq.Error
+	return errors.As(err, &pqErr) && pqErr.Code == uniqueViolation
+}
@@ -0,0 +1,130 @@
+package account
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+
+	"github.com/aliskhannn/sales-tracker/internal/model"
+)
+
+// repository provides methods to interact with accounts.
+type repository interface {
+	// Create adds a new account to the database.
+	Create(ctx context.Context, a *model.Account) (uuid.UUID, error)
+
+	// GetByID retrieves an account by its ID.
+	GetByID(ctx context.Context, id uuid.UUID) (*model.Account, error)
+
+	// List retrieves all accounts from the database.
+	List(ctx context.Context) ([]model.Account, error)
+
+	// Update updates an account.
+	Update(ctx context.Context, a *model.Account) error
+
+	// Delete removes an account from the database.
+	Delete(ctx context.Context, id uuid.UUID) error
+
+	// Balance calculates the balance of an account at the given time.
+	Balance(ctx context.Context, id uuid.UUID, at time.Time) (decimal.Decimal, error)
+}
+
+// Service provides account-related business logic.
+type Service struct {
+	repository repository
+}
+
+// NewService creates a new account service.
+func NewService(r repository) *Service {
+	return &Service{repository: r}
+}
+
+// Create adds a new account.
+func (s *Service) Create(ctx context.Context, name, currency string, openingBalance decimal.Decimal) (uuid.UUID, error) {
+	a := &model.Account{
+		Name:           name,
+		Currency:       currency,
+		OpeningBalance: openingBalance,
+	}
+
+	id, err := s.repository.Create(ctx, a)
+	if err != nil {
+		return uuid.Nil, fmt.Errorf("create account: %w", err)
+	}
+
+	return id, nil
+}
+
+// GetByID returns an account by its ID.
+func (s *Service) GetByID(ctx context.Context, id uuid.UUID) (*model.Account, error) {
+	a, err := s.repository.GetByID(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("get account: %w", err)
+	}
+
+	return a, nil
+}
+
+// List returns all accounts.
+func (s *Service) List(ctx context.Context) ([]model.Account, error) {
+	accounts, err := s.repository.List(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("list accounts: %w", err)
+	}
+
+	return accounts, nil
+}
+
+// Update modifies an existing account identified by id.
+func (s *Service) Update(ctx context.Context, id uuid.UUID, name, currency string, openingBalance decimal.Decimal) error {
+	a := &model.Account{
+		ID:             id,
+		Name:           name,
+		Currency:       currency,
+		OpeningBalance: openingBalance,
+	}
+
+	if err := s.repository.Update(ctx, a); err != nil {
+		return fmt.Errorf("update account: %w", err)
+	}
+
+	return nil
+}
+
+// Delete removes an account by its ID.
+func (s *Service) Delete(ctx context.Context, id uuid.UUID) error {
+	if err := s.repository.Delete(ctx, id); err != nil {
+		return fmt.Errorf("delete account: %w", err)
+	}
+
+	return nil
+}
+
+// Balance returns the balance of an account at the given time.
+// If at is nil, the current balance is returned.
+func (s *Service) Balance(ctx context.Context, id uuid.UUID, at *time.Time) (*model.AccountBalance, error) {
+	a, err := s.repository.GetByID(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("account balance: %w", err)
+	}
+
+	when := time.Now()
+	if at != nil {
+		when = *at
+	}
+
+	balance, err := s.repository.Balance(ctx, id, when)
+	if err != nil {
+		return nil, fmt.Errorf("account balance: %w", err)
+	}
+
+	return &model.AccountBalance{
+		AccountID: a.ID,
+		Currency:  a.Currency,
+		Balance:   balance,
+		At:        when,
+	}, nil
+}
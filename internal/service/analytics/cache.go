@@ -0,0 +1,443 @@
+package analytics
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+
+	"github.com/aliskhannn/sales-tracker/internal/cache"
+	"github.com/aliskhannn/sales-tracker/internal/database"
+	"github.com/aliskhannn/sales-tracker/internal/logging"
+	"github.com/aliskhannn/sales-tracker/internal/metrics"
+	"github.com/aliskhannn/sales-tracker/internal/model"
+	"github.com/aliskhannn/sales-tracker/internal/tenant"
+)
+
+// changeChannel is the notification channel the analytics change triggers
+// notify on.
+const changeChannel = "analytics_changes"
+
+const (
+	defaultCacheTTL      = 5 * time.Minute
+	pingInterval         = 90 * time.Second
+	minReconnectInterval = time.Second
+	maxReconnectInterval = time.Minute
+)
+
+var (
+	cacheRequests = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: metrics.Namespace,
+		Name:      "analytics_cache_requests_total",
+		Help:      "Number of analytics results looked up in the cache by metric and result: hit or miss.",
+	}, []string{"metric", "result"})
+	cacheInvalidations = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: metrics.Namespace,
+		Name:      "analytics_cache_invalidations_total",
+		Help:      "Number of analytics cache invalidations by reason: change, or missed for notifications that may have been lost.",
+	}, []string{"reason"})
+)
+
+// CacheOptions configures the analytics result cache.
+type CacheOptions struct {
+	TTL    time.Duration // how long results are cached at most
+	DSN    string        // database the change notifications are received from
+	Settle time.Duration // delay of a second invalidation for reads from lagging replicas; zero disables it
+}
+
+// CachedService caches the results of a Service by metric and normalized
+// filter. Run invalidates the results of the dates items were changed at, so
+// cached results are only stale until the change notification arrives.
+//
+// A result computed from data read before a change committed may finish after
+// the change was invalidated. To keep it from being cached until the TTL
+// elapses, every invalidation bumps the generation of the workspace, and a
+// result is only cached if the generation of its workspace is the same as
+// when its computation started.
+type CachedService struct {
+	service *Service
+	store   cache.Store
+	opts    CacheOptions
+
+	// mu is held for reading while a result is cached and for writing while
+	// results are invalidated, so that no result is cached between the bump
+	// of a generation and the invalidation it precedes.
+	mu          sync.RWMutex
+	all         uint64               // invalidations of all workspaces
+	generations map[uuid.UUID]uint64 // invalidations by workspace
+}
+
+// NewCachedService caches the results of s in store.
+func NewCachedService(s *Service, store cache.Store, opts CacheOptions) *CachedService {
+	if opts.TTL <= 0 {
+		opts.TTL = defaultCacheTTL
+	}
+
+	return &CachedService{service: s, store: store, opts: opts, generations: make(map[uuid.UUID]uint64)}
+}
+
+// cacheKey identifies a cached result. Its fields are normalized so that
+// equivalent filters share a key.
+type cacheKey struct {
+	Metric     string     `json:"metric"`
+	Workspace  uuid.UUID  `json:"workspace"`
+	From       *time.Time `json:"from,omitempty"`
+	To         *time.Time `json:"to,omitempty"`
+	CategoryID *uuid.UUID `json:"category_id,omitempty"`
+	Kind       *string    `json:"kind,omitempty"`
+	AccountID  *uuid.UUID `json:"account_id,omitempty"`
+	Tags       []string   `json:"tags,omitempty"`
+	MatchAll   bool       `json:"match_all,omitempty"`
+	Percentile float64    `json:"percentile,omitempty"`
+}
+
+// newCacheKey returns the key of metric over the items of the workspace in
+// ctx matching the filter.
+func newCacheKey(
+	ctx context.Context,
+	metric string,
+	from, to *time.Time,
+	categoryID *uuid.UUID,
+	kind *string,
+	accountID *uuid.UUID,
+	tags *model.TagFilter,
+) cacheKey {
+	k := cacheKey{
+		Metric:     metric,
+		Workspace:  tenant.ID(ctx),
+		From:       utc(from),
+		To:         utc(to),
+		CategoryID: categoryID,
+		Kind:       kind,
+		AccountID:  accountID,
+	}
+
+	if tags != nil && len(tags.Names) > 0 {
+		for _, name := range tags.Names {
+			k.Tags = append(k.Tags, strings.ToLower(strings.TrimSpace(name)))
+		}
+		slices.Sort(k.Tags)
+		k.Tags = slices.Compact(k.Tags)
+		k.MatchAll = tags.MatchAll && len(k.Tags) > 1
+	}
+
+	return k
+}
+
+// String returns the store key.
+func (k cacheKey) String() string {
+	b, _ := json.Marshal(k)
+	sum := sha256.Sum256(b)
+	return "analytics:" + k.Workspace.String() + ":" + hex.EncodeToString(sum[:])
+}
+
+// scope returns the data the result was computed from.
+func (k cacheKey) scope() cache.Scope {
+	return cache.Scope{Workspace: k.Workspace, From: k.From, To: k.To}
+}
+
+// utc returns t in UTC, or nil if t is nil.
+func utc(t *time.Time) *time.Time {
+	if t == nil {
+		return nil
+	}
+
+	u := t.UTC()
+	return &u
+}
+
+// cached returns the cached result of k, or computes and caches it. Reads
+// requiring strong consistency skip the cache, which may not have seen the
+// latest changes yet, but still refresh it. Cache failures are logged and the
+// result is computed as if it was not cached.
+func cached[T any](ctx context.Context, c *CachedService, k cacheKey, compute func() (T, error)) (T, error) {
+	var (
+		key   = k.String()
+		value []byte
+		ok    bool
+		err   error
+	)
+
+	if database.Consistency(ctx) != database.Strong {
+		value, ok, err = c.store.Get(ctx, key)
+		if err != nil {
+			logging.Ctx(ctx).Error().Err(err).Str("metric", k.Metric).Msg("failed to read cached analytics result")
+		}
+	}
+
+	if ok {
+		var res T
+		if err := json.Unmarshal(value, &res); err == nil {
+			cacheRequests.WithLabelValues(k.Metric, "hit").Inc()
+			return res, nil
+		}
+	}
+
+	cacheRequests.WithLabelValues(k.Metric, "miss").Inc()
+
+	gen := c.generation(k.Workspace)
+
+	res, err := compute()
+	if err != nil {
+		return res, err
+	}
+
+	if value, err = json.Marshal(res); err == nil {
+		err = c.set(ctx, key, value, k.scope(), gen)
+	}
+	if err != nil {
+		logging.Ctx(ctx).Error().Err(err).Str("metric", k.Metric).Msg("failed to cache analytics result")
+	}
+
+	return res, nil
+}
+
+// generation returns the number of invalidations that applied to workspace so
+// far.
+func (c *CachedService) generation(workspace uuid.UUID) uint64 {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	return c.all + c.generations[workspace]
+}
+
+// set caches value under key unless the data in scope was invalidated since
+// gen was read, in which case value may be stale and is dropped.
+func (c *CachedService) set(ctx context.Context, key string, value []byte, scope cache.Scope, gen uint64) error {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	if c.all+c.generations[scope.Workspace] != gen {
+		return nil
+	}
+
+	return c.store.Set(ctx, key, value, scope, c.opts.TTL)
+}
+
+// Sum returns the total amount of items matching the filter.
+func (c *CachedService) Sum(
+	ctx context.Context,
+	from, to *time.Time,
+	categoryID *uuid.UUID,
+	kind *string,
+	accountID *uuid.UUID,
+	tags *model.TagFilter,
+) (string, error) {
+	k := newCacheKey(ctx, "sum", from, to, categoryID, kind, accountID, tags)
+	return cached(ctx, c, k, func() (string, error) {
+		return c.service.Sum(ctx, from, to, categoryID, kind, accountID, tags)
+	})
+}
+
+// Avg returns the average amount of items matching the filter.
+func (c *CachedService) Avg(
+	ctx context.Context,
+	from, to *time.Time,
+	categoryID *uuid.UUID,
+	kind *string,
+	accountID *uuid.UUID,
+	tags *model.TagFilter,
+) (string, error) {
+	k := newCacheKey(ctx, "avg", from, to, categoryID, kind, accountID, tags)
+	return cached(ctx, c, k, func() (string, error) {
+		return c.service.Avg(ctx, from, to, categoryID, kind, accountID, tags)
+	})
+}
+
+// Count returns the number of items matching the filter.
+func (c *CachedService) Count(
+	ctx context.Context,
+	from, to *time.Time,
+	categoryID *uuid.UUID,
+	kind *string,
+	accountID *uuid.UUID,
+	tags *model.TagFilter,
+) (int64, error) {
+	k := newCacheKey(ctx, "count", from, to, categoryID, kind, accountID, tags)
+	return cached(ctx, c, k, func() (int64, error) {
+		return c.service.Count(ctx, from, to, categoryID, kind, accountID, tags)
+	})
+}
+
+// Median returns the median amount of items matching the filter.
+func (c *CachedService) Median(
+	ctx context.Context,
+	from, to *time.Time,
+	categoryID *uuid.UUID,
+	kind *string,
+	accountID *uuid.UUID,
+	tags *model.TagFilter,
+) (string, error) {
+	k := newCacheKey(ctx, "median", from, to, categoryID, kind, accountID, tags)
+	return cached(ctx, c, k, func() (string, error) {
+		return c.service.Median(ctx, from, to, categoryID, kind, accountID, tags)
+	})
+}
+
+// Percentile returns the N-th percentile amount of items matching the filter.
+func (c *CachedService) Percentile(
+	ctx context.Context,
+	from, to *time.Time,
+	categoryID *uuid.UUID,
+	kind *string,
+	accountID *uuid.UUID,
+	tags *model.TagFilter,
+	percentile float64,
+) (string, error) {
+	k := newCacheKey(ctx, "percentile", from, to, categoryID, kind, accountID, tags)
+	k.Percentile = percentile
+	return cached(ctx, c, k, func() (string, error) {
+		return c.service.Percentile(ctx, from, to, categoryID, kind, accountID, tags, percentile)
+	})
+}
+
+// Revenue returns gross income, refunds and net-of-refunds revenue of items
+// matching the filter.
+func (c *CachedService) Revenue(
+	ctx context.Context,
+	from, to *time.Time,
+	categoryID *uuid.UUID,
+	accountID *uuid.UUID,
+	tags *model.TagFilter,
+) (*model.Revenue, error) {
+	k := newCacheKey(ctx, "revenue", from, to, categoryID, nil, accountID, tags)
+	return cached(ctx, c, k, func() (*model.Revenue, error) {
+		return c.service.Revenue(ctx, from, to, categoryID, accountID, tags)
+	})
+}
+
+// ByCategory returns count and sum per category of items matching the filter.
+func (c *CachedService) ByCategory(
+	ctx context.Context,
+	from, to *time.Time,
+	kind *string,
+	accountID *uuid.UUID,
+	tags *model.TagFilter,
+) ([]model.CategoryTotal, error) {
+	k := newCacheKey(ctx, "categories", from, to, nil, kind, accountID, tags)
+	return cached(ctx, c, k, func() ([]model.CategoryTotal, error) {
+		return c.service.ByCategory(ctx, from, to, kind, accountID, tags)
+	})
+}
+
+// ByTag returns count and sum per tag of items matching the filter.
+func (c *CachedService) ByTag(
+	ctx context.Context,
+	from, to *time.Time,
+	categoryID *uuid.UUID,
+	kind *string,
+	accountID *uuid.UUID,
+	tags *model.TagFilter,
+) ([]model.TagTotal, error) {
+	k := newCacheKey(ctx, "tags", from, to, categoryID, kind, accountID, tags)
+	return cached(ctx, c, k, func() ([]model.TagTotal, error) {
+		return c.service.ByTag(ctx, from, to, categoryID, kind, accountID, tags)
+	})
+}
+
+// change is the payload of an analytics change notification.
+type change struct {
+	WorkspaceID uuid.UUID  `json:"workspace_id"`
+	From        *time.Time `json:"from"`
+	To          *time.Time `json:"to"`
+}
+
+// Run listens for analytics change notifications and invalidates the cached
+// results of the changed workspace and dates until ctx is cancelled. Since a
+// result computed on a replica right after a change may miss it, every
+// invalidation is repeated once the settle delay has passed.
+func (c *CachedService) Run(ctx context.Context) {
+	l := pq.NewListener(c.opts.DSN, minReconnectInterval, maxReconnectInterval, func(ev pq.ListenerEventType, err error) {
+		switch ev {
+		case pq.ListenerEventDisconnected:
+			logging.Ctx(ctx).Warn().Err(err).Msg("analytics change listener disconnected")
+		case pq.ListenerEventReconnected:
+			logging.Ctx(ctx).Info().Msg("analytics change listener reconnected")
+		case pq.ListenerEventConnectionAttemptFailed:
+			logging.Ctx(ctx).Error().Err(err).Msg("analytics change listener failed to connect")
+		}
+	})
+	defer func() { _ = l.Close() }()
+
+	// Listen blocks until the database is reachable; closing the listener on
+	// shutdown unblocks it.
+	go func() {
+		if err := l.Listen(changeChannel); err != nil && ctx.Err() == nil {
+			logging.Ctx(ctx).Error().Err(err).Msg("failed to listen for analytics changes")
+		}
+	}()
+
+	ping := time.NewTicker(pingInterval)
+	defer ping.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case n := <-l.Notify:
+			// A nil notification follows a reconnect, after which
+			// notifications may have been missed.
+			if n == nil {
+				c.invalidate(ctx, cache.All, "missed")
+				continue
+			}
+
+			var p change
+			if err := json.Unmarshal([]byte(n.Extra), &p); err != nil {
+				logging.Ctx(ctx).Error().Err(err).Str("payload", n.Extra).Msg("invalid analytics change notification")
+				c.invalidate(ctx, cache.All, "missed")
+				continue
+			}
+
+			c.invalidate(ctx, cache.Scope{Workspace: p.WorkspaceID, From: p.From, To: p.To}, "change")
+		case <-ping.C:
+			go func() { _ = l.Ping() }()
+		}
+	}
+}
+
+// invalidate invalidates the cached results in scope now and again after the
+// settle delay.
+func (c *CachedService) invalidate(ctx context.Context, scope cache.Scope, reason string) {
+	cacheInvalidations.WithLabelValues(reason).Inc()
+
+	c.invalidateScope(ctx, scope)
+
+	if c.opts.Settle <= 0 {
+		return
+	}
+
+	time.AfterFunc(c.opts.Settle, func() {
+		if ctx.Err() != nil {
+			return
+		}
+
+		c.invalidateScope(ctx, scope)
+	})
+}
+
+// invalidateScope bumps the generation of the workspace of scope, or of all
+// workspaces, and removes the cached results in scope.
+func (c *CachedService) invalidateScope(ctx context.Context, scope cache.Scope) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if scope.Workspace == uuid.Nil {
+		c.all++
+	} else {
+		c.generations[scope.Workspace]++
+	}
+
+	if err := c.store.Invalidate(ctx, scope); err != nil {
+		logging.Ctx(ctx).Error().Err(err).Msg("failed to invalidate cached analytics results")
+	}
+}
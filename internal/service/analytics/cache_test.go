@@ -0,0 +1,83 @@
+package analytics
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/aliskhannn/sales-tracker/internal/cache"
+)
+
+func TestCachedSkipsResultsInvalidatedWhileComputed(t *testing.T) {
+	workspace, other := uuid.New(), uuid.New()
+	day := time.Date(2025, 10, 1, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name       string
+		invalidate *cache.Scope // invalidated while the result is computed
+		wantCached bool
+	}{
+		{name: "no change", wantCached: true},
+		{name: "change in the workspace", invalidate: &cache.Scope{Workspace: workspace, From: &day, To: &day}},
+		{name: "missed notifications", invalidate: &cache.All},
+		{name: "change in another workspace", invalidate: &cache.Scope{Workspace: other}, wantCached: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			c := NewCachedService(nil, cache.NewLRU(10), CacheOptions{})
+			k := cacheKey{Metric: "sum", Workspace: workspace}
+
+			res, err := cached(ctx, c, k, func() (string, error) {
+				if tt.invalidate != nil {
+					c.invalidate(ctx, *tt.invalidate, "change")
+				}
+				return "100.00", nil
+			})
+			if err != nil || res != "100.00" {
+				t.Fatalf("cached() = %q, %v, want the computed result", res, err)
+			}
+
+			_, ok, err := c.store.Get(ctx, k.String())
+			if err != nil {
+				t.Fatal(err)
+			}
+			if ok != tt.wantCached {
+				t.Errorf("result cached = %v, want %v", ok, tt.wantCached)
+			}
+		})
+	}
+}
+
+func TestCachedReturnsCachedResult(t *testing.T) {
+	ctx := context.Background()
+	c := NewCachedService(nil, cache.NewLRU(10), CacheOptions{})
+	k := cacheKey{Metric: "count", Workspace: uuid.New()}
+
+	computed := 0
+	compute := func() (int64, error) {
+		computed++
+		return 42, nil
+	}
+
+	for range 2 {
+		if res, err := cached(ctx, c, k, compute); err != nil || res != 42 {
+			t.Fatalf("cached() = %d, %v, want 42", res, err)
+		}
+	}
+	if computed != 1 {
+		t.Errorf("computed %d times, want once", computed)
+	}
+
+	c.invalidate(ctx, k.scope(), "change")
+
+	if _, err := cached(ctx, c, k, compute); err != nil {
+		t.Fatal(err)
+	}
+	if computed != 2 {
+		t.Errorf("computed %d times after invalidation, want twice", computed)
+	}
+}
@@ -6,10 +6,16 @@ import (
 	"time"
 
 	"github.com/google/uuid"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
 
 	"github.com/aliskhannn/sales-tracker/internal/model"
+	"github.com/aliskhannn/sales-tracker/internal/tracing"
 )
 
+// tracer records the spans of analytics calls.
+var tracer = otel.Tracer("github.com/aliskhannn/sales-tracker/internal/service/analytics")
+
 // repository defines the required behavior for analytics persistence.
 type repository interface {
 	// Sum calculates the total amount of items matching the filter.
@@ -26,6 +32,15 @@ type repository interface {
 
 	// Percentile calculates the N-th percentile of items matching the filter.
 	Percentile(ctx context.Context, filter *model.ItemFilter, percentile float64) (string, error)
+
+	// Revenue calculates gross income, refunds and net revenue of items matching the filter.
+	Revenue(ctx context.Context, filter *model.ItemFilter) (*model.Revenue, error)
+
+	// ByCategory calculates count and sum per category of items matching the filter.
+	ByCategory(ctx context.Context, filter *model.ItemFilter) ([]model.CategoryTotal, error)
+
+	// ByTag calculates count and sum per tag of items matching the filter.
+	ByTag(ctx context.Context, filter *model.ItemFilter) ([]model.TagTotal, error)
 }
 
 // Service provides analytics-related business logic.
@@ -44,16 +59,24 @@ func (s *Service) Sum(
 	from, to *time.Time,
 	categoryID *uuid.UUID,
 	kind *string,
+	accountID *uuid.UUID,
+	tags *model.TagFilter,
 ) (string, error) {
+	ctx, span := tracer.Start(ctx, "analytics.Sum")
+	defer span.End()
+
 	filter := &model.ItemFilter{
 		From:       from,
 		To:         to,
 		CategoryID: categoryID,
 		Kind:       kind,
+		AccountID:  accountID,
+		Tags:       tags,
 	}
 
 	total, err := s.repository.Sum(ctx, filter)
 	if err != nil {
+		tracing.Fail(span, err)
 		return "", fmt.Errorf("analytics sum: %w", err)
 	}
 	return total, nil
@@ -65,16 +88,24 @@ func (s *Service) Avg(
 	from, to *time.Time,
 	categoryID *uuid.UUID,
 	kind *string,
+	accountID *uuid.UUID,
+	tags *model.TagFilter,
 ) (string, error) {
+	ctx, span := tracer.Start(ctx, "analytics.Avg")
+	defer span.End()
+
 	filter := &model.ItemFilter{
 		From:       from,
 		To:         to,
 		CategoryID: categoryID,
 		Kind:       kind,
+		AccountID:  accountID,
+		Tags:       tags,
 	}
 
 	avg, err := s.repository.Avg(ctx, filter)
 	if err != nil {
+		tracing.Fail(span, err)
 		return "", fmt.Errorf("analytics avg: %w", err)
 	}
 	return avg, nil
@@ -86,16 +117,24 @@ func (s *Service) Count(
 	from, to *time.Time,
 	categoryID *uuid.UUID,
 	kind *string,
+	accountID *uuid.UUID,
+	tags *model.TagFilter,
 ) (int64, error) {
+	ctx, span := tracer.Start(ctx, "analytics.Count")
+	defer span.End()
+
 	filter := &model.ItemFilter{
 		From:       from,
 		To:         to,
 		CategoryID: categoryID,
 		Kind:       kind,
+		AccountID:  accountID,
+		Tags:       tags,
 	}
 
 	cnt, err := s.repository.Count(ctx, filter)
 	if err != nil {
+		tracing.Fail(span, err)
 		return 0, fmt.Errorf("analytics count: %w", err)
 	}
 	return cnt, nil
@@ -107,16 +146,24 @@ func (s *Service) Median(
 	from, to *time.Time,
 	categoryID *uuid.UUID,
 	kind *string,
+	accountID *uuid.UUID,
+	tags *model.TagFilter,
 ) (string, error) {
+	ctx, span := tracer.Start(ctx, "analytics.Median")
+	defer span.End()
+
 	filter := &model.ItemFilter{
 		From:       from,
 		To:         to,
 		CategoryID: categoryID,
 		Kind:       kind,
+		AccountID:  accountID,
+		Tags:       tags,
 	}
 
 	median, err := s.repository.Median(ctx, filter)
 	if err != nil {
+		tracing.Fail(span, err)
 		return "", fmt.Errorf("analytics median: %w", err)
 	}
 	return median, nil
@@ -128,18 +175,114 @@ func (s *Service) Percentile(
 	from, to *time.Time,
 	categoryID *uuid.UUID,
 	kind *string,
+	accountID *uuid.UUID,
+	tags *model.TagFilter,
 	percentile float64,
 ) (string, error) {
+	ctx, span := tracer.Start(ctx, "analytics.Percentile")
+	defer span.End()
+
 	filter := &model.ItemFilter{
 		From:       from,
 		To:         to,
 		CategoryID: categoryID,
 		Kind:       kind,
+		AccountID:  accountID,
+		Tags:       tags,
 	}
 
+	span.SetAttributes(attribute.Float64("analytics.percentile", percentile))
+
 	value, err := s.repository.Percentile(ctx, filter, percentile)
 	if err != nil {
+		tracing.Fail(span, err)
 		return "", fmt.Errorf("analytics percentile: %w", err)
 	}
 	return value, nil
 }
+
+// Revenue returns gross income, refunds and net-of-refunds revenue of items
+// matching the filter.
+func (s *Service) Revenue(
+	ctx context.Context,
+	from, to *time.Time,
+	categoryID *uuid.UUID,
+	accountID *uuid.UUID,
+	tags *model.TagFilter,
+) (*model.Revenue, error) {
+	ctx, span := tracer.Start(ctx, "analytics.Revenue")
+	defer span.End()
+
+	filter := &model.ItemFilter{
+		From:       from,
+		To:         to,
+		CategoryID: categoryID,
+		AccountID:  accountID,
+		Tags:       tags,
+	}
+
+	rev, err := s.repository.Revenue(ctx, filter)
+	if err != nil {
+		tracing.Fail(span, err)
+		return nil, fmt.Errorf("analytics revenue: %w", err)
+	}
+	return rev, nil
+}
+
+// ByCategory returns count and sum per category of items matching the filter.
+// Split items are attributed to the categories of their splits.
+func (s *Service) ByCategory(
+	ctx context.Context,
+	from, to *time.Time,
+	kind *string,
+	accountID *uuid.UUID,
+	tags *model.TagFilter,
+) ([]model.CategoryTotal, error) {
+	ctx, span := tracer.Start(ctx, "analytics.ByCategory")
+	defer span.End()
+
+	filter := &model.ItemFilter{
+		From:      from,
+		To:        to,
+		Kind:      kind,
+		AccountID: accountID,
+		Tags:      tags,
+	}
+
+	totals, err := s.repository.ByCategory(ctx, filter)
+	if err != nil {
+		tracing.Fail(span, err)
+		return nil, fmt.Errorf("analytics by category: %w", err)
+	}
+	return totals, nil
+}
+
+// ByTag returns count and sum per tag of items matching the filter.
+// Items with several tags count towards each of them.
+func (s *Service) ByTag(
+	ctx context.Context,
+	from, to *time.Time,
+	categoryID *uuid.UUID,
+	kind *string,
+	accountID *uuid.UUID,
+	tags *model.TagFilter,
+) ([]model.TagTotal, error) {
+	ctx, span := tracer.Start(ctx, "analytics.ByTag")
+	defer span.End()
+
+	filter := &model.ItemFilter{
+		From:       from,
+		To:         to,
+		CategoryID: categoryID,
+		Kind:       kind,
+		AccountID:  accountID,
+		Tags:       tags,
+	}
+
+	totals, err := s.repository.ByTag(ctx, filter)
+	if err != nil {
+		tracing.Fail(span, err)
+		return nil, fmt.Errorf("analytics by tag: %w", err)
+	}
+	return totals, nil
+}
@@ -0,0 +1,164 @@
+package apikey
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/aliskhannn/sales-tracker/internal/auth"
+	"github.com/aliskhannn/sales-tracker/internal/logging"
+	"github.com/aliskhannn/sales-tracker/internal/model"
+	"github.com/aliskhannn/sales-tracker/internal/repository/apikey"
+)
+
+var (
+	ErrInvalidRole   = errors.New("role must be one of viewer, editor, admin")
+	ErrInvalidScope  = errors.New("unknown scope")
+	ErrExpiresInPast = errors.New("expires_at must be in the future")
+)
+
+// touchInterval is how stale last_used_at may get before an authenticated
+// request updates it, so that not every request writes to the database.
+const touchInterval = time.Minute
+
+// repository provides methods to interact with API keys.
+type repository interface {
+	// Create adds a new API key with the hash of its secret to the database.
+	Create(ctx context.Context, k *model.APIKey, hash string) (uuid.UUID, error)
+
+	// GetByHash retrieves an API key by the hash of its secret.
+	GetByHash(ctx context.Context, hash string) (*model.APIKey, error)
+
+	// List retrieves API keys, newest first, optionally only those of a workspace.
+	List(ctx context.Context, workspaceID *uuid.UUID) ([]model.APIKey, error)
+
+	// Revoke marks an API key as revoked, optionally only if it belongs to a workspace.
+	Revoke(ctx context.Context, id uuid.UUID, workspaceID *uuid.UUID) error
+
+	// TouchLastUsed records that an API key authenticated a request at t.
+	TouchLastUsed(ctx context.Context, id uuid.UUID, t time.Time) error
+}
+
+// Service provides API key management and authentication.
+type Service struct {
+	repository repository
+}
+
+// NewService creates a new API key service.
+func NewService(r repository) *Service {
+	return &Service{repository: r}
+}
+
+// Create issues a new API key, bound to workspaceID unless it is nil. The
+// returned secret is only available here; only its hash is stored.
+func (s *Service) Create(ctx context.Context, name, role string, scopes []string, workspaceID *uuid.UUID, expiresAt *time.Time) (*model.APIKey, string, error) {
+	if !model.IsRole(role) {
+		return nil, "", ErrInvalidRole
+	}
+
+	scopes, err := normalizeScopes(scopes)
+	if err != nil {
+		return nil, "", err
+	}
+
+	if expiresAt != nil && !expiresAt.After(time.Now()) {
+		return nil, "", ErrExpiresInPast
+	}
+
+	secret, prefix, hash, err := auth.GenerateKey()
+	if err != nil {
+		return nil, "", fmt.Errorf("generate api key: %w", err)
+	}
+
+	k := &model.APIKey{
+		Name:        strings.TrimSpace(name),
+		Prefix:      prefix,
+		Role:        role,
+		Scopes:      scopes,
+		WorkspaceID: workspaceID,
+		ExpiresAt:   expiresAt,
+	}
+
+	if _, err = s.repository.Create(ctx, k, hash); err != nil {
+		return nil, "", fmt.Errorf("create api key: %w", err)
+	}
+
+	return k, secret, nil
+}
+
+// List returns API keys without their secrets. With a non-nil workspaceID
+// only keys bound to that workspace are returned.
+func (s *Service) List(ctx context.Context, workspaceID *uuid.UUID) ([]model.APIKey, error) {
+	keys, err := s.repository.List(ctx, workspaceID)
+	if err != nil {
+		return nil, fmt.Errorf("list api keys: %w", err)
+	}
+
+	return keys, nil
+}
+
+// Revoke revokes an API key; requests using it are rejected from then on.
+// With a non-nil workspaceID only a key bound to that workspace is revoked.
+func (s *Service) Revoke(ctx context.Context, id uuid.UUID, workspaceID *uuid.UUID) error {
+	if err := s.repository.Revoke(ctx, id, workspaceID); err != nil {
+		return fmt.Errorf("revoke api key: %w", err)
+	}
+
+	return nil
+}
+
+// Authenticate returns the principal of an active API key.
+// Returns auth.ErrInvalidAPIKey if the key is unknown, revoked or expired.
+func (s *Service) Authenticate(ctx context.Context, key string) (*model.Principal, error) {
+	k, err := s.repository.GetByHash(ctx, auth.HashKey(key))
+	if err != nil {
+		if errors.Is(err, apikey.ErrAPIKeyNotFound) {
+			return nil, auth.ErrInvalidAPIKey
+		}
+
+		return nil, fmt.Errorf("get api key: %w", err)
+	}
+
+	now := time.Now()
+	if k.RevokedAt != nil || (k.ExpiresAt != nil && !now.Before(*k.ExpiresAt)) {
+		return nil, auth.ErrInvalidAPIKey
+	}
+
+	if k.LastUsedAt == nil || now.Sub(*k.LastUsedAt) >= touchInterval {
+		if err = s.repository.TouchLastUsed(ctx, k.ID, now); err != nil {
+			logging.Ctx(ctx).Error().Err(err).Str("api_key_id", k.ID.String()).Msg("failed to record api key usage")
+		}
+	}
+
+	return &model.Principal{
+		Subject:     k.ID.String(),
+		Role:        k.Role,
+		Scopes:      k.Scopes,
+		WorkspaceID: k.WorkspaceID,
+		Method:      model.AuthAPIKey,
+	}, nil
+}
+
+// normalizeScopes lower-cases, de-duplicates and checks scopes.
+func normalizeScopes(scopes []string) ([]string, error) {
+	seen := make(map[string]bool, len(scopes))
+	out := make([]string, 0, len(scopes))
+
+	for _, sc := range scopes {
+		sc = strings.ToLower(strings.TrimSpace(sc))
+		if !model.IsScope(sc) {
+			return nil, fmt.Errorf("%w: %q", ErrInvalidScope, sc)
+		}
+
+		if !seen[sc] {
+			seen[sc] = true
+			out = append(out, sc)
+		}
+	}
+
+	return out, nil
+}
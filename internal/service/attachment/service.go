@@ -0,0 +1,221 @@
+package attachment
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/google/uuid"
+
+	"github.com/aliskhannn/sales-tracker/internal/model"
+)
+
+var (
+	ErrFileTooLarge           = errors.New("file is too large")
+	ErrEmptyFile              = errors.New("file is empty")
+	ErrContentTypeNotAllowed  = errors.New("file type is not allowed")
+	ErrAttachmentContentsLost = errors.New("attachment contents are missing from storage")
+)
+
+// sniffLen is the number of leading bytes used to detect the content type.
+const sniffLen = 512
+
+// repository provides methods to interact with attachments.
+type repository interface {
+	// Create calls store and adds a new attachment, or loads the existing
+	// attachment of the item with the same SHA-256 and reports created as false.
+	Create(ctx context.Context, a *model.Attachment, store func(ctx context.Context) error) (created bool, err error)
+
+	// GetByID retrieves an attachment of an item by its ID.
+	GetByID(ctx context.Context, itemID, id uuid.UUID) (*model.Attachment, error)
+
+	// ListByItem retrieves the attachments of an item.
+	ListByItem(ctx context.Context, itemID uuid.UUID) ([]model.Attachment, error)
+
+	// Delete removes an attachment and calls remove with its SHA-256 if no
+	// other attachment references its contents.
+	Delete(ctx context.Context, itemID, id uuid.UUID, remove func(ctx context.Context, sha256 string) error) error
+}
+
+// itemRepository provides read access to items.
+type itemRepository interface {
+	// GetByID retrieves an item by its ID.
+	GetByID(ctx context.Context, id uuid.UUID) (*model.Item, error)
+}
+
+// storage stores attachment contents by key. The default implementation
+// writes to a local directory; other backends only need to implement it.
+type storage interface {
+	// Put stores the contents of r under key, replacing an existing object.
+	Put(ctx context.Context, key string, r io.Reader) error
+
+	// Exists reports whether an object is stored under key.
+	Exists(ctx context.Context, key string) (bool, error)
+
+	// Open returns a reader of the object stored under key.
+	Open(ctx context.Context, key string) (io.ReadCloser, error)
+
+	// Delete removes the object stored under key.
+	Delete(ctx context.Context, key string) error
+}
+
+// Service provides attachment-related business logic.
+type Service struct {
+	repository   repository
+	items        itemRepository
+	storage      storage
+	maxSize      int64
+	allowedTypes []string
+}
+
+// NewService creates a new attachment service. Files larger than maxSize bytes
+// are rejected; if allowedTypes is not empty, only files whose sniffed content
+// type is in the list are accepted.
+func NewService(r repository, items itemRepository, s storage, maxSize int64, allowedTypes []string) *Service {
+	return &Service{
+		repository:   r,
+		items:        items,
+		storage:      s,
+		maxSize:      maxSize,
+		allowedTypes: allowedTypes,
+	}
+}
+
+// Upload attaches a file to an item. The content type is sniffed from the
+// contents rather than trusted from the client. Contents are stored once per
+// SHA-256; uploading the same file to the same item again returns the existing
+// attachment with created set to false.
+func (s *Service) Upload(ctx context.Context, itemID uuid.UUID, filename string, r io.Reader) (a *model.Attachment, created bool, err error) {
+	if _, err = s.items.GetByID(ctx, itemID); err != nil {
+		return nil, false, fmt.Errorf("upload attachment: %w", err)
+	}
+
+	// Spool the upload to a temporary file to hash it before storing it
+	// under its hash.
+	tmp, err := os.CreateTemp("", "attachment-*")
+	if err != nil {
+		return nil, false, fmt.Errorf("upload attachment: %w", err)
+	}
+	defer func() {
+		_ = tmp.Close()
+		_ = os.Remove(tmp.Name())
+	}()
+
+	h := sha256.New()
+	size, err := io.Copy(io.MultiWriter(tmp, h), io.LimitReader(r, s.maxSize+1))
+	if err != nil {
+		return nil, false, fmt.Errorf("upload attachment: %w", err)
+	}
+
+	if size == 0 {
+		return nil, false, ErrEmptyFile
+	}
+
+	if size > s.maxSize {
+		return nil, false, ErrFileTooLarge
+	}
+
+	head := make([]byte, sniffLen)
+	n, err := tmp.ReadAt(head, 0)
+	if err != nil && !errors.Is(err, io.EOF) {
+		return nil, false, fmt.Errorf("upload attachment: %w", err)
+	}
+
+	contentType := http.DetectContentType(head[:n])
+	if len(s.allowedTypes) > 0 && !slices.Contains(s.allowedTypes, contentType) {
+		return nil, false, fmt.Errorf("%w: %s", ErrContentTypeNotAllowed, contentType)
+	}
+
+	a = &model.Attachment{
+		ItemID:      itemID,
+		Filename:    cleanFilename(filename),
+		ContentType: contentType,
+		Size:        size,
+		SHA256:      hex.EncodeToString(h.Sum(nil)),
+	}
+
+	// Contents are stored under the lock of the row being added, so a
+	// concurrent Delete of the same contents cannot remove them in between.
+	created, err = s.repository.Create(ctx, a, func(ctx context.Context) error {
+		exists, err := s.storage.Exists(ctx, a.SHA256)
+		if err != nil || exists {
+			return err
+		}
+
+		if _, err = tmp.Seek(0, io.SeekStart); err != nil {
+			return err
+		}
+
+		return s.storage.Put(ctx, a.SHA256, tmp)
+	})
+	if err != nil {
+		return nil, false, fmt.Errorf("upload attachment: %w", err)
+	}
+
+	return a, created, nil
+}
+
+// List returns the attachments of an item.
+func (s *Service) List(ctx context.Context, itemID uuid.UUID) ([]model.Attachment, error) {
+	if _, err := s.items.GetByID(ctx, itemID); err != nil {
+		return nil, fmt.Errorf("list attachments: %w", err)
+	}
+
+	attachments, err := s.repository.ListByItem(ctx, itemID)
+	if err != nil {
+		return nil, fmt.Errorf("list attachments: %w", err)
+	}
+
+	return attachments, nil
+}
+
+// Open returns an attachment of an item together with a reader of its
+// contents. The caller must close the reader.
+func (s *Service) Open(ctx context.Context, itemID, id uuid.UUID) (*model.Attachment, io.ReadCloser, error) {
+	a, err := s.repository.GetByID(ctx, itemID, id)
+	if err != nil {
+		return nil, nil, fmt.Errorf("open attachment: %w", err)
+	}
+
+	rc, err := s.storage.Open(ctx, a.SHA256)
+	if err != nil {
+		return nil, nil, fmt.Errorf("open attachment: %w: %w", ErrAttachmentContentsLost, err)
+	}
+
+	return a, rc, nil
+}
+
+// Delete removes an attachment of an item. Its contents are removed from
+// storage once no other attachment references them.
+func (s *Service) Delete(ctx context.Context, itemID, id uuid.UUID) error {
+	err := s.repository.Delete(ctx, itemID, id, func(ctx context.Context, sum string) error {
+		if err := s.storage.Delete(ctx, sum); err != nil {
+			return fmt.Errorf("delete attachment contents: %w", err)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("delete attachment: %w", err)
+	}
+
+	return nil
+}
+
+// cleanFilename strips any directory part from a client-provided file name.
+func cleanFilename(name string) string {
+	name = filepath.Base(strings.ReplaceAll(name, `\`, "/"))
+	if name == "." || name == "/" || name == "" {
+		return "attachment"
+	}
+
+	return name
+}
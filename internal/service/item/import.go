@@ -0,0 +1,185 @@
+package item
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/aliskhannn/sales-tracker/internal/logging"
+	"github.com/aliskhannn/sales-tracker/internal/model"
+	"github.com/aliskhannn/sales-tracker/internal/statement"
+	"github.com/aliskhannn/sales-tracker/internal/validation"
+)
+
+var (
+	ErrImportCurrency = errors.New("entry has no valid currency, pass currency or account_id")
+	ErrImportAmount   = errors.New("entry has an invalid amount")
+)
+
+// msgImportFailed is reported for entries that failed for an unexpected reason.
+const msgImportFailed = "the entry could not be imported, try again later"
+
+// Import parses a bank file in the given format and creates an item for every
+// entry through Create, so rules and validation apply as usual. Credits
+// become income and debits expenses. The bank reference (e.g. FITID) is
+// stored in metadata.bank_reference, or a fingerprint of the entry if the
+// bank gave none; entries whose reference is already stored for the same
+// account are skipped, so importing overlapping files creates no duplicates.
+// accountID can be nil. Entries without a currency use currency, or the
+// account currency if currency is empty. Entries that cannot be imported are
+// reported as failed and the import continues, since the items of earlier
+// entries are already created; importing the file again retries them.
+func (s *Service) Import(
+	ctx context.Context,
+	format string,
+	r io.Reader,
+	accountID *uuid.UUID,
+	currency string,
+) (*model.ImportReport, error) {
+	lines, err := statement.Parse(format, r)
+	if err != nil {
+		return nil, err
+	}
+
+	currency = strings.ToUpper(currency)
+	if accountID != nil {
+		a, err := s.accounts.GetByID(ctx, *accountID)
+		if err != nil {
+			return nil, fmt.Errorf("import items: %w", err)
+		}
+
+		if currency == "" {
+			currency = a.Currency
+		}
+	}
+
+	refs := importReferences(lines)
+	existing, err := s.repository.ExistingBankReferences(ctx, accountID, refs)
+	if err != nil {
+		return nil, fmt.Errorf("import items: %w", err)
+	}
+
+	report := &model.ImportReport{
+		Format:  format,
+		Total:   len(lines),
+		Entries: make([]model.ImportEntry, 0, len(lines)),
+	}
+
+	for n, l := range lines {
+		entry := model.ImportEntry{
+			Line:      n + 1,
+			Reference: refs[n],
+			Amount:    l.Amount,
+		}
+
+		if existing[entry.Reference] {
+			entry.Status = model.ImportDuplicate
+			report.Duplicates++
+			report.Entries = append(report.Entries, entry)
+			continue
+		}
+
+		id, err := s.importLine(ctx, format, l, entry.Reference, accountID, currency)
+		switch {
+		case err == nil:
+			entry.Status = model.ImportCreated
+			entry.ItemID = &id
+			existing[entry.Reference] = true
+			report.Created++
+		case errors.Is(err, ErrImportCurrency), errors.Is(err, ErrImportAmount), errors.Is(err, ErrCurrencyMismatch):
+			entry.Status = model.ImportFailed
+			entry.Error = err.Error()
+			report.Failed++
+		default:
+			// The cause may be internal, e.g. a database error, so it is logged
+			// rather than reported.
+			logging.Ctx(ctx).Error().Err(err).Int("line", entry.Line).Msg("failed to import entry")
+			entry.Status = model.ImportFailed
+			entry.Error = msgImportFailed
+			report.Failed++
+		}
+
+		report.Entries = append(report.Entries, entry)
+	}
+
+	return report, nil
+}
+
+// importLine creates the item of a single bank file entry.
+func (s *Service) importLine(
+	ctx context.Context,
+	format string,
+	l statement.Line,
+	reference string,
+	accountID *uuid.UUID,
+	currency string,
+) (uuid.UUID, error) {
+	if l.Currency != "" {
+		currency = l.Currency
+	}
+
+	units, ok := validation.MinorUnits(currency)
+	if !ok {
+		return uuid.Nil, ErrImportCurrency
+	}
+
+	if err := validation.CheckAmount(l.Amount.Abs(), min(units, validation.AmountScale)); err != nil {
+		return uuid.Nil, fmt.Errorf("%w: %s", ErrImportAmount, err)
+	}
+
+	kind := model.KindExpense
+	if l.Amount.IsPositive() {
+		kind = model.KindIncome
+	}
+
+	title := l.Description
+	if title == "" {
+		title = "Bank transaction"
+	}
+
+	metadata, err := json.Marshal(map[string]string{
+		"bank_reference": reference,
+		"import_format":  format,
+	})
+	if err != nil {
+		return uuid.Nil, err
+	}
+
+	return s.Create(ctx, kind, title, l.Amount.Abs(), currency, l.Date, nil, accountID, nil, metadata, nil)
+}
+
+// importReferences returns the bank reference of every line. Lines without a
+// reference get a fingerprint of their date, amount, currency and
+// description; identical lines within the file are told apart by their
+// occurrence, so they are imported once each and skipped on re-import.
+func importReferences(lines []statement.Line) []string {
+	refs := make([]string, len(lines))
+	occurrences := make(map[string]int)
+	for n, l := range lines {
+		if l.Reference != "" {
+			refs[n] = l.Reference
+			continue
+		}
+
+		key := strings.Join([]string{
+			l.Date.UTC().Format(time.DateOnly),
+			l.Amount.String(),
+			l.Currency,
+			strings.ToLower(l.Description),
+		}, "|")
+		occurrences[key]++
+
+		sum := sha256.Sum256(fmt.Appendf(nil, "%s|%d", key, occurrences[key]))
+		refs[n] = "fp:" + hex.EncodeToString(sum[:16])
+	}
+
+	return refs
+}
@@ -0,0 +1,85 @@
+package item
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/shopspring/decimal"
+
+	"github.com/aliskhannn/sales-tracker/internal/statement"
+)
+
+func TestImportReferences(t *testing.T) {
+	day := time.Date(2025, 10, 1, 0, 0, 0, 0, time.UTC)
+	coffee := statement.Line{Date: day, Amount: decimal.RequireFromString("-3.50"), Currency: "EUR", Description: "Coffee"}
+
+	with := func(change func(l *statement.Line)) statement.Line {
+		l := coffee
+		change(&l)
+		return l
+	}
+
+	tests := []struct {
+		name string
+		a, b statement.Line
+		same bool // whether a and b, each alone in a file, get the same reference
+	}{
+		{name: "identical", a: coffee, b: coffee, same: true},
+		{name: "description case and trailing zeros", a: coffee, b: with(func(l *statement.Line) {
+			l.Description = "COFFEE"
+			l.Amount = decimal.RequireFromString("-3.5")
+		}), same: true},
+		{name: "date is taken in UTC", a: coffee, b: with(func(l *statement.Line) {
+			l.Date = time.Date(2025, 10, 1, 1, 0, 0, 0, time.FixedZone("CEST", 2*60*60))
+		}), same: false},
+		{name: "time of day", a: coffee, b: with(func(l *statement.Line) { l.Date = day.Add(15 * time.Hour) }), same: true},
+		{name: "date", a: coffee, b: with(func(l *statement.Line) { l.Date = day.AddDate(0, 0, 1) }), same: false},
+		{name: "amount", a: coffee, b: with(func(l *statement.Line) { l.Amount = decimal.RequireFromString("3.50") }), same: false},
+		{name: "currency", a: coffee, b: with(func(l *statement.Line) { l.Currency = "USD" }), same: false},
+		{name: "description", a: coffee, b: with(func(l *statement.Line) { l.Description = "Tea" }), same: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a := importReferences([]statement.Line{tt.a})[0]
+			b := importReferences([]statement.Line{tt.b})[0]
+
+			if !strings.HasPrefix(a, "fp:") || len(a) != len("fp:")+32 {
+				t.Fatalf("reference = %q, want a fingerprint", a)
+			}
+
+			if (a == b) != tt.same {
+				t.Errorf("references %q and %q, want same = %v", a, b, tt.same)
+			}
+		})
+	}
+}
+
+func TestImportReferencesWithinFile(t *testing.T) {
+	day := time.Date(2025, 10, 1, 0, 0, 0, 0, time.UTC)
+	coffee := statement.Line{Date: day, Amount: decimal.RequireFromString("-3.50"), Currency: "EUR", Description: "Coffee"}
+	rent := statement.Line{Date: day, Amount: decimal.NewFromInt(-900), Currency: "EUR", Description: "Rent", Reference: "TX-9"}
+
+	lines := []statement.Line{coffee, rent, coffee, coffee}
+	refs := importReferences(lines)
+
+	if refs[1] != "TX-9" {
+		t.Errorf("reference of a line with a bank reference = %q, want TX-9", refs[1])
+	}
+
+	// Identical lines are told apart by their occurrence in the file.
+	if refs[0] == refs[2] || refs[0] == refs[3] || refs[2] == refs[3] {
+		t.Errorf("references of identical lines = %q, %q, %q, want distinct", refs[0], refs[2], refs[3])
+	}
+
+	// Re-importing the file, or a file with only the first of them, gives the
+	// same references.
+	if again := importReferences(lines); strings.Join(again, ",") != strings.Join(refs, ",") {
+		t.Errorf("references on re-import = %q, want %q", again, refs)
+	}
+
+	if first := importReferences([]statement.Line{coffee}); first[0] != refs[0] {
+		t.Errorf("reference of the only line = %q, want %q", first[0], refs[0])
+	}
+}
@@ -3,6 +3,7 @@ package item
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"time"
 
@@ -10,38 +11,106 @@ import (
 	"github.com/shopspring/decimal"
 
 	"github.com/aliskhannn/sales-tracker/internal/model"
+	"github.com/aliskhannn/sales-tracker/internal/repository/item"
+)
+
+var (
+	ErrInvalidSplitAmount = errors.New("split amount must be positive")
+	ErrCurrencyMismatch   = errors.New("item currency does not match account currency")
+	ErrSameAccount        = errors.New("transfer source and destination accounts must differ")
+	ErrRefundOfKind       = errors.New("refund_of can only be set on refund items")
+	ErrNoDuplicates       = errors.New("at least one duplicate item is required")
+	ErrMergeSelf          = errors.New("item cannot be merged into itself")
+	ErrMergeTransfer      = errors.New("transfer legs cannot be merged")
+	ErrMergeMismatch      = errors.New("merged items must have the same amount and currency")
 )
 
 type repository interface {
 	// Create adds a new item to the database.
 	Create(ctx context.Context, i *model.Item) (uuid.UUID, error)
 
+	// CreateTransfer atomically adds both legs of a transfer and links them.
+	CreateTransfer(ctx context.Context, source, destination *model.Item) (uuid.UUID, error)
+
 	// GetByID retrieves an item by its ID.
 	GetByID(ctx context.Context, id uuid.UUID) (*model.Item, error)
 
 	// List retrieves items from the database applying optional filters.
-	// Filters can include date range (From, To), category, kind, pagination (Limit, Offset),
+	// Filters can include date range (From, To), category, kind, account, pagination (Limit, Offset),
 	// and sort order (SortBy).
 	List(ctx context.Context, filter *model.ItemFilter) ([]model.Item, error)
 
 	// Update updates an item.
 	Update(ctx context.Context, i *model.Item) error
 
-	// Delete removes an item from the database.
-	Delete(ctx context.Context, id uuid.UUID) error
+	// Delete removes an item and its attachments, and calls remove with the
+	// SHA-256 of each of their contents no other attachment references.
+	Delete(ctx context.Context, id uuid.UUID, remove func(ctx context.Context, sha256 string) error) error
+
+	// ListSplits retrieves the splits of an item.
+	ListSplits(ctx context.Context, itemID uuid.UUID) ([]model.ItemSplit, error)
+
+	// ReplaceSplits atomically replaces the splits of an item.
+	ReplaceSplits(ctx context.Context, itemID uuid.UUID, splits []model.ItemSplit) error
+
+	// ListRefunds retrieves refund items that reference the given item.
+	ListRefunds(ctx context.Context, itemID uuid.UUID) ([]model.Item, error)
+
+	// ListByIDs retrieves items with the given IDs.
+	ListByIDs(ctx context.Context, ids []uuid.UUID) ([]model.Item, error)
+
+	// FindDuplicatePairs returns pairs of IDs of likely duplicate items.
+	FindDuplicatePairs(ctx context.Context, from, to *time.Time, window time.Duration, similarity float64) ([][2]uuid.UUID, error)
+
+	// FindSimilar returns existing items that are likely duplicates of the given item.
+	FindSimilar(ctx context.Context, i *model.Item, window time.Duration, similarity float64) ([]model.Item, error)
+
+	// Merge keeps one item with the given metadata and deletes the duplicates.
+	Merge(ctx context.Context, keepID uuid.UUID, duplicateIDs []uuid.UUID, metadata json.RawMessage) error
+
+	// ExistingBankReferences returns which of refs are already stored as bank reference of an item in the account.
+	ExistingBankReferences(ctx context.Context, accountID *uuid.UUID, refs []string) (map[string]bool, error)
+}
+
+// accountRepository provides read access to accounts.
+type accountRepository interface {
+	// GetByID retrieves an account by its ID.
+	GetByID(ctx context.Context, id uuid.UUID) (*model.Account, error)
+}
+
+// ruleApplier runs auto-categorization rules.
+type ruleApplier interface {
+	// Apply runs enabled rules over a new item and modifies it in place.
+	Apply(ctx context.Context, i *model.Item) error
+}
+
+// contentStorage removes attachment contents by key.
+type contentStorage interface {
+	// Delete removes the object stored under key.
+	Delete(ctx context.Context, key string) error
 }
 
 // Service provides item-related business logic.
 type Service struct {
 	repository repository
+	accounts   accountRepository
+	rules      ruleApplier
+	contents   contentStorage
 }
 
-// NewService creates a new item service.
-func NewService(r repository) *Service {
-	return &Service{repository: r}
+// NewService creates a new item service. The contents of attachments of
+// deleted items are removed from contents.
+func NewService(r repository, accounts accountRepository, rules ruleApplier, contents contentStorage) *Service {
+	return &Service{repository: r, accounts: accounts, rules: rules, contents: contents}
 }
 
 // Create adds a new item with the given fields.
+// Auto-categorization rules run first and may change kind, category and metadata,
+// but never replace categoryID or metadata keys given by the caller.
+// accountID can be nil; otherwise currency must match the account currency.
+// refundOf can be nil; otherwise the item must be a refund that, together with
+// other refunds, does not exceed the refunded item amount.
+// splits can be nil; otherwise their amounts must sum to amount.
 func (s *Service) Create(
 	ctx context.Context,
 	kind string,
@@ -50,8 +119,15 @@ func (s *Service) Create(
 	currency string,
 	occurredAt time.Time,
 	categoryID *uuid.UUID,
+	accountID *uuid.UUID,
+	refundOf *uuid.UUID,
 	metadata json.RawMessage,
+	splits []model.ItemSplit,
 ) (uuid.UUID, error) {
+	if err := validateSplits(amount, splits); err != nil {
+		return uuid.Nil, err
+	}
+
 	i := &model.Item{
 		Kind:       kind,
 		Title:      title,
@@ -59,7 +135,22 @@ func (s *Service) Create(
 		Currency:   currency,
 		OccurredAt: occurredAt,
 		CategoryID: categoryID,
+		AccountID:  accountID,
+		RefundOf:   refundOf,
 		Metadata:   metadata,
+		Splits:     splits,
+	}
+
+	if err := s.rules.Apply(ctx, i); err != nil {
+		return uuid.Nil, fmt.Errorf("create item: %w", err)
+	}
+
+	if i.RefundOf != nil && i.Kind != model.KindRefund {
+		return uuid.Nil, ErrRefundOfKind
+	}
+
+	if err := s.checkAccount(ctx, accountID, currency); err != nil {
+		return uuid.Nil, fmt.Errorf("create item: %w", err)
 	}
 
 	id, err := s.repository.Create(ctx, i)
@@ -81,12 +172,15 @@ func (s *Service) GetByID(ctx context.Context, id uuid.UUID) (*model.Item, error
 }
 
 // List returns items applying the given filters such as date range,
-// category, kind, pagination, and sort order.
+// category, kind, account, tags, reconciliation state, pagination, and sort order.
 func (s *Service) List(
 	ctx context.Context,
 	from, to *time.Time,
 	categoryID *uuid.UUID,
 	kind *string,
+	accountID *uuid.UUID,
+	tags *model.TagFilter,
+	reconciled *bool,
 	limit, offset int,
 	sortBy string,
 ) ([]model.Item, error) {
@@ -95,6 +189,9 @@ func (s *Service) List(
 		To:         to,
 		CategoryID: categoryID,
 		Kind:       kind,
+		AccountID:  accountID,
+		Tags:       tags,
+		Reconciled: reconciled,
 		Limit:      limit,
 		Offset:     offset,
 		SortBy:     sortBy,
@@ -109,6 +206,8 @@ func (s *Service) List(
 }
 
 // Update modifies an existing item by its ID.
+// If splits is nil, existing splits are kept and must still sum to amount;
+// otherwise they are replaced, and an empty slice removes them.
 func (s *Service) Update(
 	ctx context.Context,
 	id uuid.UUID,
@@ -118,8 +217,23 @@ func (s *Service) Update(
 	currency string,
 	occurredAt time.Time,
 	categoryID *uuid.UUID,
+	accountID *uuid.UUID,
+	refundOf *uuid.UUID,
 	metadata json.RawMessage,
+	splits []model.ItemSplit,
 ) error {
+	if err := validateSplits(amount, splits); err != nil {
+		return err
+	}
+
+	if refundOf != nil && kind != model.KindRefund {
+		return ErrRefundOfKind
+	}
+
+	if err := s.checkAccount(ctx, accountID, currency); err != nil {
+		return fmt.Errorf("update item: %w", err)
+	}
+
 	i := &model.Item{
 		ID:         id,
 		Kind:       kind,
@@ -128,7 +242,10 @@ func (s *Service) Update(
 		Currency:   currency,
 		OccurredAt: occurredAt,
 		CategoryID: categoryID,
+		AccountID:  accountID,
+		RefundOf:   refundOf,
 		Metadata:   metadata,
+		Splits:     splits,
 	}
 
 	err := s.repository.Update(ctx, i)
@@ -139,12 +256,391 @@ func (s *Service) Update(
 	return nil
 }
 
-// Delete removes an item by its ID.
+// Delete removes an item by its ID together with its attachments. Their
+// contents are removed from storage once no other attachment references them.
 func (s *Service) Delete(ctx context.Context, id uuid.UUID) error {
-	err := s.repository.Delete(ctx, id)
+	err := s.repository.Delete(ctx, id, func(ctx context.Context, sum string) error {
+		if err := s.contents.Delete(ctx, sum); err != nil {
+			return fmt.Errorf("delete attachment contents: %w", err)
+		}
+
+		return nil
+	})
 	if err != nil {
 		return fmt.Errorf("delete item: %w", err)
 	}
 
 	return nil
 }
+
+// CreateTransfer moves money between two accounts. It creates a source leg
+// on the source account and a destination leg on the destination account,
+// both of kind transfer, atomically. destinationAmount is used for the
+// destination leg when the accounts have different currencies; if nil,
+// amount is used for both legs.
+func (s *Service) CreateTransfer(
+	ctx context.Context,
+	sourceAccountID uuid.UUID,
+	destinationAccountID uuid.UUID,
+	title string,
+	amount decimal.Decimal,
+	destinationAmount *decimal.Decimal,
+	occurredAt time.Time,
+	metadata json.RawMessage,
+) (*model.Transfer, error) {
+	if sourceAccountID == destinationAccountID {
+		return nil, ErrSameAccount
+	}
+
+	src, err := s.accounts.GetByID(ctx, sourceAccountID)
+	if err != nil {
+		return nil, fmt.Errorf("create transfer: %w", err)
+	}
+
+	dst, err := s.accounts.GetByID(ctx, destinationAccountID)
+	if err != nil {
+		return nil, fmt.Errorf("create transfer: %w", err)
+	}
+
+	dstAmount := amount
+	if destinationAmount != nil {
+		dstAmount = *destinationAmount
+	} else if src.Currency != dst.Currency {
+		return nil, ErrCurrencyMismatch
+	}
+
+	source := &model.Item{
+		Kind:       model.KindTransfer,
+		Title:      title,
+		Amount:     amount,
+		Currency:   src.Currency,
+		OccurredAt: occurredAt,
+		AccountID:  &src.ID,
+		Metadata:   metadata,
+	}
+	destination := &model.Item{
+		Kind:       model.KindTransfer,
+		Title:      title,
+		Amount:     dstAmount,
+		Currency:   dst.Currency,
+		OccurredAt: occurredAt,
+		AccountID:  &dst.ID,
+		Metadata:   metadata,
+	}
+
+	transferID, err := s.repository.CreateTransfer(ctx, source, destination)
+	if err != nil {
+		return nil, fmt.Errorf("create transfer: %w", err)
+	}
+
+	return &model.Transfer{
+		ID:            transferID,
+		SourceID:      source.ID,
+		DestinationID: destination.ID,
+	}, nil
+}
+
+// Refunds returns the refunds of an item together with the refunded and
+// remaining amounts.
+func (s *Service) Refunds(ctx context.Context, id uuid.UUID) (*model.RefundSummary, error) {
+	i, err := s.repository.GetByID(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("list refunds: %w", err)
+	}
+
+	refunds, err := s.repository.ListRefunds(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("list refunds: %w", err)
+	}
+
+	refunded := decimal.Zero
+	for _, r := range refunds {
+		refunded = refunded.Add(r.Amount)
+	}
+
+	if refunds == nil {
+		refunds = []model.Item{}
+	}
+
+	return &model.RefundSummary{
+		ItemID:    i.ID,
+		Currency:  i.Currency,
+		Amount:    i.Amount,
+		Refunded:  refunded,
+		Remaining: i.Amount.Sub(refunded),
+		Refunds:   refunds,
+	}, nil
+}
+
+// Duplicates returns clusters of likely duplicate items. Two items are
+// duplicates if they have the same amount and currency, occurred at most
+// window apart and their titles have a trigram similarity of at least
+// similarity; clusters are the transitive closure of that relation.
+func (s *Service) Duplicates(
+	ctx context.Context,
+	from, to *time.Time,
+	window time.Duration,
+	similarity float64,
+) ([]model.DuplicateCluster, error) {
+	pairs, err := s.repository.FindDuplicatePairs(ctx, from, to, window, similarity)
+	if err != nil {
+		return nil, fmt.Errorf("find duplicates: %w", err)
+	}
+
+	if len(pairs) == 0 {
+		return []model.DuplicateCluster{}, nil
+	}
+
+	parent := make(map[uuid.UUID]uuid.UUID)
+	var find func(id uuid.UUID) uuid.UUID
+	find = func(id uuid.UUID) uuid.UUID {
+		p, ok := parent[id]
+		if !ok || p == id {
+			parent[id] = id
+			return id
+		}
+
+		root := find(p)
+		parent[id] = root
+		return root
+	}
+
+	for _, p := range pairs {
+		a, b := find(p[0]), find(p[1])
+		if a != b {
+			parent[b] = a
+		}
+	}
+
+	ids := make([]uuid.UUID, 0, len(parent))
+	for id := range parent {
+		ids = append(ids, id)
+	}
+
+	items, err := s.repository.ListByIDs(ctx, ids)
+	if err != nil {
+		return nil, fmt.Errorf("find duplicates: %w", err)
+	}
+
+	// items are ordered by occurred_at, so clusters are ordered by their
+	// earliest item and items within a cluster by occurred_at.
+	index := make(map[uuid.UUID]int)
+	clusters := []model.DuplicateCluster{}
+	for _, i := range items {
+		root := find(i.ID)
+		n, ok := index[root]
+		if !ok {
+			n = len(clusters)
+			index[root] = n
+			clusters = append(clusters, model.DuplicateCluster{})
+		}
+
+		clusters[n].Items = append(clusters[n].Items, i)
+	}
+
+	return clusters, nil
+}
+
+// FindSimilar returns existing items that are likely duplicates of an item
+// with the given fields, using the same criteria as Duplicates.
+func (s *Service) FindSimilar(
+	ctx context.Context,
+	title string,
+	amount decimal.Decimal,
+	currency string,
+	occurredAt time.Time,
+	window time.Duration,
+	similarity float64,
+) ([]model.Item, error) {
+	i := &model.Item{
+		Title:      title,
+		Amount:     amount,
+		Currency:   currency,
+		OccurredAt: occurredAt,
+	}
+
+	items, err := s.repository.FindSimilar(ctx, i, window, similarity)
+	if err != nil {
+		return nil, fmt.Errorf("find similar items: %w", err)
+	}
+
+	return items, nil
+}
+
+// Merge keeps the item keepID and deletes the duplicates. Metadata of all
+// items is combined, with keys of the kept item taking precedence over keys of
+// the duplicates; the IDs of the merged duplicates are appended to the
+// "merged_from" metadata key. Refunds and recurring occurrences referencing a
+// duplicate are moved to the kept item. Transfer legs cannot be merged.
+func (s *Service) Merge(ctx context.Context, keepID uuid.UUID, duplicateIDs []uuid.UUID) (*model.Item, error) {
+	if len(duplicateIDs) == 0 {
+		return nil, ErrNoDuplicates
+	}
+
+	seen := map[uuid.UUID]bool{keepID: true}
+	ids := []uuid.UUID{keepID}
+	for _, id := range duplicateIDs {
+		if id == keepID {
+			return nil, ErrMergeSelf
+		}
+
+		if !seen[id] {
+			seen[id] = true
+			ids = append(ids, id)
+		}
+	}
+
+	items, err := s.repository.ListByIDs(ctx, ids)
+	if err != nil {
+		return nil, fmt.Errorf("merge items: %w", err)
+	}
+
+	if len(items) != len(ids) {
+		return nil, fmt.Errorf("merge items: %w", item.ErrItemNotFound)
+	}
+
+	byID := make(map[uuid.UUID]model.Item, len(items))
+	for _, i := range items {
+		if i.TransferID != nil {
+			return nil, ErrMergeTransfer
+		}
+
+		byID[i.ID] = i
+	}
+
+	keep := byID[keepID]
+	for _, i := range items {
+		if !i.Amount.Equal(keep.Amount) || i.Currency != keep.Currency {
+			return nil, ErrMergeMismatch
+		}
+	}
+
+	metadata, err := mergeMetadata(keep, ids[1:], byID)
+	if err != nil {
+		return nil, fmt.Errorf("merge items: %w", err)
+	}
+
+	if err = s.repository.Merge(ctx, keepID, ids[1:], metadata); err != nil {
+		return nil, fmt.Errorf("merge items: %w", err)
+	}
+
+	merged, err := s.repository.GetByID(ctx, keepID)
+	if err != nil {
+		return nil, fmt.Errorf("merge items: %w", err)
+	}
+
+	return merged, nil
+}
+
+// mergeMetadata combines metadata of the kept item and its duplicates.
+// Keys of the kept item win; among duplicates, earlier ones win. Metadata that
+// is not a JSON object is ignored.
+func mergeMetadata(keep model.Item, duplicateIDs []uuid.UUID, byID map[uuid.UUID]model.Item) (json.RawMessage, error) {
+	combined := make(map[string]json.RawMessage)
+
+	for n := len(duplicateIDs) - 1; n >= 0; n-- {
+		var m map[string]json.RawMessage
+		if err := json.Unmarshal(byID[duplicateIDs[n]].Metadata, &m); err != nil {
+			continue
+		}
+
+		for k, v := range m {
+			combined[k] = v
+		}
+	}
+
+	var own map[string]json.RawMessage
+	if err := json.Unmarshal(keep.Metadata, &own); err == nil {
+		for k, v := range own {
+			combined[k] = v
+		}
+	}
+
+	var mergedFrom []string
+	if raw, ok := own["merged_from"]; ok {
+		_ = json.Unmarshal(raw, &mergedFrom)
+	}
+
+	for _, id := range duplicateIDs {
+		mergedFrom = append(mergedFrom, id.String())
+	}
+
+	raw, err := json.Marshal(mergedFrom)
+	if err != nil {
+		return nil, err
+	}
+	combined["merged_from"] = raw
+
+	return json.Marshal(combined)
+}
+
+// checkAccount verifies that the account exists and uses the given currency.
+// A nil accountID is always valid.
+func (s *Service) checkAccount(ctx context.Context, accountID *uuid.UUID, currency string) error {
+	if accountID == nil {
+		return nil
+	}
+
+	a, err := s.accounts.GetByID(ctx, *accountID)
+	if err != nil {
+		return err
+	}
+
+	if a.Currency != currency {
+		return ErrCurrencyMismatch
+	}
+
+	return nil
+}
+
+// ListSplits returns the splits of an item.
+func (s *Service) ListSplits(ctx context.Context, itemID uuid.UUID) ([]model.ItemSplit, error) {
+	if _, err := s.repository.GetByID(ctx, itemID); err != nil {
+		return nil, fmt.Errorf("list splits: %w", err)
+	}
+
+	splits, err := s.repository.ListSplits(ctx, itemID)
+	if err != nil {
+		return nil, fmt.Errorf("list splits: %w", err)
+	}
+
+	return splits, nil
+}
+
+// ReplaceSplits replaces the splits of an item. The amounts must sum to the
+// item amount; an empty slice removes all splits.
+func (s *Service) ReplaceSplits(ctx context.Context, itemID uuid.UUID, splits []model.ItemSplit) error {
+	for _, sp := range splits {
+		if !sp.Amount.IsPositive() {
+			return ErrInvalidSplitAmount
+		}
+	}
+
+	if err := s.repository.ReplaceSplits(ctx, itemID, splits); err != nil {
+		return fmt.Errorf("replace splits: %w", err)
+	}
+
+	return nil
+}
+
+// validateSplits checks that every split is positive and that splits, if any,
+// sum to the item amount.
+func validateSplits(amount decimal.Decimal, splits []model.ItemSplit) error {
+	if len(splits) == 0 {
+		return nil
+	}
+
+	total := decimal.Zero
+	for _, sp := range splits {
+		if !sp.Amount.IsPositive() {
+			return ErrInvalidSplitAmount
+		}
+		total = total.Add(sp.Amount)
+	}
+
+	if !total.Equal(amount) {
+		return item.ErrSplitsAmountMismatch
+	}
+
+	return nil
+}
@@ -0,0 +1,187 @@
+package job
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+
+	"github.com/aliskhannn/sales-tracker/internal/metrics"
+	"github.com/aliskhannn/sales-tracker/internal/model"
+	"github.com/aliskhannn/sales-tracker/internal/report"
+)
+
+var ErrJobFinished = errors.New("analytics job has already finished")
+
+// Defaults used when an option is not configured.
+const (
+	defaultWorkers      = 2
+	defaultPollInterval = 2 * time.Second
+	defaultHeartbeat    = 5 * time.Second
+	defaultLease        = time.Minute
+	defaultTimeout      = time.Hour
+	defaultRetention    = 7 * 24 * time.Hour
+	defaultMaxAttempts  = 3
+)
+
+// finishedJobs counts jobs that finished by status: succeeded, failed or canceled.
+var finishedJobs = promauto.NewCounterVec(prometheus.CounterOpts{
+	Namespace: metrics.Namespace,
+	Name:      "analytics_jobs_finished_total",
+	Help:      "Number of finished analytics jobs by status.",
+}, []string{"status"})
+
+// repository provides methods to interact with analytics jobs.
+type repository interface {
+	// Create enqueues a new analytics job.
+	Create(ctx context.Context, j *model.AnalyticsJob) (uuid.UUID, error)
+
+	// GetByID retrieves an analytics job with its result by its ID.
+	GetByID(ctx context.Context, id uuid.UUID) (*model.AnalyticsJob, error)
+
+	// List retrieves the latest analytics jobs without their results, newest first.
+	List(ctx context.Context, limit int) ([]model.AnalyticsJob, error)
+
+	// Cancel cancels a queued or running job, returning false if it already finished.
+	Cancel(ctx context.Context, id uuid.UUID) (bool, error)
+
+	// Claim claims the oldest job to run, or returns nil if there is none.
+	Claim(ctx context.Context, lease time.Duration, maxAttempts int) (*model.AnalyticsJob, error)
+
+	// Renew records the progress of a running job and extends its lease,
+	// returning false if the claim with token no longer holds the job.
+	Renew(ctx context.Context, id, token uuid.UUID, progress float64, lease time.Duration) (bool, error)
+
+	// Succeed stores the result of a running job, returning false if the
+	// claim with token no longer holds the job.
+	Succeed(ctx context.Context, id, token uuid.UUID, result *model.ReportResult) (bool, error)
+
+	// Fail marks a running job failed with the given message, returning false
+	// if the claim with token no longer holds the job.
+	Fail(ctx context.Context, id, token uuid.UUID, msg string) (bool, error)
+
+	// Release puts a running job back in the queue without counting the
+	// attempt, returning false if the claim with token no longer holds the job.
+	Release(ctx context.Context, id, token uuid.UUID) (bool, error)
+
+	// FailAbandoned fails jobs whose lease expired on their last attempt.
+	FailAbandoned(ctx context.Context, maxAttempts int, msg string) (int64, error)
+
+	// Prune removes jobs that finished before the given time.
+	Prune(ctx context.Context, before time.Time) (int64, error)
+}
+
+// Options configures the execution of analytics jobs.
+type Options struct {
+	Workers      int           // number of jobs run concurrently
+	PollInterval time.Duration // how often idle workers check for queued jobs
+	Heartbeat    time.Duration // how often a running job records its progress and renews its lease
+	Lease        time.Duration // how long a job stays claimed without a heartbeat before another worker takes it over
+	Timeout      time.Duration // max duration of a single attempt
+	Retention    time.Duration // how long finished jobs and their results are kept
+	MaxAttempts  int           // attempts after which a job abandoned by its worker is failed
+}
+
+// Service enqueues analytics jobs and runs them in a pool of workers.
+type Service struct {
+	repository repository
+	analytics  report.Analytics
+	opts       Options
+	wake       chan struct{}
+	now        func() time.Time
+}
+
+// NewService creates a new analytics job service computing reports with a.
+// Zero options fall back to defaults.
+func NewService(r repository, a report.Analytics, opts Options) *Service {
+	if opts.Workers <= 0 {
+		opts.Workers = defaultWorkers
+	}
+	if opts.PollInterval <= 0 {
+		opts.PollInterval = defaultPollInterval
+	}
+	if opts.Heartbeat <= 0 {
+		opts.Heartbeat = defaultHeartbeat
+	}
+	if opts.Lease <= opts.Heartbeat {
+		opts.Lease = max(defaultLease, 2*opts.Heartbeat)
+	}
+	if opts.Timeout <= 0 {
+		opts.Timeout = defaultTimeout
+	}
+	if opts.Retention <= 0 {
+		opts.Retention = defaultRetention
+	}
+	if opts.MaxAttempts <= 0 {
+		opts.MaxAttempts = defaultMaxAttempts
+	}
+
+	return &Service{
+		repository: r,
+		analytics:  a,
+		opts:       opts,
+		wake:       make(chan struct{}, 1),
+		now:        time.Now,
+	}
+}
+
+// Create validates spec and enqueues a job computing it.
+func (s *Service) Create(ctx context.Context, spec model.ReportSpec) (*model.AnalyticsJob, error) {
+	if err := report.Validate(&spec); err != nil {
+		return nil, err
+	}
+
+	j := &model.AnalyticsJob{Spec: spec}
+	if _, err := s.repository.Create(ctx, j); err != nil {
+		return nil, fmt.Errorf("create analytics job: %w", err)
+	}
+
+	// Let an idle worker pick the job up without waiting for its next poll.
+	select {
+	case s.wake <- struct{}{}:
+	default:
+	}
+
+	return j, nil
+}
+
+// GetByID returns an analytics job with its result by its ID.
+func (s *Service) GetByID(ctx context.Context, id uuid.UUID) (*model.AnalyticsJob, error) {
+	j, err := s.repository.GetByID(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("get analytics job: %w", err)
+	}
+
+	return j, nil
+}
+
+// List returns the latest analytics jobs without their results.
+func (s *Service) List(ctx context.Context, limit int) ([]model.AnalyticsJob, error) {
+	jobs, err := s.repository.List(ctx, limit)
+	if err != nil {
+		return nil, fmt.Errorf("list analytics jobs: %w", err)
+	}
+
+	return jobs, nil
+}
+
+// Cancel cancels a queued or running analytics job and returns it. A running
+// job is stopped by its worker on its next heartbeat.
+func (s *Service) Cancel(ctx context.Context, id uuid.UUID) (*model.AnalyticsJob, error) {
+	canceled, err := s.repository.Cancel(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("cancel analytics job: %w", err)
+	}
+
+	if !canceled {
+		return nil, ErrJobFinished
+	}
+
+	finishedJobs.WithLabelValues(model.JobCanceled).Inc()
+
+	return s.GetByID(ctx, id)
+}
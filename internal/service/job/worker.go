@@ -0,0 +1,222 @@
+package job
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/aliskhannn/sales-tracker/internal/logging"
+	"github.com/aliskhannn/sales-tracker/internal/model"
+	"github.com/aliskhannn/sales-tracker/internal/report"
+	"github.com/aliskhannn/sales-tracker/internal/tenant"
+)
+
+// Messages stored for failed jobs. The cause of other failures is logged
+// rather than exposed.
+const (
+	msgFailed    = "the report could not be computed"
+	msgAbandoned = "the job was abandoned by its worker too many times"
+)
+
+// Causes of stopping a running job other than shutdown.
+var (
+	errNotHeld = errors.New("analytics job canceled or claimed by another worker")
+	errTimeout = errors.New("analytics job timed out")
+)
+
+// Run starts the workers, which run queued jobs until ctx is cancelled, and
+// prunes expired jobs. Jobs running when ctx is cancelled are put back in the
+// queue, so that they are resumed on the next start.
+func (s *Service) Run(ctx context.Context) {
+	var wg sync.WaitGroup
+
+	for i := 0; i < s.opts.Workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			s.work(ctx)
+		}()
+	}
+
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		s.maintain(ctx)
+	}()
+
+	wg.Wait()
+}
+
+// work runs claimed jobs one after another and waits for the next poll or
+// a new job when there is none.
+func (s *Service) work(ctx context.Context) {
+	ticker := time.NewTicker(s.opts.PollInterval)
+	defer ticker.Stop()
+
+	for {
+		for ctx.Err() == nil {
+			j, err := s.repository.Claim(ctx, s.opts.Lease, s.opts.MaxAttempts)
+			if err != nil {
+				if ctx.Err() == nil {
+					logging.Ctx(ctx).Error().Err(err).Msg("failed to claim analytics job")
+				}
+
+				break
+			}
+
+			if j == nil {
+				break
+			}
+
+			s.execute(ctx, j)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		case <-s.wake:
+		}
+	}
+}
+
+// maintain fails jobs abandoned on their last attempt and removes jobs past
+// their retention immediately and then on every lease until ctx is cancelled.
+func (s *Service) maintain(ctx context.Context) {
+	ticker := time.NewTicker(s.opts.Lease)
+	defer ticker.Stop()
+
+	for {
+		n, err := s.repository.FailAbandoned(ctx, s.opts.MaxAttempts, msgAbandoned)
+		if err != nil && ctx.Err() == nil {
+			logging.Ctx(ctx).Error().Err(err).Msg("failed to fail abandoned analytics jobs")
+		}
+		if n > 0 {
+			finishedJobs.WithLabelValues(model.JobFailed).Add(float64(n))
+			logging.Ctx(ctx).Warn().Int64("jobs", n).Msg("failed abandoned analytics jobs")
+		}
+
+		if _, err = s.repository.Prune(ctx, s.now().Add(-s.opts.Retention)); err != nil && ctx.Err() == nil {
+			logging.Ctx(ctx).Error().Err(err).Msg("failed to prune analytics jobs")
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// execute computes the report of a claimed job in its workspace and records
+// the outcome. While it runs, the job's progress is recorded and its lease
+// renewed on every heartbeat; the job stops once its claim no longer holds
+// it, because it was canceled or, after the lease expired, claimed by another
+// worker. Outcomes are only recorded while the claim holds the job.
+func (s *Service) execute(ctx context.Context, j *model.AnalyticsJob) {
+	ctx = logging.With(tenant.WithWorkspace(ctx, j.WorkspaceID), "job", j.ID.String())
+	// Outcomes are recorded even if ctx is cancelled by the shutdown.
+	rctx := context.WithoutCancel(ctx)
+
+	runCtx, cancel := context.WithCancelCause(ctx)
+	defer cancel(nil)
+
+	runCtx, cancelTimeout := context.WithTimeoutCause(runCtx, s.opts.Timeout, errTimeout)
+	defer cancelTimeout()
+
+	var progress atomic.Uint64 // bits of the share of the report computed so far
+	heartbeatDone := make(chan struct{})
+	go func() {
+		defer close(heartbeatDone)
+		s.heartbeat(runCtx, rctx, j, &progress, cancel)
+	}()
+
+	start := s.now()
+	result, err := report.Run(runCtx, s.analytics, j.Spec, func(done, total int) error {
+		progress.Store(math.Float64bits(float64(done) / float64(total)))
+		return context.Cause(runCtx)
+	})
+
+	cancel(nil)
+	<-heartbeatDone
+
+	cause := context.Cause(runCtx)
+	switch {
+	case err == nil:
+		held, err := s.repository.Succeed(rctx, j.ID, j.ClaimToken, result)
+		if err != nil {
+			logging.Ctx(ctx).Error().Err(err).Msg("failed to record analytics job")
+			return
+		}
+		if !held {
+			logging.Ctx(ctx).Warn().Msg("analytics job was canceled or claimed by another worker, result dropped")
+			return
+		}
+
+		finishedJobs.WithLabelValues(model.JobSucceeded).Inc()
+		logging.Ctx(ctx).Info().Dur("duration", s.now().Sub(start)).Msg("analytics job succeeded")
+	case errors.Is(cause, errNotHeld):
+		logging.Ctx(ctx).Info().Msg("analytics job canceled or claimed by another worker, stopped")
+	case ctx.Err() != nil:
+		held, err := s.repository.Release(rctx, j.ID, j.ClaimToken)
+		if err != nil {
+			logging.Ctx(ctx).Error().Err(err).Msg("failed to release analytics job")
+			return
+		}
+		if !held {
+			return
+		}
+
+		logging.Ctx(ctx).Info().Msg("analytics job released on shutdown")
+	default:
+		msg := msgFailed
+		if errors.Is(cause, errTimeout) {
+			msg = fmt.Sprintf("the report did not finish within the timeout of %s", s.opts.Timeout)
+		}
+
+		logging.Ctx(ctx).Error().Err(err).Msg("analytics job failed")
+		held, err := s.repository.Fail(rctx, j.ID, j.ClaimToken, msg)
+		if err != nil {
+			logging.Ctx(ctx).Error().Err(err).Msg("failed to record analytics job")
+			return
+		}
+		if !held {
+			logging.Ctx(ctx).Warn().Msg("analytics job was canceled or claimed by another worker, failure dropped")
+			return
+		}
+
+		finishedJobs.WithLabelValues(model.JobFailed).Inc()
+	}
+}
+
+// heartbeat records the progress of a running job and renews its lease on
+// every heartbeat until runCtx is done, and cancels it with errNotHeld once
+// the claim no longer holds the job.
+func (s *Service) heartbeat(runCtx, rctx context.Context, j *model.AnalyticsJob, progress *atomic.Uint64, cancel context.CancelCauseFunc) {
+	ticker := time.NewTicker(s.opts.Heartbeat)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-runCtx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		held, err := s.repository.Renew(rctx, j.ID, j.ClaimToken, math.Float64frombits(progress.Load()), s.opts.Lease)
+		if err != nil {
+			// The lease outlasts a few missed heartbeats.
+			logging.Ctx(rctx).Error().Err(err).Msg("failed to renew analytics job")
+			continue
+		}
+
+		if !held {
+			cancel(errNotHeld)
+			return
+		}
+	}
+}
@@ -0,0 +1,146 @@
+package job
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+
+	"github.com/aliskhannn/sales-tracker/internal/model"
+	"github.com/aliskhannn/sales-tracker/internal/report"
+)
+
+// fakeRepository records the updates of a claimed job. Updates made with
+// another token than the job's current claim are refused, as after the job
+// was claimed by another worker.
+type fakeRepository struct {
+	repository
+
+	mu        sync.Mutex
+	token     uuid.UUID // token of the current claim
+	canceled  bool
+	renewals  int
+	succeeded bool
+	failed    string
+	released  bool
+}
+
+func (f *fakeRepository) holds(token uuid.UUID) bool {
+	return !f.canceled && token == f.token
+}
+
+func (f *fakeRepository) Renew(_ context.Context, _, token uuid.UUID, _ float64, _ time.Duration) (bool, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.renewals++
+	return f.holds(token), nil
+}
+
+func (f *fakeRepository) Succeed(_ context.Context, _, token uuid.UUID, _ *model.ReportResult) (bool, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if !f.holds(token) {
+		return false, nil
+	}
+	f.succeeded = true
+	return true, nil
+}
+
+func (f *fakeRepository) Fail(_ context.Context, _, token uuid.UUID, msg string) (bool, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if !f.holds(token) {
+		return false, nil
+	}
+	f.failed = msg
+	return true, nil
+}
+
+func (f *fakeRepository) Release(_ context.Context, _, token uuid.UUID) (bool, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if !f.holds(token) {
+		return false, nil
+	}
+	f.released = true
+	return true, nil
+}
+
+// countAnalytics computes counts, taking delay for each and failing with
+// the cause of ctx once it is done.
+type countAnalytics struct {
+	report.Analytics
+
+	delay time.Duration
+}
+
+func (a countAnalytics) Count(ctx context.Context, _, _ *time.Time, _ *uuid.UUID, _ *string, _ *uuid.UUID, _ *model.TagFilter) (int64, error) {
+	select {
+	case <-time.After(a.delay):
+		return 7, nil
+	case <-ctx.Done():
+		return 0, context.Cause(ctx)
+	}
+}
+
+func TestExecute(t *testing.T) {
+	tests := []struct {
+		name          string
+		delay         time.Duration // time taken by the report
+		takenOver     bool          // the job is claimed by another worker while running
+		canceled      bool          // the job is canceled while running
+		wantSucceeded bool
+		wantCounted   float64 // change of the succeeded jobs counter
+	}{
+		{name: "held", wantSucceeded: true, wantCounted: 1},
+		{name: "claimed by another worker", takenOver: true},
+		{name: "canceled", canceled: true},
+		{name: "claim lost while running", delay: time.Second, takenOver: true},
+		{name: "canceled while running", delay: time.Second, canceled: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			token := uuid.New()
+			r := &fakeRepository{token: token, canceled: tt.canceled}
+			if tt.takenOver {
+				r.token = uuid.New()
+			}
+
+			s := NewService(r, countAnalytics{delay: tt.delay}, Options{Heartbeat: 10 * time.Millisecond, Timeout: time.Minute})
+			j := &model.AnalyticsJob{
+				ID:          uuid.New(),
+				WorkspaceID: uuid.New(),
+				Spec:        model.ReportSpec{Metrics: []string{"count"}},
+				ClaimToken:  token,
+			}
+
+			before := testutil.ToFloat64(finishedJobs.WithLabelValues(model.JobSucceeded))
+
+			start := time.Now()
+			s.execute(context.Background(), j)
+
+			if r.succeeded != tt.wantSucceeded || r.failed != "" || r.released {
+				t.Errorf("succeeded = %v, failed = %q, released = %v, want succeeded = %v only",
+					r.succeeded, r.failed, r.released, tt.wantSucceeded)
+			}
+
+			if got := testutil.ToFloat64(finishedJobs.WithLabelValues(model.JobSucceeded)) - before; got != tt.wantCounted {
+				t.Errorf("succeeded jobs counted %v, want %v", got, tt.wantCounted)
+			}
+
+			// A job whose claim is lost stops at the next heartbeat rather than
+			// running to the end.
+			if tt.delay > 0 && time.Since(start) >= tt.delay {
+				t.Errorf("execute() took %s, want it stopped at the first heartbeat", time.Since(start))
+			}
+		})
+	}
+}
@@ -0,0 +1,336 @@
+package reconciliation
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+
+	"github.com/aliskhannn/sales-tracker/internal/model"
+	"github.com/aliskhannn/sales-tracker/internal/statement"
+)
+
+var (
+	ErrEmptyStatement   = errors.New("statement has no lines")
+	ErrCurrencyRequired = errors.New("statement line has no currency, pass currency or account_id")
+	ErrNoItemToConfirm  = errors.New("line is not matched, item_id is required")
+	ErrItemMismatch     = errors.New("item amount, currency or kind does not match the statement line")
+	ErrLineReconciled   = errors.New("line is already reconciled")
+)
+
+// repository provides methods to interact with reconciliation sessions.
+type repository interface {
+	// CreateSession atomically adds a session and its lines.
+	CreateSession(ctx context.Context, s *model.ReconciliationSession) (uuid.UUID, error)
+
+	// GetSession retrieves a session by its ID together with its lines.
+	GetSession(ctx context.Context, id uuid.UUID) (*model.ReconciliationSession, error)
+
+	// ListSessions retrieves all sessions with their line summaries.
+	ListSessions(ctx context.Context) ([]model.ReconciliationSession, error)
+
+	// GetLine retrieves a line of a session by its ID.
+	GetLine(ctx context.Context, sessionID, id uuid.UUID) (*model.ReconciliationLine, error)
+
+	// FindCandidate returns the best unreconciled item to match the line, or nil.
+	FindCandidate(ctx context.Context, l *model.ReconciliationLine, accountID *uuid.UUID, window time.Duration) (*uuid.UUID, error)
+
+	// MatchLine proposes an item for an unmatched line.
+	MatchLine(ctx context.Context, id, itemID uuid.UUID) (bool, error)
+
+	// SetLineItem sets the item and status of a line and marks the item as reconciled.
+	SetLineItem(ctx context.Context, id, itemID uuid.UUID, status string) error
+
+	// UnmatchLine resets a line to unmatched and clears the reconciliation of its item.
+	UnmatchLine(ctx context.Context, id uuid.UUID) error
+}
+
+// accountRepository provides read access to accounts.
+type accountRepository interface {
+	// GetByID retrieves an account by its ID.
+	GetByID(ctx context.Context, id uuid.UUID) (*model.Account, error)
+}
+
+// itemService reads and creates items, so created items go through the
+// same validation and rules as any other item.
+type itemService interface {
+	// GetByID returns an item by its ID.
+	GetByID(ctx context.Context, id uuid.UUID) (*model.Item, error)
+
+	// Create adds a new item with the given fields.
+	Create(
+		ctx context.Context,
+		kind string,
+		title string,
+		amount decimal.Decimal,
+		currency string,
+		occurredAt time.Time,
+		categoryID *uuid.UUID,
+		accountID *uuid.UUID,
+		refundOf *uuid.UUID,
+		metadata json.RawMessage,
+		splits []model.ItemSplit,
+	) (uuid.UUID, error)
+}
+
+// Service provides bank statement reconciliation business logic.
+type Service struct {
+	repository repository
+	accounts   accountRepository
+	items      itemService
+	window     time.Duration
+}
+
+// NewService creates a new reconciliation service. Statement lines are
+// auto-matched to items that occurred at most window apart.
+func NewService(r repository, accounts accountRepository, items itemService, window time.Duration) *Service {
+	return &Service{repository: r, accounts: accounts, items: items, window: window}
+}
+
+// Upload parses a statement in the given format, stores it as a new session
+// and auto-matches its lines. accountID can be nil; otherwise matching is
+// limited to items of the account and items created from lines are booked
+// against it. Lines without a currency use currency, or the account currency
+// if currency is empty.
+func (s *Service) Upload(
+	ctx context.Context,
+	format string,
+	filename string,
+	r io.Reader,
+	accountID *uuid.UUID,
+	currency string,
+) (*model.ReconciliationSession, error) {
+	parsed, err := statement.Parse(format, r)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(parsed) == 0 {
+		return nil, ErrEmptyStatement
+	}
+
+	currency = strings.ToUpper(currency)
+	if accountID != nil {
+		a, err := s.accounts.GetByID(ctx, *accountID)
+		if err != nil {
+			return nil, fmt.Errorf("upload statement: %w", err)
+		}
+
+		if currency == "" {
+			currency = a.Currency
+		}
+	}
+
+	session := &model.ReconciliationSession{
+		AccountID: accountID,
+		Format:    format,
+		Filename:  filename,
+		Lines:     make([]model.ReconciliationLine, 0, len(parsed)),
+	}
+
+	for n, p := range parsed {
+		c := p.Currency
+		if c == "" {
+			c = currency
+		}
+
+		if c == "" {
+			return nil, fmt.Errorf("%w: line %d", ErrCurrencyRequired, n+1)
+		}
+
+		session.Lines = append(session.Lines, model.ReconciliationLine{
+			LineNo:      n + 1,
+			OccurredAt:  p.Date,
+			Amount:      p.Amount,
+			Currency:    c,
+			Description: p.Description,
+			Reference:   p.Reference,
+		})
+	}
+
+	id, err := s.repository.CreateSession(ctx, session)
+	if err != nil {
+		return nil, fmt.Errorf("upload statement: %w", err)
+	}
+
+	return s.AutoMatch(ctx, id)
+}
+
+// Get returns a session with its lines.
+func (s *Service) Get(ctx context.Context, id uuid.UUID) (*model.ReconciliationSession, error) {
+	session, err := s.repository.GetSession(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("get reconciliation session: %w", err)
+	}
+
+	return session, nil
+}
+
+// List returns all sessions with their line summaries.
+func (s *Service) List(ctx context.Context) ([]model.ReconciliationSession, error) {
+	sessions, err := s.repository.ListSessions(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("list reconciliation sessions: %w", err)
+	}
+
+	return sessions, nil
+}
+
+// AutoMatch proposes an item for every unmatched line of a session and
+// returns the updated session. Each item is proposed for at most one line.
+func (s *Service) AutoMatch(ctx context.Context, id uuid.UUID) (*model.ReconciliationSession, error) {
+	session, err := s.repository.GetSession(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("auto-match: %w", err)
+	}
+
+	for n := range session.Lines {
+		l := &session.Lines[n]
+		if l.Status != model.LineUnmatched {
+			continue
+		}
+
+		itemID, err := s.repository.FindCandidate(ctx, l, session.AccountID, s.window)
+		if err != nil {
+			return nil, fmt.Errorf("auto-match: %w", err)
+		}
+
+		if itemID == nil {
+			continue
+		}
+
+		if _, err = s.repository.MatchLine(ctx, l.ID, *itemID); err != nil {
+			return nil, fmt.Errorf("auto-match: %w", err)
+		}
+	}
+
+	session, err = s.repository.GetSession(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("auto-match: %w", err)
+	}
+
+	return session, nil
+}
+
+// Confirm confirms the match of a line and marks the item as reconciled.
+// itemID can be nil to confirm the item proposed by auto-matching; otherwise
+// it matches the line to the given item. The item must have the line's
+// absolute amount and currency and a kind fitting the sign of the line.
+func (s *Service) Confirm(ctx context.Context, sessionID, lineID uuid.UUID, itemID *uuid.UUID) (*model.ReconciliationLine, error) {
+	l, err := s.repository.GetLine(ctx, sessionID, lineID)
+	if err != nil {
+		return nil, fmt.Errorf("confirm line: %w", err)
+	}
+
+	if itemID == nil {
+		itemID = l.ItemID
+	}
+
+	if itemID == nil {
+		return nil, ErrNoItemToConfirm
+	}
+
+	i, err := s.items.GetByID(ctx, *itemID)
+	if err != nil {
+		return nil, fmt.Errorf("confirm line: %w", err)
+	}
+
+	if !i.Amount.Equal(l.Amount.Abs()) || i.Currency != l.Currency || !l.MatchesKind(i.Kind, i.TransferLeg) {
+		return nil, ErrItemMismatch
+	}
+
+	status := model.LineConfirmed
+	if l.Status == model.LineCreated && l.ItemID != nil && *l.ItemID == i.ID {
+		status = model.LineCreated
+	}
+
+	if err = s.repository.SetLineItem(ctx, l.ID, i.ID, status); err != nil {
+		return nil, fmt.Errorf("confirm line: %w", err)
+	}
+
+	return s.line(ctx, sessionID, lineID)
+}
+
+// Unmatch removes the item of a line and clears the item's reconciliation.
+func (s *Service) Unmatch(ctx context.Context, sessionID, lineID uuid.UUID) (*model.ReconciliationLine, error) {
+	l, err := s.repository.GetLine(ctx, sessionID, lineID)
+	if err != nil {
+		return nil, fmt.Errorf("unmatch line: %w", err)
+	}
+
+	if err = s.repository.UnmatchLine(ctx, l.ID); err != nil {
+		return nil, fmt.Errorf("unmatch line: %w", err)
+	}
+
+	return s.line(ctx, sessionID, lineID)
+}
+
+// CreateItem creates the item missing for a line through the item service and
+// marks it as reconciled. Credits become income, debits become expenses; the
+// bank reference and session are recorded in the item metadata.
+// categoryID can be nil.
+func (s *Service) CreateItem(ctx context.Context, sessionID, lineID uuid.UUID, categoryID *uuid.UUID) (*model.ReconciliationLine, error) {
+	session, err := s.repository.GetSession(ctx, sessionID)
+	if err != nil {
+		return nil, fmt.Errorf("create item from line: %w", err)
+	}
+
+	l, err := s.repository.GetLine(ctx, sessionID, lineID)
+	if err != nil {
+		return nil, fmt.Errorf("create item from line: %w", err)
+	}
+
+	if l.Status == model.LineConfirmed || l.Status == model.LineCreated {
+		return nil, ErrLineReconciled
+	}
+
+	kind := model.KindExpense
+	if l.Amount.IsPositive() {
+		kind = model.KindIncome
+	}
+
+	title := l.Description
+	if title == "" {
+		title = fmt.Sprintf("Bank statement line %d", l.LineNo)
+	}
+
+	meta := map[string]string{"reconciliation_session_id": sessionID.String()}
+	if l.Reference != "" {
+		meta["bank_reference"] = l.Reference
+	}
+
+	metadata, err := json.Marshal(meta)
+	if err != nil {
+		return nil, fmt.Errorf("create item from line: %w", err)
+	}
+
+	itemID, err := s.items.Create(ctx,
+		kind, title, l.Amount.Abs(), l.Currency, l.OccurredAt,
+		categoryID, session.AccountID, nil, metadata, nil,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("create item from line: %w", err)
+	}
+
+	if err = s.repository.SetLineItem(ctx, l.ID, itemID, model.LineCreated); err != nil {
+		return nil, fmt.Errorf("create item from line: %w", err)
+	}
+
+	return s.line(ctx, sessionID, lineID)
+}
+
+// line reloads a line after it was changed.
+func (s *Service) line(ctx context.Context, sessionID, lineID uuid.UUID) (*model.ReconciliationLine, error) {
+	l, err := s.repository.GetLine(ctx, sessionID, lineID)
+	if err != nil {
+		return nil, fmt.Errorf("get reconciliation line: %w", err)
+	}
+
+	return l, nil
+}
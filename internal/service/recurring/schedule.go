@@ -0,0 +1,161 @@
+package recurring
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Supported schedule frequencies.
+const (
+	FreqDaily   = "DAILY"
+	FreqWeekly  = "WEEKLY"
+	FreqMonthly = "MONTHLY"
+	FreqYearly  = "YEARLY"
+)
+
+var ErrInvalidRule = errors.New("invalid recurrence rule")
+
+// Schedule is a parsed subset of an iCalendar RRULE.
+//
+// Supported parts:
+//   - FREQ: DAILY, WEEKLY, MONTHLY or YEARLY (required)
+//   - INTERVAL: positive step between occurrences (default 1)
+//   - COUNT: maximum number of occurrences
+//   - UNTIL: last possible occurrence, in 20060102T150405Z or 2006-01-02 format
+type Schedule struct {
+	Freq     string
+	Interval int
+	Count    int
+	Until    *time.Time
+}
+
+// ParseRule parses an RRULE string like "FREQ=MONTHLY;INTERVAL=1;COUNT=12".
+// An optional "RRULE:" prefix is accepted.
+func ParseRule(rule string) (*Schedule, error) {
+	rule = strings.TrimPrefix(strings.TrimSpace(rule), "RRULE:")
+	if rule == "" {
+		return nil, fmt.Errorf("%w: empty rule", ErrInvalidRule)
+	}
+
+	s := &Schedule{Interval: 1}
+	for _, part := range strings.Split(rule, ";") {
+		key, value, ok := strings.Cut(part, "=")
+		if !ok {
+			return nil, fmt.Errorf("%w: malformed part %q", ErrInvalidRule, part)
+		}
+
+		switch strings.ToUpper(key) {
+		case "FREQ":
+			freq := strings.ToUpper(value)
+			switch freq {
+			case FreqDaily, FreqWeekly, FreqMonthly, FreqYearly:
+				s.Freq = freq
+			default:
+				return nil, fmt.Errorf("%w: unsupported FREQ %q", ErrInvalidRule, value)
+			}
+		case "INTERVAL":
+			n, err := strconv.Atoi(value)
+			if err != nil || n <= 0 {
+				return nil, fmt.Errorf("%w: INTERVAL must be a positive integer", ErrInvalidRule)
+			}
+			s.Interval = n
+		case "COUNT":
+			n, err := strconv.Atoi(value)
+			if err != nil || n <= 0 {
+				return nil, fmt.Errorf("%w: COUNT must be a positive integer", ErrInvalidRule)
+			}
+			s.Count = n
+		case "UNTIL":
+			t, err := parseUntil(value)
+			if err != nil {
+				return nil, fmt.Errorf("%w: invalid UNTIL %q", ErrInvalidRule, value)
+			}
+			s.Until = &t
+		default:
+			return nil, fmt.Errorf("%w: unsupported part %q", ErrInvalidRule, key)
+		}
+	}
+
+	if s.Freq == "" {
+		return nil, fmt.Errorf("%w: FREQ is required", ErrInvalidRule)
+	}
+
+	return s, nil
+}
+
+// parseUntil parses the UNTIL value in either iCalendar or date-only format.
+func parseUntil(value string) (time.Time, error) {
+	for _, layout := range []string{"20060102T150405Z", "20060102", time.DateOnly} {
+		if t, err := time.Parse(layout, value); err == nil {
+			return t, nil
+		}
+	}
+
+	return time.Time{}, fmt.Errorf("unknown time format")
+}
+
+// At returns the n-th (zero-based) occurrence of the schedule starting at start.
+// The second return value is false when the schedule has no such occurrence
+// because of COUNT, UNTIL or the optional end timestamp.
+//
+// Monthly and yearly occurrences are computed from start rather than from the
+// previous occurrence, and are clamped to the last day of shorter months, so
+// a schedule starting on Jan 31 yields Feb 28 (29), Mar 31, Apr 30 and so on.
+func (s *Schedule) At(start time.Time, n int, end *time.Time) (time.Time, bool) {
+	if s.Count > 0 && n >= s.Count {
+		return time.Time{}, false
+	}
+
+	step := n * s.Interval
+	var t time.Time
+	switch s.Freq {
+	case FreqDaily:
+		t = start.AddDate(0, 0, step)
+	case FreqWeekly:
+		t = start.AddDate(0, 0, 7*step)
+	case FreqMonthly:
+		t = addMonthsClamped(start, step)
+	case FreqYearly:
+		t = addMonthsClamped(start, 12*step)
+	}
+
+	if s.Until != nil && t.After(*s.Until) {
+		return time.Time{}, false
+	}
+	if end != nil && t.After(*end) {
+		return time.Time{}, false
+	}
+
+	return t, true
+}
+
+// FirstFrom returns the index and timestamp of the first occurrence of the
+// schedule starting at start that is not before from. The timestamp is nil
+// when there is none because of COUNT, UNTIL or the optional end timestamp.
+func (s *Schedule) FirstFrom(start time.Time, end *time.Time, from time.Time) (int, *time.Time) {
+	for i := 0; ; i++ {
+		at, ok := s.At(start, i, end)
+		if !ok {
+			return i, nil
+		}
+		if !at.Before(from) {
+			return i, &at
+		}
+	}
+}
+
+// addMonthsClamped adds months to t keeping the day of month when possible
+// and clamping it to the last day of the target month otherwise.
+func addMonthsClamped(t time.Time, months int) time.Time {
+	y, m, d := t.Date()
+	first := time.Date(y, m+time.Month(months), 1, t.Hour(), t.Minute(), t.Second(), t.Nanosecond(), t.Location())
+	lastDay := first.AddDate(0, 1, -1).Day()
+	if d > lastDay {
+		d = lastDay
+	}
+
+	return first.AddDate(0, 0, d-1)
+}
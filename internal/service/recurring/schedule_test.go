@@ -0,0 +1,143 @@
+package recurring
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestParseRule(t *testing.T) {
+	until := time.Date(2025, 12, 31, 23, 59, 59, 0, time.UTC)
+	untilDate := time.Date(2025, 12, 31, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name    string
+		rule    string
+		want    Schedule
+		wantErr bool
+	}{
+		{name: "daily", rule: "FREQ=DAILY", want: Schedule{Freq: FreqDaily, Interval: 1}},
+		{name: "prefix and lower case", rule: " RRULE:freq=weekly;interval=2 ", want: Schedule{Freq: FreqWeekly, Interval: 2}},
+		{name: "count", rule: "FREQ=MONTHLY;COUNT=12", want: Schedule{Freq: FreqMonthly, Interval: 1, Count: 12}},
+		{name: "until ical", rule: "FREQ=YEARLY;UNTIL=20251231T235959Z", want: Schedule{Freq: FreqYearly, Interval: 1, Until: &until}},
+		{name: "until compact date", rule: "FREQ=DAILY;UNTIL=20251231", want: Schedule{Freq: FreqDaily, Interval: 1, Until: &untilDate}},
+		{name: "until date", rule: "FREQ=DAILY;UNTIL=2025-12-31", want: Schedule{Freq: FreqDaily, Interval: 1, Until: &untilDate}},
+		{name: "empty", rule: "  ", wantErr: true},
+		{name: "prefix only", rule: "RRULE:", wantErr: true},
+		{name: "missing freq", rule: "INTERVAL=2", wantErr: true},
+		{name: "unsupported freq", rule: "FREQ=HOURLY", wantErr: true},
+		{name: "malformed part", rule: "FREQ=DAILY;COUNT", wantErr: true},
+		{name: "unsupported part", rule: "FREQ=WEEKLY;BYDAY=MO", wantErr: true},
+		{name: "zero interval", rule: "FREQ=DAILY;INTERVAL=0", wantErr: true},
+		{name: "negative count", rule: "FREQ=DAILY;COUNT=-1", wantErr: true},
+		{name: "non-numeric interval", rule: "FREQ=DAILY;INTERVAL=two", wantErr: true},
+		{name: "invalid until", rule: "FREQ=DAILY;UNTIL=tomorrow", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseRule(tt.rule)
+			if tt.wantErr {
+				if !errors.Is(err, ErrInvalidRule) {
+					t.Fatalf("ParseRule(%q) error = %v, want ErrInvalidRule", tt.rule, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ParseRule(%q) error = %v", tt.rule, err)
+			}
+
+			if got.Freq != tt.want.Freq || got.Interval != tt.want.Interval || got.Count != tt.want.Count {
+				t.Errorf("ParseRule(%q) = %+v, want %+v", tt.rule, *got, tt.want)
+			}
+			if (got.Until == nil) != (tt.want.Until == nil) || got.Until != nil && !got.Until.Equal(*tt.want.Until) {
+				t.Errorf("ParseRule(%q) until = %v, want %v", tt.rule, got.Until, tt.want.Until)
+			}
+		})
+	}
+}
+
+func TestScheduleAt(t *testing.T) {
+	date := func(y int, m time.Month, d int) time.Time {
+		return time.Date(y, m, d, 9, 30, 0, 0, time.UTC)
+	}
+	end := date(2025, 3, 31)
+
+	tests := []struct {
+		name   string
+		rule   string
+		start  time.Time
+		n      int
+		end    *time.Time
+		want   time.Time
+		wantOK bool
+	}{
+		{name: "first occurrence is start", rule: "FREQ=DAILY", start: date(2025, 1, 1), n: 0, want: date(2025, 1, 1), wantOK: true},
+		{name: "daily interval", rule: "FREQ=DAILY;INTERVAL=3", start: date(2025, 1, 30), n: 2, want: date(2025, 2, 5), wantOK: true},
+		{name: "weekly", rule: "FREQ=WEEKLY", start: date(2025, 1, 6), n: 4, want: date(2025, 2, 3), wantOK: true},
+		{name: "monthly clamped to february", rule: "FREQ=MONTHLY", start: date(2025, 1, 31), n: 1, want: date(2025, 2, 28), wantOK: true},
+		{name: "monthly clamped to leap february", rule: "FREQ=MONTHLY", start: date(2024, 1, 31), n: 1, want: date(2024, 2, 29), wantOK: true},
+		{name: "monthly keeps day after short month", rule: "FREQ=MONTHLY", start: date(2025, 1, 31), n: 2, want: date(2025, 3, 31), wantOK: true},
+		{name: "monthly across year", rule: "FREQ=MONTHLY;INTERVAL=5", start: date(2025, 10, 15), n: 1, want: date(2026, 3, 15), wantOK: true},
+		{name: "yearly from leap day", rule: "FREQ=YEARLY", start: date(2024, 2, 29), n: 1, want: date(2025, 2, 28), wantOK: true},
+		{name: "within count", rule: "FREQ=DAILY;COUNT=3", start: date(2025, 1, 1), n: 2, want: date(2025, 1, 3), wantOK: true},
+		{name: "beyond count", rule: "FREQ=DAILY;COUNT=3", start: date(2025, 1, 1), n: 3},
+		{name: "on until", rule: "FREQ=DAILY;UNTIL=20250103T093000Z", start: date(2025, 1, 1), n: 2, want: date(2025, 1, 3), wantOK: true},
+		{name: "after until", rule: "FREQ=DAILY;UNTIL=20250103T093000Z", start: date(2025, 1, 1), n: 3},
+		{name: "on end", rule: "FREQ=MONTHLY", start: date(2025, 1, 31), n: 2, end: &end, want: date(2025, 3, 31), wantOK: true},
+		{name: "after end", rule: "FREQ=MONTHLY", start: date(2025, 1, 31), n: 3, end: &end},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, err := ParseRule(tt.rule)
+			if err != nil {
+				t.Fatalf("ParseRule(%q) error = %v", tt.rule, err)
+			}
+
+			got, ok := s.At(tt.start, tt.n, tt.end)
+			if ok != tt.wantOK || !got.Equal(tt.want) {
+				t.Errorf("At(%v, %d) = %v, %v, want %v, %v", tt.start, tt.n, got, ok, tt.want, tt.wantOK)
+			}
+		})
+	}
+}
+
+func TestScheduleFirstFrom(t *testing.T) {
+	date := func(y int, m time.Month, d int) time.Time {
+		return time.Date(y, m, d, 9, 30, 0, 0, time.UTC)
+	}
+	start := date(2025, 1, 31)
+	end := date(2025, 3, 31)
+
+	tests := []struct {
+		name      string
+		rule      string
+		end       *time.Time
+		from      time.Time
+		wantIndex int
+		want      *time.Time
+	}{
+		{name: "from before start", rule: "FREQ=MONTHLY", from: date(2024, 12, 1), wantIndex: 0, want: &start},
+		{name: "from on start", rule: "FREQ=MONTHLY", from: start, wantIndex: 0, want: &start},
+		{name: "from between occurrences", rule: "FREQ=MONTHLY", from: date(2025, 3, 1), wantIndex: 2, want: &end},
+		{name: "from on occurrence", rule: "FREQ=MONTHLY", from: end, wantIndex: 2, want: &end},
+		{name: "past end", rule: "FREQ=MONTHLY", end: &end, from: date(2025, 4, 1), wantIndex: 3},
+		{name: "past count", rule: "FREQ=MONTHLY;COUNT=2", from: date(2025, 3, 1), wantIndex: 2},
+		{name: "past until", rule: "FREQ=DAILY;UNTIL=2025-02-02", from: date(2025, 2, 3), wantIndex: 2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, err := ParseRule(tt.rule)
+			if err != nil {
+				t.Fatalf("ParseRule(%q) error = %v", tt.rule, err)
+			}
+
+			index, got := s.FirstFrom(start, tt.end, tt.from)
+			if index != tt.wantIndex || (got == nil) != (tt.want == nil) || (got != nil && !got.Equal(*tt.want)) {
+				t.Errorf("FirstFrom(%v) = %d, %v, want %d, %v", tt.from, index, got, tt.wantIndex, tt.want)
+			}
+		})
+	}
+}
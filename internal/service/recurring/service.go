@@ -0,0 +1,431 @@
+package recurring
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+
+	"github.com/aliskhannn/sales-tracker/internal/model"
+	"github.com/aliskhannn/sales-tracker/internal/repository/recurring"
+)
+
+var ErrNotAnOccurrence = errors.New("timestamp is not an occurrence of the schedule")
+
+// occurrenceLease is how long a claimed occurrence stays pending before another
+// worker takes it over. It is far above the time needed to create an item.
+const occurrenceLease = 5 * time.Minute
+
+// repository defines the required behavior for recurring item persistence.
+type repository interface {
+	// Create adds a new recurring item to the database.
+	Create(ctx context.Context, ri *model.RecurringItem) (uuid.UUID, error)
+
+	// GetByID retrieves a recurring item by its ID.
+	GetByID(ctx context.Context, id uuid.UUID) (*model.RecurringItem, error)
+
+	// List retrieves all recurring items from the database.
+	List(ctx context.Context) ([]model.RecurringItem, error)
+
+	// ListDue retrieves active recurring items of the context workspace whose
+	// next occurrence is not after now.
+	ListDue(ctx context.Context, now time.Time) ([]model.RecurringItem, error)
+
+	// Update updates a recurring item including its schedule state.
+	Update(ctx context.Context, ri *model.RecurringItem) error
+
+	// Advance moves the schedule of a recurring item to the given occurrence.
+	Advance(ctx context.Context, id uuid.UUID, nextIndex int, nextRunAt *time.Time) error
+
+	// Delete removes a recurring item from the database.
+	Delete(ctx context.Context, id uuid.UUID) error
+
+	// ClaimOccurrence records a pending occurrence, taking over one whose claim
+	// is older than lease. It returns true if the occurrence was taken over.
+	ClaimOccurrence(ctx context.Context, id uuid.UUID, at time.Time, lease time.Duration) (bool, error)
+
+	// FindOccurrenceItem retrieves the ID of the item materialized for an
+	// occurrence, or uuid.Nil if there is none.
+	FindOccurrenceItem(ctx context.Context, id uuid.UUID, at time.Time) (uuid.UUID, error)
+
+	// SkipOccurrence records a skipped occurrence.
+	SkipOccurrence(ctx context.Context, id uuid.UUID, at time.Time) error
+
+	// CompleteOccurrence marks a pending occurrence as created by the given item.
+	CompleteOccurrence(ctx context.Context, id uuid.UUID, at time.Time, itemID uuid.UUID) error
+
+	// ReleaseOccurrence removes a pending occurrence so it can be retried later.
+	ReleaseOccurrence(ctx context.Context, id uuid.UUID, at time.Time) error
+
+	// ListOccurrences retrieves handled occurrences of a recurring item.
+	ListOccurrences(ctx context.Context, id uuid.UUID) ([]model.RecurringOccurrence, error)
+}
+
+// itemCreator creates items; it is satisfied by the item service.
+type itemCreator interface {
+	// Create adds a new item with the given fields and optional references and splits.
+	Create(ctx context.Context, kind, title string, amount decimal.Decimal, currency string, occurredAt time.Time, categoryID, accountID, refundOf *uuid.UUID, metadata json.RawMessage, splits []model.ItemSplit) (uuid.UUID, error)
+}
+
+// workspaceLister lists workspaces; it is satisfied by the workspace service.
+type workspaceLister interface {
+	// List returns all workspaces.
+	List(ctx context.Context) ([]model.Workspace, error)
+}
+
+// Service provides recurring item business logic and materializes due occurrences.
+type Service struct {
+	repository repository
+	items      itemCreator
+	workspaces workspaceLister
+	now        func() time.Time
+}
+
+// NewService creates a new recurring item service.
+func NewService(r repository, items itemCreator, workspaces workspaceLister) *Service {
+	return &Service{repository: r, items: items, workspaces: workspaces, now: time.Now}
+}
+
+// Create adds a new recurring item with the given template and schedule.
+func (s *Service) Create(
+	ctx context.Context,
+	kind string,
+	title string,
+	amount decimal.Decimal,
+	currency string,
+	categoryID *uuid.UUID,
+	metadata json.RawMessage,
+	rule string,
+	startAt time.Time,
+	endAt *time.Time,
+) (uuid.UUID, error) {
+	schedule, err := ParseRule(rule)
+	if err != nil {
+		return uuid.Nil, err
+	}
+
+	ri := &model.RecurringItem{
+		Kind:       kind,
+		Title:      title,
+		Amount:     amount,
+		Currency:   currency,
+		CategoryID: categoryID,
+		Metadata:   metadata,
+		Rule:       rule,
+		StartAt:    startAt,
+		EndAt:      endAt,
+	}
+	ri.NextIndex, ri.NextRunAt = schedule.FirstFrom(ri.StartAt, ri.EndAt, startAt)
+
+	id, err := s.repository.Create(ctx, ri)
+	if err != nil {
+		return uuid.Nil, fmt.Errorf("create recurring item: %w", err)
+	}
+
+	return id, nil
+}
+
+// GetByID returns a recurring item by its ID.
+func (s *Service) GetByID(ctx context.Context, id uuid.UUID) (*model.RecurringItem, error) {
+	ri, err := s.repository.GetByID(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("get recurring item: %w", err)
+	}
+
+	return ri, nil
+}
+
+// List returns all recurring items.
+func (s *Service) List(ctx context.Context) ([]model.RecurringItem, error) {
+	items, err := s.repository.List(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("list recurring items: %w", err)
+	}
+
+	return items, nil
+}
+
+// Update replaces the template and schedule of a recurring item.
+// The schedule continues from the first occurrence that is not in the past,
+// so changing a rule never backfills occurrences.
+func (s *Service) Update(
+	ctx context.Context,
+	id uuid.UUID,
+	kind string,
+	title string,
+	amount decimal.Decimal,
+	currency string,
+	categoryID *uuid.UUID,
+	metadata json.RawMessage,
+	rule string,
+	startAt time.Time,
+	endAt *time.Time,
+) error {
+	schedule, err := ParseRule(rule)
+	if err != nil {
+		return err
+	}
+
+	ri, err := s.repository.GetByID(ctx, id)
+	if err != nil {
+		return fmt.Errorf("update recurring item: %w", err)
+	}
+
+	ri.Kind = kind
+	ri.Title = title
+	ri.Amount = amount
+	ri.Currency = currency
+	ri.CategoryID = categoryID
+	ri.Metadata = metadata
+	ri.Rule = rule
+	ri.StartAt = startAt
+	ri.EndAt = endAt
+	ri.NextIndex, ri.NextRunAt = schedule.FirstFrom(ri.StartAt, ri.EndAt, s.now())
+
+	if err = s.repository.Update(ctx, ri); err != nil {
+		return fmt.Errorf("update recurring item: %w", err)
+	}
+
+	return nil
+}
+
+// Delete removes a recurring item by its ID. Already created items are kept.
+func (s *Service) Delete(ctx context.Context, id uuid.UUID) error {
+	if err := s.repository.Delete(ctx, id); err != nil {
+		return fmt.Errorf("delete recurring item: %w", err)
+	}
+
+	return nil
+}
+
+// Pause suspends materialization of a recurring item.
+func (s *Service) Pause(ctx context.Context, id uuid.UUID) error {
+	ri, err := s.repository.GetByID(ctx, id)
+	if err != nil {
+		return fmt.Errorf("pause recurring item: %w", err)
+	}
+
+	ri.Paused = true
+	if err = s.repository.Update(ctx, ri); err != nil {
+		return fmt.Errorf("pause recurring item: %w", err)
+	}
+
+	return nil
+}
+
+// Resume re-enables materialization of a paused recurring item.
+// Occurrences that fell into the paused period are not created.
+func (s *Service) Resume(ctx context.Context, id uuid.UUID) error {
+	ri, err := s.repository.GetByID(ctx, id)
+	if err != nil {
+		return fmt.Errorf("resume recurring item: %w", err)
+	}
+
+	schedule, err := ParseRule(ri.Rule)
+	if err != nil {
+		return fmt.Errorf("resume recurring item: %w", err)
+	}
+
+	ri.Paused = false
+	ri.NextIndex, ri.NextRunAt = schedule.FirstFrom(ri.StartAt, ri.EndAt, s.now())
+
+	if err = s.repository.Update(ctx, ri); err != nil {
+		return fmt.Errorf("resume recurring item: %w", err)
+	}
+
+	return nil
+}
+
+// Skip marks a single occurrence as skipped so no item is created for it.
+// If at is nil, the next pending occurrence is skipped.
+func (s *Service) Skip(ctx context.Context, id uuid.UUID, at *time.Time) (time.Time, error) {
+	ri, err := s.repository.GetByID(ctx, id)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("skip occurrence: %w", err)
+	}
+
+	schedule, err := ParseRule(ri.Rule)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("skip occurrence: %w", err)
+	}
+
+	var occurrence time.Time
+	if at == nil {
+		if ri.NextRunAt == nil {
+			return time.Time{}, ErrNotAnOccurrence
+		}
+		occurrence = *ri.NextRunAt
+	} else {
+		_, next := schedule.FirstFrom(ri.StartAt, ri.EndAt, *at)
+		if next == nil || !next.Equal(*at) {
+			return time.Time{}, ErrNotAnOccurrence
+		}
+		occurrence = *next
+	}
+
+	if err = s.repository.SkipOccurrence(ctx, id, occurrence); err != nil {
+		return time.Time{}, fmt.Errorf("skip occurrence: %w", err)
+	}
+
+	return occurrence, nil
+}
+
+// ListOccurrences returns handled occurrences of a recurring item.
+func (s *Service) ListOccurrences(ctx context.Context, id uuid.UUID) ([]model.RecurringOccurrence, error) {
+	if _, err := s.repository.GetByID(ctx, id); err != nil {
+		return nil, fmt.Errorf("list occurrences: %w", err)
+	}
+
+	occurrences, err := s.repository.ListOccurrences(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("list occurrences: %w", err)
+	}
+
+	return occurrences, nil
+}
+
+// MaterializeDue creates items for every due occurrence of every active
+// recurring item of the context workspace, including occurrences missed while
+// the process was down. It returns the number of created items. A failing
+// recurring item does not stop the others; their errors are joined.
+func (s *Service) MaterializeDue(ctx context.Context) (int, error) {
+	now := s.now()
+
+	due, err := s.repository.ListDue(ctx, now)
+	if err != nil {
+		return 0, fmt.Errorf("list due recurring items: %w", err)
+	}
+
+	var (
+		created int
+		errs    []error
+	)
+	for i := range due {
+		n, err := s.materialize(ctx, &due[i], now)
+		created += n
+		if err != nil {
+			errs = append(errs, fmt.Errorf("materialize recurring item %s: %w", due[i].ID, err))
+		}
+
+		if ctx.Err() != nil {
+			break
+		}
+	}
+
+	return created, errors.Join(errs...)
+}
+
+// materialize creates items for the due occurrences of a single recurring item
+// and advances its schedule after each handled occurrence.
+func (s *Service) materialize(ctx context.Context, ri *model.RecurringItem, now time.Time) (int, error) {
+	schedule, err := ParseRule(ri.Rule)
+	if err != nil {
+		return 0, err
+	}
+
+	created := 0
+	index := ri.NextIndex
+	for {
+		at, ok := schedule.At(ri.StartAt, index, ri.EndAt)
+		if !ok || at.After(now) {
+			break
+		}
+
+		if err = ctx.Err(); err != nil {
+			return created, err
+		}
+
+		ok, err = s.createOccurrence(ctx, ri, at)
+		if errors.Is(err, recurring.ErrOccurrenceClaimed) {
+			// Another worker is creating the item; the schedule is advanced by it
+			// or, if it stopped, after the claim is taken over on a later run.
+			break
+		}
+		if err != nil {
+			return created, err
+		}
+		if ok {
+			created++
+		}
+
+		index++
+		next, hasNext := schedule.At(ri.StartAt, index, ri.EndAt)
+		var nextRunAt *time.Time
+		if hasNext {
+			nextRunAt = &next
+		}
+
+		if err = s.repository.Advance(ctx, ri.ID, index, nextRunAt); err != nil {
+			return created, err
+		}
+	}
+
+	return created, nil
+}
+
+// createOccurrence claims an occurrence and creates its item through the item service.
+// It returns false if the occurrence was already handled (created or skipped).
+// A claim taken over from a stopped worker is completed with the item that
+// worker created, if any, so the item is never created twice.
+func (s *Service) createOccurrence(ctx context.Context, ri *model.RecurringItem, at time.Time) (bool, error) {
+	takenOver, err := s.repository.ClaimOccurrence(ctx, ri.ID, at, occurrenceLease)
+	if err != nil {
+		if errors.Is(err, recurring.ErrOccurrenceHandled) {
+			return false, nil
+		}
+
+		return false, err
+	}
+
+	itemID := uuid.Nil
+	if takenOver {
+		if itemID, err = s.repository.FindOccurrenceItem(ctx, ri.ID, at); err != nil {
+			return false, err
+		}
+	}
+
+	if itemID == uuid.Nil {
+		metadata, err := withRecurringMetadata(ri.Metadata, ri.ID)
+		if err != nil {
+			_ = s.repository.ReleaseOccurrence(ctx, ri.ID, at)
+			return false, err
+		}
+
+		itemID, err = s.items.Create(ctx, ri.Kind, ri.Title, ri.Amount, ri.Currency, at, ri.CategoryID, nil, nil, metadata, nil)
+		if err != nil {
+			_ = s.repository.ReleaseOccurrence(ctx, ri.ID, at)
+			return false, err
+		}
+	}
+
+	// If this fails the occurrence stays pending and is completed with the
+	// item once its claim is taken over.
+	if err = s.repository.CompleteOccurrence(ctx, ri.ID, at, itemID); err != nil {
+		return true, err
+	}
+
+	return true, nil
+}
+
+// withRecurringMetadata adds the recurring item reference to the template metadata.
+func withRecurringMetadata(metadata json.RawMessage, id uuid.UUID) (json.RawMessage, error) {
+	fields := map[string]interface{}{}
+	if len(metadata) > 0 {
+		if err := json.Unmarshal(metadata, &fields); err != nil {
+			return nil, fmt.Errorf("decode template metadata: %w", err)
+		}
+	}
+
+	fields["recurring_item_id"] = id.String()
+
+	out, err := json.Marshal(fields)
+	if err != nil {
+		return nil, fmt.Errorf("encode item metadata: %w", err)
+	}
+
+	return out, nil
+}
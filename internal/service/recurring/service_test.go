@@ -0,0 +1,112 @@
+package recurring
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+
+	"github.com/aliskhannn/sales-tracker/internal/model"
+)
+
+// fakeRepository returns fixed due recurring items and records their
+// schedule changes.
+type fakeRepository struct {
+	repository
+
+	due      []model.RecurringItem
+	advanced map[uuid.UUID]int
+}
+
+func (f *fakeRepository) ListDue(context.Context, time.Time) ([]model.RecurringItem, error) {
+	return f.due, nil
+}
+
+func (f *fakeRepository) ClaimOccurrence(context.Context, uuid.UUID, time.Time, time.Duration) (bool, error) {
+	return false, nil
+}
+
+func (f *fakeRepository) CompleteOccurrence(context.Context, uuid.UUID, time.Time, uuid.UUID) error {
+	return nil
+}
+
+func (f *fakeRepository) ReleaseOccurrence(context.Context, uuid.UUID, time.Time) error {
+	return nil
+}
+
+func (f *fakeRepository) Advance(_ context.Context, id uuid.UUID, nextIndex int, _ *time.Time) error {
+	f.advanced[id] = nextIndex
+	return nil
+}
+
+// fakeItems creates items, failing for the title "broken".
+type fakeItems struct {
+	created []string
+}
+
+func (f *fakeItems) Create(_ context.Context, _, title string, _ decimal.Decimal, _ string, _ time.Time, _, _, _ *uuid.UUID, _ json.RawMessage, _ []model.ItemSplit) (uuid.UUID, error) {
+	if title == "broken" {
+		return uuid.Nil, errors.New("item rejected")
+	}
+
+	f.created = append(f.created, title)
+	return uuid.New(), nil
+}
+
+func TestMaterializeDueContinuesAfterFailures(t *testing.T) {
+	start := time.Date(2025, 10, 1, 9, 0, 0, 0, time.UTC)
+	item := func(title, rule string) model.RecurringItem {
+		return model.RecurringItem{
+			ID:        uuid.New(),
+			Kind:      model.KindExpense,
+			Title:     title,
+			Amount:    decimal.NewFromInt(10),
+			Currency:  "USD",
+			Rule:      rule,
+			StartAt:   start,
+			NextRunAt: &start,
+		}
+	}
+
+	invalid := item("invalid", "FREQ=HOURLY")
+	broken := item("broken", "FREQ=DAILY")
+	rent := item("rent", "FREQ=DAILY")
+
+	r := &fakeRepository{due: []model.RecurringItem{invalid, broken, rent}, advanced: map[uuid.UUID]int{}}
+	items := &fakeItems{}
+	s := NewService(r, items, nil)
+	s.now = func() time.Time { return start.Add(49 * time.Hour) }
+
+	created, err := s.MaterializeDue(context.Background())
+	if created != 3 {
+		t.Errorf("created = %d, want 3", created)
+	}
+
+	if len(items.created) != 3 || items.created[0] != "rent" {
+		t.Errorf("created items = %v, want 3 of rent", items.created)
+	}
+
+	if got := r.advanced[rent.ID]; got != 3 {
+		t.Errorf("rent advanced to index %d, want 3", got)
+	}
+	if _, ok := r.advanced[broken.ID]; ok {
+		t.Error("broken advanced, want it retried on the next run")
+	}
+
+	if err == nil {
+		t.Fatal("MaterializeDue() error = nil, want the failures")
+	}
+	for _, ri := range []model.RecurringItem{invalid, broken} {
+		if !strings.Contains(err.Error(), ri.ID.String()) {
+			t.Errorf("MaterializeDue() error = %v, want it to name %s", err, ri.Title)
+		}
+	}
+	if strings.Contains(err.Error(), rent.ID.String()) {
+		t.Errorf("MaterializeDue() error = %v, want it not to name rent", err)
+	}
+}
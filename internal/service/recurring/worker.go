@@ -0,0 +1,63 @@
+package recurring
+
+import (
+	"context"
+	"time"
+
+	"github.com/aliskhannn/sales-tracker/internal/logging"
+	"github.com/aliskhannn/sales-tracker/internal/tenant"
+)
+
+// defaultPollInterval is used when no positive poll interval is configured.
+const defaultPollInterval = time.Minute
+
+// Run materializes due occurrences of every workspace immediately and then on
+// every tick of interval until ctx is cancelled. Errors are logged and retried
+// on the next tick.
+func (s *Service) Run(ctx context.Context, interval time.Duration) {
+	if interval <= 0 {
+		interval = defaultPollInterval
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		s.materializeAll(ctx)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// materializeAll materializes due occurrences workspace by workspace.
+// A failing workspace does not stop the others.
+func (s *Service) materializeAll(ctx context.Context) {
+	workspaces, err := s.workspaces.List(ctx)
+	if err != nil {
+		if ctx.Err() == nil {
+			logging.Ctx(ctx).Error().Err(err).Msg("failed to list workspaces")
+		}
+
+		return
+	}
+
+	for _, w := range workspaces {
+		wctx := logging.With(tenant.WithWorkspace(ctx, w.ID), "workspace", w.Slug)
+
+		created, err := s.MaterializeDue(wctx)
+		if err != nil && ctx.Err() == nil {
+			logging.Ctx(wctx).Error().Err(err).Msg("failed to materialize recurring items")
+		}
+		if created > 0 {
+			logging.Ctx(wctx).Info().Int("created", created).Msg("materialized recurring items")
+		}
+
+		if ctx.Err() != nil {
+			return
+		}
+	}
+}
@@ -0,0 +1,337 @@
+package rule
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"regexp"
+	"strings"
+
+	"github.com/google/uuid"
+
+	"github.com/aliskhannn/sales-tracker/internal/model"
+)
+
+var (
+	ErrInvalidRule = errors.New("invalid rule")
+)
+
+// compiledRule is a rule with its regular expressions compiled.
+type compiledRule struct {
+	model.Rule
+	regexps map[int]*regexp.Regexp // by condition index
+}
+
+// compile validates rules and compiles their regular expressions.
+func compile(rules []model.Rule) ([]compiledRule, error) {
+	compiled := make([]compiledRule, 0, len(rules))
+	for _, r := range rules {
+		cr, err := compileRule(r)
+		if err != nil {
+			return nil, fmt.Errorf("rule %s: %w", r.ID, err)
+		}
+
+		compiled = append(compiled, cr)
+	}
+
+	return compiled, nil
+}
+
+// compileRule validates a single rule and compiles its regular expressions.
+func compileRule(r model.Rule) (compiledRule, error) {
+	cr := compiledRule{Rule: r, regexps: make(map[int]*regexp.Regexp)}
+
+	if len(r.Conditions) == 0 {
+		return cr, fmt.Errorf("%w: at least one condition is required", ErrInvalidRule)
+	}
+
+	if len(r.Actions) == 0 {
+		return cr, fmt.Errorf("%w: at least one action is required", ErrInvalidRule)
+	}
+
+	for n, c := range r.Conditions {
+		if err := validateCondition(c); err != nil {
+			return cr, fmt.Errorf("%w: condition %d: %s", ErrInvalidRule, n, err)
+		}
+
+		if c.Operator == model.RuleOpRegex {
+			re, err := regexp.Compile(c.Value)
+			if err != nil {
+				return cr, fmt.Errorf("%w: condition %d: %s", ErrInvalidRule, n, err)
+			}
+
+			cr.regexps[n] = re
+		}
+	}
+
+	for n, a := range r.Actions {
+		if err := validateAction(a); err != nil {
+			return cr, fmt.Errorf("%w: action %d: %s", ErrInvalidRule, n, err)
+		}
+	}
+
+	return cr, nil
+}
+
+// validateCondition checks that the operator is supported for the field.
+func validateCondition(c model.RuleCondition) error {
+	switch c.Field {
+	case model.RuleFieldTitle:
+		switch c.Operator {
+		case model.RuleOpEquals, model.RuleOpContains, model.RuleOpRegex:
+		default:
+			return fmt.Errorf("unsupported operator %q for title", c.Operator)
+		}
+	case model.RuleFieldAmount:
+		if c.Operator != model.RuleOpBetween {
+			return fmt.Errorf("unsupported operator %q for amount", c.Operator)
+		}
+
+		if c.Min == nil && c.Max == nil {
+			return fmt.Errorf("min or max is required")
+		}
+
+		if c.Min != nil && c.Max != nil && c.Min.GreaterThan(*c.Max) {
+			return fmt.Errorf("min must not be greater than max")
+		}
+
+		return nil
+	case model.RuleFieldKind, model.RuleFieldCurrency:
+		if c.Operator != model.RuleOpEquals {
+			return fmt.Errorf("unsupported operator %q for %s", c.Operator, c.Field)
+		}
+	case model.RuleFieldMetadata:
+		if c.Path == "" {
+			return fmt.Errorf("path is required for metadata")
+		}
+
+		switch c.Operator {
+		case model.RuleOpEquals, model.RuleOpContains:
+		case model.RuleOpExists:
+			return nil
+		default:
+			return fmt.Errorf("unsupported operator %q for metadata", c.Operator)
+		}
+	default:
+		return fmt.Errorf("unsupported field %q", c.Field)
+	}
+
+	if c.Value == "" {
+		return fmt.Errorf("value is required")
+	}
+
+	return nil
+}
+
+// validateAction checks that the action has the fields its type requires.
+func validateAction(a model.RuleAction) error {
+	switch a.Type {
+	case model.RuleActionSetCategory:
+		if a.CategoryID == nil {
+			return fmt.Errorf("category_id is required")
+		}
+	case model.RuleActionAddMetadata:
+		if a.Key == "" {
+			return fmt.Errorf("key is required")
+		}
+
+		if !json.Valid(a.Value) {
+			return fmt.Errorf("value must be valid JSON")
+		}
+	case model.RuleActionSetKind:
+		// Refunds need the item they refund, which rules cannot set.
+		switch a.Kind {
+		case model.KindIncome, model.KindExpense:
+		default:
+			return fmt.Errorf("unsupported kind %q", a.Kind)
+		}
+	default:
+		return fmt.Errorf("unsupported action %q", a.Type)
+	}
+
+	return nil
+}
+
+// apply runs rules over the item in order and modifies it in place.
+// It returns the IDs of the rules that matched.
+//
+// Transfer legs are never modified. set_kind is not applied to refunds that
+// reference a refunded item, nor to items that are refunded, as refunded
+// reports. Unless overwrite is true, set_category does not replace an
+// existing category and add_metadata does not replace an existing metadata
+// key; metadata is left untouched if the key already has the value.
+func apply(rules []compiledRule, i *model.Item, overwrite, refunded bool) ([]uuid.UUID, error) {
+	if i.TransferID != nil {
+		return nil, nil
+	}
+
+	metadata, err := decodeMetadata(i.Metadata)
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		matched      []uuid.UUID
+		metaModified bool
+	)
+
+	for _, r := range rules {
+		if !r.matches(i, metadata) {
+			continue
+		}
+
+		matched = append(matched, r.ID)
+
+		for _, a := range r.Actions {
+			switch a.Type {
+			case model.RuleActionSetCategory:
+				if i.CategoryID == nil || overwrite {
+					id := *a.CategoryID
+					i.CategoryID = &id
+				}
+			case model.RuleActionAddMetadata:
+				if metadata == nil {
+					continue
+				}
+
+				var v any
+				if err := decodeJSON(a.Value, &v); err != nil {
+					return nil, err
+				}
+
+				if old, ok := metadata[a.Key]; ok && (!overwrite || reflect.DeepEqual(old, v)) {
+					continue
+				}
+
+				metadata[a.Key] = v
+				metaModified = true
+			case model.RuleActionSetKind:
+				if i.RefundOf == nil && !refunded {
+					i.Kind = a.Kind
+				}
+			}
+		}
+
+		if r.StopProcessing {
+			break
+		}
+	}
+
+	if metaModified {
+		raw, err := json.Marshal(metadata)
+		if err != nil {
+			return nil, fmt.Errorf("encode metadata: %w", err)
+		}
+
+		i.Metadata = raw
+	}
+
+	return matched, nil
+}
+
+// matches reports whether all conditions of the rule match the item.
+func (r compiledRule) matches(i *model.Item, metadata map[string]any) bool {
+	for n, c := range r.Conditions {
+		var ok bool
+
+		switch c.Field {
+		case model.RuleFieldTitle:
+			switch c.Operator {
+			case model.RuleOpEquals:
+				ok = strings.EqualFold(i.Title, c.Value)
+			case model.RuleOpContains:
+				ok = strings.Contains(strings.ToLower(i.Title), strings.ToLower(c.Value))
+			case model.RuleOpRegex:
+				ok = r.regexps[n].MatchString(i.Title)
+			}
+		case model.RuleFieldAmount:
+			ok = (c.Min == nil || i.Amount.GreaterThanOrEqual(*c.Min)) &&
+				(c.Max == nil || i.Amount.LessThanOrEqual(*c.Max))
+		case model.RuleFieldKind:
+			ok = i.Kind == c.Value
+		case model.RuleFieldCurrency:
+			ok = strings.EqualFold(i.Currency, c.Value)
+		case model.RuleFieldMetadata:
+			ok = matchMetadata(metadata, c)
+		}
+
+		if !ok {
+			return false
+		}
+	}
+
+	return true
+}
+
+// matchMetadata evaluates a metadata condition against the value at its path.
+// Non-string values are compared by their JSON encoding, e.g. "true" or "42".
+func matchMetadata(metadata map[string]any, c model.RuleCondition) bool {
+	v, ok := lookup(metadata, c.Path)
+	if !ok {
+		return false
+	}
+
+	if c.Operator == model.RuleOpExists {
+		return true
+	}
+
+	s, isString := v.(string)
+	if !isString {
+		raw, err := json.Marshal(v)
+		if err != nil {
+			return false
+		}
+		s = string(raw)
+	}
+
+	switch c.Operator {
+	case model.RuleOpEquals:
+		return s == c.Value
+	case model.RuleOpContains:
+		return strings.Contains(strings.ToLower(s), strings.ToLower(c.Value))
+	}
+
+	return false
+}
+
+// lookup returns the value at a dot-separated path in the metadata.
+func lookup(metadata map[string]any, path string) (any, bool) {
+	var cur any = metadata
+	for _, key := range strings.Split(path, ".") {
+		m, ok := cur.(map[string]any)
+		if !ok {
+			return nil, false
+		}
+
+		if cur, ok = m[key]; !ok {
+			return nil, false
+		}
+	}
+
+	return cur, true
+}
+
+// decodeMetadata decodes item metadata. It returns nil if the metadata is
+// empty or not a JSON object; such items never match metadata conditions.
+func decodeMetadata(raw json.RawMessage) (map[string]any, error) {
+	if len(raw) == 0 {
+		return map[string]any{}, nil
+	}
+
+	var v any
+	if err := decodeJSON(raw, &v); err != nil {
+		return nil, fmt.Errorf("decode metadata: %w", err)
+	}
+
+	m, _ := v.(map[string]any)
+	return m, nil
+}
+
+// decodeJSON decodes raw JSON keeping numbers as json.Number to preserve precision.
+func decodeJSON(raw []byte, v any) error {
+	dec := json.NewDecoder(bytes.NewReader(raw))
+	dec.UseNumber()
+	return dec.Decode(v)
+}
@@ -0,0 +1,291 @@
+package rule
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/aliskhannn/sales-tracker/internal/model"
+)
+
+// applyBatchSize is the number of items loaded per query by ApplyExisting.
+const applyBatchSize = 500
+
+// repository provides methods to interact with rules.
+type repository interface {
+	// Create adds a new rule to the database.
+	Create(ctx context.Context, r *model.Rule) (uuid.UUID, error)
+
+	// GetByID retrieves a rule by its ID.
+	GetByID(ctx context.Context, id uuid.UUID) (*model.Rule, error)
+
+	// List retrieves rules in evaluation order, optionally only enabled ones.
+	List(ctx context.Context, enabledOnly bool) ([]model.Rule, error)
+
+	// Update updates a rule.
+	Update(ctx context.Context, r *model.Rule) error
+
+	// Delete removes a rule from the database.
+	Delete(ctx context.Context, id uuid.UUID) error
+}
+
+// itemRepository provides access to existing items.
+type itemRepository interface {
+	// List retrieves items from the database applying optional filters.
+	List(ctx context.Context, filter *model.ItemFilter) ([]model.Item, error)
+
+	// UpdateClassification updates the kind, category and metadata of an item.
+	UpdateClassification(ctx context.Context, i *model.Item) error
+
+	// RefundedIDs returns which of the items with the given IDs have refunds.
+	RefundedIDs(ctx context.Context, ids []uuid.UUID) (map[uuid.UUID]bool, error)
+}
+
+// Service provides rule-related business logic.
+type Service struct {
+	repository repository
+	items      itemRepository
+}
+
+// NewService creates a new rule service.
+func NewService(r repository, items itemRepository) *Service {
+	return &Service{repository: r, items: items}
+}
+
+// Create adds a new rule after validating its conditions and actions.
+func (s *Service) Create(
+	ctx context.Context,
+	name string,
+	position int,
+	enabled bool,
+	stopProcessing bool,
+	conditions []model.RuleCondition,
+	actions []model.RuleAction,
+) (uuid.UUID, error) {
+	r := &model.Rule{
+		Name:           name,
+		Position:       position,
+		Enabled:        enabled,
+		StopProcessing: stopProcessing,
+		Conditions:     conditions,
+		Actions:        actions,
+	}
+
+	if _, err := compileRule(*r); err != nil {
+		return uuid.Nil, err
+	}
+
+	id, err := s.repository.Create(ctx, r)
+	if err != nil {
+		return uuid.Nil, fmt.Errorf("create rule: %w", err)
+	}
+
+	return id, nil
+}
+
+// GetByID returns a rule by its ID.
+func (s *Service) GetByID(ctx context.Context, id uuid.UUID) (*model.Rule, error) {
+	r, err := s.repository.GetByID(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("get rule: %w", err)
+	}
+
+	return r, nil
+}
+
+// List returns all rules in evaluation order.
+func (s *Service) List(ctx context.Context) ([]model.Rule, error) {
+	rules, err := s.repository.List(ctx, false)
+	if err != nil {
+		return nil, fmt.Errorf("list rules: %w", err)
+	}
+
+	return rules, nil
+}
+
+// Update modifies an existing rule identified by id.
+func (s *Service) Update(
+	ctx context.Context,
+	id uuid.UUID,
+	name string,
+	position int,
+	enabled bool,
+	stopProcessing bool,
+	conditions []model.RuleCondition,
+	actions []model.RuleAction,
+) error {
+	r := &model.Rule{
+		ID:             id,
+		Name:           name,
+		Position:       position,
+		Enabled:        enabled,
+		StopProcessing: stopProcessing,
+		Conditions:     conditions,
+		Actions:        actions,
+	}
+
+	if _, err := compileRule(*r); err != nil {
+		return err
+	}
+
+	if err := s.repository.Update(ctx, r); err != nil {
+		return fmt.Errorf("update rule: %w", err)
+	}
+
+	return nil
+}
+
+// Delete removes a rule by its ID.
+func (s *Service) Delete(ctx context.Context, id uuid.UUID) error {
+	if err := s.repository.Delete(ctx, id); err != nil {
+		return fmt.Errorf("delete rule: %w", err)
+	}
+
+	return nil
+}
+
+// Apply runs enabled rules over a new item and modifies it in place.
+// Rules never replace a category or metadata keys already set on the item.
+func (s *Service) Apply(ctx context.Context, i *model.Item) error {
+	rules, err := s.enabledRules(ctx)
+	if err != nil {
+		return fmt.Errorf("apply rules: %w", err)
+	}
+
+	if len(rules) == 0 {
+		return nil
+	}
+
+	if _, err = apply(rules, i, false, false); err != nil {
+		return fmt.Errorf("apply rules: %w", err)
+	}
+
+	return nil
+}
+
+// ApplyExisting re-runs enabled rules over existing items that occurred
+// between from and to (both optional). If overwrite is true, rules may replace
+// existing categories and metadata keys. If dryRun is true, nothing is
+// written and the report previews the changes.
+func (s *Service) ApplyExisting(ctx context.Context, from, to *time.Time, overwrite, dryRun bool) (*model.RuleApplyReport, error) {
+	rules, err := s.enabledRules(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("apply rules: %w", err)
+	}
+
+	report := &model.RuleApplyReport{DryRun: dryRun, Results: []model.RuleResult{}}
+	if len(rules) == 0 {
+		return report, nil
+	}
+
+	filter := &model.ItemFilter{From: from, To: to, Limit: applyBatchSize}
+	for {
+		items, err := s.items.List(ctx, filter)
+		if err != nil {
+			return nil, fmt.Errorf("apply rules: %w", err)
+		}
+
+		// The kind of refunded items is kept, so that refunds keep referencing
+		// an income or expense item.
+		ids := make([]uuid.UUID, 0, len(items))
+		for _, i := range items {
+			ids = append(ids, i.ID)
+		}
+
+		refunded, err := s.items.RefundedIDs(ctx, ids)
+		if err != nil {
+			return nil, fmt.Errorf("apply rules: %w", err)
+		}
+
+		for _, i := range items {
+			report.Scanned++
+
+			before := classification(&i)
+			matched, err := apply(rules, &i, overwrite, refunded[i.ID])
+			if err != nil {
+				return nil, fmt.Errorf("apply rules to item %s: %w", i.ID, err)
+			}
+
+			after := classification(&i)
+			if sameClassification(before, after) {
+				continue
+			}
+
+			if !dryRun {
+				if err = s.items.UpdateClassification(ctx, &i); err != nil {
+					return nil, fmt.Errorf("apply rules to item %s: %w", i.ID, err)
+				}
+			}
+
+			report.Changed++
+			report.Results = append(report.Results, model.RuleResult{
+				ItemID:  i.ID,
+				Title:   i.Title,
+				RuleIDs: matched,
+				Before:  before,
+				After:   after,
+			})
+		}
+
+		if len(items) < applyBatchSize {
+			break
+		}
+
+		filter.Offset += applyBatchSize
+	}
+
+	return report, nil
+}
+
+// enabledRules loads and compiles enabled rules in evaluation order.
+func (s *Service) enabledRules(ctx context.Context) ([]compiledRule, error) {
+	rules, err := s.repository.List(ctx, true)
+	if err != nil {
+		return nil, err
+	}
+
+	return compile(rules)
+}
+
+// classification returns the fields of an item that rules can change.
+func classification(i *model.Item) model.RuleChange {
+	return model.RuleChange{
+		Kind:       i.Kind,
+		CategoryID: i.CategoryID,
+		Metadata:   i.Metadata,
+	}
+}
+
+// sameClassification reports whether two classifications are equal. Metadata
+// is compared by value, since its encoding depends on who wrote it.
+func sameClassification(a, b model.RuleChange) bool {
+	if a.Kind != b.Kind || !sameJSON(a.Metadata, b.Metadata) {
+		return false
+	}
+
+	if a.CategoryID == nil || b.CategoryID == nil {
+		return a.CategoryID == b.CategoryID
+	}
+
+	return *a.CategoryID == *b.CategoryID
+}
+
+// sameJSON reports whether two JSON documents have the same value; invalid
+// documents are equal only if they have the same bytes.
+func sameJSON(a, b json.RawMessage) bool {
+	if bytes.Equal(a, b) {
+		return true
+	}
+
+	var va, vb any
+	if decodeJSON(a, &va) != nil || decodeJSON(b, &vb) != nil {
+		return false
+	}
+
+	return reflect.DeepEqual(va, vb)
+}
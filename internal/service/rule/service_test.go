@@ -0,0 +1,191 @@
+package rule
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/google/uuid"
+
+	"github.com/aliskhannn/sales-tracker/internal/model"
+)
+
+// fakeRepository returns fixed enabled rules.
+type fakeRepository struct {
+	repository
+	rules []model.Rule
+}
+
+func (f *fakeRepository) List(context.Context, bool) ([]model.Rule, error) {
+	return f.rules, nil
+}
+
+// fakeItems returns fixed items and records classification updates.
+type fakeItems struct {
+	items    []model.Item
+	refunded map[uuid.UUID]bool
+	updated  []model.Item
+}
+
+func (f *fakeItems) List(context.Context, *model.ItemFilter) ([]model.Item, error) {
+	return f.items, nil
+}
+
+func (f *fakeItems) UpdateClassification(_ context.Context, i *model.Item) error {
+	f.updated = append(f.updated, *i)
+	return nil
+}
+
+func (f *fakeItems) RefundedIDs(context.Context, []uuid.UUID) (map[uuid.UUID]bool, error) {
+	return f.refunded, nil
+}
+
+func TestApplyExisting(t *testing.T) {
+	// Matches every item titled "Coffee".
+	coffee := []model.RuleCondition{{Field: model.RuleFieldTitle, Operator: model.RuleOpEquals, Value: "Coffee"}}
+	addMetadata := func(key, value string) model.RuleAction {
+		return model.RuleAction{Type: model.RuleActionAddMetadata, Key: key, Value: json.RawMessage(value)}
+	}
+
+	tests := []struct {
+		name         string
+		actions      []model.RuleAction
+		metadata     string
+		overwrite    bool
+		wantMetadata string // empty if the item is unchanged
+	}{
+		{
+			name:         "new key",
+			actions:      []model.RuleAction{addMetadata("source", `"rule"`)},
+			metadata:     `{"note": "x"}`,
+			wantMetadata: `{"note": "x", "source": "rule"}`,
+		},
+		{
+			name:     "existing key is kept",
+			actions:  []model.RuleAction{addMetadata("source", `"rule"`)},
+			metadata: `{"source": "bank"}`,
+		},
+		{
+			name:         "existing key is overwritten",
+			actions:      []model.RuleAction{addMetadata("source", `"rule"`)},
+			metadata:     `{"source": "bank"}`,
+			overwrite:    true,
+			wantMetadata: `{"source": "rule"}`,
+		},
+		{
+			name:      "overwrite with the same value",
+			actions:   []model.RuleAction{addMetadata("source", `"rule"`), addMetadata("tags", `{"a": [1, 2.50]}`)},
+			metadata:  `{"tags": {"a": [1, 2.50]}, "note": "x", "source": "rule"}`,
+			overwrite: true,
+		},
+		{
+			name:      "value changed and changed back",
+			actions:   []model.RuleAction{addMetadata("source", `"rule"`), addMetadata("source", `"bank"`)},
+			metadata:  `{"note": "x", "source": "bank"}`,
+			overwrite: true,
+		},
+	}
+
+	for _, tt := range tests {
+		for _, dryRun := range []bool{true, false} {
+			t.Run(fmt.Sprintf("%s/dry run %t", tt.name, dryRun), func(t *testing.T) {
+				rules := &fakeRepository{rules: []model.Rule{{ID: uuid.New(), Enabled: true, Conditions: coffee, Actions: tt.actions}}}
+				items := &fakeItems{items: []model.Item{{
+					ID:       uuid.New(),
+					Kind:     model.KindExpense,
+					Title:    "Coffee",
+					Metadata: json.RawMessage(tt.metadata),
+				}}}
+				s := NewService(rules, items)
+
+				report, err := s.ApplyExisting(context.Background(), nil, nil, tt.overwrite, dryRun)
+				if err != nil {
+					t.Fatalf("ApplyExisting() error = %v", err)
+				}
+
+				wantChanged := 0
+				if tt.wantMetadata != "" {
+					wantChanged = 1
+				}
+				if report.Scanned != 1 || report.Changed != wantChanged || len(report.Results) != wantChanged {
+					t.Fatalf("report = scanned %d, changed %d, %d results, want 1, %[4]d, %[4]d",
+						report.Scanned, report.Changed, len(report.Results), wantChanged)
+				}
+
+				wantUpdates := wantChanged
+				if dryRun {
+					wantUpdates = 0
+				}
+				if len(items.updated) != wantUpdates {
+					t.Fatalf("updated %d items, want %d", len(items.updated), wantUpdates)
+				}
+
+				if wantChanged == 1 && !sameJSON(report.Results[0].After.Metadata, json.RawMessage(tt.wantMetadata)) {
+					t.Errorf("metadata = %s, want %s", report.Results[0].After.Metadata, tt.wantMetadata)
+				}
+			})
+		}
+	}
+}
+
+func TestApplyExistingSetKind(t *testing.T) {
+	refunded, plain, refund := uuid.New(), uuid.New(), uuid.New()
+	items := &fakeItems{
+		items: []model.Item{
+			{ID: refunded, Kind: model.KindIncome, Title: "Coffee"},
+			{ID: plain, Kind: model.KindIncome, Title: "Coffee"},
+			{ID: refund, Kind: model.KindRefund, Title: "Coffee", RefundOf: &refunded},
+		},
+		refunded: map[uuid.UUID]bool{refunded: true},
+	}
+	rules := &fakeRepository{rules: []model.Rule{{
+		ID:         uuid.New(),
+		Enabled:    true,
+		Conditions: []model.RuleCondition{{Field: model.RuleFieldTitle, Operator: model.RuleOpEquals, Value: "Coffee"}},
+		Actions:    []model.RuleAction{{Type: model.RuleActionSetKind, Kind: model.KindExpense}},
+	}}}
+
+	report, err := NewService(rules, items).ApplyExisting(context.Background(), nil, nil, true, false)
+	if err != nil {
+		t.Fatalf("ApplyExisting() error = %v", err)
+	}
+
+	if report.Changed != 1 || len(items.updated) != 1 {
+		t.Fatalf("changed %d items, updated %d, want 1", report.Changed, len(items.updated))
+	}
+
+	if got := items.updated[0]; got.ID != plain || got.Kind != model.KindExpense {
+		t.Errorf("updated item %s to %s, want %s to %s", got.ID, got.Kind, plain, model.KindExpense)
+	}
+}
+
+func TestCompileRuleSetKind(t *testing.T) {
+	tests := []struct {
+		kind    string
+		wantErr bool
+	}{
+		{kind: model.KindIncome},
+		{kind: model.KindExpense},
+		{kind: model.KindRefund, wantErr: true},
+		{kind: model.KindTransfer, wantErr: true},
+		{kind: "", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.kind, func(t *testing.T) {
+			_, err := compileRule(model.Rule{
+				Conditions: []model.RuleCondition{{Field: model.RuleFieldTitle, Operator: model.RuleOpContains, Value: "x"}},
+				Actions:    []model.RuleAction{{Type: model.RuleActionSetKind, Kind: tt.kind}},
+			})
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("compileRule() error = %v, wantErr %v", err, tt.wantErr)
+			}
+
+			if err != nil && !errors.Is(err, ErrInvalidRule) {
+				t.Errorf("compileRule() error = %v, want ErrInvalidRule", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,243 @@
+package schedule
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+
+	"github.com/aliskhannn/sales-tracker/internal/mail"
+	"github.com/aliskhannn/sales-tracker/internal/metrics"
+	"github.com/aliskhannn/sales-tracker/internal/model"
+	"github.com/aliskhannn/sales-tracker/internal/report"
+	srvcrecurring "github.com/aliskhannn/sales-tracker/internal/service/recurring"
+)
+
+var ErrInvalidRange = errors.New("invalid range, expected day, week, month, quarter or year")
+
+// Defaults used when an option is not configured.
+const (
+	defaultPollInterval = 30 * time.Second
+	defaultTimeout      = 10 * time.Minute
+	defaultMaxAttempts  = 3
+	defaultRetryDelay   = 15 * time.Minute
+)
+
+// reportRuns counts finished runs of scheduled reports by status: succeeded or failed.
+var reportRuns = promauto.NewCounterVec(prometheus.CounterOpts{
+	Namespace: metrics.Namespace,
+	Name:      "report_runs_total",
+	Help:      "Number of finished scheduled report runs by status.",
+}, []string{"status"})
+
+// repository provides methods to interact with scheduled reports.
+type repository interface {
+	// Create adds a new scheduled report to the database.
+	Create(ctx context.Context, sr *model.ScheduledReport) (uuid.UUID, error)
+
+	// GetByID retrieves a scheduled report by its ID.
+	GetByID(ctx context.Context, id uuid.UUID) (*model.ScheduledReport, error)
+
+	// List retrieves all scheduled reports of the context workspace.
+	List(ctx context.Context) ([]model.ScheduledReport, error)
+
+	// Update updates a scheduled report including its schedule state.
+	Update(ctx context.Context, sr *model.ScheduledReport) error
+
+	// Delete removes a scheduled report and its run history.
+	Delete(ctx context.Context, id uuid.UUID) error
+
+	// ListRuns retrieves the latest runs of a scheduled report, newest first.
+	ListRuns(ctx context.Context, id uuid.UUID, limit int) ([]model.ReportRun, error)
+
+	// ClaimDue locks a due scheduled report of any workspace, or returns nil if there is none.
+	ClaimDue(ctx context.Context, lease time.Duration) (*model.ScheduledReport, error)
+
+	// Advance moves the schedule of a claimed report to the given occurrence and unlocks it.
+	Advance(ctx context.Context, id uuid.UUID, updatedAt time.Time, nextIndex int, nextRunAt *time.Time) error
+
+	// Retry records a failed attempt at an occurrence of a claimed report and unlocks it.
+	Retry(ctx context.Context, id uuid.UUID, updatedAt time.Time, index int, occurrence time.Time, attempts int, retryAt time.Time) error
+
+	// Release unlocks a claimed report without recording an attempt.
+	Release(ctx context.Context, id uuid.UUID) error
+
+	// StartRun records a running run and fails the runs of the report left running.
+	StartRun(ctx context.Context, run *model.ReportRun, abandoned string) error
+
+	// FinishRun records the outcome of a run.
+	FinishRun(ctx context.Context, id uuid.UUID, status string, msg *string) error
+}
+
+// categoryLister lists categories; it is satisfied by the category service.
+type categoryLister interface {
+	// List returns all categories.
+	List(ctx context.Context) ([]model.Category, error)
+}
+
+// Options configures the scheduler.
+type Options struct {
+	PollInterval time.Duration // how often due reports are checked
+	Lease        time.Duration // how long a report stays claimed before another scheduler takes it over, above timeout
+	Timeout      time.Duration // max duration of computing and sending a single report
+	MaxAttempts  int           // attempts at an occurrence before it is given up
+	RetryDelay   time.Duration // delay before retrying a failed occurrence
+	From         string        // sender address of the emails
+}
+
+// Service manages scheduled reports and computes and emails them when due.
+type Service struct {
+	repository repository
+	analytics  report.Analytics
+	categories categoryLister
+	mailer     mail.Sender
+	opts       Options
+	now        func() time.Time
+}
+
+// NewService creates a new scheduled report service computing reports with a
+// and sending them with m. Zero options fall back to defaults.
+func NewService(r repository, a report.Analytics, categories categoryLister, m mail.Sender, opts Options) *Service {
+	if opts.PollInterval <= 0 {
+		opts.PollInterval = defaultPollInterval
+	}
+	if opts.Timeout <= 0 {
+		opts.Timeout = defaultTimeout
+	}
+	if opts.Lease <= opts.Timeout {
+		opts.Lease = 2 * opts.Timeout
+	}
+	if opts.MaxAttempts <= 0 {
+		opts.MaxAttempts = defaultMaxAttempts
+	}
+	if opts.RetryDelay <= 0 {
+		opts.RetryDelay = defaultRetryDelay
+	}
+
+	return &Service{
+		repository: r,
+		analytics:  a,
+		categories: categories,
+		mailer:     m,
+		opts:       opts,
+		now:        time.Now,
+	}
+}
+
+// Create validates and saves a scheduled report. Its schedule starts with the
+// first occurrence that is not in the past.
+func (s *Service) Create(ctx context.Context, sr *model.ScheduledReport) (*model.ScheduledReport, error) {
+	if err := s.prepare(sr); err != nil {
+		return nil, err
+	}
+
+	if _, err := s.repository.Create(ctx, sr); err != nil {
+		return nil, fmt.Errorf("create scheduled report: %w", err)
+	}
+
+	return sr, nil
+}
+
+// GetByID returns a scheduled report by its ID.
+func (s *Service) GetByID(ctx context.Context, id uuid.UUID) (*model.ScheduledReport, error) {
+	sr, err := s.repository.GetByID(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("get scheduled report: %w", err)
+	}
+
+	return sr, nil
+}
+
+// List returns all scheduled reports.
+func (s *Service) List(ctx context.Context) ([]model.ScheduledReport, error) {
+	reports, err := s.repository.List(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("list scheduled reports: %w", err)
+	}
+
+	return reports, nil
+}
+
+// Update replaces the definition of a scheduled report. Like on creation its
+// schedule continues from the first occurrence that is not in the past, so
+// changing it never sends missed reports.
+func (s *Service) Update(ctx context.Context, id uuid.UUID, def *model.ScheduledReport) (*model.ScheduledReport, error) {
+	sr, err := s.repository.GetByID(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("update scheduled report: %w", err)
+	}
+
+	sr.Name = def.Name
+	sr.Spec = def.Spec
+	sr.Range = def.Range
+	sr.Rule = def.Rule
+	sr.StartAt = def.StartAt
+	sr.Recipients = def.Recipients
+	sr.Enabled = def.Enabled
+	if err = s.prepare(sr); err != nil {
+		return nil, err
+	}
+
+	if err = s.repository.Update(ctx, sr); err != nil {
+		return nil, fmt.Errorf("update scheduled report: %w", err)
+	}
+
+	return sr, nil
+}
+
+// Delete removes a scheduled report and its run history by its ID.
+func (s *Service) Delete(ctx context.Context, id uuid.UUID) error {
+	if err := s.repository.Delete(ctx, id); err != nil {
+		return fmt.Errorf("delete scheduled report: %w", err)
+	}
+
+	return nil
+}
+
+// ListRuns returns the latest runs of a scheduled report.
+func (s *Service) ListRuns(ctx context.Context, id uuid.UUID, limit int) ([]model.ReportRun, error) {
+	if _, err := s.repository.GetByID(ctx, id); err != nil {
+		return nil, fmt.Errorf("list report runs: %w", err)
+	}
+
+	runs, err := s.repository.ListRuns(ctx, id, limit)
+	if err != nil {
+		return nil, fmt.Errorf("list report runs: %w", err)
+	}
+
+	return runs, nil
+}
+
+// prepare validates the definition of sr, normalizes its report and sets its
+// schedule to the first occurrence that is not in the past.
+func (s *Service) prepare(sr *model.ScheduledReport) error {
+	switch sr.Range {
+	case model.GroupDay, model.GroupWeek, model.GroupMonth, model.GroupQuarter, model.GroupYear:
+	default:
+		return ErrInvalidRange
+	}
+
+	schedule, err := srvcrecurring.ParseRule(sr.Rule)
+	if err != nil {
+		return err
+	}
+
+	// The date range is set on every run; the report is validated over the
+	// range of a run now, which e.g. bounds the number of periods.
+	now := s.now()
+	period := report.Previous(now, sr.Range)
+	sr.Spec.From, sr.Spec.To = period.From, period.To
+	err = report.Validate(&sr.Spec)
+	sr.Spec.From, sr.Spec.To = nil, nil
+	if err != nil {
+		return err
+	}
+
+	sr.NextIndex, sr.NextRunAt = schedule.FirstFrom(sr.StartAt, nil, now)
+
+	return nil
+}
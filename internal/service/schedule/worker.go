@@ -0,0 +1,222 @@
+package schedule
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/aliskhannn/sales-tracker/internal/logging"
+	"github.com/aliskhannn/sales-tracker/internal/mail"
+	"github.com/aliskhannn/sales-tracker/internal/model"
+	"github.com/aliskhannn/sales-tracker/internal/report"
+	srvcrecurring "github.com/aliskhannn/sales-tracker/internal/service/recurring"
+	"github.com/aliskhannn/sales-tracker/internal/tenant"
+)
+
+// Messages stored for failed runs. The cause of a failed computation is
+// logged rather than exposed; delivery errors are stored, since they are
+// usually caused by the SMTP configuration or a recipient.
+const (
+	msgFailed      = "the report could not be computed"
+	msgAbandoned   = "the run was abandoned by its scheduler"
+	msgInterrupted = "the run was interrupted by a shutdown and is retried on the next start"
+)
+
+// errTimeout is the cause of stopping a run that exceeded the timeout.
+var errTimeout = errors.New("scheduled report timed out")
+
+// Run sends due reports of every workspace immediately and then on every poll
+// until ctx is cancelled. Errors are logged and the report retried later.
+func (s *Service) Run(ctx context.Context) {
+	ticker := time.NewTicker(s.opts.PollInterval)
+	defer ticker.Stop()
+
+	for {
+		for ctx.Err() == nil {
+			sr, err := s.repository.ClaimDue(ctx, s.opts.Lease)
+			if err != nil {
+				if ctx.Err() == nil {
+					logging.Ctx(ctx).Error().Err(err).Msg("failed to claim scheduled report")
+				}
+
+				break
+			}
+
+			if sr == nil {
+				break
+			}
+
+			s.execute(ctx, sr)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// execute computes and sends a claimed report for its latest due occurrence,
+// records the run and moves its schedule on. Occurrences missed while no
+// scheduler ran are not caught up, since their reports would be sent at once.
+func (s *Service) execute(ctx context.Context, sr *model.ScheduledReport) {
+	ctx = logging.With(tenant.WithWorkspace(ctx, sr.WorkspaceID), "report", sr.ID.String())
+	// Outcomes are recorded even if ctx is cancelled by the shutdown.
+	rctx := context.WithoutCancel(ctx)
+
+	schedule, err := srvcrecurring.ParseRule(sr.Rule)
+	if err != nil {
+		// Rules are validated when saved, so this is not retried.
+		logging.Ctx(ctx).Error().Err(err).Msg("invalid rule of scheduled report")
+		s.advance(rctx, sr, sr.NextIndex, nil)
+		return
+	}
+
+	now := s.now()
+	index, occurrence, attempt := sr.NextIndex, *sr.NextRunAt, sr.Attempts+1
+	for {
+		at, ok := schedule.At(sr.StartAt, index+1, nil)
+		if !ok || at.After(now) {
+			break
+		}
+
+		index, occurrence, attempt = index+1, at, 1
+	}
+
+	var nextRunAt *time.Time
+	if next, ok := schedule.At(sr.StartAt, index+1, nil); ok {
+		nextRunAt = &next
+	}
+
+	period := report.Previous(occurrence, sr.Range)
+	run := &model.ReportRun{
+		ReportID:     sr.ID,
+		OccurrenceAt: occurrence,
+		PeriodFrom:   *period.From,
+		PeriodTo:     *period.To,
+		Attempt:      attempt,
+		Recipients:   sr.Recipients,
+	}
+	if err = s.repository.StartRun(rctx, run, msgAbandoned); err != nil {
+		logging.Ctx(ctx).Error().Err(err).Msg("failed to record report run")
+		if err = s.repository.Release(rctx, sr.ID); err != nil {
+			logging.Ctx(ctx).Error().Err(err).Msg("failed to release scheduled report")
+		}
+		return
+	}
+	ctx = logging.With(ctx, "run", run.ID.String())
+
+	runCtx, cancel := context.WithTimeoutCause(ctx, s.opts.Timeout, errTimeout)
+	msg, err := s.send(runCtx, sr, period, now)
+	cause := context.Cause(runCtx)
+	cancel()
+
+	switch {
+	case err == nil:
+		s.finish(rctx, run.ID, model.RunSucceeded, nil)
+		s.advance(rctx, sr, index+1, nextRunAt)
+		logging.Ctx(ctx).Info().Time("occurrence", occurrence).Dur("duration", s.now().Sub(now)).Msg("scheduled report sent")
+	case ctx.Err() != nil:
+		msg = msgInterrupted
+		s.finish(rctx, run.ID, model.RunFailed, &msg)
+		if err = s.repository.Release(rctx, sr.ID); err != nil {
+			logging.Ctx(ctx).Error().Err(err).Msg("failed to release scheduled report")
+		}
+		logging.Ctx(ctx).Info().Msg("scheduled report released on shutdown")
+	default:
+		if errors.Is(cause, errTimeout) {
+			msg = fmt.Sprintf("the report was not sent within the timeout of %s", s.opts.Timeout)
+		}
+
+		logging.Ctx(ctx).Error().Err(err).Int("attempt", attempt).Msg("scheduled report failed")
+		s.finish(rctx, run.ID, model.RunFailed, &msg)
+
+		if attempt < s.opts.MaxAttempts {
+			err = s.repository.Retry(rctx, sr.ID, sr.UpdatedAt, index, occurrence, attempt, s.now().Add(s.opts.RetryDelay))
+			if err != nil {
+				logging.Ctx(ctx).Error().Err(err).Msg("failed to reschedule scheduled report")
+			}
+			return
+		}
+
+		logging.Ctx(ctx).Warn().Time("occurrence", occurrence).Msg("scheduled report given up")
+		s.advance(rctx, sr, index+1, nextRunAt)
+	}
+}
+
+// send computes the report of sr over period and emails it to its recipients.
+// On failure it returns the message to record for the run.
+func (s *Service) send(ctx context.Context, sr *model.ScheduledReport, period report.Period, generated time.Time) (string, error) {
+	spec := sr.Spec
+	spec.From, spec.To = period.From, period.To
+
+	result, err := report.Run(ctx, s.analytics, spec, nil)
+	if err != nil {
+		return msgFailed, fmt.Errorf("compute report: %w", err)
+	}
+
+	doc := report.Document{Title: sr.Name, Range: period, Spec: spec, Result: result, Generated: generated}
+	if slices.Contains(spec.Metrics, model.MetricCategories) {
+		categories, err := s.categories.List(ctx)
+		if err != nil {
+			return msgFailed, fmt.Errorf("list categories: %w", err)
+		}
+
+		doc.Categories = make(map[uuid.UUID]string, len(categories))
+		for _, c := range categories {
+			doc.Categories[c.ID] = c.Name
+		}
+	}
+
+	html, err := report.RenderHTML(doc)
+	if err != nil {
+		return msgFailed, err
+	}
+
+	csv, err := report.RenderCSV(doc)
+	if err != nil {
+		return msgFailed, err
+	}
+
+	from, to := period.From.Format(time.DateOnly), period.To.Format(time.DateOnly)
+	name := fmt.Sprintf("report_%s_%s", from, to)
+	m := &mail.Message{
+		From:    s.opts.From,
+		To:      sr.Recipients,
+		Subject: fmt.Sprintf("%s: %s – %s", sr.Name, from, to),
+		Text:    fmt.Sprintf("%s for %s – %s is attached as HTML and CSV.\r\n", sr.Name, from, to),
+		HTML:    string(html),
+		Attachments: []mail.Attachment{
+			{Filename: name + ".html", ContentType: "text/html; charset=utf-8", Data: html},
+			{Filename: name + ".csv", ContentType: "text/csv; charset=utf-8", Data: csv},
+		},
+	}
+
+	if err = s.mailer.Send(ctx, m); err != nil {
+		return fmt.Sprintf("the report could not be sent: %v", err), fmt.Errorf("send report: %w", err)
+	}
+
+	return "", nil
+}
+
+// finish records the outcome of a run.
+func (s *Service) finish(ctx context.Context, id uuid.UUID, status string, msg *string) {
+	if err := s.repository.FinishRun(ctx, id, status, msg); err != nil {
+		logging.Ctx(ctx).Error().Err(err).Msg("failed to record report run")
+		return
+	}
+
+	reportRuns.WithLabelValues(status).Inc()
+}
+
+// advance moves the schedule of a claimed report to the given occurrence.
+func (s *Service) advance(ctx context.Context, sr *model.ScheduledReport, nextIndex int, nextRunAt *time.Time) {
+	if err := s.repository.Advance(ctx, sr.ID, sr.UpdatedAt, nextIndex, nextRunAt); err != nil {
+		logging.Ctx(ctx).Error().Err(err).Msg("failed to advance scheduled report")
+	}
+}
@@ -0,0 +1,278 @@
+package schedule
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/aliskhannn/sales-tracker/internal/mail"
+	"github.com/aliskhannn/sales-tracker/internal/model"
+	"github.com/aliskhannn/sales-tracker/internal/report"
+)
+
+// advance is a call of Advance or Retry recorded by fakeRepository.
+type advance struct {
+	retry     bool
+	index     int
+	nextRunAt *time.Time // occurrence of a retry
+	attempt   int
+	retryAt   time.Time
+}
+
+// fakeRepository records the runs and schedule changes of a claimed report.
+type fakeRepository struct {
+	repository
+
+	runs     []*model.ReportRun
+	outcomes []string
+	advances []advance
+	released bool
+}
+
+func (f *fakeRepository) StartRun(_ context.Context, run *model.ReportRun, _ string) error {
+	run.ID = uuid.New()
+	f.runs = append(f.runs, run)
+	return nil
+}
+
+func (f *fakeRepository) FinishRun(_ context.Context, _ uuid.UUID, status string, _ *string) error {
+	f.outcomes = append(f.outcomes, status)
+	return nil
+}
+
+func (f *fakeRepository) Advance(_ context.Context, _ uuid.UUID, _ time.Time, nextIndex int, nextRunAt *time.Time) error {
+	f.advances = append(f.advances, advance{index: nextIndex, nextRunAt: nextRunAt})
+	return nil
+}
+
+func (f *fakeRepository) Retry(_ context.Context, _ uuid.UUID, _ time.Time, index int, occurrence time.Time, attempts int, retryAt time.Time) error {
+	f.advances = append(f.advances, advance{retry: true, index: index, nextRunAt: &occurrence, attempt: attempts, retryAt: retryAt})
+	return nil
+}
+
+func (f *fakeRepository) Release(context.Context, uuid.UUID) error {
+	f.released = true
+	return nil
+}
+
+// countAnalytics counts one item in every period.
+type countAnalytics struct {
+	report.Analytics
+}
+
+func (countAnalytics) Count(context.Context, *time.Time, *time.Time, *uuid.UUID, *string, *uuid.UUID, *model.TagFilter) (int64, error) {
+	return 1, nil
+}
+
+// fakeMailer records sent messages, or fails with err.
+type fakeMailer struct {
+	err  error
+	sent []*mail.Message
+}
+
+func (m *fakeMailer) Send(_ context.Context, msg *mail.Message) error {
+	if m.err != nil {
+		return m.err
+	}
+
+	m.sent = append(m.sent, msg)
+	return nil
+}
+
+func TestExecute(t *testing.T) {
+	// Weekly reports on Monday mornings, covering the previous week.
+	start := time.Date(2025, 10, 6, 8, 0, 0, 0, time.UTC)
+	week := 7 * 24 * time.Hour
+	at := func(n int) *time.Time {
+		t := start.Add(time.Duration(n) * week)
+		return &t
+	}
+	errSMTP := errors.New("550 mailbox unavailable")
+
+	tests := []struct {
+		name       string
+		rule       string
+		nextIndex  int
+		attempts   int // failed attempts at the next occurrence
+		now        time.Time
+		sendErr    error
+		wantRun    *model.ReportRun // nil if no run is started
+		wantSent   bool
+		wantChange advance
+	}{
+		{
+			name: "on time",
+			rule: "FREQ=WEEKLY",
+			now:  start.Add(time.Minute),
+			wantRun: &model.ReportRun{
+				OccurrenceAt: start, PeriodFrom: time.Date(2025, 9, 29, 0, 0, 0, 0, time.UTC), Attempt: 1,
+			},
+			wantSent:   true,
+			wantChange: advance{index: 1, nextRunAt: at(1)},
+		},
+		{
+			name:     "retry of the due occurrence",
+			rule:     "FREQ=WEEKLY",
+			attempts: 1,
+			now:      start.Add(time.Hour),
+			wantRun: &model.ReportRun{
+				OccurrenceAt: start, PeriodFrom: time.Date(2025, 9, 29, 0, 0, 0, 0, time.UTC), Attempt: 2,
+			},
+			wantSent:   true,
+			wantChange: advance{index: 1, nextRunAt: at(1)},
+		},
+		{
+			// Three occurrences were missed; only the latest is sent, as its
+			// first attempt.
+			name:     "catches up to the latest occurrence",
+			rule:     "FREQ=WEEKLY",
+			attempts: 2,
+			now:      start.Add(3*week + time.Hour),
+			wantRun: &model.ReportRun{
+				OccurrenceAt: *at(3), PeriodFrom: time.Date(2025, 10, 20, 0, 0, 0, 0, time.UTC), Attempt: 1,
+			},
+			wantSent:   true,
+			wantChange: advance{index: 4, nextRunAt: at(4)},
+		},
+		{
+			name:      "catch-up stops at the end of the schedule",
+			rule:      "FREQ=WEEKLY;COUNT=3",
+			nextIndex: 1,
+			now:       start.Add(10 * week),
+			wantRun: &model.ReportRun{
+				OccurrenceAt: *at(2), PeriodFrom: time.Date(2025, 10, 13, 0, 0, 0, 0, time.UTC), Attempt: 1,
+			},
+			wantSent:   true,
+			wantChange: advance{index: 3},
+		},
+		{
+			name:    "failed send is retried",
+			rule:    "FREQ=WEEKLY",
+			now:     start.Add(3*week + time.Hour),
+			sendErr: errSMTP,
+			wantRun: &model.ReportRun{
+				OccurrenceAt: *at(3), PeriodFrom: time.Date(2025, 10, 20, 0, 0, 0, 0, time.UTC), Attempt: 1,
+			},
+			wantChange: advance{retry: true, index: 3, nextRunAt: at(3), attempt: 1, retryAt: start.Add(3*week + time.Hour + time.Minute)},
+		},
+		{
+			name:     "failed send is given up after the last attempt",
+			rule:     "FREQ=WEEKLY",
+			attempts: 2,
+			now:      start.Add(time.Hour),
+			sendErr:  errSMTP,
+			wantRun: &model.ReportRun{
+				OccurrenceAt: start, PeriodFrom: time.Date(2025, 9, 29, 0, 0, 0, 0, time.UTC), Attempt: 3,
+			},
+			wantChange: advance{index: 1, nextRunAt: at(1)},
+		},
+		{
+			name:       "invalid rule",
+			rule:       "FREQ=HOURLY",
+			now:        start.Add(time.Minute),
+			wantChange: advance{index: 0},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := &fakeRepository{}
+			mailer := &fakeMailer{err: tt.sendErr}
+			s := NewService(r, countAnalytics{}, nil, mailer, Options{
+				MaxAttempts: 3,
+				RetryDelay:  time.Minute,
+				From:        "reports@example.com",
+			})
+			s.now = func() time.Time { return tt.now }
+
+			sr := &model.ScheduledReport{
+				ID:          uuid.New(),
+				WorkspaceID: uuid.New(),
+				Name:        "Weekly count",
+				Spec:        model.ReportSpec{Metrics: []string{model.MetricCount}},
+				Range:       model.GroupWeek,
+				Rule:        tt.rule,
+				StartAt:     start,
+				Recipients:  []string{"ops@example.com"},
+				NextIndex:   tt.nextIndex,
+				NextRunAt:   at(tt.nextIndex),
+				Attempts:    tt.attempts,
+			}
+
+			s.execute(context.Background(), sr)
+
+			switch {
+			case tt.wantRun == nil && len(r.runs) != 0:
+				t.Errorf("started %d runs, want none", len(r.runs))
+			case tt.wantRun != nil && len(r.runs) != 1:
+				t.Fatalf("started %d runs, want 1", len(r.runs))
+			case tt.wantRun != nil:
+				run := r.runs[0]
+				wantTo := tt.wantRun.PeriodFrom.Add(week - time.Microsecond)
+				if !run.OccurrenceAt.Equal(tt.wantRun.OccurrenceAt) || !run.PeriodFrom.Equal(tt.wantRun.PeriodFrom) ||
+					!run.PeriodTo.Equal(wantTo) || run.Attempt != tt.wantRun.Attempt {
+					t.Errorf("run = occurrence %s, period %s – %s, attempt %d, want occurrence %s, period %s – %s, attempt %d",
+						run.OccurrenceAt, run.PeriodFrom, run.PeriodTo, run.Attempt,
+						tt.wantRun.OccurrenceAt, tt.wantRun.PeriodFrom, wantTo, tt.wantRun.Attempt)
+				}
+
+				wantStatus := model.RunFailed
+				if tt.wantSent {
+					wantStatus = model.RunSucceeded
+				}
+				if len(r.outcomes) != 1 || r.outcomes[0] != wantStatus {
+					t.Errorf("run outcomes = %v, want [%s]", r.outcomes, wantStatus)
+				}
+			}
+
+			wantMails := 0
+			if tt.wantSent {
+				wantMails = 1
+			}
+			if len(mailer.sent) != wantMails {
+				t.Errorf("sent %d emails, want %d", len(mailer.sent), wantMails)
+			}
+			if tt.wantSent && !strings.HasPrefix(mailer.sent[0].Subject, "Weekly count: "+tt.wantRun.PeriodFrom.Format(time.DateOnly)) {
+				t.Errorf("subject = %q, want the name and period", mailer.sent[0].Subject)
+			}
+
+			if len(r.advances) != 1 {
+				t.Fatalf("schedule changed %d times, want once", len(r.advances))
+			}
+			if got := r.advances[0]; !sameAdvance(got, tt.wantChange) {
+				t.Errorf("schedule change = %s, want %s", got, tt.wantChange)
+			}
+			if r.released {
+				t.Error("report released, want it advanced or retried")
+			}
+		})
+	}
+}
+
+// sameAdvance reports whether a and b record the same schedule change.
+func sameAdvance(a, b advance) bool {
+	if (a.nextRunAt == nil) != (b.nextRunAt == nil) || a.nextRunAt != nil && !a.nextRunAt.Equal(*b.nextRunAt) {
+		return false
+	}
+
+	return a.retry == b.retry && a.index == b.index && a.attempt == b.attempt && a.retryAt.Equal(b.retryAt)
+}
+
+// String formats a for test failures.
+func (a advance) String() string {
+	next := "none"
+	if a.nextRunAt != nil {
+		next = a.nextRunAt.Format(time.RFC3339)
+	}
+
+	if a.retry {
+		return fmt.Sprintf("retry index %d at %s (occurrence %s, attempt %d)", a.index, a.retryAt.Format(time.RFC3339), next, a.attempt)
+	}
+
+	return fmt.Sprintf("advance to index %d at %s", a.index, next)
+}
@@ -0,0 +1,108 @@
+package stream
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+
+	"github.com/aliskhannn/sales-tracker/internal/logging"
+)
+
+// channel is the notification channel the item change trigger notifies on.
+const channel = "item_changes"
+
+const (
+	defaultRetention     = 24 * time.Hour
+	pruneInterval        = time.Hour
+	pingInterval         = 90 * time.Second
+	minReconnectInterval = time.Second
+	maxReconnectInterval = time.Minute
+)
+
+// notification is the payload of an item change notification.
+type notification struct {
+	ID          int64     `json:"id"`
+	WorkspaceID uuid.UUID `json:"workspace_id"`
+}
+
+// Run listens for item change notifications and wakes up the subscriptions of
+// the changed workspace until ctx is cancelled, pruning changes older than the
+// retention period meanwhile. When Run returns all subscriptions are closed,
+// which ends the open streams.
+func (s *Service) Run(ctx context.Context) {
+	defer s.closeAll()
+
+	l := pq.NewListener(s.dsn, minReconnectInterval, maxReconnectInterval, func(ev pq.ListenerEventType, err error) {
+		switch ev {
+		case pq.ListenerEventDisconnected:
+			logging.Ctx(ctx).Warn().Err(err).Msg("item change listener disconnected")
+		case pq.ListenerEventReconnected:
+			logging.Ctx(ctx).Info().Msg("item change listener reconnected")
+		case pq.ListenerEventConnectionAttemptFailed:
+			logging.Ctx(ctx).Error().Err(err).Msg("item change listener failed to connect")
+		}
+	})
+	defer func() { _ = l.Close() }()
+
+	// Listen blocks until the database is reachable; closing the listener on
+	// shutdown unblocks it.
+	go func() {
+		if err := l.Listen(channel); err != nil && ctx.Err() == nil {
+			logging.Ctx(ctx).Error().Err(err).Msg("failed to listen for item changes")
+		}
+	}()
+
+	ping := time.NewTicker(pingInterval)
+	defer ping.Stop()
+
+	prune := time.NewTicker(pruneInterval)
+	defer prune.Stop()
+
+	s.prune(ctx)
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case n := <-l.Notify:
+			// A nil notification follows a reconnect, after which
+			// notifications may have been missed.
+			if n == nil {
+				s.wake(uuid.Nil)
+				continue
+			}
+
+			var p notification
+			if err := json.Unmarshal([]byte(n.Extra), &p); err != nil {
+				logging.Ctx(ctx).Error().Err(err).Str("payload", n.Extra).Msg("invalid item change notification")
+				s.wake(uuid.Nil)
+				continue
+			}
+
+			s.wake(p.WorkspaceID)
+		case <-ping.C:
+			go func() { _ = l.Ping() }()
+		case <-prune.C:
+			s.prune(ctx)
+		}
+	}
+}
+
+// prune removes changes older than the retention period.
+func (s *Service) prune(ctx context.Context) {
+	n, err := s.repository.PruneItemChanges(ctx, time.Now().Add(-s.retention))
+	if err != nil {
+		if ctx.Err() == nil {
+			logging.Ctx(ctx).Error().Err(err).Msg("failed to prune item changes")
+		}
+
+		return
+	}
+
+	if n > 0 {
+		logging.Ctx(ctx).Info().Int64("removed", n).Msg("pruned item changes")
+	}
+}
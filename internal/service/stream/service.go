@@ -0,0 +1,238 @@
+package stream
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/aliskhannn/sales-tracker/internal/model"
+	"github.com/aliskhannn/sales-tracker/internal/tenant"
+)
+
+var (
+	ErrClosed = errors.New("streams are shutting down")
+)
+
+// changeBatchSize is the max number of changes read from the log at once.
+const changeBatchSize = 500
+
+// repository provides methods to read the item change log.
+type repository interface {
+	// ItemChanges retrieves stable changes after the cursor, in transaction order.
+	ItemChanges(ctx context.Context, after model.ChangeCursor, limit int) ([]model.ItemChange, error)
+
+	// HasPendingItemChanges reports whether committed changes after the cursor are not stable yet.
+	HasPendingItemChanges(ctx context.Context, after model.ChangeCursor) (bool, error)
+
+	// LatestItemChange returns the position of the latest stable change, or the zero cursor if there is none.
+	LatestItemChange(ctx context.Context) (model.ChangeCursor, error)
+
+	// PruneItemChanges removes changes of all workspaces made before the given time.
+	PruneItemChanges(ctx context.Context, before time.Time) (int64, error)
+}
+
+// analytics calculates aggregates of items.
+type analytics interface {
+	// Totals calculates the sum, average and count of items matching the filter.
+	Totals(ctx context.Context, filter *model.ItemFilter) (*model.Totals, error)
+}
+
+// Service streams item changes and running totals. Run listens for change
+// notifications and wakes up the subscriptions of the changed workspace.
+type Service struct {
+	repository repository
+	analytics  analytics
+	dsn        string
+	retention  time.Duration
+
+	mu     sync.Mutex
+	subs   map[uuid.UUID]map[*Subscription]struct{}
+	closed bool
+}
+
+// NewService creates a new stream service listening for notifications on the
+// database at dsn. Changes older than retention are pruned and can no longer
+// be replayed.
+func NewService(r repository, a analytics, dsn string, retention time.Duration) *Service {
+	if retention <= 0 {
+		retention = defaultRetention
+	}
+
+	return &Service{
+		repository: r,
+		analytics:  a,
+		dsn:        dsn,
+		retention:  retention,
+		subs:       make(map[uuid.UUID]map[*Subscription]struct{}),
+	}
+}
+
+// Subscription wakes up a stream when items of its workspace may have changed.
+type Subscription struct {
+	// C receives a value when new changes may be available. Wake-ups are
+	// coalesced, so a receiver must read all changes since its last one. C is
+	// closed when the service shuts down.
+	C <-chan struct{}
+
+	c         chan struct{}
+	workspace uuid.UUID
+	service   *Service
+}
+
+// Subscribe subscribes to changes of the workspace of ctx. The subscription
+// must be closed when no longer needed.
+func (s *Service) Subscribe(ctx context.Context) (*Subscription, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.closed {
+		return nil, ErrClosed
+	}
+
+	c := make(chan struct{}, 1)
+	sub := &Subscription{C: c, c: c, workspace: tenant.ID(ctx), service: s}
+
+	if s.subs[sub.workspace] == nil {
+		s.subs[sub.workspace] = make(map[*Subscription]struct{})
+	}
+	s.subs[sub.workspace][sub] = struct{}{}
+
+	return sub, nil
+}
+
+// Close unsubscribes. It is safe to call after the service has shut down.
+func (sub *Subscription) Close() {
+	s := sub.service
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.subs[sub.workspace][sub]; !ok {
+		return
+	}
+
+	delete(s.subs[sub.workspace], sub)
+	if len(s.subs[sub.workspace]) == 0 {
+		delete(s.subs, sub.workspace)
+	}
+}
+
+// Latest returns the position of the latest stable change to items of the
+// workspace, the position a stream without Last-Event-ID starts from.
+func (s *Service) Latest(ctx context.Context) (model.ChangeCursor, error) {
+	c, err := s.repository.LatestItemChange(ctx)
+	if err != nil {
+		return model.ChangeCursor{}, fmt.Errorf("latest item change: %w", err)
+	}
+
+	return c, nil
+}
+
+// ItemChanges returns the next changes after the cursor whose item matches
+// the filter, and the cursor to continue from, which equals after once the
+// stream has caught up. Only stable changes are returned: a change is held
+// back while a transaction older than the one that made it is running, since
+// that transaction may still commit changes that come before it. Items match
+// by their own category; splits are not considered.
+func (s *Service) ItemChanges(ctx context.Context, after model.ChangeCursor, filter *model.ItemFilter) ([]model.ItemChange, model.ChangeCursor, error) {
+	changes, err := s.repository.ItemChanges(ctx, after, changeBatchSize)
+	if err != nil {
+		return nil, after, fmt.Errorf("item changes: %w", err)
+	}
+
+	matching := changes[:0]
+	for _, c := range changes {
+		after = c.Cursor()
+		if matches(filter, &c.Item) {
+			matching = append(matching, c)
+		}
+	}
+
+	return matching, after, nil
+}
+
+// Pending reports whether changes after the cursor are committed but held
+// back by ItemChanges. No notification announces when they become stable,
+// so streams poll for them.
+func (s *Service) Pending(ctx context.Context, after model.ChangeCursor) (bool, error) {
+	pending, err := s.repository.HasPendingItemChanges(ctx, after)
+	if err != nil {
+		return false, fmt.Errorf("pending item changes: %w", err)
+	}
+
+	return pending, nil
+}
+
+// Totals returns the running totals of items matching the filter.
+func (s *Service) Totals(ctx context.Context, filter *model.ItemFilter) (*model.Totals, error) {
+	t, err := s.analytics.Totals(ctx, filter)
+	if err != nil {
+		return nil, fmt.Errorf("stream totals: %w", err)
+	}
+
+	return t, nil
+}
+
+// wake wakes up the subscriptions of a workspace, or of all workspaces if
+// workspace is uuid.Nil.
+func (s *Service) wake(workspace uuid.UUID) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for w, subs := range s.subs {
+		if workspace != uuid.Nil && w != workspace {
+			continue
+		}
+
+		for sub := range subs {
+			select {
+			case sub.c <- struct{}{}:
+			default:
+			}
+		}
+	}
+}
+
+// closeAll closes all subscriptions and rejects new ones.
+func (s *Service) closeAll() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.closed = true
+	for _, subs := range s.subs {
+		for sub := range subs {
+			close(sub.c)
+		}
+	}
+	s.subs = make(map[uuid.UUID]map[*Subscription]struct{})
+}
+
+// matches reports whether an item matches the date range, category, kind and
+// account of the filter. A nil filter matches every item.
+func matches(f *model.ItemFilter, item *model.Item) bool {
+	if f == nil {
+		return true
+	}
+
+	if f.From != nil && item.OccurredAt.Before(*f.From) {
+		return false
+	}
+	if f.To != nil && item.OccurredAt.After(*f.To) {
+		return false
+	}
+	if f.CategoryID != nil && (item.CategoryID == nil || *item.CategoryID != *f.CategoryID) {
+		return false
+	}
+	if f.Kind != nil && item.Kind != *f.Kind {
+		return false
+	}
+	if f.AccountID != nil && (item.AccountID == nil || *item.AccountID != *f.AccountID) {
+		return false
+	}
+
+	return true
+}
@@ -0,0 +1,176 @@
+package tag
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/google/uuid"
+
+	"github.com/aliskhannn/sales-tracker/internal/model"
+)
+
+var (
+	ErrInvalidTagName = errors.New("tag name must not be empty or contain commas")
+)
+
+// repository provides methods to interact with tags.
+type repository interface {
+	// Create adds a new tag to the database.
+	Create(ctx context.Context, t *model.Tag) (uuid.UUID, error)
+
+	// GetByID retrieves a tag by its ID.
+	GetByID(ctx context.Context, id uuid.UUID) (*model.Tag, error)
+
+	// List retrieves all tags ordered by name.
+	List(ctx context.Context) ([]model.Tag, error)
+
+	// ListByItem retrieves the tags attached to an item.
+	ListByItem(ctx context.Context, itemID uuid.UUID) ([]model.Tag, error)
+
+	// Update updates a tag.
+	Update(ctx context.Context, t *model.Tag) error
+
+	// Delete removes a tag from the database.
+	Delete(ctx context.Context, id uuid.UUID) error
+
+	// Attach attaches tags to an item.
+	Attach(ctx context.Context, itemID uuid.UUID, tagIDs []uuid.UUID) error
+
+	// Detach removes a tag from an item.
+	Detach(ctx context.Context, itemID, tagID uuid.UUID) error
+}
+
+// itemRepository provides read access to items.
+type itemRepository interface {
+	// GetByID retrieves an item by its ID.
+	GetByID(ctx context.Context, id uuid.UUID) (*model.Item, error)
+}
+
+// Service provides tag-related business logic.
+type Service struct {
+	repository repository
+	items      itemRepository
+}
+
+// NewService creates a new tag service.
+func NewService(r repository, items itemRepository) *Service {
+	return &Service{repository: r, items: items}
+}
+
+// Create adds a new tag.
+func (s *Service) Create(ctx context.Context, name string, description *string) (uuid.UUID, error) {
+	name, err := normalizeName(name)
+	if err != nil {
+		return uuid.Nil, err
+	}
+
+	t := &model.Tag{
+		Name:        name,
+		Description: description,
+	}
+
+	id, err := s.repository.Create(ctx, t)
+	if err != nil {
+		return uuid.Nil, fmt.Errorf("create tag: %w", err)
+	}
+
+	return id, nil
+}
+
+// GetByID returns a tag by its ID.
+func (s *Service) GetByID(ctx context.Context, id uuid.UUID) (*model.Tag, error) {
+	t, err := s.repository.GetByID(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("get tag: %w", err)
+	}
+
+	return t, nil
+}
+
+// List returns all tags.
+func (s *Service) List(ctx context.Context) ([]model.Tag, error) {
+	tags, err := s.repository.List(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("list tags: %w", err)
+	}
+
+	return tags, nil
+}
+
+// Update modifies an existing tag identified by id.
+func (s *Service) Update(ctx context.Context, id uuid.UUID, name string, description *string) error {
+	name, err := normalizeName(name)
+	if err != nil {
+		return err
+	}
+
+	t := &model.Tag{
+		ID:          id,
+		Name:        name,
+		Description: description,
+	}
+
+	if err = s.repository.Update(ctx, t); err != nil {
+		return fmt.Errorf("update tag: %w", err)
+	}
+
+	return nil
+}
+
+// Delete removes a tag by its ID and detaches it from all items.
+func (s *Service) Delete(ctx context.Context, id uuid.UUID) error {
+	if err := s.repository.Delete(ctx, id); err != nil {
+		return fmt.Errorf("delete tag: %w", err)
+	}
+
+	return nil
+}
+
+// ListByItem returns the tags attached to an item.
+func (s *Service) ListByItem(ctx context.Context, itemID uuid.UUID) ([]model.Tag, error) {
+	if _, err := s.items.GetByID(ctx, itemID); err != nil {
+		return nil, fmt.Errorf("list item tags: %w", err)
+	}
+
+	tags, err := s.repository.ListByItem(ctx, itemID)
+	if err != nil {
+		return nil, fmt.Errorf("list item tags: %w", err)
+	}
+
+	return tags, nil
+}
+
+// Attach attaches tags to an item. Already attached tags are ignored.
+func (s *Service) Attach(ctx context.Context, itemID uuid.UUID, tagIDs []uuid.UUID) error {
+	if _, err := s.items.GetByID(ctx, itemID); err != nil {
+		return fmt.Errorf("attach tags: %w", err)
+	}
+
+	if err := s.repository.Attach(ctx, itemID, tagIDs); err != nil {
+		return fmt.Errorf("attach tags: %w", err)
+	}
+
+	return nil
+}
+
+// Detach removes a tag from an item.
+func (s *Service) Detach(ctx context.Context, itemID, tagID uuid.UUID) error {
+	if err := s.repository.Detach(ctx, itemID, tagID); err != nil {
+		return fmt.Errorf("detach tag: %w", err)
+	}
+
+	return nil
+}
+
+// normalizeName trims a tag name and checks that it can be used in
+// comma-separated tag filters.
+func normalizeName(name string) (string, error) {
+	name = strings.TrimSpace(name)
+	if name == "" || strings.Contains(name, ",") {
+		return "", ErrInvalidTagName
+	}
+
+	return name, nil
+}
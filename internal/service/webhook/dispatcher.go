@@ -0,0 +1,226 @@
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+
+	"github.com/aliskhannn/sales-tracker/internal/logging"
+	"github.com/aliskhannn/sales-tracker/internal/metrics"
+	"github.com/aliskhannn/sales-tracker/internal/model"
+)
+
+// Defaults used when an option is not configured.
+const (
+	defaultPollInterval = 2 * time.Second
+	defaultBatchSize    = 100
+	defaultTimeout      = 10 * time.Second
+	defaultMaxAttempts  = 8
+	defaultBackoffBase  = 30 * time.Second
+	defaultBackoffMax   = 6 * time.Hour
+)
+
+// leaseMargin is added to the request timeout when claiming deliveries so that
+// a claim never expires while its request is still in flight.
+const leaseMargin = 30 * time.Second
+
+// maxErrorLength bounds the error message stored for a failed attempt.
+const maxErrorLength = 512
+
+// deliveryAttempts counts delivery attempts by outcome: succeeded, retrying
+// or failed once all attempts are used up.
+var deliveryAttempts = promauto.NewCounterVec(prometheus.CounterOpts{
+	Namespace: metrics.Namespace,
+	Name:      "webhook_delivery_attempts_total",
+	Help:      "Number of webhook delivery attempts by outcome.",
+}, []string{"outcome"})
+
+// Headers sent with every delivery.
+const (
+	HeaderEventID    = "X-Webhook-ID"
+	HeaderDeliveryID = "X-Webhook-Delivery"
+	HeaderEvent      = "X-Webhook-Event"
+	HeaderTimestamp  = "X-Webhook-Timestamp"
+	HeaderSignature  = "X-Webhook-Signature"
+)
+
+// Run dispatches outbox events to webhooks immediately and then on every poll
+// interval until ctx is cancelled. Errors are logged and retried on the next
+// tick. Deliveries in flight when ctx is cancelled are allowed to finish.
+func (s *Service) Run(ctx context.Context) {
+	ticker := time.NewTicker(s.opts.PollInterval)
+	defer ticker.Stop()
+
+	for {
+		s.dispatch(ctx)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// dispatch fans out all pending outbox events and sends the due deliveries.
+func (s *Service) dispatch(ctx context.Context) {
+	for ctx.Err() == nil {
+		n, err := s.repository.FanOut(ctx, s.opts.BatchSize)
+		if err != nil {
+			if ctx.Err() == nil {
+				logging.Ctx(ctx).Error().Err(err).Msg("failed to fan out outbox events")
+			}
+
+			break
+		}
+
+		if n < s.opts.BatchSize {
+			break
+		}
+	}
+
+	for ctx.Err() == nil {
+		deliveries, err := s.repository.ClaimDue(ctx, s.opts.BatchSize, s.opts.Timeout+leaseMargin)
+		if err != nil {
+			if ctx.Err() == nil {
+				logging.Ctx(ctx).Error().Err(err).Msg("failed to claim webhook deliveries")
+			}
+
+			return
+		}
+
+		var wg sync.WaitGroup
+		for _, d := range deliveries {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				s.deliver(ctx, d)
+			}()
+		}
+		wg.Wait()
+
+		if len(deliveries) < s.opts.BatchSize {
+			return
+		}
+	}
+}
+
+// deliver sends a single delivery and records its outcome.
+func (s *Service) deliver(ctx context.Context, d model.PendingDelivery) {
+	// The attempt outlives ctx so that shutdown does not turn in-flight
+	// requests into failures; the lease bounds how long it may take.
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), s.opts.Timeout)
+	defer cancel()
+
+	statusCode, err := s.send(ctx, d)
+	if err == nil {
+		deliveryAttempts.WithLabelValues(model.DeliverySucceeded).Inc()
+		if err = s.repository.Succeed(ctx, d.ID, statusCode); err != nil {
+			logging.Ctx(ctx).Error().Err(err).Str("delivery", d.ID.String()).Msg("failed to record webhook delivery")
+		}
+
+		return
+	}
+
+	var code *int
+	if statusCode != 0 {
+		code = &statusCode
+	}
+
+	var retryAt *time.Time
+	attempts := d.Attempts + 1
+	if attempts < s.opts.MaxAttempts {
+		t := s.now().Add(s.backoff(attempts))
+		retryAt = &t
+		deliveryAttempts.WithLabelValues("retrying").Inc()
+	} else {
+		deliveryAttempts.WithLabelValues(model.DeliveryFailed).Inc()
+	}
+
+	msg := err.Error()
+	if len(msg) > maxErrorLength {
+		msg = msg[:maxErrorLength]
+	}
+
+	if err = s.repository.Fail(ctx, d.ID, code, msg, retryAt); err != nil {
+		logging.Ctx(ctx).Error().Err(err).Str("delivery", d.ID.String()).Msg("failed to record webhook delivery")
+		return
+	}
+
+	if retryAt == nil {
+		logging.Ctx(ctx).Warn().Str("delivery", d.ID.String()).Str("url", d.URL).Int("attempts", attempts).
+			Msg("webhook delivery failed permanently")
+	}
+}
+
+// send POSTs the signed event to the webhook URL. It returns the response
+// status, or 0 if none was received, and an error unless the status is 2xx.
+func (s *Service) send(ctx context.Context, d model.PendingDelivery) (int, error) {
+	body, err := json.Marshal(d.Event)
+	if err != nil {
+		return 0, fmt.Errorf("marshal event: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, d.URL, bytes.NewReader(body))
+	if err != nil {
+		return 0, fmt.Errorf("build request: %w", err)
+	}
+
+	timestamp := strconv.FormatInt(s.now().Unix(), 10)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "sales-tracker-webhooks")
+	req.Header.Set(HeaderEventID, d.Event.ID.String())
+	req.Header.Set(HeaderDeliveryID, d.ID.String())
+	req.Header.Set(HeaderEvent, d.Event.Type)
+	req.Header.Set(HeaderTimestamp, timestamp)
+	req.Header.Set(HeaderSignature, Sign(d.Secret, timestamp, body))
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+
+	// Drain a bounded part of the body so the connection can be reused.
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return resp.StatusCode, fmt.Errorf("unexpected status %s", resp.Status)
+	}
+
+	return resp.StatusCode, nil
+}
+
+// backoff returns the delay before the retry following the given attempt.
+func (s *Service) backoff(attempt int) time.Duration {
+	d := s.opts.BackoffBase
+	for i := 1; i < attempt && d < s.opts.BackoffMax; i++ {
+		d *= 2
+	}
+
+	return min(d, s.opts.BackoffMax)
+}
+
+// Sign returns the value of the signature header for a delivery body sent at
+// timestamp: "sha256=" followed by the hex HMAC-SHA256 of "<timestamp>.<body>"
+// keyed with the webhook secret.
+func Sign(secret, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(body)
+
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
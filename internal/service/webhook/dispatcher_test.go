@@ -0,0 +1,234 @@
+package webhook
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/aliskhannn/sales-tracker/internal/model"
+)
+
+// outcome is an attempt recorded by fakeRepository.
+type outcome struct {
+	succeeded  bool
+	statusCode *int
+	msg        string
+	retryAt    *time.Time
+}
+
+// fakeRepository fans out a fixed number of outbox events in batches, hands
+// out deliveries once and records their outcomes.
+type fakeRepository struct {
+	repository
+
+	mu         sync.Mutex
+	events     int // outbox events left to fan out
+	fanOuts    int
+	deliveries []model.PendingDelivery
+	outcomes   map[uuid.UUID]outcome
+}
+
+func (f *fakeRepository) FanOut(_ context.Context, limit int) (int, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.fanOuts++
+	n := min(f.events, limit)
+	f.events -= n
+	return n, nil
+}
+
+func (f *fakeRepository) ClaimDue(_ context.Context, limit int, _ time.Duration) ([]model.PendingDelivery, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	n := min(len(f.deliveries), limit)
+	claimed := f.deliveries[:n]
+	f.deliveries = f.deliveries[n:]
+	return claimed, nil
+}
+
+func (f *fakeRepository) Succeed(_ context.Context, id uuid.UUID, statusCode int) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.outcomes[id] = outcome{succeeded: true, statusCode: &statusCode}
+	return nil
+}
+
+func (f *fakeRepository) Fail(_ context.Context, id uuid.UUID, statusCode *int, msg string, retryAt *time.Time) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.outcomes[id] = outcome{statusCode: statusCode, msg: msg, retryAt: retryAt}
+	return nil
+}
+
+// newTestService returns a service delivering to local test servers with a
+// fixed clock.
+func newTestService(r repository, now time.Time) *Service {
+	s := NewService(r, Options{
+		BatchSize:           2,
+		Timeout:             time.Second,
+		MaxAttempts:         3,
+		BackoffBase:         time.Minute,
+		BackoffMax:          10 * time.Minute,
+		AllowPrivateTargets: true,
+	})
+	s.now = func() time.Time { return now }
+	return s
+}
+
+func delivery(url string, attempts int) model.PendingDelivery {
+	return model.PendingDelivery{
+		ID:       uuid.New(),
+		Attempts: attempts,
+		URL:      url,
+		Secret:   "whsec_test",
+		Event: model.Event{
+			ID:          uuid.New(),
+			WorkspaceID: uuid.New(),
+			Type:        model.EventItemCreated,
+			Data:        json.RawMessage(`{"title":"Coffee"}`),
+			CreatedAt:   time.Date(2025, 10, 1, 12, 0, 0, 0, time.UTC),
+		},
+	}
+}
+
+func TestSign(t *testing.T) {
+	tests := []struct {
+		secret    string
+		timestamp string
+		body      string
+		want      string
+	}{
+		{"whsec_test", "1759320000", "", "sha256=0b9434bb03421a9d142e9a9fd33383d70f028527e524863fb9f62deef9c44e96"},
+		{"whsec_test", "1759320000", `{"id":1}`, "sha256=5ff6874ab20b292a26f8c189d9f440d91b1647013335b9ed517fbbd1d2774f3f"},
+		{"other", "1759320001", `{"id":1}`, "sha256=070686637ef3eb8501f82fd98e69ffd6efe8b3e9ae7ee12d00ba9921f746e914"},
+	}
+
+	for _, tt := range tests {
+		if got := Sign(tt.secret, tt.timestamp, []byte(tt.body)); got != tt.want {
+			t.Errorf("Sign(%q, %q, %q) = %s, want %s", tt.secret, tt.timestamp, tt.body, got, tt.want)
+		}
+	}
+}
+
+func TestBackoff(t *testing.T) {
+	s := newTestService(nil, time.Now())
+
+	tests := []struct {
+		attempt int
+		want    time.Duration
+	}{
+		{1, time.Minute},
+		{2, 2 * time.Minute},
+		{3, 4 * time.Minute},
+		{4, 8 * time.Minute},
+		{5, 10 * time.Minute},
+		{30, 10 * time.Minute},
+	}
+
+	for _, tt := range tests {
+		if got := s.backoff(tt.attempt); got != tt.want {
+			t.Errorf("backoff(%d) = %s, want %s", tt.attempt, got, tt.want)
+		}
+	}
+}
+
+func TestDispatch(t *testing.T) {
+	now := time.Date(2025, 10, 1, 12, 0, 0, 0, time.UTC)
+
+	var (
+		mu       sync.Mutex
+		received []http.Header
+		bodies   [][]byte
+	)
+	ok := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+
+		mu.Lock()
+		received = append(received, r.Header.Clone())
+		bodies = append(bodies, body)
+		mu.Unlock()
+
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	defer ok.Close()
+
+	failing := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer failing.Close()
+
+	first, retried, exhausted, third := delivery(ok.URL, 0), delivery(failing.URL, 1), delivery(failing.URL, 2), delivery(ok.URL, 0)
+	r := &fakeRepository{
+		events:     5,
+		deliveries: []model.PendingDelivery{first, retried, exhausted, third},
+		outcomes:   make(map[uuid.UUID]outcome),
+	}
+
+	newTestService(r, now).dispatch(context.Background())
+
+	// Events are fanned out in batches until a batch is not full.
+	if r.fanOuts != 3 || r.events != 0 {
+		t.Errorf("fan-outs = %d with %d events left, want 3 with 0", r.fanOuts, r.events)
+	}
+
+	// Deliveries are claimed in batches until a batch is not full.
+	if len(r.outcomes) != 4 {
+		t.Fatalf("recorded %d outcomes, want 4", len(r.outcomes))
+	}
+
+	for _, d := range []model.PendingDelivery{first, third} {
+		o := r.outcomes[d.ID]
+		if !o.succeeded || *o.statusCode != http.StatusAccepted {
+			t.Errorf("delivery to ok server: %+v, want succeeded with 202", o)
+		}
+	}
+
+	o := r.outcomes[retried.ID]
+	if o.succeeded || o.statusCode == nil || *o.statusCode != http.StatusServiceUnavailable || o.msg == "" {
+		t.Errorf("failed delivery: %+v, want failed with 503 and a message", o)
+	}
+	if o.retryAt == nil || !o.retryAt.Equal(now.Add(2*time.Minute)) {
+		t.Errorf("failed delivery retried at %v, want %v after the second attempt", o.retryAt, now.Add(2*time.Minute))
+	}
+
+	if o = r.outcomes[exhausted.ID]; o.succeeded || o.retryAt != nil {
+		t.Errorf("delivery at max attempts: %+v, want failed without retry", o)
+	}
+
+	if len(received) != 2 {
+		t.Fatalf("ok server received %d requests, want 2", len(received))
+	}
+
+	for i, header := range received {
+		var event model.Event
+		if err := json.Unmarshal(bodies[i], &event); err != nil {
+			t.Fatalf("decode body: %v", err)
+		}
+
+		timestamp := header.Get(HeaderTimestamp)
+		if timestamp != strconv.FormatInt(now.Unix(), 10) {
+			t.Errorf("timestamp header = %q, want %d", timestamp, now.Unix())
+		}
+		if got, want := header.Get(HeaderSignature), Sign("whsec_test", timestamp, bodies[i]); got != want {
+			t.Errorf("signature header = %q, want %q", got, want)
+		}
+		if header.Get(HeaderEventID) != event.ID.String() || header.Get(HeaderEvent) != model.EventItemCreated {
+			t.Errorf("event headers = %q %q, want %s %s", header.Get(HeaderEventID), header.Get(HeaderEvent), event.ID, model.EventItemCreated)
+		}
+		if header.Get("Content-Type") != "application/json" {
+			t.Errorf("content type = %q", header.Get("Content-Type"))
+		}
+	}
+}
@@ -0,0 +1,255 @@
+package webhook
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/aliskhannn/sales-tracker/internal/model"
+)
+
+var (
+	ErrInvalidURL       = errors.New("url must be an absolute http or https URL")
+	ErrInvalidEventType = errors.New("unknown event type")
+)
+
+// secretPrefix marks generated webhook secrets.
+const secretPrefix = "whsec_"
+
+// repository provides methods to interact with webhooks and their deliveries.
+type repository interface {
+	// Create adds a new webhook to the database.
+	Create(ctx context.Context, w *model.Webhook) (uuid.UUID, error)
+
+	// GetByID retrieves a webhook by its ID.
+	GetByID(ctx context.Context, id uuid.UUID) (*model.Webhook, error)
+
+	// List retrieves all webhooks, oldest first.
+	List(ctx context.Context) ([]model.Webhook, error)
+
+	// Update updates the URL, event types, secret and state of a webhook.
+	Update(ctx context.Context, w *model.Webhook) error
+
+	// Delete removes a webhook together with its delivery log.
+	Delete(ctx context.Context, id uuid.UUID) error
+
+	// ListDeliveries retrieves the latest deliveries of a webhook, newest first.
+	ListDeliveries(ctx context.Context, webhookID uuid.UUID, limit int) ([]model.WebhookDelivery, error)
+
+	// Redeliver schedules the event of a delivery for immediate delivery again.
+	Redeliver(ctx context.Context, webhookID, deliveryID uuid.UUID) (*model.WebhookDelivery, error)
+
+	// FanOut turns undispatched outbox events into pending deliveries.
+	FanOut(ctx context.Context, limit int) (int, error)
+
+	// ClaimDue claims pending deliveries whose next attempt is due.
+	ClaimDue(ctx context.Context, limit int, lease time.Duration) ([]model.PendingDelivery, error)
+
+	// Succeed records a successful attempt of a delivery.
+	Succeed(ctx context.Context, id uuid.UUID, statusCode int) error
+
+	// Fail records a failed attempt of a delivery, retried at retryAt unless it is nil.
+	Fail(ctx context.Context, id uuid.UUID, statusCode *int, msg string, retryAt *time.Time) error
+}
+
+// Options configures the delivery of webhooks.
+type Options struct {
+	PollInterval time.Duration // how often the outbox and due deliveries are checked
+	BatchSize    int           // max events fanned out and deliveries sent per round
+	Timeout      time.Duration // timeout of a single delivery request
+	MaxAttempts  int           // attempts after which a delivery is marked failed
+	BackoffBase  time.Duration // delay before the first retry, doubled for each further one
+	BackoffMax   time.Duration // upper bound of the retry delay
+
+	AllowPrivateTargets bool // allow URLs of loopback, private and other non-public addresses, for development only
+}
+
+// Service provides webhook management and delivers outbox events to webhooks.
+type Service struct {
+	repository repository
+	client     *http.Client
+	opts       Options
+	now        func() time.Time
+}
+
+// NewService creates a new webhook service. Zero options fall back to defaults.
+func NewService(r repository, opts Options) *Service {
+	if opts.PollInterval <= 0 {
+		opts.PollInterval = defaultPollInterval
+	}
+	if opts.BatchSize <= 0 {
+		opts.BatchSize = defaultBatchSize
+	}
+	if opts.Timeout <= 0 {
+		opts.Timeout = defaultTimeout
+	}
+	if opts.MaxAttempts <= 0 {
+		opts.MaxAttempts = defaultMaxAttempts
+	}
+	if opts.BackoffBase <= 0 {
+		opts.BackoffBase = defaultBackoffBase
+	}
+	if opts.BackoffMax < opts.BackoffBase {
+		opts.BackoffMax = max(defaultBackoffMax, opts.BackoffBase)
+	}
+
+	return &Service{
+		repository: r,
+		client:     newClient(opts.Timeout, opts.AllowPrivateTargets),
+		opts:       opts,
+		now:        time.Now,
+	}
+}
+
+// Create adds a new webhook subscribed to eventTypes, or to all events if
+// eventTypes is empty. A secret is generated if none is given.
+func (s *Service) Create(ctx context.Context, rawURL string, eventTypes []string, secret string, enabled bool) (*model.Webhook, error) {
+	w := &model.Webhook{Enabled: enabled}
+	if err := s.fill(w, rawURL, eventTypes, secret); err != nil {
+		return nil, err
+	}
+
+	if _, err := s.repository.Create(ctx, w); err != nil {
+		return nil, fmt.Errorf("create webhook: %w", err)
+	}
+
+	return w, nil
+}
+
+// GetByID returns a webhook by its ID.
+func (s *Service) GetByID(ctx context.Context, id uuid.UUID) (*model.Webhook, error) {
+	w, err := s.repository.GetByID(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("get webhook: %w", err)
+	}
+
+	return w, nil
+}
+
+// List returns all webhooks.
+func (s *Service) List(ctx context.Context) ([]model.Webhook, error) {
+	webhooks, err := s.repository.List(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("list webhooks: %w", err)
+	}
+
+	return webhooks, nil
+}
+
+// Update replaces the URL, event types and state of a webhook. The secret is
+// kept unless a new one is given.
+func (s *Service) Update(ctx context.Context, id uuid.UUID, rawURL string, eventTypes []string, secret *string, enabled bool) (*model.Webhook, error) {
+	w, err := s.repository.GetByID(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("update webhook: %w", err)
+	}
+
+	newSecret := w.Secret
+	if secret != nil {
+		newSecret = *secret
+	}
+
+	w.Enabled = enabled
+	if err = s.fill(w, rawURL, eventTypes, newSecret); err != nil {
+		return nil, err
+	}
+
+	if err = s.repository.Update(ctx, w); err != nil {
+		return nil, fmt.Errorf("update webhook: %w", err)
+	}
+
+	return w, nil
+}
+
+// Delete removes a webhook by its ID together with its delivery log.
+func (s *Service) Delete(ctx context.Context, id uuid.UUID) error {
+	if err := s.repository.Delete(ctx, id); err != nil {
+		return fmt.Errorf("delete webhook: %w", err)
+	}
+
+	return nil
+}
+
+// ListDeliveries returns up to limit latest deliveries of a webhook.
+func (s *Service) ListDeliveries(ctx context.Context, webhookID uuid.UUID, limit int) ([]model.WebhookDelivery, error) {
+	if _, err := s.repository.GetByID(ctx, webhookID); err != nil {
+		return nil, fmt.Errorf("list webhook deliveries: %w", err)
+	}
+
+	deliveries, err := s.repository.ListDeliveries(ctx, webhookID, limit)
+	if err != nil {
+		return nil, fmt.Errorf("list webhook deliveries: %w", err)
+	}
+
+	return deliveries, nil
+}
+
+// Redeliver sends the event of a delivery again as a new delivery, regardless
+// of the outcome of the original one.
+func (s *Service) Redeliver(ctx context.Context, webhookID, deliveryID uuid.UUID) (*model.WebhookDelivery, error) {
+	d, err := s.repository.Redeliver(ctx, webhookID, deliveryID)
+	if err != nil {
+		return nil, fmt.Errorf("redeliver webhook delivery: %w", err)
+	}
+
+	return d, nil
+}
+
+// fill validates and sets the URL, event types and secret of w. URLs of
+// non-public hosts are rejected unless private targets are allowed.
+func (s *Service) fill(w *model.Webhook, rawURL string, eventTypes []string, secret string) error {
+	rawURL = strings.TrimSpace(rawURL)
+	u, err := url.Parse(rawURL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return ErrInvalidURL
+	}
+
+	if !s.opts.AllowPrivateTargets {
+		if err = checkHost(u.Hostname()); err != nil {
+			return err
+		}
+	}
+
+	types := make([]string, 0, len(eventTypes))
+	seen := make(map[string]bool, len(eventTypes))
+	for _, t := range eventTypes {
+		t = strings.TrimSpace(t)
+		if !model.IsEventType(t) {
+			return fmt.Errorf("%w: %q", ErrInvalidEventType, t)
+		}
+
+		if !seen[t] {
+			seen[t] = true
+			types = append(types, t)
+		}
+	}
+
+	secret = strings.TrimSpace(secret)
+	if secret == "" {
+		if secret, err = generateSecret(); err != nil {
+			return fmt.Errorf("generate webhook secret: %w", err)
+		}
+	}
+
+	w.URL, w.EventTypes, w.Secret = rawURL, types, secret
+
+	return nil
+}
+
+// generateSecret returns a new random webhook secret.
+func generateSecret() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+
+	return secretPrefix + hex.EncodeToString(b), nil
+}
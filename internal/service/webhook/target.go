@@ -0,0 +1,105 @@
+package webhook
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/netip"
+	"strings"
+	"syscall"
+	"time"
+)
+
+var ErrForbiddenTarget = errors.New("webhook target is not a public address")
+
+// nonPublicPrefixes are special-purpose ranges not covered by the netip
+// predicates used in isPublic: "this network", carrier-grade NAT, IETF
+// protocol assignments, documentation, benchmarking, reserved and the
+// IPv4-mapping prefixes through which IPv6 reaches IPv4 hosts.
+var nonPublicPrefixes = []netip.Prefix{
+	netip.MustParsePrefix("0.0.0.0/8"),
+	netip.MustParsePrefix("100.64.0.0/10"),
+	netip.MustParsePrefix("192.0.0.0/24"),
+	netip.MustParsePrefix("192.0.2.0/24"),
+	netip.MustParsePrefix("198.18.0.0/15"),
+	netip.MustParsePrefix("198.51.100.0/24"),
+	netip.MustParsePrefix("203.0.113.0/24"),
+	netip.MustParsePrefix("240.0.0.0/4"),
+	netip.MustParsePrefix("64:ff9b::/96"),
+	netip.MustParsePrefix("64:ff9b:1::/48"),
+	netip.MustParsePrefix("2001:db8::/32"),
+	netip.MustParsePrefix("2002::/16"),
+}
+
+// isPublic reports whether ip is a globally routable unicast address.
+// Loopback, private, link-local (including cloud metadata endpoints such as
+// 169.254.169.254), multicast and other special-purpose addresses are not.
+func isPublic(ip netip.Addr) bool {
+	ip = ip.Unmap()
+	if !ip.IsGlobalUnicast() || ip.IsPrivate() {
+		return false
+	}
+
+	for _, p := range nonPublicPrefixes {
+		if p.Contains(ip) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// checkHost rejects URL hosts that are known not to be public before any
+// request is made: IP literals of non-public addresses and localhost names.
+// Other host names are checked when a delivery connects.
+func checkHost(host string) error {
+	host = strings.TrimSuffix(strings.ToLower(host), ".")
+	if host == "localhost" || strings.HasSuffix(host, ".localhost") {
+		return ErrForbiddenTarget
+	}
+
+	if ip, err := netip.ParseAddr(strings.Trim(host, "[]")); err == nil && !isPublic(ip) {
+		return ErrForbiddenTarget
+	}
+
+	return nil
+}
+
+// controlPublic is a net.Dialer Control function that refuses connections to
+// non-public addresses. It runs after name resolution for every address
+// dialed, so host names resolving to internal addresses are refused too.
+func controlPublic(_, address string, _ syscall.RawConn) error {
+	addrPort, err := netip.ParseAddrPort(address)
+	if err != nil {
+		return fmt.Errorf("%w: %s", ErrForbiddenTarget, address)
+	}
+
+	if !isPublic(addrPort.Addr()) {
+		return fmt.Errorf("%w: %s", ErrForbiddenTarget, addrPort.Addr())
+	}
+
+	return nil
+}
+
+// newClient returns the HTTP client used for deliveries. Unless allowPrivate
+// is set, it only connects to public addresses and does not use a proxy,
+// which would connect on its behalf. Redirects are never followed, since they
+// could point a delivery at an internal target; they count as failed attempts.
+func newClient(timeout time.Duration, allowPrivate bool) *http.Client {
+	dialer := &net.Dialer{Timeout: timeout}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	if !allowPrivate {
+		dialer.Control = controlPublic
+		transport.Proxy = nil
+	}
+	transport.DialContext = dialer.DialContext
+
+	return &http.Client{
+		Timeout:   timeout,
+		Transport: transport,
+		CheckRedirect: func(*http.Request, []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+}
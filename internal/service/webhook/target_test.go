@@ -0,0 +1,132 @@
+package webhook
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"testing"
+	"time"
+
+	"github.com/aliskhannn/sales-tracker/internal/model"
+)
+
+func TestIsPublic(t *testing.T) {
+	tests := []struct {
+		addr string
+		want bool
+	}{
+		{"93.184.216.34", true},
+		{"8.8.8.8", true},
+		{"2606:4700:4700::1111", true},
+		{"127.0.0.1", false},
+		{"::1", false},
+		{"10.1.2.3", false},
+		{"172.16.0.1", false},
+		{"192.168.1.1", false},
+		{"169.254.169.254", false},
+		{"100.64.0.1", false},
+		{"0.0.0.0", false},
+		{"::", false},
+		{"224.0.0.1", false},
+		{"255.255.255.255", false},
+		{"fd00::1", false},
+		{"fe80::1", false},
+		{"::ffff:127.0.0.1", false},
+		{"::ffff:10.0.0.1", false},
+		{"64:ff9b::a00:1", false},
+		{"198.18.0.1", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.addr, func(t *testing.T) {
+			if got := isPublic(netip.MustParseAddr(tt.addr)); got != tt.want {
+				t.Errorf("isPublic(%s) = %v, want %v", tt.addr, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFillRejectsNonPublicHosts(t *testing.T) {
+	tests := []struct {
+		url     string
+		wantErr error
+	}{
+		{url: "https://hooks.example.com/sales"},
+		{url: "http://93.184.216.34:8080/hook"},
+		{url: "http://localhost:8080/hook", wantErr: ErrForbiddenTarget},
+		{url: "http://api.localhost./hook", wantErr: ErrForbiddenTarget},
+		{url: "http://127.0.0.1/hook", wantErr: ErrForbiddenTarget},
+		{url: "http://[::1]:9000/hook", wantErr: ErrForbiddenTarget},
+		{url: "http://169.254.169.254/latest/meta-data", wantErr: ErrForbiddenTarget},
+		{url: "http://10.0.0.5/hook", wantErr: ErrForbiddenTarget},
+		{url: "ftp://hooks.example.com", wantErr: ErrInvalidURL},
+		{url: "/relative", wantErr: ErrInvalidURL},
+	}
+
+	s := NewService(nil, Options{})
+	for _, tt := range tests {
+		t.Run(tt.url, func(t *testing.T) {
+			err := s.fill(&model.Webhook{}, tt.url, nil, "secret")
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("fill(%q) error = %v, want %v", tt.url, err, tt.wantErr)
+			}
+		})
+	}
+
+	allowed := NewService(nil, Options{AllowPrivateTargets: true})
+	if err := allowed.fill(&model.Webhook{}, "http://localhost:8080/hook", nil, "secret"); err != nil {
+		t.Errorf("fill() with private targets allowed error = %v", err)
+	}
+}
+
+func TestClientRefusesNonPublicAddresses(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer srv.Close()
+
+	req, err := http.NewRequestWithContext(context.Background(), http.MethodPost, srv.URL, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err = newClient(time.Second, false).Do(req); !errors.Is(err, ErrForbiddenTarget) {
+		t.Fatalf("Do() error = %v, want ErrForbiddenTarget", err)
+	}
+
+	resp, err := newClient(time.Second, true).Do(req)
+	if err != nil {
+		t.Fatalf("Do() with private targets allowed error = %v", err)
+	}
+	_ = resp.Body.Close()
+}
+
+func TestClientDoesNotFollowRedirects(t *testing.T) {
+	var followed bool
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/internal" {
+			followed = true
+			return
+		}
+
+		http.Redirect(w, r, "/internal", http.StatusTemporaryRedirect)
+	}))
+	defer srv.Close()
+
+	req, err := http.NewRequestWithContext(context.Background(), http.MethodPost, srv.URL+"/hook", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	resp, err := newClient(time.Second, true).Do(req)
+	if err != nil {
+		t.Fatalf("Do() error = %v", err)
+	}
+	_ = resp.Body.Close()
+
+	if resp.StatusCode != http.StatusTemporaryRedirect || followed {
+		t.Errorf("status = %d, followed = %v, want the redirect response", resp.StatusCode, followed)
+	}
+}
@@ -0,0 +1,112 @@
+package workspace
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/google/uuid"
+
+	"github.com/aliskhannn/sales-tracker/internal/model"
+)
+
+var (
+	ErrInvalidSlug = errors.New("slug must be 1-63 lower-case letters, digits or dashes and start with a letter or digit")
+)
+
+// slugPattern matches valid workspace slugs; keep in sync with the DB check.
+var slugPattern = regexp.MustCompile(`^[a-z0-9][a-z0-9-]{0,62}$`)
+
+// repository provides methods to interact with workspaces.
+type repository interface {
+	// Create adds a new workspace to the database.
+	Create(ctx context.Context, w *model.Workspace) (uuid.UUID, error)
+
+	// GetByID retrieves a workspace by its ID.
+	GetByID(ctx context.Context, id uuid.UUID) (*model.Workspace, error)
+
+	// GetBySlug retrieves a workspace by its slug.
+	GetBySlug(ctx context.Context, slug string) (*model.Workspace, error)
+
+	// List retrieves all workspaces ordered by slug.
+	List(ctx context.Context) ([]model.Workspace, error)
+
+	// Update renames a workspace.
+	Update(ctx context.Context, w *model.Workspace) error
+}
+
+// Service provides workspace-related business logic.
+type Service struct {
+	repository repository
+}
+
+// NewService creates a new workspace service.
+func NewService(r repository) *Service {
+	return &Service{repository: r}
+}
+
+// Create adds a new workspace.
+func (s *Service) Create(ctx context.Context, slug, name string) (*model.Workspace, error) {
+	slug = strings.ToLower(strings.TrimSpace(slug))
+	if !slugPattern.MatchString(slug) {
+		return nil, ErrInvalidSlug
+	}
+
+	w := &model.Workspace{Slug: slug, Name: strings.TrimSpace(name)}
+	if _, err := s.repository.Create(ctx, w); err != nil {
+		return nil, fmt.Errorf("create workspace: %w", err)
+	}
+
+	return w, nil
+}
+
+// GetByID returns a workspace by its ID.
+func (s *Service) GetByID(ctx context.Context, id uuid.UUID) (*model.Workspace, error) {
+	w, err := s.repository.GetByID(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("get workspace: %w", err)
+	}
+
+	return w, nil
+}
+
+// List returns all workspaces.
+func (s *Service) List(ctx context.Context) ([]model.Workspace, error) {
+	workspaces, err := s.repository.List(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("list workspaces: %w", err)
+	}
+
+	return workspaces, nil
+}
+
+// Update renames a workspace.
+func (s *Service) Update(ctx context.Context, id uuid.UUID, name string) error {
+	if err := s.repository.Update(ctx, &model.Workspace{ID: id, Name: strings.TrimSpace(name)}); err != nil {
+		return fmt.Errorf("update workspace: %w", err)
+	}
+
+	return nil
+}
+
+// Resolve returns the workspace identified by ref, a workspace ID or slug.
+func (s *Service) Resolve(ctx context.Context, ref string) (*model.Workspace, error) {
+	var (
+		w   *model.Workspace
+		err error
+	)
+
+	if id, parseErr := uuid.Parse(ref); parseErr == nil {
+		w, err = s.repository.GetByID(ctx, id)
+	} else {
+		w, err = s.repository.GetBySlug(ctx, strings.ToLower(ref))
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("resolve workspace: %w", err)
+	}
+
+	return w, nil
+}
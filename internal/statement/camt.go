@@ -0,0 +1,177 @@
+package statement
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+)
+
+// camtDocument is the subset of an ISO 20022 camt.053 bank-to-customer
+// statement read by ParseCAMT053. Elements are matched by local name, so all
+// camt.053 versions are accepted.
+type camtDocument struct {
+	XMLName    xml.Name        `xml:"Document"`
+	Statements []camtStatement `xml:"BkToCstmrStmt>Stmt"`
+}
+
+type camtStatement struct {
+	Entries []camtEntry `xml:"Ntry"`
+}
+
+type camtEntry struct {
+	Amount struct {
+		Value    string `xml:",chardata"`
+		Currency string `xml:"Ccy,attr"`
+	} `xml:"Amt"`
+	CreditDebit string `xml:"CdtDbtInd"`
+	Status      struct {
+		Value string `xml:",chardata"`
+		Code  string `xml:"Cd"`
+	} `xml:"Sts"`
+	BookingDate camtDate    `xml:"BookgDt"`
+	ValueDate   camtDate    `xml:"ValDt"`
+	EntryRef    string      `xml:"NtryRef"`
+	ServicerRef string      `xml:"AcctSvcrRef"`
+	Details     []camtTxDtl `xml:"NtryDtls>TxDtls"`
+	Info        string      `xml:"AddtlNtryInf"`
+}
+
+type camtDate struct {
+	Date     string `xml:"Dt"`
+	DateTime string `xml:"DtTm"`
+}
+
+type camtTxDtl struct {
+	ServicerRef string   `xml:"Refs>AcctSvcrRef"`
+	EndToEndID  string   `xml:"Refs>EndToEndId"`
+	Creditor    camtName `xml:"RltdPties>Cdtr"`
+	Debtor      camtName `xml:"RltdPties>Dbtr"`
+	Remittance  []string `xml:"RmtInf>Ustrd"`
+}
+
+// camtName holds a party name, which newer versions nest in a Pty element.
+type camtName struct {
+	Name      string `xml:"Nm"`
+	PartyName string `xml:"Pty>Nm"`
+}
+
+func (n camtName) String() string {
+	if n.Name != "" {
+		return n.Name
+	}
+
+	return n.PartyName
+}
+
+// ParseCAMT053 parses an ISO 20022 camt.053 statement. Pending entries are
+// skipped. DBIT entries become negative amounts; the account servicer
+// reference, or the entry or end-to-end reference, is used as reference.
+func ParseCAMT053(r io.Reader) ([]Line, error) {
+	var doc camtDocument
+	if err := xml.NewDecoder(r).Decode(&doc); err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrInvalidStatement, err)
+	}
+
+	var lines []Line
+	no := 0
+	for _, stmt := range doc.Statements {
+		for _, e := range stmt.Entries {
+			no++
+
+			status := e.Status.Code
+			if status == "" {
+				status = strings.TrimSpace(e.Status.Value)
+			}
+			if status != "" && status != "BOOK" {
+				continue
+			}
+
+			line, err := camtLine(e)
+			if err != nil {
+				return nil, fmt.Errorf("%w: entry %d: %s", ErrInvalidStatement, no, err)
+			}
+
+			lines = append(lines, line)
+		}
+	}
+
+	return lines, nil
+}
+
+// camtLine builds a statement line from a camt.053 entry.
+func camtLine(e camtEntry) (Line, error) {
+	amount, err := parseAmount(strings.TrimSpace(e.Amount.Value))
+	if err != nil {
+		return Line{}, err
+	}
+
+	switch strings.TrimSpace(e.CreditDebit) {
+	case "DBIT":
+		amount = amount.Abs().Neg()
+	case "CRDT":
+		amount = amount.Abs()
+	default:
+		return Line{}, fmt.Errorf("invalid credit/debit indicator %q", e.CreditDebit)
+	}
+
+	date, err := e.BookingDate.parse()
+	if err != nil {
+		date, err = e.ValueDate.parse()
+	}
+	if err != nil {
+		return Line{}, err
+	}
+
+	var (
+		party  string
+		remit  []string
+		txRefs []string
+	)
+	for _, d := range e.Details {
+		if party == "" {
+			if amount.IsNegative() {
+				party = d.Creditor.String()
+			} else {
+				party = d.Debtor.String()
+			}
+		}
+
+		remit = append(remit, d.Remittance...)
+		txRefs = append(txRefs, d.ServicerRef, d.EndToEndID)
+	}
+
+	parts := append([]string{party}, remit...)
+	parts = append(parts, e.Info)
+
+	return Line{
+		Date:        date,
+		Amount:      amount,
+		Currency:    strings.ToUpper(e.Amount.Currency),
+		Description: description(parts...),
+		Reference:   firstReference(append([]string{e.ServicerRef, e.EntryRef}, txRefs...)...),
+	}, nil
+}
+
+// parse returns the date or date-time of a camt.053 date element.
+func (d camtDate) parse() (time.Time, error) {
+	if d.DateTime != "" {
+		return parseDate(strings.TrimSpace(d.DateTime), []string{time.RFC3339, "2006-01-02T15:04:05"})
+	}
+
+	return parseDate(strings.TrimSpace(d.Date), []string{time.DateOnly})
+}
+
+// firstReference returns the first usable reference, skipping the
+// NOTPROVIDED placeholder.
+func firstReference(refs ...string) string {
+	for _, r := range refs {
+		r = strings.TrimSpace(r)
+		if r != "" && r != "NOTPROVIDED" {
+			return r
+		}
+	}
+
+	return ""
+}
@@ -0,0 +1,108 @@
+package statement
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+// camtStatementXML wraps entries in a camt.053.001.08 document.
+func camtStatementXML(entries string) string {
+	return `<?xml version="1.0" encoding="UTF-8"?>
+<Document xmlns="urn:iso:std:iso:20022:tech:xsd:camt.053.001.08">
+  <BkToCstmrStmt><Stmt>` + entries + `</Stmt></BkToCstmrStmt>
+</Document>`
+}
+
+const camtDebit = `
+<Ntry>
+  <NtryRef>E-1</NtryRef>
+  <Amt Ccy="eur">42.10</Amt>
+  <CdtDbtInd>DBIT</CdtDbtInd>
+  <Sts><Cd>BOOK</Cd></Sts>
+  <BookgDt><Dt>2025-10-01</Dt></BookgDt>
+  <AcctSvcrRef>NOTPROVIDED</AcctSvcrRef>
+  <NtryDtls><TxDtls>
+    <Refs><EndToEndId>E2E-1</EndToEndId></Refs>
+    <RltdPties><Cdtr><Pty><Nm>Power Company</Nm></Pty></Cdtr><Dbtr><Nm>Me</Nm></Dbtr></RltdPties>
+    <RmtInf><Ustrd>Invoice 7</Ustrd></RmtInf>
+  </TxDtls></NtryDtls>
+</Ntry>`
+
+const camtCredit = `
+<Ntry>
+  <Amt Ccy="EUR">1500.00</Amt>
+  <CdtDbtInd>CRDT</CdtDbtInd>
+  <Sts>BOOK</Sts>
+  <ValDt><DtTm>2025-10-02T08:30:00+02:00</DtTm></ValDt>
+  <AcctSvcrRef>SR-2</AcctSvcrRef>
+  <NtryDtls><TxDtls>
+    <RltdPties><Dbtr><Nm>Employer</Nm></Dbtr><Cdtr><Nm>Me</Nm></Cdtr></RltdPties>
+  </TxDtls></NtryDtls>
+  <AddtlNtryInf>Salary</AddtlNtryInf>
+</Ntry>`
+
+const camtPending = `
+<Ntry>
+  <Amt Ccy="EUR">9.99</Amt>
+  <CdtDbtInd>DBIT</CdtDbtInd>
+  <Sts><Cd>PDNG</Cd></Sts>
+  <BookgDt><Dt>2025-10-03</Dt></BookgDt>
+</Ntry>`
+
+func TestParseCAMT053(t *testing.T) {
+	tests := []struct {
+		name    string
+		xml     string
+		want    []Line
+		wantErr bool
+	}{
+		{
+			name: "debit, credit and pending entries",
+			xml:  camtStatementXML(camtDebit + camtCredit + camtPending),
+			want: []Line{
+				{Date: date(2025, 10, 1), Amount: decimal.RequireFromString("-42.10"), Currency: "EUR", Description: "Power Company - Invoice 7", Reference: "E-1"},
+				{Date: time.Date(2025, 10, 2, 6, 30, 0, 0, time.UTC), Amount: decimal.NewFromInt(1500), Currency: "EUR", Description: "Employer - Salary", Reference: "SR-2"},
+			},
+		},
+		{
+			name: "signed amount follows the indicator",
+			xml:  camtStatementXML(`<Ntry><Amt Ccy="EUR">-5</Amt><CdtDbtInd>CRDT</CdtDbtInd><BookgDt><Dt>2025-10-04</Dt></BookgDt></Ntry>`),
+			want: []Line{{Date: date(2025, 10, 4), Amount: decimal.NewFromInt(5), Currency: "EUR"}},
+		},
+		{name: "no entries", xml: camtStatementXML("")},
+		{name: "not xml", xml: "date,amount\n", wantErr: true},
+		{name: "other document", xml: "<Statement/>", wantErr: true},
+		{
+			name:    "missing indicator",
+			xml:     camtStatementXML(`<Ntry><Amt Ccy="EUR">5</Amt><BookgDt><Dt>2025-10-04</Dt></BookgDt></Ntry>`),
+			wantErr: true,
+		},
+		{
+			name:    "missing date",
+			xml:     camtStatementXML(`<Ntry><Amt Ccy="EUR">5</Amt><CdtDbtInd>DBIT</CdtDbtInd></Ntry>`),
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseCAMT053(strings.NewReader(tt.xml))
+			if tt.wantErr {
+				if !errors.Is(err, ErrInvalidStatement) {
+					t.Fatalf("ParseCAMT053() error = %v, want ErrInvalidStatement", err)
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("ParseCAMT053() error = %v", err)
+			}
+
+			checkLines(t, got, tt.want)
+		})
+	}
+}
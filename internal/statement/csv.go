@@ -0,0 +1,215 @@
+package statement
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+// csvDateLayouts are the date formats accepted in CSV statements.
+var csvDateLayouts = []string{
+	time.RFC3339,
+	time.DateOnly,
+	"2006-01-02 15:04:05",
+	"02.01.2006",
+	"02/01/2006",
+}
+
+// csvColumns maps accepted header names to statement fields.
+var csvColumns = map[string]string{
+	"date":           "date",
+	"booking date":   "date",
+	"posted":         "date",
+	"amount":         "amount",
+	"debit":          "debit",
+	"credit":         "credit",
+	"currency":       "currency",
+	"description":    "description",
+	"title":          "description",
+	"memo":           "description",
+	"payee":          "payee",
+	"name":           "payee",
+	"reference":      "reference",
+	"id":             "reference",
+	"fitid":          "reference",
+	"transaction id": "reference",
+}
+
+// ParseCSV parses a CSV statement with a header row.
+//
+// Required columns are a date and either a signed amount or separate debit and
+// credit columns. Optional columns are currency, description (or title/memo),
+// payee (or name) and reference (or id/fitid). Comma, semicolon and tab
+// delimiters are detected from the header.
+func ParseCSV(r io.Reader) ([]Line, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, fmt.Errorf("read csv: %w", err)
+	}
+
+	text := strings.TrimPrefix(string(data), "\ufeff")
+	header, _, _ := strings.Cut(text, "\n")
+
+	cr := csv.NewReader(strings.NewReader(text))
+	cr.Comma = detectDelimiter(header)
+	cr.FieldsPerRecord = -1
+	// Leading tabs would be trimmed too, dropping empty fields of tab-separated files.
+	cr.TrimLeadingSpace = cr.Comma != '\t'
+
+	records, err := cr.ReadAll()
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrInvalidStatement, err)
+	}
+
+	if len(records) == 0 {
+		return nil, fmt.Errorf("%w: empty file", ErrInvalidStatement)
+	}
+
+	cols := make(map[string]int)
+	for n, name := range records[0] {
+		if field, ok := csvColumns[strings.ToLower(strings.TrimSpace(name))]; ok {
+			if _, seen := cols[field]; !seen {
+				cols[field] = n
+			}
+		}
+	}
+
+	_, hasAmount := cols["amount"]
+	_, hasDebit := cols["debit"]
+	_, hasCredit := cols["credit"]
+	if _, ok := cols["date"]; !ok || (!hasAmount && !hasDebit && !hasCredit) {
+		return nil, fmt.Errorf("%w: date and amount (or debit/credit) columns are required", ErrInvalidStatement)
+	}
+
+	get := func(rec []string, field string) string {
+		n, ok := cols[field]
+		if !ok || n >= len(rec) {
+			return ""
+		}
+
+		return strings.TrimSpace(rec[n])
+	}
+
+	var lines []Line
+	for n, rec := range records[1:] {
+		if isBlank(rec) {
+			continue
+		}
+
+		row := n + 2
+
+		date, err := parseDate(get(rec, "date"), csvDateLayouts)
+		if err != nil {
+			return nil, fmt.Errorf("%w: row %d: %s", ErrInvalidStatement, row, err)
+		}
+
+		amount, err := csvAmount(get(rec, "amount"), get(rec, "debit"), get(rec, "credit"))
+		if err != nil {
+			return nil, fmt.Errorf("%w: row %d: %s", ErrInvalidStatement, row, err)
+		}
+
+		lines = append(lines, Line{
+			Date:        date,
+			Amount:      amount,
+			Currency:    strings.ToUpper(get(rec, "currency")),
+			Description: description(get(rec, "payee"), get(rec, "description")),
+			Reference:   get(rec, "reference"),
+		})
+	}
+
+	return lines, nil
+}
+
+// csvAmount returns the signed amount of a CSV row from either the amount
+// column or the debit and credit columns.
+func csvAmount(amount, debit, credit string) (decimal.Decimal, error) {
+	if amount != "" {
+		return parseAmount(amount)
+	}
+
+	total := decimal.Zero
+	if credit != "" {
+		c, err := parseAmount(credit)
+		if err != nil {
+			return decimal.Zero, err
+		}
+		total = total.Add(c.Abs())
+	}
+
+	if debit != "" {
+		d, err := parseAmount(debit)
+		if err != nil {
+			return decimal.Zero, err
+		}
+		total = total.Sub(d.Abs())
+	}
+
+	if debit == "" && credit == "" {
+		return decimal.Zero, errors.New("amount is missing")
+	}
+
+	return total, nil
+}
+
+// parseAmount parses a decimal amount, accepting a comma as decimal separator
+// when there is no dot and ignoring spaces and thousands separators.
+func parseAmount(s string) (decimal.Decimal, error) {
+	s = strings.NewReplacer(" ", "", "\u00a0", "", "'", "").Replace(s)
+	switch {
+	case strings.Contains(s, ",") && strings.Contains(s, "."):
+		if strings.LastIndex(s, ",") > strings.LastIndex(s, ".") {
+			s = strings.ReplaceAll(s, ".", "")
+			s = strings.ReplaceAll(s, ",", ".")
+		} else {
+			s = strings.ReplaceAll(s, ",", "")
+		}
+	case strings.Contains(s, ","):
+		s = strings.ReplaceAll(s, ",", ".")
+	}
+
+	d, err := decimal.NewFromString(s)
+	if err != nil {
+		return decimal.Zero, fmt.Errorf("invalid amount %q", s)
+	}
+
+	return d, nil
+}
+
+// parseDate parses s with the first matching layout.
+func parseDate(s string, layouts []string) (time.Time, error) {
+	for _, layout := range layouts {
+		if t, err := time.Parse(layout, s); err == nil {
+			return t, nil
+		}
+	}
+
+	return time.Time{}, fmt.Errorf("invalid date %q", s)
+}
+
+// detectDelimiter picks the most frequent of comma, semicolon and tab in the header.
+func detectDelimiter(header string) rune {
+	best, bestCount := ',', strings.Count(header, ",")
+	for _, d := range []rune{';', '\t'} {
+		if n := strings.Count(header, string(d)); n > bestCount {
+			best, bestCount = d, n
+		}
+	}
+
+	return best
+}
+
+// isBlank reports whether all fields of a record are empty.
+func isBlank(rec []string) bool {
+	for _, f := range rec {
+		if strings.TrimSpace(f) != "" {
+			return false
+		}
+	}
+
+	return true
+}
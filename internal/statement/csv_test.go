@@ -0,0 +1,141 @@
+package statement
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+// date returns midnight UTC of the given day.
+func date(y int, m time.Month, d int) time.Time {
+	return time.Date(y, m, d, 0, 0, 0, 0, time.UTC)
+}
+
+// checkLines compares parsed lines with the expected ones.
+func checkLines(t *testing.T, got, want []Line) {
+	t.Helper()
+
+	if len(got) != len(want) {
+		t.Fatalf("got %d lines, want %d: %+v", len(got), len(want), got)
+	}
+
+	for n := range want {
+		g, w := got[n], want[n]
+		if !g.Date.Equal(w.Date) || !g.Amount.Equal(w.Amount) || g.Currency != w.Currency ||
+			g.Description != w.Description || g.Reference != w.Reference {
+			t.Errorf("line %d = %+v, want %+v", n, g, w)
+		}
+	}
+}
+
+func TestParseAmount(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    string
+		wantErr bool
+	}{
+		{in: "12.50", want: "12.5"},
+		{in: "-12.50", want: "-12.5"},
+		{in: "12,50", want: "12.5"},
+		{in: "1,234.56", want: "1234.56"},
+		{in: "1.234,56", want: "1234.56"},
+		{in: "-1 234,56", want: "-1234.56"},
+		{in: "1 234.56", want: "1234.56"},
+		{in: "1'234.56", want: "1234.56"},
+		{in: "0", want: "0"},
+		{in: "", wantErr: true},
+		{in: "12.5 EUR", wantErr: true},
+		{in: "abc", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			got, err := parseAmount(tt.in)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("parseAmount(%q) = %s, want error", tt.in, got)
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("parseAmount(%q) error = %v", tt.in, err)
+			}
+
+			if !got.Equal(decimal.RequireFromString(tt.want)) {
+				t.Errorf("parseAmount(%q) = %s, want %s", tt.in, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseCSV(t *testing.T) {
+	tests := []struct {
+		name    string
+		csv     string
+		want    []Line
+		wantErr bool
+	}{
+		{
+			name: "signed amount",
+			csv: "Date,Amount,Currency,Description,Payee,Reference\n" +
+				"2025-10-01,-12.50,eur,Card payment,Coffee Shop,TX1\n" +
+				"2025-10-02,1000,EUR,Salary,,TX2\n",
+			want: []Line{
+				{Date: date(2025, 10, 1), Amount: decimal.RequireFromString("-12.50"), Currency: "EUR", Description: "Coffee Shop - Card payment", Reference: "TX1"},
+				{Date: date(2025, 10, 2), Amount: decimal.NewFromInt(1000), Currency: "EUR", Description: "Salary", Reference: "TX2"},
+			},
+		},
+		{
+			name: "semicolons, decimal commas and day-first dates",
+			csv: "\ufeffBooking date;Debit;Credit;Memo\n" +
+				"01.10.2025;1.234,56;;Rent\n" +
+				"02.10.2025;;99,90;Refund\n" +
+				";;;\n",
+			want: []Line{
+				{Date: date(2025, 10, 1), Amount: decimal.RequireFromString("-1234.56"), Description: "Rent"},
+				{Date: date(2025, 10, 2), Amount: decimal.RequireFromString("99.90"), Description: "Refund"},
+			},
+		},
+		{
+			name: "tabs, signed debit and date-time",
+			csv: "posted\tdebit\tcredit\tname\tfitid\n" +
+				"2025-10-03 14:30:00\t-20\t\tGrocer\tA-1\n",
+			want: []Line{
+				{Date: time.Date(2025, 10, 3, 14, 30, 0, 0, time.UTC), Amount: decimal.NewFromInt(-20), Description: "Grocer", Reference: "A-1"},
+			},
+		},
+		{
+			name: "payee repeated in description",
+			csv:  "date,amount,name,memo\n2025-10-04,-5,Bakery,  bakery \n",
+			want: []Line{{Date: date(2025, 10, 4), Amount: decimal.NewFromInt(-5), Description: "Bakery"}},
+		},
+		{name: "header only", csv: "date,amount\n"},
+		{name: "empty", csv: "", wantErr: true},
+		{name: "missing amount column", csv: "date,description\n2025-10-01,x\n", wantErr: true},
+		{name: "invalid date", csv: "date,amount\n10/32/2025,1\n", wantErr: true},
+		{name: "invalid amount", csv: "date,amount\n2025-10-01,ten\n", wantErr: true},
+		{name: "missing debit and credit", csv: "date,debit,credit\n2025-10-01,,\n", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseCSV(strings.NewReader(tt.csv))
+			if tt.wantErr {
+				if !errors.Is(err, ErrInvalidStatement) {
+					t.Fatalf("ParseCSV() error = %v, want ErrInvalidStatement", err)
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("ParseCSV() error = %v", err)
+			}
+
+			checkLines(t, got, tt.want)
+		})
+	}
+}
@@ -0,0 +1,147 @@
+package statement
+
+import (
+	"fmt"
+	"html"
+	"io"
+	"strings"
+	"time"
+)
+
+// ofxDateLayouts are the OFX date formats, longest first. Time zone suffixes
+// such as "[-5:EST]" are stripped before parsing.
+var ofxDateLayouts = []string{
+	"20060102150405.000",
+	"20060102150405",
+	"200601021504",
+	"20060102",
+}
+
+// ParseOFX parses an OFX statement. Both SGML (OFX 1.x, unclosed leaf
+// elements) and XML (OFX 2.x) files are accepted.
+func ParseOFX(r io.Reader) ([]Line, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, fmt.Errorf("read ofx: %w", err)
+	}
+
+	text := string(data)
+	if !strings.Contains(strings.ToUpper(text), "<OFX>") {
+		return nil, fmt.Errorf("%w: missing OFX element", ErrInvalidStatement)
+	}
+
+	var (
+		lines    []Line
+		currency string
+		trn      map[string]string
+	)
+
+	for _, tok := range ofxTokens(text) {
+		switch {
+		case tok.name == "CURDEF" && !tok.closing && trn == nil:
+			currency = strings.ToUpper(tok.value)
+		case tok.name == "STMTTRN" && !tok.closing:
+			trn = make(map[string]string)
+		case tok.name == "STMTTRN" && tok.closing:
+			if trn == nil {
+				continue
+			}
+
+			line, err := ofxLine(trn, currency)
+			if err != nil {
+				return nil, fmt.Errorf("%w: transaction %d: %s", ErrInvalidStatement, len(lines)+1, err)
+			}
+
+			lines = append(lines, line)
+			trn = nil
+		case trn != nil && !tok.closing && tok.value != "":
+			trn[tok.name] = tok.value
+		}
+	}
+
+	if trn != nil {
+		return nil, fmt.Errorf("%w: unterminated STMTTRN element", ErrInvalidStatement)
+	}
+
+	return lines, nil
+}
+
+// ofxLine builds a statement line from the fields of an STMTTRN element.
+func ofxLine(trn map[string]string, currency string) (Line, error) {
+	amount, err := parseAmount(trn["TRNAMT"])
+	if err != nil {
+		return Line{}, err
+	}
+
+	date, err := parseOFXDate(trn["DTPOSTED"])
+	if err != nil {
+		return Line{}, err
+	}
+
+	if c := trn["CURRENCY"]; c != "" {
+		currency = c
+	}
+
+	return Line{
+		Date:        date,
+		Amount:      amount,
+		Currency:    strings.ToUpper(currency),
+		Description: description(trn["NAME"], trn["PAYEE"], trn["MEMO"]),
+		Reference:   trn["FITID"],
+	}, nil
+}
+
+// parseOFXDate parses an OFX date, ignoring a trailing time zone in brackets.
+func parseOFXDate(s string) (time.Time, error) {
+	if i := strings.IndexByte(s, '['); i >= 0 {
+		s = s[:i]
+	}
+
+	return parseDate(strings.TrimSpace(s), ofxDateLayouts)
+}
+
+// ofxToken is an OFX element tag together with the text that follows it.
+type ofxToken struct {
+	name    string
+	closing bool
+	value   string
+}
+
+// ofxTokens splits an OFX document into tags and their text values. It does
+// not require leaf elements to be closed, which makes it work for both the
+// SGML and the XML flavour of OFX.
+func ofxTokens(text string) []ofxToken {
+	var tokens []ofxToken
+	for {
+		start := strings.IndexByte(text, '<')
+		if start < 0 {
+			return tokens
+		}
+
+		end := strings.IndexByte(text[start:], '>')
+		if end < 0 {
+			return tokens
+		}
+
+		tag := text[start+1 : start+end]
+		text = text[start+end+1:]
+
+		if strings.HasPrefix(tag, "?") || strings.HasPrefix(tag, "!") {
+			continue
+		}
+
+		value := text
+		if next := strings.IndexByte(text, '<'); next >= 0 {
+			value = text[:next]
+		}
+
+		tok := ofxToken{value: html.UnescapeString(strings.TrimSpace(value))}
+		if strings.HasPrefix(tag, "/") {
+			tok.closing = true
+			tag = tag[1:]
+		}
+		tok.name = strings.ToUpper(strings.TrimSpace(tag))
+
+		tokens = append(tokens, tok)
+	}
+}
@@ -0,0 +1,108 @@
+package statement
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+// ofxSGML is an OFX 1.x statement with unclosed leaf elements.
+const ofxSGML = `OFXHEADER:100
+DATA:OFXSGML
+VERSION:102
+
+<OFX>
+<BANKMSGSRSV1><STMTTRNRS><STMTRS>
+<CURDEF>usd
+<BANKTRANLIST>
+<STMTTRN>
+<TRNTYPE>DEBIT
+<DTPOSTED>20251001120000.000[-5:EST]
+<TRNAMT>-42.10
+<FITID>2025100101
+<NAME>AT&amp;T
+<MEMO>Phone bill
+</STMTTRN>
+<STMTTRN>
+<TRNTYPE>CREDIT
+<DTPOSTED>20251002
+<TRNAMT>1500.00
+<FITID>2025100201
+<NAME>Payroll
+<CURRENCY>eur
+</STMTTRN>
+</BANKTRANLIST>
+</STMTRS></STMTTRNRS></BANKMSGSRSV1>
+</OFX>
+`
+
+// ofxXML is an OFX 2.x statement.
+const ofxXML = `<?xml version="1.0" encoding="UTF-8"?>
+<?OFX OFXHEADER="200" VERSION="220"?>
+<OFX>
+  <BANKMSGSRSV1><STMTTRNRS><STMTRS>
+    <CURDEF>GBP</CURDEF>
+    <BANKTRANLIST>
+      <STMTTRN>
+        <TRNTYPE>POS</TRNTYPE>
+        <DTPOSTED>202510031530</DTPOSTED>
+        <TRNAMT>-3,20</TRNAMT>
+        <FITID>X-1</FITID>
+        <NAME>Bakery</NAME>
+        <MEMO>bakery</MEMO>
+      </STMTTRN>
+    </BANKTRANLIST>
+  </STMTRS></STMTTRNRS></BANKMSGSRSV1>
+</OFX>
+`
+
+func TestParseOFX(t *testing.T) {
+	tests := []struct {
+		name    string
+		ofx     string
+		want    []Line
+		wantErr bool
+	}{
+		{
+			name: "sgml",
+			ofx:  ofxSGML,
+			want: []Line{
+				{Date: time.Date(2025, 10, 1, 12, 0, 0, 0, time.UTC), Amount: decimal.RequireFromString("-42.10"), Currency: "USD", Description: "AT&T - Phone bill", Reference: "2025100101"},
+				{Date: date(2025, 10, 2), Amount: decimal.NewFromInt(1500), Currency: "EUR", Description: "Payroll", Reference: "2025100201"},
+			},
+		},
+		{
+			name: "xml",
+			ofx:  ofxXML,
+			want: []Line{
+				{Date: time.Date(2025, 10, 3, 15, 30, 0, 0, time.UTC), Amount: decimal.RequireFromString("-3.20"), Currency: "GBP", Description: "Bakery", Reference: "X-1"},
+			},
+		},
+		{name: "no transactions", ofx: "<OFX><CURDEF>USD</OFX>"},
+		{name: "not ofx", ofx: "date,amount\n", wantErr: true},
+		{name: "unterminated transaction", ofx: "<OFX><STMTTRN><TRNAMT>1<DTPOSTED>20251001", wantErr: true},
+		{name: "invalid amount", ofx: "<OFX><STMTTRN><TRNAMT>one<DTPOSTED>20251001</STMTTRN></OFX>", wantErr: true},
+		{name: "missing date", ofx: "<OFX><STMTTRN><TRNAMT>1</STMTTRN></OFX>", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseOFX(strings.NewReader(tt.ofx))
+			if tt.wantErr {
+				if !errors.Is(err, ErrInvalidStatement) {
+					t.Fatalf("ParseOFX() error = %v, want ErrInvalidStatement", err)
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("ParseOFX() error = %v", err)
+			}
+
+			checkLines(t, got, tt.want)
+		})
+	}
+}
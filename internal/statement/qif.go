@@ -0,0 +1,134 @@
+package statement
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+)
+
+// qifDateLayouts are the QIF date formats. Quicken writes US month-first
+// dates, with an apostrophe before two-digit years after 1999.
+var qifDateLayouts = []string{
+	"1/2/2006",
+	"1/2/06",
+	time.DateOnly,
+	"02.01.2006",
+}
+
+// ParseQIF parses the bank, cash or credit card section of a QIF file.
+// Investment and list sections are ignored. The check number (N) is used as
+// reference.
+func ParseQIF(r io.Reader) ([]Line, error) {
+	var (
+		lines   []Line
+		fields  = make(map[byte]string)
+		inBank  = true
+		started bool
+		no      int
+	)
+
+	flush := func() error {
+		if len(fields) == 0 {
+			return nil
+		}
+		defer clear(fields)
+
+		no++
+		if !inBank {
+			return nil
+		}
+
+		date, err := parseQIFDate(fields['D'])
+		if err != nil {
+			return fmt.Errorf("%w: record %d: %s", ErrInvalidStatement, no, err)
+		}
+
+		raw := fields['T']
+		if raw == "" {
+			raw = fields['U']
+		}
+
+		amount, err := parseAmount(raw)
+		if err != nil {
+			return fmt.Errorf("%w: record %d: %s", ErrInvalidStatement, no, err)
+		}
+
+		lines = append(lines, Line{
+			Date:        date,
+			Amount:      amount,
+			Description: description(fields['P'], fields['M']),
+			Reference:   fields['N'],
+		})
+
+		return nil
+	}
+
+	sc := bufio.NewScanner(r)
+	for sc.Scan() {
+		text := strings.TrimRight(sc.Text(), "\r")
+		if strings.TrimSpace(text) == "" {
+			continue
+		}
+
+		switch {
+		case strings.HasPrefix(text, "!Type:"):
+			if err := flush(); err != nil {
+				return nil, err
+			}
+
+			switch strings.ToLower(strings.TrimSpace(strings.TrimPrefix(text, "!Type:"))) {
+			case "bank", "cash", "ccard", "oth a", "oth l":
+				inBank = true
+			default:
+				inBank = false
+			}
+			started = true
+		case strings.HasPrefix(text, "!"):
+			if err := flush(); err != nil {
+				return nil, err
+			}
+
+			// Option and account list headers, e.g. !Account or !Option:AutoSwitch.
+			inBank = false
+			started = true
+		case text == "^":
+			if err := flush(); err != nil {
+				return nil, err
+			}
+		default:
+			if !started {
+				return nil, fmt.Errorf("%w: missing !Type header", ErrInvalidStatement)
+			}
+
+			code := text[0]
+			// Split lines (S, E, $) describe parts of the transaction and are not kept.
+			if _, ok := fields[code]; !ok {
+				fields[code] = strings.TrimSpace(text[1:])
+			}
+		}
+	}
+
+	if err := sc.Err(); err != nil {
+		return nil, fmt.Errorf("read qif: %w", err)
+	}
+
+	if err := flush(); err != nil {
+		return nil, err
+	}
+
+	if !started {
+		return nil, fmt.Errorf("%w: missing !Type header", ErrInvalidStatement)
+	}
+
+	return lines, nil
+}
+
+// parseQIFDate parses a QIF date such as 1/2/2006, 01/02/06 or 1/ 2'06.
+func parseQIFDate(s string) (time.Time, error) {
+	s = strings.ReplaceAll(s, " ", "")
+	s = strings.ReplaceAll(s, "'", "/")
+
+	return parseDate(s, qifDateLayouts)
+}
@@ -0,0 +1,65 @@
+package statement
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/shopspring/decimal"
+)
+
+func TestParseQIF(t *testing.T) {
+	tests := []struct {
+		name    string
+		qif     string
+		want    []Line
+		wantErr bool
+	}{
+		{
+			name: "bank",
+			qif: "!Type:Bank\r\n" +
+				"D10/ 1'25\r\nT-1,234.56\r\nPLandlord\r\nMOctober rent\r\nN1001\r\n^\r\n" +
+				"D10/2/2025\r\nU250.00\r\nPRefund\r\n^\r\n",
+			want: []Line{
+				{Date: date(2025, 10, 1), Amount: decimal.RequireFromString("-1234.56"), Description: "Landlord - October rent", Reference: "1001"},
+				{Date: date(2025, 10, 2), Amount: decimal.NewFromInt(250), Description: "Refund"},
+			},
+		},
+		{
+			name: "splits keep the transaction total",
+			qif: "!Type:CCard\n" +
+				"D2025-10-03\nT-30\nPGrocer\nSFood\n$-20\nSHome\n$-10\n^\n",
+			want: []Line{{Date: date(2025, 10, 3), Amount: decimal.NewFromInt(-30), Description: "Grocer"}},
+		},
+		{
+			name: "other sections are skipped",
+			qif: "!Option:AutoSwitch\n!Account\nNChecking\nTBank\n^\n" +
+				"!Type:Invst\nD10/4/2025\nT100\n^\n" +
+				"!Type:Cash\nD04.10.2025\nT-2,50\nPKiosk\n",
+			want: []Line{{Date: date(2025, 10, 4), Amount: decimal.RequireFromString("-2.50"), Description: "Kiosk"}},
+		},
+		{name: "empty bank section", qif: "!Type:Bank\n"},
+		{name: "missing header", qif: "D10/1/2025\nT1\n^\n", wantErr: true},
+		{name: "empty", qif: "", wantErr: true},
+		{name: "invalid date", qif: "!Type:Bank\nD2025/31/10\nT1\n^\n", wantErr: true},
+		{name: "missing amount", qif: "!Type:Bank\nD10/1/2025\nPShop\n^\n", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseQIF(strings.NewReader(tt.qif))
+			if tt.wantErr {
+				if !errors.Is(err, ErrInvalidStatement) {
+					t.Fatalf("ParseQIF() error = %v, want ErrInvalidStatement", err)
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("ParseQIF() error = %v", err)
+			}
+
+			checkLines(t, got, tt.want)
+		})
+	}
+}
@@ -0,0 +1,78 @@
+// Package statement parses bank statement files into statement lines.
+package statement
+
+import (
+	"errors"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+// Statement formats.
+const (
+	FormatCSV     = "csv"
+	FormatOFX     = "ofx"
+	FormatQIF     = "qif"
+	FormatCAMT053 = "camt053"
+)
+
+var (
+	ErrInvalidStatement  = errors.New("invalid statement")
+	ErrUnsupportedFormat = errors.New("unsupported statement format, expected csv, ofx, qif or camt053")
+)
+
+// Line is a single transaction of a bank statement.
+//
+// Amount is signed: negative amounts are debits (money leaving the account),
+// positive amounts are credits. Reference is the bank's transaction
+// identifier, e.g. the OFX FITID, and may be empty.
+type Line struct {
+	Date        time.Time       `json:"date"`
+	Amount      decimal.Decimal `json:"amount"`
+	Currency    string          `json:"currency,omitempty"`
+	Description string          `json:"description"`
+	Reference   string          `json:"reference,omitempty"`
+}
+
+// Parse parses a statement in the given format.
+func Parse(format string, r io.Reader) ([]Line, error) {
+	switch format {
+	case FormatCSV:
+		return ParseCSV(r)
+	case FormatOFX:
+		return ParseOFX(r)
+	case FormatQIF:
+		return ParseQIF(r)
+	case FormatCAMT053:
+		return ParseCAMT053(r)
+	default:
+		return nil, ErrUnsupportedFormat
+	}
+}
+
+// description joins non-empty, distinct parts with " - ".
+func description(parts ...string) string {
+	var out []string
+	for _, p := range parts {
+		p = strings.Join(strings.Fields(p), " ")
+		if p == "" {
+			continue
+		}
+
+		duplicate := false
+		for _, o := range out {
+			if strings.EqualFold(o, p) {
+				duplicate = true
+				break
+			}
+		}
+
+		if !duplicate {
+			out = append(out, p)
+		}
+	}
+
+	return strings.Join(out, " - ")
+}
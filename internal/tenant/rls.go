@@ -0,0 +1,127 @@
+package tenant
+
+import (
+	"context"
+	"database/sql/driver"
+	"fmt"
+
+	"github.com/aliskhannn/sales-tracker/internal/database"
+)
+
+// noWorkspace is the setting of connections used without a workspace; it
+// matches no workspace, so such queries see no tenant rows.
+const noWorkspace = "none"
+
+// Connector wraps c so that every connection sets app.workspace_id to the
+// workspace of the context it is used with, which the row-level security
+// policies of the tenant tables check. It is a database.Wrapper.
+func Connector(c driver.Connector) driver.Connector {
+	return &connector{Connector: c}
+}
+
+// connector creates workspace-aware connections.
+type connector struct {
+	driver.Connector
+}
+
+// Connect implements driver.Connector.
+func (c *connector) Connect(ctx context.Context) (driver.Conn, error) {
+	cn, err := c.Connector.Connect(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	dc, ok := cn.(database.Conn)
+	if !ok {
+		_ = cn.Close()
+		return nil, fmt.Errorf("unexpected driver connection %T", cn)
+	}
+
+	return &conn{Conn: dc}, nil
+}
+
+// conn sets app.workspace_id before statements whose context acts in a
+// different workspace than the connection's current setting.
+type conn struct {
+	database.Conn
+
+	workspace string // current app.workspace_id, valid if known
+	known     bool
+}
+
+// apply makes the connection's app.workspace_id match the workspace of ctx.
+func (c *conn) apply(ctx context.Context) error {
+	want := noWorkspace
+	if id, ok := FromContext(ctx); ok {
+		want = id.String()
+	}
+
+	if c.known && c.workspace == want {
+		return nil
+	}
+
+	args := []driver.NamedValue{{Ordinal: 1, Value: want}}
+	if _, err := c.Conn.ExecContext(ctx, "SELECT set_config('app.workspace_id', $1, false)", args); err != nil {
+		c.known = false
+		return fmt.Errorf("set workspace: %w", err)
+	}
+
+	c.workspace, c.known = want, true
+	return nil
+}
+
+// QueryContext implements driver.QueryerContext.
+func (c *conn) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	if err := c.apply(ctx); err != nil {
+		return nil, err
+	}
+
+	return c.Conn.QueryContext(ctx, query, args)
+}
+
+// ExecContext implements driver.ExecerContext.
+func (c *conn) ExecContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	if err := c.apply(ctx); err != nil {
+		return nil, err
+	}
+
+	return c.Conn.ExecContext(ctx, query, args)
+}
+
+// PrepareContext implements driver.ConnPrepareContext. The workspace is set
+// when the statement is prepared.
+func (c *conn) PrepareContext(ctx context.Context, query string) (driver.Stmt, error) {
+	if err := c.apply(ctx); err != nil {
+		return nil, err
+	}
+
+	return c.Conn.PrepareContext(ctx, query)
+}
+
+// BeginTx implements driver.ConnBeginTx. The workspace is set before the
+// transaction starts, so a rollback does not undo it.
+func (c *conn) BeginTx(ctx context.Context, opts driver.TxOptions) (driver.Tx, error) {
+	if err := c.apply(ctx); err != nil {
+		return nil, err
+	}
+
+	tx, err := c.Conn.BeginTx(ctx, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	return &rlsTx{Tx: tx, conn: c}, nil
+}
+
+// rlsTx forgets the connection's setting on rollback, which undoes a
+// set_config made inside the transaction.
+type rlsTx struct {
+	driver.Tx
+	conn *conn
+}
+
+// Rollback implements driver.Tx.
+func (t *rlsTx) Rollback() error {
+	t.conn.known = false
+	return t.Tx.Rollback()
+}
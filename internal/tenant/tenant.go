@@ -0,0 +1,32 @@
+// Package tenant carries the workspace a request acts in through contexts.
+// Repositories scope every query to it, and with row-level security enabled
+// database connections carry it to PostgreSQL as well.
+package tenant
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+)
+
+// workspaceKey is the context key the workspace ID is stored under.
+type workspaceKey struct{}
+
+// WithWorkspace returns a copy of ctx that acts in workspace id.
+func WithWorkspace(ctx context.Context, id uuid.UUID) context.Context {
+	return context.WithValue(ctx, workspaceKey{}, id)
+}
+
+// FromContext returns the workspace of ctx and whether there is one.
+func FromContext(ctx context.Context) (uuid.UUID, bool) {
+	id, ok := ctx.Value(workspaceKey{}).(uuid.UUID)
+	return id, ok && id != uuid.Nil
+}
+
+// ID returns the workspace of ctx, or uuid.Nil if there is none. Queries
+// scoped to uuid.Nil match no rows and inserts fail, so a missing workspace
+// never exposes data of another one.
+func ID(ctx context.Context) uuid.UUID {
+	id, _ := FromContext(ctx)
+	return id
+}
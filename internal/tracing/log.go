@@ -0,0 +1,28 @@
+package tracing
+
+import (
+	"github.com/rs/zerolog"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// LogHook adds the trace_id and span_id of the span in the context of a log
+// event, set with Ctx, so that log lines can be joined with traces:
+//
+//	zlog.Logger = zlog.Logger.Hook(tracing.LogHook{})
+//	zlog.Logger.Error().Ctx(ctx).Err(err).Msg("...")
+type LogHook struct{}
+
+// Run implements zerolog.Hook.
+func (LogHook) Run(e *zerolog.Event, _ zerolog.Level, _ string) {
+	ctx := e.GetCtx()
+	if ctx == nil {
+		return
+	}
+
+	sc := trace.SpanContextFromContext(ctx)
+	if !sc.IsValid() {
+		return
+	}
+
+	e.Str("trace_id", sc.TraceID().String()).Str("span_id", sc.SpanID().String())
+}
@@ -0,0 +1,305 @@
+package tracing
+
+import (
+	"context"
+	"database/sql/driver"
+	"fmt"
+	"io"
+	"reflect"
+	"strings"
+	"unicode"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/aliskhannn/sales-tracker/internal/database"
+)
+
+// scopeName names the instrumentation recording query spans.
+const scopeName = "github.com/aliskhannn/sales-tracker/internal/tracing"
+
+// maxStatementLength bounds the length of the db.statement attribute.
+const maxStatementLength = 2048
+
+// Connector wraps c so that every query and statement executed on its
+// connections is recorded as a client span with the sanitized SQL and the
+// number of rows returned or affected. It is a database.Wrapper.
+func Connector(c driver.Connector) driver.Connector {
+	return &connector{Connector: c}
+}
+
+// connector creates traced connections.
+type connector struct {
+	driver.Connector
+}
+
+// Connect implements driver.Connector.
+func (c *connector) Connect(ctx context.Context) (driver.Conn, error) {
+	cn, err := c.Connector.Connect(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	dc, ok := cn.(database.Conn)
+	if !ok {
+		_ = cn.Close()
+		return nil, fmt.Errorf("unexpected driver connection %T", cn)
+	}
+
+	return &conn{Conn: dc}, nil
+}
+
+// conn records a span for every query and statement it executes.
+type conn struct {
+	database.Conn
+}
+
+// QueryContext implements driver.QueryerContext. The span ends when the rows
+// are closed, so it covers reading them.
+func (c *conn) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	span, ok := startQuery(ctx, query)
+	if !ok {
+		return c.Conn.QueryContext(ctx, query, args)
+	}
+
+	rows, err := c.Conn.QueryContext(ctx, query, args)
+	if err != nil {
+		if err != driver.ErrSkip {
+			Fail(span, err)
+		}
+		span.End()
+		return nil, err
+	}
+
+	return &tracedRows{Rows: rows, span: span}, nil
+}
+
+// ExecContext implements driver.ExecerContext.
+func (c *conn) ExecContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	span, ok := startQuery(ctx, query)
+	if !ok {
+		return c.Conn.ExecContext(ctx, query, args)
+	}
+	defer span.End()
+
+	res, err := c.Conn.ExecContext(ctx, query, args)
+	if err != nil {
+		if err != driver.ErrSkip {
+			Fail(span, err)
+		}
+		return nil, err
+	}
+
+	if n, err := res.RowsAffected(); err == nil {
+		span.SetAttributes(attribute.Int64("db.rows_affected", n))
+	}
+
+	return res, nil
+}
+
+// startQuery starts a client span for query if ctx is part of a recorded
+// trace and reports whether it did. Queries of background work outside any
+// trace, e.g. polling, are not recorded, since each would start a trace of
+// its own.
+func startQuery(ctx context.Context, query string) (trace.Span, bool) {
+	if !trace.SpanFromContext(ctx).IsRecording() {
+		return nil, false
+	}
+
+	statement := SanitizeSQL(query)
+	operation := statement
+	if i := strings.IndexByte(operation, ' '); i > 0 {
+		operation = operation[:i]
+	}
+	operation = strings.ToUpper(operation)
+
+	_, span := otel.Tracer(scopeName).Start(ctx, operation,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			attribute.String("db.system", "postgresql"),
+			attribute.String("db.operation", operation),
+			attribute.String("db.statement", statement),
+		),
+	)
+
+	return span, true
+}
+
+// tracedRows counts the rows read and ends the span of its query when closed.
+type tracedRows struct {
+	driver.Rows
+	span trace.Span
+	n    int64
+}
+
+// Next implements driver.Rows.
+func (r *tracedRows) Next(dest []driver.Value) error {
+	err := r.Rows.Next(dest)
+	switch {
+	case err == nil:
+		r.n++
+	case err != io.EOF:
+		Fail(r.span, err)
+	}
+
+	return err
+}
+
+// Close implements driver.Rows.
+func (r *tracedRows) Close() error {
+	err := r.Rows.Close()
+
+	r.span.SetAttributes(attribute.Int64("db.response.returned_rows", r.n))
+	r.span.End()
+
+	return err
+}
+
+// ColumnTypeScanType implements driver.RowsColumnTypeScanType.
+func (r *tracedRows) ColumnTypeScanType(index int) reflect.Type {
+	if rs, ok := r.Rows.(driver.RowsColumnTypeScanType); ok {
+		return rs.ColumnTypeScanType(index)
+	}
+
+	return reflect.TypeFor[any]()
+}
+
+// ColumnTypeDatabaseTypeName implements driver.RowsColumnTypeDatabaseTypeName.
+func (r *tracedRows) ColumnTypeDatabaseTypeName(index int) string {
+	if rs, ok := r.Rows.(driver.RowsColumnTypeDatabaseTypeName); ok {
+		return rs.ColumnTypeDatabaseTypeName(index)
+	}
+
+	return ""
+}
+
+// ColumnTypeLength implements driver.RowsColumnTypeLength.
+func (r *tracedRows) ColumnTypeLength(index int) (int64, bool) {
+	if rs, ok := r.Rows.(driver.RowsColumnTypeLength); ok {
+		return rs.ColumnTypeLength(index)
+	}
+
+	return 0, false
+}
+
+// ColumnTypePrecisionScale implements driver.RowsColumnTypePrecisionScale.
+func (r *tracedRows) ColumnTypePrecisionScale(index int) (int64, int64, bool) {
+	if rs, ok := r.Rows.(driver.RowsColumnTypePrecisionScale); ok {
+		return rs.ColumnTypePrecisionScale(index)
+	}
+
+	return 0, 0, false
+}
+
+// SanitizeSQL returns query with string and numeric literals replaced by ?
+// and whitespace and comments collapsed, so that spans carry the shape of a
+// statement but none of the values it was written with. Placeholders such as
+// $1 and identifiers are kept.
+func SanitizeSQL(query string) string {
+	var (
+		b     strings.Builder
+		space bool
+	)
+
+	b.Grow(len(query))
+
+	write := func(s string) {
+		if space && b.Len() > 0 {
+			b.WriteByte(' ')
+		}
+		space = false
+		b.WriteString(s)
+	}
+
+	for i := 0; i < len(query); {
+		c := query[i]
+
+		switch {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r':
+			space = true
+			i++
+
+		case c == '-' && i+1 < len(query) && query[i+1] == '-':
+			// Line comment.
+			for i < len(query) && query[i] != '\n' {
+				i++
+			}
+			space = true
+
+		case c == '/' && i+1 < len(query) && query[i+1] == '*':
+			// Block comment.
+			end := strings.Index(query[i+2:], "*/")
+			if end < 0 {
+				i = len(query)
+			} else {
+				i += end + 4
+			}
+			space = true
+
+		case c == '\'':
+			// String literal, with '' as an escaped quote.
+			i++
+			for i < len(query) {
+				if query[i] == '\'' {
+					if i+1 < len(query) && query[i+1] == '\'' {
+						i += 2
+						continue
+					}
+					break
+				}
+				i++
+			}
+			i++
+			write("?")
+
+		case c == '$' && i+1 < len(query) && isDigit(query[i+1]):
+			// Placeholder.
+			j := i + 1
+			for j < len(query) && isDigit(query[j]) {
+				j++
+			}
+			write(query[i:j])
+			i = j
+
+		case isDigit(c):
+			// Numeric literal.
+			j := i
+			for j < len(query) && (isDigit(query[j]) || query[j] == '.' || query[j] == 'e' || query[j] == 'E') {
+				j++
+			}
+			write("?")
+			i = j
+
+		case isIdentStart(c):
+			// Identifiers and keywords, which may contain digits.
+			j := i
+			for j < len(query) && (isIdentStart(query[j]) || isDigit(query[j]) || query[j] == '$') {
+				j++
+			}
+			write(query[i:j])
+			i = j
+
+		default:
+			write(query[i : i+1])
+			i++
+		}
+	}
+
+	out := b.String()
+	if len(out) > maxStatementLength {
+		out = strings.ToValidUTF8(out[:maxStatementLength], "")
+	}
+
+	return out
+}
+
+// isDigit reports whether c is an ASCII digit.
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
+
+// isIdentStart reports whether c may start an identifier.
+func isIdentStart(c byte) bool {
+	return c == '_' || c >= 0x80 || unicode.IsLetter(rune(c))
+}
@@ -0,0 +1,115 @@
+// Package tracing sets up OpenTelemetry tracing: spans of requests, service
+// calls and database queries are exported in the OpenTelemetry protocol (OTLP)
+// or to stdout. Trace context is propagated with the W3C traceparent header,
+// so traces join those of callers and collectors such as the OpenTelemetry
+// Collector, Jaeger or Tempo accept them.
+//
+// Spans are started with tracers of the global provider, otel.Tracer. Until
+// Init is called, or if tracing is disabled, they record nothing.
+package tracing
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.37.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Exporters supported by Init.
+const (
+	ExporterStdout = "stdout"
+	ExporterOTLP   = "otlp"
+)
+
+// Defaults used when an option is not configured.
+const (
+	defaultServiceName = "sales-tracker"
+	defaultTimeout     = 10 * time.Second
+)
+
+// otlpTracesPath is the path OTLP/HTTP receivers accept traces on.
+const otlpTracesPath = "/v1/traces"
+
+// Options configures tracing.
+type Options struct {
+	Exporter    string        // ExporterStdout or ExporterOTLP
+	Endpoint    string        // base URL of the OTLP/HTTP receiver, e.g. http://localhost:4318
+	ServiceName string        // service.name resource attribute
+	SampleRatio float64       // share of new traces recorded (0.0–1.0); callers' sampling decisions are kept
+	Timeout     time.Duration // timeout of a single export
+}
+
+// Init installs a global tracer provider exporting spans as configured by
+// opts and the W3C Trace Context propagator. The returned function flushes
+// the spans not yet exported and stops tracing.
+func Init(opts Options) (func(context.Context) error, error) {
+	if opts.ServiceName == "" {
+		opts.ServiceName = defaultServiceName
+	}
+	if opts.Timeout <= 0 {
+		opts.Timeout = defaultTimeout
+	}
+
+	exp, err := newExporter(opts)
+	if err != nil {
+		return nil, err
+	}
+
+	res, err := resource.Merge(resource.Default(), resource.NewSchemaless(semconv.ServiceName(opts.ServiceName)))
+	if err != nil {
+		return nil, fmt.Errorf("tracing: build resource: %w", err)
+	}
+
+	tp := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exp, sdktrace.WithExportTimeout(opts.Timeout)),
+		sdktrace.WithResource(res),
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(min(max(opts.SampleRatio, 0), 1)))),
+	)
+
+	otel.SetTracerProvider(tp)
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+
+	return tp.Shutdown, nil
+}
+
+// newExporter creates the span exporter selected by opts.
+func newExporter(opts Options) (sdktrace.SpanExporter, error) {
+	switch opts.Exporter {
+	case ExporterStdout:
+		return stdouttrace.New()
+	case ExporterOTLP:
+		if opts.Endpoint == "" {
+			return nil, errors.New("tracing: OTLP exporter needs an endpoint")
+		}
+
+		// The client only connects when the first batch is exported.
+		return otlptracehttp.New(context.Background(),
+			otlptracehttp.WithEndpointURL(strings.TrimSuffix(opts.Endpoint, "/")+otlpTracesPath),
+			otlptracehttp.WithTimeout(opts.Timeout),
+		)
+	default:
+		return nil, fmt.Errorf("tracing: unknown exporter %q", opts.Exporter)
+	}
+}
+
+// Fail records err on span and marks the span as failed. A nil err is
+// ignored.
+func Fail(span trace.Span, err error) {
+	if err == nil {
+		return
+	}
+
+	span.RecordError(err)
+	span.SetStatus(codes.Error, err.Error())
+}
@@ -0,0 +1,157 @@
+package tracing
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/rs/zerolog"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func TestInitRejectsInvalidOptions(t *testing.T) {
+	tests := []struct {
+		name string
+		opts Options
+	}{
+		{"unknown exporter", Options{Exporter: "zipkin"}},
+		{"no exporter", Options{}},
+		{"otlp without endpoint", Options{Exporter: ExporterOTLP}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := Init(tt.opts); err == nil {
+				t.Fatalf("Init(%+v) error = nil, want an error", tt.opts)
+			}
+		})
+	}
+}
+
+func TestInitExportsOTLP(t *testing.T) {
+	var (
+		mu      sync.Mutex
+		path    string
+		ctype   string
+		payload []byte
+	)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+
+		mu.Lock()
+		path, ctype, payload = r.URL.Path, r.Header.Get("Content-Type"), body
+		mu.Unlock()
+
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer srv.Close()
+
+	shutdown, err := Init(Options{
+		Exporter:    ExporterOTLP,
+		Endpoint:    srv.URL + "/",
+		ServiceName: "tracing-test",
+		SampleRatio: 1,
+		Timeout:     time.Second,
+	})
+	if err != nil {
+		t.Fatalf("Init() error = %v", err)
+	}
+
+	// A caller's traceparent is continued by spans started from the extracted
+	// context.
+	const traceparent = "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"
+	ctx := otel.GetTextMapPropagator().Extract(context.Background(),
+		propagation.HeaderCarrier{"Traceparent": []string{traceparent}})
+
+	_, span := otel.Tracer("test").Start(ctx, "analytics.Sum")
+	sc := span.SpanContext()
+	span.End()
+
+	if got := sc.TraceID().String(); got != "4bf92f3577b34da6a3ce929d0e0e4736" {
+		t.Errorf("trace ID = %s, want the caller's", got)
+	}
+	if !sc.IsSampled() {
+		t.Error("span of a sampled caller is not sampled")
+	}
+
+	if err = shutdown(context.Background()); err != nil {
+		t.Fatalf("shutdown() error = %v", err)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+
+	if path != otlpTracesPath {
+		t.Errorf("exported to %q, want %q", path, otlpTracesPath)
+	}
+	if ctype != "application/x-protobuf" {
+		t.Errorf("content type = %q, want application/x-protobuf", ctype)
+	}
+	for _, want := range []string{"tracing-test", "analytics.Sum"} {
+		if !bytes.Contains(payload, []byte(want)) {
+			t.Errorf("payload does not contain %q", want)
+		}
+	}
+}
+
+func TestLogHook(t *testing.T) {
+	traceID, _ := trace.TraceIDFromHex("4bf92f3577b34da6a3ce929d0e0e4736")
+	spanID, _ := trace.SpanIDFromHex("00f067aa0ba902b7")
+	traced := trace.ContextWithSpanContext(context.Background(), trace.NewSpanContext(trace.SpanContextConfig{
+		TraceID: traceID,
+		SpanID:  spanID,
+	}))
+
+	tests := []struct {
+		name string
+		ctx  context.Context
+		want string
+	}{
+		{"traced", traced, `"trace_id":"4bf92f3577b34da6a3ce929d0e0e4736","span_id":"00f067aa0ba902b7"`},
+		{"untraced", context.Background(), ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			logger := zerolog.New(&buf).Hook(LogHook{})
+			logger.Info().Ctx(tt.ctx).Msg("hello")
+
+			got := buf.String()
+			if tt.want != "" && !strings.Contains(got, tt.want) {
+				t.Errorf("log line = %s, want it to contain %s", got, tt.want)
+			}
+			if tt.want == "" && strings.Contains(got, "trace_id") {
+				t.Errorf("log line = %s, want no trace_id", got)
+			}
+		})
+	}
+}
+
+func TestSanitizeSQL(t *testing.T) {
+	tests := []struct {
+		query string
+		want  string
+	}{
+		{"SELECT id FROM items WHERE id = $1", "SELECT id FROM items WHERE id = $1"},
+		{"SELECT *\n\tFROM items\n  WHERE amount > 100.50", "SELECT * FROM items WHERE amount > ?"},
+		{"UPDATE items SET title = 'O''Brien' WHERE id = $2", "UPDATE items SET title = ? WHERE id = $2"},
+		{"SELECT 1 -- trailing comment\nFROM t2", "SELECT ? FROM t2"},
+		{"SELECT /* hint */ count(*) FROM items", "SELECT count(*) FROM items"},
+		{"INSERT INTO t (a, b) VALUES (1e3, 'x')", "INSERT INTO t (a, b) VALUES (?, ?)"},
+	}
+
+	for _, tt := range tests {
+		if got := SanitizeSQL(tt.query); got != tt.want {
+			t.Errorf("SanitizeSQL(%q) = %q, want %q", tt.query, got, tt.want)
+		}
+	}
+}
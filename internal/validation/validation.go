@@ -0,0 +1,215 @@
+// Package validation registers the domain validation rules used by request
+// bodies and turns validation failures into per-field errors.
+package validation
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/shopspring/decimal"
+
+	"github.com/aliskhannn/sales-tracker/internal/model"
+)
+
+// AmountScale is the number of decimal places amounts are stored with (NUMERIC(18, 2)).
+const AmountScale = 2
+
+// MaxAmount is the largest amount that fits into NUMERIC(18, 2).
+var MaxAmount = decimal.RequireFromString("9999999999999999.99")
+
+// Options configures the limits of the registered rules.
+type Options struct {
+	MaxFuture       time.Duration // how far in the future a not_future time may be
+	MaxMetadataSize int           // max size of a json_object field in bytes
+}
+
+// FieldError describes why a single request field is invalid.
+type FieldError struct {
+	Field   string `json:"field"`
+	Rule    string `json:"rule"`
+	Message string `json:"message"`
+}
+
+// Register adds the domain rules to v and makes field errors use JSON field
+// names. The rules are:
+//   - item_kind: one of the item_kind enum values
+//   - iso4217: an active upper-case ISO 4217 currency code
+//   - amount: a positive decimal.Decimal within NUMERIC(18, 2); with a
+//     parameter naming a currency field, e.g. amount=Currency, it also must
+//     not have more decimal places than the currency's minor unit
+//   - not_future: a time.Time at most opts.MaxFuture after now
+//   - json_object: a JSON object of at most opts.MaxMetadataSize bytes
+func Register(v *validator.Validate, opts Options) error {
+	v.RegisterTagNameFunc(func(f reflect.StructField) string {
+		name, _, _ := strings.Cut(f.Tag.Get("json"), ",")
+		if name == "-" {
+			return ""
+		}
+
+		if name == "" {
+			return f.Name
+		}
+
+		return name
+	})
+
+	// Decimals are validated as their string form.
+	v.RegisterCustomTypeFunc(func(f reflect.Value) any {
+		if d, ok := f.Interface().(decimal.Decimal); ok {
+			return d.String()
+		}
+
+		return nil
+	}, decimal.Decimal{})
+
+	rules := map[string]validator.Func{
+		"item_kind":   validateKind,
+		"iso4217":     validateCurrency,
+		"amount":      validateAmount,
+		"not_future":  notFuture(opts.MaxFuture),
+		"json_object": jsonObject(opts.MaxMetadataSize),
+	}
+
+	for tag, fn := range rules {
+		if err := v.RegisterValidation(tag, fn); err != nil {
+			return fmt.Errorf("register %s validation: %w", tag, err)
+		}
+	}
+
+	return nil
+}
+
+// Fields converts an error returned by validator.Struct into field errors.
+func Fields(err error) []FieldError {
+	var verrs validator.ValidationErrors
+	if !errors.As(err, &verrs) {
+		return []FieldError{{Rule: "invalid", Message: err.Error()}}
+	}
+
+	fields := make([]FieldError, 0, len(verrs))
+	for _, fe := range verrs {
+		field := fe.Namespace()
+		if _, rest, ok := strings.Cut(field, "."); ok {
+			field = rest
+		}
+
+		fields = append(fields, FieldError{
+			Field:   field,
+			Rule:    fe.Tag(),
+			Message: message(fe),
+		})
+	}
+
+	return fields
+}
+
+// message returns a human-readable description of a failed rule.
+func message(fe validator.FieldError) string {
+	switch fe.Tag() {
+	case "required":
+		return "is required"
+	case "item_kind":
+		return fmt.Sprintf("must be one of %s", strings.Join(kinds, ", "))
+	case "iso4217":
+		return "must be an upper-case ISO 4217 currency code, e.g. USD"
+	case "amount":
+		return fmt.Sprintf("must be a positive amount of at most %s without more decimal places than the currency allows", MaxAmount)
+	case "not_future":
+		return "is too far in the future"
+	case "json_object":
+		return "must be a JSON object within the size limit"
+	case "min":
+		return fmt.Sprintf("must have at least %s elements", fe.Param())
+	default:
+		return fmt.Sprintf("failed on the %q rule", fe.Tag())
+	}
+}
+
+// kinds are the values of the item_kind enum.
+var kinds = []string{model.KindIncome, model.KindExpense, model.KindTransfer, model.KindRefund}
+
+func validateKind(fl validator.FieldLevel) bool {
+	kind := fl.Field().String()
+	for _, k := range kinds {
+		if kind == k {
+			return true
+		}
+	}
+
+	return false
+}
+
+func validateCurrency(fl validator.FieldLevel) bool {
+	return IsCurrency(fl.Field().String())
+}
+
+func validateAmount(fl validator.FieldLevel) bool {
+	d, err := decimal.NewFromString(fl.Field().String())
+	if err != nil {
+		return false
+	}
+
+	scale := int32(AmountScale)
+	if fl.Param() != "" {
+		currency, _, _, ok := fl.GetStructFieldOKAdvanced2(fl.Parent(), fl.Param())
+		if !ok {
+			return false
+		}
+
+		if units, known := MinorUnits(currency.String()); known && units < scale {
+			scale = units
+		}
+	}
+
+	return CheckAmount(d, scale) == nil
+}
+
+// CheckAmount verifies that amount is positive, fits into NUMERIC(18, 2) and
+// has at most scale decimal places.
+func CheckAmount(amount decimal.Decimal, scale int32) error {
+	if !amount.IsPositive() {
+		return errors.New("amount must be positive")
+	}
+
+	if amount.GreaterThan(MaxAmount) {
+		return fmt.Errorf("amount must not exceed %s", MaxAmount)
+	}
+
+	if !amount.Equal(amount.Truncate(scale)) {
+		return fmt.Errorf("amount must have at most %d decimal places", scale)
+	}
+
+	return nil
+}
+
+func notFuture(maxFuture time.Duration) validator.Func {
+	return func(fl validator.FieldLevel) bool {
+		t, ok := fl.Field().Interface().(time.Time)
+		if !ok {
+			return false
+		}
+
+		return !t.After(time.Now().Add(maxFuture))
+	}
+}
+
+func jsonObject(maxSize int) validator.Func {
+	return func(fl validator.FieldLevel) bool {
+		raw, ok := fl.Field().Interface().(json.RawMessage)
+		if !ok {
+			return false
+		}
+
+		if maxSize > 0 && len(raw) > maxSize {
+			return false
+		}
+
+		var obj map[string]json.RawMessage
+		return json.Unmarshal(raw, &obj) == nil && obj != nil
+	}
+}
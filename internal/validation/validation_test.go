@@ -0,0 +1,236 @@
+package validation
+
+import (
+	"encoding/json"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/shopspring/decimal"
+)
+
+// testOptions are the limits the rules are registered with in the tests.
+var testOptions = Options{MaxFuture: time.Hour, MaxMetadataSize: 32}
+
+// newValidator returns a validator with the domain rules registered.
+func newValidator(t *testing.T) *validator.Validate {
+	t.Helper()
+
+	v := validator.New()
+	if err := Register(v, testOptions); err != nil {
+		t.Fatalf("Register: %v", err)
+	}
+
+	return v
+}
+
+func TestRules(t *testing.T) {
+	type kindBody struct {
+		Kind string `json:"kind" validate:"item_kind"`
+	}
+
+	type currencyBody struct {
+		Currency string `json:"currency" validate:"iso4217"`
+	}
+
+	type amountBody struct {
+		Amount decimal.Decimal `json:"amount" validate:"amount"`
+	}
+
+	type currencyAmountBody struct {
+		Amount   decimal.Decimal `json:"amount" validate:"amount=Currency"`
+		Currency string          `json:"currency"`
+	}
+
+	type timeBody struct {
+		At time.Time `json:"at" validate:"not_future"`
+	}
+
+	type metadataBody struct {
+		Metadata json.RawMessage `json:"metadata" validate:"json_object"`
+	}
+
+	dec := decimal.RequireFromString
+	now := time.Now()
+
+	tests := []struct {
+		name  string
+		body  any
+		valid bool
+	}{
+		{name: "kind income", body: kindBody{Kind: "income"}, valid: true},
+		{name: "kind expense", body: kindBody{Kind: "expense"}, valid: true},
+		{name: "kind transfer", body: kindBody{Kind: "transfer"}, valid: true},
+		{name: "kind refund", body: kindBody{Kind: "refund"}, valid: true},
+		{name: "kind unknown", body: kindBody{Kind: "gift"}},
+		{name: "kind upper case", body: kindBody{Kind: "INCOME"}},
+		{name: "kind empty", body: kindBody{}},
+
+		{name: "currency USD", body: currencyBody{Currency: "USD"}, valid: true},
+		{name: "currency JPY", body: currencyBody{Currency: "JPY"}, valid: true},
+		{name: "currency lower case", body: currencyBody{Currency: "usd"}},
+		{name: "currency unknown", body: currencyBody{Currency: "ABC"}},
+		{name: "currency withdrawn", body: currencyBody{Currency: "DEM"}},
+		{name: "currency empty", body: currencyBody{}},
+
+		{name: "amount cents", body: amountBody{Amount: dec("12.34")}, valid: true},
+		{name: "amount whole", body: amountBody{Amount: dec("100")}, valid: true},
+		{name: "amount max", body: amountBody{Amount: MaxAmount}, valid: true},
+		{name: "amount zero", body: amountBody{Amount: dec("0")}},
+		{name: "amount negative", body: amountBody{Amount: dec("-1")}},
+		{name: "amount over max", body: amountBody{Amount: MaxAmount.Add(dec("0.01"))}},
+		{name: "amount three places", body: amountBody{Amount: dec("1.234")}},
+
+		{name: "amount JPY whole", body: currencyAmountBody{Amount: dec("100"), Currency: "JPY"}, valid: true},
+		{name: "amount JPY fraction", body: currencyAmountBody{Amount: dec("100.5"), Currency: "JPY"}},
+		{name: "amount USD cents", body: currencyAmountBody{Amount: dec("1.25"), Currency: "USD"}, valid: true},
+		{name: "amount USD three places", body: currencyAmountBody{Amount: dec("1.255"), Currency: "USD"}},
+		// Amounts are stored with two places even for three-place currencies.
+		{name: "amount BHD three places", body: currencyAmountBody{Amount: dec("1.255"), Currency: "BHD"}},
+		{name: "amount BHD cents", body: currencyAmountBody{Amount: dec("1.25"), Currency: "BHD"}, valid: true},
+		{name: "amount unknown currency", body: currencyAmountBody{Amount: dec("1.25"), Currency: "ABC"}, valid: true},
+
+		{name: "time past", body: timeBody{At: now.Add(-24 * time.Hour)}, valid: true},
+		{name: "time now", body: timeBody{At: now}, valid: true},
+		{name: "time within max future", body: timeBody{At: now.Add(30 * time.Minute)}, valid: true},
+		{name: "time beyond max future", body: timeBody{At: now.Add(2 * time.Hour)}},
+
+		{name: "metadata object", body: metadataBody{Metadata: json.RawMessage(`{"a":1}`)}, valid: true},
+		{name: "metadata empty object", body: metadataBody{Metadata: json.RawMessage(`{}`)}, valid: true},
+		{name: "metadata array", body: metadataBody{Metadata: json.RawMessage(`[1]`)}},
+		{name: "metadata string", body: metadataBody{Metadata: json.RawMessage(`"a"`)}},
+		{name: "metadata null", body: metadataBody{Metadata: json.RawMessage(`null`)}},
+		{name: "metadata malformed", body: metadataBody{Metadata: json.RawMessage(`{"a":`)}},
+		{name: "metadata too large", body: metadataBody{Metadata: json.RawMessage(`{"key":"` + strings.Repeat("a", 32) + `"}`)}},
+	}
+
+	v := newValidator(t)
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := v.Struct(tt.body)
+			if tt.valid && err != nil {
+				t.Fatalf("Struct(%+v) = %v, want nil", tt.body, err)
+			}
+
+			if !tt.valid && err == nil {
+				t.Fatalf("Struct(%+v) = nil, want error", tt.body)
+			}
+		})
+	}
+}
+
+func TestCheckAmount(t *testing.T) {
+	tests := []struct {
+		amount  string
+		scale   int32
+		wantErr bool
+	}{
+		{amount: "0.01", scale: 2},
+		{amount: "5", scale: 0},
+		{amount: "5.1", scale: 0, wantErr: true},
+		{amount: "0.001", scale: 2, wantErr: true},
+		{amount: "0", scale: 2, wantErr: true},
+		{amount: "-0.01", scale: 2, wantErr: true},
+		{amount: "10000000000000000", scale: 2, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.amount, func(t *testing.T) {
+			err := CheckAmount(decimal.RequireFromString(tt.amount), tt.scale)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("CheckAmount(%s, %d) = %v, wantErr %t", tt.amount, tt.scale, err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestFields(t *testing.T) {
+	type line struct {
+		Amount   decimal.Decimal `json:"amount" validate:"amount=Currency"`
+		Currency string          `json:"currency" validate:"iso4217"`
+		Internal string          `json:"-" validate:"required"`
+	}
+
+	type body struct {
+		Kind  string `json:"kind,omitempty" validate:"required,item_kind"`
+		Lines []line `json:"lines" validate:"min=1,dive"`
+		Note  string `validate:"required"`
+	}
+
+	v := newValidator(t)
+
+	tests := []struct {
+		name string
+		body body
+		want []FieldError
+	}{
+		{
+			name: "missing fields",
+			body: body{},
+			want: []FieldError{
+				{Field: "kind", Rule: "required", Message: "is required"},
+				{Field: "lines", Rule: "min", Message: "must have at least 1 elements"},
+				{Field: "Note", Rule: "required", Message: "is required"},
+			},
+		},
+		{
+			name: "nested fields",
+			body: body{
+				Kind:  "gift",
+				Lines: []line{{Amount: decimal.RequireFromString("1.5"), Currency: "JPY", Internal: "x"}, {Amount: decimal.RequireFromString("1"), Currency: "usd", Internal: "x"}},
+				Note:  "n",
+			},
+			want: []FieldError{
+				{Field: "kind", Rule: "item_kind", Message: "must be one of income, expense, transfer, refund"},
+				{Field: "lines[0].amount", Rule: "amount", Message: "must be a positive amount of at most 9999999999999999.99 without more decimal places than the currency allows"},
+				{Field: "lines[1].currency", Rule: "iso4217", Message: "must be an upper-case ISO 4217 currency code, e.g. USD"},
+			},
+		},
+		{
+			name: "field without json name",
+			body: body{Kind: "income", Lines: []line{{Amount: decimal.RequireFromString("1"), Currency: "USD"}}, Note: "n"},
+			want: []FieldError{
+				{Field: "lines[0].Internal", Rule: "required", Message: "is required"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := Fields(v.Struct(tt.body))
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Fields() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+
+	t.Run("not a validation error", func(t *testing.T) {
+		got := Fields(errors.New("unexpected EOF"))
+		want := []FieldError{{Rule: "invalid", Message: "unexpected EOF"}}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("Fields() = %+v, want %+v", got, want)
+		}
+	})
+
+	t.Run("rule messages", func(t *testing.T) {
+		type all struct {
+			At       time.Time       `json:"at" validate:"not_future"`
+			Metadata json.RawMessage `json:"metadata" validate:"json_object"`
+			Name     string          `json:"name" validate:"max=1"`
+		}
+
+		got := Fields(v.Struct(all{At: time.Now().Add(2 * time.Hour), Metadata: json.RawMessage(`[]`), Name: "ab"}))
+		want := []FieldError{
+			{Field: "at", Rule: "not_future", Message: "is too far in the future"},
+			{Field: "metadata", Rule: "json_object", Message: "must be a JSON object within the size limit"},
+			{Field: "name", Rule: "max", Message: `failed on the "max" rule`},
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("Fields() = %+v, want %+v", got, want)
+		}
+	})
+}
@@ -0,0 +1,48 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE IF NOT EXISTS recurring_items
+(
+    id           UUID PRIMARY KEY        DEFAULT gen_random_uuid(),
+    kind         item_kind      NOT NULL,                     -- kind of the materialized items
+    title        TEXT           NOT NULL,
+    amount       NUMERIC(18, 2) NOT NULL CHECK (amount >= 0),
+    currency     VARCHAR(3)     NOT NULL DEFAULT 'USD',
+    category_id  UUID           REFERENCES categories (id) ON DELETE SET NULL,
+    metadata     JSONB                   DEFAULT '{}'::jsonb,
+    rule         TEXT           NOT NULL,                     -- RRULE subset, e.g. "FREQ=MONTHLY;INTERVAL=1"
+    start_at     TIMESTAMPTZ    NOT NULL,                     -- first occurrence
+    end_at       TIMESTAMPTZ,                                 -- optional last possible occurrence
+    paused       BOOLEAN        NOT NULL DEFAULT false,
+    next_index   INTEGER        NOT NULL DEFAULT 0,           -- index of the next occurrence to materialize
+    next_run_at  TIMESTAMPTZ,                                 -- NULL when the schedule is exhausted
+    created_at   TIMESTAMPTZ    NOT NULL DEFAULT now(),
+    updated_at   TIMESTAMPTZ    NOT NULL DEFAULT now()
+);
+
+-- Every handled occurrence is recorded here, which makes materialization idempotent.
+CREATE TABLE IF NOT EXISTS recurring_item_occurrences
+(
+    recurring_item_id UUID        NOT NULL REFERENCES recurring_items (id) ON DELETE CASCADE,
+    occurrence_at     TIMESTAMPTZ NOT NULL,
+    status            TEXT        NOT NULL CHECK (status IN ('pending', 'created', 'skipped')),
+    item_id           UUID        REFERENCES items (id) ON DELETE SET NULL,
+    created_at        TIMESTAMPTZ NOT NULL DEFAULT now(),
+    PRIMARY KEY (recurring_item_id, occurrence_at)
+);
+
+CREATE INDEX IF NOT EXISTS idx_recurring_items_due ON recurring_items (next_run_at) WHERE NOT paused;
+
+CREATE TRIGGER trg_recurring_items_updated_at
+    BEFORE UPDATE
+    ON recurring_items
+    FOR EACH ROW
+EXECUTE FUNCTION trg_set_updated_at();
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TRIGGER IF EXISTS trg_recurring_items_updated_at ON recurring_items;
+DROP INDEX IF EXISTS idx_recurring_items_due;
+DROP TABLE IF EXISTS recurring_item_occurrences;
+DROP TABLE IF EXISTS recurring_items;
+-- +goose StatementEnd
@@ -0,0 +1,24 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Splits attribute parts of a single item amount to different categories.
+-- When an item has splits, their amounts sum up to the item amount.
+CREATE TABLE IF NOT EXISTS item_splits
+(
+    id          UUID PRIMARY KEY        DEFAULT gen_random_uuid(),
+    item_id     UUID           NOT NULL REFERENCES items (id) ON DELETE CASCADE,
+    category_id UUID           REFERENCES categories (id) ON DELETE SET NULL,
+    amount      NUMERIC(18, 2) NOT NULL CHECK (amount > 0),
+    note        TEXT,
+    created_at  TIMESTAMPTZ    NOT NULL DEFAULT now()
+);
+
+CREATE INDEX IF NOT EXISTS idx_item_splits_item ON item_splits (item_id);
+CREATE INDEX IF NOT EXISTS idx_item_splits_category ON item_splits (category_id);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS idx_item_splits_category;
+DROP INDEX IF EXISTS idx_item_splits_item;
+DROP TABLE IF EXISTS item_splits;
+-- +goose StatementEnd
@@ -0,0 +1,42 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE IF NOT EXISTS accounts
+(
+    id              UUID PRIMARY KEY        DEFAULT gen_random_uuid(),
+    name            TEXT           NOT NULL,
+    currency        VARCHAR(3)     NOT NULL DEFAULT 'USD',
+    opening_balance NUMERIC(18, 2) NOT NULL DEFAULT 0,
+    created_at      TIMESTAMPTZ    NOT NULL DEFAULT now(),
+    updated_at      TIMESTAMPTZ    NOT NULL DEFAULT now()
+);
+
+CREATE TRIGGER trg_accounts_updated_at
+    BEFORE UPDATE
+    ON accounts
+    FOR EACH ROW
+EXECUTE FUNCTION trg_set_updated_at();
+
+-- Items optionally belong to an account. Transfers are stored as two linked
+-- items of kind 'transfer': a source leg and a destination leg sharing transfer_id.
+ALTER TABLE items
+    ADD COLUMN IF NOT EXISTS account_id   UUID REFERENCES accounts (id) ON DELETE RESTRICT,
+    ADD COLUMN IF NOT EXISTS transfer_id  UUID,
+    ADD COLUMN IF NOT EXISTS transfer_leg TEXT CHECK (transfer_leg IN ('source', 'destination')),
+    ADD CONSTRAINT chk_items_transfer_leg CHECK ((transfer_id IS NULL) = (transfer_leg IS NULL));
+
+CREATE INDEX IF NOT EXISTS idx_items_account_occurred_at ON items (account_id, occurred_at);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_items_transfer_leg ON items (transfer_id, transfer_leg) WHERE transfer_id IS NOT NULL;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS idx_items_transfer_leg;
+DROP INDEX IF EXISTS idx_items_account_occurred_at;
+ALTER TABLE items
+    DROP CONSTRAINT IF EXISTS chk_items_transfer_leg,
+    DROP COLUMN IF EXISTS transfer_leg,
+    DROP COLUMN IF EXISTS transfer_id,
+    DROP COLUMN IF EXISTS account_id;
+DROP TRIGGER IF EXISTS trg_accounts_updated_at ON accounts;
+DROP TABLE IF EXISTS accounts;
+-- +goose StatementEnd
@@ -0,0 +1,19 @@
+-- +goose Up
+-- +goose StatementBegin
+-- A refund item can reference the item it reverses. Originals with refunds cannot be deleted.
+ALTER TABLE items
+    ADD COLUMN IF NOT EXISTS refund_of UUID REFERENCES items (id) ON DELETE RESTRICT,
+    ADD CONSTRAINT chk_items_refund_of_kind CHECK (refund_of IS NULL OR kind = 'refund'),
+    ADD CONSTRAINT chk_items_refund_of_self CHECK (refund_of IS NULL OR refund_of <> id);
+
+CREATE INDEX IF NOT EXISTS idx_items_refund_of ON items (refund_of) WHERE refund_of IS NOT NULL;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS idx_items_refund_of;
+ALTER TABLE items
+    DROP CONSTRAINT IF EXISTS chk_items_refund_of_self,
+    DROP CONSTRAINT IF EXISTS chk_items_refund_of_kind,
+    DROP COLUMN IF EXISTS refund_of;
+-- +goose StatementEnd
@@ -0,0 +1,15 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Trigram similarity on titles is used to detect near-duplicate items.
+CREATE EXTENSION IF NOT EXISTS pg_trgm;
+
+CREATE INDEX IF NOT EXISTS idx_items_title_trgm ON items USING gin (title gin_trgm_ops);
+CREATE INDEX IF NOT EXISTS idx_items_amount_currency_occurred_at ON items (amount, currency, occurred_at);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS idx_items_amount_currency_occurred_at;
+DROP INDEX IF EXISTS idx_items_title_trgm;
+DROP EXTENSION IF EXISTS pg_trgm;
+-- +goose StatementEnd
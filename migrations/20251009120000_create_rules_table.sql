@@ -0,0 +1,32 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Auto-categorization rules. Enabled rules are evaluated in position order;
+-- conditions and actions are stored as JSON arrays.
+CREATE TABLE IF NOT EXISTS rules
+(
+    id              UUID PRIMARY KEY     DEFAULT gen_random_uuid(),
+    name            TEXT        NOT NULL,
+    position        INTEGER     NOT NULL DEFAULT 0,
+    enabled         BOOLEAN     NOT NULL DEFAULT TRUE,
+    stop_processing BOOLEAN     NOT NULL DEFAULT FALSE,
+    conditions      JSONB       NOT NULL DEFAULT '[]'::jsonb,
+    actions         JSONB       NOT NULL DEFAULT '[]'::jsonb,
+    created_at      TIMESTAMPTZ NOT NULL DEFAULT now(),
+    updated_at      TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE INDEX IF NOT EXISTS idx_rules_position ON rules (position, created_at);
+
+CREATE TRIGGER trg_rules_updated_at
+    BEFORE UPDATE
+    ON rules
+    FOR EACH ROW
+EXECUTE FUNCTION trg_set_updated_at();
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TRIGGER IF EXISTS trg_rules_updated_at ON rules;
+DROP INDEX IF EXISTS idx_rules_position;
+DROP TABLE IF EXISTS rules;
+-- +goose StatementEnd
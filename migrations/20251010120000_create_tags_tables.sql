@@ -0,0 +1,39 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Tags are free-form labels orthogonal to categories. Names are unique case-insensitively.
+CREATE TABLE IF NOT EXISTS tags
+(
+    id          UUID PRIMARY KEY     DEFAULT gen_random_uuid(),
+    name        TEXT        NOT NULL,
+    description TEXT,
+    created_at  TIMESTAMPTZ NOT NULL DEFAULT now(),
+    updated_at  TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_tags_name ON tags (lower(name));
+
+CREATE TRIGGER trg_tags_updated_at
+    BEFORE UPDATE
+    ON tags
+    FOR EACH ROW
+EXECUTE FUNCTION trg_set_updated_at();
+
+CREATE TABLE IF NOT EXISTS item_tags
+(
+    item_id    UUID        NOT NULL REFERENCES items (id) ON DELETE CASCADE,
+    tag_id     UUID        NOT NULL REFERENCES tags (id) ON DELETE CASCADE,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    PRIMARY KEY (item_id, tag_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_item_tags_tag_id ON item_tags (tag_id);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS idx_item_tags_tag_id;
+DROP TABLE IF EXISTS item_tags;
+DROP TRIGGER IF EXISTS trg_tags_updated_at ON tags;
+DROP INDEX IF EXISTS idx_tags_name;
+DROP TABLE IF EXISTS tags;
+-- +goose StatementEnd
@@ -0,0 +1,25 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Files attached to items. Contents are stored outside the database, addressed
+-- by their SHA-256 hash, so identical files are stored once.
+CREATE TABLE IF NOT EXISTS attachments
+(
+    id           UUID PRIMARY KEY     DEFAULT gen_random_uuid(),
+    item_id      UUID        NOT NULL REFERENCES items (id) ON DELETE CASCADE,
+    filename     TEXT        NOT NULL,
+    content_type TEXT        NOT NULL,
+    size         BIGINT      NOT NULL CHECK (size >= 0),
+    sha256       CHAR(64)    NOT NULL,
+    created_at   TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_attachments_item_sha256 ON attachments (item_id, sha256);
+CREATE INDEX IF NOT EXISTS idx_attachments_sha256 ON attachments (sha256);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS idx_attachments_sha256;
+DROP INDEX IF EXISTS idx_attachments_item_sha256;
+DROP TABLE IF EXISTS attachments;
+-- +goose StatementEnd
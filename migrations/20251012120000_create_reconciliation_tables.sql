@@ -0,0 +1,64 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Items matched to a bank statement line are marked as reconciled.
+ALTER TABLE items
+    ADD COLUMN IF NOT EXISTS reconciled_at TIMESTAMPTZ;
+
+CREATE INDEX IF NOT EXISTS idx_items_reconciled_at ON items (reconciled_at);
+
+-- A reconciliation session holds the lines of one uploaded bank statement.
+CREATE TABLE IF NOT EXISTS reconciliation_sessions
+(
+    id         UUID PRIMARY KEY     DEFAULT gen_random_uuid(),
+    account_id UUID REFERENCES accounts (id) ON DELETE SET NULL,
+    format     TEXT        NOT NULL CHECK (format IN ('csv', 'ofx')),
+    filename   TEXT        NOT NULL DEFAULT '',
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE TRIGGER trg_reconciliation_sessions_updated_at
+    BEFORE UPDATE
+    ON reconciliation_sessions
+    FOR EACH ROW
+EXECUTE FUNCTION trg_set_updated_at();
+
+-- Statement lines keep the signed bank amount: negative for debits, positive for credits.
+CREATE TABLE IF NOT EXISTS reconciliation_lines
+(
+    id          UUID PRIMARY KEY        DEFAULT gen_random_uuid(),
+    session_id  UUID           NOT NULL REFERENCES reconciliation_sessions (id) ON DELETE CASCADE,
+    line_no     INTEGER        NOT NULL,
+    occurred_at TIMESTAMPTZ    NOT NULL,
+    amount      NUMERIC(18, 2) NOT NULL,
+    currency    VARCHAR(3)     NOT NULL,
+    description TEXT           NOT NULL DEFAULT '',
+    reference   TEXT           NOT NULL DEFAULT '',
+    status      TEXT           NOT NULL DEFAULT 'unmatched'
+        CHECK (status IN ('unmatched', 'matched', 'confirmed', 'created')),
+    item_id     UUID REFERENCES items (id) ON DELETE SET NULL,
+    created_at  TIMESTAMPTZ    NOT NULL DEFAULT now(),
+    updated_at  TIMESTAMPTZ    NOT NULL DEFAULT now(),
+    UNIQUE (session_id, line_no)
+);
+
+CREATE INDEX IF NOT EXISTS idx_reconciliation_lines_item_id ON reconciliation_lines (item_id) WHERE item_id IS NOT NULL;
+
+CREATE TRIGGER trg_reconciliation_lines_updated_at
+    BEFORE UPDATE
+    ON reconciliation_lines
+    FOR EACH ROW
+EXECUTE FUNCTION trg_set_updated_at();
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TRIGGER IF EXISTS trg_reconciliation_lines_updated_at ON reconciliation_lines;
+DROP INDEX IF EXISTS idx_reconciliation_lines_item_id;
+DROP TABLE IF EXISTS reconciliation_lines;
+DROP TRIGGER IF EXISTS trg_reconciliation_sessions_updated_at ON reconciliation_sessions;
+DROP TABLE IF EXISTS reconciliation_sessions;
+DROP INDEX IF EXISTS idx_items_reconciled_at;
+ALTER TABLE items
+    DROP COLUMN IF EXISTS reconciled_at;
+-- +goose StatementEnd
@@ -0,0 +1,19 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Imported items keep the bank's transaction reference in metadata, which is
+-- looked up to skip entries that were already imported.
+CREATE INDEX IF NOT EXISTS idx_items_bank_reference ON items ((metadata ->> 'bank_reference'));
+
+-- Reconciliation accepts every statement format supported by the import.
+ALTER TABLE reconciliation_sessions
+    DROP CONSTRAINT IF EXISTS reconciliation_sessions_format_check,
+    ADD CONSTRAINT reconciliation_sessions_format_check CHECK (format IN ('csv', 'ofx', 'qif', 'camt053'));
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE reconciliation_sessions
+    DROP CONSTRAINT IF EXISTS reconciliation_sessions_format_check,
+    ADD CONSTRAINT reconciliation_sessions_format_check CHECK (format IN ('csv', 'ofx'));
+DROP INDEX IF EXISTS idx_items_bank_reference;
+-- +goose StatementEnd
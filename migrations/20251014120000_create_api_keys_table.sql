@@ -0,0 +1,34 @@
+-- +goose Up
+-- +goose StatementBegin
+-- API keys authenticate machine clients. Only the SHA-256 hash of a key is stored;
+-- the prefix identifies a key in listings without revealing it.
+CREATE TABLE IF NOT EXISTS api_keys
+(
+    id           UUID PRIMARY KEY     DEFAULT gen_random_uuid(),
+    name         TEXT        NOT NULL,
+    prefix       TEXT        NOT NULL,
+    key_hash     TEXT        NOT NULL,
+    role         TEXT        NOT NULL CHECK (role IN ('viewer', 'editor', 'admin')),
+    scopes       TEXT[]      NOT NULL DEFAULT '{}',
+    expires_at   TIMESTAMPTZ,
+    last_used_at TIMESTAMPTZ,
+    revoked_at   TIMESTAMPTZ,
+    created_at   TIMESTAMPTZ NOT NULL DEFAULT now(),
+    updated_at   TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_api_keys_key_hash ON api_keys (key_hash);
+
+CREATE TRIGGER trg_api_keys_updated_at
+    BEFORE UPDATE
+    ON api_keys
+    FOR EACH ROW
+EXECUTE FUNCTION trg_set_updated_at();
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TRIGGER IF EXISTS trg_api_keys_updated_at ON api_keys;
+DROP INDEX IF EXISTS idx_api_keys_key_hash;
+DROP TABLE IF EXISTS api_keys;
+-- +goose StatementEnd
@@ -0,0 +1,357 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Workspaces isolate the data of tenants (business units). Existing data is moved
+-- into the "default" workspace.
+CREATE TABLE IF NOT EXISTS workspaces
+(
+    id         UUID PRIMARY KEY     DEFAULT gen_random_uuid(),
+    slug       TEXT        NOT NULL CHECK (slug ~ '^[a-z0-9][a-z0-9-]{0,62}$'),
+    name       TEXT        NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_workspaces_slug ON workspaces (slug);
+
+CREATE TRIGGER trg_workspaces_updated_at
+    BEFORE UPDATE
+    ON workspaces
+    FOR EACH ROW
+EXECUTE FUNCTION trg_set_updated_at();
+
+INSERT INTO workspaces (slug, name)
+VALUES ('default', 'Default');
+
+-- Every top-level table belongs to a workspace.
+ALTER TABLE categories
+    ADD COLUMN IF NOT EXISTS workspace_id UUID REFERENCES workspaces (id) ON DELETE RESTRICT;
+ALTER TABLE items
+    ADD COLUMN IF NOT EXISTS workspace_id UUID REFERENCES workspaces (id) ON DELETE RESTRICT;
+ALTER TABLE accounts
+    ADD COLUMN IF NOT EXISTS workspace_id UUID REFERENCES workspaces (id) ON DELETE RESTRICT;
+ALTER TABLE recurring_items
+    ADD COLUMN IF NOT EXISTS workspace_id UUID REFERENCES workspaces (id) ON DELETE RESTRICT;
+ALTER TABLE rules
+    ADD COLUMN IF NOT EXISTS workspace_id UUID REFERENCES workspaces (id) ON DELETE RESTRICT;
+ALTER TABLE tags
+    ADD COLUMN IF NOT EXISTS workspace_id UUID REFERENCES workspaces (id) ON DELETE RESTRICT;
+ALTER TABLE reconciliation_sessions
+    ADD COLUMN IF NOT EXISTS workspace_id UUID REFERENCES workspaces (id) ON DELETE RESTRICT;
+
+UPDATE categories SET workspace_id = (SELECT id FROM workspaces WHERE slug = 'default');
+UPDATE items SET workspace_id = (SELECT id FROM workspaces WHERE slug = 'default');
+UPDATE accounts SET workspace_id = (SELECT id FROM workspaces WHERE slug = 'default');
+UPDATE recurring_items SET workspace_id = (SELECT id FROM workspaces WHERE slug = 'default');
+UPDATE rules SET workspace_id = (SELECT id FROM workspaces WHERE slug = 'default');
+UPDATE tags SET workspace_id = (SELECT id FROM workspaces WHERE slug = 'default');
+UPDATE reconciliation_sessions SET workspace_id = (SELECT id FROM workspaces WHERE slug = 'default');
+
+ALTER TABLE categories ALTER COLUMN workspace_id SET NOT NULL;
+ALTER TABLE items ALTER COLUMN workspace_id SET NOT NULL;
+ALTER TABLE accounts ALTER COLUMN workspace_id SET NOT NULL;
+ALTER TABLE recurring_items ALTER COLUMN workspace_id SET NOT NULL;
+ALTER TABLE rules ALTER COLUMN workspace_id SET NOT NULL;
+ALTER TABLE tags ALTER COLUMN workspace_id SET NOT NULL;
+ALTER TABLE reconciliation_sessions ALTER COLUMN workspace_id SET NOT NULL;
+
+-- API keys may be bound to a workspace; unbound keys may act in any workspace.
+ALTER TABLE api_keys
+    ADD COLUMN IF NOT EXISTS workspace_id UUID REFERENCES workspaces (id) ON DELETE CASCADE;
+
+CREATE INDEX IF NOT EXISTS idx_categories_workspace ON categories (workspace_id, lower(name));
+CREATE INDEX IF NOT EXISTS idx_items_workspace_occurred_at ON items (workspace_id, occurred_at);
+CREATE INDEX IF NOT EXISTS idx_accounts_workspace ON accounts (workspace_id);
+CREATE INDEX IF NOT EXISTS idx_recurring_items_workspace ON recurring_items (workspace_id);
+CREATE INDEX IF NOT EXISTS idx_rules_workspace_position ON rules (workspace_id, position, created_at);
+CREATE INDEX IF NOT EXISTS idx_reconciliation_sessions_workspace ON reconciliation_sessions (workspace_id, created_at);
+
+-- Tag names are unique per workspace.
+DROP INDEX IF EXISTS idx_tags_name;
+CREATE UNIQUE INDEX IF NOT EXISTS idx_tags_workspace_name ON tags (workspace_id, lower(name));
+
+-- References between rows must stay within a workspace. The arguments are pairs
+-- of a referencing column and the referenced table.
+CREATE OR REPLACE FUNCTION trg_check_workspace()
+    RETURNS TRIGGER
+    LANGUAGE plpgsql
+AS
+$$
+DECLARE
+    i   INTEGER := 0;
+    ref UUID;
+    ok  BOOLEAN;
+BEGIN
+    WHILE i < TG_NARGS
+        LOOP
+            EXECUTE format('SELECT ($1).%I', TG_ARGV[i]) INTO ref USING NEW;
+            IF ref IS NOT NULL THEN
+                EXECUTE format('SELECT EXISTS (SELECT 1 FROM %I WHERE id = $1 AND workspace_id = $2)', TG_ARGV[i + 1])
+                    INTO ok USING ref, NEW.workspace_id;
+                IF NOT ok THEN
+                    RAISE EXCEPTION '%.% references a row of another workspace', TG_TABLE_NAME, TG_ARGV[i]
+                        USING ERRCODE = 'foreign_key_violation';
+                END IF;
+            END IF;
+            i := i + 2;
+        END LOOP;
+    RETURN NEW;
+END;
+$$;
+
+-- Same for tables without a workspace_id of their own: the first two arguments
+-- name the column and table of the parent row whose workspace the row belongs to.
+CREATE OR REPLACE FUNCTION trg_check_child_workspace()
+    RETURNS TRIGGER
+    LANGUAGE plpgsql
+AS
+$$
+DECLARE
+    i         INTEGER := 2;
+    parent    UUID;
+    workspace UUID;
+    ref       UUID;
+    ok        BOOLEAN;
+BEGIN
+    EXECUTE format('SELECT ($1).%I', TG_ARGV[0]) INTO parent USING NEW;
+    EXECUTE format('SELECT workspace_id FROM %I WHERE id = $1', TG_ARGV[1]) INTO workspace USING parent;
+
+    WHILE i < TG_NARGS
+        LOOP
+            EXECUTE format('SELECT ($1).%I', TG_ARGV[i]) INTO ref USING NEW;
+            IF ref IS NOT NULL THEN
+                EXECUTE format('SELECT EXISTS (SELECT 1 FROM %I WHERE id = $1 AND workspace_id = $2)', TG_ARGV[i + 1])
+                    INTO ok USING ref, workspace;
+                IF NOT ok THEN
+                    RAISE EXCEPTION '%.% references a row of another workspace', TG_TABLE_NAME, TG_ARGV[i]
+                        USING ERRCODE = 'foreign_key_violation';
+                END IF;
+            END IF;
+            i := i + 2;
+        END LOOP;
+    RETURN NEW;
+END;
+$$;
+
+CREATE TRIGGER trg_categories_workspace
+    BEFORE INSERT OR UPDATE
+    ON categories
+    FOR EACH ROW
+EXECUTE FUNCTION trg_check_workspace('parent_id', 'categories');
+
+CREATE TRIGGER trg_items_workspace
+    BEFORE INSERT OR UPDATE
+    ON items
+    FOR EACH ROW
+EXECUTE FUNCTION trg_check_workspace('category_id', 'categories', 'account_id', 'accounts', 'refund_of', 'items');
+
+CREATE TRIGGER trg_recurring_items_workspace
+    BEFORE INSERT OR UPDATE
+    ON recurring_items
+    FOR EACH ROW
+EXECUTE FUNCTION trg_check_workspace('category_id', 'categories');
+
+CREATE TRIGGER trg_reconciliation_sessions_workspace
+    BEFORE INSERT OR UPDATE
+    ON reconciliation_sessions
+    FOR EACH ROW
+EXECUTE FUNCTION trg_check_workspace('account_id', 'accounts');
+
+CREATE TRIGGER trg_item_splits_workspace
+    BEFORE INSERT OR UPDATE
+    ON item_splits
+    FOR EACH ROW
+EXECUTE FUNCTION trg_check_child_workspace('item_id', 'items', 'category_id', 'categories');
+
+CREATE TRIGGER trg_item_tags_workspace
+    BEFORE INSERT OR UPDATE
+    ON item_tags
+    FOR EACH ROW
+EXECUTE FUNCTION trg_check_child_workspace('item_id', 'items', 'tag_id', 'tags');
+
+CREATE TRIGGER trg_recurring_item_occurrences_workspace
+    BEFORE INSERT OR UPDATE
+    ON recurring_item_occurrences
+    FOR EACH ROW
+EXECUTE FUNCTION trg_check_child_workspace('recurring_item_id', 'recurring_items', 'item_id', 'items');
+
+CREATE TRIGGER trg_reconciliation_lines_workspace
+    BEFORE INSERT OR UPDATE
+    ON reconciliation_lines
+    FOR EACH ROW
+EXECUTE FUNCTION trg_check_child_workspace('session_id', 'reconciliation_sessions', 'item_id', 'items');
+
+-- Row-level security as a second line of defence. The API sets app.workspace_id
+-- on its connections when workspaces.row_level_security is enabled; connections
+-- that never set it (migrations, maintenance) see all rows. Superusers and roles
+-- with BYPASSRLS are not subject to the policies.
+CREATE OR REPLACE FUNCTION workspace_visible(ws UUID)
+    RETURNS BOOLEAN
+    LANGUAGE sql
+    STABLE
+AS
+$$
+SELECT COALESCE(current_setting('app.workspace_id', true), '') IN ('', ws::text)
+$$;
+
+ALTER TABLE categories ENABLE ROW LEVEL SECURITY;
+ALTER TABLE categories FORCE ROW LEVEL SECURITY;
+CREATE POLICY workspace_isolation ON categories
+    USING (workspace_visible(workspace_id)) WITH CHECK (workspace_visible(workspace_id));
+
+ALTER TABLE items ENABLE ROW LEVEL SECURITY;
+ALTER TABLE items FORCE ROW LEVEL SECURITY;
+CREATE POLICY workspace_isolation ON items
+    USING (workspace_visible(workspace_id)) WITH CHECK (workspace_visible(workspace_id));
+
+ALTER TABLE accounts ENABLE ROW LEVEL SECURITY;
+ALTER TABLE accounts FORCE ROW LEVEL SECURITY;
+CREATE POLICY workspace_isolation ON accounts
+    USING (workspace_visible(workspace_id)) WITH CHECK (workspace_visible(workspace_id));
+
+ALTER TABLE recurring_items ENABLE ROW LEVEL SECURITY;
+ALTER TABLE recurring_items FORCE ROW LEVEL SECURITY;
+CREATE POLICY workspace_isolation ON recurring_items
+    USING (workspace_visible(workspace_id)) WITH CHECK (workspace_visible(workspace_id));
+
+ALTER TABLE rules ENABLE ROW LEVEL SECURITY;
+ALTER TABLE rules FORCE ROW LEVEL SECURITY;
+CREATE POLICY workspace_isolation ON rules
+    USING (workspace_visible(workspace_id)) WITH CHECK (workspace_visible(workspace_id));
+
+ALTER TABLE tags ENABLE ROW LEVEL SECURITY;
+ALTER TABLE tags FORCE ROW LEVEL SECURITY;
+CREATE POLICY workspace_isolation ON tags
+    USING (workspace_visible(workspace_id)) WITH CHECK (workspace_visible(workspace_id));
+
+ALTER TABLE reconciliation_sessions ENABLE ROW LEVEL SECURITY;
+ALTER TABLE reconciliation_sessions FORCE ROW LEVEL SECURITY;
+CREATE POLICY workspace_isolation ON reconciliation_sessions
+    USING (workspace_visible(workspace_id)) WITH CHECK (workspace_visible(workspace_id));
+
+-- Child tables are visible through their parent row. Attachments are left out:
+-- their contents are shared by SHA-256 across workspaces, so the reference
+-- check on delete must see every row.
+ALTER TABLE item_splits ENABLE ROW LEVEL SECURITY;
+ALTER TABLE item_splits FORCE ROW LEVEL SECURITY;
+CREATE POLICY workspace_isolation ON item_splits
+    USING (EXISTS (SELECT 1 FROM items i WHERE i.id = item_id));
+
+ALTER TABLE item_tags ENABLE ROW LEVEL SECURITY;
+ALTER TABLE item_tags FORCE ROW LEVEL SECURITY;
+CREATE POLICY workspace_isolation ON item_tags
+    USING (EXISTS (SELECT 1 FROM items i WHERE i.id = item_id));
+
+ALTER TABLE recurring_item_occurrences ENABLE ROW LEVEL SECURITY;
+ALTER TABLE recurring_item_occurrences FORCE ROW LEVEL SECURITY;
+CREATE POLICY workspace_isolation ON recurring_item_occurrences
+    USING (EXISTS (SELECT 1 FROM recurring_items ri WHERE ri.id = recurring_item_id));
+
+ALTER TABLE reconciliation_lines ENABLE ROW LEVEL SECURITY;
+ALTER TABLE reconciliation_lines FORCE ROW LEVEL SECURITY;
+CREATE POLICY workspace_isolation ON reconciliation_lines
+    USING (EXISTS (SELECT 1 FROM reconciliation_sessions s WHERE s.id = session_id));
+
+-- Daily aggregates are computed per workspace.
+DROP INDEX IF EXISTS idx_mv_daily_aggregates_day;
+DROP MATERIALIZED VIEW IF EXISTS mv_daily_aggregates;
+
+CREATE MATERIALIZED VIEW IF NOT EXISTS mv_daily_aggregates AS
+SELECT workspace_id,
+       date_trunc('day', occurred_at) AS day,
+       kind,
+       category_id,
+       count(*)                       AS cnt,
+       sum(amount)                    AS total_amount,
+       avg(amount)                    AS avg_amount
+FROM items
+GROUP BY workspace_id, date_trunc('day', occurred_at), kind, category_id;
+
+CREATE INDEX IF NOT EXISTS idx_mv_daily_aggregates_workspace_day ON mv_daily_aggregates (workspace_id, day);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS idx_mv_daily_aggregates_workspace_day;
+DROP MATERIALIZED VIEW IF EXISTS mv_daily_aggregates;
+
+CREATE MATERIALIZED VIEW IF NOT EXISTS mv_daily_aggregates AS
+SELECT date_trunc('day', occurred_at) AS day,
+       kind,
+       category_id,
+       count(*)                       AS cnt,
+       sum(amount)                    AS total_amount,
+       avg(amount)                    AS avg_amount
+FROM items
+GROUP BY date_trunc('day', occurred_at), kind, category_id;
+
+CREATE INDEX IF NOT EXISTS idx_mv_daily_aggregates_day ON mv_daily_aggregates (day);
+
+DROP POLICY IF EXISTS workspace_isolation ON reconciliation_lines;
+ALTER TABLE reconciliation_lines NO FORCE ROW LEVEL SECURITY;
+ALTER TABLE reconciliation_lines DISABLE ROW LEVEL SECURITY;
+DROP POLICY IF EXISTS workspace_isolation ON recurring_item_occurrences;
+ALTER TABLE recurring_item_occurrences NO FORCE ROW LEVEL SECURITY;
+ALTER TABLE recurring_item_occurrences DISABLE ROW LEVEL SECURITY;
+DROP POLICY IF EXISTS workspace_isolation ON item_tags;
+ALTER TABLE item_tags NO FORCE ROW LEVEL SECURITY;
+ALTER TABLE item_tags DISABLE ROW LEVEL SECURITY;
+DROP POLICY IF EXISTS workspace_isolation ON item_splits;
+ALTER TABLE item_splits NO FORCE ROW LEVEL SECURITY;
+ALTER TABLE item_splits DISABLE ROW LEVEL SECURITY;
+DROP POLICY IF EXISTS workspace_isolation ON reconciliation_sessions;
+ALTER TABLE reconciliation_sessions NO FORCE ROW LEVEL SECURITY;
+ALTER TABLE reconciliation_sessions DISABLE ROW LEVEL SECURITY;
+DROP POLICY IF EXISTS workspace_isolation ON tags;
+ALTER TABLE tags NO FORCE ROW LEVEL SECURITY;
+ALTER TABLE tags DISABLE ROW LEVEL SECURITY;
+DROP POLICY IF EXISTS workspace_isolation ON rules;
+ALTER TABLE rules NO FORCE ROW LEVEL SECURITY;
+ALTER TABLE rules DISABLE ROW LEVEL SECURITY;
+DROP POLICY IF EXISTS workspace_isolation ON recurring_items;
+ALTER TABLE recurring_items NO FORCE ROW LEVEL SECURITY;
+ALTER TABLE recurring_items DISABLE ROW LEVEL SECURITY;
+DROP POLICY IF EXISTS workspace_isolation ON accounts;
+ALTER TABLE accounts NO FORCE ROW LEVEL SECURITY;
+ALTER TABLE accounts DISABLE ROW LEVEL SECURITY;
+DROP POLICY IF EXISTS workspace_isolation ON items;
+ALTER TABLE items NO FORCE ROW LEVEL SECURITY;
+ALTER TABLE items DISABLE ROW LEVEL SECURITY;
+DROP POLICY IF EXISTS workspace_isolation ON categories;
+ALTER TABLE categories NO FORCE ROW LEVEL SECURITY;
+ALTER TABLE categories DISABLE ROW LEVEL SECURITY;
+DROP FUNCTION IF EXISTS workspace_visible(UUID);
+
+DROP TRIGGER IF EXISTS trg_reconciliation_lines_workspace ON reconciliation_lines;
+DROP TRIGGER IF EXISTS trg_recurring_item_occurrences_workspace ON recurring_item_occurrences;
+DROP TRIGGER IF EXISTS trg_item_tags_workspace ON item_tags;
+DROP TRIGGER IF EXISTS trg_item_splits_workspace ON item_splits;
+DROP TRIGGER IF EXISTS trg_reconciliation_sessions_workspace ON reconciliation_sessions;
+DROP TRIGGER IF EXISTS trg_recurring_items_workspace ON recurring_items;
+DROP TRIGGER IF EXISTS trg_items_workspace ON items;
+DROP TRIGGER IF EXISTS trg_categories_workspace ON categories;
+DROP FUNCTION IF EXISTS trg_check_child_workspace();
+DROP FUNCTION IF EXISTS trg_check_workspace();
+
+DROP INDEX IF EXISTS idx_tags_workspace_name;
+CREATE UNIQUE INDEX IF NOT EXISTS idx_tags_name ON tags (lower(name));
+
+DROP INDEX IF EXISTS idx_reconciliation_sessions_workspace;
+DROP INDEX IF EXISTS idx_rules_workspace_position;
+DROP INDEX IF EXISTS idx_recurring_items_workspace;
+DROP INDEX IF EXISTS idx_accounts_workspace;
+DROP INDEX IF EXISTS idx_items_workspace_occurred_at;
+DROP INDEX IF EXISTS idx_categories_workspace;
+
+ALTER TABLE api_keys DROP COLUMN IF EXISTS workspace_id;
+ALTER TABLE reconciliation_sessions DROP COLUMN IF EXISTS workspace_id;
+ALTER TABLE tags DROP COLUMN IF EXISTS workspace_id;
+ALTER TABLE rules DROP COLUMN IF EXISTS workspace_id;
+ALTER TABLE recurring_items DROP COLUMN IF EXISTS workspace_id;
+ALTER TABLE accounts DROP COLUMN IF EXISTS workspace_id;
+ALTER TABLE items DROP COLUMN IF EXISTS workspace_id;
+ALTER TABLE categories DROP COLUMN IF EXISTS workspace_id;
+
+DROP TRIGGER IF EXISTS trg_workspaces_updated_at ON workspaces;
+DROP INDEX IF EXISTS idx_workspaces_slug;
+DROP TABLE IF EXISTS workspaces;
+-- +goose StatementEnd
@@ -0,0 +1,76 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Lifecycle events are written to the outbox in the same transaction as the change
+-- they describe and fanned out to webhook deliveries by the dispatcher.
+CREATE TABLE IF NOT EXISTS outbox_events
+(
+    id            UUID PRIMARY KEY     DEFAULT gen_random_uuid(),
+    workspace_id  UUID        NOT NULL REFERENCES workspaces (id) ON DELETE CASCADE,
+    event_type    TEXT        NOT NULL,
+    payload       JSONB       NOT NULL,
+    created_at    TIMESTAMPTZ NOT NULL DEFAULT now(),
+    dispatched_at TIMESTAMPTZ
+);
+
+CREATE INDEX IF NOT EXISTS idx_outbox_events_pending ON outbox_events (created_at) WHERE dispatched_at IS NULL;
+
+-- Webhook subscriptions. An empty event_types list subscribes to all events.
+CREATE TABLE IF NOT EXISTS webhooks
+(
+    id           UUID PRIMARY KEY     DEFAULT gen_random_uuid(),
+    workspace_id UUID        NOT NULL REFERENCES workspaces (id) ON DELETE CASCADE,
+    url          TEXT        NOT NULL,
+    event_types  TEXT[]      NOT NULL DEFAULT '{}',
+    secret       TEXT        NOT NULL,
+    enabled      BOOLEAN     NOT NULL DEFAULT TRUE,
+    created_at   TIMESTAMPTZ NOT NULL DEFAULT now(),
+    updated_at   TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE INDEX IF NOT EXISTS idx_webhooks_workspace ON webhooks (workspace_id);
+
+CREATE TRIGGER trg_webhooks_updated_at
+    BEFORE UPDATE
+    ON webhooks
+    FOR EACH ROW
+EXECUTE FUNCTION trg_set_updated_at();
+
+-- One delivery per webhook and event; redeliveries add a new row so the log
+-- keeps every attempt sequence.
+CREATE TABLE IF NOT EXISTS webhook_deliveries
+(
+    id               UUID PRIMARY KEY     DEFAULT gen_random_uuid(),
+    webhook_id       UUID        NOT NULL REFERENCES webhooks (id) ON DELETE CASCADE,
+    event_id         UUID        NOT NULL REFERENCES outbox_events (id) ON DELETE CASCADE,
+    status           TEXT        NOT NULL DEFAULT 'pending' CHECK (status IN ('pending', 'succeeded', 'failed')),
+    attempts         INT         NOT NULL DEFAULT 0,
+    next_attempt_at  TIMESTAMPTZ NOT NULL DEFAULT now(),
+    last_status_code INT,
+    last_error       TEXT,
+    delivered_at     TIMESTAMPTZ,
+    created_at       TIMESTAMPTZ NOT NULL DEFAULT now(),
+    updated_at       TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE INDEX IF NOT EXISTS idx_webhook_deliveries_due ON webhook_deliveries (next_attempt_at) WHERE status = 'pending';
+CREATE INDEX IF NOT EXISTS idx_webhook_deliveries_webhook ON webhook_deliveries (webhook_id, created_at DESC);
+
+CREATE TRIGGER trg_webhook_deliveries_updated_at
+    BEFORE UPDATE
+    ON webhook_deliveries
+    FOR EACH ROW
+EXECUTE FUNCTION trg_set_updated_at();
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TRIGGER IF EXISTS trg_webhook_deliveries_updated_at ON webhook_deliveries;
+DROP INDEX IF EXISTS idx_webhook_deliveries_webhook;
+DROP INDEX IF EXISTS idx_webhook_deliveries_due;
+DROP TABLE IF EXISTS webhook_deliveries;
+DROP TRIGGER IF EXISTS trg_webhooks_updated_at ON webhooks;
+DROP INDEX IF EXISTS idx_webhooks_workspace;
+DROP TABLE IF EXISTS webhooks;
+DROP INDEX IF EXISTS idx_outbox_events_pending;
+DROP TABLE IF EXISTS outbox_events;
+-- +goose StatementEnd
@@ -0,0 +1,68 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Every change to items is logged with an increasing id, which streams use as
+-- the SSE event id to replay missed changes, and announced on the item_changes
+-- channel when its transaction commits. The log is pruned by the application.
+-- Like the outbox it has no row-level security, so pruning sees all workspaces.
+CREATE TABLE IF NOT EXISTS item_changes
+(
+    id           BIGSERIAL PRIMARY KEY,
+    workspace_id UUID        NOT NULL REFERENCES workspaces (id) ON DELETE CASCADE,
+    item_id      UUID        NOT NULL,
+    event_type   TEXT        NOT NULL,
+    item         JSONB       NOT NULL,
+    changed_at   TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE INDEX IF NOT EXISTS idx_item_changes_workspace ON item_changes (workspace_id, id);
+CREATE INDEX IF NOT EXISTS idx_item_changes_changed_at ON item_changes (changed_at);
+
+-- The item is logged after the change, or before it for deletions. Amounts are
+-- logged as strings like the API returns them.
+CREATE OR REPLACE FUNCTION trg_log_item_change()
+    RETURNS TRIGGER
+    LANGUAGE plpgsql AS
+$$
+DECLARE
+    r          items;
+    event_type TEXT;
+    change_id  BIGINT;
+BEGIN
+    IF TG_OP = 'DELETE' THEN
+        r := OLD;
+        event_type := 'item.deleted';
+    ELSIF TG_OP = 'UPDATE' THEN
+        r := NEW;
+        event_type := 'item.updated';
+    ELSE
+        r := NEW;
+        event_type := 'item.created';
+    END IF;
+
+    INSERT INTO item_changes (workspace_id, item_id, event_type, item)
+    VALUES (r.workspace_id, r.id, event_type,
+            (to_jsonb(r) - 'workspace_id') || jsonb_build_object('amount', r.amount::text))
+    RETURNING id INTO change_id;
+
+    PERFORM pg_notify('item_changes',
+                      json_build_object('id', change_id, 'workspace_id', r.workspace_id)::text);
+
+    RETURN NULL;
+END;
+$$;
+
+CREATE TRIGGER trg_items_log_change
+    AFTER INSERT OR UPDATE OR DELETE
+    ON items
+    FOR EACH ROW
+EXECUTE FUNCTION trg_log_item_change();
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TRIGGER IF EXISTS trg_items_log_change ON items;
+DROP FUNCTION IF EXISTS trg_log_item_change();
+DROP INDEX IF EXISTS idx_item_changes_changed_at;
+DROP INDEX IF EXISTS idx_item_changes_workspace;
+DROP TABLE IF EXISTS item_changes;
+-- +goose StatementEnd
@@ -0,0 +1,117 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Changes that affect analytics results are announced on the analytics_changes
+-- channel when their transaction commits, so that cached results can be
+-- invalidated. The payload holds the workspace and the range of occurred_at
+-- dates affected; a null bound means the change may affect any date.
+CREATE OR REPLACE FUNCTION notify_analytics_change(workspace UUID, range_from TIMESTAMPTZ, range_to TIMESTAMPTZ)
+    RETURNS VOID
+    LANGUAGE sql AS
+$$
+SELECT pg_notify('analytics_changes',
+                 json_build_object('workspace_id', workspace, 'from', range_from, 'to', range_to)::text);
+$$;
+
+-- Items affect the dates they occurred at before and after the change. Refunds
+-- are attributed to the category of the refunded item, so a changed category
+-- may affect any date.
+CREATE OR REPLACE FUNCTION trg_items_analytics_change()
+    RETURNS TRIGGER
+    LANGUAGE plpgsql AS
+$$
+BEGIN
+    IF TG_OP = 'INSERT' THEN
+        PERFORM notify_analytics_change(NEW.workspace_id, NEW.occurred_at, NEW.occurred_at);
+    ELSIF TG_OP = 'DELETE' THEN
+        PERFORM notify_analytics_change(OLD.workspace_id, OLD.occurred_at, OLD.occurred_at);
+    ELSIF NEW.category_id IS DISTINCT FROM OLD.category_id THEN
+        PERFORM notify_analytics_change(NEW.workspace_id, NULL, NULL);
+    ELSE
+        PERFORM notify_analytics_change(NEW.workspace_id,
+                                        LEAST(OLD.occurred_at, NEW.occurred_at),
+                                        GREATEST(OLD.occurred_at, NEW.occurred_at));
+    END IF;
+
+    RETURN NULL;
+END;
+$$;
+
+-- Splits and tags affect the date of their item. When they are removed along
+-- with the item, the item trigger already announced the change.
+CREATE OR REPLACE FUNCTION trg_item_children_analytics_change()
+    RETURNS TRIGGER
+    LANGUAGE plpgsql AS
+$$
+DECLARE
+    r items;
+BEGIN
+    IF TG_OP = 'DELETE' THEN
+        SELECT * INTO r FROM items WHERE id = OLD.item_id;
+    ELSE
+        SELECT * INTO r FROM items WHERE id = NEW.item_id;
+    END IF;
+
+    IF FOUND THEN
+        PERFORM notify_analytics_change(r.workspace_id, r.occurred_at, r.occurred_at);
+    END IF;
+
+    RETURN NULL;
+END;
+$$;
+
+-- Categories and tags are reported by name, so renaming or removing them may
+-- affect any date.
+CREATE OR REPLACE FUNCTION trg_labels_analytics_change()
+    RETURNS TRIGGER
+    LANGUAGE plpgsql AS
+$$
+BEGIN
+    PERFORM notify_analytics_change(OLD.workspace_id, NULL, NULL);
+
+    RETURN NULL;
+END;
+$$;
+
+CREATE TRIGGER trg_items_analytics_change
+    AFTER INSERT OR UPDATE OR DELETE
+    ON items
+    FOR EACH ROW
+EXECUTE FUNCTION trg_items_analytics_change();
+
+CREATE TRIGGER trg_item_splits_analytics_change
+    AFTER INSERT OR UPDATE OR DELETE
+    ON item_splits
+    FOR EACH ROW
+EXECUTE FUNCTION trg_item_children_analytics_change();
+
+CREATE TRIGGER trg_item_tags_analytics_change
+    AFTER INSERT OR DELETE
+    ON item_tags
+    FOR EACH ROW
+EXECUTE FUNCTION trg_item_children_analytics_change();
+
+CREATE TRIGGER trg_categories_analytics_change
+    AFTER UPDATE OR DELETE
+    ON categories
+    FOR EACH ROW
+EXECUTE FUNCTION trg_labels_analytics_change();
+
+CREATE TRIGGER trg_tags_analytics_change
+    AFTER UPDATE OR DELETE
+    ON tags
+    FOR EACH ROW
+EXECUTE FUNCTION trg_labels_analytics_change();
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TRIGGER IF EXISTS trg_tags_analytics_change ON tags;
+DROP TRIGGER IF EXISTS trg_categories_analytics_change ON categories;
+DROP TRIGGER IF EXISTS trg_item_tags_analytics_change ON item_tags;
+DROP TRIGGER IF EXISTS trg_item_splits_analytics_change ON item_splits;
+DROP TRIGGER IF EXISTS trg_items_analytics_change ON items;
+DROP FUNCTION IF EXISTS trg_labels_analytics_change();
+DROP FUNCTION IF EXISTS trg_item_children_analytics_change();
+DROP FUNCTION IF EXISTS trg_items_analytics_change();
+DROP FUNCTION IF EXISTS notify_analytics_change(UUID, TIMESTAMPTZ, TIMESTAMPTZ);
+-- +goose StatementEnd
@@ -0,0 +1,42 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Reports computed in the background. A worker claims a queued job, or a running
+-- one whose worker stopped renewing its lease, and stores the result in the row.
+-- Like the outbox it has no row-level security, so workers see all workspaces.
+CREATE TABLE IF NOT EXISTS analytics_jobs
+(
+    id           UUID PRIMARY KEY          DEFAULT gen_random_uuid(),
+    workspace_id UUID             NOT NULL REFERENCES workspaces (id) ON DELETE CASCADE,
+    spec         JSONB            NOT NULL,
+    status       TEXT             NOT NULL DEFAULT 'queued'
+        CHECK (status IN ('queued', 'running', 'succeeded', 'failed', 'canceled')),
+    progress     DOUBLE PRECISION NOT NULL DEFAULT 0,
+    result       JSONB,
+    error        TEXT,
+    attempts     INT              NOT NULL DEFAULT 0,
+    locked_until TIMESTAMPTZ,
+    created_at   TIMESTAMPTZ      NOT NULL DEFAULT now(),
+    started_at   TIMESTAMPTZ,
+    finished_at  TIMESTAMPTZ,
+    updated_at   TIMESTAMPTZ      NOT NULL DEFAULT now()
+);
+
+CREATE INDEX IF NOT EXISTS idx_analytics_jobs_pending ON analytics_jobs (created_at) WHERE status IN ('queued', 'running');
+CREATE INDEX IF NOT EXISTS idx_analytics_jobs_workspace ON analytics_jobs (workspace_id, created_at DESC);
+CREATE INDEX IF NOT EXISTS idx_analytics_jobs_finished_at ON analytics_jobs (finished_at) WHERE finished_at IS NOT NULL;
+
+CREATE TRIGGER trg_analytics_jobs_updated_at
+    BEFORE UPDATE
+    ON analytics_jobs
+    FOR EACH ROW
+EXECUTE FUNCTION trg_set_updated_at();
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TRIGGER IF EXISTS trg_analytics_jobs_updated_at ON analytics_jobs;
+DROP INDEX IF EXISTS idx_analytics_jobs_finished_at;
+DROP INDEX IF EXISTS idx_analytics_jobs_workspace;
+DROP INDEX IF EXISTS idx_analytics_jobs_pending;
+DROP TABLE IF EXISTS analytics_jobs;
+-- +goose StatementEnd
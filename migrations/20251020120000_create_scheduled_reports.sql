@@ -0,0 +1,63 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Saved reports emailed on a schedule. The scheduler claims a due report by
+-- locking it until locked_until, so that only one server process sends it.
+-- Like the outbox it has no row-level security, so the scheduler sees all workspaces.
+CREATE TABLE IF NOT EXISTS scheduled_reports
+(
+    id           UUID PRIMARY KEY     DEFAULT gen_random_uuid(),
+    workspace_id UUID        NOT NULL REFERENCES workspaces (id) ON DELETE CASCADE,
+    name         TEXT        NOT NULL,
+    spec         JSONB       NOT NULL,                        -- metrics, filters and grouping, without a date range
+    range        TEXT        NOT NULL
+        CHECK (range IN ('day', 'week', 'month', 'quarter', 'year')), -- period covered by each run
+    rule         TEXT        NOT NULL,                        -- RRULE subset, e.g. "FREQ=WEEKLY"
+    start_at     TIMESTAMPTZ NOT NULL,                        -- first occurrence
+    recipients   TEXT[]      NOT NULL,
+    enabled      BOOLEAN     NOT NULL DEFAULT TRUE,
+    next_index   INTEGER     NOT NULL DEFAULT 0,              -- index of the next occurrence to run
+    next_run_at  TIMESTAMPTZ,                                 -- NULL when the schedule is exhausted
+    attempts     INT         NOT NULL DEFAULT 0,              -- failed attempts at the next occurrence
+    retry_at     TIMESTAMPTZ,                                 -- when a failed occurrence is retried
+    locked_until TIMESTAMPTZ,
+    created_at   TIMESTAMPTZ NOT NULL DEFAULT now(),
+    updated_at   TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE INDEX IF NOT EXISTS idx_scheduled_reports_due ON scheduled_reports (COALESCE(retry_at, next_run_at)) WHERE enabled;
+CREATE INDEX IF NOT EXISTS idx_scheduled_reports_workspace ON scheduled_reports (workspace_id);
+
+CREATE TRIGGER trg_scheduled_reports_updated_at
+    BEFORE UPDATE
+    ON scheduled_reports
+    FOR EACH ROW
+EXECUTE FUNCTION trg_set_updated_at();
+
+-- Every attempt at an occurrence adds a run, so the history keeps retries.
+CREATE TABLE IF NOT EXISTS report_runs
+(
+    id            UUID PRIMARY KEY     DEFAULT gen_random_uuid(),
+    report_id     UUID        NOT NULL REFERENCES scheduled_reports (id) ON DELETE CASCADE,
+    occurrence_at TIMESTAMPTZ NOT NULL,
+    period_from   TIMESTAMPTZ NOT NULL,
+    period_to     TIMESTAMPTZ NOT NULL,
+    status        TEXT        NOT NULL DEFAULT 'running' CHECK (status IN ('running', 'succeeded', 'failed')),
+    attempt       INT         NOT NULL,
+    recipients    TEXT[]      NOT NULL,
+    error         TEXT,
+    started_at    TIMESTAMPTZ NOT NULL DEFAULT now(),
+    finished_at   TIMESTAMPTZ
+);
+
+CREATE INDEX IF NOT EXISTS idx_report_runs_report ON report_runs (report_id, started_at DESC);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS idx_report_runs_report;
+DROP TABLE IF EXISTS report_runs;
+DROP TRIGGER IF EXISTS trg_scheduled_reports_updated_at ON scheduled_reports;
+DROP INDEX IF EXISTS idx_scheduled_reports_workspace;
+DROP INDEX IF EXISTS idx_scheduled_reports_due;
+DROP TABLE IF EXISTS scheduled_reports;
+-- +goose StatementEnd
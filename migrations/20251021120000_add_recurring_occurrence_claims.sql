@@ -0,0 +1,19 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Pending occurrences are claimed for a lease: a claim left by a crashed
+-- worker is taken over once claimed_at is older than the lease.
+ALTER TABLE recurring_item_occurrences
+    ADD COLUMN IF NOT EXISTS claimed_at TIMESTAMPTZ NOT NULL DEFAULT now();
+
+-- Items materialized from a recurring item are found by their reference when
+-- a claim is taken over, so the item is not created twice.
+CREATE INDEX IF NOT EXISTS idx_items_recurring_item ON items ((metadata ->> 'recurring_item_id'), occurred_at)
+    WHERE metadata ? 'recurring_item_id';
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS idx_items_recurring_item;
+ALTER TABLE recurring_item_occurrences
+    DROP COLUMN IF EXISTS claimed_at;
+-- +goose StatementEnd
@@ -0,0 +1,14 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Every claim of a job gets a new token, which the worker's later updates must
+-- match: a worker whose lease expired and whose job was claimed again can no
+-- longer renew, finish or release it.
+ALTER TABLE analytics_jobs
+    ADD COLUMN IF NOT EXISTS claim_token UUID;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE analytics_jobs
+    DROP COLUMN IF EXISTS claim_token;
+-- +goose StatementEnd
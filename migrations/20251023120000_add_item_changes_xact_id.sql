@@ -0,0 +1,21 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Change IDs are taken when a change is made, not when it commits, so a change
+-- may become visible after changes with greater IDs. Streams therefore read
+-- changes in the order of the transactions that made them and only once no
+-- older transaction is running; changes logged before this migration count as
+-- made by it.
+ALTER TABLE item_changes
+    ADD COLUMN IF NOT EXISTS xact_id XID8 NOT NULL DEFAULT pg_current_xact_id();
+
+DROP INDEX IF EXISTS idx_item_changes_workspace;
+CREATE INDEX IF NOT EXISTS idx_item_changes_workspace_xact ON item_changes (workspace_id, xact_id, id);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS idx_item_changes_workspace_xact;
+CREATE INDEX IF NOT EXISTS idx_item_changes_workspace ON item_changes (workspace_id, id);
+ALTER TABLE item_changes
+    DROP COLUMN IF EXISTS xact_id;
+-- +goose StatementEnd
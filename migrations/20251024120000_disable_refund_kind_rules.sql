@@ -0,0 +1,17 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Rules can no longer turn items into refunds, since a refund needs the item it
+-- refunds. Rules that did are disabled rather than changed; they are rejected
+-- until the action is removed.
+UPDATE rules
+SET enabled = FALSE
+WHERE enabled
+  AND actions @> '[{"type": "set_kind", "kind": "refund"}]'::jsonb;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+-- Disabled rules are left disabled, since rules disabled by users cannot be told
+-- apart.
+SELECT 1;
+-- +goose StatementEnd